@@ -0,0 +1,61 @@
+//go:build linux
+
+package srdb
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// madvise 向内核提示映射区域的访问模式
+//
+// accessDontNeed 只解除本进程对这些页的映射，页缓存本身由 fadvise 释放
+func madvise(data []byte, pattern accessPattern) {
+	if len(data) == 0 {
+		return
+	}
+
+	var advice int
+	switch pattern {
+	case accessSequential:
+		advice = syscall.MADV_SEQUENTIAL
+	case accessDontNeed:
+		advice = syscall.MADV_DONTNEED
+	default:
+		advice = syscall.MADV_NORMAL
+	}
+
+	// 提示失败不影响正确性，忽略错误
+	_ = syscall.Madvise(data, advice)
+}
+
+// fadvise 向内核提示整个文件的访问模式
+//
+// accessSequential 加大内核预读，accessDontNeed 从页缓存中丢弃文件的页
+// （仍被映射的页不会被丢弃，mmap 模式下先调用 madvise 解除映射）
+func fadvise(file *os.File, pattern accessPattern) {
+	if file == nil {
+		return
+	}
+
+	var advice int
+	switch pattern {
+	case accessSequential:
+		advice = unix.FADV_SEQUENTIAL
+	case accessDontNeed:
+		advice = unix.FADV_DONTNEED
+	default:
+		advice = unix.FADV_NORMAL
+	}
+
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return
+	}
+	// 提示失败不影响正确性，忽略错误
+	_ = conn.Control(func(fd uintptr) {
+		_ = unix.Fadvise(int(fd), 0, 0, advice)
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package srdb
+
+import "os"
+
+// madvise 非 Linux 平台不发送访问模式提示
+func madvise(data []byte, pattern accessPattern) {}
+
+// fadvise 非 Linux 平台不发送访问模式提示
+func fadvise(file *os.File, pattern accessPattern) {}
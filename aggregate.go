@@ -0,0 +1,589 @@
+package srdb
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"reflect"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+// SumMode Sum 与 Avg 的累加方式，通过 Aggregate.BigInt、Aggregate.Decimal 为每个聚合单独选择
+type SumMode int
+
+const (
+	// SumChecked 按列类型累加（默认）：整数列使用 int64/uint64，超出范围时返回 ErrCodeAggregateOverflow；
+	// 浮点列使用 float64，结果为 ±Inf 时同样返回 ErrCodeAggregateOverflow；Decimal 列精确累加
+	SumChecked SumMode = iota
+	// SumBigInt 使用 big.Int 累加，不会溢出，只支持整数列
+	SumBigInt
+	// SumDecimal 使用 decimal.Decimal 累加，不会溢出，浮点值按 decimal.NewFromFloat 转换
+	SumDecimal
+)
+
+// String 返回累加方式名称
+func (m SumMode) String() string {
+	switch m {
+	case SumChecked:
+		return "checked"
+	case SumBigInt:
+		return "bigint"
+	case SumDecimal:
+		return "decimal"
+	}
+	return fmt.Sprintf("SumMode(%d)", int(m))
+}
+
+// Aggregate 聚合函数，使用 Count、Sum、Avg、Min、Max 创建
+type Aggregate struct {
+	Func  string  // COUNT、SUM、AVG、MIN、MAX
+	Field string  // 聚合的字段，COUNT 为空
+	Mode  SumMode // SUM 与 AVG 的累加方式
+}
+
+// Count 统计匹配的行数
+func Count() Aggregate {
+	return Aggregate{Func: "COUNT"}
+}
+
+// Sum 字段求和
+func Sum(field string) Aggregate {
+	return Aggregate{Func: "SUM", Field: field}
+}
+
+// Avg 字段平均值
+func Avg(field string) Aggregate {
+	return Aggregate{Func: "AVG", Field: field}
+}
+
+// Min 字段最小值
+func Min(field string) Aggregate {
+	return Aggregate{Func: "MIN", Field: field}
+}
+
+// Max 字段最大值
+func Max(field string) Aggregate {
+	return Aggregate{Func: "MAX", Field: field}
+}
+
+// BigInt 使用 big.Int 累加（只对 SUM、AVG 有效）
+func (a Aggregate) BigInt() Aggregate {
+	a.Mode = SumBigInt
+	return a
+}
+
+// Decimal 使用 decimal.Decimal 累加（只对 SUM、AVG 有效）
+func (a Aggregate) Decimal() Aggregate {
+	a.Mode = SumDecimal
+	return a
+}
+
+// String 返回聚合的描述，如 SUM(amount)、SUM(amount) bigint
+func (a Aggregate) String() string {
+	s := fmt.Sprintf("%s(%s)", a.Func, a.Field)
+	if a.Mode != SumChecked {
+		s += " " + a.Mode.String()
+	}
+	return s
+}
+
+// aggKind 聚合字段的数值类别
+type aggKind int
+
+const (
+	aggInt aggKind = iota
+	aggUint
+	aggFloat
+	aggDecimal
+)
+
+// aggKindOf 返回字段类型对应的数值类别，不支持聚合的类型返回 false
+func aggKindOf(t FieldType) (aggKind, bool) {
+	switch t {
+	case Int, Int8, Int16, Int32, Int64, Rune:
+		return aggInt, true
+	case Uint, Uint8, Uint16, Uint32, Uint64, Byte:
+		return aggUint, true
+	case Float32, Float64:
+		return aggFloat, true
+	case Decimal:
+		return aggDecimal, true
+	}
+	return 0, false
+}
+
+// aggState 一个聚合的累加状态
+//
+// 整数列在 BigInt、Decimal 模式下先用 int64/uint64 累加，即将溢出时才并入 big.Int，
+// 避免逐行分配。
+type aggState struct {
+	agg   Aggregate
+	kind  aggKind
+	count int64 // 非 NULL 值的数量
+	err   error
+
+	i64 int64
+	u64 uint64
+	f64 float64
+	dec decimal.Decimal
+	big *big.Int // 整数列溢出部分
+}
+
+// newAggState 校验聚合并创建累加状态
+func newAggState(agg Aggregate, schema *Schema) (*aggState, error) {
+	switch agg.Func {
+	case "COUNT":
+		return &aggState{agg: agg}, nil
+	case "SUM", "AVG", "MIN", "MAX":
+	default:
+		return nil, NewErrorf(ErrCodeInvalidParam, "unsupported aggregate function %q", agg.Func)
+	}
+
+	field, err := schema.GetField(agg.Field)
+	if err != nil {
+		return nil, NewErrorf(ErrCodeFieldNotFound, "field %s not found", agg.Field)
+	}
+	kind, ok := aggKindOf(field.Type)
+	if !ok {
+		return nil, NewErrorf(ErrCodeFieldTypeMismatch, "%s: field %s of type %s is not numeric", agg, agg.Field, field.Type)
+	}
+	if agg.Mode == SumBigInt && (kind == aggFloat || kind == aggDecimal) {
+		return nil, NewErrorf(ErrCodeInvalidParam, "%s: big.Int accumulation requires an integer field, got %s", agg, field.Type)
+	}
+	if agg.Mode < SumChecked || agg.Mode > SumDecimal {
+		return nil, NewErrorf(ErrCodeInvalidParam, "%s: unsupported sum mode", agg)
+	}
+	return &aggState{agg: agg, kind: kind}, nil
+}
+
+// overflow 记录溢出错误（只记录第一次）
+func (s *aggState) overflow() {
+	if s.err != nil {
+		return
+	}
+	switch s.kind {
+	case aggInt:
+		s.err = NewErrorf(ErrCodeAggregateOverflow, "%s overflows int64, use BigInt() or Decimal() accumulation", s.agg)
+	case aggUint:
+		s.err = NewErrorf(ErrCodeAggregateOverflow, "%s overflows uint64, use BigInt() or Decimal() accumulation", s.agg)
+	default:
+		s.err = NewErrorf(ErrCodeAggregateOverflow, "%s overflows float64, use Decimal() accumulation", s.agg)
+	}
+}
+
+// spill 将整数部分和并入 big.Int
+func (s *aggState) spill() {
+	if s.big == nil {
+		s.big = new(big.Int)
+	}
+	switch s.kind {
+	case aggInt:
+		s.big.Add(s.big, big.NewInt(s.i64))
+		s.i64 = 0
+	case aggUint:
+		s.big.Add(s.big, new(big.Int).SetUint64(s.u64))
+		s.u64 = 0
+	}
+}
+
+func (s *aggState) addInt(v int64) {
+	s.count++
+	switch s.agg.Func {
+	case "MIN":
+		if s.count == 1 || v < s.i64 {
+			s.i64 = v
+		}
+		return
+	case "MAX":
+		if s.count == 1 || v > s.i64 {
+			s.i64 = v
+		}
+		return
+	}
+
+	sum := s.i64 + v
+	if (v > 0 && sum < s.i64) || (v < 0 && sum > s.i64) {
+		if s.agg.Mode == SumChecked {
+			s.overflow()
+			return
+		}
+		s.spill()
+		sum = v
+	}
+	s.i64 = sum
+}
+
+func (s *aggState) addUint(v uint64) {
+	s.count++
+	switch s.agg.Func {
+	case "MIN":
+		if s.count == 1 || v < s.u64 {
+			s.u64 = v
+		}
+		return
+	case "MAX":
+		if s.count == 1 || v > s.u64 {
+			s.u64 = v
+		}
+		return
+	}
+
+	sum, carry := bits.Add64(s.u64, v, 0)
+	if carry != 0 {
+		if s.agg.Mode == SumChecked {
+			s.overflow()
+			return
+		}
+		s.spill()
+		sum = v
+	}
+	s.u64 = sum
+}
+
+func (s *aggState) addFloat(v float64) {
+	s.count++
+	switch {
+	case s.agg.Func == "MIN":
+		if s.count == 1 || v < s.f64 {
+			s.f64 = v
+		}
+	case s.agg.Func == "MAX":
+		if s.count == 1 || v > s.f64 {
+			s.f64 = v
+		}
+	case s.agg.Mode == SumDecimal:
+		s.dec = s.dec.Add(decimal.NewFromFloat(v))
+	default:
+		s.f64 += v
+	}
+}
+
+func (s *aggState) addDecimal(v decimal.Decimal) {
+	s.count++
+	switch s.agg.Func {
+	case "MIN":
+		if s.count == 1 || v.LessThan(s.dec) {
+			s.dec = v
+		}
+	case "MAX":
+		if s.count == 1 || v.GreaterThan(s.dec) {
+			s.dec = v
+		}
+	default:
+		s.dec = s.dec.Add(v)
+	}
+}
+
+// addValue 累加 Rows 中的一个值（nil 表示 NULL，跳过）
+func (s *aggState) addValue(v any) {
+	if v == nil {
+		return
+	}
+	if d, ok := v.(decimal.Decimal); ok {
+		s.addDecimal(d)
+		return
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s.addInt(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s.addUint(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		s.addFloat(rv.Float())
+	}
+}
+
+// addColumn 累加一列向量（跳过 NULL）
+func (s *aggState) addColumn(col *ColumnVector) {
+	switch s.kind {
+	case aggInt:
+		for i, v := range col.Int64s {
+			if !col.IsNull(i) {
+				s.addInt(v)
+			}
+		}
+	case aggUint:
+		for i, v := range col.Uint64s {
+			if !col.IsNull(i) {
+				s.addUint(v)
+			}
+		}
+	case aggFloat:
+		for i, v := range col.Float64s {
+			if !col.IsNull(i) {
+				s.addFloat(v)
+			}
+		}
+	}
+}
+
+// addColumnAt 累加列向量中第 i 行的值（跳过 NULL）
+func (s *aggState) addColumnAt(col *ColumnVector, i int) {
+	if col.IsNull(i) {
+		return
+	}
+	switch s.kind {
+	case aggInt:
+		s.addInt(col.Int64s[i])
+	case aggUint:
+		s.addUint(col.Uint64s[i])
+	case aggFloat:
+		s.addFloat(col.Float64s[i])
+	}
+}
+
+// sum 返回 SUM 的结果（调用前已确认 count > 0）
+func (s *aggState) sum() any {
+	if s.kind == aggDecimal || (s.kind == aggFloat && s.agg.Mode == SumDecimal) {
+		return s.dec
+	}
+	if s.kind == aggFloat {
+		if math.IsInf(s.f64, 0) {
+			s.overflow()
+		}
+		return s.f64
+	}
+	if s.agg.Mode == SumChecked {
+		if s.kind == aggUint {
+			return s.u64
+		}
+		return s.i64
+	}
+
+	total := new(big.Int)
+	if s.big != nil {
+		total.Set(s.big)
+	}
+	if s.kind == aggUint {
+		total.Add(total, new(big.Int).SetUint64(s.u64))
+	} else {
+		total.Add(total, big.NewInt(s.i64))
+	}
+	if s.agg.Mode == SumDecimal {
+		return decimal.NewFromBigInt(total, 0)
+	}
+	return total
+}
+
+// result 返回聚合结果，没有非 NULL 值时 SUM、AVG、MIN、MAX 返回 nil
+func (s *aggState) result() (any, error) {
+	if s.agg.Func == "COUNT" {
+		return s.count, nil
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.count == 0 {
+		return nil, nil
+	}
+
+	switch s.agg.Func {
+	case "MIN", "MAX":
+		switch s.kind {
+		case aggInt:
+			return s.i64, nil
+		case aggUint:
+			return s.u64, nil
+		case aggFloat:
+			return s.f64, nil
+		}
+		return s.dec, nil
+	case "SUM":
+		sum := s.sum()
+		return sum, s.err
+	}
+
+	// AVG
+	sum := s.sum()
+	if s.err != nil {
+		return nil, s.err
+	}
+	switch v := sum.(type) {
+	case int64:
+		return float64(v) / float64(s.count), nil
+	case uint64:
+		return float64(v) / float64(s.count), nil
+	case float64:
+		return v / float64(s.count), nil
+	case *big.Int:
+		return decimal.NewFromBigInt(v, 0).Div(decimal.NewFromInt(s.count)), nil
+	}
+	return sum.(decimal.Decimal).Div(decimal.NewFromInt(s.count)), nil
+}
+
+// Aggregate 对匹配的行计算聚合，结果与 aggs 按顺序对应
+//
+// 结果类型：
+//   - Count: int64
+//   - Sum: 整数列为 int64/uint64，浮点列为 float64，Decimal 列为 decimal.Decimal；
+//     BigInt 模式为 *big.Int，Decimal 模式为 decimal.Decimal
+//   - Avg: float64；Decimal 列、BigInt 与 Decimal 模式为 decimal.Decimal
+//   - Min/Max: 与 Sum 的默认类型相同
+//
+// NULL 值不参与计算，没有非 NULL 值时 Sum、Avg、Min、Max 的结果为 nil。
+// 默认模式下整数和超出 int64/uint64 范围（或浮点和为 ±Inf）时返回 ErrCodeAggregateOverflow，
+// 不会静默回绕；需要更大范围时使用 Sum(field).BigInt() 或 Sum(field).Decimal()。
+//
+// 数值列通过 BatchRows 列式扫描；包含 Decimal 列或 OrderBy 时逐行扫描；
+// 只有 Count 且没有过滤条件、Offset 与 Limit 时直接统计各数据源的 key，不读取行数据。
+// 支持 Where 条件、Offset 与 Limit。
+//
+//	res, err := table.Query().Eq("currency", "CNY").Aggregate(srdb.Count(), srdb.Sum("cents").BigInt())
+//	total := res[1].(*big.Int)
+func (qb *QueryBuilder) Aggregate(aggs ...Aggregate) ([]any, error) {
+	if qb.table == nil {
+		return nil, fmt.Errorf("table is nil")
+	}
+	if len(aggs) == 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "no aggregates specified")
+	}
+
+	states := make([]*aggState, len(aggs))
+	var columns []string
+	vectorized := qb.orderBy == ""
+	for i, agg := range aggs {
+		state, err := newAggState(agg, qb.table.schema.Load())
+		if err != nil {
+			return nil, err
+		}
+		states[i] = state
+		if agg.Func == "COUNT" {
+			continue
+		}
+		if state.kind == aggDecimal {
+			vectorized = false
+		}
+		if !slices.Contains(columns, agg.Field) {
+			columns = append(columns, agg.Field)
+		}
+	}
+
+	var err error
+	switch {
+	case len(columns) == 0 && !qb.filtered() && qb.orderBy == "" && qb.offset == 0 && qb.limit == 0 && !qb.distinct:
+		err = qb.aggregateCount(states)
+	case vectorized:
+		err = qb.aggregateBatches(states, columns)
+	default:
+		err = qb.aggregateRows(states)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]any, len(states))
+	for i, state := range states {
+		if results[i], err = state.result(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Count 返回匹配的行数，等价于 Aggregate(Count())
+func (qb *QueryBuilder) Count() (int64, error) {
+	res, err := qb.Aggregate(Count())
+	if err != nil {
+		return 0, err
+	}
+	return res[0].(int64), nil
+}
+
+// Sum 字段求和，等价于 Aggregate(Sum(field))；结果类型见 Aggregate，没有非 NULL 值时为 nil
+func (qb *QueryBuilder) Sum(field string) (any, error) {
+	return qb.aggregateOne(Sum(field))
+}
+
+// Avg 字段平均值，等价于 Aggregate(Avg(field))
+func (qb *QueryBuilder) Avg(field string) (any, error) {
+	return qb.aggregateOne(Avg(field))
+}
+
+// Min 字段最小值，等价于 Aggregate(Min(field))
+func (qb *QueryBuilder) Min(field string) (any, error) {
+	return qb.aggregateOne(Min(field))
+}
+
+// Max 字段最大值，等价于 Aggregate(Max(field))
+func (qb *QueryBuilder) Max(field string) (any, error) {
+	return qb.aggregateOne(Max(field))
+}
+
+// aggregateOne 计算单个聚合
+func (qb *QueryBuilder) aggregateOne(agg Aggregate) (any, error) {
+	res, err := qb.Aggregate(agg)
+	if err != nil {
+		return nil, err
+	}
+	return res[0], nil
+}
+
+// aggregateCount 统计快照内可见的 key（只有 Count 且没有过滤条件时使用）
+func (qb *QueryBuilder) aggregateCount(states []*aggState) error {
+	rows, err := qb.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.cached {
+		return qb.aggregateBatches(states, nil)
+	}
+
+	count := int64(rows.countVisible())
+	for _, state := range states {
+		state.count = count
+	}
+	return nil
+}
+
+// aggregateBatches 通过列式扫描累加
+func (qb *QueryBuilder) aggregateBatches(states []*aggState, columns []string) error {
+	br, err := qb.BatchRows(columns, DefaultBatchSize)
+	if err != nil {
+		return err
+	}
+	defer br.Close()
+
+	for br.Next() {
+		batch := br.Batch()
+		for _, state := range states {
+			if state.agg.Func == "COUNT" {
+				state.count += int64(batch.Len())
+				continue
+			}
+			state.addColumn(batch.Column(state.agg.Field))
+			if state.err != nil {
+				return state.err
+			}
+		}
+	}
+	return br.Err()
+}
+
+// aggregateRows 逐行累加
+func (qb *QueryBuilder) aggregateRows(states []*aggState) error {
+	rows, err := qb.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := rows.Row().inner.Data
+		for _, state := range states {
+			if state.agg.Func == "COUNT" {
+				state.count++
+				continue
+			}
+			state.addValue(data[state.agg.Field])
+			if state.err != nil {
+				return state.err
+			}
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,105 @@
+package srdb
+
+import "time"
+
+// timeBucket GroupByTimeBucket 的分桶方式
+type timeBucket struct {
+	field  string // "_time" 或 Time 字段
+	width  time.Duration
+	loc    *time.Location
+	offset time.Duration
+}
+
+// GroupByTimeBucket 按时间桶分组（可以再按其他字段分组），通过 GroupQuery.Aggregate 计算每个桶的聚合
+//
+// field 为 "_time"（写入时间）或 Time 字段，每行归入 [Start, Start+width) 的时间桶，
+// 分组键的第一个值是桶的起始时间（time.Time），之后是 fields 的值；字段为 NULL 的行归入 NULL 分组：
+//
+//	groups, err := table.Query().
+//	    TimeRange(time.Now().Add(-time.Hour), time.Time{}).
+//	    GroupByTimeBucket("_time", time.Minute, "region").
+//	    Aggregate(srdb.Count(), srdb.Avg("latency_ms"))
+//	for _, g := range groups {
+//	    fmt.Println(g.Key[0].(time.Time), g.Key[1], g.Values[0], g.Values[1])
+//	}
+//
+// 时间桶默认按 UTC 的 Unix 纪元对齐（与连续聚合相同），BucketLocation 与 BucketOffset 可以调整对齐方式。
+func (qb *QueryBuilder) GroupByTimeBucket(field string, width time.Duration, fields ...string) *GroupQuery {
+	return &GroupQuery{qb: qb, fields: fields, bucket: &timeBucket{field: field, width: width, loc: time.UTC}}
+}
+
+// BucketLocation 按 loc 的本地时间对齐时间桶，如按天分桶时每个桶从当地的 0 点开始，nil 表示 UTC
+//
+// 桶的起始时间使用 loc 表示；夏令时切换当天的桶按本地时间划分，长度可能不等于 width。
+// 没有使用 GroupByTimeBucket 时不起作用。
+func (g *GroupQuery) BucketLocation(loc *time.Location) *GroupQuery {
+	if g.bucket != nil {
+		if loc == nil {
+			loc = time.UTC
+		}
+		g.bucket.loc = loc
+	}
+	return g
+}
+
+// BucketOffset 将时间桶的边界平移 offset，如按天分桶、offset 为 6h 时每个桶从 06:00 开始
+//
+// 没有使用 GroupByTimeBucket 时不起作用。
+func (g *GroupQuery) BucketOffset(offset time.Duration) *GroupQuery {
+	if g.bucket != nil {
+		g.bucket.offset = offset
+	}
+	return g
+}
+
+// bucketBy 校验分桶方式，并将时间桶作为第一个分组键
+func (a *groupAccumulator) bucketBy(b *timeBucket) error {
+	if b.width <= 0 {
+		return NewErrorf(ErrCodeInvalidParam, "time bucket width must be positive")
+	}
+	if b.field != "_time" {
+		field, err := a.schema.GetField(b.field)
+		if err != nil {
+			return NewErrorf(ErrCodeFieldNotFound, "field %s not found", b.field)
+		}
+		if field.Type != Time {
+			return NewErrorf(ErrCodeFieldTypeMismatch, "field %s of type %s is not a time", b.field, field.Type)
+		}
+	}
+	a.bucket = b
+	a.fields = append([]*Field{{Name: b.field, Type: Time}}, a.fields...)
+	return nil
+}
+
+// key 返回行所在时间桶的起始时间，字段为 NULL 时返回 nil
+func (b *timeBucket) key(row *SSTableRow) any {
+	var t time.Time
+	if b.field == "_time" {
+		t = time.Unix(0, row.Time)
+	} else {
+		v, ok := row.Data[b.field].(time.Time)
+		if !ok {
+			return nil
+		}
+		t = v
+	}
+	return b.start(t)
+}
+
+// start 返回 t 所在时间桶的起始时间
+//
+// 在本地时间（UTC 时间加上时区偏移）上向下取整，再按本地时间转换回 loc 中的时刻
+func (b *timeBucket) start(t time.Time) time.Time {
+	t = t.In(b.loc)
+	_, zone := t.Zone()
+	width := int64(b.width)
+	offset := int64(b.offset) % width
+
+	wall := t.UnixNano() + int64(zone)*int64(time.Second) - offset
+	start := wall - wall%width
+	if wall%width < 0 {
+		start -= width // 负数时间同样向下
+	}
+	s := time.Unix(0, start+offset).UTC()
+	return time.Date(s.Year(), s.Month(), s.Day(), s.Hour(), s.Minute(), s.Second(), s.Nanosecond(), b.loc)
+}
@@ -0,0 +1,126 @@
+package srdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGroupByTimeBucket(t *testing.T) {
+	start := time.Unix(1700000000, 0) // 2023-11-14 22:13:20 UTC
+	clock := NewManualClock(start)
+	table, err := OpenTable(&TableOptions{
+		Dir:   t.TempDir(),
+		Name:  "requests",
+		Clock: clock,
+		Fields: []Field{
+			{Name: "region", Type: String},
+			{Name: "latency", Type: Int64},
+			{Name: "at", Type: Time, Nullable: true},
+			{Name: "code", Type: Int32},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 6 {
+		row := map[string]any{
+			"region":  []string{"a", "b"}[i%2],
+			"latency": int64(i),
+			"code":    int32(200),
+		}
+		if i != 5 {
+			row["at"] = time.Date(2024, 3, 1, 20+i*2, 0, 0, 0, time.UTC) // 20:00 起每 2 小时
+		}
+		if err := table.Insert(row); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			if err := table.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		clock.Advance(30 * time.Second)
+	}
+
+	minute := func(m int) time.Time { return time.Date(2023, 11, 14, 22, m, 0, 0, time.UTC) }
+
+	// 按写入时间每分钟分桶
+	groups, err := table.Query().GroupByTimeBucket("_time", time.Minute).Aggregate(Count(), Sum("latency"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Group{
+		{Key: []any{minute(13)}, Values: []any{int64(2), int64(1)}},
+		{Key: []any{minute(14)}, Values: []any{int64(2), int64(5)}},
+		{Key: []any{minute(15)}, Values: []any{int64(2), int64(9)}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("expected %v, got %v", want, groups)
+	}
+
+	// 时间桶之后再按字段分组，条件与时间范围在分组之前生效
+	groups, err = table.Query().TimeRange(start.Add(time.Minute), time.Time{}).Eq("region", "a").
+		GroupByTimeBucket("_time", 2*time.Minute, "region").Aggregate(Count())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Group{
+		{Key: []any{minute(14), "a"}, Values: []any{int64(2)}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("expected %v, got %v", want, groups)
+	}
+
+	// Time 字段按天分桶：UTC、本地时区与偏移；NULL 的行单独成组
+	day := func(d, h int, loc *time.Location) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, loc) }
+	groups, err = table.Query().GroupByTimeBucket("at", 24*time.Hour).Aggregate(Count())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Group{
+		{Key: []any{nil}, Values: []any{int64(1)}},
+		{Key: []any{day(1, 0, time.UTC)}, Values: []any{int64(2)}},
+		{Key: []any{day(2, 0, time.UTC)}, Values: []any{int64(3)}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("expected %v, got %v", want, groups)
+	}
+
+	tokyo := time.FixedZone("JST", 9*3600) // 1 日 20:00 UTC 到 2 日 04:00 UTC 都在 2 日（JST）
+	groups, err = table.Query().NotNull("at").GroupByTimeBucket("at", 24*time.Hour).BucketLocation(tokyo).Aggregate(Count())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Group{
+		{Key: []any{day(2, 0, tokyo)}, Values: []any{int64(5)}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("expected %v, got %v", want, groups)
+	}
+
+	groups, err = table.Query().NotNull("at").GroupByTimeBucket("at", 24*time.Hour).BucketOffset(22 * time.Hour).Aggregate(Count())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Group{
+		{Key: []any{time.Date(2024, 2, 29, 22, 0, 0, 0, time.UTC)}, Values: []any{int64(1)}},
+		{Key: []any{day(1, 22, time.UTC)}, Values: []any{int64(4)}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("expected %v, got %v", want, groups)
+	}
+
+	// 无效的分桶方式
+	if _, err := table.Query().GroupByTimeBucket("_time", 0).Aggregate(Count()); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected invalid param for zero width, got %v", err)
+	}
+	if _, err := table.Query().GroupByTimeBucket("code", time.Minute).Aggregate(Count()); !IsError(err, ErrCodeFieldTypeMismatch) {
+		t.Errorf("expected type mismatch for non-time field, got %v", err)
+	}
+	if _, err := table.Query().GroupByTimeBucket("missing", time.Minute).Aggregate(Count()); !IsError(err, ErrCodeFieldNotFound) {
+		t.Errorf("expected field not found, got %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package srdb
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+)
+
+const (
+	groupMemoryOverhead = 64  // 一个分组（map 项与分组键切片）的固定开销
+	aggStateMemory      = 128 // 一个聚合累加状态
+)
+
+// GroupQuery 分组聚合查询，使用 QueryBuilder.GroupBy 创建
+type GroupQuery struct {
+	qb     *QueryBuilder
+	fields []string
+	bucket *timeBucket // GroupByTimeBucket 的时间桶，作为第一个分组键
+}
+
+// Group 一个分组的聚合结果
+type Group struct {
+	Key    []any // 分组字段的值，与 GroupBy 的字段按顺序对应，NULL 为 nil
+	Values []any // 聚合结果，与 Aggregate 的参数按顺序对应，类型见 QueryBuilder.Aggregate
+}
+
+// GroupBy 按字段分组，通过 GroupQuery.Aggregate 计算每个分组的聚合
+//
+//	groups, err := table.Query().TimeRange(from, time.Time{}).GroupBy("status").Aggregate(srdb.Count(), srdb.Avg("latency_ms"))
+//	for _, g := range groups {
+//	    fmt.Println(g.Key[0], g.Values[0], g.Values[1])
+//	}
+func (qb *QueryBuilder) GroupBy(fields ...string) *GroupQuery {
+	return &GroupQuery{qb: qb, fields: fields}
+}
+
+// groupState 一个分组的累加状态
+type groupState struct {
+	key    []any
+	states []*aggState
+}
+
+// Aggregate 计算每个分组的聚合，结果按分组键升序排列（按字段类型比较，NULL 在最前）
+//
+// 与 QueryBuilder.Aggregate 一样流式扫描匹配的行，只在内存中保留每个分组的累加状态：
+// 分组字段与聚合字段都是数值或布尔列（不包括 Time）且没有 OrderBy 时通过 BatchRows 列式扫描，
+// 否则逐行扫描。分组数量计入 MaxMemory；Where 条件、Offset 与 Limit 应用在分组之前。
+func (g *GroupQuery) Aggregate(aggs ...Aggregate) ([]Group, error) {
+	qb := g.qb
+	if qb.table == nil {
+		return nil, fmt.Errorf("table is nil")
+	}
+	if len(g.fields) == 0 && g.bucket == nil {
+		return nil, NewErrorf(ErrCodeInvalidParam, "no group by fields specified")
+	}
+	if len(aggs) == 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "no aggregates specified")
+	}
+
+	acc, err := newGroupAccumulator(qb, g.fields, aggs)
+	if err != nil {
+		return nil, err
+	}
+	if g.bucket != nil {
+		if err := acc.bucketBy(g.bucket); err != nil {
+			return nil, err
+		}
+	}
+	vectorized := qb.orderBy == "" && g.bucket == nil
+	var columns []string
+	for _, field := range acc.fields {
+		if vectorFieldSize(field.Type) == 0 || field.Type == Time {
+			vectorized = false // Time 列的向量只有秒级精度
+		}
+		if !slices.Contains(columns, field.Name) {
+			columns = append(columns, field.Name)
+		}
+	}
+	for _, agg := range aggs {
+		if agg.Func == "COUNT" {
+			continue
+		}
+		if state, _ := newAggState(agg, acc.schema); state.kind == aggDecimal {
+			vectorized = false
+		}
+		if !slices.Contains(columns, agg.Field) {
+			columns = append(columns, agg.Field)
+		}
+	}
+
+	if vectorized {
+		err = acc.scanBatches(qb, columns)
+	} else {
+		err = acc.scanRows(qb)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return acc.results()
+}
+
+// newGroupAccumulator 校验分组字段与聚合并创建累加器，没有分组字段时所有行属于同一个分组
+func newGroupAccumulator(qb *QueryBuilder, names []string, aggs []Aggregate) (*groupAccumulator, error) {
+	schema := qb.table.schema.Load()
+	fields := make([]*Field, len(names))
+	for i, name := range names {
+		field, err := schema.GetField(name)
+		if err != nil {
+			return nil, NewErrorf(ErrCodeFieldNotFound, "field %s not found", name)
+		}
+		fields[i] = field
+	}
+	for _, agg := range aggs {
+		if _, err := newAggState(agg, schema); err != nil {
+			return nil, err
+		}
+	}
+	return &groupAccumulator{
+		fields: fields,
+		aggs:   aggs,
+		schema: schema,
+		groups: make(map[string]*groupState),
+		memory: queryMemory{limit: qb.maxMemory},
+	}, nil
+}
+
+// groupAccumulator 按分组键累加
+type groupAccumulator struct {
+	fields []*Field
+	aggs   []Aggregate
+	schema *Schema
+	groups map[string]*groupState
+	memory queryMemory
+	buf    bytes.Buffer
+	key    []any       // addRow 复用的分组键
+	bucket *timeBucket // 不为 nil 时 fields[0] 是时间桶
+}
+
+// group 返回分组键对应的分组（不存在时创建）
+func (a *groupAccumulator) group(key []any) (*groupState, error) {
+	a.buf.Reset()
+	for i, field := range a.fields {
+		writeKeyValue(&a.buf, field, key[i])
+	}
+	if group, ok := a.groups[string(a.buf.Bytes())]; ok {
+		return group, nil
+	}
+
+	size := int64(groupMemoryOverhead + a.buf.Len() + len(a.aggs)*aggStateMemory)
+	for _, value := range key {
+		size += fieldMemoryOverhead + valueMemory(value)
+	}
+	if err := a.memory.charge(size); err != nil {
+		return nil, err
+	}
+	group := &groupState{key: slices.Clone(key), states: make([]*aggState, len(a.aggs))}
+	for i, agg := range a.aggs {
+		group.states[i], _ = newAggState(agg, a.schema) // 已在 Aggregate 中校验
+	}
+	a.groups[a.buf.String()] = group
+	return group, nil
+}
+
+// scanBatches 通过列式扫描累加
+func (a *groupAccumulator) scanBatches(qb *QueryBuilder, columns []string) error {
+	br, err := qb.BatchRows(columns, DefaultBatchSize)
+	if err != nil {
+		return err
+	}
+	defer br.Close()
+
+	keyCols := make([]*ColumnVector, len(a.fields))
+	aggCols := make([]*ColumnVector, len(a.aggs))
+	key := make([]any, len(a.fields))
+	for br.Next() {
+		batch := br.Batch()
+		for i, field := range a.fields {
+			keyCols[i] = batch.Column(field.Name)
+		}
+		for i, agg := range a.aggs {
+			if agg.Func != "COUNT" {
+				aggCols[i] = batch.Column(agg.Field)
+			}
+		}
+		for row := range batch.Len() {
+			for i, col := range keyCols {
+				key[i] = columnValue(col, row)
+			}
+			group, err := a.group(key)
+			if err != nil {
+				return err
+			}
+			for i, state := range group.states {
+				if aggCols[i] == nil {
+					state.count++
+					continue
+				}
+				state.addColumnAt(aggCols[i], row)
+				if state.err != nil {
+					return state.err
+				}
+			}
+		}
+	}
+	return br.Err()
+}
+
+// scanRows 逐行累加
+func (a *groupAccumulator) scanRows(qb *QueryBuilder) error {
+	rows, err := qb.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := a.addRow(rows.Row().inner); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// addRow 累加一行
+func (a *groupAccumulator) addRow(row *SSTableRow) error {
+	if a.key == nil {
+		a.key = make([]any, len(a.fields))
+	}
+	data := row.Data
+	for i, field := range a.fields {
+		if i == 0 && a.bucket != nil {
+			a.key[i] = a.bucket.key(row)
+			continue
+		}
+		a.key[i] = data[field.Name]
+	}
+	group, err := a.group(a.key)
+	if err != nil {
+		return err
+	}
+	for _, state := range group.states {
+		if state.agg.Func == "COUNT" {
+			state.count++
+			continue
+		}
+		state.addValue(data[state.agg.Field])
+		if state.err != nil {
+			return state.err
+		}
+	}
+	return nil
+}
+
+// results 返回按分组键排序的结果
+func (a *groupAccumulator) results() ([]Group, error) {
+	type sortedGroup struct {
+		keys  []indexSortValue // nil 表示 NULL
+		group Group
+	}
+	sorted := make([]sortedGroup, 0, len(a.groups))
+	for _, state := range a.groups {
+		g := sortedGroup{
+			keys:  make([]indexSortValue, len(state.key)),
+			group: Group{Key: state.key, Values: make([]any, len(state.states))},
+		}
+		for i, value := range state.key {
+			if value != nil {
+				raw := fmt.Sprintf("%v", value)
+				g.keys[i] = indexSortValue{raw: raw, key: indexSortKey(raw, a.fields[i].Type)}
+			}
+		}
+		for i, s := range state.states {
+			var err error
+			if g.group.Values[i], err = s.result(); err != nil {
+				return nil, err
+			}
+		}
+		sorted = append(sorted, g)
+	}
+
+	slices.SortFunc(sorted, func(x, y sortedGroup) int {
+		for i := range x.keys {
+			xNull, yNull := x.group.Key[i] == nil, y.group.Key[i] == nil
+			switch {
+			case xNull && yNull:
+				continue
+			case xNull:
+				return -1
+			case yNull:
+				return 1
+			}
+			if c := compareIndexSortValues(x.keys[i], y.keys[i]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	groups := make([]Group, len(sorted))
+	for i, g := range sorted {
+		groups[i] = g.group
+	}
+	return groups, nil
+}
+
+// columnValue 返回列向量中第 i 行的值，类型与逐行读取时相同（NULL 为 nil）
+func columnValue(col *ColumnVector, i int) any {
+	if col.IsNull(i) {
+		return nil
+	}
+	var value any
+	switch {
+	case isVectorInt64(col.Type):
+		value = col.Int64s[i]
+	case isVectorUint64(col.Type):
+		value = col.Uint64s[i]
+	case col.Type == Float32 || col.Type == Float64:
+		value = col.Float64s[i]
+	default:
+		return col.Bools[i]
+	}
+	if converted, err := convertValue(value, col.Type); err == nil {
+		return converted
+	}
+	return value
+}
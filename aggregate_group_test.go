@@ -0,0 +1,156 @@
+package srdb
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGroupByAggregate(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "requests",
+		Fields: []Field{
+			{Name: "status", Type: String},
+			{Name: "code", Type: Int32},
+			{Name: "region", Type: String, Nullable: true},
+			{Name: "latency", Type: Float64},
+			{Name: "elapsed", Type: Duration},
+			{Name: "cost", Type: Decimal},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 30 {
+		row := map[string]any{
+			"status":  []string{"ok", "error", "timeout"}[i%3],
+			"code":    int32([]int{200, 500, 504}[i%3]),
+			"latency": float64(i),
+			"elapsed": time.Duration(i%2) * time.Second,
+			"cost":    decimal.New(int64(i), -2),
+		}
+		if i%5 != 0 {
+			row["region"] = fmt.Sprintf("r%d", i%2)
+		}
+		if err := table.Insert(row); err != nil {
+			t.Fatal(err)
+		}
+		if i == 14 {
+			// 一部分在 SST 中，一部分在 MemTable 中
+			if err := table.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 列式扫描：分组键的类型与逐行读取相同
+	groups, err := table.Query().GroupBy("code").Aggregate(Count(), Sum("latency"), Max("latency"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Group{
+		{Key: []any{int32(200)}, Values: []any{int64(10), 135.0, 27.0}},
+		{Key: []any{int32(500)}, Values: []any{int64(10), 145.0, 28.0}},
+		{Key: []any{int32(504)}, Values: []any{int64(10), 155.0, 29.0}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("expected %v, got %v", want, groups)
+	}
+
+	// 多个分组字段，字符串分组逐行扫描；NULL 分组排在最前
+	groups, err = table.Query().Eq("status", "ok").GroupBy("status", "region").Aggregate(Count(), Min("latency"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Group{
+		{Key: []any{"ok", nil}, Values: []any{int64(2), 0.0}},
+		{Key: []any{"ok", "r0"}, Values: []any{int64(4), 6.0}},
+		{Key: []any{"ok", "r1"}, Values: []any{int64(4), 3.0}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("expected %v, got %v", want, groups)
+	}
+
+	// Decimal 聚合与 Duration 分组
+	groups, err = table.Query().GroupBy("elapsed").Aggregate(Sum("cost"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].Key[0] != time.Duration(0) || groups[1].Key[0] != time.Second {
+		t.Fatalf("unexpected duration groups %v", groups)
+	}
+	if sum := groups[1].Values[0].(decimal.Decimal); !sum.Equal(decimal.RequireFromString("2.25")) {
+		t.Errorf("expected odd rows to cost 2.25, got %s", sum)
+	}
+
+	// 分组数量计入 MaxMemory
+	if _, err := table.Query().MaxMemory(256).GroupBy("latency").Aggregate(Count()); !IsError(err, ErrCodeQueryMemoryExceeded) {
+		t.Errorf("expected ErrCodeQueryMemoryExceeded, got %v", err)
+	}
+	if _, err := table.Query().GroupBy("missing").Aggregate(Count()); !IsError(err, ErrCodeFieldNotFound) {
+		t.Errorf("expected ErrCodeFieldNotFound, got %v", err)
+	}
+	if _, err := table.Query().GroupBy("code").Aggregate(); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+}
+
+func TestQueryAggregateShortcuts(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "metrics",
+		Fields: []Field{{Name: "value", Type: Int64, Nullable: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for _, v := range []any{int64(4), int64(-2), nil, int64(10)} {
+		if err := table.Insert(map[string]any{"value": v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := table.Query().Count(); err != nil || n != 4 {
+		t.Errorf("expected count 4, got %d %v", n, err)
+	}
+	if n, err := table.Query().Gt("value", 0).Count(); err != nil || n != 2 {
+		t.Errorf("expected 2 positive values, got %d %v", n, err)
+	}
+	checks := []struct {
+		name string
+		fn   func(string) (any, error)
+		want any
+	}{
+		{"sum", table.Query().Sum, int64(12)},
+		{"avg", table.Query().Avg, 4.0},
+		{"min", table.Query().Min, int64(-2)},
+		{"max", table.Query().Max, int64(10)},
+	}
+	for _, c := range checks {
+		if got, err := c.fn("value"); err != nil || got != c.want {
+			t.Errorf("%s: expected %v, got %v %v", c.name, c.want, got, err)
+		}
+	}
+	if got, err := table.Query().Lt("value", -100).Sum("value"); err != nil || got != nil {
+		t.Errorf("expected nil sum without values, got %v %v", got, err)
+	}
+
+	// 没有过滤条件时直接统计 key，被删除的行不计入
+	if err := table.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := table.Query().Count(); err != nil || n != 3 {
+		t.Errorf("expected count 3 after delete, got %d %v", n, err)
+	}
+	if n, err := table.Query().Limit(2).Count(); err != nil || n != 2 {
+		t.Errorf("expected count 2 with limit, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,107 @@
+package srdb
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAggregate(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "ledger",
+		Fields: []Field{
+			{Name: "account", Type: String},
+			{Name: "cents", Type: Int64},
+			{Name: "units", Type: Uint64},
+			{Name: "rate", Type: Float64},
+			{Name: "amount", Type: Decimal},
+			{Name: "memo", Type: String, Nullable: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	rows := []map[string]any{
+		{"account": "a", "cents": int64(math.MaxInt64), "units": uint64(math.MaxUint64), "rate": 1.5, "amount": decimal.RequireFromString("0.1")},
+		{"account": "a", "cents": int64(math.MaxInt64), "units": uint64(1), "rate": 2.5, "amount": decimal.RequireFromString("0.2")},
+		{"account": "b", "cents": int64(-5), "units": uint64(2), "rate": -1.0, "amount": decimal.RequireFromString("-0.3")},
+	}
+	if err := table.Insert(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认模式：整数溢出返回类型化错误，不回绕
+	_, err = table.Query().Aggregate(Sum("cents"))
+	if !IsError(err, ErrCodeAggregateOverflow) {
+		t.Fatalf("expected ErrCodeAggregateOverflow, got %v", err)
+	}
+	if _, err := table.Query().Aggregate(Sum("units")); !IsError(err, ErrCodeAggregateOverflow) {
+		t.Errorf("expected ErrCodeAggregateOverflow for uint64 sum, got %v", err)
+	}
+	if _, err := table.Query().Aggregate(Avg("cents")); !IsError(err, ErrCodeAggregateOverflow) {
+		t.Errorf("expected ErrCodeAggregateOverflow for avg, got %v", err)
+	}
+
+	// 每个聚合单独选择累加方式
+	res, err := table.Query().Aggregate(Count(), Sum("cents").BigInt(), Sum("units").Decimal(), Sum("rate"), Sum("amount"), Sum("cents").Decimal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCents := new(big.Int).Mul(big.NewInt(math.MaxInt64), big.NewInt(2))
+	wantCents.Sub(wantCents, big.NewInt(5))
+	if res[0] != int64(3) {
+		t.Errorf("expected count 3, got %v", res[0])
+	}
+	if got, ok := res[1].(*big.Int); !ok || got.Cmp(wantCents) != 0 {
+		t.Errorf("expected big.Int sum %s, got %v", wantCents, res[1])
+	}
+	wantUnits := decimal.NewFromBigInt(new(big.Int).SetUint64(math.MaxUint64), 0).Add(decimal.NewFromInt(3))
+	if got, ok := res[2].(decimal.Decimal); !ok || !got.Equal(wantUnits) {
+		t.Errorf("expected decimal sum %s, got %v", wantUnits, res[2])
+	}
+	if res[3] != 3.0 {
+		t.Errorf("expected float sum 3, got %v", res[3])
+	}
+	if got, ok := res[4].(decimal.Decimal); !ok || !got.IsZero() {
+		t.Errorf("expected exact decimal sum 0, got %v", res[4])
+	}
+	if got, ok := res[5].(decimal.Decimal); !ok || got.String() != wantCents.String() {
+		t.Errorf("expected decimal sum %s, got %v", wantCents, res[5])
+	}
+
+	// 过滤、Avg、Min、Max
+	res, err = table.Query().Eq("account", "a").Aggregate(Avg("cents").BigInt(), Avg("rate"), Min("units"), Max("amount"), Min("cents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := res[0].(decimal.Decimal); !ok || got.String() != "9223372036854775807" {
+		t.Errorf("unexpected avg %v", res[0])
+	}
+	if res[1] != 2.0 || res[2] != uint64(1) || res[4] != int64(math.MaxInt64) {
+		t.Errorf("unexpected results %v", res)
+	}
+	if got, ok := res[3].(decimal.Decimal); !ok || got.String() != "0.2" {
+		t.Errorf("unexpected max %v", res[3])
+	}
+
+	// 没有匹配的行
+	res, err = table.Query().Eq("account", "none").Aggregate(Count(), Sum("cents"), Max("amount"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[0] != int64(0) || res[1] != nil || res[2] != nil {
+		t.Errorf("expected 0, nil, nil for empty result, got %v", res)
+	}
+
+	// 参数校验
+	for _, agg := range []Aggregate{Sum("memo"), Sum("missing"), Sum("rate").BigInt(), Sum("amount").BigInt(), {Func: "MEDIAN", Field: "cents"}} {
+		if _, err := table.Query().Aggregate(agg); err == nil {
+			t.Errorf("expected error for %s", agg)
+		}
+	}
+}
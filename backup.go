@@ -0,0 +1,448 @@
+package srdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 在线备份与恢复
+//
+// Backup 在写入继续进行时生成数据库的一致性副本，每张表按 Table.Snapshot 的方式固定一个视图：
+//   - SST：固定当前 Version 的文件（备份期间 compaction 不会删除），硬链接到备份目录，跨文件系统时复制
+//   - MANIFEST：按固定的 Version 重新生成（不复制正在追加的 MANIFEST，不会得到写了一半的记录）
+//   - WAL：将固定时 MemTable 中的行（包括修改与删除标记）写入新的 WAL，恢复时照常回放
+//   - Schema、mutations、保留策略按备份时的状态写入
+//
+// 二级索引与连续聚合在固定视图之后仍然在更新，备份中只保留定义：索引在恢复后首次打开时重建，
+// 连续聚合的持久化状态没有超出备份的行时保留，否则从备份中的行重新累加。
+//
+// 备份先写入目标目录旁的临时目录，完成后重命名为目标目录，失败的备份不会留下不完整的目录。
+// 备份目录本身就是一个数据库目录，Restore 复制并校验后即可打开。
+
+const (
+	// backupInfoFile 备份目录中描述备份的文件，Restore 据此识别备份目录
+	backupInfoFile = "BACKUP"
+
+	// backupTmpSuffix 备份写入时的临时目录后缀，完成后重命名为目标目录
+	backupTmpSuffix = ".backup"
+
+	// restoreTmpSuffix 恢复时的临时目录后缀，复制并校验完成后重命名为目标目录
+	restoreTmpSuffix = ".restoring"
+)
+
+// BackupInfo 备份的描述，写入备份目录的 BACKUP 文件
+type BackupInfo struct {
+	CreatedAt time.Time        `json:"created_at"`
+	Tables    map[string]int64 `json:"tables"` // 表名 -> 备份的 seq（不大于它的行都在备份中）
+}
+
+// Backup 在线备份数据库到 dir（dir 必须不存在或为空目录），备份期间写入照常进行
+//
+// 每张表的备份是创建时的一致性视图：之后的插入、修改与删除都不在备份中。
+// 备份期间不能创建、删除表或打开 KV 存储（这些操作等待备份完成）。
+// 硬链接的 SST 文件与数据库共享磁盘空间，之后被 compaction 删除也不影响备份。
+func (db *Database) Backup(dir string) (*BackupInfo, error) {
+	src, err := filepath.Abs(db.dir)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if src == dst {
+		return nil, NewErrorf(ErrCodeInvalidParam, "backup directory is the database directory")
+	}
+	if rel, err := filepath.Rel(src, dst); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, NewErrorf(ErrCodeInvalidParam, "backup directory %s is inside the database directory", dir)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if err := checkRelocateTarget(dst); err != nil {
+		return nil, err
+	}
+	tmp := dst + backupTmpSuffix
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return nil, err
+	}
+
+	info, err := db.backupTo(tmp, false)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	syncDir(filepath.Dir(dst))
+
+	db.options.Logger.Info("[Database] Backup completed",
+		"dir", dst,
+		"tables", len(info.Tables))
+	return info, nil
+}
+
+// backupTo 将所有表、KV 存储与元数据写入 dir，调用者必须持有 db.mu 读锁
+//
+// inflight 为 true 时 MemTable 中大于持久化水位的行也写入备份（复制的检查点需要，见 ServeReplica）。
+func (db *Database) backupTo(dir string, inflight bool) (*BackupInfo, error) {
+	info := &BackupInfo{CreatedAt: db.options.Clock.Now(), Tables: make(map[string]int64)}
+
+	// 只备份就绪的表，未完成的创建、删除不写入备份的元数据
+	metadata := *db.metadata
+	metadata.Tables = nil
+	for _, tableInfo := range db.metadata.Tables {
+		if tableInfo.State != TableReady {
+			continue
+		}
+		target := filepath.Join(dir, tableInfo.Name)
+		if table, ok := db.tables[tableInfo.Name]; ok {
+			seq, err := table.backup(target, inflight)
+			if err != nil {
+				return nil, fmt.Errorf("backup table %s: %w", tableInfo.Name, err)
+			}
+			info.Tables[tableInfo.Name] = seq
+		} else {
+			// 打开失败的表没有写入，原样复制，恢复后与原数据库一样需要人工处理
+			if err := copyVerifiedTree(filepath.Join(db.dir, tableInfo.Name), target); err != nil {
+				return nil, fmt.Errorf("copy table %s: %w", tableInfo.Name, err)
+			}
+		}
+		metadata.Tables = append(metadata.Tables, tableInfo)
+	}
+
+	// KV 存储：已打开时在线备份，否则目录不会被写入，直接复制
+	kvDir := filepath.Join(dir, kvTableName)
+	if db.kv != nil {
+		if _, err := db.kv.table.backup(kvDir, inflight); err != nil {
+			return nil, fmt.Errorf("backup kv: %w", err)
+		}
+	} else if _, err := os.Stat(filepath.Join(db.dir, kvTableName)); err == nil {
+		if err := copyVerifiedTree(filepath.Join(db.dir, kvTableName), kvDir); err != nil {
+			return nil, fmt.Errorf("copy kv: %w", err)
+		}
+	}
+
+	if db.sequence != nil {
+		metadata.Sequence = max(metadata.Sequence, db.sequence.Last())
+	}
+	if err := writeBackupJSON(filepath.Join(dir, "database.meta"), &metadata); err != nil {
+		return nil, err
+	}
+	if err := writeBackupJSON(filepath.Join(dir, backupInfoFile), info); err != nil {
+		return nil, err
+	}
+	syncDir(dir)
+	return info, nil
+}
+
+// writeBackupJSON 将 v 编码为 JSON 写入 path 并 fsync
+func writeBackupJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileSync(path, data)
+}
+
+// writeFileSync 写入文件并 fsync
+func writeFileSync(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// backup 将表的一致性视图写入 dir，返回备份的 seq
+//
+// inflight 为 false 时只写入不大于返回的 seq 的 MemTable 行，否则写入固定时 MemTable 中的全部行。
+func (t *Table) backup(dir string, inflight bool) (int64, error) {
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	// 与 Snapshot 相同：先固定 MemTable 再固定 SST，期间 flush 的行同时出现在两者中
+	t.mutateMu.Lock()
+	seq := t.durability.watermark()
+	memtables := t.memtableManager.snapshot()
+	readers, version := t.pinVersion()
+	nextFile := t.versionSet.GetNextFileNumber()
+	t.mutateMu.Unlock()
+	defer t.sstManager.unpin(readers)
+
+	for _, sub := range []string{"sst", "wal", "idx"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return 0, err
+		}
+	}
+
+	// 1. SST 与按固定 Version 生成的 MANIFEST
+	edit := NewVersionEdit()
+	edit.SetReason(EditReasonBackup)
+	for level := range NumLevels {
+		for _, file := range version.GetLevel(level) {
+			name := fmt.Sprintf("%06d.sst", file.FileNumber)
+			if err := linkOrCopyFile(filepath.Join(t.dir, "sst", name), filepath.Join(dir, "sst", name)); err != nil {
+				return 0, err
+			}
+			meta := *file
+			edit.AddFile(&meta)
+		}
+	}
+	edit.SetNextFileNumber(nextFile)
+	edit.SetLastSequence(version.GetLastSequence())
+	versionSet, err := NewVersionSet(dir)
+	if err != nil {
+		return 0, err
+	}
+	err = versionSet.LogAndApply(edit)
+	if closeErr := versionSet.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("write manifest: %w", err)
+	}
+
+	// 2. MemTable 中的行写入 WAL（从旧到新，同一 seq 的新版本后回放）
+	limit := seq
+	if inflight {
+		limit = math.MaxInt64
+	}
+	if err := writeBackupWAL(filepath.Join(dir, "wal"), memtables, limit); err != nil {
+		return 0, fmt.Errorf("write wal: %w", err)
+	}
+
+	// 3. Schema、修改记录与保留策略
+	if err := writeSchemaFile(dir, t.schema.Load()); err != nil {
+		return 0, err
+	}
+	t.mutations.mu.RLock()
+	mutations, err := t.mutations.seqs.MarshalBinary()
+	t.mutations.mu.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	if !t.mutations.empty.Load() {
+		if err := writeFileSync(filepath.Join(dir, mutationsFile), mutations); err != nil {
+			return 0, err
+		}
+	}
+	if err := copyIfExists(filepath.Join(t.dir, retentionPolicyFile), filepath.Join(dir, retentionPolicyFile)); err != nil {
+		return 0, err
+	}
+
+	// 4. 索引与连续聚合的定义
+	if err := t.backupIndexes(filepath.Join(dir, "idx")); err != nil {
+		return 0, fmt.Errorf("backup indexes: %w", err)
+	}
+	if err := t.backupContinuous(filepath.Join(dir, "cagg"), seq); err != nil {
+		return 0, fmt.Errorf("backup continuous aggregates: %w", err)
+	}
+
+	for _, sub := range []string{"sst", "wal", "idx", ""} {
+		syncDir(filepath.Join(dir, sub))
+	}
+	return seq, nil
+}
+
+// pinVersion 固定当前 Version 的所有 SST 文件，返回固定的 reader（用于 unpin）与 Version
+func (t *Table) pinVersion() ([]*SSTableReader, *Version) {
+	for {
+		readers := t.sstManager.pin()
+		version := t.versionSet.GetCurrent()
+
+		pinned := make(map[int64]bool, len(readers))
+		for _, reader := range readers {
+			if fileNumber, ok := reader.fileNumber(); ok {
+				pinned[fileNumber] = true
+			}
+		}
+		complete := true
+		for _, file := range version.GetSSTFiles() {
+			if !pinned[file.FileNumber] {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return readers, version
+		}
+		// pin 之后有新文件加入 Version，重新固定
+		t.sstManager.unpin(readers)
+	}
+}
+
+// writeBackupWAL 将 memtables（从新到旧）中 seq 不大于 seq 的行写入 dir 下的第一个 WAL
+func writeBackupWAL(dir string, memtables []*MemTable, seq int64) error {
+	wal, err := OpenWAL(filepath.Join(dir, fmt.Sprintf("%06d.wal", 1)))
+	if err != nil {
+		return err
+	}
+	for i := len(memtables) - 1; i >= 0 && err == nil; i-- {
+		iter := memtables[i].NewIterator()
+		for iter.Next() {
+			key, value := iter.Key(), iter.Value()
+			if key > seq {
+				continue
+			}
+			entryType := byte(WALEntryTypePut)
+			if _, deleted, ok := rowVersion(value); ok && deleted {
+				entryType = WALEntryTypeDelete
+			}
+			if err = wal.Append(&WALEntry{Type: entryType, Seq: key, Data: value}); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = wal.Sync()
+	}
+	if closeErr := wal.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return saveWALCurrentNumber(dir, 1)
+}
+
+// backupIndexes 在 dir 中创建与表相同的空索引（包括部分索引的条件），恢复后首次打开时重建
+func (t *Table) backupIndexes(dir string) error {
+	m := NewIndexManager(dir, t.schema.Load())
+	defer m.Close()
+
+	for _, field := range t.indexManager.ListIndexes() {
+		idx, ok := t.indexManager.GetIndex(field)
+		if !ok {
+			continue
+		}
+		if err := m.createIndex(field, idx.where); err != nil {
+			return err
+		}
+	}
+	return m.BuildAll()
+}
+
+// backupContinuous 写入连续聚合：持久化的状态没有超出 seq 时保留，否则只保留定义（恢复后重新累加）
+func (t *Table) backupContinuous(dir string, seq int64) error {
+	files, err := filepath.Glob(filepath.Join(t.caggs.dir, "*.json"))
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var file continuousAggregateFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			continue // 打开时同样会被跳过
+		}
+		if file.MaxSeq > seq {
+			file.MaxSeq, file.Buckets = 0, nil
+		}
+		if err := writeBackupJSON(filepath.Join(dir, filepath.Base(path)), &file); err != nil {
+			return err
+		}
+	}
+	syncDir(dir)
+	return nil
+}
+
+// linkOrCopyFile 将 src 硬链接到 dst，无法链接时（例如跨文件系统）复制并校验
+func linkOrCopyFile(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return copyVerifiedFile(src, dst)
+}
+
+// copyIfExists 复制 src 到 dst，src 不存在时忽略
+func copyIfExists(src, dst string) error {
+	err := copyVerifiedFile(src, dst)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Restore 从 Backup 生成的备份目录恢复数据库到 targetDir（targetDir 必须不存在或为空目录）
+//
+// 所有文件复制到 targetDir 旁的临时目录并逐个校验，完成后重命名为 targetDir，
+// 失败时 targetDir 保持不变，备份目录不会被修改，可以多次恢复。恢复后使用 Open 打开。
+func Restore(backupDir, targetDir string) error {
+	src, err := filepath.Abs(backupDir)
+	if err != nil {
+		return err
+	}
+	dst, err := filepath.Abs(targetDir)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(src, backupInfoFile))
+	if err != nil {
+		return NewErrorf(ErrCodeInvalidParam, "%s is not a backup directory: %v", backupDir, err)
+	}
+	var info BackupInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return NewErrorf(ErrCodeCorrupted, "invalid backup description in %s: %v", backupDir, err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "database.meta")); err != nil {
+		return NewErrorf(ErrCodeCorrupted, "backup %s has no database metadata: %v", backupDir, err)
+	}
+	if src == dst {
+		return NewErrorf(ErrCodeInvalidParam, "backup and target are the same directory")
+	}
+	if err := checkRelocateTarget(dst); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmp := dst + restoreTmpSuffix
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := copyVerifiedTree(src, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("copy %s: %w", backupDir, err)
+	}
+	if err := os.Remove(filepath.Join(tmp, backupInfoFile)); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(dst))
+	return nil
+}
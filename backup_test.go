@@ -0,0 +1,204 @@
+package srdb
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackupRestore(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "data")
+	backupDir := filepath.Join(base, "backup")
+
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := NewSchema("events", []Field{
+		{Name: "host", Type: String, Indexed: true},
+		{Name: "n", Type: Int64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := db.CreateTable("events", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv, err := db.KV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.Put([]byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+
+	insert := func(from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			if err := table.Insert(map[string]any{"host": []string{"a", "b"}[i%2], "n": int64(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 一部分在 SST 中，一部分在 MemTable 中；各有一行被修改和删除
+	insert(0, 50)
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for table.memtableManager.GetImmutableCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("flush did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	insert(50, 100)
+	for _, seq := range []int64{3, 60} {
+		if err := table.Update(seq, map[string]any{"n": int64(1000 + seq)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, seq := range []int64{4, 61} {
+		if err := table.Delete(seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 备份期间写入继续进行
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 100; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := table.Insert(map[string]any{"host": "c", "n": int64(i)}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	info, err := db.Backup(backupDir)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backupSeq := info.Tables["events"]
+	if backupSeq < 100 {
+		t.Fatalf("expected backup seq >= 100, got %d", backupSeq)
+	}
+
+	// 目标目录不为空
+	if _, err := db.Backup(backupDir); !IsError(err, ErrCodeExists) {
+		t.Errorf("expected ErrCodeExists, got %v", err)
+	}
+	if _, err := db.Backup(filepath.Join(dir, "backup")); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+
+	// 备份之后的修改与 compaction 不影响备份，原数据库删除后仍然可以恢复
+	if err := table.Update(5, map[string]any{"n": int64(-1)}); err != nil {
+		t.Fatal(err)
+	}
+	insert(100, 110)
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Restore(filepath.Join(base, "missing"), filepath.Join(base, "x")); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for a non-backup directory, got %v", err)
+	}
+	for _, target := range []string{filepath.Join(base, "restored1"), filepath.Join(base, "restored2")} {
+		if err := Restore(backupDir, target); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(target, backupInfoFile)); !os.IsNotExist(err) {
+			t.Errorf("expected no backup description in the restored database, got %v", err)
+		}
+
+		restored, err := Open(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, err := restored.GetTable("events")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 不大于备份 seq 的行都在，修改与删除都保留，备份之后的修改不在
+		seqs, err := events.Query().Seqs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for seq := int64(1); seq <= backupSeq; seq++ {
+			if want := seq != 4 && seq != 61; slices.Contains(seqs, seq) != want {
+				t.Errorf("seq %d: expected present=%v", seq, want)
+			}
+		}
+		for seq, want := range map[int64]int64{3: 1003, 60: 1060, 5: 4} {
+			row, err := events.Get(seq)
+			if err != nil || row.Data["n"] != want {
+				t.Errorf("seq %d: expected n=%d, got %v %v", seq, want, row, err)
+			}
+		}
+
+		// 索引在打开时重建
+		if n, err := events.Query().Eq("host", "b").Count(); err != nil || n != 49 {
+			t.Errorf("expected 49 rows for host b, got %d %v", n, err)
+		}
+		if err := events.Insert(map[string]any{"host": "b", "n": int64(0)}); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := events.Query().Eq("host", "b").Count(); err != nil || n != 50 {
+			t.Errorf("expected 50 rows for host b after insert, got %d %v", n, err)
+		}
+
+		kv, err := restored.KV()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, err := kv.Get([]byte("k")); err != nil || string(v) != "v" {
+			t.Errorf("expected kv value v, got %q %v", v, err)
+		}
+		if err := restored.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Restore(backupDir, filepath.Join(base, "restored1")); !IsError(err, ErrCodeExists) {
+		t.Errorf("expected ErrCodeExists when restoring into a non-empty directory, got %v", err)
+	}
+}
+
+func TestBackupClock(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	opts := DefaultOptions(filepath.Join(t.TempDir(), "data"))
+	opts.Clock = NewManualClock(created)
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	info, err := db.Backup(filepath.Join(t.TempDir(), "backup"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.CreatedAt.Equal(created) {
+		t.Errorf("expected CreatedAt %v, got %v", created, info.CreatedAt)
+	}
+}
@@ -0,0 +1,507 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// DefaultBatchSize BatchRows 默认每批行数
+const DefaultBatchSize = 1024
+
+// ColumnVector 一列数据的向量表示，与 Batch.Seqs 按行对齐
+//
+// 按字段类型只填充其中一个切片：
+//   - Int64s: Int/Int8/Int16/Int32/Int64/Rune、Time（Unix 秒）、Duration（纳秒）
+//   - Uint64s: Uint/Uint8/Uint16/Uint32/Uint64/Byte
+//   - Float64s: Float32/Float64
+//   - Bools: Bool
+//
+// NULL 值在对应切片中为零值，并在 Nulls 中标记；整批没有 NULL 时 Nulls 为 nil。
+type ColumnVector struct {
+	Name     string
+	Type     FieldType
+	Int64s   []int64
+	Uint64s  []uint64
+	Float64s []float64
+	Bools    []bool
+	Nulls    []bool
+}
+
+// IsNull 第 i 行是否为 NULL
+func (c *ColumnVector) IsNull(i int) bool {
+	return c.Nulls != nil && c.Nulls[i]
+}
+
+// reset 清空数据，保留已分配的容量
+func (c *ColumnVector) reset() {
+	c.Int64s = c.Int64s[:0]
+	c.Uint64s = c.Uint64s[:0]
+	c.Float64s = c.Float64s[:0]
+	c.Bools = c.Bools[:0]
+	c.Nulls = nil
+}
+
+// appendNull 追加一个 NULL 值
+func (c *ColumnVector) appendNull(row int) {
+	if c.Nulls == nil {
+		c.Nulls = make([]bool, row, row+1)
+	}
+	c.Nulls = append(c.Nulls, true)
+
+	switch {
+	case isVectorInt64(c.Type):
+		c.Int64s = append(c.Int64s, 0)
+	case isVectorUint64(c.Type):
+		c.Uint64s = append(c.Uint64s, 0)
+	case c.Type == Float32 || c.Type == Float64:
+		c.Float64s = append(c.Float64s, 0)
+	case c.Type == Bool:
+		c.Bools = append(c.Bools, false)
+	}
+}
+
+// appendValue 从字段的二进制编码追加一个值（不经过 interface{} 装箱）
+func (c *ColumnVector) appendValue(data []byte) error {
+	need := vectorFieldSize(c.Type)
+	if len(data) < need {
+		return fmt.Errorf("field %s: expected %d bytes, got %d", c.Name, need, len(data))
+	}
+
+	switch c.Type {
+	case Int, Int64, Time, Duration:
+		c.Int64s = append(c.Int64s, int64(binary.LittleEndian.Uint64(data)))
+	case Int8:
+		c.Int64s = append(c.Int64s, int64(int8(data[0])))
+	case Int16:
+		c.Int64s = append(c.Int64s, int64(int16(binary.LittleEndian.Uint16(data))))
+	case Int32, Rune:
+		c.Int64s = append(c.Int64s, int64(int32(binary.LittleEndian.Uint32(data))))
+	case Uint, Uint64:
+		c.Uint64s = append(c.Uint64s, binary.LittleEndian.Uint64(data))
+	case Uint8, Byte:
+		c.Uint64s = append(c.Uint64s, uint64(data[0]))
+	case Uint16:
+		c.Uint64s = append(c.Uint64s, uint64(binary.LittleEndian.Uint16(data)))
+	case Uint32:
+		c.Uint64s = append(c.Uint64s, uint64(binary.LittleEndian.Uint32(data)))
+	case Float32:
+		c.Float64s = append(c.Float64s, float64(math.Float32frombits(binary.LittleEndian.Uint32(data))))
+	case Float64:
+		c.Float64s = append(c.Float64s, math.Float64frombits(binary.LittleEndian.Uint64(data)))
+	case Bool:
+		c.Bools = append(c.Bools, data[0] != 0)
+	}
+
+	if c.Nulls != nil {
+		c.Nulls = append(c.Nulls, false)
+	}
+	return nil
+}
+
+// isVectorInt64 是否使用 Int64s 存储
+func isVectorInt64(t FieldType) bool {
+	switch t {
+	case Int, Int8, Int16, Int32, Int64, Rune, Time, Duration:
+		return true
+	}
+	return false
+}
+
+// isVectorUint64 是否使用 Uint64s 存储
+func isVectorUint64(t FieldType) bool {
+	switch t {
+	case Uint, Uint8, Uint16, Uint32, Uint64, Byte:
+		return true
+	}
+	return false
+}
+
+// vectorFieldSize 定长字段的编码字节数，不支持向量化的类型返回 0
+func vectorFieldSize(t FieldType) int {
+	switch t {
+	case Int8, Uint8, Byte, Bool:
+		return 1
+	case Int16, Uint16:
+		return 2
+	case Int32, Rune, Uint32, Float32:
+		return 4
+	case Int, Int64, Uint, Uint64, Float64, Time, Duration:
+		return 8
+	}
+	return 0
+}
+
+// Batch 一批行的列式数据
+type Batch struct {
+	Seqs    []int64         // 每行的 _seq
+	Times   []int64         // 每行的 _time
+	Columns []*ColumnVector // 按 BatchRows 请求的列顺序
+}
+
+// Len 批内行数
+func (b *Batch) Len() int {
+	return len(b.Seqs)
+}
+
+// Column 按字段名获取列，不存在时返回 nil
+func (b *Batch) Column(name string) *ColumnVector {
+	for _, col := range b.Columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// reset 清空数据，保留已分配的容量
+func (b *Batch) reset() {
+	b.Seqs = b.Seqs[:0]
+	b.Times = b.Times[:0]
+	for _, col := range b.Columns {
+		col.reset()
+	}
+}
+
+// batchSource BatchRows 的一个数据源（MemTable 或 SST 文件）
+type batchSource struct {
+	keys  []int64
+	index int
+
+	// SST 文件数据位置（与 keys 对齐），MemTable 数据源为 nil
+	reader  *SSTableReader
+	offsets []int64
+	sizes   []int32
+}
+
+// BatchRows 列式批量扫描结果
+//
+// 数值列直接从二进制编码解码到类型化的切片中，避免逐行的 map 与 interface{} 装箱，
+// 适合求和、均值等分析型扫描。Batch() 返回的数据在下一次调用 Next() 前有效。
+// 扫描期间表被 Clean 或 Close 时，Next() 返回 false，Err() 返回 ErrTableReset 或 ErrTableClosed。
+type BatchRows struct {
+	table     *Table
+	qb        *QueryBuilder
+	epoch     int64 // 创建时表的 epoch，用于检测 Clean/Close
+	ref       bool  // 是否计入表的未关闭迭代器数量
+	batchSize int
+	colIdx    []int // 每个请求列在 Schema 中的下标
+	colPos    []int // 每个请求列在 ROW2 中的位置（见 fieldPositions）
+
+	sources     []*batchSource
+	scanning    []*SSTableReader
+	snap        *Snapshot // 读取期间持有的快照引用（见 Table.Snapshot）
+	snapshotSeq int64     // 创建时的最大 seq，更大的 seq 不可见
+	lastSeq     int64
+	scratch     SSTableRow // 过滤条件求值时复用的行
+
+	batch         Batch
+	skippedCount  int
+	returnedCount int
+	err           error
+	closed        bool
+}
+
+// BatchRows 以列式批量方式扫描数值列
+//
+// columns 只能包含整数、浮点、布尔、Time 与 Duration 类型的字段；
+// batchSize <= 0 时使用 DefaultBatchSize。
+// 支持 Where 条件、Offset 与 Limit，不支持 OrderBy（结果按 _seq 升序）。
+func (qb *QueryBuilder) BatchRows(columns []string, batchSize int) (*BatchRows, error) {
+	if qb.table == nil {
+		return nil, fmt.Errorf("table is nil")
+	}
+	if qb.orderBy != "" {
+		return nil, NewErrorf(ErrCodeInvalidParam, "BatchRows does not support OrderBy")
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	// 快照查询期间快照固定的文件不会被删除
+	if !qb.snap.acquire() {
+		return nil, errSnapshotReleased
+	}
+	defer qb.snap.release()
+
+	t := qb.table
+	epoch := qb.readEpoch()
+	done, err := t.beginRead(epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	br := &BatchRows{
+		table:     t,
+		qb:        qb,
+		epoch:     epoch,
+		batchSize: batchSize,
+		lastSeq:   -1,
+	}
+
+	// 1. 解析列
+	for _, name := range columns {
+		idx := -1
+		for i, f := range t.schema.Load().Fields {
+			if f.Name == name {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, NewErrorf(ErrCodeFieldNotFound, "field %s not found", name)
+		}
+		field := t.schema.Load().Fields[idx]
+		if vectorFieldSize(field.Type) == 0 {
+			return nil, NewErrorf(ErrCodeFieldTypeMismatch, "field %s of type %s cannot be vectorized", name, field.Type)
+		}
+		br.colIdx = append(br.colIdx, idx)
+		br.batch.Columns = append(br.batch.Columns, &ColumnVector{Name: field.Name, Type: field.Type})
+	}
+
+	positions := fieldPositions(t.schema.Load())
+	for _, idx := range br.colIdx {
+		br.colPos = append(br.colPos, positions[idx])
+	}
+
+	// 2. MemTable 数据源（与 Rows 相同，创建时固定快照）
+	br.snapshotSeq = qb.readSeq()
+	for _, mt := range qb.memtables() {
+		br.sources = append(br.sources, &batchSource{keys: mt.Keys()})
+	}
+
+	// 3. SST 数据源：一次性收集数据位置，后续按块顺序读取（跳过列统计信息不匹配的文件）
+	readers := t.sstManager.GetReaders()
+	if qb.snap != nil {
+		readers = qb.snap.readers
+	}
+	for _, reader := range readers {
+		if !reader.mayMatch(qb.conds) {
+			continue
+		}
+		reader.beginScan()
+		br.scanning = append(br.scanning, reader)
+
+		src := &batchSource{reader: reader}
+		reader.ForEach(func(key, dataOffset int64, dataSize int32) bool {
+			src.keys = append(src.keys, key)
+			src.offsets = append(src.offsets, dataOffset)
+			src.sizes = append(src.sizes, dataSize)
+			return true
+		})
+		br.sources = append(br.sources, src)
+	}
+	br.ref = true
+	t.iterators.Add(1)
+	br.snap = qb.snap.retain()
+
+	return br, nil
+}
+
+// Next 读取下一批数据，没有更多数据时返回 false
+func (br *BatchRows) Next() bool {
+	if br.closed || br.err != nil {
+		return false
+	}
+	if err := br.qb.canceled(); err != nil {
+		br.err = err
+		br.release()
+		return false
+	}
+
+	// 持有读锁，期间 Clean/Close 会等待（SST 数据可能是映射内存）
+	done, err := br.table.beginRead(br.epoch)
+	if err != nil {
+		br.err = err
+		br.release()
+		return false
+	}
+	defer done()
+
+	br.batch.reset()
+	for br.batch.Len() < br.batchSize {
+		if br.qb.limit > 0 && br.returnedCount >= br.qb.limit {
+			break
+		}
+
+		seq, data, ok := br.nextRaw()
+		if !ok {
+			break
+		}
+		if data == nil {
+			continue
+		}
+
+		// 过滤条件与行级策略需要完整的行
+		if br.qb.filtered() {
+			if err := decodeSSTableRowBinaryInto(data, br.table.schema.Load(), nil, &br.scratch); err != nil {
+				if IsError(err, ErrCodeChecksumMismatch) {
+					br.err = err
+					br.release()
+					return false
+				}
+				continue
+			}
+			if !br.qb.matchRow(&br.scratch) {
+				continue
+			}
+		}
+
+		if br.qb.offset > 0 && br.skippedCount < br.qb.offset {
+			br.skippedCount++
+			continue
+		}
+
+		if err := br.appendRow(seq, data); err != nil {
+			br.err = err
+			return false
+		}
+		br.returnedCount++
+	}
+
+	if br.batch.Len() == 0 {
+		br.release()
+		return false
+	}
+	return true
+}
+
+// nextRaw 按 seq 升序归并所有数据源，返回下一行的原始编码
+// data 为 nil 表示该行读取失败（已被跳过）
+func (br *BatchRows) nextRaw() (seq int64, data []byte, ok bool) {
+	for {
+		var min *batchSource
+		for _, src := range br.sources {
+			if src.index < len(src.keys) && (min == nil || src.keys[src.index] < min.keys[min.index]) {
+				min = src
+			}
+		}
+		if min == nil {
+			return 0, nil, false
+		}
+
+		i := min.index
+		min.index++
+		seq = min.keys[i]
+
+		// 同一 seq 可能同时存在于 MemTable 和 SST（flush 过程中），只取一次
+		if seq == br.lastSeq || seq > br.snapshotSeq {
+			continue
+		}
+		br.lastSeq = seq
+
+		// 被修改过的行：数据源中的可能是旧版本，按 seq 读取最新版本（已删除的跳过）
+		// （内容被修改的行继续按原方式读取，解码时报告错误）
+		if br.table.mutated(seq) {
+			row, err := br.qb.getRow(seq)
+			if err == nil {
+				raw, err := encodeSSTableRowBinary(row, br.table.schema.Load())
+				if err != nil {
+					return seq, nil, true
+				}
+				return seq, raw, true
+			}
+			if !IsError(err, ErrCodeChecksumMismatch) {
+				return seq, nil, true
+			}
+		}
+
+		if min.reader != nil {
+			raw, err := min.reader.src.Slice(min.offsets[i], int(min.sizes[i]))
+			if err != nil {
+				return seq, nil, true
+			}
+			return seq, raw, true
+		}
+
+		// MemTable 可能已被 flush，回退到 SST 查找
+		raw, err := br.qb.rawRow(seq)
+		if err != nil {
+			return seq, nil, true
+		}
+		return seq, raw, true
+	}
+}
+
+// appendRow 将一行的请求列追加到当前批
+func (br *BatchRows) appendRow(seq int64, data []byte) error {
+	layout, err := parseRowLayout(data)
+	if err != nil {
+		return NewErrorf(ErrCodeDecodeFailed, "invalid row encoding for seq %d", seq)
+	}
+	if err := layout.checkSchema(br.table.schema.Load()); err != nil {
+		return NewError(ErrCodeDecodeFailed, err)
+	}
+
+	row := br.batch.Len()
+	br.batch.Seqs = append(br.batch.Seqs, seq)
+	br.batch.Times = append(br.batch.Times, int64(binary.LittleEndian.Uint64(data[12:20])))
+
+	for c, fi := range br.colIdx {
+		col := br.batch.Columns[c]
+		if fi >= layout.fieldCount {
+			// 旧数据中不存在该字段
+			col.appendNull(row)
+			continue
+		}
+
+		// ROW1 中字段按下标占用字段表项，ROW2 中打包的字段从位中读取
+		var raw []byte
+		switch pos := br.colPos[c]; {
+		case !layout.packed:
+			raw, err = layout.slot(data, fi)
+		case pos < 0:
+			value, ok := layout.packedBool(-pos - 1)
+			if ok {
+				raw = boolEncoding(value)
+			}
+		default:
+			raw, err = layout.slot(data, pos)
+		}
+		if err != nil {
+			return NewErrorf(ErrCodeDecodeFailed, "field %s out of range for seq %d", col.Name, seq)
+		}
+		if len(raw) == 0 {
+			col.appendNull(row)
+			continue
+		}
+		if err := col.appendValue(raw); err != nil {
+			return NewError(ErrCodeDecodeFailed, err)
+		}
+	}
+	return nil
+}
+
+// Batch 返回当前批，数据在下一次调用 Next() 前有效
+func (br *BatchRows) Batch() *Batch {
+	return &br.batch
+}
+
+// Err 返回扫描过程中的错误
+func (br *BatchRows) Err() error {
+	return br.err
+}
+
+// Close 关闭扫描
+func (br *BatchRows) Close() error {
+	br.closed = true
+	br.release()
+	return nil
+}
+
+// release 结束对 SST 文件的顺序扫描
+func (br *BatchRows) release() {
+	if br.ref {
+		br.ref = false
+		br.table.iterators.Add(-1)
+	}
+	for _, reader := range br.scanning {
+		reader.endScan(br.qb.bypass)
+	}
+	br.scanning = nil
+	if br.snap != nil {
+		br.snap.release()
+		br.snap = nil
+	}
+}
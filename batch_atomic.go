@@ -0,0 +1,273 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// 提交记录文件中的记录类型（使用 WAL 的记录格式）
+const (
+	batchLogCommit = 1 // 批次已提交，Seq 为批次编号
+	batchLogFloor  = 2 // 已分配的最大批次编号（重写文件时保留，避免编号被复用）
+)
+
+// batchLog 数据库目录下的原子批量写入提交记录（batch.log）
+//
+// 原子批量写入先将各表的行以 WALEntryTypeBatchPut 写入各自的 WAL 并 fsync，再追加提交标记并 fsync；
+// 恢复表时只回放提交记录中有的批次，崩溃时只写入了一部分表的批次整体丢弃。
+// 打开数据库时重写文件，只保留恢复时仍被 WAL 引用的批次。
+type batchLog struct {
+	dir    string
+	faults *FaultInjector
+
+	mu     sync.Mutex // 串行化原子提交
+	wal    *WAL
+	next   int64 // 下一个批次编号
+	closed bool
+
+	stateMu   sync.Mutex
+	committed map[int64]struct{} // 已提交的批次
+	replayed  map[int64]struct{} // 恢复表时回放过的批次
+}
+
+// openBatchLog 读取提交记录，损坏的尾部（写入提交标记时崩溃）视为未提交
+func openBatchLog(dir string, faults *FaultInjector) (*batchLog, error) {
+	l := &batchLog{
+		dir:       dir,
+		faults:    faults,
+		next:      1,
+		committed: make(map[int64]struct{}),
+		replayed:  make(map[int64]struct{}),
+	}
+
+	reader, err := NewWALReader(l.path())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if reader != nil {
+		defer reader.Close()
+		for {
+			entry, err := reader.readEntry()
+			if err != nil {
+				break
+			}
+			if entry.Type == batchLogCommit {
+				l.committed[entry.Seq] = struct{}{}
+			}
+			l.next = max(l.next, entry.Seq+1)
+		}
+	}
+	return l, nil
+}
+
+// path 返回提交记录文件的路径
+func (l *batchLog) path() string {
+	return filepath.Join(l.dir, "batch.log")
+}
+
+// replay 解析 WALEntryTypeBatchPut 记录，返回行编码以及批次是否已提交（l 为 nil 时总是未提交）
+func (l *batchLog) replay(data []byte) ([]byte, bool) {
+	if l == nil || len(data) < 8 {
+		return nil, false
+	}
+	id := int64(binary.LittleEndian.Uint64(data))
+
+	l.stateMu.Lock()
+	defer l.stateMu.Unlock()
+	if _, ok := l.committed[id]; !ok {
+		return nil, false
+	}
+	l.replayed[id] = struct{}{}
+	return data[8:], true
+}
+
+// rewrite 重写提交记录并打开用于追加，prune 为 true 时只保留恢复时回放过的批次
+//
+// 表打开失败时不能裁剪：这些表的 WAL 中可能还有已提交的批次
+func (l *batchLog) rewrite(prune bool) error {
+	l.stateMu.Lock()
+	keep := l.committed
+	if prune {
+		keep = l.replayed
+		l.committed = maps.Clone(keep)
+	}
+	ids := make([]int64, 0, len(keep))
+	for id := range keep {
+		ids = append(ids, id)
+	}
+	l.stateMu.Unlock()
+	slices.Sort(ids)
+
+	var buf []byte
+	var w WAL
+	buf = append(buf, w.marshalEntry(&WALEntry{Type: batchLogFloor, Seq: l.next - 1})...)
+	for _, id := range ids {
+		buf = append(buf, w.marshalEntry(&WALEntry{Type: batchLogCommit, Seq: id})...)
+	}
+
+	path := l.path()
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(buf)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(l.dir)
+
+	l.wal, err = OpenWAL(path)
+	return err
+}
+
+// commit 追加提交标记并 fsync，调用者必须持有 mu
+func (l *batchLog) commit(id int64) error {
+	if err := l.faults.check(FaultBatchCommit); err != nil {
+		return err
+	}
+	if err := l.wal.Append(&WALEntry{Type: batchLogCommit, Seq: id}); err != nil {
+		return err
+	}
+	if err := l.wal.Sync(); err != nil {
+		return err
+	}
+	l.stateMu.Lock()
+	l.committed[id] = struct{}{}
+	l.stateMu.Unlock()
+	return nil
+}
+
+// close 等待进行中的提交完成后关闭
+func (l *batchLog) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed || l.wal == nil {
+		l.closed = true
+		return nil
+	}
+	l.closed = true
+	return l.wal.Close()
+}
+
+// Batch 创建跨表原子写入的批量写入：提交时所有表的行要么全部写入，要么全部不写入
+//
+// 用法与 NewBatch 相同，Commit 时：
+//  1. 转换并验证所有行（同 NewBatch）
+//  2. 将各表的行写入各自的 WAL 并 fsync
+//  3. 在数据库的提交记录（batch.log）中追加提交标记并 fsync
+//  4. 将行写入 MemTable 与索引，所有行在此时一起对查询可见
+//
+// 第 3 步之前出错或崩溃时，已写入 WAL 的行在恢复时被忽略，任何表都不会出现这一批的数据。
+// 原子提交之间串行执行，并且每次提交都要 fsync，吞吐量低于 NewBatch；不经过 MaxConcurrentWriters 限制。
+// 写入提交标记后 fsync 失败时返回错误，但恢复后这一批可能可见（与单机 fsync 失败的语义相同）。
+//
+// 原子批量写入的行在 flush 之前依赖提交记录，只能通过 Database 打开表来恢复；
+// 直接使用 OpenTable 打开表目录时，尚未 flush 的原子批量写入的行会被忽略。
+func (db *Database) Batch() *WriteBatch {
+	return &WriteBatch{db: db, atomic: true}
+}
+
+// atomicRow 原子提交中已写入 WAL、等待写入 MemTable 的行
+type atomicRow struct {
+	table   *Table
+	seq     int64
+	rowData []byte
+	row     preparedRow
+}
+
+// commitAtomic 原子地写入已验证的各表数据（见 Database.Batch）
+func (b *WriteBatch) commitAtomic(tables []*writeBatchTable) ([]DurabilityToken, error) {
+	l := b.db.batches
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, NewErrorf(ErrCodeClosed, "database is closed")
+	}
+
+	// 提交期间禁止各表切换 MemTable，避免 WAL 中的记录在写入 MemTable 之前随 flush 被删除；
+	// 按表名加锁，原子提交之间已由 mu 串行化
+	locked := slices.Clone(tables)
+	slices.SortFunc(locked, func(x, y *writeBatchTable) int { return strings.Compare(x.name, y.name) })
+	for _, wt := range locked {
+		wt.table.flushMu.Lock()
+	}
+	unlocked := false
+	unlock := func() {
+		if !unlocked {
+			unlocked = true
+			for _, wt := range locked {
+				wt.table.flushMu.Unlock()
+			}
+		}
+	}
+	defer unlock()
+
+	id := l.next
+	l.next++
+
+	var rows []atomicRow
+	abort := func(err error, format string, args ...any) ([]DurabilityToken, error) {
+		for _, r := range rows {
+			r.table.durability.appended(r.seq)
+		}
+		return nil, WrapError(err, format, args...)
+	}
+
+	// 1. 写入各表的 WAL
+	tokens := make([]DurabilityToken, 0, len(tables))
+	for _, wt := range tables {
+		if len(wt.prepared) == 0 {
+			continue
+		}
+		t := wt.table
+		t.durability.mu.Lock()
+		epoch := t.durability.epoch
+		t.durability.mu.Unlock()
+
+		var seq int64
+		for _, row := range wt.prepared {
+			var rowData []byte
+			var err error
+			if seq, rowData, err = t.appendRow(row.schema, row.converted, row.now, id); err != nil {
+				return abort(err, "write batch to table %s", wt.name)
+			}
+			rows = append(rows, atomicRow{table: t, seq: seq, rowData: rowData, row: row})
+		}
+		tokens = append(tokens, DurabilityToken{Seq: seq, table: t, epoch: epoch})
+	}
+
+	// 2. fsync 各表的 WAL，之后才能写入提交标记
+	for _, tok := range tokens {
+		if err := tok.table.walManager.Sync(); err != nil {
+			return abort(err, "sync wal of table %s", tok.table.schema.Load().Name)
+		}
+	}
+
+	// 3. 提交
+	if err := l.commit(id); err != nil {
+		return abort(err, "commit batch %d", id)
+	}
+
+	// 4. 写入 MemTable 与索引
+	for _, r := range rows {
+		r.table.applyRow(r.seq, r.rowData, r.row.converted, r.row.indexed, r.row.now)
+	}
+	unlock()
+	return tokens, nil
+}
@@ -0,0 +1,140 @@
+package srdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicBatch(t *testing.T) {
+	dir := t.TempDir()
+	faults := NewFaultInjector()
+	opts := DefaultOptions(dir)
+	opts.FaultInjector = faults
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"accounts", "transfers"} {
+		schema, err := NewSchema(name, []Field{{Name: "name", Type: String}, {Name: "amount", Type: Int64}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.CreateTable(name, schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := func(db *Database, table string) int64 {
+		t.Helper()
+		tbl, err := db.GetTable(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := tbl.Query().Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	batch := func(db *Database, name string) *WriteBatch {
+		b := db.Batch()
+		b.Insert("accounts", map[string]any{"name": name, "amount": int64(-10)})
+		b.Insert("transfers", []map[string]any{{"name": name, "amount": int64(10)}, {"name": name, "amount": int64(0)}})
+		return b
+	}
+
+	// 提交成功后两张表同时可见
+	if err := batch(db, "alice").Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if count(db, "accounts") != 1 || count(db, "transfers") != 2 {
+		t.Fatalf("expected committed rows in both tables")
+	}
+
+	// 提交标记写入失败：各表的 WAL 已写入，但都不可见
+	faults.FailNext(FaultBatchCommit, nil)
+	if err := batch(db, "bob").Commit(); !IsError(err, ErrCodeFaultInjected) {
+		t.Fatalf("expected injected commit failure, got %v", err)
+	}
+	// 第二张表写入 WAL 失败
+	faults.FailAfter(FaultWALAppend, 1, nil)
+	if err := batch(db, "carol").Commit(); !IsError(err, ErrCodeFaultInjected) {
+		t.Fatalf("expected injected append failure, got %v", err)
+	}
+	// 验证失败时不写入任何表
+	b := batch(db, "dave")
+	b.Insert("transfers", map[string]any{"name": "dave", "amount": "not a number"})
+	if err := b.Commit(); err == nil {
+		t.Fatal("expected validation failure")
+	}
+	if count(db, "accounts") != 1 || count(db, "transfers") != 2 {
+		t.Fatalf("expected failed batches to leave no rows, got %d and %d", count(db, "accounts"), count(db, "transfers"))
+	}
+
+	if err := batch(db, "erin").CommitDurable(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 崩溃后只恢复已提交的批次
+	if err := db.SimulateCrash(); err != nil {
+		t.Fatal(err)
+	}
+	if db, err = OpenWithOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	if count(db, "accounts") != 2 || count(db, "transfers") != 4 {
+		t.Fatalf("expected two committed batches after recovery, got %d and %d", count(db, "accounts"), count(db, "transfers"))
+	}
+	accounts, _ := db.GetTable("accounts")
+	rows, err := accounts.Query().OrderBy("_seq").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for rows.Next() {
+		names = append(names, rows.Row().Data()["name"].(string))
+	}
+	rows.Close()
+	if len(names) != 2 || names[0] != "alice" || names[1] != "erin" {
+		t.Errorf("unexpected recovered rows %v", names)
+	}
+
+	// 恢复后的写入不复用未提交批次的 seq
+	if err := batch(db, "frank").Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if count(db, "accounts") != 3 {
+		t.Errorf("expected 3 accounts, got %d", count(db, "accounts"))
+	}
+
+	// 正常关闭后 WAL 已 flush，重新打开时提交记录只保留编号水位
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if db, err = OpenWithOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	if count(db, "accounts") != 3 || count(db, "transfers") != 6 {
+		t.Errorf("unexpected rows after reopen: %d and %d", count(db, "accounts"), count(db, "transfers"))
+	}
+	info, err := os.Stat(filepath.Join(dir, "batch.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != WALEntryHeaderSize {
+		t.Errorf("expected pruned batch log, got %d bytes", info.Size())
+	}
+	if db.batches.next != 6 {
+		t.Errorf("expected batch numbering to continue at 6, got %d", db.batches.next)
+	}
+
+	// 关闭后提交失败
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch(db, "grace").Commit(); !IsError(err, ErrCodeClosed) {
+		t.Errorf("expected closed error, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package srdb
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// openBatchTestTable 创建用于 BatchRows 测试的表
+func openBatchTestTable(t *testing.T, dir string) *Table {
+	t.Helper()
+
+	table, err := OpenTable(&TableOptions{
+		Dir:  dir,
+		Name: "metrics",
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "value", Type: Int64},
+			{Name: "ratio", Type: Float32},
+			{Name: "count", Type: Uint16},
+			{Name: "ok", Type: Bool},
+			{Name: "delay", Type: Duration},
+			{Name: "extra", Type: Int32, Nullable: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestBatchRows(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestBatchRows")
+	defer os.RemoveAll(tmpDir)
+
+	table := openBatchTestTable(t, tmpDir)
+	defer table.Close()
+
+	insert := func(from, to int) {
+		for i := from; i < to; i++ {
+			data := map[string]any{
+				"name":  "m",
+				"value": int64(i),
+				"ratio": float32(i) / 2,
+				"count": uint16(i),
+				"ok":    i%2 == 0,
+				"delay": time.Duration(i) * time.Millisecond,
+			}
+			if i%3 == 0 {
+				data["extra"] = int32(-i)
+			}
+			if err := table.Insert(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 一半数据落盘到 SST，一半保留在 MemTable
+	insert(0, 150)
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	insert(150, 300)
+
+	br, err := table.Query().BatchRows([]string{"value", "ratio", "count", "ok", "delay", "extra"}, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+
+	var (
+		total   int
+		batches int
+		sum     int64
+		lastSeq int64
+	)
+	for br.Next() {
+		b := br.Batch()
+		batches++
+		if b.Len() > 64 {
+			t.Fatalf("batch size %d exceeds 64", b.Len())
+		}
+
+		value := b.Column("value")
+		ratio := b.Column("ratio")
+		count := b.Column("count")
+		ok := b.Column("ok")
+		delay := b.Column("delay")
+		extra := b.Column("extra")
+
+		for i := 0; i < b.Len(); i++ {
+			if b.Seqs[i] <= lastSeq {
+				t.Fatalf("seqs not ascending: %d after %d", b.Seqs[i], lastSeq)
+			}
+			lastSeq = b.Seqs[i]
+
+			v := value.Int64s[i]
+			sum += v
+			if ratio.Float64s[i] != float64(v)/2 {
+				t.Errorf("ratio[%d] = %v, want %v", v, ratio.Float64s[i], float64(v)/2)
+			}
+			if count.Uint64s[i] != uint64(v) {
+				t.Errorf("count[%d] = %d", v, count.Uint64s[i])
+			}
+			if ok.Bools[i] != (v%2 == 0) {
+				t.Errorf("ok[%d] = %v", v, ok.Bools[i])
+			}
+			if delay.Int64s[i] != int64(time.Duration(v)*time.Millisecond) {
+				t.Errorf("delay[%d] = %d", v, delay.Int64s[i])
+			}
+			if v%3 == 0 {
+				if extra.IsNull(i) || extra.Int64s[i] != -v {
+					t.Errorf("extra[%d] = %d (null=%v), want %d", v, extra.Int64s[i], extra.IsNull(i), -v)
+				}
+			} else if !extra.IsNull(i) {
+				t.Errorf("extra[%d] should be NULL", v)
+			}
+		}
+		total += b.Len()
+	}
+	if err := br.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 300 {
+		t.Errorf("expected 300 rows, got %d", total)
+	}
+	if batches != 5 {
+		t.Errorf("expected 5 batches, got %d", batches)
+	}
+	if want := int64(299 * 300 / 2); sum != want {
+		t.Errorf("sum = %d, want %d", sum, want)
+	}
+}
+
+func TestBatchRowsWithConditions(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestBatchRowsWithConditions")
+	defer os.RemoveAll(tmpDir)
+
+	table := openBatchTestTable(t, tmpDir)
+	defer table.Close()
+
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"name": "m", "value": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	br, err := table.Query().Gte("value", int64(50)).Offset(10).Limit(20).BatchRows([]string{"value"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+
+	var values []int64
+	for br.Next() {
+		values = append(values, br.Batch().Column("value").Int64s...)
+	}
+	if len(values) != 20 {
+		t.Fatalf("expected 20 rows, got %d", len(values))
+	}
+	if values[0] != 60 || values[19] != 79 {
+		t.Errorf("unexpected range: %d..%d", values[0], values[19])
+	}
+}
+
+func TestBatchRowsInvalidColumns(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestBatchRowsInvalidColumns")
+	defer os.RemoveAll(tmpDir)
+
+	table := openBatchTestTable(t, tmpDir)
+	defer table.Close()
+
+	if _, err := table.Query().BatchRows([]string{"missing"}, 0); !IsError(err, ErrCodeFieldNotFound) {
+		t.Errorf("expected ErrCodeFieldNotFound, got %v", err)
+	}
+	if _, err := table.Query().BatchRows([]string{"name"}, 0); !IsError(err, ErrCodeFieldTypeMismatch) {
+		t.Errorf("expected ErrCodeFieldTypeMismatch, got %v", err)
+	}
+	if _, err := table.Query().OrderBy("value").BatchRows([]string{"value"}, 0); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+}
+
+// BenchmarkBatchRowsSum 对比列式批量扫描与逐行扫描的求和
+func BenchmarkBatchRowsSum(b *testing.B) {
+	tmpDir, _ := os.MkdirTemp("", "BenchmarkBatchRowsSum")
+	defer os.RemoveAll(tmpDir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:    tmpDir,
+		Name:   "metrics",
+		Fields: []Field{{Name: "value", Type: Int64}},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 10000 {
+		table.Insert(map[string]any{"value": int64(i)})
+	}
+
+	b.Run("BatchRows", func(b *testing.B) {
+		for b.Loop() {
+			br, _ := table.Query().BatchRows([]string{"value"}, 0)
+			var sum int64
+			for br.Next() {
+				for _, v := range br.Batch().Column("value").Int64s {
+					sum += v
+				}
+			}
+			br.Close()
+		}
+	})
+
+	b.Run("Rows", func(b *testing.B) {
+		for b.Loop() {
+			rows, _ := table.Query().Rows()
+			var sum int64
+			for rows.Next() {
+				sum += rows.Row().Data()["value"].(int64)
+			}
+			rows.Close()
+		}
+	})
+}
@@ -0,0 +1,425 @@
+// Package bench 为 srdb 表生成可配置的负载（行大小分布、读写比例、并发数），
+// 报告各类操作的吞吐量与延迟分位数，用于在自己的硬件上验证配置调优与版本差异。
+//
+//	schema, _ := srdb.NewSchema("bench", bench.Fields())
+//	table, _ := db.CreateTable("bench", schema)
+//	report, err := bench.Run(ctx, table, bench.Config{
+//	    Duration:    30 * time.Second,
+//	    Concurrency: 8,
+//	    Mix:         bench.Mix{Insert: 80, Get: 15, Scan: 5},
+//	    RowSize:     256,
+//	    RowSizeMax:  4096,
+//	    RowSizeDist: bench.Exponential,
+//	})
+//	fmt.Println(report)
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hupeh/srdb"
+)
+
+// Op 操作类型
+type Op string
+
+const (
+	OpInsert     Op = "insert" // 插入（BatchSize 行为一次操作）
+	OpGet        Op = "get"    // 按 seq 随机点查
+	OpScan       Op = "scan"   // 全表扫描前 ScanLimit 行
+	OpIndexQuery Op = "index"  // 索引字段等值查询，最多读取 ScanLimit 行
+)
+
+// ops 报告中操作的顺序
+var ops = []Op{OpInsert, OpGet, OpScan, OpIndexQuery}
+
+// Mix 各类操作的权重（按比例随机选择，权重为 0 的操作不执行）
+type Mix struct {
+	Insert     int
+	Get        int
+	Scan       int
+	IndexQuery int
+}
+
+func (m Mix) weight(op Op) int {
+	switch op {
+	case OpInsert:
+		return m.Insert
+	case OpGet:
+		return m.Get
+	case OpScan:
+		return m.Scan
+	case OpIndexQuery:
+		return m.IndexQuery
+	}
+	return 0
+}
+
+func (m Mix) total() int {
+	return m.Insert + m.Get + m.Scan + m.IndexQuery
+}
+
+// String 返回 ParseMix 可以解析的格式
+func (m Mix) String() string {
+	var parts []string
+	for _, op := range ops {
+		if w := m.weight(op); w > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", op, w))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseMix 解析操作权重，格式为 "insert=80,get=15,scan=5,index=0"
+func ParseMix(s string) (Mix, error) {
+	var m Mix
+	for part := range strings.SplitSeq(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Mix{}, fmt.Errorf("invalid mix entry %q, expected op=weight", part)
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || w < 0 {
+			return Mix{}, fmt.Errorf("invalid weight for %s: %q", name, value)
+		}
+		switch Op(strings.TrimSpace(name)) {
+		case OpInsert:
+			m.Insert = w
+		case OpGet:
+			m.Get = w
+		case OpScan:
+			m.Scan = w
+		case OpIndexQuery:
+			m.IndexQuery = w
+		default:
+			return Mix{}, fmt.Errorf("unknown op %q, expected one of %v", name, ops)
+		}
+	}
+	if m.total() == 0 {
+		return Mix{}, fmt.Errorf("mix %q has no positive weight", s)
+	}
+	return m, nil
+}
+
+// SizeDistribution 行大小（payload 字节数）分布
+type SizeDistribution int
+
+const (
+	Fixed       SizeDistribution = iota // 固定为 RowSize
+	Uniform                             // RowSize 与 RowSizeMax 之间均匀分布
+	Exponential                         // 均值为 RowSize 的指数分布，截断到 RowSizeMax（大量小行与少量大行）
+)
+
+// String 返回 ParseSizeDistribution 可以解析的名称
+func (d SizeDistribution) String() string {
+	switch d {
+	case Fixed:
+		return "fixed"
+	case Uniform:
+		return "uniform"
+	case Exponential:
+		return "exp"
+	}
+	return fmt.Sprintf("SizeDistribution(%d)", int(d))
+}
+
+// ParseSizeDistribution 解析行大小分布名称：fixed、uniform、exp
+func ParseSizeDistribution(s string) (SizeDistribution, error) {
+	switch strings.ToLower(s) {
+	case "fixed":
+		return Fixed, nil
+	case "uniform":
+		return Uniform, nil
+	case "exp", "exponential":
+		return Exponential, nil
+	}
+	return 0, fmt.Errorf("unknown size distribution %q, expected fixed, uniform or exp", s)
+}
+
+// Config 负载配置
+type Config struct {
+	Duration    time.Duration // 运行时长；与 Operations 都为 0 时默认 10s
+	Operations  int64         // 总操作数（> 0 时达到后停止，先到者为准）
+	Concurrency int           // 并发 worker 数，默认 runtime.NumCPU()
+	Mix         Mix           // 操作权重，默认只插入
+
+	RowSize     int              // 行大小（payload 字节数），默认 256
+	RowSizeMax  int              // Uniform/Exponential 的上限，默认 RowSize 的 4 倍
+	RowSizeDist SizeDistribution // 行大小分布，默认 Fixed
+	BatchSize   int              // 每次插入的行数，默认 1
+
+	Categories int // category 字段（索引）的不同值数量，默认 100
+	ScanLimit  int // Scan/IndexQuery 每次最多读取的行数，默认 100
+	Preload    int // 开始计时前写入的行数；表为空且包含读操作时默认 10000
+	Seed       uint64
+}
+
+// withDefaults 返回填充默认值后的配置
+func (c Config) withDefaults() Config {
+	if c.Duration == 0 && c.Operations == 0 {
+		c.Duration = 10 * time.Second
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = runtime.NumCPU()
+	}
+	if c.Mix.total() == 0 {
+		c.Mix = Mix{Insert: 1}
+	}
+	if c.RowSize <= 0 {
+		c.RowSize = 256
+	}
+	if c.RowSizeMax <= 0 {
+		c.RowSizeMax = c.RowSize * 4
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 1
+	}
+	if c.Categories <= 0 {
+		c.Categories = 100
+	}
+	if c.ScanLimit <= 0 {
+		c.ScanLimit = 100
+	}
+	return c
+}
+
+func (c Config) validate() error {
+	if c.Duration < 0 || c.Operations < 0 || c.Preload < 0 {
+		return fmt.Errorf("duration, operations and preload must not be negative")
+	}
+	if c.Mix.Insert < 0 || c.Mix.Get < 0 || c.Mix.Scan < 0 || c.Mix.IndexQuery < 0 {
+		return fmt.Errorf("mix weights must not be negative: %+v", c.Mix)
+	}
+	if c.RowSizeDist != Fixed && c.RowSizeMax < c.RowSize {
+		return fmt.Errorf("RowSizeMax %d is less than RowSize %d", c.RowSizeMax, c.RowSize)
+	}
+	if c.RowSizeDist < Fixed || c.RowSizeDist > Exponential {
+		return fmt.Errorf("unknown size distribution %d", c.RowSizeDist)
+	}
+	return nil
+}
+
+// Fields 返回负载使用的字段：category（索引）、value、payload
+func Fields() []srdb.Field {
+	return []srdb.Field{
+		{Name: "category", Type: srdb.String, Indexed: true, Comment: "分类（IndexQuery 使用）"},
+		{Name: "value", Type: srdb.Float64, Comment: "随机数值"},
+		{Name: "payload", Type: srdb.String, Comment: "随机内容，长度按行大小分布生成"},
+	}
+}
+
+// Run 对 table 执行负载直到 ctx 取消、达到 Duration 或 Operations，返回统计报告
+//
+// table 必须包含 Fields 返回的字段。操作失败不会中止运行，计入报告的错误数。
+func Run(ctx context.Context, table *srdb.Table, cfg Config) (*Report, error) {
+	if table == nil {
+		return nil, fmt.Errorf("table is nil")
+	}
+	cfg = cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	schema := table.GetSchema()
+	for _, f := range Fields() {
+		if _, err := schema.GetField(f.Name); err != nil {
+			return nil, fmt.Errorf("table %s is missing bench field %q, create it with bench.Fields()", schema.Name, f.Name)
+		}
+	}
+
+	r := &runner{table: table, cfg: cfg, payload: newPayload(max(cfg.RowSize, cfg.RowSizeMax))}
+
+	// 预先写入数据，保证读操作有数据可读
+	preload := cfg.Preload
+	if preload == 0 && table.GetMaxSeq() == 0 && cfg.Mix.total() > cfg.Mix.Insert {
+		preload = 10000
+	}
+	if preload > 0 {
+		w := r.newWorker(0)
+		for preload > 0 {
+			n := min(preload, 1000)
+			if err := table.Insert(w.rows(n)); err != nil {
+				return nil, fmt.Errorf("preload: %w", err)
+			}
+			preload -= n
+		}
+	}
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+	r.remaining.Store(cfg.Operations)
+
+	workers := make([]*worker, cfg.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range workers {
+		workers[i] = r.newWorker(uint64(i + 1))
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			w.run(ctx)
+		}(workers[i])
+	}
+	wg.Wait()
+
+	return newReport(cfg, time.Since(start), workers), nil
+}
+
+// runner 一次负载运行的共享状态
+type runner struct {
+	table     *srdb.Table
+	cfg       Config
+	payload   string       // 随机内容，行的 payload 取其子串
+	remaining atomic.Int64 // 剩余操作数（Operations > 0 时）
+}
+
+// newPayload 生成长度为 n 的随机可打印内容
+func newPayload(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	rng := rand.New(rand.NewPCG(1, 2))
+	buf := make([]byte, n*2)
+	for i := range buf {
+		buf[i] = letters[rng.IntN(len(letters))]
+	}
+	return string(buf)
+}
+
+// worker 单个并发 worker 的状态与统计
+type worker struct {
+	r        *runner
+	rng      *rand.Rand
+	hists    map[Op]*histogram
+	errors   map[Op]int64
+	err      error // 第一个错误
+	inserted int64 // 插入的行数
+	bytes    int64 // 插入的 payload 字节数
+}
+
+func (r *runner) newWorker(id uint64) *worker {
+	w := &worker{
+		r:      r,
+		rng:    rand.New(rand.NewPCG(r.cfg.Seed, id)),
+		hists:  make(map[Op]*histogram),
+		errors: make(map[Op]int64),
+	}
+	for _, op := range ops {
+		w.hists[op] = &histogram{}
+	}
+	return w
+}
+
+func (w *worker) run(ctx context.Context) {
+	limited := w.r.cfg.Operations > 0
+	for ctx.Err() == nil {
+		if limited && w.r.remaining.Add(-1) < 0 {
+			return
+		}
+		op := w.pick()
+		start := time.Now()
+		err := w.do(op)
+		w.hists[op].record(time.Since(start))
+		if err != nil && ctx.Err() == nil {
+			w.errors[op]++
+			if w.err == nil {
+				w.err = fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+}
+
+// pick 按权重随机选择操作
+func (w *worker) pick() Op {
+	mix := w.r.cfg.Mix
+	n := w.rng.IntN(mix.total())
+	for _, op := range ops {
+		if n < mix.weight(op) {
+			return op
+		}
+		n -= mix.weight(op)
+	}
+	return OpInsert
+}
+
+func (w *worker) do(op Op) error {
+	table := w.r.table
+	switch op {
+	case OpInsert:
+		return table.Insert(w.rows(w.r.cfg.BatchSize))
+
+	case OpGet:
+		maxSeq := table.GetMaxSeq()
+		if maxSeq <= 0 {
+			return nil
+		}
+		_, err := table.Get(w.rng.Int64N(maxSeq) + 1)
+		return err
+
+	case OpScan:
+		return w.drain(table.Query().Limit(w.r.cfg.ScanLimit))
+
+	case OpIndexQuery:
+		return w.drain(table.Query().Eq("category", w.category()).Limit(w.r.cfg.ScanLimit))
+	}
+	return fmt.Errorf("unknown op %q", op)
+}
+
+// drain 读取查询的所有结果
+func (w *worker) drain(qb *srdb.QueryBuilder) error {
+	rows, err := qb.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		_ = rows.Row().Data()
+	}
+	return rows.Err()
+}
+
+// rows 生成 n 行随机数据
+func (w *worker) rows(n int) []map[string]any {
+	rows := make([]map[string]any, n)
+	for i := range rows {
+		size := w.rowSize()
+		offset := w.rng.IntN(len(w.r.payload) - size + 1)
+		rows[i] = map[string]any{
+			"category": w.category(),
+			"value":    w.rng.Float64() * 1000,
+			"payload":  w.r.payload[offset : offset+size],
+		}
+		w.inserted++
+		w.bytes += int64(size)
+	}
+	return rows
+}
+
+func (w *worker) category() string {
+	return fmt.Sprintf("c%04d", w.rng.IntN(w.r.cfg.Categories))
+}
+
+// rowSize 按分布生成行大小
+func (w *worker) rowSize() int {
+	cfg := w.r.cfg
+	switch cfg.RowSizeDist {
+	case Uniform:
+		return cfg.RowSize + w.rng.IntN(cfg.RowSizeMax-cfg.RowSize+1)
+	case Exponential:
+		return min(max(int(w.rng.ExpFloat64()*float64(cfg.RowSize)), 1), cfg.RowSizeMax)
+	default:
+		return cfg.RowSize
+	}
+}
@@ -0,0 +1,116 @@
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hupeh/srdb"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	var h histogram
+	for i := 1; i <= 10000; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	for _, c := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0.5, 5000 * time.Microsecond},
+		{0.99, 9900 * time.Microsecond},
+		{1, 10000 * time.Microsecond},
+	} {
+		got := h.percentile(c.p)
+		if diff := (got - c.want).Abs(); diff > c.want/100 {
+			t.Errorf("p%v = %v, want %v ±1%%", c.p*100, got, c.want)
+		}
+	}
+
+	for _, v := range []uint64{0, 127, 128, 1000, 1 << 40, 1<<64 - 1} {
+		if i := histBucket(v); i < 0 || i >= histBuckets {
+			t.Errorf("bucket of %d out of range: %d", v, i)
+		}
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	m, err := ParseMix("insert=80, get=15,scan=5,index=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != (Mix{Insert: 80, Get: 15, Scan: 5}) {
+		t.Errorf("unexpected mix %+v", m)
+	}
+	if m.String() != "insert=80,get=15,scan=5" {
+		t.Errorf("unexpected String() %q", m.String())
+	}
+
+	for _, s := range []string{"", "insert=0", "insert", "insert=-1", "delete=1"} {
+		if _, err := ParseMix(s); err == nil {
+			t.Errorf("ParseMix(%q) should fail", s)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	table, err := srdb.OpenTable(&srdb.TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "bench",
+		Fields: Fields(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	report, err := Run(context.Background(), table, Config{
+		Operations:  400,
+		Concurrency: 4,
+		Mix:         Mix{Insert: 2, Get: 1, Scan: 1, IndexQuery: 1},
+		RowSize:     16,
+		RowSizeMax:  64,
+		RowSizeDist: Uniform,
+		BatchSize:   2,
+		Preload:     100,
+		Categories:  10,
+		ScanLimit:   10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Operations != 400 || report.Errors != 0 {
+		t.Fatalf("unexpected report: operations=%d errors=%d err=%v", report.Operations, report.Errors, report.Err)
+	}
+	if len(report.Ops) != 4 {
+		t.Fatalf("expected 4 op reports, got %d", len(report.Ops))
+	}
+	for _, op := range report.Ops {
+		if op.Count == 0 || op.P50 > op.P99 || op.P99 > op.Max {
+			t.Errorf("inconsistent op report %+v", op)
+		}
+	}
+	if want := report.Ops[0].Count * 2; report.RowsInserted != want {
+		t.Errorf("expected %d rows inserted, got %d", want, report.RowsInserted)
+	}
+	if report.BytesWritten < report.RowsInserted*16 || report.BytesWritten > report.RowsInserted*64 {
+		t.Errorf("bytes written %d outside row size range", report.BytesWritten)
+	}
+	if table.GetMaxSeq() != 100+report.RowsInserted {
+		t.Errorf("expected max seq %d, got %d", 100+report.RowsInserted, table.GetMaxSeq())
+	}
+
+	// 缺少字段的表
+	other, err := srdb.OpenTable(&srdb.TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "other",
+		Fields: []srdb.Field{{Name: "n", Type: srdb.Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if _, err := Run(context.Background(), other, Config{Operations: 1}); err == nil {
+		t.Error("expected error for table without bench fields")
+	}
+}
@@ -0,0 +1,81 @@
+package bench
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// 延迟直方图：对数分桶，每个 2 的幂区间再细分为 64 个桶，
+// 分位数的相对误差不超过 1/128，内存占用固定（与样本数量无关）
+const (
+	histSubBits = 6
+	histSub     = 1 << histSubBits
+	histBuckets = (64 - histSubBits + 1) * histSub
+)
+
+type histogram struct {
+	counts [histBuckets]uint64
+	total  uint64
+	sum    float64 // 纳秒
+	max    uint64
+}
+
+// histBucket 返回 v 所在的桶
+func histBucket(v uint64) int {
+	if v < histSub*2 {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histSubBits - 1
+	return (shift+1)*histSub + int(v>>shift) - histSub
+}
+
+// histValue 返回桶的代表值（区间中点）
+func histValue(i int) uint64 {
+	if i < histSub*2 {
+		return uint64(i)
+	}
+	shift := i/histSub - 1
+	low := uint64(histSub+i%histSub) << shift
+	return low + (uint64(1)<<shift)/2
+}
+
+func (h *histogram) record(d time.Duration) {
+	v := uint64(max(d, 0))
+	h.counts[histBucket(v)]++
+	h.total++
+	h.sum += float64(v)
+	h.max = max(h.max, v)
+}
+
+func (h *histogram) merge(other *histogram) {
+	for i, n := range other.counts {
+		h.counts[i] += n
+	}
+	h.total += other.total
+	h.sum += other.sum
+	h.max = max(h.max, other.max)
+}
+
+func (h *histogram) mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / float64(h.total))
+}
+
+// percentile 返回分位数 p（0 < p <= 1）对应的延迟
+func (h *histogram) percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(p * float64(h.total)))
+	var seen uint64
+	for i, n := range h.counts {
+		seen += n
+		if seen >= target {
+			return time.Duration(min(histValue(i), h.max))
+		}
+	}
+	return time.Duration(h.max)
+}
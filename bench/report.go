@@ -0,0 +1,128 @@
+package bench
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// OpReport 单类操作的统计
+type OpReport struct {
+	Op         Op
+	Count      int64
+	Errors     int64
+	Throughput float64 // 每秒操作数
+	Mean       time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	P999       time.Duration
+	Max        time.Duration
+}
+
+// Report 负载运行结果
+type Report struct {
+	Config       Config
+	Duration     time.Duration
+	Operations   int64
+	Errors       int64
+	Throughput   float64 // 每秒操作数
+	RowsInserted int64
+	BytesWritten int64      // 插入的 payload 字节数
+	Ops          []OpReport // 按 insert、get、scan、index 顺序，不含未执行的操作
+	Err          error      // 第一个操作错误（运行不会因错误中止）
+}
+
+func newReport(cfg Config, elapsed time.Duration, workers []*worker) *Report {
+	report := &Report{Config: cfg, Duration: elapsed}
+	seconds := elapsed.Seconds()
+	rate := func(n int64) float64 {
+		if seconds <= 0 {
+			return 0
+		}
+		return float64(n) / seconds
+	}
+
+	for _, op := range ops {
+		var h histogram
+		var errors int64
+		for _, w := range workers {
+			h.merge(w.hists[op])
+			errors += w.errors[op]
+		}
+		if h.total == 0 {
+			continue
+		}
+		report.Ops = append(report.Ops, OpReport{
+			Op:         op,
+			Count:      int64(h.total),
+			Errors:     errors,
+			Throughput: rate(int64(h.total)),
+			Mean:       h.mean(),
+			P50:        h.percentile(0.50),
+			P90:        h.percentile(0.90),
+			P99:        h.percentile(0.99),
+			P999:       h.percentile(0.999),
+			Max:        time.Duration(h.max),
+		})
+		report.Operations += int64(h.total)
+		report.Errors += errors
+	}
+	for _, w := range workers {
+		report.RowsInserted += w.inserted
+		report.BytesWritten += w.bytes
+		if report.Err == nil {
+			report.Err = w.err
+		}
+	}
+	report.Throughput = rate(report.Operations)
+	return report
+}
+
+// String 以表格形式输出报告
+func (r *Report) String() string {
+	var b strings.Builder
+	cfg := r.Config
+	fmt.Fprintf(&b, "Workload: mix=%s concurrency=%d batch=%d row-size=%s(%d", cfg.Mix, cfg.Concurrency, cfg.BatchSize, cfg.RowSizeDist, cfg.RowSize)
+	if cfg.RowSizeDist != Fixed {
+		fmt.Fprintf(&b, "..%d", cfg.RowSizeMax)
+	}
+	b.WriteString(")\n")
+	fmt.Fprintf(&b, "Duration: %s, operations: %d, errors: %d, throughput: %.1f ops/s\n",
+		r.Duration.Round(time.Millisecond), r.Operations, r.Errors, r.Throughput)
+	if r.RowsInserted > 0 {
+		seconds := max(r.Duration.Seconds(), 1e-9)
+		fmt.Fprintf(&b, "Inserted: %d rows (%.1f rows/s, %.2f MB/s)\n",
+			r.RowsInserted, float64(r.RowsInserted)/seconds, float64(r.BytesWritten)/seconds/(1<<20))
+	}
+	b.WriteString("\n")
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\tcount\terrors\tops/s\tmean\tp50\tp90\tp99\tp99.9\tmax\t")
+	for _, op := range r.Ops {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			op.Op, op.Count, op.Errors, op.Throughput,
+			fmtLatency(op.Mean), fmtLatency(op.P50), fmtLatency(op.P90),
+			fmtLatency(op.P99), fmtLatency(op.P999), fmtLatency(op.Max))
+	}
+	tw.Flush()
+
+	if r.Err != nil {
+		fmt.Fprintf(&b, "\nFirst error: %v\n", r.Err)
+	}
+	return b.String()
+}
+
+// fmtLatency 保留 3 位有效数字
+func fmtLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	case d >= time.Microsecond:
+		return d.Round(10 * time.Nanosecond).String()
+	}
+	return d.String()
+}
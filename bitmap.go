@@ -0,0 +1,504 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+/*
+Bitmap 压缩位图（Roaring Bitmap），二级索引用它存储每个值对应的 seq 列表
+
+结构：
+  - seq 按高 48 位分组，每组一个容器，容器只存低 16 位（最多 65536 个）
+  - 稀疏容器：基数 ≤ 4096 时使用升序的 []uint16（每个 seq 2 字节）
+  - 稠密容器：基数 > 4096 时使用 1024 个 uint64 的位图（固定 8KB）
+
+同一个值对应几百万行时（如状态字段），seq 通常是连续或密集的，每 65536 个 seq 只占 8KB，
+而 []int64 需要 512KB；多个条件求交集（And）、并集（Or）、差集（AndNot）按容器逐字计算，
+不需要构建 map。
+
+序列化格式（小端）:
+  [Count(4)] + Count × [Key(8)][Cardinality-1(2)] + Count × 容器数据
+  容器数据：基数 ≤ 4096 时为 Cardinality × 2 字节的升序低 16 位，否则为 8192 字节的位图
+
+seq 应为正数（负数按无符号整数排在最后）。Bitmap 不是并发安全的。
+*/
+
+const (
+	bitmapArrayMaxSize = 4096 // 稀疏容器的最大基数
+	bitmapWords        = 1024 // 稠密容器的 uint64 个数（65536 位）
+)
+
+// Bitmap 压缩位图，存储升序去重的 seq 集合
+type Bitmap struct {
+	keys       []uint64           // 容器的高 48 位，升序
+	containers []*bitmapContainer // 与 keys 对齐
+}
+
+// bitmapContainer 存储同一高位下的低 16 位
+type bitmapContainer struct {
+	array []uint16 // 稀疏容器：升序的低 16 位（words 为 nil 时使用）
+	words []uint64 // 稠密容器：位图
+	n     int      // 基数
+}
+
+// NewBitmap 创建包含 seqs 的位图
+func NewBitmap(seqs ...int64) *Bitmap {
+	b := &Bitmap{}
+	for _, seq := range seqs {
+		b.Add(seq)
+	}
+	return b
+}
+
+// Add 添加 seq
+func (b *Bitmap) Add(seq int64) {
+	high, low := uint64(seq)>>16, uint16(seq)
+
+	// 按 seq 递增写入时总是落在最后一个容器
+	if n := len(b.keys); n > 0 && b.keys[n-1] == high {
+		b.containers[n-1].add(low)
+		return
+	}
+	i, found := slices.BinarySearch(b.keys, high)
+	if !found {
+		b.keys = slices.Insert(b.keys, i, high)
+		b.containers = slices.Insert(b.containers, i, &bitmapContainer{})
+	}
+	b.containers[i].add(low)
+}
+
+// Contains 是否包含 seq
+func (b *Bitmap) Contains(seq int64) bool {
+	if b == nil {
+		return false
+	}
+	i, found := slices.BinarySearch(b.keys, uint64(seq)>>16)
+	return found && b.containers[i].contains(uint16(seq))
+}
+
+// Cardinality 返回 seq 的个数
+func (b *Bitmap) Cardinality() int {
+	if b == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range b.containers {
+		n += c.n
+	}
+	return n
+}
+
+// IsEmpty 是否为空
+func (b *Bitmap) IsEmpty() bool {
+	return b == nil || len(b.keys) == 0
+}
+
+// Clone 返回副本
+func (b *Bitmap) Clone() *Bitmap {
+	result := &Bitmap{}
+	if b == nil {
+		return result
+	}
+	result.keys = slices.Clone(b.keys)
+	result.containers = make([]*bitmapContainer, len(b.containers))
+	for i, c := range b.containers {
+		result.containers[i] = c.clone()
+	}
+	return result
+}
+
+// And 返回交集（新位图，b 与 other 不变）
+func (b *Bitmap) And(other *Bitmap) *Bitmap {
+	result := &Bitmap{}
+	if b.IsEmpty() || other.IsEmpty() {
+		return result
+	}
+	i, j := 0, 0
+	for i < len(b.keys) && j < len(other.keys) {
+		switch {
+		case b.keys[i] < other.keys[j]:
+			i++
+		case b.keys[i] > other.keys[j]:
+			j++
+		default:
+			if c := b.containers[i].and(other.containers[j]); c != nil {
+				result.keys = append(result.keys, b.keys[i])
+				result.containers = append(result.containers, c)
+			}
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// Or 返回并集（新位图，b 与 other 不变）
+func (b *Bitmap) Or(other *Bitmap) *Bitmap {
+	result := b.Clone()
+	result.Merge(other)
+	return result
+}
+
+// AndNot 返回差集，即在 b 中但不在 other 中的 seq（新位图，b 与 other 不变）
+func (b *Bitmap) AndNot(other *Bitmap) *Bitmap {
+	if other.IsEmpty() {
+		return b.Clone()
+	}
+	result := &Bitmap{}
+	if b.IsEmpty() {
+		return result
+	}
+	j := 0
+	for i, key := range b.keys {
+		for j < len(other.keys) && other.keys[j] < key {
+			j++
+		}
+		c := b.containers[i].clone()
+		if j < len(other.keys) && other.keys[j] == key {
+			c = c.andNot(other.containers[j])
+		}
+		if c != nil {
+			result.keys = append(result.keys, key)
+			result.containers = append(result.containers, c)
+		}
+	}
+	return result
+}
+
+// Merge 将 other 合并到 b（原地求并集，用于累加多个位图）
+func (b *Bitmap) Merge(other *Bitmap) {
+	if other.IsEmpty() {
+		return
+	}
+	for j, key := range other.keys {
+		i, found := slices.BinarySearch(b.keys, key)
+		if found {
+			b.containers[i] = b.containers[i].or(other.containers[j])
+			continue
+		}
+		b.keys = slices.Insert(b.keys, i, key)
+		b.containers = slices.Insert(b.containers, i, other.containers[j].clone())
+	}
+}
+
+// Iterate 升序遍历所有 seq，fn 返回 false 时停止
+func (b *Bitmap) Iterate(fn func(seq int64) bool) {
+	if b == nil {
+		return
+	}
+	for i, key := range b.keys {
+		if !b.containers[i].iterate(key<<16, fn) {
+			return
+		}
+	}
+}
+
+// ToArray 返回升序的 seq 列表
+func (b *Bitmap) ToArray() []int64 {
+	seqs := make([]int64, 0, b.Cardinality())
+	b.Iterate(func(seq int64) bool {
+		seqs = append(seqs, seq)
+		return true
+	})
+	return seqs
+}
+
+// MarshalBinary 序列化位图
+func (b *Bitmap) MarshalBinary() ([]byte, error) {
+	return b.appendBinary(nil), nil
+}
+
+// appendBinary 将序列化结果追加到 buf
+func (b *Bitmap) appendBinary(buf []byte) []byte {
+	var keys []uint64
+	var containers []*bitmapContainer
+	if b != nil {
+		keys, containers = b.keys, b.containers
+	}
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(keys)))
+	for i, key := range keys {
+		buf = binary.LittleEndian.AppendUint64(buf, key)
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(containers[i].n-1))
+	}
+	for _, c := range containers {
+		if c.words == nil {
+			for _, low := range c.array {
+				buf = binary.LittleEndian.AppendUint16(buf, low)
+			}
+		} else {
+			for _, w := range c.words {
+				buf = binary.LittleEndian.AppendUint64(buf, w)
+			}
+		}
+	}
+	return buf
+}
+
+// UnmarshalBinary 反序列化位图
+func (b *Bitmap) UnmarshalBinary(data []byte) error {
+	_, err := b.readBinary(data)
+	return err
+}
+
+// readBinary 从 data 开头解码位图，返回读取的字节数
+func (b *Bitmap) readBinary(data []byte) (int, error) {
+	if len(data) < 4 {
+		return 0, fmt.Errorf("bitmap data too short: %d bytes", len(data))
+	}
+	count := int(binary.LittleEndian.Uint32(data[0:4]))
+	offset := 4
+	if count > (len(data)-offset)/10 {
+		return 0, fmt.Errorf("bitmap data too short for %d containers", count)
+	}
+
+	keys := make([]uint64, count)
+	containers := make([]*bitmapContainer, count)
+	for i := range count {
+		keys[i] = binary.LittleEndian.Uint64(data[offset : offset+8])
+		if i > 0 && keys[i] <= keys[i-1] {
+			return 0, fmt.Errorf("bitmap keys not sorted")
+		}
+		containers[i] = &bitmapContainer{n: int(binary.LittleEndian.Uint16(data[offset+8:offset+10])) + 1}
+		offset += 10
+	}
+
+	for _, c := range containers {
+		if c.n <= bitmapArrayMaxSize {
+			if offset+c.n*2 > len(data) {
+				return 0, fmt.Errorf("bitmap container truncated")
+			}
+			c.array = make([]uint16, c.n)
+			for i := range c.array {
+				c.array[i] = binary.LittleEndian.Uint16(data[offset+i*2:])
+			}
+			offset += c.n * 2
+		} else {
+			if offset+bitmapWords*8 > len(data) {
+				return 0, fmt.Errorf("bitmap container truncated")
+			}
+			c.words = make([]uint64, bitmapWords)
+			for i := range c.words {
+				c.words[i] = binary.LittleEndian.Uint64(data[offset+i*8:])
+			}
+			offset += bitmapWords * 8
+		}
+	}
+
+	b.keys = keys
+	b.containers = containers
+	return offset, nil
+}
+
+// add 添加低 16 位
+func (c *bitmapContainer) add(low uint16) {
+	if c.words != nil {
+		w, bit := low>>6, uint64(1)<<(low&63)
+		if c.words[w]&bit == 0 {
+			c.words[w] |= bit
+			c.n++
+		}
+		return
+	}
+
+	// 递增写入时直接追加
+	if n := len(c.array); n == 0 || c.array[n-1] < low {
+		c.array = append(c.array, low)
+	} else {
+		i, found := slices.BinarySearch(c.array, low)
+		if found {
+			return
+		}
+		c.array = slices.Insert(c.array, i, low)
+	}
+	c.n++
+	if c.n > bitmapArrayMaxSize {
+		c.toWords()
+	}
+}
+
+// contains 是否包含低 16 位
+func (c *bitmapContainer) contains(low uint16) bool {
+	if c.words != nil {
+		return c.words[low>>6]&(uint64(1)<<(low&63)) != 0
+	}
+	_, found := slices.BinarySearch(c.array, low)
+	return found
+}
+
+// clone 返回副本
+func (c *bitmapContainer) clone() *bitmapContainer {
+	return &bitmapContainer{array: slices.Clone(c.array), words: slices.Clone(c.words), n: c.n}
+}
+
+// toWords 转换为稠密容器
+func (c *bitmapContainer) toWords() {
+	words := make([]uint64, bitmapWords)
+	for _, low := range c.array {
+		words[low>>6] |= uint64(1) << (low & 63)
+	}
+	c.words = words
+	c.array = nil
+}
+
+// normalize 重新计算稠密容器的基数，基数较小时转换为稀疏容器，为空时返回 nil
+func (c *bitmapContainer) normalize() *bitmapContainer {
+	if c.words == nil {
+		c.n = len(c.array)
+	} else {
+		c.n = 0
+		for _, w := range c.words {
+			c.n += bits.OnesCount64(w)
+		}
+		if c.n <= bitmapArrayMaxSize {
+			array := make([]uint16, 0, c.n)
+			c.iterate(0, func(seq int64) bool {
+				array = append(array, uint16(seq))
+				return true
+			})
+			c.array, c.words = array, nil
+		}
+	}
+	if c.n == 0 {
+		return nil
+	}
+	return c
+}
+
+// and 返回交集，为空时返回 nil
+func (c *bitmapContainer) and(other *bitmapContainer) *bitmapContainer {
+	switch {
+	case c.words != nil && other.words != nil:
+		words := make([]uint64, bitmapWords)
+		for i := range words {
+			words[i] = c.words[i] & other.words[i]
+		}
+		return (&bitmapContainer{words: words}).normalize()
+	case c.words != nil:
+		return other.and(c)
+	}
+
+	// c 为稀疏容器
+	array := make([]uint16, 0, min(c.n, other.n))
+	if other.words != nil {
+		for _, low := range c.array {
+			if other.contains(low) {
+				array = append(array, low)
+			}
+		}
+	} else {
+		i, j := 0, 0
+		for i < len(c.array) && j < len(other.array) {
+			switch {
+			case c.array[i] < other.array[j]:
+				i++
+			case c.array[i] > other.array[j]:
+				j++
+			default:
+				array = append(array, c.array[i])
+				i++
+				j++
+			}
+		}
+	}
+	return (&bitmapContainer{array: array}).normalize()
+}
+
+// or 返回并集（不修改 other，可能修改并返回 c）
+func (c *bitmapContainer) or(other *bitmapContainer) *bitmapContainer {
+	if c.words == nil && other.words == nil && c.n+other.n <= bitmapArrayMaxSize {
+		array := make([]uint16, 0, c.n+other.n)
+		i, j := 0, 0
+		for i < len(c.array) || j < len(other.array) {
+			switch {
+			case j == len(other.array) || (i < len(c.array) && c.array[i] < other.array[j]):
+				array = append(array, c.array[i])
+				i++
+			case i == len(c.array) || c.array[i] > other.array[j]:
+				array = append(array, other.array[j])
+				j++
+			default:
+				array = append(array, c.array[i])
+				i++
+				j++
+			}
+		}
+		return &bitmapContainer{array: array, n: len(array)}
+	}
+
+	if c.words == nil {
+		c.toWords()
+	}
+	if other.words != nil {
+		for i, w := range other.words {
+			c.words[i] |= w
+		}
+	} else {
+		for _, low := range other.array {
+			c.words[low>>6] |= uint64(1) << (low & 63)
+		}
+	}
+	return c.normalize()
+}
+
+// andNot 返回差集（修改并返回 c），为空时返回 nil
+func (c *bitmapContainer) andNot(other *bitmapContainer) *bitmapContainer {
+	if c.words == nil {
+		array := c.array[:0]
+		for _, low := range c.array {
+			if !other.contains(low) {
+				array = append(array, low)
+			}
+		}
+		c.array = array
+		return c.normalize()
+	}
+
+	if other.words != nil {
+		for i, w := range other.words {
+			c.words[i] &^= w
+		}
+	} else {
+		for _, low := range other.array {
+			c.words[low>>6] &^= uint64(1) << (low & 63)
+		}
+	}
+	return c.normalize()
+}
+
+// iterate 升序遍历，base 为高位部分
+func (c *bitmapContainer) iterate(base uint64, fn func(seq int64) bool) bool {
+	if c.words == nil {
+		for _, low := range c.array {
+			if !fn(int64(base | uint64(low))) {
+				return false
+			}
+		}
+		return true
+	}
+	for i, w := range c.words {
+		for w != 0 {
+			bit := uint64(bits.TrailingZeros64(w))
+			if !fn(int64(base | uint64(i)<<6 | bit)) {
+				return false
+			}
+			w &= w - 1
+		}
+	}
+	return true
+}
+
+// memorySize 估算位图占用的内存（字节）
+func (b *Bitmap) memorySize() int64 {
+	if b == nil {
+		return 0
+	}
+	size := int64(len(b.keys))*8 + int64(len(b.containers))*8
+	for _, c := range b.containers {
+		size += 56 + int64(cap(c.array))*2 + int64(cap(c.words))*8
+	}
+	return size
+}
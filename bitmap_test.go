@@ -0,0 +1,96 @@
+package srdb
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestBitmap(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// 随机生成稀疏与稠密混合的集合，与 map 的结果对比
+	gen := func() (*Bitmap, map[int64]bool) {
+		b := NewBitmap()
+		set := make(map[int64]bool)
+		for range 20000 {
+			var seq int64
+			switch rng.Intn(3) {
+			case 0:
+				seq = 1 + rng.Int63n(70000) // 前两个容器，足够稠密
+			case 1:
+				seq = 1<<20 + rng.Int63n(1<<22) // 稀疏
+			default:
+				seq = 1<<40 + rng.Int63n(5000)
+			}
+			b.Add(seq)
+			set[seq] = true
+		}
+		return b, set
+	}
+	check := func(name string, b *Bitmap, want map[int64]bool) {
+		t.Helper()
+		got := b.ToArray()
+		expected := slices.Sorted(maps.Keys(want))
+		if !slices.Equal(got, expected) {
+			t.Fatalf("%s: expected %d seqs, got %d", name, len(expected), len(got))
+		}
+		if b.Cardinality() != len(expected) {
+			t.Fatalf("%s: cardinality %d, expected %d", name, b.Cardinality(), len(expected))
+		}
+	}
+
+	a, setA := gen()
+	b, setB := gen()
+	check("a", a, setA)
+	if a.containers[0].words == nil || a.containers[len(a.containers)-1].words != nil {
+		t.Fatal("expected both dense and sparse containers")
+	}
+
+	and, or, andNot := make(map[int64]bool), maps.Clone(setA), make(map[int64]bool)
+	for seq := range setA {
+		if setB[seq] {
+			and[seq] = true
+		} else {
+			andNot[seq] = true
+		}
+	}
+	maps.Copy(or, setB)
+
+	check("and", a.And(b), and)
+	check("or", a.Or(b), or)
+	check("and not", a.AndNot(b), andNot)
+	check("a unchanged", a, setA)
+	check("b unchanged", b, setB)
+
+	merged := a.Clone()
+	merged.Merge(b)
+	check("merge", merged, or)
+	if !a.Contains(slices.Min(slices.Collect(maps.Keys(setA)))) || a.Contains(-1) {
+		t.Error("unexpected Contains result")
+	}
+
+	// 序列化
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Bitmap
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	check("restored", &restored, setA)
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("expected truncated data to fail")
+	}
+
+	// 空位图与 nil
+	var empty *Bitmap
+	if !empty.IsEmpty() || empty.Cardinality() != 0 || len(empty.ToArray()) != 0 {
+		t.Error("nil bitmap should be empty")
+	}
+	check("and nil", a.And(nil), nil)
+	check("and not nil", a.AndNot(nil), setA)
+	check("and not self", a.AndNot(a), nil)
+}
@@ -0,0 +1,116 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// rowBounds 一组行的 _seq 与 _time 范围
+type rowBounds struct {
+	minSeq, maxSeq   int64
+	minTime, maxTime int64
+	ok               bool // 是否包含行
+}
+
+// add 加入一行
+func (b *rowBounds) add(seq, rowTime int64) {
+	b.merge(rowBounds{minSeq: seq, maxSeq: seq, minTime: rowTime, maxTime: rowTime, ok: true})
+}
+
+// merge 合并另一组范围
+func (b *rowBounds) merge(other rowBounds) {
+	if !other.ok {
+		return
+	}
+	if !b.ok {
+		*b = other
+		return
+	}
+	b.minSeq = min(b.minSeq, other.minSeq)
+	b.maxSeq = max(b.maxSeq, other.maxSeq)
+	b.minTime = min(b.minTime, other.minTime)
+	b.maxTime = max(b.maxTime, other.maxTime)
+}
+
+// encodedRowTime 从编码后的行数据头部读取写入时间：Magic(4) + Seq(8) + Time(8)
+func encodedRowTime(value []byte) (int64, bool) {
+	if len(value) < 20 || !isRowMagic(binary.LittleEndian.Uint32(value[0:4])) {
+		return 0, false
+	}
+	return int64(binary.LittleEndian.Uint64(value[12:20])), true
+}
+
+// bounds 返回 MemTable 中行的范围
+func (m *MemTable) bounds() rowBounds {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.rowBounds
+}
+
+// bounds 返回所有 MemTable（Active 与 Immutable）中行的范围
+func (m *MemTableManager) bounds() rowBounds {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b := m.active.bounds()
+	for _, imm := range m.immutables {
+		b.merge(imm.bounds())
+	}
+	return b
+}
+
+// bounds 根据文件头部的统计信息返回所有 SST 中行的范围（不读取数据）
+func (m *SSTableManager) bounds() rowBounds {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var b rowBounds
+	for _, reader := range m.readers {
+		header := reader.GetHeader()
+		if header.RowCount == 0 {
+			continue
+		}
+		b.merge(rowBounds{
+			minSeq:  header.MinKey,
+			maxSeq:  header.MaxKey,
+			minTime: header.MinTime,
+			maxTime: header.MaxTime,
+			ok:      true,
+		})
+	}
+	return b
+}
+
+// bounds 返回表中所有行的范围
+//
+// 由 SST 文件头部与 MemTable 维护的统计信息合并得到，只与文件数有关，不读取数据。
+// flush 期间行可能同时位于 SST 与 Immutable MemTable 中，不影响最小、最大值。
+func (t *Table) bounds() rowBounds {
+	b := t.sstManager.bounds()
+	b.merge(t.memtableManager.bounds())
+	return b
+}
+
+// MinSeq 返回表中最小的 _seq，空表返回 0
+//
+// 与 MaxSeq、TimeBounds 一样由文件与 MemTable 的统计信息得到，不读取数据，
+// 适合分页或按范围清理时确定边界。
+func (t *Table) MinSeq() int64 {
+	return t.bounds().minSeq
+}
+
+// MaxSeq 返回表中最大的 _seq，空表返回 0
+//
+// 与 GetMaxSeq（最后分配的序列号）不同，只统计实际存在的行。
+func (t *Table) MaxSeq() int64 {
+	return t.bounds().maxSeq
+}
+
+// TimeBounds 返回表中行的最小、最大写入时间 _time，空表时 ok 为 false
+func (t *Table) TimeBounds() (minTime, maxTime time.Time, ok bool) {
+	b := t.bounds()
+	if !b.ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return time.Unix(0, b.minTime), time.Unix(0, b.maxTime), true
+}
@@ -0,0 +1,80 @@
+package srdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTableBounds(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:    dir,
+		Name:   "events",
+		Fields: []Field{{Name: "name", Type: String}},
+		Clock:  clock,
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 空表
+	if table.MinSeq() != 0 || table.MaxSeq() != 0 {
+		t.Errorf("expected zero bounds for empty table, got %d..%d", table.MinSeq(), table.MaxSeq())
+	}
+	if _, _, ok := table.TimeBounds(); ok {
+		t.Error("expected no time bounds for empty table")
+	}
+
+	insert := func(n int) {
+		t.Helper()
+		for range n {
+			if err := table.Insert(map[string]any{"name": "x"}); err != nil {
+				t.Fatal(err)
+			}
+			clock.Advance(time.Second)
+		}
+	}
+	check := func(minSeq, maxSeq int64, minTime, maxTime time.Time) {
+		t.Helper()
+		if got := table.MinSeq(); got != minSeq {
+			t.Errorf("expected MinSeq %d, got %d", minSeq, got)
+		}
+		if got := table.MaxSeq(); got != maxSeq {
+			t.Errorf("expected MaxSeq %d, got %d", maxSeq, got)
+		}
+		gotMin, gotMax, ok := table.TimeBounds()
+		if !ok || !gotMin.Equal(minTime) || !gotMax.Equal(maxTime) {
+			t.Errorf("expected time bounds %v..%v, got %v..%v (ok=%v)", minTime, maxTime, gotMin, gotMax, ok)
+		}
+	}
+
+	// 只在 MemTable 中
+	insert(10)
+	check(1, 10, start, start.Add(9*time.Second))
+
+	// SST 与 MemTable 合并
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	insert(5)
+	check(1, 15, start, start.Add(14*time.Second))
+
+	// 批量导入（Into）直接写入 SST
+	if err := table.bulkLoad([]map[string]any{{"name": "y"}, {"name": "z"}}); err != nil {
+		t.Fatal(err)
+	}
+	check(1, 17, start, start.Add(15*time.Second))
+
+	// 重新打开后从文件头部与恢复的 MemTable 得到相同结果
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if table, err = OpenTable(opts); err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+	check(1, 17, start, start.Add(15*time.Second))
+}
@@ -6,8 +6,6 @@
 	"os"
 	"slices"
 	"sort"
-
-	"github.com/edsrzf/mmap-go"
 )
 
 /*
@@ -475,14 +473,19 @@ func (b *BTreeBuilder) buildLevel(children []*BTreeNode, childOffsets []int64, l
 //   - 高度: log₂₀₀(1000000) ≈ 3
 //   - 查询次数: 3 次节点读取 + 3 次二分查找
 type BTreeReader struct {
-	mmap       mmap.MMap
+	src        dataSource
 	rootOffset int64
 }
 
-// NewBTreeReader 创建查询器
-func NewBTreeReader(mmap mmap.MMap, rootOffset int64) *BTreeReader {
+// NewBTreeReader 创建查询器（data 通常为 mmap 映射的文件内容）
+func NewBTreeReader(data []byte, rootOffset int64) *BTreeReader {
+	return newBTreeReaderFromSource(bytesSource(data), rootOffset)
+}
+
+// newBTreeReaderFromSource 基于数据源创建查询器（支持 mmap 与 pread）
+func newBTreeReaderFromSource(src dataSource, rootOffset int64) *BTreeReader {
 	return &BTreeReader{
-		mmap:       mmap,
+		src:        src,
 		rootOffset: rootOffset,
 	}
 }
@@ -512,11 +515,10 @@ func (r *BTreeReader) Get(key int64) (dataOffset int64, dataSize int32, found bo
 
 	for {
 		// 读取节点 (零拷贝)
-		if nodeOffset+BTreeNodeSize > int64(len(r.mmap)) {
+		nodeData, err := r.src.Slice(nodeOffset, BTreeNodeSize)
+		if err != nil {
 			return 0, 0, false
 		}
-
-		nodeData := r.mmap[nodeOffset : nodeOffset+BTreeNodeSize]
 		node := UnmarshalBTree(nodeData)
 
 		if node == nil {
@@ -669,12 +671,11 @@ func (r *BTreeReader) ForEachDesc(callback KeyCallback) {
 //   - true: 继续迭代
 //   - false: 停止迭代（外部请求或遍历完成）
 func (r *BTreeReader) forEachInternal(nodeOffset int64, callback KeyCallback, reverse bool) bool {
-	if nodeOffset+BTreeNodeSize > int64(len(r.mmap)) {
+	nodeData, err := r.src.Slice(nodeOffset, BTreeNodeSize)
+	if err != nil {
 		return true // 无效节点，继续其他分支
 	}
 
-	nodeData := r.mmap[nodeOffset : nodeOffset+BTreeNodeSize]
-
 	// 只读取 header（32 bytes）
 	if len(nodeData) < BTreeHeaderSize {
 		return true
@@ -779,13 +780,122 @@ func (r *BTreeReader) forEachInternal(nodeOffset int64, callback KeyCallback, re
 	return true
 }
 
+// btreeCursor 按升序逐个读取 B+Tree 的条目（拉取式，用于多个文件的归并）
+//
+// 只保存从根节点到当前叶子节点的路径，内存占用与树高成正比，与条目数无关。
+type btreeCursor struct {
+	r    *BTreeReader
+	path []btreeCursorFrame // 内部节点及下一个要访问的子节点
+	leaf *BTreeNode         // 当前叶子节点
+	pos  int                // 当前叶子节点中下一个条目的位置
+}
+
+// btreeCursorFrame 游标路径上的内部节点
+type btreeCursorFrame struct {
+	node *BTreeNode
+	next int
+}
+
+// cursor 创建从最小 key 开始的游标
+func (r *BTreeReader) cursor() *btreeCursor {
+	c := &btreeCursor{r: r}
+	if r.rootOffset != 0 {
+		c.descend(r.rootOffset)
+	}
+	return c
+}
+
+// descend 从 nodeOffset 沿最左侧的子节点下降到叶子节点
+func (c *btreeCursor) descend(nodeOffset int64) {
+	for {
+		nodeData, err := c.r.src.Slice(nodeOffset, BTreeNodeSize)
+		if err != nil {
+			return // 无效节点，跳过该分支（同 traverseLeafNodes）
+		}
+		node := UnmarshalBTree(nodeData)
+		if node == nil {
+			return
+		}
+		if node.NodeType == BTreeNodeTypeLeaf {
+			c.leaf, c.pos = node, 0
+			return
+		}
+		if len(node.Children) == 0 {
+			return
+		}
+		c.path = append(c.path, btreeCursorFrame{node: node, next: 1})
+		nodeOffset = node.Children[0]
+	}
+}
+
+// seek 定位到第一个 >= key 的条目，之后的 next 从该条目开始返回
+func (c *btreeCursor) seek(key int64) {
+	c.path, c.leaf, c.pos = c.path[:0], nil, 0
+	nodeOffset := c.r.rootOffset
+	for nodeOffset != 0 {
+		nodeData, err := c.r.src.Slice(nodeOffset, BTreeNodeSize)
+		if err != nil {
+			return
+		}
+		node := UnmarshalBTree(nodeData)
+		if node == nil {
+			return
+		}
+		if node.NodeType == BTreeNodeTypeLeaf {
+			c.leaf = node
+			c.pos = sort.Search(len(node.Keys), func(i int) bool {
+				return node.Keys[i] >= key
+			})
+			return
+		}
+		// 同 Get：children[idx] 包含 >= keys[idx-1] 且 < keys[idx] 的数据
+		idx := sort.Search(len(node.Keys), func(i int) bool {
+			return node.Keys[i] > key
+		})
+		if idx >= len(node.Children) {
+			if len(node.Children) == 0 {
+				return
+			}
+			idx = len(node.Children) - 1
+		}
+		c.path = append(c.path, btreeCursorFrame{node: node, next: idx + 1})
+		nodeOffset = node.Children[idx]
+	}
+}
+
+// next 返回下一个条目，ok 为 false 表示已读完
+func (c *btreeCursor) next() (key int64, dataOffset int64, dataSize int32, ok bool) {
+	for {
+		if c.leaf != nil && c.pos < len(c.leaf.Keys) {
+			i := c.pos
+			c.pos++
+			return c.leaf.Keys[i], c.leaf.DataOffsets[i], c.leaf.DataSizes[i], true
+		}
+		c.leaf = nil
+
+		// 回溯到还有未访问子节点的内部节点
+		for c.leaf == nil && len(c.path) > 0 {
+			top := &c.path[len(c.path)-1]
+			if top.next < len(top.node.Children) {
+				child := top.node.Children[top.next]
+				top.next++
+				c.descend(child)
+			} else {
+				c.path = c.path[:len(c.path)-1]
+			}
+		}
+		if c.leaf == nil {
+			return 0, 0, 0, false
+		}
+	}
+}
+
 // traverseLeafNodes 遍历所有叶子节点（从左到右）
 func (r *BTreeReader) traverseLeafNodes(nodeOffset int64, callback func(*BTreeNode)) {
-	if nodeOffset+BTreeNodeSize > int64(len(r.mmap)) {
+	nodeData, err := r.src.Slice(nodeOffset, BTreeNodeSize)
+	if err != nil {
 		return
 	}
-
-	nodeData := r.mmap[nodeOffset : nodeOffset+BTreeNodeSize]
 	node := UnmarshalBTree(nodeData)
 
 	if node == nil {
@@ -809,11 +919,10 @@ func (r *BTreeReader) traverseLeafNodes(nodeOffset int64, callback func(*BTreeNo
 //   - 避免先获取所有 keys 再反转
 //   - 直接从最右侧的叶子节点开始遍历
 func (r *BTreeReader) traverseLeafNodesReverse(nodeOffset int64, callback func(*BTreeNode)) {
-	if nodeOffset+BTreeNodeSize > int64(len(r.mmap)) {
+	nodeData, err := r.src.Slice(nodeOffset, BTreeNodeSize)
+	if err != nil {
 		return
 	}
-
-	nodeData := r.mmap[nodeOffset : nodeOffset+BTreeNodeSize]
 	node := UnmarshalBTree(nodeData)
 
 	if node == nil {
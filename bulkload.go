@@ -0,0 +1,171 @@
+package srdb
+
+import (
+	"crypto/sha256"
+	"slices"
+)
+
+// intoBatchSize Into 每批写入的行数（每批生成一个 L0 SST 文件）
+const intoBatchSize = 10000
+
+// bulkLoad 批量导入：跳过 WAL 与 MemTable，直接将数据写入新的 L0 SST 文件
+//
+// 数据写入 SST 并记录到 MANIFEST 后即已持久化；任一行验证失败时整批都不会写入。
+// 不触发 OnFlush 监听器。
+func (t *Table) bulkLoad(batch []map[string]any) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// 期间阻止 Clean/Close
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	// 1. 验证并转换所有行
+	schema := t.schema.Load()
+	now := t.clock.Now().UnixNano()
+	rows := make([]*SSTableRow, len(batch))
+	indexed := make([]map[string]any, len(batch))
+	for i, data := range batch {
+		converted, idx, err := t.prepareRow(schema, data, now)
+		if err != nil {
+			return err
+		}
+		rows[i] = &SSTableRow{Time: now, Data: converted}
+		indexed[i] = idx
+	}
+
+	// 2. 分配 _seq（写入 SST 前计入持久化跟踪）
+	for _, row := range rows {
+		row.Seq = t.durability.allocate()
+	}
+	defer func() {
+		for _, row := range rows {
+			t.durability.appended(row.Seq)
+		}
+		t.inserted.notify()
+	}()
+
+	// 3. 行校验和
+	if t.rowChecksum {
+		for _, row := range rows {
+			encoded, err := encodeSSTableRowBinary(row, schema)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(encoded)
+			row.Checksum = sum[:]
+		}
+	}
+
+	// 4. 写入 L0 SST 并记录到 MANIFEST
+	if _, err := t.writeL0(rows, EditReasonBulkLoad); err != nil {
+		return err
+	}
+
+	// 5. 更新并持久化索引
+	for i, row := range rows {
+		t.indexManager.AddToIndexes(indexed[i], row.Seq)
+		t.caggs.add(indexed[i], row.Time, row.Seq)
+	}
+	t.indexManager.BuildAll()
+	t.caggs.save()
+	t.replicateRows(rows)
+
+	t.lastWriteTime.Store(t.clock.Now().UnixNano())
+	return nil
+}
+
+// Into 将查询结果写入新表，返回新表
+//
+// 新表的 Schema 为查询的投影（Select 的字段，未调用 Select 时为全部字段），
+// 保留字段类型、Nullable、索引与注释；计算列按普通字段复制已物化的值。
+// 结果通过批量导入写入（不经过 WAL），表已存在时返回 ErrCodeTableExists，
+// 写入失败时删除新表。
+func (qb *QueryBuilder) Into(db *Database, name string) (*Table, error) {
+	if db == nil {
+		return nil, NewErrorf(ErrCodeInvalidParam, "database is nil")
+	}
+	if qb.table == nil {
+		return nil, NewErrorf(ErrCodeInvalidParam, "table is nil")
+	}
+
+	if qb.selectErr != nil {
+		return nil, qb.selectErr
+	}
+	if len(qb.exprs) > 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "Into does not support computed select expressions")
+	}
+
+	// 1. 投影 Schema
+	fields := slices.Clone(qb.table.schema.Load().Fields)
+	if len(qb.fields) > 0 {
+		fields = nil
+	}
+	for _, selected := range qb.fields {
+		if selected == "_seq" || selected == "_time" {
+			continue
+		}
+		field, err := qb.table.schema.Load().GetField(selected)
+		if err != nil {
+			return nil, NewErrorf(ErrCodeFieldNotFound, "field %s not found", selected)
+		}
+		fields = append(fields, *field)
+	}
+	if len(fields) == 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "query selects no fields")
+	}
+	for i := range fields {
+		fields[i].Computed = ""
+	}
+
+	schema, err := NewSchema(name, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 执行查询（先于建表，查询条件无效时不留下空表）
+	rows, err := qb.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table, err := db.CreateTable(name, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 分批写入
+	fail := func(err error) (*Table, error) {
+		rows.Close()
+		db.DropTable(name)
+		return nil, err
+	}
+
+	batch := make([]map[string]any, 0, intoBatchSize)
+	for rows.Next() {
+		data := rows.Row().Data()
+		delete(data, "_seq")
+		delete(data, "_time")
+		batch = append(batch, data)
+
+		if len(batch) == intoBatchSize {
+			if err := table.bulkLoad(batch); err != nil {
+				return fail(err)
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fail(err)
+	}
+	if err := table.bulkLoad(batch); err != nil {
+		return fail(err)
+	}
+
+	return table, nil
+}
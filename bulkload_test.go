@@ -0,0 +1,141 @@
+package srdb
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestQueryInto(t *testing.T) {
+	dir := t.TempDir()
+
+	opts := DefaultOptions(dir)
+	opts.RowChecksum = true
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := NewSchema("users", []Field{
+		{Name: "name", Type: String, Indexed: true, Comment: "用户名"},
+		{Name: "age", Type: Int64},
+		{Name: "email", Type: String},
+		{Name: "email_lower", Type: String, Computed: "lower(email)"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := db.CreateTable("users", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 一部分数据在 SST，一部分在 MemTable
+	for i := range 40 {
+		if err := users.Insert(map[string]any{
+			"name":  fmt.Sprintf("user-%02d", i),
+			"age":   int64(i),
+			"email": fmt.Sprintf("User%02d@Example.com", i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 19 {
+			if err := users.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	adults, err := users.Query().Gte("age", 30).Select("name", "_seq", "email_lower").Into(db, "adults")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 投影 Schema
+	fields := adults.GetSchema().Fields
+	if len(fields) != 2 || fields[0].Name != "name" || fields[1].Name != "email_lower" {
+		t.Fatalf("unexpected projected fields %+v", fields)
+	}
+	if !fields[0].Indexed || fields[0].Comment != "用户名" || fields[1].Computed != "" {
+		t.Errorf("unexpected field attributes %+v", fields)
+	}
+
+	check := func(adults *Table) {
+		t.Helper()
+		rows, err := adults.Query().Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		var names []string
+		for rows.Next() {
+			data := rows.Row().Data()
+			names = append(names, data["name"].(string))
+			if _, ok := data["age"]; ok {
+				t.Errorf("unexpected field age in %v", data)
+			}
+			if err := adults.VerifyRow(rows.Row().Seq()); err != nil {
+				t.Errorf("VerifyRow(%d): %v", rows.Row().Seq(), err)
+			}
+		}
+		if len(names) != 10 || names[0] != "user-30" || names[9] != "user-39" {
+			t.Errorf("unexpected rows %v", names)
+		}
+
+		row, err := adults.Query().Eq("name", "user-35").First()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row.Data()["email_lower"] != "user35@example.com" {
+			t.Errorf("unexpected email_lower %v", row.Data()["email_lower"])
+		}
+	}
+	check(adults)
+
+	// 批量导入不经过 WAL
+	if n := adults.memtableManager.GetActive().Size(); n != 0 {
+		t.Errorf("expected empty memtable after Into, got %d bytes", n)
+	}
+
+	// 错误处理
+	if _, err := users.Query().Into(db, "adults"); !IsError(err, ErrCodeTableExists) {
+		t.Errorf("expected ErrCodeTableExists, got %v", err)
+	}
+	if _, err := users.Query().Select("missing").Into(db, "broken"); !IsError(err, ErrCodeFieldNotFound) {
+		t.Errorf("expected ErrCodeFieldNotFound, got %v", err)
+	}
+	if slices.Contains(db.ListTables(), "broken") {
+		t.Error("failed Into should not leave a table behind")
+	}
+	if err := adults.bulkLoad([]map[string]any{{"name": "ok", "email_lower": "ok"}, {"name": 1}}); !IsError(err, ErrCodeSchemaValidationFailed) {
+		t.Errorf("expected ErrCodeSchemaValidationFailed, got %v", err)
+	}
+	if n := adults.sstManager.Count(); n != 1 {
+		t.Errorf("expected failed bulk load to write nothing, got %d SST files", n)
+	}
+
+	// 重新打开后数据仍在，新写入的 seq 接在导入数据之后
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	adults, err = db.GetTable("adults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(adults)
+
+	maxSeq := adults.seq.Load()
+	if err := adults.Insert(map[string]any{"name": "late", "email_lower": "late"}); err != nil {
+		t.Fatal(err)
+	}
+	if adults.seq.Load() != maxSeq+1 || maxSeq != 10 {
+		t.Errorf("expected seq to continue after bulk load (max %d), got %d", maxSeq, adults.seq.Load())
+	}
+}
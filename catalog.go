@@ -0,0 +1,132 @@
+package srdb
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// 表目录（database.meta）
+//
+// 创建与删除表分两个阶段提交，任一步骤失败或崩溃都能在下次打开时修复：
+//   - 创建：先登记为 creating 并保存，再创建目录与表，最后标记为就绪
+//   - 删除：先标记为 dropping 并保存，再删除目录，最后移除登记
+//
+// 打开数据库时 reconcileCatalog 对照目录与磁盘：回滚未完成的创建、完成未完成的删除、
+// 移除目录已不存在的表，并报告不属于任何表的目录（只报告，不删除）。
+
+// TableState 表在目录中的状态
+type TableState string
+
+const (
+	TableReady    TableState = ""         // 就绪
+	TableCreating TableState = "creating" // 创建中（未完成的创建在打开时回滚）
+	TableDropping TableState = "dropping" // 删除中（未完成的删除在打开时完成）
+)
+
+// CatalogIssueKind 目录与磁盘不一致的类型
+type CatalogIssueKind string
+
+const (
+	CatalogIncompleteCreate CatalogIssueKind = "incomplete_create" // 创建未完成，已回滚
+	CatalogIncompleteDrop   CatalogIssueKind = "incomplete_drop"   // 删除未完成，已完成删除
+	CatalogMissingDir       CatalogIssueKind = "missing_dir"       // 表的目录不存在，已移除登记
+	CatalogOrphanDir        CatalogIssueKind = "orphan_dir"        // 目录不属于任何表，未处理
+)
+
+// CatalogIssue 打开数据库时发现的一处不一致
+type CatalogIssue struct {
+	Table    string           // 表名（目录名）
+	Kind     CatalogIssueKind // 不一致的类型
+	Repaired bool             // 是否已修复
+	Err      error            // 修复失败的原因
+}
+
+// CatalogIssues 返回打开数据库时发现的目录与磁盘的不一致（没有时返回 nil）
+func (db *Database) CatalogIssues() []CatalogIssue {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return slices.Clone(db.catalogIssues)
+}
+
+// tableInfoIndex 返回表在目录中的位置，不存在时返回 -1
+func (db *Database) tableInfoIndex(name string) int {
+	return slices.IndexFunc(db.metadata.Tables, func(info TableInfo) bool {
+		return info.Name == name
+	})
+}
+
+// setTableState 修改表在目录中的状态（不保存）
+func (db *Database) setTableState(name string, state TableState) {
+	if i := db.tableInfoIndex(name); i >= 0 {
+		db.metadata.Tables = slices.Clone(db.metadata.Tables)
+		db.metadata.Tables[i].State = state
+	}
+}
+
+// removeTableInfo 从目录中移除表及其注册的模型（不保存）
+func (db *Database) removeTableInfo(name string) {
+	db.metadata.Tables = slices.DeleteFunc(slices.Clone(db.metadata.Tables), func(info TableInfo) bool {
+		return info.Name == name
+	})
+	db.forgetModel(name)
+}
+
+// reconcileCatalog 修复目录与磁盘的不一致，结果见 CatalogIssues
+func (db *Database) reconcileCatalog() error {
+	var issues []CatalogIssue
+	for _, info := range slices.Clone(db.metadata.Tables) {
+		tableDir := filepath.Join(db.dir, info.Name)
+		issue := CatalogIssue{Table: info.Name, Repaired: true}
+
+		switch info.State {
+		case TableCreating:
+			issue.Kind = CatalogIncompleteCreate
+			if !info.Adopted {
+				issue.Err = os.RemoveAll(tableDir)
+			}
+		case TableDropping:
+			issue.Kind = CatalogIncompleteDrop
+			issue.Err = os.RemoveAll(tableDir)
+		default:
+			if _, err := os.Stat(tableDir); !os.IsNotExist(err) {
+				continue
+			}
+			issue.Kind = CatalogMissingDir
+		}
+
+		if issue.Err != nil {
+			// 保留登记，下次打开时重试
+			issue.Repaired = false
+		} else {
+			db.removeTableInfo(info.Name)
+		}
+		issues = append(issues, issue)
+	}
+
+	entries, err := os.ReadDir(db.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || name == kvTableName || db.tableInfoIndex(name) >= 0 {
+			continue
+		}
+		issues = append(issues, CatalogIssue{Table: name, Kind: CatalogOrphanDir})
+	}
+
+	for _, issue := range issues {
+		db.options.Logger.Warn("[Database] Catalog mismatch",
+			"table", issue.Table,
+			"kind", issue.Kind,
+			"repaired", issue.Repaired,
+			"error", issue.Err)
+	}
+	db.catalogIssues = issues
+
+	if slices.ContainsFunc(issues, func(issue CatalogIssue) bool { return issue.Repaired }) {
+		return db.saveMetadata()
+	}
+	return nil
+}
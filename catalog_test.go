@@ -0,0 +1,153 @@
+package srdb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCatalogReconcile(t *testing.T) {
+	dir := t.TempDir()
+	schema, err := NewSchema("t", []Field{{Name: "name", Type: String}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"creating", "adopted", "dropping", "ready"} {
+		if _, err := db.CreateTable(name, schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟各阶段崩溃后的目录
+	metaPath := filepath.Join(dir, "database.meta")
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	for i := range meta.Tables {
+		switch meta.Tables[i].Name {
+		case "creating":
+			meta.Tables[i].State = TableCreating
+		case "adopted":
+			meta.Tables[i].State = TableCreating
+			meta.Tables[i].Adopted = true
+		case "dropping":
+			meta.Tables[i].State = TableDropping
+		}
+	}
+	meta.Tables = append(meta.Tables, TableInfo{Name: "missing", Dir: "missing"})
+	if data, err = json.Marshal(&meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(metaPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "stray"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 回滚创建时保留的遗留目录随后作为不属于任何表的目录报告
+	want := []CatalogIssue{
+		{Table: "creating", Kind: CatalogIncompleteCreate, Repaired: true},
+		{Table: "adopted", Kind: CatalogIncompleteCreate, Repaired: true},
+		{Table: "dropping", Kind: CatalogIncompleteDrop, Repaired: true},
+		{Table: "missing", Kind: CatalogMissingDir, Repaired: true},
+		{Table: "adopted", Kind: CatalogOrphanDir},
+		{Table: "stray", Kind: CatalogOrphanDir},
+	}
+	if issues := db.CatalogIssues(); !slices.Equal(issues, want) {
+		t.Errorf("unexpected issues:\n got %+v\nwant %+v", issues, want)
+	}
+
+	if tables := db.ListTables(); !slices.Equal(tables, []string{"ready"}) {
+		t.Errorf("expected only table ready, got %v", tables)
+	}
+	for name, exists := range map[string]bool{"creating": false, "adopted": true, "dropping": false, "ready": true, "stray": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != exists {
+			t.Errorf("directory %s: expected exists=%v, got %v", name, exists, err)
+		}
+	}
+
+	// 修复已保存：再次打开时只剩未处理的目录
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	issues := db.CatalogIssues()
+	if len(issues) != 2 || issues[0].Kind != CatalogOrphanDir || issues[1].Kind != CatalogOrphanDir {
+		t.Errorf("expected only orphan directories after repair, got %+v", issues)
+	}
+
+	// 遗留目录可以通过同名 CreateTable 重新登记
+	if _, err := db.CreateTable("adopted", schema); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetTable("adopted"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCatalogTwoPhase(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema, err := NewSchema("t", []Field{{Name: "name", Type: String}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateTable("events", schema); err != nil {
+		t.Fatal(err)
+	}
+	if info := db.metadata.Tables[db.tableInfoIndex("events")]; info.State != TableReady || info.Adopted {
+		t.Errorf("expected ready table, got %+v", info)
+	}
+
+	// 删除中的表在同名创建前完成删除
+	db.setTableState("events", TableDropping)
+	table, _ := db.GetTable("events")
+	table.Close()
+	delete(db.tables, "events")
+	if _, err := db.CreateTable("events", schema); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(db.metadata.Tables); n != 1 {
+		t.Errorf("expected a single catalog entry, got %d", n)
+	}
+
+	if err := db.DropTable("events"); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.metadata.Tables) != 0 {
+		t.Errorf("expected empty catalog after drop, got %+v", db.metadata.Tables)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events")); !os.IsNotExist(err) {
+		t.Errorf("expected table directory removed, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package srdb
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时间来源
+//
+// 行的写入时间 _time、表与模型的登记时间、GC 判断文件年龄、自动 flush 判断空闲时长、
+// 文件事件的时间都从 Clock 读取。默认使用系统时间；测试中可以通过 Options.Clock
+// （或 TableOptions.Clock）注入 ManualClock，确定性地推进时间而不必等待：
+//
+//	clock := srdb.NewManualClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+//	opts.Clock = clock
+//	clock.Advance(2 * time.Minute) // 之后写入的行、GC 的文件年龄都按推进后的时间计算
+//
+// 后台任务的触发间隔（CompactionInterval、GCInterval、自动 flush 检查）仍使用真实计时器，
+// 推进时间后在下一次检查时生效。
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock 系统时间
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock 使用系统时间的 Clock（默认）
+var SystemClock Clock = systemClock{}
+
+// clockOrDefault 未设置时使用 SystemClock
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
+
+// ManualClock 只在调用 Advance、Set 时改变的 Clock（用于测试），并发安全
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock 创建从 start 开始的 ManualClock
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now 返回当前时间
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 将时间向后推进 d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set 将时间设置为 t
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
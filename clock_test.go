@@ -0,0 +1,104 @@
+package srdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	if !clock.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, clock.Now())
+	}
+	clock.Advance(90 * time.Second)
+	if want := start.Add(90 * time.Second); !clock.Now().Equal(want) {
+		t.Errorf("expected %v after Advance, got %v", want, clock.Now())
+	}
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("expected %v after Set, got %v", start, clock.Now())
+	}
+	if clockOrDefault(nil) != SystemClock {
+		t.Error("expected nil clock to default to SystemClock")
+	}
+}
+
+func TestTableClock(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	clock := NewManualClock(start)
+	dir := t.TempDir()
+	table, err := OpenTable(&TableOptions{
+		Dir:    dir,
+		Name:   "events",
+		Fields: []Field{{Name: "name", Type: String}},
+		Clock:  clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// _time 使用注入的时钟
+	if err := table.Insert(map[string]any{"name": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if err := table.Insert(map[string]any{"name": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	for seq, want := range map[int64]time.Time{1: start, 2: start.Add(time.Hour)} {
+		row, err := table.Get(seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row.Time != want.UnixNano() {
+			t.Errorf("row %d: expected _time %v, got %v", seq, want, time.Unix(0, row.Time))
+		}
+	}
+
+	// 遗留的 WAL 按时钟计算年龄，推进时间后即可回收，不需要等待
+	if err := os.WriteFile(filepath.Join(dir, "wal", "000099.wal"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	clock.Set(time.Now())
+	if n, _ := table.collectFlushedWALs(time.Minute); n != 0 {
+		t.Fatalf("expected recently written WAL to be kept, got %d deleted", n)
+	}
+	clock.Advance(2 * time.Minute)
+	if n, _ := table.collectFlushedWALs(time.Minute); n != 1 {
+		t.Errorf("expected the leftover WAL to be collected after advancing the clock")
+	}
+}
+
+func TestDatabaseClock(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	opts := DefaultOptions(t.TempDir())
+	opts.Clock = NewManualClock(created)
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema, err := NewSchema("t", []Field{{Name: "name", Type: String}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateTable("events", schema); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.metadata.Tables[0].CreatedAt; got != created.Unix() {
+		t.Errorf("expected CreatedAt %d, got %d", created.Unix(), got)
+	}
+
+	table, err := db.GetTable("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.clock != opts.Clock {
+		t.Error("expected table to use the database clock")
+	}
+}
@@ -6,6 +6,7 @@
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -470,7 +471,24 @@ type Compactor struct {
 	versionSet *VersionSet
 	schema     *Schema
 	logger     *slog.Logger
-	mu         sync.RWMutex // 只保护 schema 和 logger 字段的读写
+	ioMode     IOMode         // 读取输入文件的方式
+	faults     *FaultInjector // 故障注入（仅测试）
+	paranoid   bool           // 校验输入行数与输出文件（见 compaction_verify.go）
+	mu         sync.RWMutex   // 只保护 schema 和 logger 字段的读写
+
+	// 隔离的行数（见 quarantine.go）
+	corruptRows atomic.Int64 // 校验和不一致或无法解码
+	invalidRows atomic.Int64 // 不符合 Schema
+
+	// 保留策略（见 retention.go）
+	expireBefore atomic.Int64 // _time 早于该时间（UnixNano）的行不写入输出文件，0 表示不过期
+	expiredRows  atomic.Int64 // 累计删除的过期行数
+	expiredSeq   atomic.Int64 // 删除的过期行中最大的 seq
+
+	// 删除标记（见 mutate.go）
+	// olderVersions 报告输入文件之外是否还有数据源含有 seq（由 Table 设置），nil 时保留所有删除标记
+	olderVersions     func(seq int64, inputs []int64) bool
+	droppedTombstones atomic.Int64 // 累计丢弃的删除标记数
 }
 
 // NewCompactor 创建新的 Compactor
@@ -532,14 +550,11 @@ func (c *Compactor) DoCompaction(task *CompactionTask, version *Version) (*Versi
 		return nil, nil // 返回 nil 表示不需要应用任何 VersionEdit
 	}
 
-	// 1. 读取输入文件的所有行
-	inputRows, err := c.readInputFiles(existingInputFiles)
-	if err != nil {
-		return nil, fmt.Errorf("read input files: %w", err)
-	}
-
-	// 2. 如果输出层级有文件，需要合并重叠的文件
-	outputFiles := c.getOverlappingFiles(version, task.OutputLevel, inputRows)
+	// 1. 输出层级中与输入文件重叠的文件需要一起合并
+	// 输出层级与输入层级相同（L0 合并）时，输入文件本身已经在合并之列，不再重复读取和隔离
+	outputFiles := slices.DeleteFunc(c.getOverlappingFiles(version, task.OutputLevel, existingInputFiles), func(f *FileMetadata) bool {
+		return slices.ContainsFunc(task.InputFiles, func(in *FileMetadata) bool { return in.FileNumber == f.FileNumber })
+	})
 	var existingOutputFiles []*FileMetadata
 	var missingOutputFiles []*FileMetadata
 	if len(outputFiles) > 0 {
@@ -557,29 +572,29 @@ func (c *Compactor) DoCompaction(task *CompactionTask, version *Version) (*Versi
 				missingOutputFiles = append(missingOutputFiles, file)
 			}
 		}
-
-		outputRows, err := c.readInputFiles(existingOutputFiles)
-		if err != nil {
-			return nil, fmt.Errorf("read output files: %w", err)
-		}
-		inputRows = append(inputRows, outputRows...)
 	}
 
-	// 3. 合并和去重 (保留最新的记录)
-	mergedRows := c.mergeRows(inputRows)
+	// 2. 打开所有文件，流式归并（按 seq 排序、去重，保留最新的记录）
+	// 每个文件只保留当前行，不会把输入文件整个读入内存
+	merger, err := c.newCompactionMerger(slices.Concat(existingInputFiles, existingOutputFiles))
+	if err != nil {
+		return nil, fmt.Errorf("read input files: %w", err)
+	}
+	defer merger.close()
 
-	// 计算平均行大小（基于输入文件的 FileMetadata）
+	// 3. 计算平均行大小（基于输入文件的 FileMetadata）
 	avgRowSize := c.calculateAvgRowSize(existingInputFiles, existingOutputFiles)
 
 	// 4. 写入新的 SST 文件
 	// 传入输出层级，L0合并时根据文件大小动态决定，升级任务强制使用OutputLevel
-	newFiles, err := c.writeOutputFiles(mergedRows, task.OutputLevel, avgRowSize)
+	newFiles, err := c.writeOutputFiles(merger, task.OutputLevel, avgRowSize)
 	if err != nil {
 		return nil, fmt.Errorf("write output files: %w", err)
 	}
 
 	// 5. 创建 VersionEdit
 	edit := NewVersionEdit()
+	edit.SetReason(EditReasonCompaction)
 
 	// 删除实际存在且被处理的输入文件
 	for _, file := range existingInputFiles {
@@ -617,60 +632,18 @@ func (c *Compactor) DoCompaction(task *CompactionTask, version *Version) (*Versi
 	return edit, nil
 }
 
-// readInputFiles 读取输入文件的所有行
-// 注意：调用者必须确保传入的文件都存在，否则会返回错误
-func (c *Compactor) readInputFiles(files []*FileMetadata) ([]*SSTableRow, error) {
-	var allRows []*SSTableRow
-
-	for _, file := range files {
-		sstPath := filepath.Join(c.sstDir, fmt.Sprintf("%06d.sst", file.FileNumber))
-
-		reader, err := NewSSTableReader(sstPath)
-		if err != nil {
-			return nil, fmt.Errorf("open sst %d: %w", file.FileNumber, err)
-		}
-
-		// 设置 Schema（如果可用）
-		c.mu.RLock()
-		schema := c.schema
-		c.mu.RUnlock()
-		if schema != nil {
-			reader.SetSchema(schema)
-		}
-
-		// 获取文件中实际存在的所有 key（不能用 MinKey-MaxKey 范围遍历，因为 key 可能是稀疏的）
-		keys := reader.GetAllKeys()
-		for _, seq := range keys {
-			row, err := reader.Get(seq)
-			if err != nil {
-				// 这种情况理论上不应该发生（key 来自索引），但为了安全还是处理一下
-				continue
-			}
-			allRows = append(allRows, row)
-		}
-
-		reader.Close()
-	}
-
-	return allRows, nil
-}
-
-// getOverlappingFiles 获取输出层级中与输入行重叠的文件
-func (c *Compactor) getOverlappingFiles(version *Version, level int, rows []*SSTableRow) []*FileMetadata {
-	if len(rows) == 0 {
+// getOverlappingFiles 获取输出层级中与输入文件的 key 范围重叠的文件
+func (c *Compactor) getOverlappingFiles(version *Version, level int, files []*FileMetadata) []*FileMetadata {
+	if len(files) == 0 {
 		return nil
 	}
 
-	// 找到输入行的 key range
-	minKey := rows[0].Seq
-	maxKey := rows[0].Seq
-	for _, row := range rows {
-		if row.Seq < minKey {
-			minKey = row.Seq
-		}
-		if row.Seq > maxKey {
-			maxKey = row.Seq
-		}
+	// 找到输入文件的 key range
+	minKey := files[0].MinKey
+	maxKey := files[0].MaxKey
+	for _, file := range files {
+		minKey = min(minKey, file.MinKey)
+		maxKey = max(maxKey, file.MaxKey)
 	}
 
 	// 找到输出层级中重叠的文件
@@ -686,38 +659,6 @@ func (c *Compactor) getOverlappingFiles(version *Version, level int, rows []*SST
 	return overlapping
 }
 
-// mergeRows 合并行，去重并保留最新的记录
-func (c *Compactor) mergeRows(rows []*SSTableRow) []*SSTableRow {
-	if len(rows) == 0 {
-		return rows
-	}
-
-	// 按 Seq 排序
-	sort.Slice(rows, func(i, j int) bool {
-		return rows[i].Seq < rows[j].Seq
-	})
-
-	// 去重：保留相同 Seq 的最新记录 (Timestamp 最大的)
-	merged := make([]*SSTableRow, 0, len(rows))
-	var lastRow *SSTableRow
-
-	for _, row := range rows {
-		if lastRow == nil || lastRow.Seq != row.Seq {
-			// 新的 Seq
-			merged = append(merged, row)
-			lastRow = row
-		} else {
-			// 相同 Seq，保留 Time 更大的
-			if row.Time > lastRow.Time {
-				merged[len(merged)-1] = row
-				lastRow = row
-			}
-		}
-	}
-
-	return merged
-}
-
 // calculateAvgRowSize 基于输入文件的 FileMetadata 计算平均行大小
 func (c *Compactor) calculateAvgRowSize(inputFiles []*FileMetadata, outputFiles []*FileMetadata) int64 {
 	var totalSize int64
@@ -753,13 +694,15 @@ func (c *Compactor) calculateAvgRowSize(inputFiles []*FileMetadata, outputFiles
 // - 触发阈值已经控制了文件大小（64MB/256MB/512MB/1GB）
 // - 没有必要累积到大阈值后再分割成小文件
 // - mmap 可以高效处理大文件（按需加载 4KB 页面）
-func (c *Compactor) writeOutputFiles(rows []*SSTableRow, level int, avgRowSize int64) ([]*FileMetadata, error) {
-	if len(rows) == 0 {
-		return nil, nil
+func (c *Compactor) writeOutputFiles(merger *compactionMerger, level int, avgRowSize int64) ([]*FileMetadata, error) {
+	// 所有行都被隔离时不产生输出文件
+	first, err := merger.next()
+	if err != nil || first == nil {
+		return nil, err
 	}
 
 	// Append-Only 优化：不分割，直接写成一个文件
-	file, err := c.writeFile(rows, level)
+	file, err := c.writeFile(first, merger, level)
 	if err != nil {
 		return nil, err
 	}
@@ -826,14 +769,15 @@ func (c *Compactor) determineLevel(fileSize int64, sourceLevel int) int {
 	return NumLevels - 1
 }
 
-// writeFile 写入单个 SST 文件
-func (c *Compactor) writeFile(rows []*SSTableRow, level int) (*FileMetadata, error) {
+// writeFile 写入单个 SST 文件：first 及 merger 中剩余的所有行
+func (c *Compactor) writeFile(first *SSTableRow, merger *compactionMerger, level int) (*FileMetadata, error) {
 	// 从 VersionSet 分配新的文件编号
 	fileNumber := c.versionSet.AllocateFileNumber()
 	sstPath := filepath.Join(c.sstDir, fmt.Sprintf("%06d.sst", fileNumber))
+	tmpPath := sstPath + sstTempSuffix
 
-	// 创建文件
-	file, err := os.Create(sstPath)
+	// 写入临时文件，完成并校验后才重命名为正式文件名（见 publishSST）
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		return nil, err
 	}
@@ -848,19 +792,50 @@ func (c *Compactor) writeFile(rows []*SSTableRow, level int) (*FileMetadata, err
 	// 注意：这个方法只负责创建文件，不负责注册到 SSTableManager
 	// 注册工作由 CompactionManager 在 VersionEdit apply 后完成
 
-	// 写入所有行
-	for _, row := range rows {
+	// 写入所有行（边归并边写入，每次只持有一行）
+	lastSeq := first.Seq
+	var rowCount int64
+	var digests []rowDigest
+	for row := first; row != nil; row, err = merger.next() {
+		if c.paranoid {
+			var digest rowDigest
+			if digest, err = digestRow(row, schema); err != nil {
+				break
+			}
+			digests = append(digests, digest)
+		}
 		err = writer.Add(row)
 		if err != nil {
-			os.Remove(sstPath)
+			os.Remove(tmpPath)
 			return nil, err
 		}
+		lastSeq = row.Seq
+		rowCount++
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
 	}
 
-	// 完成写入
-	err = writer.Finish()
+	// 完成写入（Finish 会 fsync）
+	err = c.faults.check(FaultCompactionWrite)
+	if err == nil {
+		err = writer.Finish()
+	}
 	if err != nil {
-		os.Remove(sstPath)
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	// 重新读取输出文件，与写入的行逐行比对
+	if c.paranoid {
+		if err := c.verifyOutputFile(tmpPath, schema, digests); err != nil {
+			os.Remove(tmpPath)
+			return nil, fmt.Errorf("verify sst %d: %w", fileNumber, err)
+		}
+	}
+
+	if err := publishSST(tmpPath, sstPath); err != nil {
 		return nil, err
 	}
 
@@ -880,9 +855,11 @@ func (c *Compactor) writeFile(rows []*SSTableRow, level int) (*FileMetadata, err
 		FileNumber: fileNumber,
 		Level:      actualLevel,
 		FileSize:   fileInfo.Size(),
-		MinKey:     rows[0].Seq,
-		MaxKey:     rows[len(rows)-1].Seq,
-		RowCount:   int64(len(rows)),
+		MinKey:     first.Seq,
+		MaxKey:     lastSeq,
+		RowCount:   rowCount,
+		MinTime:    writer.minTime,
+		MaxTime:    writer.maxTime,
 	}
 
 	return metadata, nil
@@ -892,6 +869,14 @@ func (c *Compactor) writeFile(rows []*SSTableRow, level int) (*FileMetadata, err
 type CompactionStats struct {
 	TotalCompactions   int64     `json:"total_compactions"`    // 总 compaction 次数
 	LastCompactionTime time.Time `json:"last_compaction_time"` // 最后一次 compaction 时间
+	LastGCTime         time.Time `json:"last_gc_time"`         // 最后一次垃圾回收时间
+	OrphanSSTsDeleted  int64     `json:"orphan_ssts_deleted"`  // 累计删除的孤儿 SST 文件数
+	OrphanWALsDeleted  int64     `json:"orphan_wals_deleted"`  // 累计删除的已 flush WAL 文件数
+	OrphanWALBytes     int64     `json:"orphan_wal_bytes"`     // 累计删除的 WAL 字节数
+	CorruptRows        int64     `json:"corrupt_rows"`         // 累计隔离的损坏行数（校验和不一致或无法解码）
+	InvalidRows        int64     `json:"invalid_rows"`         // 累计隔离的不符合 Schema 的行数
+	ExpiredRows        int64     `json:"expired_rows"`         // 累计按保留策略删除的行数
+	DroppedTombstones  int64     `json:"dropped_tombstones"`   // 累计丢弃的删除标记数（被删除的行已从所有文件中清除）
 }
 
 // LevelStats 层级统计信息
@@ -909,9 +894,22 @@ type CompactionManager struct {
 	sstManager *SSTableManager // 添加 sstManager 引用，用于同步删除 readers
 	sstDir     string
 
-	// 配置（从 Database Options 传递，启动后不可变）
-	configMu           sync.Mutex // 仅用于 ApplyConfig，防御性编程
-	logger             *slog.Logger
+	// walCollector 删除已 flush 的 WAL 文件，返回删除的文件数和字节数（由 Table 设置，nil 表示不回收 WAL）
+	walCollector func(minAge time.Duration) (int, int64)
+
+	// clock GC 判断文件年龄使用的时间来源（由 Table 设置）
+	clock Clock
+
+	// fileListener 接收 SST 文件创建与删除事件（由 Table 设置，nil 表示不通知）
+	fileListener func(FileEvent)
+
+	// retentionEnforcer 执行表的保留策略（由 Table 设置，nil 表示没有策略需要执行）
+	retentionEnforcer func()
+
+	// 配置（从 Database Options 传递，可通过 Database.SetOption 在运行时修改）
+	configMu           sync.Mutex   // 保护以下配置
+	logger             *slog.Logger // compaction 子系统日志器
+	gcLogger           *slog.Logger // gc 子系统日志器
 	level0SizeLimit    int64
 	level1SizeLimit    int64
 	level2SizeLimit    int64
@@ -919,12 +917,15 @@ type CompactionManager struct {
 	compactionInterval time.Duration
 	gcInterval         time.Duration
 	gcFileMinAge       time.Duration
+	tidyFileSize       int64 // L0 小文件整理阈值（见 TidyL0），非正数表示不整理
 	disableCompaction  bool
 	disableGC          bool
 
 	// 控制后台 Compaction
-	stopCh chan struct{}
-	wg     sync.WaitGroup
+	stopCh           chan struct{}
+	compactionConfig chan struct{} // 配置变更通知（后台 Compaction 循环）
+	gcConfig         chan struct{} // 配置变更通知（后台垃圾回收循环）
+	wg               sync.WaitGroup
 
 	// Compaction 并发控制
 	compactionMu sync.Mutex // 防止并发执行 compaction
@@ -937,18 +938,28 @@ type CompactionManager struct {
 	consecutiveFails   int   // 连续失败次数
 	lastGCTime         time.Time
 	totalOrphansFound  int64
+	totalWALsDeleted   int64
+	totalWALBytes      int64
 }
 
 // NewCompactionManager 创建新的 Compaction Manager（使用默认配置）
 func NewCompactionManager(sstDir string, versionSet *VersionSet, sstManager *SSTableManager) *CompactionManager {
+	compactor := NewCompactor(sstDir, versionSet)
+	if sstManager != nil {
+		compactor.ioMode = sstManager.GetIOMode()
+	}
+
 	return &CompactionManager{
-		compactor:  NewCompactor(sstDir, versionSet),
-		versionSet: versionSet,
-		sstManager: sstManager,
-		sstDir:     sstDir,
-		stopCh:     make(chan struct{}),
-		// 默认 logger：丢弃日志（将在 ApplyConfig 中设置为 Database.options.Logger）
-		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		compactor:        compactor,
+		versionSet:       versionSet,
+		sstManager:       sstManager,
+		sstDir:           sstDir,
+		stopCh:           make(chan struct{}),
+		compactionConfig: make(chan struct{}, 1),
+		gcConfig:         make(chan struct{}, 1),
+		// 默认 logger：丢弃日志（将在 ApplyConfig 中设置为数据库的子系统日志器）
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		gcLogger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 		// 使用硬编码常量作为默认值（向后兼容）
 		level0SizeLimit:    level0SizeLimit,
 		level1SizeLimit:    level1SizeLimit,
@@ -957,26 +968,51 @@ func NewCompactionManager(sstDir string, versionSet *VersionSet, sstManager *SST
 		compactionInterval: 10 * time.Second,
 		gcInterval:         5 * time.Minute,
 		gcFileMinAge:       1 * time.Minute,
+		tidyFileSize:       DefaultL0TidyFileSize,
 		disableCompaction:  false,
 		disableGC:          false,
+		clock:              SystemClock,
 	}
 }
 
+// SetFaultInjector 设置故障注入器（仅测试，需在 Start 之前调用）
+func (m *CompactionManager) SetFaultInjector(faults *FaultInjector) {
+	m.compactor.faults = faults
+}
+
+// SetParanoidChecks 设置是否校验 Compaction 的输入行数与输出文件（需在 Start 之前调用）
+func (m *CompactionManager) SetParanoidChecks(enabled bool) {
+	m.compactor.paranoid = enabled
+}
+
+// SetClock 设置 GC 判断文件年龄使用的时间来源（需在 Start 之前调用）
+func (m *CompactionManager) SetClock(clock Clock) {
+	m.clock = clockOrDefault(clock)
+}
+
+// SetVersionChecker 设置删除标记的检查函数：输入文件之外没有数据源含有该 seq 时，
+// Compaction 丢弃删除标记（需在 Start 之前调用）
+func (m *CompactionManager) SetVersionChecker(fn func(seq int64, inputs []int64) bool) {
+	m.compactor.olderVersions = fn
+}
+
+// SetWALCollector 设置 WAL 回收函数，GC 循环删除孤儿 SST 后调用（需在 Start 之前调用）
+func (m *CompactionManager) SetWALCollector(fn func(minAge time.Duration) (int, int64)) {
+	m.walCollector = fn
+}
+
 // ApplyConfig 应用数据库级配置（从 Database Options）
 func (m *CompactionManager) ApplyConfig(opts *Options) {
-	m.configMu.Lock()
-	defer m.configMu.Unlock()
+	logger := opts.subsystemLogger(LogCompaction)
 
-	m.logger = opts.Logger
+	m.configMu.Lock()
+	m.logger = logger
+	m.gcLogger = opts.subsystemLogger(LogGC)
 	m.level0SizeLimit = opts.Level0SizeLimit
 	m.level1SizeLimit = opts.Level1SizeLimit
 	m.level2SizeLimit = opts.Level2SizeLimit
 	m.level3SizeLimit = opts.Level3SizeLimit
-	m.compactionInterval = opts.CompactionInterval
-	m.gcInterval = opts.GCInterval
-	m.gcFileMinAge = opts.GCFileMinAge
-	m.disableCompaction = opts.DisableAutoCompaction
-	m.disableGC = opts.DisableGC
+	m.tidyFileSize = opts.L0TidyFileSize
 
 	// 同时更新 compactor 的 picker 和 logger
 	m.compactor.picker.UpdateLevelLimits(
@@ -985,7 +1021,40 @@ func (m *CompactionManager) ApplyConfig(opts *Options) {
 		m.level2SizeLimit,
 		m.level3SizeLimit,
 	)
-	m.compactor.SetLogger(opts.Logger)
+	m.configMu.Unlock()
+
+	m.compactor.SetLogger(logger)
+	m.applyRuntimeConfig(opts)
+}
+
+// applyRuntimeConfig 应用可在运行时修改的配置（后台任务间隔、禁用开关、GC 文件最小年龄），
+// 并通知后台循环重新读取
+func (m *CompactionManager) applyRuntimeConfig(opts *Options) {
+	m.configMu.Lock()
+	m.compactionInterval = opts.CompactionInterval
+	m.gcInterval = opts.GCInterval
+	m.gcFileMinAge = opts.GCFileMinAge
+	m.disableCompaction = opts.DisableAutoCompaction
+	m.disableGC = opts.DisableGC
+	m.configMu.Unlock()
+
+	// 非阻塞通知，已有未处理的通知时无需重复发送
+	for _, ch := range []chan struct{}{m.compactionConfig, m.gcConfig} {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// loopConfig 读取后台循环的间隔与禁用状态
+func (m *CompactionManager) loopConfig(gc bool) (time.Duration, bool) {
+	m.configMu.Lock()
+	defer m.configMu.Unlock()
+	if gc {
+		return m.gcInterval, m.disableGC
+	}
+	return m.compactionInterval, m.disableCompaction
 }
 
 // GetPicker 获取 Compaction Picker
@@ -998,11 +1067,12 @@ func (m *CompactionManager) SetSchema(schema *Schema) {
 	m.compactor.SetSchema(schema)
 }
 
-// Start 启动后台 Compaction 和垃圾回收
+// Start 启动后台 Compaction、垃圾回收与保留策略
 func (m *CompactionManager) Start() {
-	m.wg.Add(2)
+	m.wg.Add(3)
 	go m.backgroundCompaction()
 	go m.backgroundGarbageCollection()
+	go m.backgroundRetention()
 }
 
 // Stop 停止后台 Compaction
@@ -1016,24 +1086,32 @@ func (m *CompactionManager) backgroundCompaction() {
 	defer m.wg.Done()
 
 	// 读取配置（加锁保护）
-	m.configMu.Lock()
-	interval := m.compactionInterval
-	disabled := m.disableCompaction
-	m.configMu.Unlock()
-
-	if disabled {
-		return // 禁用自动 Compaction，直接退出
-	}
+	interval, disabled := m.loopConfig(false)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	// 打开后的第一次检查先整理 L0 小文件（此时数据库配置已经应用）
+	tidied := false
+
 	for {
 		select {
 		case <-m.stopCh:
 			return
+		case <-m.compactionConfig:
+			// 配置变更：按新间隔重新计时
+			interval, disabled = m.loopConfig(false)
+			ticker.Reset(interval)
 		case <-ticker.C:
-			m.maybeCompact()
+			if !disabled {
+				if !tidied {
+					tidied = true
+					if _, err := m.TidyL0(); err != nil {
+						m.logger.Warn("[Compaction] Failed to tidy small L0 files", "error", err)
+					}
+				}
+				m.maybeCompact()
+			}
 		}
 	}
 }
@@ -1218,6 +1296,7 @@ func (m *CompactionManager) DoCompactionWithVersion(task *CompactionTask, versio
 	}
 
 	// 执行 Compaction（使用传入的 version，而不是重新获取）
+	start := time.Now()
 	edit, err := m.compactor.DoCompaction(task, version)
 	if err != nil {
 		return fmt.Errorf("compaction failed: %w", err)
@@ -1238,13 +1317,17 @@ func (m *CompactionManager) DoCompactionWithVersion(task *CompactionTask, versio
 		m.cleanupNewFiles(edit)
 		return fmt.Errorf("apply version edit: %w", err)
 	}
+	duration := time.Since(start)
+	for _, file := range edit.AddedFiles {
+		m.notifyFile(FileCreated, FileReasonCompaction, file, duration)
+	}
 
 	// LogAndApply 成功后，注册新创建的 SST 文件到 SSTableManager
 	// 这样查询才能读取到 compaction 创建的文件
 	if m.sstManager != nil {
 		for _, file := range edit.AddedFiles {
 			sstPath := filepath.Join(m.sstDir, fmt.Sprintf("%06d.sst", file.FileNumber))
-			reader, err := NewSSTableReader(sstPath)
+			reader, err := NewSSTableReaderWithIOMode(sstPath, m.sstManager.GetIOMode())
 			if err != nil {
 				m.logger.Warn("[Compaction] Failed to open new file",
 					"file_number", file.FileNumber,
@@ -1264,7 +1347,7 @@ func (m *CompactionManager) DoCompactionWithVersion(task *CompactionTask, versio
 	}
 
 	// LogAndApply 成功后，删除废弃的 SST 文件
-	m.deleteObsoleteFiles(edit)
+	m.deleteObsoleteFiles(edit, version, FileReasonCompaction)
 
 	// 更新统计信息
 	m.mu.Lock()
@@ -1306,12 +1389,13 @@ func (m *CompactionManager) cleanupNewFiles(edit *VersionEdit) {
 		} else {
 			m.logger.Info("[Compaction] Cleaned up new file",
 				"file_number", file.FileNumber)
+			m.notifyFile(FileDeleted, FileReasonCleanup, file, 0)
 		}
 	}
 }
 
-// deleteObsoleteFiles 删除废弃的 SST 文件
-func (m *CompactionManager) deleteObsoleteFiles(edit *VersionEdit) {
+// deleteObsoleteFiles 删除废弃的 SST 文件（version 为变更之前的版本，用于获取文件元数据）
+func (m *CompactionManager) deleteObsoleteFiles(edit *VersionEdit, version *Version, reason FileReason) {
 	if edit == nil {
 		m.logger.Warn("[Compaction] deleteObsoleteFiles: edit is nil")
 		return
@@ -1320,16 +1404,27 @@ func (m *CompactionManager) deleteObsoleteFiles(edit *VersionEdit) {
 	m.logger.Info("[Compaction] Deleting obsolete files",
 		"file_count", len(edit.DeletedFiles))
 
+	metas := make(map[int64]*FileMetadata)
+	for _, file := range version.GetSSTFiles() {
+		metas[file.FileNumber] = file
+	}
+
 	// 删除被标记为删除的文件
 	for _, fileNum := range edit.DeletedFiles {
 		// 1. 从 SSTableManager 移除 reader（如果 sstManager 可用）
 		if m.sstManager != nil {
-			err := m.sstManager.RemoveReader(fileNum)
+			retained, err := m.sstManager.retire(fileNum)
 			if err != nil {
 				m.logger.Warn("[Compaction] Failed to remove reader",
 					"file_number", fileNum,
 					"error", err)
 			}
+			// 仍被快照引用的文件在快照释放时删除
+			if retained {
+				m.logger.Info("[Compaction] Deferred deletion of file referenced by snapshot",
+					"file_number", fileNum)
+				continue
+			}
 		}
 
 		// 2. 删除物理文件
@@ -1344,6 +1439,11 @@ func (m *CompactionManager) deleteObsoleteFiles(edit *VersionEdit) {
 		} else {
 			m.logger.Info("[Compaction] Deleted obsolete file",
 				"file_number", fileNum)
+			file, ok := metas[fileNum]
+			if !ok {
+				file = &FileMetadata{FileNumber: fileNum, Level: -1}
+			}
+			m.notifyFile(FileDeleted, reason, file, 0)
 		}
 	}
 }
@@ -1386,6 +1486,14 @@ func (m *CompactionManager) GetStats() *CompactionStats {
 	return &CompactionStats{
 		TotalCompactions:   m.totalCompactions,
 		LastCompactionTime: m.lastCompactionTime,
+		LastGCTime:         m.lastGCTime,
+		OrphanSSTsDeleted:  m.totalOrphansFound,
+		OrphanWALsDeleted:  m.totalWALsDeleted,
+		OrphanWALBytes:     m.totalWALBytes,
+		CorruptRows:        m.compactor.corruptRows.Load(),
+		InvalidRows:        m.compactor.invalidRows.Load(),
+		ExpiredRows:        m.compactor.expiredRows.Load(),
+		DroppedTombstones:  m.compactor.droppedTombstones.Load(),
 	}
 }
 
@@ -1439,14 +1547,7 @@ func (m *CompactionManager) backgroundGarbageCollection() {
 	defer m.wg.Done()
 
 	// 读取配置（加锁保护）
-	m.configMu.Lock()
-	interval := m.gcInterval
-	disabled := m.disableGC
-	m.configMu.Unlock()
-
-	if disabled {
-		return // 禁用垃圾回收，直接退出
-	}
+	interval, disabled := m.loopConfig(true)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -1455,13 +1556,19 @@ func (m *CompactionManager) backgroundGarbageCollection() {
 		select {
 		case <-m.stopCh:
 			return
+		case <-m.gcConfig:
+			// 配置变更：按新间隔重新计时
+			interval, disabled = m.loopConfig(true)
+			ticker.Reset(interval)
 		case <-ticker.C:
-			m.collectOrphanFiles()
+			if !disabled {
+				m.collectOrphanFiles()
+			}
 		}
 	}
 }
 
-// collectOrphanFiles 收集并删除孤儿 SST 文件
+// collectOrphanFiles 收集并删除孤儿 SST 文件、崩溃残留的 SST 临时文件和已 flush 的 WAL 文件
 func (m *CompactionManager) collectOrphanFiles() {
 	// 1. 获取当前版本中的所有活跃文件
 	version := m.versionSet.GetCurrent()
@@ -1481,11 +1588,15 @@ func (m *CompactionManager) collectOrphanFiles() {
 	pattern := filepath.Join(m.sstDir, "*.sst")
 	sstFiles, err := filepath.Glob(pattern)
 	if err != nil {
-		m.logger.Error("[GC] Failed to scan SST directory", "error", err)
+		m.gcLogger.Error("[GC] Failed to scan SST directory", "error", err)
 		return
 	}
 
 	// 3. 找出孤儿文件并删除
+	m.configMu.Lock()
+	minAge := m.gcFileMinAge
+	m.configMu.Unlock()
+
 	orphanCount := 0
 	for _, sstPath := range sstFiles {
 		// 提取文件编号
@@ -1495,20 +1606,17 @@ func (m *CompactionManager) collectOrphanFiles() {
 			continue
 		}
 
-		// 检查是否是活跃文件
-		if !activeFiles[fileNum] {
+		// 检查是否是活跃文件（被快照引用的文件在快照释放时删除）
+		if !activeFiles[fileNum] && (m.sstManager == nil || !m.sstManager.retained(fileNum)) {
 			// 检查文件修改时间，避免删除正在 flush 的文件
-			// Options 是不可变的，直接读取配置即可
-			minAge := m.gcFileMinAge
-
 			fileInfo, err := os.Stat(sstPath)
 			if err != nil {
 				continue
 			}
-			if time.Since(fileInfo.ModTime()) < minAge {
-				m.logger.Info("[GC] Skipping recently modified file",
+			if age := m.clock.Now().Sub(fileInfo.ModTime()); age < minAge {
+				m.gcLogger.Info("[GC] Skipping recently modified file",
 					"file_number", fileNum,
-					"age", time.Since(fileInfo.ModTime()),
+					"age", age,
 					"min_age", minAge)
 				continue
 			}
@@ -1516,33 +1624,74 @@ func (m *CompactionManager) collectOrphanFiles() {
 			// 这是孤儿文件，删除它
 			err = os.Remove(sstPath)
 			if err != nil {
-				m.logger.Warn("[GC] Failed to delete orphan file",
+				m.gcLogger.Warn("[GC] Failed to delete orphan file",
 					"file_number", fileNum,
 					"error", err)
 			} else {
-				m.logger.Info("[GC] Deleted orphan file",
+				m.gcLogger.Info("[GC] Deleted orphan file",
 					"file_number", fileNum)
 				orphanCount++
+				m.notifyFile(FileDeleted, FileReasonGC, &FileMetadata{FileNumber: fileNum, Level: -1, FileSize: fileInfo.Size()}, 0)
 			}
 		}
 	}
 
-	// 4. 更新统计信息
+	// 删除写入过程中崩溃残留的临时文件（正在写入的文件修改时间不断更新，不会满足最小年龄）
+	tmpCount := m.collectTempFiles(minAge)
+
+	// 4. 删除已 flush 的 WAL 文件（同样遵守最小年龄）
+	walCount, walBytes := 0, int64(0)
+	if m.walCollector != nil {
+		walCount, walBytes = m.walCollector(minAge)
+	}
+
+	// 5. 更新统计信息
 	m.mu.Lock()
-	m.lastGCTime = time.Now()
+	m.lastGCTime = m.clock.Now()
 	m.totalOrphansFound += int64(orphanCount)
+	m.totalWALsDeleted += int64(walCount)
+	m.totalWALBytes += walBytes
 	totalOrphans := m.totalOrphansFound
+	totalWALs := m.totalWALsDeleted
 	m.mu.Unlock()
 
-	if orphanCount > 0 {
-		m.logger.Info("[GC] Completed",
+	if orphanCount > 0 || tmpCount > 0 || walCount > 0 {
+		m.gcLogger.Info("[GC] Completed",
 			"cleaned_up", orphanCount,
-			"total_orphans", totalOrphans)
+			"temp_files", tmpCount,
+			"total_orphans", totalOrphans,
+			"wals_deleted", walCount,
+			"wal_bytes", walBytes,
+			"total_wals", totalWALs)
+	}
+}
+
+// collectTempFiles 删除修改时间早于 minAge 的 SST 临时文件（见 publishSST），返回删除的文件数
+func (m *CompactionManager) collectTempFiles(minAge time.Duration) int {
+	tmpFiles, err := filepath.Glob(filepath.Join(m.sstDir, "*.sst"+sstTempSuffix))
+	if err != nil {
+		m.gcLogger.Error("[GC] Failed to scan SST temp files", "error", err)
+		return 0
+	}
+
+	count := 0
+	for _, tmpPath := range tmpFiles {
+		fileInfo, err := os.Stat(tmpPath)
+		if err != nil || m.clock.Now().Sub(fileInfo.ModTime()) < minAge {
+			continue
+		}
+		if err := os.Remove(tmpPath); err != nil {
+			m.gcLogger.Warn("[GC] Failed to delete SST temp file", "path", tmpPath, "error", err)
+			continue
+		}
+		m.gcLogger.Info("[GC] Deleted SST temp file", "path", tmpPath, "size", fileInfo.Size())
+		count++
 	}
+	return count
 }
 
 // CleanupOrphanFiles 手动触发孤儿文件清理（可在启动时调用）
 func (m *CompactionManager) CleanupOrphanFiles() {
-	m.logger.Info("[GC] Manual cleanup triggered")
+	m.gcLogger.Info("[GC] Manual cleanup triggered")
 	m.collectOrphanFiles()
 }
@@ -0,0 +1,200 @@
+package srdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// compactionInput Compaction 的一个输入文件，按 seq 升序逐行读取
+type compactionInput struct {
+	c          *Compactor
+	fileNumber int64
+	reader     *SSTableReader
+	iter       *SSTableIterator
+	schema     *Schema
+	row        *SSTableRow       // 当前行，nil 表示已读完
+	bad        []*quarantinedRow // 待隔离的行，读完后写入 .bad 文件
+	rows       int64             // 已读取的行数（包括待隔离的行）
+	closed     bool
+}
+
+// advance 读取下一个可以写入输出文件的行，损坏或不符合 Schema 的行暂存到 bad
+func (in *compactionInput) advance() error {
+	for {
+		if !in.iter.Next() {
+			in.row = nil
+			return in.finish()
+		}
+		in.rows++
+		seq := in.iter.Key()
+		data, err := in.iter.raw()
+		if err != nil {
+			return fmt.Errorf("read row %d of sst %d: %w", seq, in.fileNumber, err)
+		}
+
+		row, err := decodeSSTableRow(data, in.schema)
+		if in.schema == nil {
+			// 没有 Schema 无法解码和校验（仅测试中直接使用 Compactor 时）
+			if err == nil {
+				in.row = row
+				return nil
+			}
+			continue
+		}
+		// 损坏或不符合 Schema 的行不写入输出文件，隔离到 .bad 文件
+		if q := checkCompactionRow(row, err, in.schema); q != nil {
+			q.seq = seq
+			q.data = slices.Clone(data)
+			in.bad = append(in.bad, q)
+			continue
+		}
+		in.row = row
+		return nil
+	}
+}
+
+// finish 读完后隔离暂存的行并关闭文件
+func (in *compactionInput) finish() error {
+	count := in.reader.GetHeader().RowCount
+	in.close()
+	if in.c.paranoid && in.rows != count {
+		return NewErrorf(ErrCodeCorrupted, "read %d rows of sst %d, header has %d", in.rows, in.fileNumber, count)
+	}
+	bad := in.bad
+	in.bad = nil
+	if err := in.c.quarantine(in.fileNumber, bad); err != nil {
+		return fmt.Errorf("quarantine rows of sst %d: %w", in.fileNumber, err)
+	}
+	if len(bad) > 0 {
+		in.c.mu.RLock()
+		logger := in.c.logger
+		in.c.mu.RUnlock()
+		logger.Warn("[Compaction] Quarantined invalid rows",
+			"file_number", in.fileNumber,
+			"rows", len(bad),
+			"path", in.c.quarantinePath(in.fileNumber))
+	}
+	return nil
+}
+
+// close 关闭输入文件（可以重复调用）
+func (in *compactionInput) close() {
+	if !in.closed {
+		in.closed = true
+		in.reader.Close()
+	}
+}
+
+// compactionMerger 多个输入文件的流式归并
+//
+// 每个输入文件只保留当前行，按 seq 升序逐行产出，相同 seq 保留 _time 最大的记录，
+// 内存占用与输入文件数成正比，与文件大小无关。
+// _time 早于 expireBefore 的行被丢弃（见 RetentionPolicy）；
+// 输入文件之外没有其他数据源含有同一 seq 时，删除标记被丢弃（见 Table.Delete）。
+type compactionMerger struct {
+	c            *Compactor
+	inputs       []*compactionInput
+	expireBefore int64 // 0 表示不过期
+}
+
+// newCompactionMerger 打开输入文件并读取各自的第一行
+// 注意：调用者必须确保传入的文件都存在，否则会返回错误
+func (c *Compactor) newCompactionMerger(files []*FileMetadata) (*compactionMerger, error) {
+	c.mu.RLock()
+	schema := c.schema
+	c.mu.RUnlock()
+
+	m := &compactionMerger{c: c, expireBefore: c.expireBefore.Load()}
+	for _, file := range files {
+		sstPath := filepath.Join(c.sstDir, fmt.Sprintf("%06d.sst", file.FileNumber))
+		reader, err := NewSSTableReaderWithIOMode(sstPath, c.ioMode)
+		if err != nil {
+			m.close()
+			return nil, fmt.Errorf("open sst %d: %w", file.FileNumber, err)
+		}
+		if schema != nil {
+			reader.SetSchema(schema)
+		}
+
+		in := &compactionInput{
+			c:          c,
+			fileNumber: file.FileNumber,
+			reader:     reader,
+			iter:       reader.NewIterator(),
+			schema:     schema,
+		}
+		m.inputs = append(m.inputs, in)
+		if err := in.advance(); err != nil {
+			m.close()
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// next 返回下一个未过期的行，nil 表示所有输入都已读完
+func (m *compactionMerger) next() (*SSTableRow, error) {
+	for {
+		row, err := m.merge()
+		if err != nil || row == nil {
+			return row, err
+		}
+		if row.Deleted && m.dropTombstone(row.Seq) {
+			m.c.droppedTombstones.Add(1)
+			continue
+		}
+		if m.expireBefore == 0 || row.Time >= m.expireBefore {
+			return row, nil
+		}
+		m.c.expiredRows.Add(1)
+		m.c.noteExpiredSeq(row.Seq)
+	}
+}
+
+// merge 返回下一行，nil 表示所有输入都已读完
+//
+// 输入文件通常只有几个到几十个，按顺序比较即可，不需要堆。
+func (m *compactionMerger) merge() (*SSTableRow, error) {
+	var row *SSTableRow
+	for _, in := range m.inputs {
+		if in.row == nil {
+			continue
+		}
+		// 相同 Seq，保留 Time 更大的
+		if row == nil || in.row.Seq < row.Seq || (in.row.Seq == row.Seq && in.row.Time > row.Time) {
+			row = in.row
+		}
+	}
+	if row == nil {
+		return nil, nil
+	}
+
+	for _, in := range m.inputs {
+		if in.row != nil && in.row.Seq == row.Seq {
+			if err := in.advance(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return row, nil
+}
+
+// dropTombstone 删除标记是否可以丢弃：其他文件与 MemTable 中都没有该 seq 的更早版本
+func (m *compactionMerger) dropTombstone(seq int64) bool {
+	if m.c.olderVersions == nil {
+		return false
+	}
+	inputs := make([]int64, len(m.inputs))
+	for i, in := range m.inputs {
+		inputs[i] = in.fileNumber
+	}
+	return !m.c.olderVersions(seq, inputs)
+}
+
+// close 关闭所有输入文件
+func (m *compactionMerger) close() {
+	for _, in := range m.inputs {
+		in.close()
+	}
+}
@@ -0,0 +1,133 @@
+package srdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestSST 写入包含 rows 的 SST 文件，rows 必须按 Seq 升序
+func writeTestSST(t *testing.T, dir string, fileNumber int64, schema *Schema, rows []*SSTableRow) *FileMetadata {
+	t.Helper()
+	file, err := os.Create(filepath.Join(dir, fmt.Sprintf("%06d.sst", fileNumber)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := NewSSTableWriter(file, schema)
+	for _, row := range rows {
+		if err := writer.Add(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	return &FileMetadata{
+		FileNumber: fileNumber,
+		MinKey:     rows[0].Seq,
+		MaxKey:     rows[len(rows)-1].Seq,
+		RowCount:   int64(len(rows)),
+	}
+}
+
+// TestCompactionMerger 测试多个文件的流式归并：按 seq 升序、相同 seq 保留 _time 最大的记录
+func TestCompactionMerger(t *testing.T) {
+	dir := t.TempDir()
+	schema, err := NewSchema("test", []Field{{Name: "v", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	versionSet, err := NewVersionSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer versionSet.Close()
+
+	row := func(seq, time, v int64) *SSTableRow {
+		return &SSTableRow{Seq: seq, Time: time, Data: map[string]any{"v": v}}
+	}
+	files := []*FileMetadata{
+		writeTestSST(t, dir, 1, schema, []*SSTableRow{row(1, 10, 1), row(4, 10, 4), row(5, 10, 5)}),
+		writeTestSST(t, dir, 2, schema, []*SSTableRow{row(2, 10, 2), row(4, 20, 40), row(6, 10, 6)}),
+		writeTestSST(t, dir, 3, schema, []*SSTableRow{row(3, 10, 3), row(5, 5, 50)}),
+	}
+
+	compactor := NewCompactor(dir, versionSet)
+	compactor.SetSchema(schema)
+	merger, err := compactor.newCompactionMerger(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer merger.close()
+
+	want := map[int64]int64{1: 1, 2: 2, 3: 3, 4: 40, 5: 5, 6: 6}
+	var seqs []int64
+	for {
+		r, err := merger.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r == nil {
+			break
+		}
+		if r.Data["v"] != want[r.Seq] {
+			t.Errorf("seq %d: got v=%v, want %d", r.Seq, r.Data["v"], want[r.Seq])
+		}
+		seqs = append(seqs, r.Seq)
+	}
+	if fmt.Sprint(seqs) != "[1 2 3 4 5 6]" {
+		t.Errorf("unexpected seqs: %v", seqs)
+	}
+
+	// 读完后所有输入文件已关闭
+	for _, in := range merger.inputs {
+		if !in.closed {
+			t.Errorf("input %d not closed", in.fileNumber)
+		}
+	}
+}
+
+// TestBTreeCursor 测试游标按升序读取多层 B+Tree 的所有条目
+func TestBTreeCursor(t *testing.T) {
+	dir := t.TempDir()
+	schema, err := NewSchema("test", []Field{{Name: "v", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 超过 BTreeOrder² 个条目，B+Tree 有 3 层
+	n := int64(BTreeOrder*BTreeOrder + 500)
+	rows := make([]*SSTableRow, 0, n)
+	for seq := int64(1); seq <= n; seq++ {
+		rows = append(rows, &SSTableRow{Seq: seq * 2, Time: seq, Data: map[string]any{"v": seq}})
+	}
+	writeTestSST(t, dir, 1, schema, rows)
+
+	reader, err := NewSSTableReader(filepath.Join(dir, "000001.sst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	cursor := reader.btReader.cursor()
+	var count int64
+	for {
+		key, _, _, ok := cursor.next()
+		if !ok {
+			break
+		}
+		count++
+		if key != count*2 {
+			t.Fatalf("entry %d: got key %d", count, key)
+		}
+	}
+	if count != n {
+		t.Errorf("expected %d entries, got %d", n, count)
+	}
+	if _, _, _, ok := cursor.next(); ok {
+		t.Error("cursor should stay exhausted")
+	}
+}
@@ -0,0 +1,131 @@
+package srdb
+
+import "fmt"
+
+// Compaction 模拟：在当前版本的副本上按后台 Compaction 的规则推演，不读写任何文件
+//
+// 用于在修改层级大小限制之前了解工作负载下会发生哪些 Compaction：
+// 每轮依次执行 4 个阶段（与 doCompact 相同），同一阶段的任务基于阶段开始时的版本计算，
+// 任务的输出按输入与重叠文件的大小之和估算（Append-Only 场景下没有重复行），
+// 直到某一轮不再产生任务为止。
+
+// maxPlanRounds 模拟的最大轮数（防止异常配置导致无限推演）
+const maxPlanRounds = 64
+
+// PlannedCompaction PlanCompactions 推演出的一个 Compaction 任务
+type PlannedCompaction struct {
+	Round        int            // 第几轮（从 1 开始，后台每轮依次执行 4 个阶段）
+	Stage        int            // 阶段：0=L0合并, 1=L0升级, 2=L1升级, 3=L2升级
+	Level        int            // 源层级
+	InputFiles   []FileMetadata // 输入文件（编号不小于当前 NextFileNumber 的是前面任务推演出的文件）
+	OverlapFiles []FileMetadata // 输出层级中与输入 key 范围重叠、一起合并的文件
+	InputSize    int64          // 输入与重叠文件的总大小
+	Output       FileMetadata   // 预计的输出文件（Level 为按大小决定的实际层级）
+}
+
+// PlanCompactions 推演当前版本接下来会执行的 Compaction 任务，不执行任何任务
+//
+// opts 为 nil 时使用当前的层级大小限制；否则使用 opts 中的 Level0SizeLimit ~ Level3SizeLimit
+// （为 0 的项沿用当前值），用于在应用新配置之前评估效果。
+// 返回的任务按执行顺序排列，没有需要执行的任务时返回空切片。
+func (m *CompactionManager) PlanCompactions(opts *Options) ([]PlannedCompaction, error) {
+	m.configMu.Lock()
+	limits := [NumLevels]int64{m.level0SizeLimit, m.level1SizeLimit, m.level2SizeLimit, m.level3SizeLimit}
+	m.configMu.Unlock()
+
+	if opts != nil {
+		for level, limit := range [NumLevels]int64{opts.Level0SizeLimit, opts.Level1SizeLimit, opts.Level2SizeLimit, opts.Level3SizeLimit} {
+			if limit != 0 {
+				limits[level] = limit
+			}
+		}
+	}
+	if limits[0] < 1*1024*1024 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "Level0SizeLimit must be at least 1MB, got %d", limits[0])
+	}
+	for level := 1; level < NumLevels; level++ {
+		if limits[level] < limits[level-1] {
+			return nil, NewErrorf(ErrCodeInvalidParam, "Level%dSizeLimit (%d) must be >= Level%dSizeLimit (%d)",
+				level, limits[level], level-1, limits[level-1])
+		}
+	}
+
+	current := m.versionSet.GetCurrent()
+	if current == nil {
+		return nil, fmt.Errorf("no current version")
+	}
+	version := current.Clone()
+
+	picker := NewPicker()
+	picker.UpdateLevelLimits(limits[0], limits[1], limits[2], limits[3])
+	nextFileNumber := version.GetNextFileNumber()
+
+	plan := make([]PlannedCompaction, 0)
+	for round := 1; round <= maxPlanRounds; round++ {
+		planned := 0
+		for stage := range 4 {
+			tasks := picker.PickCompaction(version)
+			if len(tasks) == 0 {
+				continue
+			}
+
+			edit := NewVersionEdit()
+			for _, task := range tasks {
+				p := m.planTask(task, version, nextFileNumber)
+				p.Round = round
+				p.Stage = stage
+				nextFileNumber++
+
+				for _, file := range p.InputFiles {
+					edit.DeleteFile(file.FileNumber)
+				}
+				for _, file := range p.OverlapFiles {
+					edit.DeleteFile(file.FileNumber)
+				}
+				output := p.Output
+				edit.AddFile(&output)
+				plan = append(plan, p)
+			}
+			version.Apply(edit)
+			planned += len(tasks)
+		}
+		if planned == 0 {
+			break
+		}
+	}
+	return plan, nil
+}
+
+// planTask 估算任务的输出文件（对应 Compactor.DoCompaction，不读取文件内容）
+func (m *CompactionManager) planTask(task *CompactionTask, version *Version, fileNumber int64) PlannedCompaction {
+	p := PlannedCompaction{Level: task.Level}
+
+	inputs := make(map[int64]bool, len(task.InputFiles))
+	output := FileMetadata{FileNumber: fileNumber, MinKey: task.InputFiles[0].MinKey, MaxKey: task.InputFiles[0].MaxKey}
+	for _, file := range task.InputFiles {
+		inputs[file.FileNumber] = true
+		p.InputFiles = append(p.InputFiles, *file)
+		output.MinKey = min(output.MinKey, file.MinKey)
+		output.MaxKey = max(output.MaxKey, file.MaxKey)
+		output.FileSize += file.FileSize
+		output.RowCount += file.RowCount
+	}
+
+	// 输出层级中与输入重叠的文件一起合并（按输入文件的 key 范围判断）
+	minKey, maxKey := output.MinKey, output.MaxKey
+	for _, file := range version.GetLevel(task.OutputLevel) {
+		if inputs[file.FileNumber] || file.MaxKey < minKey || file.MinKey > maxKey {
+			continue
+		}
+		p.OverlapFiles = append(p.OverlapFiles, *file)
+		output.MinKey = min(output.MinKey, file.MinKey)
+		output.MaxKey = max(output.MaxKey, file.MaxKey)
+		output.FileSize += file.FileSize
+		output.RowCount += file.RowCount
+	}
+
+	p.InputSize = output.FileSize
+	output.Level = m.compactor.determineLevel(output.FileSize, task.OutputLevel)
+	p.Output = output
+	return p
+}
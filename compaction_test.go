@@ -1058,3 +1058,127 @@ func TestPickL0UpgradeContinuity(t *testing.T) {
 
 	t.Log("=== 升级任务连续性测试通过 ===")
 }
+
+func TestPlanCompactions(t *testing.T) {
+	dir := t.TempDir()
+	versionSet, err := NewVersionSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer versionSet.Close()
+
+	const mb = 1024 * 1024
+	edit := NewVersionEdit()
+	edit.AddFile(&FileMetadata{FileNumber: 1, Level: 0, FileSize: 10 * mb, MinKey: 1, MaxKey: 100, RowCount: 100})
+	edit.AddFile(&FileMetadata{FileNumber: 2, Level: 0, FileSize: 10 * mb, MinKey: 101, MaxKey: 200, RowCount: 100})
+	edit.AddFile(&FileMetadata{FileNumber: 3, Level: 0, FileSize: 40 * mb, MinKey: 201, MaxKey: 300, RowCount: 100})
+	edit.SetNextFileNumber(10)
+	if err := versionSet.LogAndApply(edit); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewCompactionManager(filepath.Join(dir, "sst"), versionSet, nil)
+	plan, err := manager.PlanCompactions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stage 0 合并两个小文件，Stage 1 将合并结果与大文件一起升级到 L1
+	if len(plan) != 2 {
+		t.Fatalf("expected 2 planned compactions, got %+v", plan)
+	}
+	merge, upgrade := plan[0], plan[1]
+	if merge.Round != 1 || merge.Stage != 0 || len(merge.InputFiles) != 2 || merge.InputSize != 20*mb ||
+		merge.Output.FileNumber != 10 || merge.Output.Level != 0 || merge.Output.MinKey != 1 || merge.Output.MaxKey != 200 {
+		t.Errorf("unexpected merge task %+v", merge)
+	}
+	if upgrade.Round != 1 || upgrade.Stage != 1 || len(upgrade.InputFiles) != 2 || upgrade.InputFiles[0].FileNumber != 10 ||
+		upgrade.Output.Level != 1 || upgrade.Output.FileSize != 60*mb || upgrade.Output.RowCount != 300 {
+		t.Errorf("unexpected upgrade task %+v", upgrade)
+	}
+
+	// 评估更小的 L1 限制：L1 文件会在同一轮继续升级到 L2
+	plan, err = manager.PlanCompactions(&Options{Level0SizeLimit: 32 * mb, Level1SizeLimit: 50 * mb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 3 || plan[2].Stage != 2 || plan[2].Level != 1 || plan[2].Output.Level != 2 {
+		t.Fatalf("unexpected plan with smaller L1 limit %+v", plan)
+	}
+
+	// 推演不修改当前版本
+	if n := versionSet.GetCurrent().GetLevelFileCount(0); n != 3 {
+		t.Errorf("expected 3 L0 files to remain, got %d", n)
+	}
+
+	if _, err := manager.PlanCompactions(&Options{Level1SizeLimit: 1 * mb}); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for decreasing level limits, got %v", err)
+	}
+}
+
+func TestSSTTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	table, err := OpenTable(&TableOptions{
+		Dir:    dir,
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	sstDir := filepath.Join(dir, "sst")
+	tempFiles := func() []string {
+		matches, _ := filepath.Glob(filepath.Join(sstDir, "*"+sstTempSuffix))
+		return matches
+	}
+
+	// flush 与 compaction 写完后只留下正式的 SST 文件
+	for round := range 2 {
+		for i := range 10 {
+			if err := table.Insert(map[string]any{"n": int64(round*10 + i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		for table.memtableManager.GetImmutableCount() > 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	manager := table.GetCompactionManager()
+	level0 := table.versionSet.GetCurrent().GetLevel(0)
+	if err := manager.DoCompaction(&CompactionTask{Level: 0, InputFiles: level0, OutputLevel: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if files := tempFiles(); len(files) != 0 {
+		t.Errorf("unexpected temp files %v", files)
+	}
+
+	// 崩溃残留的临时文件：未超过最小年龄时保留，之后由 GC 删除
+	stale := filepath.Join(sstDir, "000999.sst"+sstTempSuffix)
+	if err := os.WriteFile(stale, []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager.CleanupOrphanFiles()
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("expected young temp file to remain: %v", err)
+	}
+	manager.configMu.Lock()
+	manager.gcFileMinAge = 0
+	manager.configMu.Unlock()
+	manager.CleanupOrphanFiles()
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale temp file to be deleted, got %v", err)
+	}
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if n := rows.Count(); n != 20 {
+		t.Errorf("expected 20 rows, got %d", n)
+	}
+}
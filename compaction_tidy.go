@@ -0,0 +1,115 @@
+package srdb
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// DefaultL0TidyFileSize 默认的 L0 小文件整理阈值（见 Options.L0TidyFileSize）
+const DefaultL0TidyFileSize = 1024 * 1024 // 1MB
+
+// pickL0TidyTasks 选择 L0 小文件的整理任务（不受层级大小阈值限制）
+//
+// 策略：
+//   - 按数据大小（见 sstDataSize）判断小文件，而不是文件大小：每个文件都为索引预留了 10MB 空间
+//   - 按 seq 顺序遍历，小于 maxSize 的连续文件合并为一个任务（与 pickL0MergeTasks 一样只合并相邻文件）
+//   - 合并后仍然小于 maxSize 时并入相邻文件（前一个文件已在任务中时并入该任务，否则选择较小的相邻文件），
+//     否则被大文件隔开的小文件永远凑不够合并条件；整理一次后 L0 中不再有可以整理的文件
+//   - OutputLevel=0，由 determineLevel 按输出大小决定层级
+//
+// 为什么需要整理？
+//   - 每次关闭表都会 flush 出一个很小的 L0 文件，频繁重启后 L0 积累大量远小于阈值的文件
+//   - 这些文件夹在大文件之间时不会被 Stage 0 合并，增加每次查询需要打开的文件数
+func (p *Picker) pickL0TidyTasks(version *Version, maxSize int64) []*CompactionTask {
+	files := version.GetLevel(0)
+	if len(files) < 2 || maxSize <= 0 {
+		return nil
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].MinKey < files[j].MinKey
+	})
+
+	var tasks []*CompactionTask
+	taken := -1 // 最后一个任务中最后一个文件的位置
+	for i := 0; i < len(files); {
+		if sstDataSize(files[i]) >= maxSize {
+			i++
+			continue
+		}
+		// [i, j) 为连续的小文件
+		j := i + 1
+		total := sstDataSize(files[i])
+		for j < len(files) && sstDataSize(files[j]) < maxSize {
+			total += sstDataSize(files[j])
+			j++
+		}
+		inputs := slices.Clone(files[i:j])
+		next := j // 下一个未处理的文件
+
+		// 合并后仍是小文件：并入相邻文件（相邻文件都不是小文件）
+		if total < maxSize {
+			switch {
+			case i > 0 && taken == i-1:
+				last := tasks[len(tasks)-1]
+				last.InputFiles = append(last.InputFiles, inputs...)
+				taken = j - 1
+				inputs = nil
+			case i > 0 && (j == len(files) || sstDataSize(files[i-1]) <= sstDataSize(files[j])):
+				inputs = append([]*FileMetadata{files[i-1]}, inputs...)
+			case j < len(files):
+				inputs = append(inputs, files[j])
+				next = j + 1
+			}
+		}
+		if len(inputs) > 1 {
+			tasks = append(tasks, &CompactionTask{
+				Level:       0,
+				InputFiles:  inputs,
+				OutputLevel: 0,
+			})
+			taken = next - 1
+		}
+		i = next
+	}
+	return tasks
+}
+
+// sstDataSize 估算 SST 文件中数据的大小：文件大小减去文件头与为索引预留的空间
+func sstDataSize(file *FileMetadata) int64 {
+	return max(file.FileSize-SSTableHeaderSize-sstIndexReserveSize, 0)
+}
+
+// TidyL0 合并 L0 中数据小于 L0TidyFileSize 的文件，返回被合并的输入文件数
+//
+// 后台 Compaction 在表打开后的第一次检查时自动执行一次（DisableAutoCompaction 时不执行），
+// 也可以手动调用；与其他 Compaction 串行执行。
+func (m *CompactionManager) TidyL0() (int, error) {
+	m.compactionMu.Lock()
+	defer m.compactionMu.Unlock()
+
+	m.configMu.Lock()
+	maxSize := m.tidyFileSize
+	m.configMu.Unlock()
+
+	version := m.versionSet.GetCurrent()
+	if version == nil {
+		return 0, fmt.Errorf("no current version")
+	}
+	tasks := m.compactor.GetPicker().pickL0TidyTasks(version, maxSize)
+
+	merged := 0
+	for _, task := range tasks {
+		if err := m.DoCompactionWithVersion(task, m.versionSet.GetCurrent()); err != nil {
+			return merged, err
+		}
+		merged += len(task.InputFiles)
+	}
+	if merged > 0 {
+		m.logger.Info("[Compaction] Tidied small L0 files",
+			"tasks", len(tasks),
+			"files", merged,
+			"max_size", maxSize)
+	}
+	return merged, nil
+}
@@ -0,0 +1,110 @@
+package srdb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPickL0TidyTasks(t *testing.T) {
+	const kb = 1024
+	version := NewVersion()
+	// 小 | 大 | 小 小 小 | 大 | 小 | 大 | 小
+	sizes := []int64{10 * kb, 4096 * kb, 10 * kb, 20 * kb, 30 * kb, 2048 * kb, 10 * kb, 3072 * kb, 10 * kb}
+	for i, size := range sizes {
+		version.Levels[0] = append(version.Levels[0], &FileMetadata{
+			FileNumber: int64(i + 1),
+			FileSize:   SSTableHeaderSize + sstIndexReserveSize + size,
+			MinKey:     int64(i*100 + 1),
+			MaxKey:     int64(i*100 + 100),
+		})
+	}
+
+	tasks := NewPicker().pickL0TidyTasks(version, 1024*kb)
+	var got [][]int64
+	for _, task := range tasks {
+		var numbers []int64
+		for _, file := range task.InputFiles {
+			numbers = append(numbers, file.FileNumber)
+		}
+		got = append(got, numbers)
+	}
+	// 合并后仍是小文件时并入相邻文件：前一个文件已在任务中时并入该任务，否则选择较小的相邻文件
+	want := [][]int64{{1, 2, 3, 4, 5}, {6, 7}, {8, 9}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("expected tasks %v, got %v", want, got)
+	}
+
+	if tasks := NewPicker().pickL0TidyTasks(version, -1); len(tasks) != 0 {
+		t.Errorf("expected no tasks when disabled, got %d", len(tasks))
+	}
+}
+
+func TestTidyL0(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "payload", Type: String}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	flush := func(count int, size int) {
+		t.Helper()
+		for range count {
+			payload := make([]byte, size)
+			rand.Read(payload)
+			if err := table.Insert(map[string]any{"payload": hex.EncodeToString(payload)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		for table.memtableManager.GetImmutableCount() > 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	// 三个小文件 | 一个大文件 | 一个小文件：合并为一个文件
+	flush(1, 10)
+	flush(1, 10)
+	flush(1, 10)
+	flush(200, 1024)
+	flush(1, 10)
+
+	manager := table.GetCompactionManager()
+	if n := len(table.versionSet.GetCurrent().GetLevel(0)); n != 5 {
+		t.Fatalf("expected 5 L0 files, got %d", n)
+	}
+	manager.configMu.Lock()
+	manager.tidyFileSize = 64 * 1024
+	manager.configMu.Unlock()
+
+	merged, err := manager.TidyL0()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged != 5 {
+		t.Errorf("expected 5 merged files, got %d", merged)
+	}
+	if n := len(table.versionSet.GetCurrent().GetLevel(0)); n != 1 {
+		t.Errorf("expected 1 L0 file after tidy, got %d", n)
+	}
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if n := rows.Count(); n != 204 {
+		t.Errorf("expected 204 rows, got %d", n)
+	}
+
+	// 已经整理过，没有新的任务
+	if merged, err := manager.TidyL0(); err != nil || merged != 0 {
+		t.Errorf("expected nothing to tidy, got %d %v", merged, err)
+	}
+}
@@ -0,0 +1,109 @@
+package srdb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestParanoidCompactionChecks 测试开启输出校验后 Compaction 正常完成
+func TestParanoidCompactionChecks(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:                      t.TempDir(),
+		Name:                     "events",
+		Fields:                   []Field{{Name: "n", Type: Int64}, {Name: "name", Type: String}},
+		ParanoidCompactionChecks: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for batch := range 4 {
+		for i := range 10 {
+			if err := table.Insert(map[string]any{"n": int64(batch*10 + i), "name": "row"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for table.memtableManager.GetImmutableCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("flush did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	before := table.Stats().SSTCount
+	if err := table.compactionManager.TriggerCompaction(); err != nil {
+		t.Fatal(err)
+	}
+	if after := table.Stats().SSTCount; after >= before {
+		t.Errorf("expected fewer sst files after compaction, got %d -> %d", before, after)
+	}
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := rows.Collect(); len(data) != 40 {
+		t.Errorf("expected 40 rows after compaction, got %d", len(data))
+	}
+}
+
+// TestCompactorVerifyOutputFile 测试输出文件与摘要不一致时返回错误
+func TestCompactorVerifyOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	schema, err := NewSchema("test", []Field{{Name: "v", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	versionSet, err := NewVersionSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer versionSet.Close()
+
+	rows := []*SSTableRow{
+		{Seq: 1, Time: 10, Data: map[string]any{"v": int64(1)}},
+		{Seq: 2, Time: 10, Data: map[string]any{"v": int64(2)}},
+		{Seq: 3, Time: 10, Data: map[string]any{"v": int64(3)}},
+	}
+	writeTestSST(t, dir, 1, schema, rows)
+	path := filepath.Join(dir, "000001.sst")
+
+	digests := make([]rowDigest, len(rows))
+	for i, row := range rows {
+		if digests[i], err = digestRow(row, schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	compactor := NewCompactor(dir, versionSet)
+	compactor.SetSchema(schema)
+	if err := compactor.verifyOutputFile(path, schema, digests); err != nil {
+		t.Fatalf("expected matching file to verify, got %v", err)
+	}
+
+	// 行数不一致
+	if err := compactor.verifyOutputFile(path, schema, digests[:2]); !IsError(err, ErrCodeCorrupted) {
+		t.Errorf("expected corrupted for row count, got %v", err)
+	}
+
+	// seq 不一致
+	wrongSeq := append([]rowDigest(nil), digests...)
+	wrongSeq[1].seq = 5
+	if err := compactor.verifyOutputFile(path, schema, wrongSeq); !IsError(err, ErrCodeCorrupted) {
+		t.Errorf("expected corrupted for seq, got %v", err)
+	}
+
+	// 数据不一致
+	wrongSum := append([]rowDigest(nil), digests...)
+	wrongSum[2].sum++
+	if err := compactor.verifyOutputFile(path, schema, wrongSum); !IsError(err, ErrCodeChecksumMismatch) {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package srdb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 计算列函数
+const (
+	computedLower     = "lower"      // lower(field)：转为小写，用于大小写不敏感的查找
+	computedUpper     = "upper"      // upper(field)：转为大写
+	computedTrim      = "trim"       // trim(field)：去除首尾空白
+	computedDateTrunc = "date_trunc" // date_trunc(unit, field)：按时间单位截断（UTC），用于按天/小时分组
+	computedComposite = "composite"  // composite(a, b, ...)：多个字段组成的复合键，用于复合索引
+)
+
+// computedExpr 解析后的计算列表达式
+type computedExpr struct {
+	fn     string // 函数名
+	unit   string // date_trunc 的截断单位：second/minute/hour/day/month/year
+	source string // 源字段名，date_trunc 可以使用 _time
+
+	sources []string // composite 的源字段（按顺序）
+}
+
+// parseComputedExpr 解析计算列表达式
+//
+// 支持的表达式：
+//   - lower(field)、upper(field)、trim(field)：源字段与计算列均为 String
+//   - date_trunc(unit, field)：源字段为 Time 或 _time，计算列为 Time
+//   - composite(a, b, ...)：至少两个标量源字段，计算列为 String（见 compositeKey）
+func parseComputedExpr(expr string) (*computedExpr, error) {
+	expr = strings.TrimSpace(expr)
+	open := strings.IndexByte(expr, '(')
+	if open <= 0 || !strings.HasSuffix(expr, ")") {
+		return nil, fmt.Errorf("invalid computed expression %q", expr)
+	}
+
+	fn := strings.ToLower(strings.TrimSpace(expr[:open]))
+	args := strings.Split(expr[open+1:len(expr)-1], ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+
+	switch fn {
+	case computedLower, computedUpper, computedTrim:
+		if len(args) != 1 || args[0] == "" {
+			return nil, fmt.Errorf("%s expects 1 argument, got %q", fn, expr)
+		}
+		return &computedExpr{fn: fn, source: args[0]}, nil
+
+	case computedDateTrunc:
+		if len(args) != 2 || args[1] == "" {
+			return nil, fmt.Errorf("%s expects 2 arguments, got %q", fn, expr)
+		}
+		unit := strings.ToLower(args[0])
+		switch unit {
+		case "second", "minute", "hour", "day", "month", "year":
+		default:
+			return nil, fmt.Errorf("%s: unsupported unit %q", fn, args[0])
+		}
+		return &computedExpr{fn: fn, unit: unit, source: args[1]}, nil
+
+	case computedComposite:
+		if len(args) < 2 || slices.Contains(args, "") {
+			return nil, fmt.Errorf("%s expects at least 2 fields, got %q", fn, expr)
+		}
+		return &computedExpr{fn: fn, sources: args}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported computed function %q", fn)
+	}
+}
+
+// resultType 计算结果的类型
+func (e *computedExpr) resultType() FieldType {
+	if e.fn == computedDateTrunc {
+		return Time
+	}
+	return String
+}
+
+// sourceType 源字段要求的类型
+func (e *computedExpr) sourceType() FieldType {
+	return e.resultType()
+}
+
+// eval 计算结果，源字段不存在或为 NULL 时返回 nil
+// data 中的值必须已经按 Schema 转换类型，rowTime 为行的 _time（UnixNano）
+func (e *computedExpr) eval(data map[string]any, rowTime int64) (any, error) {
+	if e.fn == computedComposite {
+		values := make([]any, len(e.sources))
+		for i, source := range e.sources {
+			if values[i] = data[source]; values[i] == nil {
+				return nil, nil
+			}
+		}
+		return compositeKey(values), nil
+	}
+
+	var value any
+	if e.source == "_time" {
+		value = time.Unix(0, rowTime)
+	} else {
+		value = data[e.source]
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	switch e.fn {
+	case computedLower, computedUpper, computedTrim:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s(%s): expected string, got %T", e.fn, e.source, value)
+		}
+		switch e.fn {
+		case computedLower:
+			return strings.ToLower(s), nil
+		case computedUpper:
+			return strings.ToUpper(s), nil
+		default:
+			return strings.TrimSpace(s), nil
+		}
+
+	case computedDateTrunc:
+		t, ok := value.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("%s(%s): expected time, got %T", e.fn, e.source, value)
+		}
+		t = t.UTC()
+		switch e.unit {
+		case "second":
+			return t.Truncate(time.Second), nil
+		case "minute":
+			return t.Truncate(time.Minute), nil
+		case "hour":
+			return t.Truncate(time.Hour), nil
+		case "day":
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		case "month":
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+		default:
+			return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported computed function %q", e.fn)
+}
+
+// validateComputed 验证计算列定义
+// 源字段必须存在、类型匹配且不能是计算列（不支持计算列链）
+func validateComputed(field Field, fields []Field) error {
+	expr, err := parseComputedExpr(field.Computed)
+	if err != nil {
+		return fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	if field.Type != expr.resultType() {
+		return fmt.Errorf("field %s: %s produces %s, field type is %s", field.Name, expr.fn, expr.resultType(), field.Type)
+	}
+	if expr.fn == computedComposite {
+		return validateComposite(field, expr.sources, fields)
+	}
+	if expr.source == "_time" {
+		if expr.fn != computedDateTrunc {
+			return fmt.Errorf("field %s: %s cannot be applied to _time", field.Name, expr.fn)
+		}
+		return nil
+	}
+	if expr.source == field.Name {
+		return fmt.Errorf("field %s: computed field cannot reference itself", field.Name)
+	}
+	for _, f := range fields {
+		if f.Name != expr.source {
+			continue
+		}
+		if f.Computed != "" {
+			return fmt.Errorf("field %s: source field %s is computed", field.Name, f.Name)
+		}
+		if f.Type != expr.sourceType() {
+			return fmt.Errorf("field %s: %s expects %s source, field %s is %s", field.Name, expr.fn, expr.sourceType(), f.Name, f.Type)
+		}
+		return nil
+	}
+	return fmt.Errorf("field %s: source field %s not found", field.Name, expr.source)
+}
+
+// hasComputed 是否包含计算列
+func (s *Schema) hasComputed() bool {
+	for _, field := range s.Fields {
+		if field.Computed != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyComputed 计算所有计算列并写入 data（覆盖写入时提供的值）
+// data 中的值必须已经按 Schema 转换类型，rowTime 为行的 _time（UnixNano）
+func (s *Schema) applyComputed(data map[string]any, rowTime int64) error {
+	for _, field := range s.Fields {
+		if field.Computed == "" {
+			continue
+		}
+		expr, err := parseComputedExpr(field.Computed)
+		if err != nil {
+			return NewErrorf(ErrCodeSchemaInvalid, "field %s: %v", field.Name, err)
+		}
+		value, err := expr.eval(data, rowTime)
+		if err != nil {
+			return NewErrorf(ErrCodeSchemaValidationFailed, "compute field %s: %v", field.Name, err)
+		}
+		if value == nil {
+			delete(data, field.Name)
+			continue
+		}
+		data[field.Name] = value
+	}
+	return nil
+}
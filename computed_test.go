@@ -0,0 +1,138 @@
+package srdb
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestComputedColumns(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestComputedColumns")
+	defer os.RemoveAll(tmpDir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:  tmpDir,
+		Name: "users",
+		Fields: []Field{
+			{Name: "email", Type: String},
+			{Name: "email_lower", Type: String, Indexed: true, Computed: "lower(email)"},
+			{Name: "created_day", Type: Time, Computed: "date_trunc(day, _time)"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	data := map[string]any{"email": "Alice@Example.COM", "email_lower": "ignored"}
+	if err := table.Insert(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"email": "bob@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if data["email_lower"] != "ignored" {
+		t.Error("Insert should not modify the caller's map")
+	}
+
+	check := func() {
+		t.Helper()
+		rows, err := table.Query().Eq("email_lower", "alice@example.com").Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		count := 0
+		for rows.Next() {
+			count++
+			row := rows.Row().Data()
+			if row["email"] != "Alice@Example.COM" {
+				t.Errorf("unexpected email %v", row["email"])
+			}
+			day, ok := row["created_day"].(time.Time)
+			if !ok {
+				t.Fatalf("expected time.Time, got %T", row["created_day"])
+			}
+			now := time.Now().UTC()
+			want := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			if !day.Equal(want) && !day.Equal(want.AddDate(0, 0, -1)) {
+				t.Errorf("expected created_day %v, got %v", want, day)
+			}
+		}
+		if count != 1 {
+			t.Errorf("expected 1 row, got %d", count)
+		}
+	}
+
+	check()
+
+	// 索引构建后通过索引查找
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	if idx, ok := table.GetIndex("email_lower"); !ok || !idx.IsReady() {
+		t.Fatal("expected email_lower index to be ready")
+	}
+	check()
+}
+
+func TestComputedSchemaValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []Field
+		valid  bool
+	}{
+		{"lower", []Field{{Name: "a", Type: String}, {Name: "b", Type: String, Computed: "lower(a)"}}, true},
+		{"upper", []Field{{Name: "a", Type: String}, {Name: "b", Type: String, Computed: "UPPER( a )"}}, true},
+		{"date_trunc time", []Field{{Name: "b", Type: Time, Computed: "date_trunc(hour, _time)"}}, true},
+		{"date_trunc field", []Field{{Name: "a", Type: Time}, {Name: "b", Type: Time, Computed: "date_trunc(month, a)"}}, true},
+		{"unknown function", []Field{{Name: "a", Type: String}, {Name: "b", Type: String, Computed: "reverse(a)"}}, false},
+		{"malformed", []Field{{Name: "a", Type: String}, {Name: "b", Type: String, Computed: "lower(a"}}, false},
+		{"missing source", []Field{{Name: "b", Type: String, Computed: "lower(a)"}}, false},
+		{"self reference", []Field{{Name: "b", Type: String, Computed: "lower(b)"}}, false},
+		{"result type", []Field{{Name: "a", Type: String}, {Name: "b", Type: Int64, Computed: "lower(a)"}}, false},
+		{"source type", []Field{{Name: "a", Type: Int64}, {Name: "b", Type: String, Computed: "lower(a)"}}, false},
+		{"chained", []Field{{Name: "a", Type: String}, {Name: "b", Type: String, Computed: "lower(a)"}, {Name: "c", Type: String, Computed: "trim(b)"}}, false},
+		{"bad unit", []Field{{Name: "b", Type: Time, Computed: "date_trunc(week, _time)"}}, false},
+		{"string on _time", []Field{{Name: "b", Type: String, Computed: "lower(_time)"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSchema("test", tt.fields)
+			if tt.valid && err != nil {
+				t.Errorf("expected valid schema, got %v", err)
+			}
+			if !tt.valid && !IsError(err, ErrCodeSchemaInvalid) {
+				t.Errorf("expected ErrCodeSchemaInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestComputedStructTag(t *testing.T) {
+	type User struct {
+		Email      string `srdb:"email"`
+		EmailLower string `srdb:"email_lower;indexed;computed:lower(email)"`
+	}
+
+	fields, err := StructToFields(User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields[1].Computed != "lower(email)" || !fields[1].Indexed {
+		t.Errorf("unexpected field %+v", fields[1])
+	}
+
+	// 计算列参与校验和，未配置时校验和不变
+	plain, _ := NewSchema("users", fields[:1])
+	withComputed, _ := NewSchema("users", fields)
+	withoutComputed, _ := NewSchema("users", []Field{fields[0], {Name: "email_lower", Type: String, Indexed: true}})
+	if withComputed.IsCompatibleWith(withoutComputed) {
+		t.Error("computed expression should be part of the schema structure")
+	}
+	if plain.IsCompatibleWith(withComputed) {
+		t.Error("schemas with different fields should not be compatible")
+	}
+}
@@ -0,0 +1,50 @@
+package srdb
+
+import "context"
+
+// cancelCheckInterval 扫描时每读取多少行检查一次 context 是否已取消
+const cancelCheckInterval = 64
+
+// InsertContext 与 Insert 相同，ctx 已取消时不写入并返回 ctx.Err()
+//
+// 设置了 MaxConcurrentWriters 时，排队等待名额期间取消会放弃排队；
+// 开始写入之后不再检查 ctx，已开始的写入不会只写入一部分。
+func (t *Table) InsertContext(ctx context.Context, data any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rows, err := t.normalizeInsertData(data)
+	if err != nil {
+		return err
+	}
+	_, err = t.insertBatch(ctx, rows)
+	return err
+}
+
+// GetContext 与 Get 相同，ctx 已取消时返回 ctx.Err()
+func (t *Table) GetContext(ctx context.Context, seq int64) (*SSTableRow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return t.Get(seq)
+}
+
+// RowsContext 与 WithContext(ctx).Rows() 相同：ctx 取消后 Next 返回 false，Err 返回 ctx.Err()
+//
+// 取消后立即结束扫描并释放 SST 文件的读取状态，不需要等到 Close；
+// 扫描中每读取 cancelCheckInterval 行检查一次，跳过大量不匹配的行时也能及时停止。
+// ctx 同时用于行级可见性策略（见 SetRowPolicy）。
+func (qb *QueryBuilder) RowsContext(ctx context.Context) (*Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return qb.WithContext(ctx).Rows()
+}
+
+// canceled 返回查询 context 的取消原因，没有 context 或未取消时返回 nil
+func (qb *QueryBuilder) canceled() error {
+	if qb.ctx == nil {
+		return nil
+	}
+	return qb.ctx.Err()
+}
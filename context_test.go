@@ -0,0 +1,124 @@
+package srdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestContextCancellation(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 1000 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 迭代中途取消：Next 返回 false，扫描状态立即释放
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, err := table.Query().RowsContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		if !rows.Next() {
+			t.Fatal("expected rows before cancel")
+		}
+	}
+	cancel()
+	if rows.Next() {
+		t.Error("expected Next to stop after cancel")
+	}
+	if !errors.Is(rows.Err(), context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", rows.Err())
+	}
+	if rows.scanning != nil || table.iterators.Load() != 0 {
+		t.Error("expected the scan to be released before Close")
+	}
+	rows.Close()
+
+	// 跳过不匹配的行时也会定期检查
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	checked := 0
+	table.SetRowPolicy(func(context.Context, map[string]any) bool {
+		if checked++; checked == 100 {
+			cancel()
+		}
+		return false
+	})
+	rows, err = table.Query().RowsContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Next() || !errors.Is(rows.Err(), context.Canceled) || checked > 100+cancelCheckInterval {
+		t.Errorf("expected the scan to stop soon after cancel, checked %d rows, err %v", checked, rows.Err())
+	}
+	rows.Close()
+	table.SetRowPolicy(nil)
+
+	if _, err := table.Query().RowsContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected RowsContext to fail with a canceled context, got %v", err)
+	}
+	if err := table.InsertContext(ctx, map[string]any{"n": 1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected InsertContext to fail, got %v", err)
+	}
+	if _, err := table.GetContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected GetContext to fail, got %v", err)
+	}
+	if err := table.InsertContext(context.Background(), map[string]any{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if row, err := table.GetContext(context.Background(), 1001); err != nil || row.Data["n"] != int64(1) {
+		t.Errorf("expected the inserted row, got %v %v", row, err)
+	}
+	if n, _ := table.Query().Count(); n != 1001 {
+		t.Errorf("expected 1001 rows, got %d", n)
+	}
+}
+
+func TestWriterGateCancel(t *testing.T) {
+	gate := newWriterGate(1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go gate.do([]map[string]any{{}}, func([]map[string]any) (int64, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	// 排队期间取消：放弃排队，不执行写入
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	wrote := false
+	if _, err := gate.doContext(ctx, []map[string]any{{}}, func([]map[string]any) (int64, error) {
+		wrote = true
+		return 2, nil
+	}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	close(release)
+	if seq, err := gate.do([]map[string]any{{}}, func([]map[string]any) (int64, error) { return 3, nil }); err != nil || seq != 3 {
+		t.Errorf("expected the gate to stay usable, got %d %v", seq, err)
+	}
+	if wrote || len(gate.queue) != 0 {
+		t.Error("expected the canceled request to be removed from the queue")
+	}
+}
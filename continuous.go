@@ -0,0 +1,538 @@
+package srdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ContinuousAggregate 连续聚合的定义
+//
+// 按时间桶（如每分钟）预先计算聚合，插入时增量更新，查询时直接返回各时间桶的结果，
+// 不需要扫描原始数据。时间桶按 Unix 纪元（UTC）对齐，[Start, Start+Bucket)。
+type ContinuousAggregate struct {
+	Name       string        // 名称（同时用作文件名）
+	Bucket     time.Duration // 时间桶大小，如 time.Minute
+	TimeField  string        // 分桶使用的 Time 字段，为空时使用行的写入时间 _time；字段为 NULL 的行不参与
+	Aggregates []Aggregate   // 每个时间桶计算的聚合（Count、Sum、Avg、Min、Max）
+}
+
+// AggregateBucket 连续聚合一个时间桶的结果
+type AggregateBucket struct {
+	Start  time.Time // 时间桶起始时间
+	Values []any     // 与 Aggregates 按顺序对应，类型同 QueryBuilder.Aggregate
+}
+
+// continuousAggregate 一个连续聚合的状态
+type continuousAggregate struct {
+	def      ContinuousAggregate
+	template []*aggState // 每个聚合的空状态（已按 Schema 校验）
+
+	mu      sync.Mutex
+	buckets map[int64][]*aggState // 时间桶起始时间（UnixNano）→ 聚合状态
+	maxSeq  int64                 // 已处理的最大 seq
+	skipTo  int64                 // 创建时回填覆盖的 seq，不大于它的插入由回填处理
+	dirty   bool                  // 有尚未持久化的更新
+}
+
+// continuousManager 管理表的连续聚合，每个连续聚合持久化为 cagg/ 目录下的一个 JSON 文件
+type continuousManager struct {
+	dir    string
+	schema *Schema
+	mu     sync.RWMutex
+	aggs   map[string]*continuousAggregate
+}
+
+// continuousAggregateFile 连续聚合的持久化格式
+type continuousAggregateFile struct {
+	Definition ContinuousAggregate    `json:"definition"`
+	MaxSeq     int64                  `json:"max_seq"`
+	Buckets    []continuousBucketJSON `json:"buckets"`
+}
+
+type continuousBucketJSON struct {
+	Start  int64          `json:"start"`
+	States []aggStateJSON `json:"states"`
+}
+
+// aggStateJSON 聚合累加状态的持久化格式
+type aggStateJSON struct {
+	Count    int64  `json:"count"`
+	Int      int64  `json:"int,omitempty"`
+	Uint     uint64 `json:"uint,omitempty"`
+	Float    uint64 `json:"float,omitempty"` // math.Float64bits，可以表示 ±Inf
+	Decimal  string `json:"decimal,omitempty"`
+	Big      string `json:"big,omitempty"`
+	Overflow bool   `json:"overflow,omitempty"`
+}
+
+// newContinuousManager 加载 dir 下的所有连续聚合，无法读取或定义无效的文件被跳过
+func newContinuousManager(dir string, schema *Schema) *continuousManager {
+	m := &continuousManager{
+		dir:    dir,
+		schema: schema,
+		aggs:   make(map[string]*continuousAggregate),
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var file continuousAggregateFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			continue
+		}
+		c, err := m.newAggregate(file.Definition)
+		if err != nil {
+			continue
+		}
+		if err := c.restore(&file); err != nil {
+			continue
+		}
+		m.aggs[c.def.Name] = c
+	}
+	return m
+}
+
+// newAggregate 校验定义并创建空的连续聚合
+func (m *continuousManager) newAggregate(def ContinuousAggregate) (*continuousAggregate, error) {
+	if def.Name == "" || def.Name == "." || def.Name == ".." || strings.ContainsAny(def.Name, `/\`) {
+		return nil, NewErrorf(ErrCodeInvalidParam, "invalid continuous aggregate name %q", def.Name)
+	}
+	if def.Bucket <= 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "continuous aggregate %s: bucket must be positive", def.Name)
+	}
+	if len(def.Aggregates) == 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "continuous aggregate %s: no aggregates specified", def.Name)
+	}
+	if def.TimeField != "" {
+		field, err := m.schema.GetField(def.TimeField)
+		if err != nil {
+			return nil, NewErrorf(ErrCodeFieldNotFound, "field %s not found", def.TimeField)
+		}
+		if field.Type != Time {
+			return nil, NewErrorf(ErrCodeFieldTypeMismatch, "continuous aggregate %s: field %s of type %s is not a time", def.Name, def.TimeField, field.Type)
+		}
+	}
+	template := make([]*aggState, len(def.Aggregates))
+	for i, agg := range def.Aggregates {
+		state, err := newAggState(agg, m.schema)
+		if err != nil {
+			return nil, err
+		}
+		template[i] = state
+	}
+
+	def.Aggregates = slices.Clone(def.Aggregates)
+	return &continuousAggregate{def: def, template: template, buckets: make(map[int64][]*aggState)}, nil
+}
+
+// path 返回连续聚合的文件路径
+func (m *continuousManager) path(name string) string {
+	return filepath.Join(m.dir, name+".json")
+}
+
+// create 注册新的连续聚合，skipTo 之前的数据由调用者回填
+func (m *continuousManager) create(def ContinuousAggregate, skipTo int64) (*continuousAggregate, error) {
+	c, err := m.newAggregate(def)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.aggs[def.Name]; exists {
+		return nil, NewErrorf(ErrCodeExists, "continuous aggregate %s already exists", def.Name)
+	}
+	c.skipTo = skipTo
+	c.maxSeq = skipTo
+	m.aggs[def.Name] = c
+	return c, nil
+}
+
+// drop 删除连续聚合及其文件
+func (m *continuousManager) drop(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.aggs[name]; !exists {
+		return NewErrorf(ErrCodeNotFound, "continuous aggregate %s not found", name)
+	}
+	delete(m.aggs, name)
+	if err := os.Remove(m.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// get 返回连续聚合
+func (m *continuousManager) get(name string) (*continuousAggregate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, exists := m.aggs[name]
+	if !exists {
+		return nil, NewErrorf(ErrCodeNotFound, "continuous aggregate %s not found", name)
+	}
+	return c, nil
+}
+
+// list 返回所有连续聚合的定义（按名称排序）
+func (m *continuousManager) list() []ContinuousAggregate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	defs := make([]ContinuousAggregate, 0, len(m.aggs))
+	for _, name := range slices.Sorted(maps.Keys(m.aggs)) {
+		def := m.aggs[name].def
+		def.Aggregates = slices.Clone(def.Aggregates)
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// add 将新插入的一行累加到所有连续聚合
+func (m *continuousManager) add(data map[string]any, rowTime, seq int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, c := range m.aggs {
+		c.mu.Lock()
+		if seq > c.skipTo {
+			c.addLocked(data, rowTime, seq)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// repair 累加持久化之后写入的行（崩溃后从 WAL 恢复的数据）
+func (m *continuousManager) repair(currentMaxSeq int64, getRow func(int64) (*SSTableRow, error)) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, c := range m.aggs {
+		c.mu.Lock()
+		for seq := c.maxSeq + 1; seq <= currentMaxSeq; seq++ {
+			row, err := getRow(seq)
+			if err != nil {
+				continue // 跳过读取失败的行
+			}
+			c.addLocked(row.Data, row.Time, seq)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// save 持久化所有有更新的连续聚合
+func (m *continuousManager) save() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var firstErr error
+	for name, c := range m.aggs {
+		if err := c.save(m.dir, m.path(name)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// reset 清空所有连续聚合的数据（表被清空时调用），保留定义
+func (m *continuousManager) reset() error {
+	m.mu.RLock()
+	for _, c := range m.aggs {
+		c.mu.Lock()
+		c.buckets = make(map[int64][]*aggState)
+		c.maxSeq = 0
+		c.skipTo = 0
+		c.dirty = true
+		c.mu.Unlock()
+	}
+	m.mu.RUnlock()
+	return m.save()
+}
+
+// prune 删除连续聚合中结束时间不晚于 before（UnixNano）的时间桶，返回删除的数量
+func (m *continuousManager) prune(name string, before int64) (int, error) {
+	c, err := m.get(name)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	pruned := 0
+	for start := range c.buckets {
+		if start <= before-int64(c.def.Bucket) {
+			delete(c.buckets, start)
+			pruned++
+		}
+	}
+	if pruned > 0 {
+		c.dirty = true
+	}
+	c.mu.Unlock()
+
+	if pruned == 0 {
+		return 0, nil
+	}
+	return pruned, c.save(m.dir, m.path(name))
+}
+
+// addLocked 累加一行，调用者必须持有 mu
+func (c *continuousAggregate) addLocked(data map[string]any, rowTime, seq int64) {
+	c.maxSeq = max(c.maxSeq, seq)
+	c.dirty = true
+
+	ts := rowTime
+	if c.def.TimeField != "" {
+		t, ok := data[c.def.TimeField].(time.Time)
+		if !ok {
+			return
+		}
+		ts = t.UnixNano()
+	}
+
+	// 向下取整到时间桶（负数时间同样向下）
+	bucket := int64(c.def.Bucket)
+	start := ts - ts%bucket
+	if ts%bucket < 0 {
+		start -= bucket
+	}
+
+	states, ok := c.buckets[start]
+	if !ok {
+		states = c.newStates()
+		c.buckets[start] = states
+	}
+	for _, state := range states {
+		if state.agg.Func == "COUNT" {
+			state.count++
+			continue
+		}
+		state.addValue(data[state.agg.Field])
+	}
+}
+
+// newStates 创建一个时间桶的累加状态
+func (c *continuousAggregate) newStates() []*aggState {
+	states := make([]*aggState, len(c.template))
+	for i, state := range c.template {
+		states[i] = &aggState{agg: state.agg, kind: state.kind}
+	}
+	return states
+}
+
+// query 返回 [from, to) 内的时间桶结果，按时间升序
+func (c *continuousAggregate) query(from, to int64) ([]AggregateBucket, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	starts := make([]int64, 0, len(c.buckets))
+	for start := range c.buckets {
+		if start >= from && start < to {
+			starts = append(starts, start)
+		}
+	}
+	slices.Sort(starts)
+
+	result := make([]AggregateBucket, 0, len(starts))
+	for _, start := range starts {
+		states := c.buckets[start]
+		values := make([]any, len(states))
+		for i, state := range states {
+			value, err := state.result()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		result = append(result, AggregateBucket{Start: time.Unix(0, start), Values: values})
+	}
+	return result, nil
+}
+
+// save 将连续聚合写入文件（临时文件 + 重命名）
+func (c *continuousAggregate) save(dir, path string) error {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	file := continuousAggregateFile{
+		Definition: c.def,
+		MaxSeq:     c.maxSeq,
+		Buckets:    make([]continuousBucketJSON, 0, len(c.buckets)),
+	}
+	for _, start := range slices.Sorted(maps.Keys(c.buckets)) {
+		states := c.buckets[start]
+		bucket := continuousBucketJSON{Start: start, States: make([]aggStateJSON, len(states))}
+		for i, state := range states {
+			bucket.States[i] = state.snapshot()
+		}
+		file.Buckets = append(file.Buckets, bucket)
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	err := func() error {
+		data, err := json.Marshal(file)
+		if err != nil {
+			return NewErrorf(ErrCodeEncodeFailed, "failed to encode continuous aggregate %s: %v", c.def.Name, err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		tmpPath := path + ".tmp"
+		if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+			return err
+		}
+		return os.Rename(tmpPath, path)
+	}()
+	if err != nil {
+		c.mu.Lock()
+		c.dirty = true
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// restore 从持久化格式恢复状态
+func (c *continuousAggregate) restore(file *continuousAggregateFile) error {
+	c.maxSeq = file.MaxSeq
+	for _, bucket := range file.Buckets {
+		if len(bucket.States) != len(c.def.Aggregates) {
+			return fmt.Errorf("continuous aggregate %s: bucket has %d states, expected %d", c.def.Name, len(bucket.States), len(c.def.Aggregates))
+		}
+		states := c.newStates()
+		for i, state := range states {
+			if err := state.restore(bucket.States[i]); err != nil {
+				return fmt.Errorf("continuous aggregate %s: %w", c.def.Name, err)
+			}
+		}
+		c.buckets[bucket.Start] = states
+	}
+	return nil
+}
+
+// snapshot 返回累加状态的持久化格式
+func (s *aggState) snapshot() aggStateJSON {
+	j := aggStateJSON{
+		Count:    s.count,
+		Int:      s.i64,
+		Uint:     s.u64,
+		Float:    math.Float64bits(s.f64),
+		Overflow: s.err != nil,
+	}
+	if !s.dec.IsZero() {
+		j.Decimal = s.dec.String()
+	}
+	if s.big != nil {
+		j.Big = s.big.String()
+	}
+	return j
+}
+
+// restore 从持久化格式恢复累加状态
+func (s *aggState) restore(j aggStateJSON) error {
+	s.count = j.Count
+	s.i64 = j.Int
+	s.u64 = j.Uint
+	s.f64 = math.Float64frombits(j.Float)
+	if j.Decimal != "" {
+		dec, err := decimal.NewFromString(j.Decimal)
+		if err != nil {
+			return fmt.Errorf("invalid decimal state %q", j.Decimal)
+		}
+		s.dec = dec
+	}
+	if j.Big != "" {
+		n, ok := new(big.Int).SetString(j.Big, 10)
+		if !ok {
+			return fmt.Errorf("invalid big integer state %q", j.Big)
+		}
+		s.big = n
+	}
+	if j.Overflow {
+		s.overflow()
+	}
+	return nil
+}
+
+// CreateContinuousAggregate 创建连续聚合，并立即为已有数据计算
+//
+// 之后每次插入都会增量更新对应时间桶，数据随 flush 与 Close 持久化，
+// 崩溃后打开表时补齐持久化之后写入的行：
+//
+//	table.CreateContinuousAggregate(srdb.ContinuousAggregate{
+//		Name:       "latency_1m",
+//		Bucket:     time.Minute,
+//		Aggregates: []srdb.Aggregate{srdb.Count(), srdb.Avg("latency_ms")},
+//	})
+//	buckets, err := table.QueryContinuousAggregate("latency_1m", from, to)
+func (t *Table) CreateContinuousAggregate(def ContinuousAggregate) error {
+	// 先注册再回填：快照之后的插入由插入路径累加，快照之前的由回填累加
+	snapshot := t.seq.Load()
+	c, err := t.caggs.create(def, snapshot)
+	if err != nil {
+		return err
+	}
+
+	rows, err := t.Query().Rows()
+	if err != nil {
+		t.caggs.drop(def.Name)
+		return err
+	}
+	defer rows.Close()
+
+	// 逐行加锁，回填期间不阻塞插入
+	for rows.Next() {
+		row := rows.Row().inner
+		if row.Seq <= snapshot {
+			c.mu.Lock()
+			c.addLocked(row.Data, row.Time, row.Seq)
+			c.mu.Unlock()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.caggs.drop(def.Name)
+		return err
+	}
+
+	return c.save(t.caggs.dir, t.caggs.path(def.Name))
+}
+
+// DropContinuousAggregate 删除连续聚合
+func (t *Table) DropContinuousAggregate(name string) error {
+	return t.caggs.drop(name)
+}
+
+// ContinuousAggregates 返回表的所有连续聚合定义
+func (t *Table) ContinuousAggregates() []ContinuousAggregate {
+	return t.caggs.list()
+}
+
+// QueryContinuousAggregate 查询连续聚合在 [from, to) 内的时间桶，按时间升序
+//
+// from 或 to 为零值时不限制该方向；没有数据的时间桶不返回。
+func (t *Table) QueryContinuousAggregate(name string, from, to time.Time) ([]AggregateBucket, error) {
+	c, err := t.caggs.get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := int64(math.MinInt64), int64(math.MaxInt64)
+	if !from.IsZero() {
+		start = from.UnixNano()
+	}
+	if !to.IsZero() {
+		end = to.UnixNano()
+	}
+	return c.query(start, end)
+}
@@ -0,0 +1,202 @@
+package srdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestContinuousAggregate(t *testing.T) {
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:  dir,
+		Name: "metrics",
+		Fields: []Field{
+			{Name: "host", Type: String},
+			{Name: "latency", Type: Int64},
+			{Name: "amount", Type: Decimal},
+			{Name: "at", Type: Time, Nullable: true},
+		},
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	insert := func(minute int, latency int64, amount string) {
+		t.Helper()
+		err := table.Insert(map[string]any{
+			"host":    "a",
+			"latency": latency,
+			"amount":  decimal.RequireFromString(amount),
+			"at":      base.Add(time.Duration(minute)*time.Minute + 30*time.Second),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 已有数据由创建时回填
+	insert(0, 10, "0.1")
+	insert(0, 20, "0.2")
+	insert(1, 30, "0.3")
+
+	def := ContinuousAggregate{
+		Name:       "latency_1m",
+		Bucket:     time.Minute,
+		TimeField:  "at",
+		Aggregates: []Aggregate{Count(), Sum("latency"), Max("latency"), Sum("amount")},
+	}
+	if err := table.CreateContinuousAggregate(def); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.CreateContinuousAggregate(def); !IsError(err, ErrCodeExists) {
+		t.Errorf("expected ErrCodeExists, got %v", err)
+	}
+
+	// 之后的插入增量更新
+	insert(1, 50, "0.4")
+	insert(2, 5, "0.5")
+	if err := table.Insert(map[string]any{"host": "b", "latency": int64(1), "amount": decimal.Zero}); err != nil {
+		t.Fatal(err) // at 为 NULL，不参与
+	}
+
+	check := func(buckets []AggregateBucket, want [][]any) {
+		t.Helper()
+		if len(buckets) != len(want) {
+			t.Fatalf("expected %d buckets, got %d", len(want), len(buckets))
+		}
+		for i, b := range buckets {
+			if !b.Start.Equal(base.Add(time.Duration(want[i][0].(int)) * time.Minute)) {
+				t.Errorf("bucket %d: unexpected start %v", i, b.Start)
+			}
+			if b.Values[0] != want[i][1] || b.Values[1] != want[i][2] || b.Values[2] != want[i][3] {
+				t.Errorf("bucket %d: unexpected values %v", i, b.Values)
+			}
+			if got := b.Values[3].(decimal.Decimal); !got.Equal(decimal.RequireFromString(want[i][4].(string))) {
+				t.Errorf("bucket %d: unexpected decimal sum %v", i, got)
+			}
+		}
+	}
+	want := [][]any{
+		{0, int64(2), int64(30), int64(20), "0.3"},
+		{1, int64(2), int64(80), int64(50), "0.7"},
+		{2, int64(1), int64(5), int64(5), "0.5"},
+	}
+
+	buckets, err := table.QueryContinuousAggregate("latency_1m", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(buckets, want)
+
+	// [from, to) 范围
+	buckets, err = table.QueryContinuousAggregate("latency_1m", base.Add(time.Minute), base.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(buckets, want[1:2])
+
+	// 重新打开后保留
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	table, err = OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { table.Close() }()
+
+	if defs := table.ContinuousAggregates(); len(defs) != 1 || defs[0].Name != "latency_1m" {
+		t.Fatalf("unexpected definitions after reopen: %+v", defs)
+	}
+	insert(2, 7, "0.1")
+	want[2] = []any{2, int64(2), int64(12), int64(7), "0.6"}
+	buckets, err = table.QueryContinuousAggregate("latency_1m", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(buckets, want)
+
+	// 无效定义
+	invalid := []ContinuousAggregate{
+		{Name: "", Bucket: time.Minute, Aggregates: []Aggregate{Count()}},
+		{Name: "../x", Bucket: time.Minute, Aggregates: []Aggregate{Count()}},
+		{Name: "x", Bucket: 0, Aggregates: []Aggregate{Count()}},
+		{Name: "x", Bucket: time.Minute},
+		{Name: "x", Bucket: time.Minute, TimeField: "host", Aggregates: []Aggregate{Count()}},
+		{Name: "x", Bucket: time.Minute, Aggregates: []Aggregate{Sum("host")}},
+	}
+	for i, def := range invalid {
+		if err := table.CreateContinuousAggregate(def); err == nil {
+			t.Errorf("invalid definition %d: expected error", i)
+		}
+	}
+
+	// 删除
+	if err := table.DropContinuousAggregate("latency_1m"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.QueryContinuousAggregate("latency_1m", time.Time{}, time.Time{}); !IsError(err, ErrCodeNotFound) {
+		t.Errorf("expected ErrCodeNotFound after drop, got %v", err)
+	}
+	if err := table.DropContinuousAggregate("latency_1m"); !IsError(err, ErrCodeNotFound) {
+		t.Errorf("expected ErrCodeNotFound for second drop, got %v", err)
+	}
+}
+
+func TestContinuousAggregateRowTime(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "value", Type: Float64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	err = table.CreateContinuousAggregate(ContinuousAggregate{
+		Name:       "hourly",
+		Bucket:     time.Hour,
+		Aggregates: []Aggregate{Count(), Avg("value")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []float64{1, 2, 6} {
+		if err := table.Insert(map[string]any{"value": v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 按写入时间分桶，插入在同一小时内（跨整点时落在相邻两个桶）
+	buckets, err := table.QueryContinuousAggregate("hourly", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	for _, b := range buckets {
+		count += b.Values[0].(int64)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 rows in buckets, got %d (%+v)", count, buckets)
+	}
+	if len(buckets) == 1 && buckets[0].Values[1] != float64(3) {
+		t.Errorf("expected avg 3, got %v", buckets[0].Values[1])
+	}
+
+	// 清空表后数据重置，定义保留
+	if err := table.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	buckets, err = table.QueryContinuousAggregate("hourly", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 0 {
+		t.Errorf("expected no buckets after Clean, got %+v", buckets)
+	}
+}
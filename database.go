@@ -8,6 +8,8 @@
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,12 +22,31 @@ type Database struct {
 	// 所有表
 	tables map[string]*Table
 
+	// 键值存储（首次调用 KV() 时打开）
+	kv *KV
+
 	// 元数据
 	metadata *Metadata
 
+	// 打开时发现的目录与磁盘的不一致（见 CatalogIssues）
+	catalogIssues []CatalogIssue
+
+	// 所有表共享的 _seq 序列（Options.GlobalSequence），nil 表示按表分配
+	sequence *Sequence
+
+	// 原子批量写入的提交记录（见 Batch）
+	batches *batchLog
+
 	// 配置选项
 	options *Options
 
+	// 运行时日志级别（过滤 Options.Logger 的输出）
+	logLevel *slog.LevelVar
+
+	// 进行中的复制流（见 ServeReplica），创建表时全部结束
+	replicas   map[*replicaFeed]struct{}
+	replicasMu sync.Mutex
+
 	// 锁
 	mu sync.RWMutex
 }
@@ -34,13 +55,19 @@ type Database struct {
 type Metadata struct {
 	Version int         `json:"version"`
 	Tables  []TableInfo `json:"tables"`
+	Models  []ModelInfo `json:"models,omitempty"` // RegisterModel 注册的模型
+
+	// Sequence 共享序列已分配的序列号（删除表、关闭时保存），避免删除的表的 _seq 在重新打开后被复用
+	Sequence int64 `json:"sequence,omitempty"`
 }
 
 // TableInfo 表信息
 type TableInfo struct {
-	Name      string `json:"name"`
-	Dir       string `json:"dir"`
-	CreatedAt int64  `json:"created_at"`
+	Name      string     `json:"name"`
+	Dir       string     `json:"dir"`
+	CreatedAt int64      `json:"created_at"`
+	State     TableState `json:"state,omitempty"`   // 两阶段创建、删除的状态，就绪时为空
+	Adopted   bool       `json:"adopted,omitempty"` // 创建时目录已存在（遗留数据），回滚创建时保留目录
 }
 
 // Options 数据库配置选项
@@ -60,16 +87,67 @@ type Options struct {
 	Level2SizeLimit int64 // L2 层大小限制，默认 512MB
 	Level3SizeLimit int64 // L3 层大小限制，默认 1GB
 
+	// L0TidyFileSize 打开表后的第一次后台 Compaction 合并数据小于此大小的 L0 文件（不受层级大小阈值限制），
+	// 清理频繁重启留下的小文件；默认 1MB，负数表示禁用
+	L0TidyFileSize int64
+
 	// 后台任务间隔
 	CompactionInterval time.Duration // Compaction 检查间隔，默认 10s
 	GCInterval         time.Duration // 垃圾回收检查间隔，默认 5min
 
+	// ========== IO 配置 ==========
+	IOMode IOMode // SST 与索引文件读取方式，默认 IOModeMmap（映射失败时自动回退到 pread）
+
+	// MetadataCacheSize SST 索引节点常驻内存的预算（所有表共享），默认 32MB，负数表示禁用
+	// 点查询访问过的文件在预算内常驻索引，大范围扫描不会把它们挤出缓存
+	MetadataCacheSize int64
+
+	// ========== 数据完整性 ==========
+	RowChecksum    bool // 插入时计算并存储每行的 SHA-256 校验和，读取时自动校验，默认 false
+	WALCompression bool // 使用 Snappy 压缩 WAL 记录（与 SST 存储格式无关），默认 false
+
+	// ParanoidCompactionChecks Compaction 删除输入文件之前，重新读取新的 SST 文件，
+	// 与输入逐行比对行数、seq 与 CRC32，不一致时放弃本次 Compaction，默认 false
+	ParanoidCompactionChecks bool
+
+	// ========== 结构体映射 ==========
+	NamingStrategy NamingStrategy // 结构体字段名到数据库字段名的默认规则（Insert/Scan），默认 SnakeCase
+
+	// ========== 表生命周期回调（可选）==========
+	// 回调在数据库锁之外同步调用，可以在回调中访问 Database（如 GetTable）
+	OnTableCreated TableHook // 表创建后调用（CreateTable、OpenOrCreateTable 新建表、Into）
+	OnTableDropped TableHook // 表删除后调用（DropTable、DestroyTable、Destroy）
+	OnTableOpened  TableHook // 表可用时调用：Open 恢复已有表时，以及新建表后（在 OnTableCreated 之后）
+
+	// OnFileEvent 任意表的 WAL、SST 文件创建或删除后调用（见 FileEvent），
+	// 在 flush、Compaction、GC 的 goroutine 中同步调用，应尽快返回
+	OnFileEvent func(FileEvent)
+
+	// ========== 写入并发 ==========
+	// MaxConcurrentWriters 每张表同时执行插入的 goroutine 数量上限，默认 0（不限制）
+	// 突发写入时超出的写入者按到达顺序排队，避免所有写入者在锁上争抢
+	MaxConcurrentWriters int
+
+	// GlobalSequence 所有表从同一个序列分配 _seq（见 Sequence），默认 false（每张表独立分配）
+	// 合并多张表的数据重放时，按 _seq 排序即可得到跨表的插入顺序
+	GlobalSequence bool
+
 	// ========== 高级配置（可选）==========
 	DisableAutoCompaction bool          // 禁用自动 Compaction，默认 false
 	DisableGC             bool          // 禁用垃圾回收，默认 false
 	GCFileMinAge          time.Duration // GC 文件最小年龄，默认 1min
+
+	// ========== 测试 ==========
+	FaultInjector *FaultInjector // 故障注入器，仅用于测试（见 FaultInjector 与 Database.SimulateCrash）
+	Clock         Clock          // 时间来源，默认 SystemClock；测试中可以使用 ManualClock 推进时间（见 Clock）
+
+	logs      *logRegistry   // 子系统日志器（Open 时创建，见 Database.SetLogLevel）
+	metaCache *metadataCache // 索引常驻预算（Open 时按 MetadataCacheSize 创建）
 }
 
+// TableHook 表生命周期回调，接收表名与表的 Schema
+type TableHook func(name string, schema *Schema)
+
 // DefaultOptions 返回默认配置
 func DefaultOptions(dir string) *Options {
 	return &Options{
@@ -81,11 +159,13 @@ func DefaultOptions(dir string) *Options {
 		Level1SizeLimit:       256 * 1024 * 1024,  // 256MB
 		Level2SizeLimit:       512 * 1024 * 1024,  // 512MB
 		Level3SizeLimit:       1024 * 1024 * 1024, // 1GB
+		L0TidyFileSize:        1024 * 1024,        // 1MB
 		CompactionInterval:    10 * time.Second,   // 10s
 		GCInterval:            5 * time.Minute,    // 5min
 		DisableAutoCompaction: false,
 		DisableGC:             false,
 		GCFileMinAge:          1 * time.Minute, // 1min
+		MetadataCacheSize:     DefaultMetadataCacheSize,
 	}
 }
 
@@ -113,6 +193,9 @@ func (opts *Options) fillDefaults() {
 	if opts.Level3SizeLimit == 0 {
 		opts.Level3SizeLimit = 1024 * 1024 * 1024 // 1GB
 	}
+	if opts.L0TidyFileSize == 0 {
+		opts.L0TidyFileSize = DefaultL0TidyFileSize // 1MB
+	}
 	if opts.CompactionInterval == 0 {
 		opts.CompactionInterval = 10 * time.Second // 10s
 	}
@@ -122,6 +205,12 @@ func (opts *Options) fillDefaults() {
 	if opts.GCFileMinAge == 0 {
 		opts.GCFileMinAge = 1 * time.Minute // 1min
 	}
+	if opts.MetadataCacheSize == 0 {
+		opts.MetadataCacheSize = DefaultMetadataCacheSize // 32MB
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
 }
 
 // Validate 验证配置的有效性
@@ -153,6 +242,12 @@ func (opts *Options) Validate() error {
 	if opts.GCFileMinAge < 0 {
 		return NewErrorf(ErrCodeInvalidParam, "GCFileMinAge cannot be negative, got %v", opts.GCFileMinAge)
 	}
+	if opts.IOMode < IOModeMmap || opts.IOMode > IOModeChunkedMmap {
+		return NewErrorf(ErrCodeInvalidParam, "unsupported IOMode %v", opts.IOMode)
+	}
+	if opts.MaxConcurrentWriters < 0 {
+		return NewErrorf(ErrCodeInvalidParam, "MaxConcurrentWriters cannot be negative, got %d", opts.MaxConcurrentWriters)
+	}
 	return nil
 }
 
@@ -177,10 +272,20 @@ func OpenWithOptions(opts *Options) (*Database, error) {
 		return nil, err
 	}
 
+	// 包装 Logger，使日志级别可通过 SetOption("LogLevel", ...) 在运行时调整
+	// 默认不额外过滤，由 Logger 自身的级别决定
+	// 各子系统的日志器可通过 SetLogLevel 单独调整级别
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(logLevelAll)
+	opts.logs = newLogRegistry(opts.Logger.Handler(), logLevel)
+	opts.metaCache = newMetadataCache(opts.MetadataCacheSize)
+	opts.Logger = slog.New(&logLevelHandler{Handler: opts.Logger.Handler(), level: logLevel})
+
 	db := &Database{
-		dir:     opts.Dir,
-		tables:  make(map[string]*Table),
-		options: opts,
+		dir:      opts.Dir,
+		tables:   make(map[string]*Table),
+		options:  opts,
+		logLevel: logLevel,
 	}
 
 	// 加载元数据
@@ -197,12 +302,35 @@ func OpenWithOptions(opts *Options) (*Database, error) {
 		}
 	}
 
+	// 共享序列从上次保存的位置开始，恢复表时再推进到表中已有的最大 seq
+	if opts.GlobalSequence {
+		db.sequence = NewSequence(db.metadata.Sequence)
+	}
+
+	// 修复未完成的创建、删除
+	err = db.reconcileCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	// 恢复表之前读取原子批量写入的提交记录
+	db.batches, err = openBatchLog(db.dir, opts.FaultInjector)
+	if err != nil {
+		return nil, err
+	}
+
 	// 恢复所有表
 	err = db.recoverTables()
 	if err != nil {
 		return nil, err
 	}
 
+	for _, info := range db.metadata.Tables {
+		if table, ok := db.tables[info.Name]; ok {
+			db.options.OnTableOpened.call(info.Name, table.schema.Load())
+		}
+	}
+
 	return db, nil
 }
 
@@ -226,14 +354,30 @@ func (db *Database) saveMetadata() error {
 		return err
 	}
 
-	// 原子性写入
+	// 原子性写入：同步临时文件后重命名，再同步目录
 	tmpPath := metaPath + ".tmp"
-	err = os.WriteFile(tmpPath, data, 0644)
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
 
-	return os.Rename(tmpPath, metaPath)
+	if err := os.Rename(tmpPath, metaPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(db.dir)
+	return nil
 }
 
 // recoverTables 恢复所有表
@@ -243,9 +387,22 @@ func (db *Database) recoverTables() error {
 	for _, tableInfo := range db.metadata.Tables {
 		tableDir := filepath.Join(db.dir, tableInfo.Name)
 		table, err := OpenTable(&TableOptions{
-			Dir:              tableDir,
-			MemTableSize:     db.options.MemTableSize,
-			AutoFlushTimeout: db.options.AutoFlushTimeout,
+			Dir:                      tableDir,
+			MemTableSize:             db.options.MemTableSize,
+			AutoFlushTimeout:         db.options.AutoFlushTimeout,
+			IOMode:                   db.options.IOMode,
+			RowChecksum:              db.options.RowChecksum,
+			WALCompression:           db.options.WALCompression,
+			ParanoidCompactionChecks: db.options.ParanoidCompactionChecks,
+			FaultInjector:            db.options.FaultInjector,
+			Clock:                    db.options.Clock,
+			NamingStrategy:           db.options.NamingStrategy,
+			MetadataCacheSize:        db.options.MetadataCacheSize,
+			metaCache:                db.options.metaCache,
+			OnFileEvent:              db.options.OnFileEvent,
+			MaxConcurrentWriters:     db.options.MaxConcurrentWriters,
+			Sequence:                 db.sequence,
+			batches:                  db.batches,
 		})
 		if err != nil {
 			// 记录失败的表，但继续恢复其他表
@@ -259,7 +416,7 @@ func (db *Database) recoverTables() error {
 		}
 
 		// 设置 Logger
-		table.SetLogger(db.options.Logger)
+		table.setLoggers(db.options)
 
 		// 将数据库级 Compaction 配置应用到表的 CompactionManager
 		if table.compactionManager != nil {
@@ -269,6 +426,11 @@ func (db *Database) recoverTables() error {
 		db.tables[tableInfo.Name] = table
 	}
 
+	// 只保留仍被 WAL 引用的提交记录；有表打开失败时全部保留
+	if err := db.batches.rewrite(len(failedTables) == 0); err != nil {
+		return fmt.Errorf("rewrite batch log: %w", err)
+	}
+
 	// 如果有失败的表，输出汇总信息
 	if len(failedTables) > 0 {
 		db.options.Logger.Warn("[Database] Failed to recover tables",
@@ -284,59 +446,182 @@ func (db *Database) recoverTables() error {
 // CreateTable 创建表
 func (db *Database) CreateTable(name string, schema *Schema) (*Table, error) {
 	db.mu.Lock()
-	defer db.mu.Unlock()
 
 	// 检查表是否已存在
 	if _, exists := db.tables[name]; exists {
+		db.mu.Unlock()
 		return nil, NewErrorf(ErrCodeTableExists, "table %s already exists", name)
 	}
 
-	// 创建表目录
+	table, err := db.createTable(name, schema)
+	db.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	db.notifyTableCreated(name, table)
+	return table, nil
+}
+
+// OpenOrCreateTable 获取表，不存在时创建（幂等）
+//
+// 表已存在时校验 Schema 结构是否兼容（仅注释不同视为兼容），不兼容返回 ErrCodeSchemaMismatch。
+// 多个 goroutine 并发调用时只会创建一次，均返回同一个 *Table。
+func (db *Database) OpenOrCreateTable(name string, schema *Schema) (*Table, error) {
+	if schema == nil {
+		return nil, NewErrorf(ErrCodeInvalidParam, "schema is required")
+	}
+
+	db.mu.Lock()
+
+	if table, exists := db.tables[name]; exists {
+		db.mu.Unlock()
+		if !table.schema.Load().IsCompatibleWith(schema) {
+			return nil, NewErrorf(ErrCodeSchemaMismatch, "table %s exists with an incompatible schema", name)
+		}
+		return table, nil
+	}
+
+	table, err := db.createTable(name, schema)
+	db.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	db.notifyTableCreated(name, table)
+	return table, nil
+}
+
+// notifyTableCreated 调用新建表的回调（调用者不能持有 db.mu）
+func (db *Database) notifyTableCreated(name string, table *Table) {
+	db.options.OnTableCreated.call(name, table.schema.Load())
+	db.options.OnTableOpened.call(name, table.schema.Load())
+}
+
+// call 调用回调，未设置时忽略
+func (h TableHook) call(name string, schema *Schema) {
+	if h != nil {
+		h(name, schema)
+	}
+}
+
+// createTable 创建表并写入元数据（调用者必须持有 db.mu 写锁）
+//
+// 先在元数据中登记为创建中，表创建完成后再标记为就绪（见 catalog.go）。
+// 任一步骤失败时回滚：关闭表、删除本次创建的目录、移除登记；回滚未能保存时在下次打开时回滚。
+func (db *Database) createTable(name string, schema *Schema) (*Table, error) {
+	if err := validateTableName(name); err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, NewErrorf(ErrCodeInvalidParam, "schema is required")
+	}
+
+	// 0. 同名表的删除未完成时先完成删除；打开失败的同名表保留目录，由新登记取代
 	tableDir := filepath.Join(db.dir, name)
+	if i := db.tableInfoIndex(name); i >= 0 {
+		if db.metadata.Tables[i].State == TableDropping {
+			if err := os.RemoveAll(tableDir); err != nil {
+				return nil, err
+			}
+		}
+		db.removeTableInfo(name)
+	}
+
+	// 1. 登记为创建中（已存在的目录可能是之前遗留的数据，失败时不能删除）
+	_, statErr := os.Stat(tableDir)
+	createdDir := os.IsNotExist(statErr)
+	tables := db.metadata.Tables
+	db.metadata.Tables = append(slices.Clip(tables), TableInfo{
+		Name:      name,
+		Dir:       name,
+		CreatedAt: db.options.Clock.Now().Unix(),
+		State:     TableCreating,
+		Adopted:   !createdDir,
+	})
+	if err := db.saveMetadata(); err != nil {
+		db.metadata.Tables = tables
+		return nil, err
+	}
+
+	rollback := func() {
+		if createdDir {
+			os.RemoveAll(tableDir)
+		}
+		db.metadata.Tables = tables
+		db.saveMetadata()
+	}
+
+	// 2. 创建表目录
 	err := os.MkdirAll(tableDir, 0755)
 	if err != nil {
+		rollback()
 		return nil, err
 	}
 
 	// 创建表（传递数据库级配置）
 	table, err := OpenTable(&TableOptions{
-		Dir:              tableDir,
-		MemTableSize:     db.options.MemTableSize,
-		AutoFlushTimeout: db.options.AutoFlushTimeout,
-		Name:             schema.Name,
-		Fields:           schema.Fields,
+		Dir:                      tableDir,
+		MemTableSize:             db.options.MemTableSize,
+		AutoFlushTimeout:         db.options.AutoFlushTimeout,
+		IOMode:                   db.options.IOMode,
+		RowChecksum:              db.options.RowChecksum,
+		WALCompression:           db.options.WALCompression,
+		ParanoidCompactionChecks: db.options.ParanoidCompactionChecks,
+		FaultInjector:            db.options.FaultInjector,
+		Clock:                    db.options.Clock,
+		NamingStrategy:           db.options.NamingStrategy,
+		MetadataCacheSize:        db.options.MetadataCacheSize,
+		metaCache:                db.options.metaCache,
+		OnFileEvent:              db.options.OnFileEvent,
+		MaxConcurrentWriters:     db.options.MaxConcurrentWriters,
+		Sequence:                 db.sequence,
+		batches:                  db.batches,
+		Name:                     schema.Name,
+		Fields:                   schema.Fields,
 	})
 	if err != nil {
-		os.RemoveAll(tableDir)
+		rollback()
 		return nil, err
 	}
 
 	// 设置 Logger
-	table.SetLogger(db.options.Logger)
+	table.setLoggers(db.options)
 
 	// 将数据库级 Compaction 配置应用到表的 CompactionManager
 	if table.compactionManager != nil {
 		table.compactionManager.ApplyConfig(db.options)
 	}
 
-	// 添加到 tables map
-	db.tables[name] = table
-
-	// 更新元数据
-	db.metadata.Tables = append(db.metadata.Tables, TableInfo{
-		Name:      name,
-		Dir:       name,
-		CreatedAt: time.Now().Unix(),
-	})
-
+	// 3. 标记为就绪
+	db.setTableState(name, TableReady)
 	err = db.saveMetadata()
 	if err != nil {
+		table.Close()
+		rollback()
 		return nil, err
 	}
 
+	// 添加到 tables map
+	db.tables[name] = table
+
+	// 新表不在从库的检查点中
+	db.failReplicas(NewErrorf(ErrCodeTableExists, "table %s created during replication, replica must bootstrap again", name))
+
 	return table, nil
 }
 
+// validateTableName 验证表名（表名同时用作目录名）
+func validateTableName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return NewErrorf(ErrCodeInvalidParam, "invalid table name %q", name)
+	}
+	if name == kvTableName {
+		return NewErrorf(ErrCodeInvalidParam, "table name %q is reserved", name)
+	}
+	return nil
+}
+
 // GetTable 获取表
 func (db *Database) GetTable(name string) (*Table, error) {
 	db.mu.RLock()
@@ -353,40 +638,53 @@ func (db *Database) GetTable(name string) (*Table, error) {
 // DropTable 删除表
 func (db *Database) DropTable(name string) error {
 	db.mu.Lock()
-	defer db.mu.Unlock()
 
 	// 检查表是否存在
 	table, exists := db.tables[name]
 	if !exists {
+		db.mu.Unlock()
 		return NewErrorf(ErrCodeTableNotFound, "table %s not found", name)
 	}
 
-	// 关闭表
+	removed, err := db.dropTable(name, table)
+	db.mu.Unlock()
+
+	// 表已从数据库中移除时调用回调（即使删除目录或保存元数据失败）
+	if removed {
+		db.options.OnTableDropped.call(name, table.schema.Load())
+	}
+	return err
+}
+
+// dropTable 关闭并删除表，返回表是否已从 map 中移除（调用者必须持有 db.mu 写锁）
+//
+// 先在元数据中标记为删除中，之后任一步骤失败，下次打开时完成删除（见 catalog.go）
+func (db *Database) dropTable(name string, table *Table) (bool, error) {
+	// 1. 标记为删除中（同时保存共享序列，表中的 _seq 在之后不会被复用）
+	db.recordSequence()
+	db.setTableState(name, TableDropping)
+	if err := db.saveMetadata(); err != nil {
+		db.setTableState(name, TableReady)
+		return false, err
+	}
+
+	// 2. 关闭表并从 map 中移除
 	err := table.Close()
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	// 从 map 中移除
 	delete(db.tables, name)
 
-	// 删除表目录
+	// 3. 删除表目录
 	tableDir := filepath.Join(db.dir, name)
 	err = os.RemoveAll(tableDir)
 	if err != nil {
-		return err
+		return true, err
 	}
 
-	// 更新元数据
-	newTables := make([]TableInfo, 0)
-	for _, info := range db.metadata.Tables {
-		if info.Name != name {
-			newTables = append(newTables, info)
-		}
-	}
-	db.metadata.Tables = newTables
-
-	return db.saveMetadata()
+	// 4. 移除登记
+	db.removeTableInfo(name)
+	return true, db.saveMetadata()
 }
 
 // ListTables 列出所有表
@@ -406,6 +704,11 @@ func (db *Database) Close() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	// 等待进行中的原子批量写入完成，之后的提交返回 ErrCodeClosed
+	if err := db.batches.close(); err != nil {
+		return err
+	}
+
 	// 关闭所有表
 	for _, table := range db.tables {
 		err := table.Close()
@@ -414,9 +717,30 @@ func (db *Database) Close() error {
 		}
 	}
 
+	// 关闭键值存储
+	if db.kv != nil {
+		if err := db.kv.close(); err != nil {
+			return err
+		}
+	}
+
+	// 保存共享序列
+	if db.recordSequence() {
+		return db.saveMetadata()
+	}
+
 	return nil
 }
 
+// recordSequence 将共享序列的位置记录到元数据（不保存），返回是否有变化（调用者必须持有 db.mu 写锁）
+func (db *Database) recordSequence() bool {
+	if db.sequence == nil || db.sequence.Last() == db.metadata.Sequence {
+		return false
+	}
+	db.metadata.Sequence = db.sequence.Last()
+	return true
+}
+
 // GetAllTablesInfo 获取所有表的信息（用于 WebUI）
 func (db *Database) GetAllTablesInfo() map[string]*Table {
 	db.mu.RLock()
@@ -444,32 +768,43 @@ func (db *Database) CleanTable(name string) error {
 // DestroyTable 销毁指定表并从 Database 中删除
 func (db *Database) DestroyTable(name string) error {
 	db.mu.Lock()
-	defer db.mu.Unlock()
 
 	table, exists := db.tables[name]
 	if !exists {
+		db.mu.Unlock()
 		return fmt.Errorf("table %s does not exist", name)
 	}
 
-	// 1. 销毁表（删除文件）
-	if err := table.Destroy(); err != nil {
-		return fmt.Errorf("destroy table: %w", err)
+	removed, err := db.destroyTable(name, table)
+	db.mu.Unlock()
+
+	if removed {
+		db.options.OnTableDropped.call(name, table.schema.Load())
 	}
+	return err
+}
 
-	// 2. 从内存中删除
-	delete(db.tables, name)
+// destroyTable 销毁表，返回表是否已从 map 中移除（调用者必须持有 db.mu 写锁）
+func (db *Database) destroyTable(name string, table *Table) (bool, error) {
+	// 1. 标记为删除中（之后失败时下次打开完成删除）
+	db.recordSequence()
+	db.setTableState(name, TableDropping)
+	if err := db.saveMetadata(); err != nil {
+		db.setTableState(name, TableReady)
+		return false, err
+	}
 
-	// 3. 从元数据中删除
-	newTables := make([]TableInfo, 0, len(db.metadata.Tables)-1)
-	for _, info := range db.metadata.Tables {
-		if info.Name != name {
-			newTables = append(newTables, info)
-		}
+	// 2. 销毁表（删除文件）
+	if err := table.Destroy(); err != nil {
+		return false, fmt.Errorf("destroy table: %w", err)
 	}
-	db.metadata.Tables = newTables
 
-	// 4. 保存元数据
-	return db.saveMetadata()
+	// 3. 从内存中删除
+	delete(db.tables, name)
+
+	// 4. 移除登记并保存元数据
+	db.removeTableInfo(name)
+	return true, db.saveMetadata()
 }
 
 // Clean 清除所有表的数据（保留表结构和 Database 可用）
@@ -484,20 +819,53 @@ func (db *Database) Clean() error {
 		}
 	}
 
+	// 清除键值存储
+	if db.kv != nil {
+		if err := db.kv.clean(); err != nil {
+			return fmt.Errorf("clean kv: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // Destroy 销毁整个数据库并删除所有数据文件
 func (db *Database) Destroy() error {
 	db.mu.Lock()
-	defer db.mu.Unlock()
+	infos := db.metadata.Tables
+	tables := db.tables
+	err := db.destroy()
+	db.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// 按创建顺序调用回调
+	for _, info := range infos {
+		if table, ok := tables[info.Name]; ok {
+			db.options.OnTableDropped.call(info.Name, table.schema.Load())
+		}
+	}
+	return nil
+}
 
+// destroy 关闭所有表并删除数据库目录（调用者必须持有 db.mu 写锁）
+func (db *Database) destroy() error {
 	// 1. 关闭所有表
+	if err := db.batches.close(); err != nil {
+		return fmt.Errorf("close batch log: %w", err)
+	}
 	for _, table := range db.tables {
 		if err := table.Close(); err != nil {
 			return fmt.Errorf("close table: %w", err)
 		}
 	}
+	if db.kv != nil {
+		if err := db.kv.table.Close(); err != nil {
+			return fmt.Errorf("close kv: %w", err)
+		}
+	}
 
 	// 2. 删除整个数据库目录
 	if err := os.RemoveAll(db.dir); err != nil {
@@ -507,6 +875,8 @@ func (db *Database) Destroy() error {
 	// 3. 清空内存中的表
 	db.tables = make(map[string]*Table)
 	db.metadata.Tables = nil
+	db.metadata.Models = nil
+	db.kv = nil
 
 	return nil
 }
@@ -4,6 +4,7 @@
 	"fmt"
 	"os"
 	"slices"
+	"sync"
 	"testing"
 )
 
@@ -773,3 +774,187 @@ func TestDatabaseDestroyTableMultiple(t *testing.T) {
 		t.Error("table1 and table3 should still exist")
 	}
 }
+
+func TestOpenOrCreateTable(t *testing.T) {
+	dir := "./test_db_open_or_create"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	schema, _ := NewSchema("users", []Field{
+		{Name: "name", Type: String, Comment: "用户名"},
+		{Name: "age", Type: Int64},
+	})
+
+	// 并发创建同一张表：只创建一次，返回同一个 *Table
+	const workers = 16
+	tables := make([]*Table, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tables[i], errs[i] = db.OpenOrCreateTable("users", schema)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range workers {
+		if errs[i] != nil {
+			t.Fatalf("OpenOrCreateTable failed: %v", errs[i])
+		}
+		if tables[i] != tables[0] {
+			t.Fatal("OpenOrCreateTable returned different tables")
+		}
+	}
+	if n := len(db.metadata.Tables); n != 1 {
+		t.Errorf("expected 1 table in metadata, got %d", n)
+	}
+
+	// 仅注释不同视为兼容
+	commented, _ := NewSchema("users", []Field{
+		{Name: "name", Type: String, Comment: "名称"},
+		{Name: "age", Type: Int64},
+	})
+	if table, err := db.OpenOrCreateTable("users", commented); err != nil || table != tables[0] {
+		t.Errorf("expected existing table for comment-only change, got %v", err)
+	}
+
+	// 结构不兼容
+	incompatible, _ := NewSchema("users", []Field{
+		{Name: "name", Type: String},
+		{Name: "age", Type: String},
+	})
+	if _, err := db.OpenOrCreateTable("users", incompatible); !IsError(err, ErrCodeSchemaMismatch) {
+		t.Errorf("expected ErrCodeSchemaMismatch, got %v", err)
+	}
+
+	// CreateTable 仍然拒绝已存在的表
+	if _, err := db.CreateTable("users", schema); !IsError(err, ErrCodeTableExists) {
+		t.Errorf("expected ErrCodeTableExists, got %v", err)
+	}
+
+	// 非法表名
+	for _, name := range []string{"", "..", "a/b"} {
+		if _, err := db.OpenOrCreateTable(name, schema); !IsError(err, ErrCodeInvalidParam) {
+			t.Errorf("expected ErrCodeInvalidParam for %q, got %v", name, err)
+		}
+	}
+
+	db.Close()
+
+	// 重新打开后幂等
+	db, err = Open(dir)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.OpenOrCreateTable("users", schema); err != nil {
+		t.Errorf("OpenOrCreateTable after reopen failed: %v", err)
+	}
+	if n := len(db.ListTables()); n != 1 {
+		t.Errorf("expected 1 table after reopen, got %d", n)
+	}
+}
+
+func TestDatabaseTableHooks(t *testing.T) {
+	dir := t.TempDir()
+
+	var mu sync.Mutex
+	var events []string
+	record := func(kind string) TableHook {
+		return func(name string, schema *Schema) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, fmt.Sprintf("%s:%s:%d", kind, name, len(schema.Fields)))
+		}
+	}
+
+	var db *Database
+	opts := DefaultOptions(dir)
+	opts.OnTableCreated = record("created")
+	opts.OnTableDropped = record("dropped")
+	opts.OnTableOpened = func(name string, schema *Schema) {
+		record("opened")(name, schema)
+		// 回调在锁外调用，可以访问 Database
+		if db != nil {
+			if _, err := db.GetTable(name); err != nil {
+				t.Errorf("GetTable in hook: %v", err)
+			}
+		}
+	}
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, _ := NewSchema("customer", []Field{
+		{Name: "name", Type: String},
+		{Name: "plan", Type: String},
+	})
+	for _, name := range []string{"customer_a", "customer_b", "customer_c"} {
+		if _, err := db.CreateTable(name, schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 已存在的表与失败的创建不触发回调
+	if _, err := db.OpenOrCreateTable("customer_a", schema); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateTable("customer_a", schema); err == nil {
+		t.Fatal("expected error for existing table")
+	}
+	if _, err := db.OpenOrCreateTable("customer_d", schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.DropTable("customer_b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DestroyTable("customer_d"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DropTable("customer_b"); err == nil {
+		t.Fatal("expected error for missing table")
+	}
+
+	expected := []string{
+		"created:customer_a:2", "opened:customer_a:2",
+		"created:customer_b:2", "opened:customer_b:2",
+		"created:customer_c:2", "opened:customer_c:2",
+		"created:customer_d:2", "opened:customer_d:2",
+		"dropped:customer_b:2",
+		"dropped:customer_d:2",
+	}
+	if !slices.Equal(events, expected) {
+		t.Errorf("unexpected events:\n got %v\nwant %v", events, expected)
+	}
+
+	// 重新打开时按创建顺序触发 OnTableOpened
+	db.Close()
+	events = nil
+	db = nil
+	db, err = OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"opened:customer_a:2", "opened:customer_c:2"}; !slices.Equal(events, expected) {
+		t.Errorf("unexpected events after reopen: %v", events)
+	}
+
+	// Destroy 删除所有表
+	events = nil
+	if err := db.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"dropped:customer_a:2", "dropped:customer_c:2"}; !slices.Equal(events, expected) {
+		t.Errorf("unexpected events after destroy: %v", events)
+	}
+}
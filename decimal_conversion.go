@@ -0,0 +1,205 @@
+package srdb
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/shopspring/decimal"
+)
+
+// DecimalConversion Decimal 与数值、字符串之间的转换方式
+//
+// 写入时 decimal.Decimal 值可以写入整数、浮点数和 String 字段，浮点数、整数和数字字符串可以写入 Decimal 字段；
+// 扫描到结构体时规则相同（Decimal 字段扫描到数值或 string 成员，数值和字符串字段扫描到 decimal.Decimal 成员）。
+// 两种方式都拒绝超出目标类型范围的值以及 NaN、±Inf，区别在于是否允许损失精度。
+type DecimalConversion int32
+
+const (
+	// DecimalLenient 允许损失精度（默认）：转换为浮点数时取最接近的值，转换为整数时截断小数部分
+	DecimalLenient DecimalConversion = iota
+
+	// DecimalStrict 只允许无损的转换：转换为浮点数时按最短表示转换回 Decimal 必须相等，转换为整数时不能有小数部分
+	DecimalStrict
+)
+
+// String 返回转换方式名称
+func (c DecimalConversion) String() string {
+	switch c {
+	case DecimalLenient:
+		return "lenient"
+	case DecimalStrict:
+		return "strict"
+	default:
+		return "unknown"
+	}
+}
+
+// SetDecimalConversion 设置 Decimal 与数值、字符串之间的转换方式（见 DecimalConversion），
+// 对之后的写入与扫描生效
+func (t *Table) SetDecimalConversion(conversion DecimalConversion) {
+	t.decimals.Store(int32(conversion))
+}
+
+// DecimalConversion 返回 Decimal 与数值、字符串之间的转换方式
+func (t *Table) DecimalConversion() DecimalConversion {
+	return DecimalConversion(t.decimals.Load())
+}
+
+// decimalConversion 返回结果集所属表的 Decimal 转换方式
+func (r *Rows) decimalConversion() DecimalConversion {
+	if r.table == nil {
+		return DecimalLenient
+	}
+	return r.table.DecimalConversion()
+}
+
+var decimalType = reflect.TypeFor[decimal.Decimal]()
+
+// convertDecimals 将写入非 Decimal 字段的 decimal.Decimal 值按 conversion 转换为字段类型，
+// 有值被转换时返回 data 的副本，否则原样返回
+func (s *Schema) convertDecimals(data map[string]any, conversion DecimalConversion) (map[string]any, error) {
+	converted, cloned := data, false
+	for key, value := range data {
+		d, ok := value.(decimal.Decimal)
+		if !ok {
+			continue
+		}
+		field, err := s.GetField(key)
+		if err != nil {
+			continue
+		}
+
+		var v any
+		switch field.Type {
+		case Int, Int8, Int16, Int32, Int64, Rune:
+			v, err = decimalToInt(d, fieldTypeBits(field.Type), conversion)
+		case Uint, Uint8, Uint16, Uint32, Uint64, Byte:
+			v, err = decimalToUint(d, fieldTypeBits(field.Type), conversion)
+		case Float32:
+			var f float64
+			f, err = decimalToFloat(d, 32, conversion)
+			v = float32(f)
+		case Float64:
+			v, err = decimalToFloat(d, 64, conversion)
+		case String:
+			v = d.String()
+		default:
+			continue // Decimal 字段无需转换，其他类型交给 Validate 报告
+		}
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+		if !cloned {
+			converted, cloned = maps.Clone(data), true
+		}
+		converted[key] = v
+	}
+	return converted, nil
+}
+
+// fieldTypeBits 返回整数字段类型的位数
+func fieldTypeBits(typ FieldType) int {
+	switch typ {
+	case Int8, Uint8, Byte:
+		return 8
+	case Int16, Uint16:
+		return 16
+	case Int32, Uint32, Rune:
+		return 32
+	default:
+		return 64
+	}
+}
+
+// decimalToInt 将 d 转换为 bits 位有符号整数（以 int64 返回）
+func decimalToInt(d decimal.Decimal, bits int, conversion DecimalConversion) (int64, error) {
+	i := d.Truncate(0)
+	if conversion == DecimalStrict && !i.Equal(d) {
+		return 0, fmt.Errorf("decimal %s is not an integer", d)
+	}
+	limit := decimal.NewFromBigInt(new(big.Int).Lsh(big.NewInt(1), uint(bits-1)), 0)
+	if i.GreaterThanOrEqual(limit) || i.LessThan(limit.Neg()) {
+		return 0, fmt.Errorf("decimal %s out of range for %d-bit integer", d, bits)
+	}
+	return i.IntPart(), nil
+}
+
+// decimalToUint 将 d 转换为 bits 位无符号整数（以 uint64 返回）
+func decimalToUint(d decimal.Decimal, bits int, conversion DecimalConversion) (uint64, error) {
+	i := d.Truncate(0)
+	if conversion == DecimalStrict && !i.Equal(d) {
+		return 0, fmt.Errorf("decimal %s is not an integer", d)
+	}
+	limit := decimal.NewFromBigInt(new(big.Int).Lsh(big.NewInt(1), uint(bits)), 0)
+	if i.Sign() < 0 || i.GreaterThanOrEqual(limit) {
+		return 0, fmt.Errorf("decimal %s out of range for %d-bit unsigned integer", d, bits)
+	}
+	return i.BigInt().Uint64(), nil
+}
+
+// decimalToFloat 将 d 转换为 bits 位浮点数（以 float64 返回）
+func decimalToFloat(d decimal.Decimal, bits int, conversion DecimalConversion) (float64, error) {
+	f, _ := d.Float64()
+	exact := decimal.NewFromFloat
+	if bits == 32 {
+		f = float64(float32(f))
+		exact = func(f float64) decimal.Decimal { return decimal.NewFromFloat32(float32(f)) }
+	}
+	if math.IsInf(f, 0) {
+		return 0, fmt.Errorf("decimal %s out of range for float%d", d, bits)
+	}
+	if conversion == DecimalStrict && !exact(f).Equal(d) {
+		return 0, fmt.Errorf("decimal %s cannot be represented exactly as float%d", d, bits)
+	}
+	return f, nil
+}
+
+// setDecimalField 扫描时处理 Decimal 与数值、字符串之间的转换，
+// 返回 false 表示 dbValue 与 fieldValue 都不是 Decimal，由调用者继续处理
+func setDecimalField(fieldValue reflect.Value, dbValue any, conversion DecimalConversion) (bool, error) {
+	fieldType := fieldValue.Type()
+	if fieldType == decimalType {
+		if _, ok := dbValue.(bool); ok {
+			return true, fmt.Errorf("cannot convert bool to decimal.Decimal")
+		}
+		d, err := convertToDecimal(dbValue)
+		if err != nil {
+			return true, err
+		}
+		fieldValue.Set(reflect.ValueOf(d))
+		return true, nil
+	}
+
+	d, ok := dbValue.(decimal.Decimal)
+	if !ok {
+		return false, nil
+	}
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := decimalToInt(d, fieldType.Bits(), conversion)
+		if err != nil {
+			return true, err
+		}
+		fieldValue.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := decimalToUint(d, fieldType.Bits(), conversion)
+		if err != nil {
+			return true, err
+		}
+		fieldValue.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := decimalToFloat(d, fieldType.Bits(), conversion)
+		if err != nil {
+			return true, err
+		}
+		fieldValue.SetFloat(f)
+	case reflect.String:
+		fieldValue.SetString(d.String())
+	default:
+		return true, fmt.Errorf("cannot convert decimal.Decimal to %s", fieldType)
+	}
+	return true, nil
+}
@@ -0,0 +1,153 @@
+package srdb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDecimalConversionInsert(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "prices",
+		Fields: []Field{
+			{Name: "f64", Type: Float64, Nullable: true},
+			{Name: "f32", Type: Float32, Nullable: true},
+			{Name: "i64", Type: Int64, Nullable: true},
+			{Name: "i8", Type: Int8, Nullable: true},
+			{Name: "u16", Type: Uint16, Nullable: true},
+			{Name: "s", Type: String, Nullable: true},
+			{Name: "d", Type: Decimal, Nullable: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	if table.DecimalConversion() != DecimalLenient {
+		t.Fatalf("expected lenient by default, got %s", table.DecimalConversion())
+	}
+
+	price := decimal.RequireFromString("12.345")
+	data := map[string]any{"f64": price, "f32": price, "i64": price, "i8": decimal.NewFromInt(-7), "u16": price, "s": price, "d": 0.1}
+	if err := table.Insert(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data["f64"].(decimal.Decimal); !ok {
+		t.Error("expected the caller's map to be left unchanged")
+	}
+
+	row, err := table.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{"f64": 12.345, "f32": float32(12.345), "i64": int64(12), "i8": int8(-7), "u16": uint16(12), "s": "12.345"}
+	for name, want := range expected {
+		if row.Data[name] != want {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", name, want, want, row.Data[name], row.Data[name])
+		}
+	}
+	if d, ok := row.Data["d"].(decimal.Decimal); !ok || !d.Equal(decimal.RequireFromString("0.1")) {
+		t.Errorf("expected decimal 0.1, got %v", row.Data["d"])
+	}
+
+	// 两种方式都拒绝超出范围的值与 NaN、±Inf
+	for _, mode := range []DecimalConversion{DecimalLenient, DecimalStrict} {
+		table.SetDecimalConversion(mode)
+		for _, data := range []map[string]any{
+			{"i8": decimal.NewFromInt(128)},
+			{"u16": decimal.NewFromInt(-1)},
+			{"i64": decimal.RequireFromString("9223372036854775808")},
+			{"f32": decimal.RequireFromString("1e39")},
+			{"d": math.NaN()},
+			{"d": math.Inf(1)},
+		} {
+			if err := table.Insert(data); !IsError(err, ErrCodeSchemaValidationFailed) {
+				t.Errorf("%s: expected %v to be rejected, got %v", mode, data, err)
+			}
+		}
+	}
+
+	// 严格方式拒绝损失精度的转换
+	table.SetDecimalConversion(DecimalStrict)
+	for _, data := range []map[string]any{
+		{"i64": price},
+		{"f32": decimal.RequireFromString("0.123456789")},
+		{"f64": decimal.RequireFromString("0.12345678901234567890123")},
+	} {
+		if err := table.Insert(data); !IsError(err, ErrCodeSchemaValidationFailed) {
+			t.Errorf("expected %v to be rejected in strict mode, got %v", data, err)
+		}
+	}
+	if err := table.Insert(map[string]any{"i64": decimal.RequireFromString("42.000"), "f32": decimal.RequireFromString("0.5"), "f64": decimal.RequireFromString("0.1"), "s": price}); err != nil {
+		t.Fatalf("expected exact conversions in strict mode, got %v", err)
+	}
+}
+
+func TestDecimalConversionScan(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "prices",
+		Fields: []Field{
+			{Name: "price", Type: Decimal},
+			{Name: "amount", Type: Float64},
+			{Name: "count", Type: Int64},
+			{Name: "label", Type: String},
+		},
+		DecimalConversion: DecimalStrict,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(map[string]any{"price": "19.99", "amount": 2.5, "count": int64(3), "label": "7.25"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decimal 字段扫描到数值与 string 成员，数值与字符串字段扫描到 decimal.Decimal 成员
+	type mixed struct {
+		Price     float64          `srdb:"price"`
+		PriceText string           `srdb:"field:price"`
+		Amount    decimal.Decimal  `srdb:"amount"`
+		Count     *decimal.Decimal `srdb:"count"`
+		Label     decimal.Decimal  `srdb:"label"`
+	}
+	scan := func() (mixed, error) {
+		var got mixed
+		err := table.Query().Scan(&got)
+		return got, err
+	}
+	got, err := scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Price != 19.99 || got.PriceText != "19.99" {
+		t.Errorf("unexpected price: %v %q", got.Price, got.PriceText)
+	}
+	if !got.Amount.Equal(decimal.RequireFromString("2.5")) || got.Count == nil || !got.Count.Equal(decimal.NewFromInt(3)) ||
+		!got.Label.Equal(decimal.RequireFromString("7.25")) {
+		t.Errorf("unexpected decimals: %v %v %v", got.Amount, got.Count, got.Label)
+	}
+
+	// 严格方式下扫描到整数成员需要整数值，宽松方式截断小数部分
+	type whole struct {
+		Price int64 `srdb:"price"`
+	}
+	var w whole
+	if err := table.Query().Scan(&w); err == nil {
+		t.Errorf("expected strict scan of 19.99 into int64 to fail, got %d", w.Price)
+	}
+	table.SetDecimalConversion(DecimalLenient)
+	if err := table.Query().Scan(&w); err != nil || w.Price != 19 {
+		t.Errorf("expected lenient scan to truncate to 19, got %d %v", w.Price, err)
+	}
+
+	// 切片扫描同样生效
+	var all []whole
+	if err := table.Query().Scan(&all); err != nil || len(all) != 1 || all[0].Price != 19 {
+		t.Errorf("expected lenient slice scan to truncate to 19, got %v %v", all, err)
+	}
+}
@@ -0,0 +1,25 @@
+package srdb
+
+// TableDescription 表的定义：Schema 与附加在表上的持久化元数据
+type TableDescription struct {
+	Name                 string                `json:"name"`
+	Dir                  string                `json:"dir"`
+	Schema               *Schema               `json:"schema"`
+	Indexes              []string              `json:"indexes"`
+	ContinuousAggregates []ContinuousAggregate `json:"continuous_aggregates"`
+	RetentionPolicy      *RetentionPolicy      `json:"retention_policy,omitempty"` // 未设置时为 nil
+}
+
+// Describe 返回表的定义，包括索引、连续聚合与保留策略
+//
+// 与 Stats 不同，只包含重新打开表后仍然生效的配置，不包含运行时统计。
+func (t *Table) Describe() *TableDescription {
+	return &TableDescription{
+		Name:                 t.schema.Load().Name,
+		Dir:                  t.dir,
+		Schema:               t.schema.Load(),
+		Indexes:              t.ListIndexes(),
+		ContinuousAggregates: t.ContinuousAggregates(),
+		RetentionPolicy:      t.RetentionPolicy(),
+	}
+}
@@ -0,0 +1,51 @@
+package srdb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTableDescribe(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "users",
+		Fields: []Field{
+			{Name: "email", Type: String, Indexed: true},
+			{Name: "age", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	def := ContinuousAggregate{Name: "signups", Bucket: time.Hour, Aggregates: []Aggregate{Count()}}
+	if err := table.CreateContinuousAggregate(def); err != nil {
+		t.Fatal(err)
+	}
+
+	desc := table.Describe()
+	if desc.Name != "users" || len(desc.Schema.Fields) != 2 || desc.RetentionPolicy != nil {
+		t.Errorf("unexpected description %+v", desc)
+	}
+	if len(desc.Indexes) != 1 || desc.Indexes[0] != "email" {
+		t.Errorf("unexpected indexes %v", desc.Indexes)
+	}
+	if len(desc.ContinuousAggregates) != 1 || desc.ContinuousAggregates[0].Name != "signups" {
+		t.Errorf("unexpected continuous aggregates %+v", desc.ContinuousAggregates)
+	}
+
+	// 未设置保留策略时不输出该字段
+	data, err := json.Marshal(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["retention_policy"]; ok {
+		t.Errorf("expected no retention_policy in %s", data)
+	}
+}
@@ -0,0 +1,232 @@
+package srdb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// DurabilityToken 插入返回的持久化凭证
+//
+// 插入本身不等待 fsync；需要确认数据落盘时（例如持久化后才向上游确认消息），
+// 调用 Wait 等待对应的行写入已 fsync 的 WAL 或已 flush 的 SST。
+type DurabilityToken struct {
+	Seq   int64 // 本次插入的最大 seq（批量插入时覆盖整批数据）
+	table *Table
+	epoch int64 // 插入时的水位 epoch，表被清空后凭证失效
+}
+
+// Wait 等待凭证对应的数据持久化，表在插入后被清空时返回 ErrTableReset
+func (tok DurabilityToken) Wait(ctx context.Context) error {
+	if tok.table == nil {
+		return NewErrorf(ErrCodeInvalidParam, "invalid durability token")
+	}
+	return tok.table.waitDurable(ctx, tok.Seq, tok.epoch)
+}
+
+// durabilityTracker 跟踪已持久化的 seq 水位
+//
+// 并发插入时 seq 的分配顺序与写入 WAL 的顺序可能不同，因此记录已分配但尚未写入 WAL 的 seq，
+// fsync 前取 min(pending)-1 作为水位：水位以下的行必然已写入 WAL，fsync 完成后即已持久化。
+type durabilityTracker struct {
+	seq    *atomic.Int64 // 表的序列号（表内最后分配的 seq）
+	shared *Sequence     // 共享的序列，nil 表示按表分配
+
+	mu      sync.Mutex
+	pending map[int64]struct{} // 已分配 seq 但尚未写入 WAL（及 MemTable 与索引）
+	durable int64              // seq <= durable 的行均已持久化
+	epoch   int64              // 水位对应的表 epoch（Clean 后重置）
+	changed chan struct{}      // 水位变化或 sync 结束时关闭并替换
+	syncing bool               // 是否有进行中的 WaitDurable 触发的 sync
+	syncErr error              // 最近一次 sync 的错误
+}
+
+func newDurabilityTracker(seq *atomic.Int64, shared *Sequence) *durabilityTracker {
+	return &durabilityTracker{
+		seq:     seq,
+		shared:  shared,
+		pending: make(map[int64]struct{}),
+		durable: seq.Load(),
+		changed: make(chan struct{}),
+	}
+}
+
+// allocate 分配新的 seq，写入 WAL 后必须调用 appended
+func (d *durabilityTracker) allocate() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var seq int64
+	if d.shared != nil {
+		// 表内的分配由 mu 串行化，共享序列分配的值递增
+		seq = d.shared.next()
+		d.seq.Store(seq)
+	} else {
+		seq = d.seq.Add(1)
+	}
+	d.pending[seq] = struct{}{}
+	return seq
+}
+
+// appended 标记 seq 已写入 WAL（写入失败时也需要调用）
+func (d *durabilityTracker) appended(seq int64) {
+	d.mu.Lock()
+	delete(d.pending, seq)
+	d.mu.Unlock()
+}
+
+// observe 确保之后分配的 seq 大于 seq（从库应用主库的写入时调用）
+func (d *durabilityTracker) observe(seq int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if seq > d.seq.Load() {
+		d.seq.Store(seq)
+	}
+	if d.shared != nil {
+		d.shared.observe(seq)
+	}
+}
+
+// watermark 返回当前已写入 WAL 的连续 seq 水位，在 fsync 之前调用
+func (d *durabilityTracker) watermark() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	mark := d.seq.Load()
+	for seq := range d.pending {
+		mark = min(mark, seq-1)
+	}
+	return mark
+}
+
+// advance 推进持久化水位并唤醒等待者
+func (d *durabilityTracker) advance(mark int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if mark > d.durable {
+		d.durable = mark
+		d.notify()
+	}
+}
+
+// finishSync 结束 WaitDurable 触发的 sync，成功时推进水位
+func (d *durabilityTracker) finishSync(mark int64, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.syncing = false
+	d.syncErr = err
+	if err == nil && mark > d.durable {
+		d.durable = mark
+	}
+	d.notify()
+}
+
+// reset 表被清空后重置水位，之前的 seq 不再有效
+func (d *durabilityTracker) reset(epoch int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	clear(d.pending)
+	d.durable = d.seq.Load()
+	d.epoch = epoch
+	d.notify()
+}
+
+// notify 唤醒所有等待者，调用者必须持有 mu
+func (d *durabilityTracker) notify() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// InsertWithToken 插入数据并返回持久化凭证（支持的类型同 Insert）
+//
+// 数据写入 WAL 与 MemTable 后立即返回，不等待 fsync；
+// 通过 token.Wait 或 WaitDurable 等待数据持久化。
+func (t *Table) InsertWithToken(data any) (DurabilityToken, error) {
+	rows, err := t.normalizeInsertData(data)
+	if err != nil {
+		return DurabilityToken{}, err
+	}
+	if len(rows) == 0 {
+		return DurabilityToken{}, NewErrorf(ErrCodeInvalidParam, "no rows to insert")
+	}
+
+	t.durability.mu.Lock()
+	epoch := t.durability.epoch
+	t.durability.mu.Unlock()
+
+	seq, err := t.insertBatch(context.Background(), rows)
+	if err != nil {
+		return DurabilityToken{}, err
+	}
+	return DurabilityToken{Seq: seq, table: t, epoch: epoch}, nil
+}
+
+// WaitDurable 等待 seq 及之前的所有行持久化（写入已 fsync 的 WAL 或已 flush 的 SST）
+//
+// 尚未持久化时触发一次 WAL fsync，并发的等待者共享同一次 fsync。
+// ctx 取消时返回 ctx.Err()；等待期间表被关闭或清空时返回 ErrTableClosed 或 ErrTableReset。
+func (t *Table) WaitDurable(ctx context.Context, seq int64) error {
+	if seq <= 0 || seq > t.seq.Load() {
+		return NewErrorf(ErrCodeInvalidParam, "seq %d has not been written", seq)
+	}
+
+	t.durability.mu.Lock()
+	epoch := t.durability.epoch
+	t.durability.mu.Unlock()
+
+	return t.waitDurable(ctx, seq, epoch)
+}
+
+// waitDurable 等待 seq 持久化，epoch 与当前水位不一致（表已被清空）时返回 ErrTableReset
+func (t *Table) waitDurable(ctx context.Context, seq, epoch int64) error {
+	d := t.durability
+	started := false
+	for {
+		d.mu.Lock()
+		if d.epoch != epoch {
+			d.mu.Unlock()
+			return ErrTableReset
+		}
+		if d.durable >= seq {
+			d.mu.Unlock()
+			return nil
+		}
+		if started && d.syncErr != nil {
+			err := d.syncErr
+			d.mu.Unlock()
+			return err
+		}
+		changed := d.changed
+		if !d.syncing {
+			d.syncing = true
+			d.syncErr = nil
+			started = true
+			go t.syncWAL(epoch)
+		}
+		d.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// syncWAL fsync 当前 WAL 并推进持久化水位
+func (t *Table) syncWAL(epoch int64) {
+	done, err := t.beginRead(epoch)
+	if err != nil {
+		t.durability.finishSync(0, err)
+		return
+	}
+	defer done()
+
+	mark := t.durability.watermark()
+	err = t.walManager.Sync()
+	t.durability.finishSync(mark, err)
+}
@@ -0,0 +1,141 @@
+package srdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitDurable(t *testing.T) {
+	dir := t.TempDir()
+
+	table, err := OpenTable(&TableOptions{
+		Dir:  dir,
+		Name: "events",
+		Fields: []Field{
+			{Name: "msg", Type: String},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	ctx := context.Background()
+
+	// 单条插入：等待 WAL fsync
+	token, err := table.InsertWithToken(map[string]any{"msg": "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Seq != 1 {
+		t.Errorf("expected seq 1, got %d", token.Seq)
+	}
+	if err := token.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 批量插入：凭证覆盖整批数据
+	token, err = table.InsertWithToken([]map[string]any{{"msg": "a"}, {"msg": "b"}, {"msg": "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Seq != 4 {
+		t.Errorf("expected seq 4, got %d", token.Seq)
+	}
+	if err := table.WaitDurable(ctx, token.Seq); err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发插入与等待共享 fsync
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := table.InsertWithToken(map[string]any{"msg": fmt.Sprintf("msg-%d", i)})
+			if err == nil {
+				err = token.Wait(ctx)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if durable := table.durability.watermark(); durable != table.seq.Load() {
+		t.Errorf("expected watermark %d, got %d", table.seq.Load(), durable)
+	}
+
+	// Flush 后无需 fsync 即已持久化
+	token, err = table.InsertWithToken(map[string]any{"msg": "flushed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	table.durability.mu.Lock()
+	durable := table.durability.durable
+	table.durability.mu.Unlock()
+	if durable < token.Seq {
+		t.Errorf("expected flush to advance durable seq to %d, got %d", token.Seq, durable)
+	}
+
+	// 参数校验
+	if err := table.WaitDurable(ctx, table.seq.Load()+1); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for unwritten seq, got %v", err)
+	}
+	if err := (DurabilityToken{}).Wait(ctx); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for zero token, got %v", err)
+	}
+	if _, err := table.InsertWithToken([]map[string]any{}); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for empty insert, got %v", err)
+	}
+
+	// ctx 超时
+	token, err = table.InsertWithToken(map[string]any{"msg": "timeout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := token.Wait(cancelled); err != nil && err != context.Canceled {
+		t.Errorf("expected nil or context.Canceled, got %v", err)
+	}
+
+	// Clean 后旧凭证失效
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := table.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		if err := table.Insert(map[string]any{"msg": "after clean"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := token.Wait(ctx); !IsError(err, ErrCodeTableReset) {
+		t.Errorf("expected ErrCodeTableReset for stale token, got %v", err)
+	}
+
+	// 关闭后已持久化的数据仍可确认
+	token, err = table.InsertWithToken(map[string]any{"msg": "before close"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := token.Wait(ctx); err != nil {
+		t.Errorf("expected data flushed on close to be durable, got %v", err)
+	}
+}
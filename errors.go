@@ -11,12 +11,13 @@
 // 错误码定义
 const (
 	// 通用错误 (1000-1999)
-	ErrCodeNotFound     ErrCode = 1000 // 数据未找到
-	ErrCodeClosed       ErrCode = 1001 // 对象已关闭
-	ErrCodeInvalidData  ErrCode = 1002 // 无效数据
-	ErrCodeCorrupted    ErrCode = 1003 // 数据损坏
-	ErrCodeExists       ErrCode = 1004 // 对象已存在
-	ErrCodeInvalidParam ErrCode = 1005 // 无效参数
+	ErrCodeNotFound      ErrCode = 1000 // 数据未找到
+	ErrCodeClosed        ErrCode = 1001 // 对象已关闭
+	ErrCodeInvalidData   ErrCode = 1002 // 无效数据
+	ErrCodeCorrupted     ErrCode = 1003 // 数据损坏
+	ErrCodeExists        ErrCode = 1004 // 对象已存在
+	ErrCodeInvalidParam  ErrCode = 1005 // 无效参数
+	ErrCodeFaultInjected ErrCode = 1006 // 注入的故障（FaultInjector）
 
 	// 数据库错误 (2000-2999)
 	ErrCodeDatabaseNotFound ErrCode = 2000 // 数据库不存在
@@ -27,6 +28,7 @@
 	ErrCodeTableNotFound ErrCode = 3000 // 表不存在
 	ErrCodeTableExists   ErrCode = 3001 // 表已存在
 	ErrCodeTableClosed   ErrCode = 3002 // 表已关闭
+	ErrCodeTableReset    ErrCode = 3003 // 表已被清空（迭代器失效）
 
 	// Schema 错误 (4000-4999)
 	ErrCodeSchemaNotFound         ErrCode = 4000 // Schema 不存在
@@ -67,17 +69,23 @@
 	// 编解码错误 (11000-11999)
 	ErrCodeEncodeFailed ErrCode = 11000 // 编码失败
 	ErrCodeDecodeFailed ErrCode = 11001 // 解码失败
+
+	// 查询错误 (12000-12999)
+	ErrCodeAggregateOverflow   ErrCode = 12000 // 聚合结果超出累加类型的范围
+	ErrCodeConcurrentRowsUse   ErrCode = 12001 // 多个 goroutine 同时使用同一个 Rows
+	ErrCodeQueryMemoryExceeded ErrCode = 12002 // 查询物化的数据超过内存上限
 )
 
 // 错误码消息映射
 var errCodeMessages = map[ErrCode]string{
 	// 通用错误
-	ErrCodeNotFound:     "not found",
-	ErrCodeClosed:       "already closed",
-	ErrCodeInvalidData:  "invalid data",
-	ErrCodeCorrupted:    "data corrupted",
-	ErrCodeExists:       "already exists",
-	ErrCodeInvalidParam: "invalid parameter",
+	ErrCodeNotFound:      "not found",
+	ErrCodeClosed:        "already closed",
+	ErrCodeInvalidData:   "invalid data",
+	ErrCodeCorrupted:     "data corrupted",
+	ErrCodeExists:        "already exists",
+	ErrCodeInvalidParam:  "invalid parameter",
+	ErrCodeFaultInjected: "injected fault",
 
 	// 数据库错误
 	ErrCodeDatabaseNotFound: "database not found",
@@ -88,6 +96,7 @@
 	ErrCodeTableNotFound: "table not found",
 	ErrCodeTableExists:   "table already exists",
 	ErrCodeTableClosed:   "table closed",
+	ErrCodeTableReset:    "table reset",
 
 	// Schema 错误
 	ErrCodeSchemaNotFound:         "schema not found",
@@ -128,6 +137,11 @@
 	// 编解码错误
 	ErrCodeEncodeFailed: "encode failed",
 	ErrCodeDecodeFailed: "decode failed",
+
+	// 查询错误
+	ErrCodeAggregateOverflow:   "aggregate overflow",
+	ErrCodeConcurrentRowsUse:   "concurrent use of rows",
+	ErrCodeQueryMemoryExceeded: "query memory limit exceeded",
 }
 
 // Error 错误类型
@@ -220,6 +234,7 @@ func NewErrorf(code ErrCode, format string, args ...any) *Error {
 	ErrTableNotFound = NewError(ErrCodeTableNotFound, nil)
 	ErrTableExists   = NewError(ErrCodeTableExists, nil)
 	ErrTableClosed   = NewError(ErrCodeTableClosed, nil)
+	ErrTableReset    = NewError(ErrCodeTableReset, nil)
 )
 
 // Schema 错误（向后兼容）
@@ -278,6 +293,13 @@ func NewErrorf(code ErrCode, format string, args ...any) *Error {
 	ErrDecodeFailed = NewError(ErrCodeDecodeFailed, nil)
 )
 
+// 查询错误
+var (
+	ErrAggregateOverflow   = NewError(ErrCodeAggregateOverflow, nil)
+	ErrConcurrentRowsUse   = NewError(ErrCodeConcurrentRowsUse, nil)
+	ErrQueryMemoryExceeded = NewError(ErrCodeQueryMemoryExceeded, nil)
+)
+
 // 辅助函数
 
 // GetErrorCode 获取错误码
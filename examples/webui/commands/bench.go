@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+
+	"github.com/hupeh/srdb"
+	"github.com/hupeh/srdb/bench"
+)
+
+// Bench 在指定表上运行负载并输出吞吐量与延迟分位数
+// 表不存在时使用 bench.Fields() 创建；Ctrl+C 提前结束并输出已有结果
+func Bench(dbPath, tableName string, cfg bench.Config) {
+	// 打开数据库
+	db, err := srdb.Open(dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	var table *srdb.Table
+	if slices.Contains(db.ListTables(), tableName) {
+		table, err = db.GetTable(tableName)
+	} else {
+		var schema *srdb.Schema
+		schema, err = srdb.NewSchema(tableName, bench.Fields())
+		if err == nil {
+			table, err = db.CreateTable(tableName, schema)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Running benchmark on table '%s'...\n", tableName)
+	report, err := bench.Run(ctx, table, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println()
+	fmt.Print(report)
+}
@@ -3,12 +3,13 @@
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/hupeh/srdb"
 )
 
-// DumpManifest 导出 manifest 信息
-func DumpManifest(dbPath string) {
+// DumpManifest 导出 manifest 信息，history 为输出的最近版本变更条数（0 表示不输出）
+func DumpManifest(dbPath string, history int) {
 	db, err := srdb.Open(dbPath)
 	if err != nil {
 		log.Fatal(err)
@@ -54,4 +55,31 @@ func DumpManifest(dbPath string) {
 			}
 		}
 	}
+
+	if history <= 0 {
+		return
+	}
+	edits, err := versionSet.History(history)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nRecent %d edits:\n", len(edits))
+	for _, edit := range edits {
+		when := "-"
+		if t := edit.When(); !t.IsZero() {
+			when = t.Format(time.RFC3339)
+		}
+		reason := edit.Reason
+		if reason == "" {
+			reason = "-"
+		}
+		fmt.Printf("  %s %-10s", when, reason)
+		for _, f := range edit.AddedFiles {
+			fmt.Printf(" +%06d(L%d)", f.FileNumber, f.Level)
+		}
+		for _, num := range edit.DeletedFiles {
+			fmt.Printf(" -%06d", num)
+		}
+		fmt.Println()
+	}
 }
@@ -42,7 +42,15 @@ func InspectAllSST(sstDir string) {
 		}
 
 		header := reader.GetHeader()
-		allKeys := reader.GetAllKeys()
+
+		// 按顺序扫描 key，不需要一次性加载所有 key
+		var count, firstKey, lastKey int64
+		for it := reader.NewIterator(); it.Next(); count++ {
+			if count == 0 {
+				firstKey = it.Key()
+			}
+			lastKey = it.Key()
+		}
 
 		// Extract file number
 		numStr := strings.TrimPrefix(filename, "000")
@@ -53,17 +61,17 @@ func InspectAllSST(sstDir string) {
 
 		fmt.Printf("File #%d (%s):\n", fileNum, filename)
 		fmt.Printf("  Header: MinKey=%d MaxKey=%d RowCount=%d\n", header.MinKey, header.MaxKey, header.RowCount)
-		fmt.Printf("  Actual: %d keys", len(allKeys))
-		if len(allKeys) > 0 {
-			fmt.Printf(" [%d ... %d]", allKeys[0], allKeys[len(allKeys)-1])
+		fmt.Printf("  Actual: %d keys", count)
+		if count > 0 {
+			fmt.Printf(" [%d ... %d]", firstKey, lastKey)
 		}
 		fmt.Printf("\n")
 
 		// Check if header matches actual keys
-		if len(allKeys) > 0 {
-			if header.MinKey != allKeys[0] || header.MaxKey != allKeys[len(allKeys)-1] {
+		if count > 0 {
+			if header.MinKey != firstKey || header.MaxKey != lastKey {
 				fmt.Printf("  *** MISMATCH: Header says %d-%d but file has %d-%d ***\n",
-					header.MinKey, header.MaxKey, allKeys[0], allKeys[len(allKeys)-1])
+					header.MinKey, header.MaxKey, firstKey, lastKey)
 			}
 		}
 
@@ -15,7 +15,8 @@
 )
 
 // StartWebUI 启动 WebUI 服务器
-func StartWebUI(dbPath string, addr string) {
+// showSensitive 为 true 时返回敏感字段的原始值，否则脱敏显示
+func StartWebUI(dbPath string, addr string, showSensitive bool) {
 	// 打开数据库
 	db, err := srdb.Open(dbPath)
 	if err != nil {
@@ -112,7 +113,7 @@ func StartWebUI(dbPath string, addr string) {
 	go autoInsertData(db)
 
 	// 创建 WebUI，使用 /debug 作为 basePath
-	ui := webui.NewWebUI(db, "/debug")
+	ui := webui.NewWebUI(db, "/debug").SetPrivileged(showSensitive)
 
 	// 创建主路由
 	mux := http.NewServeMux()
@@ -5,6 +5,7 @@
 	"fmt"
 	"os"
 
+	"github.com/hupeh/srdb/bench"
 	"github.com/hupeh/srdb/examples/webui/commands"
 )
 
@@ -22,8 +23,9 @@ func main() {
 		serveCmd := flag.NewFlagSet("webui", flag.ExitOnError)
 		dbPath := serveCmd.String("db", "./data", "Database directory path")
 		addr := serveCmd.String("addr", ":8080", "Server address")
+		showSensitive := serveCmd.Bool("show-sensitive", false, "Show sensitive field values instead of redacting them")
 		serveCmd.Parse(args)
-		commands.StartWebUI(*dbPath, *addr)
+		commands.StartWebUI(*dbPath, *addr, *showSensitive)
 
 	case "check-data":
 		checkDataCmd := flag.NewFlagSet("check-data", flag.ExitOnError)
@@ -40,8 +42,9 @@ func main() {
 	case "dump-manifest":
 		dumpCmd := flag.NewFlagSet("dump-manifest", flag.ExitOnError)
 		dbPath := dumpCmd.String("db", "./data", "Database directory path")
+		history := dumpCmd.Int("history", 20, "Number of recent version edits to print (0 to skip)")
 		dumpCmd.Parse(args)
-		commands.DumpManifest(*dbPath)
+		commands.DumpManifest(*dbPath, *history)
 
 	case "inspect-all-sst":
 		inspectAllCmd := flag.NewFlagSet("inspect-all-sst", flag.ExitOnError)
@@ -74,6 +77,45 @@ func main() {
 		genTablesCmd.Parse(args)
 		commands.GenerateTables(*dbPath, *count)
 
+	case "bench":
+		benchCmd := flag.NewFlagSet("bench", flag.ExitOnError)
+		dbPath := benchCmd.String("db", "./data", "Database directory path")
+		tableName := benchCmd.String("table", "bench", "Table to run the workload on (created if missing)")
+		duration := benchCmd.Duration("duration", 0, "How long to run (default 10s unless -ops is set)")
+		ops := benchCmd.Int64("ops", 0, "Total number of operations (0 = unlimited)")
+		concurrency := benchCmd.Int("concurrency", 0, "Number of concurrent workers (default: number of CPUs)")
+		mix := benchCmd.String("mix", "insert=1", "Operation weights, e.g. insert=80,get=15,scan=5,index=0")
+		rowSize := benchCmd.Int("row-size", 256, "Row payload size in bytes (mean for exp)")
+		rowSizeMax := benchCmd.Int("row-size-max", 0, "Maximum row payload size for uniform/exp (default: 4x row-size)")
+		rowDist := benchCmd.String("row-dist", "fixed", "Row size distribution: fixed, uniform or exp")
+		batch := benchCmd.Int("batch", 1, "Rows per insert operation")
+		preload := benchCmd.Int("preload", 0, "Rows to insert before timing (default 10000 for reads on an empty table)")
+		seed := benchCmd.Uint64("seed", 0, "Random seed")
+		benchCmd.Parse(args)
+
+		opMix, err := bench.ParseMix(*mix)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		dist, err := bench.ParseSizeDistribution(*rowDist)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		commands.Bench(*dbPath, *tableName, bench.Config{
+			Duration:    *duration,
+			Operations:  *ops,
+			Concurrency: *concurrency,
+			Mix:         opMix,
+			RowSize:     *rowSize,
+			RowSizeMax:  *rowSizeMax,
+			RowSizeDist: dist,
+			BatchSize:   *batch,
+			Preload:     *preload,
+			Seed:        *seed,
+		})
+
 	case "help", "-h", "--help":
 		printUsage()
 
@@ -98,10 +140,12 @@ func printUsage() {
 	fmt.Println("  test-fix           Test fix for data retrieval")
 	fmt.Println("  test-keys          Test key existence")
 	fmt.Println("  generate-tables    Generate test tables (default: 100)")
+	fmt.Println("  bench              Run a load-generation benchmark")
 	fmt.Println("  help               Show this help message")
 	fmt.Println("\nExamples:")
 	fmt.Println("  webui serve -db ./mydb -addr :3000")
 	fmt.Println("  webui check-data -db ./mydb")
 	fmt.Println("  webui inspect-sst -file ./data/logs/sst/000046.sst")
 	fmt.Println("  webui generate-tables -db ./mydb -count 100")
+	fmt.Println("  webui bench -db ./benchdb -duration 30s -concurrency 8 -mix insert=80,get=15,scan=5 -row-dist exp")
 }
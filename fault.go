@@ -0,0 +1,178 @@
+package srdb
+
+import (
+	"sync"
+)
+
+// FaultPoint 可注入故障的位置
+type FaultPoint string
+
+const (
+	FaultWALAppend       FaultPoint = "wal.append"       // 写入 WAL 记录（Insert）
+	FaultWALSync         FaultPoint = "wal.sync"         // WAL fsync（WaitDurable、切换 MemTable）
+	FaultManifestWrite   FaultPoint = "manifest.write"   // 写入 MANIFEST 记录（flush、compaction、批量导入）
+	FaultCompactionWrite FaultPoint = "compaction.write" // 完成 compaction 输出文件（提交前的最后一步）
+	FaultBatchCommit     FaultPoint = "batch.commit"     // 写入原子批量写入的提交标记（各表 WAL 已写入并 fsync）
+)
+
+// FaultInjector 故障注入器（仅用于测试）
+//
+// 通过 TableOptions.FaultInjector 或 Options.FaultInjector 配置后，在指定位置返回错误，
+// 用于编写确定性的故障与崩溃恢复测试。未配置时没有额外开销。
+//
+//	faults := srdb.NewFaultInjector()
+//	opts.FaultInjector = faults
+//	faults.FailNext(srdb.FaultWALSync, nil) // 下一次 fsync 失败
+//	err := table.WaitDurable(ctx, seq)      // IsError(err, ErrCodeFaultInjected)
+//	table.SimulateCrash()                   // 不 flush 直接"崩溃"，然后重新打开验证恢复
+type FaultInjector struct {
+	mu    sync.Mutex
+	rules map[FaultPoint]*faultRule
+	hits  map[FaultPoint]int
+}
+
+// faultRule 一个位置的故障规则
+type faultRule struct {
+	skip   int   // 失败前允许成功的次数
+	times  int   // 失败次数，-1 表示一直失败
+	err    error // nil 时使用 ErrCodeFaultInjected
+	failed int
+}
+
+// NewFaultInjector 创建故障注入器
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{
+		rules: make(map[FaultPoint]*faultRule),
+		hits:  make(map[FaultPoint]int),
+	}
+}
+
+// FailNext 下一次经过 point 时返回 err（nil 表示 ErrCodeFaultInjected 错误），之后恢复正常
+func (f *FaultInjector) FailNext(point FaultPoint, err error) {
+	f.FailAfter(point, 0, err)
+}
+
+// FailAfter 经过 point 时先成功 n 次，第 n+1 次返回 err，之后恢复正常
+func (f *FaultInjector) FailAfter(point FaultPoint, n int, err error) {
+	f.setRule(point, &faultRule{skip: max(n, 0), times: 1, err: err})
+}
+
+// FailAlways 每次经过 point 都返回 err，直到调用 Clear
+func (f *FaultInjector) FailAlways(point FaultPoint, err error) {
+	f.setRule(point, &faultRule{times: -1, err: err})
+}
+
+// Clear 清除 point 的故障规则（不重置计数）
+func (f *FaultInjector) Clear(point FaultPoint) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.rules, point)
+}
+
+// Hits 返回经过 point 的次数（包括失败的次数）
+func (f *FaultInjector) Hits(point FaultPoint) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hits[point]
+}
+
+func (f *FaultInjector) setRule(point FaultPoint, rule *faultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules[point] = rule
+}
+
+// check 记录经过 point，按规则返回注入的错误（f 为 nil 时总是返回 nil）
+func (f *FaultInjector) check(point FaultPoint) error {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.hits[point]++
+	rule, ok := f.rules[point]
+	if !ok {
+		return nil
+	}
+	if rule.skip > 0 {
+		rule.skip--
+		return nil
+	}
+
+	rule.failed++
+	if rule.times >= 0 && rule.failed >= rule.times {
+		delete(f.rules, point)
+	}
+	if rule.err != nil {
+		return rule.err
+	}
+	return NewErrorf(ErrCodeFaultInjected, "injected fault at %s", point)
+}
+
+// SimulateCrash 模拟进程崩溃（仅用于测试，需要配置 TableOptions.FaultInjector）
+//
+// 停止后台任务并关闭文件，不 flush MemTable、不保存索引，之后表不可用。
+// 已写入 WAL 的数据仍在操作系统缓存中，重新打开表时按崩溃恢复流程从 WAL 回放。
+func (t *Table) SimulateCrash() error {
+	if t.faults == nil {
+		return NewErrorf(ErrCodeInvalidParam, "SimulateCrash requires a fault injector")
+	}
+
+	t.lifecycleMu.Lock()
+	defer t.lifecycleMu.Unlock()
+
+	if t.closed.Swap(true) {
+		return nil
+	}
+	t.epoch.Add(1)
+
+	if t.stopAutoFlush != nil {
+		select {
+		case <-t.stopAutoFlush:
+		default:
+			close(t.stopAutoFlush)
+		}
+	}
+	if t.compactionManager != nil {
+		t.compactionManager.Stop()
+	}
+	if t.walManager != nil {
+		t.walManager.Close()
+	}
+	if t.versionSet != nil {
+		t.versionSet.Close()
+	}
+	if t.indexManager != nil {
+		t.indexManager.Close()
+	}
+	if t.sstManager != nil {
+		t.sstManager.Close()
+	}
+
+	return nil
+}
+
+// SimulateCrash 模拟进程崩溃：所有表调用 Table.SimulateCrash（需要配置 Options.FaultInjector）
+//
+// 之后 Database 不可用，使用相同的目录重新打开以验证恢复。
+func (db *Database) SimulateCrash() error {
+	if db.options.FaultInjector == nil {
+		return NewErrorf(ErrCodeInvalidParam, "SimulateCrash requires a fault injector")
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.batches.close()
+	for _, table := range db.tables {
+		if err := table.SimulateCrash(); err != nil {
+			return err
+		}
+	}
+	if db.kv != nil {
+		return db.kv.table.SimulateCrash()
+	}
+	return nil
+}
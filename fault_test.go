@@ -0,0 +1,199 @@
+package srdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func openFaultTable(t *testing.T, dir string, faults *FaultInjector) *Table {
+	t.Helper()
+	table, err := OpenTable(&TableOptions{
+		Dir:           dir,
+		Name:          "events",
+		Fields:        []Field{{Name: "n", Type: Int64}},
+		FaultInjector: faults,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestFaultInjectorRules(t *testing.T) {
+	faults := NewFaultInjector()
+
+	faults.FailAfter(FaultWALAppend, 2, nil)
+	for i := range 4 {
+		err := faults.check(FaultWALAppend)
+		if (i == 2) != (err != nil) {
+			t.Fatalf("check %d: unexpected error %v", i, err)
+		}
+		if err != nil && !IsError(err, ErrCodeFaultInjected) {
+			t.Errorf("expected ErrCodeFaultInjected, got %v", err)
+		}
+	}
+
+	custom := errors.New("disk full")
+	faults.FailAlways(FaultWALSync, custom)
+	for range 3 {
+		if err := faults.check(FaultWALSync); err != custom {
+			t.Fatalf("expected custom error, got %v", err)
+		}
+	}
+	faults.Clear(FaultWALSync)
+	if err := faults.check(FaultWALSync); err != nil {
+		t.Fatalf("expected no error after Clear, got %v", err)
+	}
+
+	if faults.Hits(FaultWALAppend) != 4 || faults.Hits(FaultWALSync) != 4 {
+		t.Errorf("unexpected hits %d/%d", faults.Hits(FaultWALAppend), faults.Hits(FaultWALSync))
+	}
+
+	var nilInjector *FaultInjector
+	if err := nilInjector.check(FaultWALAppend); err != nil {
+		t.Errorf("nil injector should never fail, got %v", err)
+	}
+}
+
+func TestFaultInjectionWAL(t *testing.T) {
+	faults := NewFaultInjector()
+	table := openFaultTable(t, t.TempDir(), faults)
+	defer table.Close()
+
+	// 第 3 次写 WAL 失败，数据不应写入
+	faults.FailAfter(FaultWALAppend, 2, nil)
+	for i := range 4 {
+		err := table.Insert(map[string]any{"n": int64(i)})
+		if i == 2 {
+			if !IsError(err, ErrCodeFaultInjected) {
+				t.Fatalf("expected injected fault, got %v", err)
+			}
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := rows.Collect(); len(data) != 3 {
+		t.Errorf("expected 3 rows, got %d", len(data))
+	}
+
+	// fsync 失败时 WaitDurable 返回错误，重试后成功
+	token, err := table.InsertWithToken(map[string]any{"n": int64(10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	faults.FailNext(FaultWALSync, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := table.WaitDurable(ctx, token.Seq); !IsError(err, ErrCodeFaultInjected) {
+		t.Fatalf("expected injected fault, got %v", err)
+	}
+	if err := table.WaitDurable(ctx, token.Seq); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+}
+
+func TestFaultInjectionManifestCrash(t *testing.T) {
+	dir := t.TempDir()
+	faults := NewFaultInjector()
+	table := openFaultTable(t, dir, faults)
+
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// flush 时写 MANIFEST 失败，随后崩溃：数据只能从 WAL 恢复
+	faults.FailNext(FaultManifestWrite, nil)
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for faults.Hits(FaultManifestWrite) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("flush did not reach MANIFEST write")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := table.SimulateCrash(); err != nil {
+		t.Fatal(err)
+	}
+
+	table = openFaultTable(t, dir, nil)
+	defer table.Close()
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int64]int)
+	for _, row := range rows.Collect() {
+		seen[row["n"].(int64)]++
+	}
+	if len(seen) != 100 {
+		t.Fatalf("expected 100 distinct rows after recovery, got %d", len(seen))
+	}
+	for n, count := range seen {
+		if count != 1 {
+			t.Errorf("row %d recovered %d times", n, count)
+		}
+	}
+}
+
+func TestFaultInjectionCompaction(t *testing.T) {
+	faults := NewFaultInjector()
+	table := openFaultTable(t, t.TempDir(), faults)
+	defer table.Close()
+
+	// 生成多个 L0 文件
+	for batch := range 4 {
+		for i := range 10 {
+			if err := table.Insert(map[string]any{"n": int64(batch*10 + i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for table.memtableManager.GetImmutableCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("flush did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	faults.FailAlways(FaultCompactionWrite, nil)
+	if err := table.compactionManager.TriggerCompaction(); !IsError(err, ErrCodeFaultInjected) {
+		t.Fatalf("expected injected fault, got %v", err)
+	}
+	faults.Clear(FaultCompactionWrite)
+	if err := table.compactionManager.TriggerCompaction(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := rows.Collect(); len(data) != 40 {
+		t.Errorf("expected 40 rows after compaction, got %d", len(data))
+	}
+}
+
+func TestSimulateCrashRequiresInjector(t *testing.T) {
+	table := openFaultTable(t, t.TempDir(), nil)
+	defer table.Close()
+
+	if err := table.SimulateCrash(); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+}
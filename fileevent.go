@@ -0,0 +1,127 @@
+package srdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileOp 文件事件的操作
+type FileOp string
+
+const (
+	FileCreated FileOp = "created" // 文件已创建（SST 已写入完成）
+	FileDeleted FileOp = "deleted" // 文件已删除
+)
+
+// FileType 文件事件的文件类型
+type FileType string
+
+const (
+	FileTypeWAL FileType = "wal"
+	FileTypeSST FileType = "sst"
+)
+
+// FileReason 文件创建或删除的原因
+type FileReason string
+
+const (
+	FileReasonRotate     FileReason = "rotate"     // MemTable 切换时创建新的 WAL
+	FileReasonFlush      FileReason = "flush"      // flush 写入 L0 SST，并删除对应的 WAL
+	FileReasonCompaction FileReason = "compaction" // Compaction 写入新的 SST，并删除输入文件
+	FileReasonCleanup    FileReason = "cleanup"    // Compaction 提交失败，删除已写入的输出文件
+	FileReasonGC         FileReason = "gc"         // 垃圾回收删除孤儿 SST 或已 flush 的 WAL
+	FileReasonRetention  FileReason = "retention"  // 保留策略删除所有行都已过期的 SST
+)
+
+// FileEvent 表的 WAL 或 SST 文件创建或删除后的事件
+//
+// 用于外部监控或清理工具实时核对磁盘内容与数据库状态：
+// 每个由数据库创建的文件都有一个 FileCreated 事件，删除时有一个 FileDeleted 事件。
+// Clean、Destroy 整体删除目录时不逐个文件通知（见 Options.OnTableDropped）。
+type FileEvent struct {
+	Table      string        // 表名
+	Op         FileOp        // 创建或删除
+	Type       FileType      // WAL 或 SST
+	Reason     FileReason    // 原因
+	FileNumber int64         // 文件编号
+	Path       string        // 文件路径
+	Level      int           // SST 所在层级（WAL 与孤儿 SST 为 -1）
+	Size       int64         // 文件大小（字节），新 WAL 为 0
+	Duration   time.Duration // 创建文件的耗时（flush、Compaction 写入 SST），其他为 0
+	Time       time.Time     // 事件时间
+}
+
+// OnFileEvent 注册文件事件监听器
+// 在创建或删除文件的 goroutine 中同步调用（flush、Compaction、GC），监听器应尽快返回
+func (t *Table) OnFileEvent(fn func(FileEvent)) {
+	if fn == nil {
+		return
+	}
+	t.fileListenersMu.Lock()
+	t.fileListeners = append(t.fileListeners, fn)
+	t.fileListenersMu.Unlock()
+}
+
+// notifyFile 调用所有文件事件监听器（补全表名与时间）
+func (t *Table) notifyFile(event FileEvent) {
+	t.fileListenersMu.RLock()
+	listeners := t.fileListeners
+	t.fileListenersMu.RUnlock()
+	if len(listeners) == 0 {
+		return
+	}
+
+	event.Table = t.schema.Load().Name
+	if event.Time.IsZero() {
+		event.Time = t.clock.Now()
+	}
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// deleteWAL 删除 WAL 文件并通知文件事件
+func (t *Table) deleteWAL(number int64, reason FileReason) error {
+	walPath := filepath.Join(t.walManager.dir, fmt.Sprintf("%06d.wal", number))
+	var size int64
+	if info, err := os.Stat(walPath); err == nil {
+		size = info.Size()
+	}
+	if err := t.walManager.Delete(number); err != nil {
+		return err
+	}
+	t.notifyFile(FileEvent{
+		Op:         FileDeleted,
+		Type:       FileTypeWAL,
+		Reason:     reason,
+		FileNumber: number,
+		Path:       walPath,
+		Level:      -1,
+		Size:       size,
+	})
+	return nil
+}
+
+// SetFileListener 设置 SST 文件事件的接收函数（由 Table 设置，需在 Start 之前调用）
+func (m *CompactionManager) SetFileListener(fn func(FileEvent)) {
+	m.fileListener = fn
+}
+
+// notifyFile 通知 SST 文件事件
+func (m *CompactionManager) notifyFile(op FileOp, reason FileReason, file *FileMetadata, duration time.Duration) {
+	if m.fileListener == nil {
+		return
+	}
+	m.fileListener(FileEvent{
+		Op:         op,
+		Type:       FileTypeSST,
+		Reason:     reason,
+		FileNumber: file.FileNumber,
+		Path:       filepath.Join(m.sstDir, fmt.Sprintf("%06d.sst", file.FileNumber)),
+		Level:      file.Level,
+		Size:       file.FileSize,
+		Duration:   duration,
+	})
+}
@@ -0,0 +1,104 @@
+package srdb
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileEvents(t *testing.T) {
+	var mu sync.Mutex
+	var events []FileEvent
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+		OnFileEvent: func(e FileEvent) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 生成多个 L0 文件
+	for batch := range 4 {
+		for i := range 10 {
+			if err := table.Insert(map[string]any{"n": int64(batch*10 + i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for table.memtableManager.GetImmutableCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("flush did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := table.compactionManager.TriggerCompaction(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	count := func(op FileOp, typ FileType, reason FileReason) int {
+		n := 0
+		for _, e := range events {
+			if e.Op == op && e.Type == typ && e.Reason == reason {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(FileCreated, FileTypeWAL, FileReasonRotate); n != 4 {
+		t.Errorf("expected 4 rotated WALs, got %d", n)
+	}
+	if n := count(FileCreated, FileTypeSST, FileReasonFlush); n != 4 {
+		t.Errorf("expected 4 flushed SSTs, got %d", n)
+	}
+	if n := count(FileDeleted, FileTypeWAL, FileReasonFlush); n != 4 {
+		t.Errorf("expected 4 deleted WALs, got %d", n)
+	}
+	if count(FileCreated, FileTypeSST, FileReasonCompaction) == 0 || count(FileDeleted, FileTypeSST, FileReasonCompaction) == 0 {
+		t.Errorf("expected compaction events, got %+v", events)
+	}
+
+	// 事件与磁盘内容一致：创建后未删除的 SST 都存在，删除的都不存在
+	live := make(map[string]bool)
+	for _, e := range events {
+		if e.Table != "events" || e.Time.IsZero() {
+			t.Errorf("event missing table or time: %+v", e)
+		}
+		if e.Type != FileTypeSST {
+			continue
+		}
+		if e.Op == FileCreated {
+			if e.Size <= 0 || e.Duration <= 0 {
+				t.Errorf("created SST missing size or duration: %+v", e)
+			}
+			live[e.Path] = true
+		} else {
+			if e.Size <= 0 || e.Level < 0 {
+				t.Errorf("deleted SST missing metadata: %+v", e)
+			}
+			delete(live, e.Path)
+		}
+	}
+	if len(live) != table.sstManager.Count() {
+		t.Errorf("expected %d live SSTs, events report %d", table.sstManager.Count(), len(live))
+	}
+	for path := range live {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("live SST %s: %v", path, err)
+		}
+	}
+}
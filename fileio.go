@@ -0,0 +1,355 @@
+package srdb
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/edsrzf/mmap-go"
+)
+
+// IOMode SST 与索引文件的读取方式
+type IOMode int
+
+const (
+	IOModeMmap        IOMode = iota // 内存映射（默认），映射失败时自动回退到 pread
+	IOModePread                     // 通过 pread（ReadAt）按需读取，不依赖 mmap，适用于 NFS 等文件系统
+	IOModeChunkedMmap               // 按 64MB 窗口按需映射，适用于地址空间有限的 32 位平台
+)
+
+const (
+	// chunkedMmapWindowSize 分块映射的窗口大小
+	chunkedMmapWindowSize = 64 * 1024 * 1024
+
+	// chunkedMmapMaxWindows 同时保持映射的最大窗口数
+	chunkedMmapMaxWindows = 4
+
+	// largeFileMmapThreshold 32 位平台上超过该大小的文件在 IOModeMmap 下自动使用分块映射
+	largeFileMmapThreshold = 256 * 1024 * 1024
+)
+
+// is32Bit 当前平台是否为 32 位（地址空间有限，无法映射大文件）
+const is32Bit = strconv.IntSize == 32
+
+// String 返回读取方式名称
+func (m IOMode) String() string {
+	switch m {
+	case IOModeMmap:
+		return "mmap"
+	case IOModePread:
+		return "pread"
+	case IOModeChunkedMmap:
+		return "chunked-mmap"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(m))
+	}
+}
+
+// dataSource 只读文件数据源
+//
+// 不同 IOMode 提供相同的读取语义：
+//   - mmap: Slice 返回映射内存的切片（零拷贝），调用者不得修改
+//   - pread: Slice 每次从文件读取到新分配的缓冲区
+type dataSource interface {
+	// Slice 返回 [off, off+n) 的数据，越界时返回错误
+	Slice(off int64, n int) ([]byte, error)
+	// Size 返回数据总长度
+	Size() int64
+	// Close 释放数据源占用的资源（不关闭底层文件）
+	Close() error
+	// advise 提示后续的访问模式（仅为优化提示，不影响读取结果）
+	advise(pattern accessPattern)
+}
+
+// accessPattern 数据源访问模式提示
+type accessPattern int
+
+const (
+	accessNormal     accessPattern = iota // 随机访问（默认）
+	accessSequential                      // 顺序扫描：启用预读
+	accessDontNeed                        // 数据不再需要：释放本进程的映射与缓冲区（页缓存由 SSTableReader 通过 fadvise 释放）
+)
+
+const (
+	// scanReadaheadSize pread 模式顺序扫描时每次预读的字节数
+	scanReadaheadSize = 256 * 1024
+)
+
+// bytesSource 基于内存字节切片的数据源
+type bytesSource []byte
+
+func (b bytesSource) Slice(off int64, n int) ([]byte, error) {
+	if off < 0 || n < 0 || off+int64(n) > int64(len(b)) {
+		return nil, fmt.Errorf("read out of range: offset=%d, size=%d, len=%d", off, n, len(b))
+	}
+	return b[off : off+int64(n)], nil
+}
+
+func (b bytesSource) Size() int64 {
+	return int64(len(b))
+}
+
+func (b bytesSource) Close() error {
+	return nil
+}
+
+func (b bytesSource) advise(pattern accessPattern) {}
+
+// mmapSource 基于 mmap 的数据源
+type mmapSource struct {
+	bytesSource
+	data mmap.MMap
+}
+
+func (m *mmapSource) advise(pattern accessPattern) {
+	madvise(m.data, pattern)
+}
+
+func (m *mmapSource) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	err := m.data.Unmap()
+	m.data = nil
+	m.bytesSource = nil
+	return err
+}
+
+// preadSource 基于 pread 的数据源
+//
+// 顺序扫描期间（accessSequential）按 scanReadaheadSize 批量预读，
+// 后续命中预读缓冲区的读取不再发起系统调用。
+type preadSource struct {
+	file *os.File
+	size int64
+
+	mu        sync.Mutex
+	readahead bool   // 是否启用预读
+	buf       []byte // 预读缓冲区
+	bufOff    int64  // 预读缓冲区对应的文件偏移
+}
+
+func (p *preadSource) Slice(off int64, n int) ([]byte, error) {
+	if off < 0 || n < 0 || off+int64(n) > p.size {
+		return nil, fmt.Errorf("read out of range: offset=%d, size=%d, len=%d", off, n, p.size)
+	}
+
+	p.mu.Lock()
+	readahead := p.readahead
+	if readahead && n <= scanReadaheadSize {
+		defer p.mu.Unlock()
+
+		// 预读缓冲区未命中，从 off 开始读取一整块
+		if off < p.bufOff || off+int64(n) > p.bufOff+int64(len(p.buf)) {
+			length := min(int64(scanReadaheadSize), p.size-off)
+			if int64(cap(p.buf)) < length {
+				p.buf = make([]byte, length)
+			}
+			p.buf = p.buf[:length]
+			if _, err := p.file.ReadAt(p.buf, off); err != nil {
+				p.buf = p.buf[:0]
+				return nil, err
+			}
+			p.bufOff = off
+		}
+
+		// 返回副本，缓冲区会被后续预读覆盖
+		start := off - p.bufOff
+		return append([]byte(nil), p.buf[start:start+int64(n)]...), nil
+	}
+	p.mu.Unlock()
+
+	buf := make([]byte, n)
+	if _, err := p.file.ReadAt(buf, off); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (p *preadSource) Size() int64 {
+	return p.size
+}
+
+func (p *preadSource) Close() error {
+	p.advise(accessDontNeed)
+	return nil
+}
+
+func (p *preadSource) advise(pattern accessPattern) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.readahead = pattern == accessSequential
+	if !p.readahead {
+		// 释放预读缓冲区
+		p.buf = nil
+		p.bufOff = 0
+	}
+}
+
+// chunkedMmapSource 按窗口分块映射的数据源
+//
+// 每个窗口映射文件的一段连续区域，按需映射并按 LRU 淘汰，
+// 因此地址空间占用不超过 windowSize * maxWindows。
+// Slice 返回数据副本（窗口可能随时被淘汰）。
+type chunkedMmapSource struct {
+	file       *os.File
+	size       int64
+	windowSize int64
+	maxWindows int
+
+	mu      sync.Mutex
+	windows map[int64]mmap.MMap // 窗口编号 -> 映射
+	lru     []int64             // 窗口编号，最近使用的在末尾
+	pattern accessPattern       // 当前访问模式提示，新映射的窗口同样应用
+}
+
+// newChunkedMmapSource 创建分块映射数据源
+// windowSize 必须是系统页大小（Windows 上为 64KB 分配粒度）的整数倍
+func newChunkedMmapSource(file *os.File, size, windowSize int64, maxWindows int) (*chunkedMmapSource, error) {
+	src := &chunkedMmapSource{
+		file:       file,
+		size:       size,
+		windowSize: windowSize,
+		maxWindows: maxWindows,
+		windows:    make(map[int64]mmap.MMap),
+	}
+
+	// 预先映射第一个窗口，尽早发现不支持 mmap 的情况
+	src.mu.Lock()
+	_, err := src.window(0)
+	src.mu.Unlock()
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	return src, nil
+}
+
+// window 获取（必要时映射）指定编号的窗口，调用者必须持有 mu
+func (c *chunkedMmapSource) window(idx int64) (mmap.MMap, error) {
+	if w, ok := c.windows[idx]; ok {
+		if pos := slices.Index(c.lru, idx); pos >= 0 {
+			c.lru = append(c.lru[:pos], c.lru[pos+1:]...)
+		}
+		c.lru = append(c.lru, idx)
+		return w, nil
+	}
+
+	offset := idx * c.windowSize
+	length := min(c.windowSize, c.size-offset)
+	w, err := mmap.MapRegion(c.file, int(length), mmap.RDONLY, 0, offset)
+	if err != nil {
+		return nil, fmt.Errorf("map window %d: %w", idx, err)
+	}
+
+	// 淘汰最久未使用的窗口
+	for len(c.lru) >= c.maxWindows {
+		oldest := c.lru[0]
+		c.lru = c.lru[1:]
+		old := c.windows[oldest]
+		old.Unmap()
+		delete(c.windows, oldest)
+	}
+
+	if c.pattern != accessNormal {
+		madvise(w, c.pattern)
+	}
+
+	c.windows[idx] = w
+	c.lru = append(c.lru, idx)
+	return w, nil
+}
+
+func (c *chunkedMmapSource) Slice(off int64, n int) ([]byte, error) {
+	if off < 0 || n < 0 || off+int64(n) > c.size {
+		return nil, fmt.Errorf("read out of range: offset=%d, size=%d, len=%d", off, n, c.size)
+	}
+
+	buf := make([]byte, n)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 逐窗口复制（读取可能跨越窗口边界）
+	copied := 0
+	for copied < n {
+		pos := off + int64(copied)
+		idx := pos / c.windowSize
+		w, err := c.window(idx)
+		if err != nil {
+			return nil, err
+		}
+		copied += copy(buf[copied:], w[pos-idx*c.windowSize:])
+	}
+	return buf, nil
+}
+
+func (c *chunkedMmapSource) Size() int64 {
+	return c.size
+}
+
+func (c *chunkedMmapSource) advise(pattern accessPattern) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, w := range c.windows {
+		madvise(w, pattern)
+	}
+	if pattern == accessDontNeed {
+		c.pattern = accessNormal
+	} else {
+		c.pattern = pattern
+	}
+}
+
+func (c *chunkedMmapSource) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for idx, w := range c.windows {
+		w.Unmap()
+		delete(c.windows, idx)
+	}
+	c.lru = nil
+	return nil
+}
+
+// openDataSource 按指定方式打开数据源
+//
+//   - IOModeMmap: 整个文件映射；32 位平台上的大文件改用分块映射
+//   - IOModeChunkedMmap: 分块映射
+//   - IOModePread: pread
+//
+// 映射失败时自动回退到 pread，返回实际使用的 IOMode
+func openDataSource(file *os.File, mode IOMode) (dataSource, IOMode, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, mode, err
+	}
+	size := info.Size()
+
+	if mode == IOModeMmap && is32Bit && size > largeFileMmapThreshold {
+		mode = IOModeChunkedMmap
+	}
+
+	if size > 0 {
+		switch mode {
+		case IOModeMmap:
+			data, err := mmap.Map(file, mmap.RDONLY, 0)
+			if err == nil {
+				return &mmapSource{bytesSource: bytesSource(data), data: data}, IOModeMmap, nil
+			}
+		case IOModeChunkedMmap:
+			src, err := newChunkedMmapSource(file, size, chunkedMmapWindowSize, chunkedMmapMaxWindows)
+			if err == nil {
+				return src, IOModeChunkedMmap, nil
+			}
+		}
+		// 映射失败（如部分网络文件系统），回退到 pread
+	}
+
+	return &preadSource{file: file, size: size}, IOModePread, nil
+}
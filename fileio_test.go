@@ -0,0 +1,421 @@
+package srdb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDataSourceModes(t *testing.T) {
+	path := "test_datasource.bin"
+	defer os.Remove(path)
+
+	content := []byte("0123456789abcdef")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []IOMode{IOModeMmap, IOModePread} {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		src, actual, err := openDataSource(file, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != mode {
+			t.Errorf("Expected mode %v, got %v", mode, actual)
+		}
+		if src.Size() != int64(len(content)) {
+			t.Errorf("%v: expected size %d, got %d", mode, len(content), src.Size())
+		}
+
+		data, err := src.Slice(4, 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "456789" {
+			t.Errorf("%v: expected 456789, got %q", mode, data)
+		}
+
+		if _, err := src.Slice(10, 10); err == nil {
+			t.Errorf("%v: expected out of range error", mode)
+		}
+
+		src.Close()
+		file.Close()
+	}
+}
+
+func TestDataSourceEmptyFileFallback(t *testing.T) {
+	path := "test_datasource_empty.bin"
+	defer os.Remove(path)
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// 空文件无法 mmap，应回退到 pread
+	src, actual, err := openDataSource(file, IOModeMmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	if actual != IOModePread {
+		t.Errorf("Expected fallback to pread, got %v", actual)
+	}
+}
+
+func TestTablePreadIOMode(t *testing.T) {
+	dir := "./test_pread_iomode"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	fields := []Field{
+		{Name: "name", Type: String, Indexed: true},
+		{Name: "age", Type: Int64},
+	}
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 1024 * 1024,
+		Name:         "users",
+		Fields:       fields,
+		IOMode:       IOModePread,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 100 {
+		err := table.Insert(map[string]any{"name": fmt.Sprintf("user%d", i%10), "age": int64(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	table.Close()
+
+	// 重新打开，数据全部来自 SST 与索引文件
+	table, err = OpenTable(&TableOptions{Dir: dir, MemTableSize: 1024 * 1024, IOMode: IOModePread})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for _, reader := range table.sstManager.GetReaders() {
+		if reader.GetIOMode() != IOModePread {
+			t.Errorf("Expected pread reader, got %v", reader.GetIOMode())
+		}
+	}
+
+	row, err := table.Get(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Data["age"] != int64(41) {
+		t.Errorf("Expected age 41, got %v", row.Data["age"])
+	}
+
+	rows, err := table.Query().Eq("name", "user3").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if n := rows.Count(); n != 10 {
+		t.Errorf("Expected 10 rows via index, got %d", n)
+	}
+}
+
+func TestChunkedMmapSource(t *testing.T) {
+	path := "test_chunked_mmap.bin"
+	defer os.Remove(path)
+
+	// 5 个 64KB 窗口 + 尾部不足一个窗口的数据
+	const windowSize = 64 * 1024
+	content := make([]byte, windowSize*5+123)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	src, err := newChunkedMmapSource(file, int64(len(content)), windowSize, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	check := func(off int64, n int) {
+		data, err := src.Slice(off, n)
+		if err != nil {
+			t.Fatalf("Slice(%d, %d): %v", off, n, err)
+		}
+		for i, b := range data {
+			if b != content[off+int64(i)] {
+				t.Fatalf("Slice(%d, %d): mismatch at %d", off, n, i)
+			}
+		}
+	}
+
+	check(0, 100)
+	check(windowSize-10, 20)               // 跨越窗口边界
+	check(windowSize*4, windowSize+123)    // 读到文件末尾
+	check(windowSize*2-5, windowSize*2+10) // 跨越多个窗口
+	check(10, 10)                          // 被淘汰后重新映射
+
+	if len(src.windows) > 2 {
+		t.Errorf("Expected at most 2 mapped windows, got %d", len(src.windows))
+	}
+	if _, err := src.Slice(int64(len(content))-1, 2); err == nil {
+		t.Error("Expected out of range error")
+	}
+}
+
+func TestTableChunkedMmapIOMode(t *testing.T) {
+	dir := "./test_chunked_iomode"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 1024 * 1024,
+		Name:         "metrics",
+		Fields:       []Field{{Name: "value", Type: Int64}},
+		IOMode:       IOModeChunkedMmap,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 50 {
+		if err := table.Insert(map[string]any{"value": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	readers := table.sstManager.GetReaders()
+	if len(readers) == 0 {
+		t.Fatal("Expected SST readers")
+	}
+	if readers[0].GetIOMode() != IOModeChunkedMmap {
+		t.Errorf("Expected chunked mmap reader, got %v", readers[0].GetIOMode())
+	}
+
+	row, err := table.Get(25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Data["value"] != int64(24) {
+		t.Errorf("Expected value 24, got %v", row.Data["value"])
+	}
+}
+
+func TestPreadSourceReadahead(t *testing.T) {
+	path := "test_pread_readahead.bin"
+	defer os.Remove(path)
+
+	content := make([]byte, scanReadaheadSize*2+100)
+	for i := range content {
+		content[i] = byte(i % 253)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	src, _, err := openDataSource(file, IOModePread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	p := src.(*preadSource)
+
+	p.advise(accessSequential)
+	for off := 0; off < len(content); off += 1000 {
+		n := min(1000, len(content)-off)
+		data, err := p.Slice(int64(off), n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != string(content[off:off+n]) {
+			t.Fatalf("Mismatch at offset %d", off)
+		}
+	}
+	if len(p.buf) == 0 {
+		t.Error("Expected readahead buffer during sequential scan")
+	}
+
+	p.advise(accessNormal)
+	if p.buf != nil || p.readahead {
+		t.Error("Expected readahead buffer to be released")
+	}
+}
+
+func TestScanReleasesReadahead(t *testing.T) {
+	dir := "./test_scan_readahead"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 1024 * 1024,
+		Name:         "events",
+		Fields:       []Field{{Name: "value", Type: Int64}},
+		IOMode:       IOModePread,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"value": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rows, err := table.Query().BypassCache().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	readers := table.sstManager.GetReaders()
+	for _, reader := range readers {
+		if reader.scans.Load() != 1 {
+			t.Errorf("Expected active scan on reader, got %d", reader.scans.Load())
+		}
+	}
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if count != 100 {
+		t.Errorf("Expected 100 rows, got %d", count)
+	}
+
+	// 扫描结束后恢复随机访问模式
+	for _, reader := range readers {
+		if reader.scans.Load() != 0 {
+			t.Errorf("Expected scan to be released, got %d", reader.scans.Load())
+		}
+		if p, ok := reader.src.(*preadSource); ok && p.readahead {
+			t.Error("Expected readahead to be disabled after scan")
+		}
+	}
+
+	// 重复 Close 不应重复释放
+	rows.Close()
+	rows.Close()
+	for _, reader := range readers {
+		if reader.scans.Load() != 0 {
+			t.Errorf("Expected scan count 0 after Close, got %d", reader.scans.Load())
+		}
+	}
+}
+
+func TestOverlappingBypassScan(t *testing.T) {
+	dir := t.TempDir()
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 1024 * 1024,
+		Name:         "events",
+		Fields:       []Field{{Name: "value", Type: Int64}},
+		IOMode:       IOModePread,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"value": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 普通扫描进行中时，BypassCache 扫描结束只记录释放请求
+	normal, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bypass, err := table.Query().BypassCache().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for bypass.Next() {
+	}
+	bypass.Close()
+
+	readers := table.sstManager.GetReaders()
+	if len(readers) == 0 {
+		t.Fatal("expected SST readers")
+	}
+	for _, reader := range readers {
+		reader.scanMu.Lock()
+		pending := reader.bypass
+		reader.scanMu.Unlock()
+		if !pending {
+			t.Error("expected bypass to be deferred while another scan is active")
+		}
+		if p, ok := reader.src.(*preadSource); ok && !p.readahead {
+			t.Error("expected readahead to stay enabled for the active scan")
+		}
+	}
+
+	// 最后一个扫描结束时释放
+	for normal.Next() {
+	}
+	normal.Close()
+	for _, reader := range readers {
+		reader.scanMu.Lock()
+		pending := reader.bypass
+		reader.scanMu.Unlock()
+		if pending {
+			t.Error("expected bypass to be released when the last scan ends")
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package srdb
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Filter 可复用的查询条件
+//
+// Filter 是不可变的值：每个方法返回新的 Filter，原 Filter 不变，因此可以构建一次后
+// 在 HTTP 层、定时任务与数据处理之间共享，也可以相互组合：
+//
+//	active := srdb.NewFilter().Eq("status", "active")
+//	adults := active.Gte("age", 18)
+//	rows, err := table.Query().WithFilter(adults).Rows()
+//
+// 多个条件之间是 AND 关系；Filter 实现了 Expr，也可以传给 Where、And、Or、Not。
+// Filter 可以序列化为 JSON 保存（比较值只能是 nil、布尔、数值、字符串及其列表）。
+type Filter struct {
+	exprs []Expr // 必须同时满足的条件
+}
+
+// NewFilter 创建由 exprs 组成的 Filter（没有条件时匹配所有行）
+func NewFilter(exprs ...Expr) Filter {
+	return Filter{exprs: slices.Clone(exprs)}
+}
+
+// with 返回追加了 expr 的新 Filter（不与原 Filter 共享底层数组）
+func (f Filter) with(expr Expr) Filter {
+	exprs := make([]Expr, 0, len(f.exprs)+1)
+	exprs = append(exprs, f.exprs...)
+	return Filter{exprs: append(exprs, expr)}
+}
+
+func (f Filter) Eq(field string, value any) Filter {
+	return f.with(Eq(field, value))
+}
+
+func (f Filter) NotEq(field string, value any) Filter {
+	return f.with(NotEq(field, value))
+}
+
+func (f Filter) Lt(field string, value any) Filter {
+	return f.with(Lt(field, value))
+}
+
+func (f Filter) Gt(field string, value any) Filter {
+	return f.with(Gt(field, value))
+}
+
+func (f Filter) Lte(field string, value any) Filter {
+	return f.with(Lte(field, value))
+}
+
+func (f Filter) Gte(field string, value any) Filter {
+	return f.with(Gte(field, value))
+}
+
+func (f Filter) In(field string, values []any) Filter {
+	return f.with(In(field, values))
+}
+
+func (f Filter) NotIn(field string, values []any) Filter {
+	return f.with(NotIn(field, values))
+}
+
+func (f Filter) Between(field string, min, max any) Filter {
+	return f.with(Between(field, min, max))
+}
+
+func (f Filter) Contains(field string, pattern string) Filter {
+	return f.with(Contains(field, pattern))
+}
+
+func (f Filter) StartsWith(field string, prefix string) Filter {
+	return f.with(StartsWith(field, prefix))
+}
+
+func (f Filter) EndsWith(field string, suffix string) Filter {
+	return f.with(EndsWith(field, suffix))
+}
+
+func (f Filter) IsNull(field string) Filter {
+	return f.with(IsNull(field))
+}
+
+func (f Filter) NotNull(field string) Filter {
+	return f.with(NotNull(field))
+}
+
+// Where 返回追加了任意条件的新 Filter
+func (f Filter) Where(exprs ...Expr) Filter {
+	return NewFilter(append(slices.Clone(f.exprs), exprs...)...)
+}
+
+// And 返回同时满足 f 与 others 的 Filter
+func (f Filter) And(others ...Filter) Filter {
+	exprs := slices.Clone(f.exprs)
+	for _, other := range others {
+		exprs = append(exprs, other.exprs...)
+	}
+	return Filter{exprs: exprs}
+}
+
+// Or 返回满足 f 或 others 任意一个的 Filter
+func (f Filter) Or(others ...Filter) Filter {
+	exprs := []Expr{f.Expr()}
+	for _, other := range others {
+		exprs = append(exprs, other.Expr())
+	}
+	return Filter{exprs: []Expr{Or(exprs...)}}
+}
+
+// Not 返回 f 取反的 Filter
+func (f Filter) Not() Filter {
+	return Filter{exprs: []Expr{Not(f.Expr())}}
+}
+
+// Expr 返回 Filter 对应的条件表达式
+func (f Filter) Expr() Expr {
+	if len(f.exprs) == 1 {
+		return f.exprs[0]
+	}
+	return And(f.exprs...)
+}
+
+// IsEmpty 是否没有任何条件
+func (f Filter) IsEmpty() bool {
+	return len(f.exprs) == 0
+}
+
+// Match 实现 Expr 接口
+func (f Filter) Match(fs Fieldset) bool {
+	for _, expr := range f.exprs {
+		if !expr.Match(fs) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchRow 检查一行数据是否满足条件（用于在查询之外复用，如处理 flush 事件或 HTTP 请求）
+// schema 为 nil 时按值的实际类型比较
+func (f Filter) MatchRow(data map[string]any, schema *Schema) bool {
+	if schema == nil {
+		schema = &Schema{}
+	}
+	return f.Match(newMapFieldset(data, schema))
+}
+
+// MarshalJSON 将 Filter 编码为 JSON（格式与部分索引的条件文件相同）
+func (f Filter) MarshalJSON() ([]byte, error) {
+	encoded, err := encodeExpr(group{f.exprs, true})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON 从 JSON 还原 Filter
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	var j exprJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	expr, err := decodeExpr(j)
+	if err != nil {
+		return NewErrorf(ErrCodeInvalidParam, "invalid filter: %v", err)
+	}
+	*f = Filter{exprs: conjuncts([]Expr{expr})}
+	return nil
+}
+
+// WithFilter 添加 Filter 中的所有条件（与已有条件是 AND 关系）
+// 条件按 AND 展开后加入，与直接调用 Eq 等方法一样可以使用索引
+func (qb *QueryBuilder) WithFilter(filters ...Filter) *QueryBuilder {
+	for _, f := range filters {
+		qb.conds = append(qb.conds, conjuncts(f.exprs)...)
+	}
+	return qb
+}
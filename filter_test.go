@@ -0,0 +1,95 @@
+package srdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "users",
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "status", Type: String, Indexed: true},
+			{Name: "age", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 100 {
+		status := "active"
+		if i%4 == 0 {
+			status = "banned"
+		}
+		if err := table.Insert(map[string]any{"name": fmt.Sprintf("user%d", i), "status": status, "age": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := func(qb *QueryBuilder) int {
+		t.Helper()
+		rows, err := qb.Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		return rows.Len()
+	}
+
+	active := NewFilter().Eq("status", "active")
+	adults := active.Gte("age", 18)
+	young := NewFilter().Lt("age", 10)
+
+	// 派生的 Filter 不影响原 Filter
+	if got := count(table.Query().WithFilter(active)); got != 75 {
+		t.Errorf("active: expected 75, got %d", got)
+	}
+	if got := count(table.Query().WithFilter(adults)); got != 62 {
+		t.Errorf("adults: expected 62, got %d", got)
+	}
+	if got := count(table.Query().WithFilter(young.Or(NewFilter().Gte("age", 95)))); got != 15 {
+		t.Errorf("or: expected 15, got %d", got)
+	}
+	if got := count(table.Query().WithFilter(active.And(young.Not()))); got != 68 {
+		t.Errorf("and not: expected 68, got %d", got)
+	}
+	// 与其他条件组合，Filter 也可以作为 Expr 使用
+	if got := count(table.Query().Lt("age", 50).Where(adults)); got != 24 {
+		t.Errorf("where: expected 24, got %d", got)
+	}
+
+	// JSON 序列化后结果相同
+	stored := active.And(young.Or(NewFilter().Between("age", 90, 99)))
+	data, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Filter
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	want := count(table.Query().WithFilter(stored))
+	if got := count(table.Query().WithFilter(restored)); got != want || want != 15 {
+		t.Errorf("restored filter: expected %d (15), got %d (json %s)", want, got, data)
+	}
+
+	// 在查询之外匹配单行
+	if !adults.MatchRow(map[string]any{"status": "active", "age": int64(30)}, table.GetSchema()) {
+		t.Error("expected row to match")
+	}
+	if adults.MatchRow(map[string]any{"status": "banned", "age": 30}, nil) {
+		t.Error("expected row not to match")
+	}
+	if !NewFilter().MatchRow(map[string]any{}, nil) {
+		t.Error("empty filter should match everything")
+	}
+
+	if _, err := json.Marshal(NewFilter().Eq("payload", struct{}{})); err == nil {
+		t.Error("expected unsupported value to fail")
+	}
+}
@@ -0,0 +1,375 @@
+package srdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// FormatDescriptionVersion 格式描述文档自身的版本号
+// 描述结构发生不兼容变化时递增
+const FormatDescriptionVersion = 1
+
+// FormatField 二进制结构中的一个字段
+type FormatField struct {
+	Name        string `json:"name"`
+	Offset      int    `json:"offset"` // 相对结构起始位置的偏移，-1 表示紧随上一字段
+	Size        int    `json:"size"`   // 字节数，-1 表示变长
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// FormatStruct 一个二进制结构的布局描述
+type FormatStruct struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Size        int           `json:"size"` // 固定大小，-1 表示变长
+	Fields      []FormatField `json:"fields"`
+}
+
+// FormatEnum 枚举值描述
+type FormatEnum struct {
+	Name   string         `json:"name"`
+	Values map[string]int `json:"values"`
+}
+
+// FormatFileKind 一类磁盘文件的描述
+type FormatFileKind struct {
+	Kind        string   `json:"kind"`
+	Path        string   `json:"path"` // 相对表目录的路径模式
+	Magic       string   `json:"magic,omitempty"`
+	Version     int      `json:"version,omitempty"`
+	Encoding    string   `json:"encoding"`
+	Structures  []string `json:"structures,omitempty"` // 引用 FormatDescription.Structures 中的名称
+	Description string   `json:"description,omitempty"`
+}
+
+// FormatInUse 当前表实际使用的文件与版本
+type FormatInUse struct {
+	SchemaFileVersion int              `json:"schema_file_version"`
+	ManifestNumber    int64            `json:"manifest_number"`
+	CurrentWALNumber  int64            `json:"current_wal_number"`
+	SSTableVersions   []uint32         `json:"sstable_versions"` // 已打开 SST 文件头中出现的格式版本
+	SSTableFiles      int              `json:"sstable_files"`
+	IndexVersions     map[string]int64 `json:"index_versions"` // 字段名 -> IndexMetadata.Version
+}
+
+// FormatDescription 磁盘格式的机器可读描述
+type FormatDescription struct {
+	DescriptionVersion int               `json:"description_version"`
+	ByteOrder          string            `json:"byte_order"`
+	Files              []FormatFileKind  `json:"files"`
+	Structures         []FormatStruct    `json:"structures"`
+	FieldEncodings     map[string]string `json:"field_encodings"` // FieldType 名称 -> 值编码
+	Enums              []FormatEnum      `json:"enums"`
+	InUse              *FormatInUse      `json:"in_use,omitempty"`
+}
+
+// DescribeFormat 返回当前代码支持的磁盘格式描述（不含表相关信息）
+func DescribeFormat() *FormatDescription {
+	return &FormatDescription{
+		DescriptionVersion: FormatDescriptionVersion,
+		ByteOrder:          "little-endian",
+		Files: []FormatFileKind{
+			{
+				Kind:        "schema",
+				Path:        "schema.json",
+				Version:     1,
+				Encoding:    "json",
+				Description: "SchemaFile{version, timestamp, checksum, structure_checksum, schema}; checksum 为 SHA256",
+			},
+			{
+				Kind:        "wal_current",
+				Path:        "wal/CURRENT",
+				Encoding:    "text",
+				Description: "当前 WAL 文件编号（十进制）",
+			},
+			{
+				Kind:        "wal",
+				Path:        "wal/%06d.wal",
+				Encoding:    "binary",
+				Structures:  []string{"wal_entry"},
+				Description: "WAL 记录顺序追加，CRC 不匹配或截断视为文件结尾",
+			},
+			{
+				Kind:        "manifest_current",
+				Path:        "CURRENT",
+				Encoding:    "text",
+				Description: "当前 MANIFEST 文件名，以换行结尾",
+			},
+			{
+				Kind:        "manifest",
+				Path:        "MANIFEST-%06d",
+				Encoding:    "binary",
+				Structures:  []string{"manifest_record"},
+				Description: "VersionEdit 记录顺序追加，记录体为 JSON；超过大小阈值时重写为一条当前版本的快照记录",
+			},
+			{
+				Kind:        "sstable",
+				Path:        "sst/%06d.sst",
+				Magic:       fmt.Sprintf("0x%08X", SSTableMagicNumber),
+				Version:     SSTableVersion,
+				Encoding:    "binary",
+				Structures:  []string{"sstable_header", "btree_node_header", "btree_leaf_entry", "sstable_row", "sstable_row_packed", "sstable_zone_map"},
+				Description: "[Header 256B][B+Tree 节点 4KB 对齐][行数据][Zone Map]，叶子条目指向行数据",
+			},
+			{
+				Kind:        "index",
+				Path:        "idx/idx_<field>.sst",
+				Magic:       fmt.Sprintf("0x%08X", IndexMagic),
+				Version:     IndexVersion,
+				Encoding:    "binary",
+				Structures:  []string{"index_header", "btree_node_header", "btree_leaf_entry", "index_entry", "index_postings", "index_entry_v1"},
+				Description: fmt.Sprintf("B+Tree key 为 MD5(value)[0:8] 的 int64，叶子条目指向 index_entry；format_version 为 %d 的旧文件叶子条目指向 index_entry_v1", indexVersionSeqList),
+			},
+		},
+		Structures: []FormatStruct{
+			{
+				Name: "wal_entry",
+				Size: -1,
+				Fields: []FormatField{
+					{Name: "crc32", Offset: 0, Size: 4, Type: "uint32", Description: "length、type、seq 与 data（写入磁盘的字节，压缩时为压缩后的数据）的 CRC32 (IEEE)，即记录中 crc32 之后的全部字节"},
+					{Name: "length", Offset: 4, Size: 4, Type: "uint32", Description: "Data 长度"},
+					{Name: "type", Offset: 8, Size: 1, Type: "uint8", Description: "见 enums.wal_entry_type；最高位（0x80）表示 Data 经过 Snappy 块格式压缩"},
+					{Name: "seq", Offset: 9, Size: 8, Type: "int64"},
+					{Name: "data", Offset: WALEntryHeaderSize, Size: -1, Type: "bytes", Description: "sstable_row 或 sstable_row_packed 编码（可能经过压缩）"},
+				},
+			},
+			{
+				Name: "manifest_record",
+				Size: -1,
+				Fields: []FormatField{
+					{Name: "crc32", Offset: 0, Size: 4, Type: "uint32", Description: "data 的 CRC32 (IEEE)，不包括 length"},
+					{Name: "length", Offset: 4, Size: 4, Type: "uint32", Description: "data 长度"},
+					{Name: "data", Offset: 8, Size: -1, Type: "json", Description: "VersionEdit{AddedFiles, DeletedFiles, NextFileNumber, LastSequence}"},
+				},
+			},
+			{
+				Name: "sstable_header",
+				Size: SSTableHeaderSize,
+				Fields: []FormatField{
+					{Name: "magic", Offset: 0, Size: 4, Type: "uint32"},
+					{Name: "version", Offset: 4, Size: 4, Type: "uint32"},
+					{Name: "compression", Offset: 8, Size: 1, Type: "uint8"},
+					{Name: "flags", Offset: 12, Size: 4, Type: "uint32"},
+					{Name: "index_offset", Offset: 32, Size: 8, Type: "int64"},
+					{Name: "index_size", Offset: 40, Size: 8, Type: "int64"},
+					{Name: "root_offset", Offset: 48, Size: 8, Type: "int64", Description: "B+Tree 根节点偏移"},
+					{Name: "data_offset", Offset: 64, Size: 8, Type: "int64"},
+					{Name: "data_size", Offset: 72, Size: 8, Type: "int64"},
+					{Name: "row_count", Offset: 80, Size: 8, Type: "int64"},
+					{Name: "min_key", Offset: 96, Size: 8, Type: "int64"},
+					{Name: "max_key", Offset: 104, Size: 8, Type: "int64"},
+					{Name: "min_time", Offset: 112, Size: 8, Type: "int64"},
+					{Name: "max_time", Offset: 120, Size: 8, Type: "int64"},
+					{Name: "crc32", Offset: 128, Size: 4, Type: "uint32"},
+					{Name: "zone_map_offset", Offset: 136, Size: 8, Type: "int64", Description: "列统计信息（zone map）位置，位于数据块之后，0 表示没有"},
+					{Name: "zone_map_size", Offset: 144, Size: 8, Type: "int64"},
+				},
+			},
+			{
+				Name: "sstable_zone_map",
+				Size: -1,
+				Description: "每列非 NULL 值的 min/max，用于扫描时跳过不可能匹配的文件；" +
+					fmt.Sprintf("只统计数值列和字符串列，字符串超过 %d 字节时放弃该列", zoneMapMaxString),
+				Fields: []FormatField{
+					{Name: "magic", Offset: 0, Size: 4, Type: "uint32", Description: fmt.Sprintf("0x%08X", zoneMapMagic)},
+					{Name: "count", Offset: 4, Size: 2, Type: "uint16"},
+					{Name: "entries", Offset: 6, Size: -1, Type: "[count]{name_len uint16, name, kind uint8, min, max}", Description: "kind 1：min/max 为 float64；kind 2：min/max 为 {len uint16, bytes}"},
+				},
+			},
+			{
+				Name: "sstable_row",
+				Size: -1,
+				Fields: []FormatField{
+					{Name: "magic", Offset: 0, Size: 4, Type: "uint32", Description: fmt.Sprintf("0x%08X", SSTableRowMagic)},
+					{Name: "seq", Offset: 4, Size: 8, Type: "int64"},
+					{Name: "time", Offset: 12, Size: 8, Type: "int64", Description: "UnixNano"},
+					{Name: "field_count", Offset: 20, Size: 2, Type: "uint16", Description: "等于 Schema 字段数，按 Schema 字段顺序"},
+					{Name: "field_table", Offset: 22, Size: -1, Type: "[field_count]{offset uint32, size uint32}", Description: "offset 相对数据区起始位置，size 为 0 表示 NULL"},
+					{Name: "field_data", Offset: -1, Size: -1, Type: "bytes", Description: "各字段值，编码见 field_encodings"},
+					{Name: "checksum", Offset: -1, Size: 32, Type: "[32]byte", Description: "可选（RowChecksum）：之前全部内容的 SHA-256"},
+					{Name: "checksum_magic", Offset: -1, Size: 4, Type: "uint32", Description: fmt.Sprintf("0x%08X，存在 checksum 时紧随其后", rowChecksumMagic)},
+				},
+			},
+			{
+				Name:        "sstable_row_packed",
+				Size:        -1,
+				Description: "Schema 含有 Bool 字段时使用：Bool 字段不占字段表项，按 Schema 顺序每个占 2 位（低位非 NULL，高位为值）",
+				Fields: []FormatField{
+					{Name: "magic", Offset: 0, Size: 4, Type: "uint32", Description: fmt.Sprintf("0x%08X", SSTableRowPackedMagic)},
+					{Name: "seq", Offset: 4, Size: 8, Type: "int64"},
+					{Name: "time", Offset: 12, Size: 8, Type: "int64", Description: "UnixNano"},
+					{Name: "field_count", Offset: 20, Size: 2, Type: "uint16", Description: "等于 Schema 字段数，按 Schema 字段顺序"},
+					{Name: "packed_count", Offset: 22, Size: 2, Type: "uint16", Description: "打包的 Bool 字段数"},
+					{Name: "field_table", Offset: 24, Size: -1, Type: "[field_count-packed_count]{offset uint32, size uint32}", Description: "非 Bool 字段，offset 相对数据区起始位置，size 为 0 表示 NULL"},
+					{Name: "bits", Offset: -1, Size: -1, Type: "[ceil(packed_count*2/8)]byte", Description: "第 k 个 Bool 字段占第 2k、2k+1 位"},
+					{Name: "field_data", Offset: -1, Size: -1, Type: "bytes", Description: "非 Bool 字段的值，编码见 field_encodings"},
+					{Name: "checksum", Offset: -1, Size: 32, Type: "[32]byte", Description: "可选（RowChecksum）：之前全部内容的 SHA-256"},
+					{Name: "checksum_magic", Offset: -1, Size: 4, Type: "uint32", Description: fmt.Sprintf("0x%08X，存在 checksum 时紧随其后", rowChecksumMagic)},
+				},
+			},
+			{
+				Name:        "sstable_row_tombstone",
+				Size:        rowDecodeHeaderSize,
+				Description: "Table.Delete 写入的删除标记：没有字段与校验和，_time 大于被删除的版本",
+				Fields: []FormatField{
+					{Name: "magic", Offset: 0, Size: 4, Type: "uint32", Description: fmt.Sprintf("0x%08X", SSTableRowTombstoneMagic)},
+					{Name: "seq", Offset: 4, Size: 8, Type: "int64"},
+					{Name: "time", Offset: 12, Size: 8, Type: "int64", Description: "UnixNano"},
+					{Name: "field_count", Offset: 20, Size: 2, Type: "uint16", Description: "总是 0"},
+				},
+			},
+			{
+				Name: "btree_node_header",
+				Size: BTreeHeaderSize,
+				Description: fmt.Sprintf("节点固定 %d 字节，header 后为 keys[key_count] int64，"+
+					"内部节点接 children[key_count+1] int64，叶子节点接 btree_leaf_entry[key_count]", BTreeNodeSize),
+				Fields: []FormatField{
+					{Name: "node_type", Offset: 0, Size: 1, Type: "uint8", Description: "见 enums.btree_node_type"},
+					{Name: "key_count", Offset: 1, Size: 2, Type: "uint16"},
+					{Name: "level", Offset: 3, Size: 1, Type: "uint8"},
+				},
+			},
+			{
+				Name: "btree_leaf_entry",
+				Size: 12,
+				Fields: []FormatField{
+					{Name: "data_offset", Offset: 0, Size: 8, Type: "int64"},
+					{Name: "data_size", Offset: 8, Size: 4, Type: "int32"},
+				},
+			},
+			{
+				Name: "index_header",
+				Size: IndexHeaderSize,
+				Fields: []FormatField{
+					{Name: "magic", Offset: 0, Size: 4, Type: "uint32"},
+					{Name: "format_version", Offset: 4, Size: 4, Type: "uint32"},
+					{Name: "index_version", Offset: 8, Size: 8, Type: "int64"},
+					{Name: "root_offset", Offset: 16, Size: 8, Type: "int64"},
+					{Name: "data_start", Offset: 24, Size: 8, Type: "int64"},
+					{Name: "min_seq", Offset: 32, Size: 8, Type: "int64"},
+					{Name: "max_seq", Offset: 40, Size: 8, Type: "int64"},
+					{Name: "row_count", Offset: 48, Size: 8, Type: "int64"},
+					{Name: "created_at", Offset: 56, Size: 8, Type: "int64"},
+					{Name: "updated_at", Offset: 64, Size: 8, Type: "int64"},
+					{Name: "include", Offset: 72, Size: 184, Type: "bytes", Description: "覆盖索引字段列表：count(1) + count × [type(1)][name_len(1)][name]，未配置时全为 0"},
+				},
+			},
+			{
+				Name: "index_entry",
+				Size: -1,
+				Fields: []FormatField{
+					{Name: "value_len", Offset: 0, Size: 4, Type: "uint32"},
+					{Name: "value", Offset: 4, Size: -1, Type: "string", Description: "fmt %v 格式的字段值，用于校验哈希冲突"},
+					{Name: "postings_len", Offset: -1, Size: 4, Type: "uint32", Description: "postings 的字节数"},
+					{Name: "postings", Offset: -1, Size: -1, Type: "bytes", Description: "seq 集合，编码见 index_postings"},
+					{Name: "covered", Offset: -1, Size: -1, Type: "bytes", Description: "仅覆盖索引：按 seq 升序依次存放各 include 字段的 [size(4)][value]，编码同 field_table，size 为 0 表示 NULL"},
+				},
+			},
+			{
+				Name:        "index_postings",
+				Description: "Roaring 位图：seq 按高 48 位分组为容器，容器只存低 16 位；基数 ≤ 4096 时 data 为 cardinality × uint16 的升序低 16 位，否则为 1024 × uint64 的位图（8192 字节，第 i 位对应低 16 位 i）",
+				Size:        -1,
+				Fields: []FormatField{
+					{Name: "container_count", Offset: 0, Size: 4, Type: "uint32"},
+					{Name: "containers", Offset: 4, Size: -1, Type: "[container_count]{key uint64, cardinality_minus_1 uint16}", Description: "key 为 seq 的高 48 位，严格升序"},
+					{Name: "data", Offset: -1, Size: -1, Type: "bytes", Description: "按 containers 的顺序依次存放各容器的数据"},
+				},
+			},
+			{
+				Name:        "index_entry_v1",
+				Description: fmt.Sprintf("format_version 为 %d 的旧索引文件的条目，仍可读取，重建索引时写为 index_entry", indexVersionSeqList),
+				Size:        -1,
+				Fields: []FormatField{
+					{Name: "value_len", Offset: 0, Size: 4, Type: "uint32"},
+					{Name: "value", Offset: 4, Size: -1, Type: "string", Description: "fmt %v 格式的字段值，用于校验哈希冲突"},
+					{Name: "seq_count", Offset: -1, Size: 4, Type: "uint32"},
+					{Name: "seqs", Offset: -1, Size: -1, Type: "[seq_count]int64", Description: "升序"},
+					{Name: "covered", Offset: -1, Size: -1, Type: "bytes", Description: "同 index_entry"},
+				},
+			},
+		},
+		FieldEncodings: map[string]string{
+			Int.String():      "int64",
+			Int8.String():     "int8",
+			Int16.String():    "int16",
+			Int32.String():    "int32",
+			Int64.String():    "int64",
+			Uint.String():     "uint64",
+			Uint8.String():    "uint8",
+			Uint16.String():   "uint16",
+			Uint32.String():   "uint32",
+			Uint64.String():   "uint64",
+			Float32.String():  "float32 (IEEE 754)",
+			Float64.String():  "float64 (IEEE 754)",
+			String.String():   "uint32 length + utf8 bytes",
+			Bool.String():     "uint8 (0/1)",
+			Byte.String():     "uint8",
+			Rune.String():     "int32",
+			Decimal.String():  "uint32 length + decimal.MarshalBinary bytes",
+			Time.String():     "int64 unix seconds",
+			Duration.String(): "int64 nanoseconds",
+			Object.String():   "uint32 length + json bytes",
+			Array.String():    "uint32 length + json bytes",
+		},
+		Enums: []FormatEnum{
+			{Name: "wal_entry_type", Values: map[string]int{
+				"put":    WALEntryTypePut,
+				"delete": WALEntryTypeDelete,
+			}},
+			{Name: "btree_node_type", Values: map[string]int{
+				"internal": BTreeNodeTypeInternal,
+				"leaf":     BTreeNodeTypeLeaf,
+			}},
+			{Name: "edit_type", Values: map[string]int{
+				"add_file":      int(EditTypeAddFile),
+				"delete_file":   int(EditTypeDeleteFile),
+				"set_next_file": int(EditTypeSetNextFile),
+				"set_last_seq":  int(EditTypeSetLastSeq),
+			}},
+		},
+	}
+}
+
+// DumpFormat 将当前磁盘布局的机器可读描述（JSON）写入 w
+// 包含 WAL 记录格式、SST 布局、MANIFEST 记录类型以及本表实际使用的版本
+func (t *Table) DumpFormat(w io.Writer) error {
+	desc := DescribeFormat()
+
+	inUse := &FormatInUse{
+		SchemaFileVersion: 1,
+		CurrentWALNumber:  t.walManager.GetCurrentNumber(),
+		IndexVersions:     make(map[string]int64),
+	}
+
+	t.versionSet.mu.RLock()
+	inUse.ManifestNumber = t.versionSet.manifestNumber
+	t.versionSet.mu.RUnlock()
+
+	versions := make(map[uint32]bool)
+	readers := t.sstManager.GetReaders()
+	for _, reader := range readers {
+		if header := reader.GetHeader(); header != nil {
+			versions[header.Version] = true
+		}
+	}
+	for v := range versions {
+		inUse.SSTableVersions = append(inUse.SSTableVersions, v)
+	}
+	sort.Slice(inUse.SSTableVersions, func(i, j int) bool {
+		return inUse.SSTableVersions[i] < inUse.SSTableVersions[j]
+	})
+	inUse.SSTableFiles = len(readers)
+
+	for field, meta := range t.indexManager.GetIndexMetadata() {
+		inUse.IndexVersions[field] = meta.Version
+	}
+
+	desc.InUse = inUse
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(desc)
+}
@@ -0,0 +1,129 @@
+package srdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTableDumpFormat(t *testing.T) {
+	dir := "./test_dump_format"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 1024 * 1024,
+		Name:         "users",
+		Fields: []Field{
+			{Name: "name", Type: String, Indexed: true},
+			{Name: "age", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(map[string]any{"name": "alice", "age": int64(20)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := table.DumpFormat(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var desc FormatDescription
+	if err := json.Unmarshal(buf.Bytes(), &desc); err != nil {
+		t.Fatalf("DumpFormat output is not valid JSON: %v", err)
+	}
+
+	if desc.DescriptionVersion != FormatDescriptionVersion {
+		t.Errorf("Expected description version %d, got %d", FormatDescriptionVersion, desc.DescriptionVersion)
+	}
+	if desc.InUse == nil {
+		t.Fatal("Expected in_use section")
+	}
+
+	// 所有文件引用的结构都必须存在
+	structs := make(map[string]bool)
+	for _, s := range desc.Structures {
+		structs[s.Name] = true
+	}
+	for _, f := range desc.Files {
+		for _, name := range f.Structures {
+			if !structs[name] {
+				t.Errorf("File kind %s references unknown structure %s", f.Kind, name)
+			}
+		}
+	}
+
+	// 索引条目描述与当前写入的版本一致：postings 为位图，旧的 seq 列表单独描述
+	for _, s := range desc.Structures {
+		if s.Name != "index_entry" {
+			continue
+		}
+		names := make([]string, len(s.Fields))
+		for i, f := range s.Fields {
+			names[i] = f.Name
+		}
+		if !slices.Contains(names, "postings") || slices.Contains(names, "seqs") {
+			t.Errorf("Expected index_entry to describe bitmap postings, got fields %v", names)
+		}
+	}
+	if !structs["index_postings"] || !structs["index_entry_v1"] {
+		t.Error("Expected index_postings and index_entry_v1 structures")
+	}
+
+	// 每个字段类型都要有编码说明
+	for _, field := range table.GetSchema().Fields {
+		if _, ok := desc.FieldEncodings[field.Type.String()]; !ok {
+			t.Errorf("Missing field encoding for %s", field.Type)
+		}
+	}
+
+	if desc.InUse.SSTableFiles > 0 && len(desc.InUse.SSTableVersions) == 0 {
+		t.Error("Expected SSTable versions for opened files")
+	}
+	if _, ok := desc.InUse.IndexVersions["name"]; !ok {
+		t.Error("Expected index version for field name")
+	}
+}
+
+// 校验和描述与实际覆盖的字节一致：WAL 覆盖 crc32 之后的全部字节，MANIFEST 只覆盖 data
+func TestFormatChecksumCoverage(t *testing.T) {
+	fields := make(map[string]string)
+	for _, s := range DescribeFormat().Structures {
+		for _, f := range s.Fields {
+			fields[s.Name+"."+f.Name] = f.Description
+		}
+	}
+
+	entry := (&WAL{}).marshalEntry(&WALEntry{Type: WALEntryTypePut, Seq: 42, Data: []byte("row")})
+	if crc32.ChecksumIEEE(entry[4:]) != binary.LittleEndian.Uint32(entry[0:4]) {
+		t.Fatal("WAL crc32 does not cover length/type/seq/data")
+	}
+	if desc := fields["wal_entry.crc32"]; !strings.Contains(desc, "length、type、seq 与 data") {
+		t.Errorf("wal_entry.crc32 description does not match coverage: %q", desc)
+	}
+
+	record, err := NewVersionEdit().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crc32.ChecksumIEEE(record[8:]) != binary.LittleEndian.Uint32(record[0:4]) {
+		t.Fatal("MANIFEST crc32 does not cover data only")
+	}
+	if desc := fields["manifest_record.crc32"]; !strings.Contains(desc, "不包括 length") {
+		t.Errorf("manifest_record.crc32 description does not match coverage: %q", desc)
+	}
+}
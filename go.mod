@@ -5,6 +5,5 @@ go 1.24.0
 require (
 	github.com/edsrzf/mmap-go v1.2.0
 	github.com/shopspring/decimal v1.4.0
+	golang.org/x/sys v0.37.0
 )
-
-require golang.org/x/sys v0.37.0 // indirect
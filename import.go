@@ -0,0 +1,392 @@
+package srdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// inferSampleSize InferSchema 未指定采样数量时的默认值
+	inferSampleSize = 1000
+
+	// inferMaxDistinct 每个字段最多统计的不同值数量（超过后视为高基数）
+	inferMaxDistinct = 1024
+
+	// inferMinIndexSamples 按基数建议索引所需的最少非空样本数
+	inferMinIndexSamples = 8
+)
+
+// InferSchema 从 NDJSON（每行一个 JSON 对象）中采样前 sampleN 条记录，推断字段列表
+//
+// 推断规则：
+//   - 字段按首次出现的顺序排列，跳过 _seq、_time 等保留字段
+//   - 整数为 Int64，出现小数时为 Float64；全部为 RFC3339 时间字符串时为 Time
+//   - 对象为 Object，数组为 Array；类型冲突或只出现过 null 的字段为 String
+//   - 在部分记录中缺失或出现过 null 的字段标记为 Nullable
+//   - 名为 id 或以 _id 结尾的字段，以及重复值较多（不同值不超过 1/4）的
+//     String/Int64 字段建议建立索引（Indexed）
+//
+// sampleN <= 0 时默认采样 1000 条。返回的字段列表可以修改后传给 NewSchema。
+func InferSchema(r io.Reader, sampleN int) ([]Field, error) {
+	if r == nil {
+		return nil, NewErrorf(ErrCodeInvalidParam, "reader is nil")
+	}
+	if sampleN <= 0 {
+		sampleN = inferSampleSize
+	}
+
+	var (
+		stats   []*inferStats
+		byName  = make(map[string]*inferStats)
+		records int
+	)
+
+	lines := newJSONLineReader(r)
+	for records < sampleN {
+		line, err := lines.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeJSONObject(line, func(key string, value any) {
+			if key == "" || key == "_seq" || key == "_time" {
+				return
+			}
+			s, ok := byName[key]
+			if !ok {
+				s = &inferStats{name: key, missing: records, distinct: make(map[string]struct{})}
+				byName[key] = s
+				stats = append(stats, s)
+			}
+			s.observe(value, records)
+		})
+		if err != nil {
+			return nil, WrapError(err, "line %d", lines.line)
+		}
+		records++
+	}
+
+	if records == 0 {
+		return nil, NewErrorf(ErrCodeInvalidData, "no JSON records to sample")
+	}
+	if len(stats) == 0 {
+		return nil, NewErrorf(ErrCodeInvalidData, "sampled records have no fields")
+	}
+
+	fields := make([]Field, len(stats))
+	for i, s := range stats {
+		fields[i] = s.field(records)
+	}
+	return fields, nil
+}
+
+// inferStats 单个字段的采样统计
+type inferStats struct {
+	name     string
+	last     int // 最后一次出现的记录序号
+	missing  int // 缺失的记录数
+	nulls    int
+	values   int // 非 null 值数量
+	ints     int
+	floats   int
+	strings  int
+	times    int
+	bools    int
+	objects  int
+	arrays   int
+	distinct map[string]struct{}
+	overflow bool // 不同值超过 inferMaxDistinct
+}
+
+// observe 记录第 record 条记录中的值
+func (s *inferStats) observe(value any, record int) {
+	if s.values+s.nulls > 0 && record > s.last {
+		s.missing += record - s.last - 1
+	}
+	s.last = record
+
+	if value == nil {
+		s.nulls++
+		return
+	}
+	s.values++
+
+	switch v := value.(type) {
+	case bool:
+		s.bools++
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			s.ints++
+		} else {
+			s.floats++
+		}
+		s.addDistinct(v.String())
+	case string:
+		s.strings++
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			s.times++
+		}
+		s.addDistinct(v)
+	case map[string]any:
+		s.objects++
+	case []any:
+		s.arrays++
+	}
+}
+
+func (s *inferStats) addDistinct(key string) {
+	if s.overflow {
+		return
+	}
+	s.distinct[key] = struct{}{}
+	if len(s.distinct) > inferMaxDistinct {
+		s.overflow = true
+		s.distinct = nil
+	}
+}
+
+// field 根据统计结果生成字段定义
+func (s *inferStats) field(records int) Field {
+	missing := s.missing + records - s.last - 1
+	field := Field{
+		Name:     s.name,
+		Type:     String,
+		Nullable: missing > 0 || s.nulls > 0,
+	}
+
+	kinds := 0
+	for _, n := range []int{s.bools, s.ints + s.floats, s.strings, s.objects, s.arrays} {
+		if n > 0 {
+			kinds++
+		}
+	}
+	if kinds == 1 {
+		switch {
+		case s.bools > 0:
+			field.Type = Bool
+		case s.floats > 0:
+			field.Type = Float64
+		case s.ints > 0:
+			field.Type = Int64
+		case s.strings > 0 && s.times == s.strings:
+			field.Type = Time
+		case s.objects > 0:
+			field.Type = Object
+		case s.arrays > 0:
+			field.Type = Array
+		}
+	}
+
+	if field.Type == String || field.Type == Int64 {
+		lower := strings.ToLower(s.name)
+		if lower == "id" || strings.HasSuffix(lower, "_id") {
+			field.Indexed = true
+		} else if kinds == 1 && !s.overflow && s.values >= inferMinIndexSamples && len(s.distinct)*4 <= s.values {
+			field.Indexed = true
+		}
+	}
+
+	return field
+}
+
+// ImportJSON 从 NDJSON 推断 Schema、创建表并导入全部记录，返回新表与导入的行数
+//
+// 前 sampleN 条记录用于推断 Schema（见 InferSchema），随后与剩余记录一起流式导入，
+// 内存占用只与采样数量和单行大小有关。表已存在时返回 ErrCodeTableExists；
+// 导入失败时保留已导入的数据并返回表，可以调用 Table.ImportJSON 继续导入。
+func (db *Database) ImportJSON(name string, r io.Reader, sampleN int) (*Table, int64, error) {
+	if r == nil {
+		return nil, 0, NewErrorf(ErrCodeInvalidParam, "reader is nil")
+	}
+
+	// 采样时读取的数据（包括缓冲区预读）保存下来，导入时重新读取
+	var sample bytes.Buffer
+	fields, err := InferSchema(io.TeeReader(r, &sample), sampleN)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	schema, err := NewSchema(name, fields)
+	if err != nil {
+		return nil, 0, err
+	}
+	table, err := db.CreateTable(name, schema)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n, err := table.ImportJSON(io.MultiReader(&sample, r))
+	return table, n, err
+}
+
+// ImportJSON 逐行导入 NDJSON（每行一个 JSON 对象），返回导入的行数
+//
+// 数字按字段类型转换，String 字段中的非字符串值保存为 JSON 文本，
+// Schema 中不存在的字段被忽略。遇到无效记录时停止并返回带行号的错误，
+// 之前的记录已写入。
+func (t *Table) ImportJSON(r io.Reader) (int64, error) {
+	if r == nil {
+		return 0, NewErrorf(ErrCodeInvalidParam, "reader is nil")
+	}
+
+	var n int64
+	lines := newJSONLineReader(r)
+	for {
+		line, err := lines.next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		row := make(map[string]any)
+		err = decodeJSONObject(line, func(key string, value any) {
+			field, err := t.schema.Load().GetField(key)
+			if err != nil || field.Computed != "" {
+				return
+			}
+			row[key] = importJSONValue(value, field.Type)
+		})
+		if err == nil {
+			_, err = t.insertSingle(row)
+		}
+		if err != nil {
+			return n, WrapError(err, "line %d", lines.line)
+		}
+		n++
+	}
+}
+
+// importJSONValue 将 JSON 解码的值转换为字段类型可以接受的值
+func importJSONValue(value any, typ FieldType) any {
+	if value == nil {
+		return nil
+	}
+
+	if num, ok := value.(json.Number); ok {
+		switch typ {
+		case Int, Int8, Int16, Int32, Int64, Time, Duration:
+			if v, err := num.Int64(); err == nil {
+				return v
+			}
+		case Uint, Uint8, Uint16, Uint32, Uint64, Byte, Rune:
+			if v, err := strconv.ParseUint(num.String(), 10, 64); err == nil {
+				return v
+			}
+		case Decimal, String:
+			return num.String()
+		}
+		if v, err := num.Float64(); err == nil {
+			return v
+		}
+		return num.String()
+	}
+
+	if typ == String {
+		if _, ok := value.(string); !ok {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return value
+			}
+			return string(data)
+		}
+		return value
+	}
+
+	return normalizeJSONNumbers(value)
+}
+
+// normalizeJSONNumbers 将嵌套对象/数组中的 json.Number 转换为 int64 或 float64
+func normalizeJSONNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeJSONNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeJSONNumbers(item)
+		}
+	}
+	return value
+}
+
+// jsonLineReader 按行读取 NDJSON，跳过空行（单行长度不受限制）
+type jsonLineReader struct {
+	r    *bufio.Reader
+	line int // 当前行号（从 1 开始）
+}
+
+func newJSONLineReader(r io.Reader) *jsonLineReader {
+	return &jsonLineReader{r: bufio.NewReader(r)}
+}
+
+// next 返回下一个非空行，读完时返回 io.EOF
+func (lr *jsonLineReader) next() ([]byte, error) {
+	for {
+		line, err := lr.r.ReadBytes('\n')
+		if len(line) > 0 {
+			lr.line++
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				return line, nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// decodeJSONObject 解码一个 JSON 对象，按键的出现顺序回调（数字保留为 json.Number）
+func decodeJSONObject(data []byte, fn func(key string, value any)) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return NewErrorf(ErrCodeInvalidData, "invalid JSON: %v", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return NewErrorf(ErrCodeInvalidData, "expected JSON object")
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return NewErrorf(ErrCodeInvalidData, "invalid JSON: %v", err)
+		}
+		key := tok.(string)
+
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			return NewErrorf(ErrCodeInvalidData, "invalid JSON value for %s: %v", key, err)
+		}
+		fn(key, value)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return NewErrorf(ErrCodeInvalidData, "invalid JSON: %v", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return NewErrorf(ErrCodeInvalidData, "unexpected data after JSON object")
+	}
+	return nil
+}
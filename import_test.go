@@ -0,0 +1,149 @@
+package srdb
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferSchema(t *testing.T) {
+	var b strings.Builder
+	for i := range 20 {
+		fmt.Fprintf(&b, `{"request_id":"req-%d","level":"%s","latency":%d,"ratio":%d,"ok":%t,"ts":"2024-01-02T15:04:%02dZ","meta":{"k":%d},"tags":["a"],"_seq":1`,
+			i, []string{"info", "warn"}[i%2], i*10, i, i%3 == 0, i, i)
+		if i%5 == 0 {
+			b.WriteString(`,"user":null,"mixed":"x"`)
+		} else {
+			b.WriteString(`,"mixed":1`)
+		}
+		if i == 7 {
+			b.WriteString(`,"ratio":0.5`)
+		}
+		b.WriteString("}\n\n")
+	}
+	fmt.Fprintf(&b, `{"late_field":1}`+"\n")
+
+	fields, err := InferSchema(strings.NewReader(b.String()), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		name     string
+		typ      FieldType
+		nullable bool
+		indexed  bool
+	}{
+		{"request_id", String, false, true},
+		{"level", String, false, true},
+		{"latency", Int64, false, false},
+		{"ratio", Float64, false, false},
+		{"ok", Bool, false, false},
+		{"ts", Time, false, false},
+		{"meta", Object, false, false},
+		{"tags", Array, false, false},
+		{"user", String, true, false},
+		{"mixed", String, false, false},
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("expected %d fields, got %+v", len(expected), fields)
+	}
+	for i, want := range expected {
+		got := fields[i]
+		if got.Name != want.name || got.Type != want.typ || got.Nullable != want.nullable || got.Indexed != want.indexed {
+			t.Errorf("field %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	// 在部分记录中缺失的字段可为空
+	fields, err = InferSchema(strings.NewReader("{\"a\":1}\n{\"a\":2,\"b\":\"x\"}\n{\"a\":3}\n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 2 || fields[0].Nullable || !fields[1].Nullable {
+		t.Errorf("unexpected nullable inference %+v", fields)
+	}
+
+	// 错误处理
+	if _, err := InferSchema(strings.NewReader(""), 10); !IsError(err, ErrCodeInvalidData) {
+		t.Errorf("expected ErrCodeInvalidData for empty input, got %v", err)
+	}
+	if _, err := InferSchema(strings.NewReader("{\"a\":1}\n[1,2]\n"), 10); !IsError(err, ErrCodeInvalidData) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected ErrCodeInvalidData at line 2, got %v", err)
+	}
+	if _, err := InferSchema(strings.NewReader("{\"a\":1} {\"a\":2}\n"), 10); !IsError(err, ErrCodeInvalidData) {
+		t.Errorf("expected ErrCodeInvalidData for trailing data, got %v", err)
+	}
+}
+
+func TestImportJSON(t *testing.T) {
+	db, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// 采样之外的记录也会导入，单行超过读取缓冲区也能处理
+	var b strings.Builder
+	for i := range 50 {
+		fmt.Fprintf(&b, `{"id":%d,"service":"svc-%d","latency":%d.5,"at":"2024-01-02T15:04:05Z","attrs":{"n":%d},"unknown":true}`+"\n", i, i%3, i, i)
+	}
+	fmt.Fprintf(&b, `{"id":50,"service":"%s","latency":1,"attrs":{"nested":[1,2.5]}}`+"\n", strings.Repeat("s", 10000))
+
+	table, n, err := db.ImportJSON("logs", strings.NewReader(b.String()), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 51 {
+		t.Errorf("expected 51 imported rows, got %d", n)
+	}
+
+	schema := table.GetSchema()
+	if _, err := schema.GetField("unknown"); err != nil {
+		t.Errorf("expected field inferred from sample: %v", err)
+	}
+	if f, _ := schema.GetField("id"); f.Type != Int64 || !f.Indexed {
+		t.Errorf("unexpected id field %+v", f)
+	}
+
+	row, err := table.Query().Eq("id", int64(7)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := row.Data()
+	if data["service"] != "svc-1" || data["latency"] != 7.5 {
+		t.Errorf("unexpected row %v", data)
+	}
+	if at, ok := data["at"].(time.Time); !ok || at.Year() != 2024 {
+		t.Errorf("unexpected time %v", data["at"])
+	}
+	if attrs, ok := data["attrs"].(map[string]any); !ok || fmt.Sprint(attrs["n"]) != "7" {
+		t.Errorf("unexpected object %v", data["attrs"])
+	}
+
+	count, err := table.Query().Eq("service", "svc-0").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := count.Count(); c != 17 {
+		t.Errorf("expected 17 svc-0 rows, got %d", c)
+	}
+
+	// 表已存在
+	if _, _, err := db.ImportJSON("logs", strings.NewReader(`{"id":1}`), 10); !IsError(err, ErrCodeTableExists) {
+		t.Errorf("expected ErrCodeTableExists, got %v", err)
+	}
+
+	// 无效记录停止导入并报告行号，之前的记录已写入
+	n, err = table.ImportJSON(strings.NewReader("{\"id\":100}\n{\"id\":\"bad\"}\n{\"id\":102}\n"))
+	if !IsError(err, ErrCodeSchemaValidationFailed) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected validation error at line 2, got %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 row imported before the error, got %d", n)
+	}
+	if _, err := table.Query().Eq("id", int64(100)).First(); err != nil {
+		t.Errorf("expected row before the error to be imported: %v", err)
+	}
+}
@@ -1,20 +1,26 @@
 package srdb
 
 import (
+	"cmp"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // IndexMetadata 索引元数据
 type IndexMetadata struct {
 	Version   int64 // 索引版本号
-	MaxSeq    int64 // 索引包含的最大 seq
+	MaxSeq    int64 // 索引已处理的最大 seq（部分索引包括因不满足条件而跳过的行）
 	MinSeq    int64 // 索引包含的最小 seq
 	RowCount  int64 // 索引包含的行数
 	CreatedAt int64 // 创建时间
@@ -28,11 +34,31 @@ type SecondaryIndex struct {
 	fieldType    FieldType          // 字段类型
 	file         *os.File           // 索引文件
 	btreeReader  *IndexBTreeReader  // B+Tree 读取器
-	valueToSeq   map[string][]int64 // 值 → seq 列表 (构建时使用)
+	valueToSeq   map[string]*Bitmap // 值 → seq 位图 (构建时使用)
+	include      []Field            // 覆盖索引字段
+	covered      map[int64][]any    // seq → Include 字段值 (构建时使用)
 	metadata     IndexMetadata      // 元数据
 	mu           sync.RWMutex
-	ready        bool // 索引是否就绪
-	useBTree     bool // 是否使用 B+Tree 存储（新格式）
+	ready        bool   // 索引是否就绪
+	useBTree     bool   // 是否使用 B+Tree 存储（新格式）
+	ioMode       IOMode // 索引文件读取方式
+	builtAt      int64  // 最后一次持久化（Build）的时间（UnixNano），0 表示尚未持久化
+
+	// 部分索引的条件（nil 表示完整索引），只为满足条件的行建立索引
+	where  Expr
+	schema *Schema // 求值 where 时使用
+	// 按字段值排序的所有值（首次按索引排序查询时构建，之后只合并新增的值）
+	sorted      []indexSortValue
+	sortedBuilt bool
+	unsorted    []string // sorted 构建之后新增、尚未合并的值
+
+	// 内存中的表示方式（见 IndexMemory）
+	memory     IndexMemory
+	sortedFile *indexSortedFile // 有序值文件（IndexMemoryMmap）
+	diskMerged bool             // B+Tree 中的条目已合并到内存（IndexMemoryHeap）
+
+	// 异步维护的写入队列（IndexAsync），nil 表示同步维护
+	queue *indexQueue
 }
 
 // NewSecondaryIndex 创建二级索引
@@ -48,11 +74,41 @@ func NewSecondaryIndex(dir, field string, fieldType FieldType) (*SecondaryIndex,
 		field:      field,
 		fieldType:  fieldType,
 		file:       file,
-		valueToSeq: make(map[string][]int64),
+		valueToSeq: make(map[string]*Bitmap),
+		covered:    make(map[int64][]any),
 		ready:      false,
 	}, nil
 }
 
+// setCovered 记录 seq 对应的覆盖索引字段值
+func (idx *SecondaryIndex) setCovered(seq int64, data map[string]any) {
+	if len(idx.include) == 0 {
+		return
+	}
+
+	values := make([]any, len(idx.include))
+	for i, f := range idx.include {
+		value, exists := data[f.Name]
+		if !exists || value == nil {
+			if !f.Nullable {
+				// 非 nullable 字段缺失时行数据写入零值，覆盖数据保持一致
+				values[i], _ = fieldZeroValue(f.Type)
+			}
+			continue
+		}
+		// 与行数据使用相同的类型，确保可以按字段类型编码
+		converted, err := convertValue(value, f.Type)
+		if err != nil {
+			continue
+		}
+		values[i] = converted
+	}
+
+	idx.mu.Lock()
+	idx.covered[seq] = values
+	idx.mu.Unlock()
+}
+
 // Add 添加索引条目（增量更新元数据）
 func (idx *SecondaryIndex) Add(value any, seq int64) error {
 	idx.mu.Lock()
@@ -60,7 +116,8 @@ func (idx *SecondaryIndex) Add(value any, seq int64) error {
 
 	// 将值转换为字符串作为 key
 	key := fmt.Sprintf("%v", value)
-	idx.valueToSeq[key] = append(idx.valueToSeq[key], seq)
+	idx.noteValue(key)
+	idx.postings(key).Add(seq)
 
 	// 增量更新元数据 O(1)
 	if idx.metadata.MinSeq == 0 || seq < idx.metadata.MinSeq {
@@ -85,6 +142,13 @@ func (idx *SecondaryIndex) Build() error {
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
 
+	// 重写文件之前合并已持久化的条目
+	if idx.memory == IndexMemoryMmap || !idx.diskMerged {
+		if err := idx.mergePersisted(); err != nil {
+			return fmt.Errorf("failed to merge persisted index entries: %w", err)
+		}
+	}
+
 	// 元数据已在 Add 时增量更新，这里只更新版本号
 	idx.metadata.Version++
 	idx.metadata.UpdatedAt = time.Now().UnixNano()
@@ -105,11 +169,23 @@ func (idx *SecondaryIndex) Build() error {
 	// 使用 B+Tree 写入器
 	writer := NewIndexBTreeWriter(idx.file, idx.metadata)
 
-	// 写入内存中的所有条目
-	// 注意：这假设 valueToSeq 包含所有数据（包括从磁盘加载的）
-	// 对于增量更新场景，Get() 会合并内存和磁盘的结果
-	for value, seqs := range idx.valueToSeq {
-		writer.Add(value, seqs)
+	// 写入内存中的所有条目（已合并从磁盘加载的条目）
+	if idx.hasAllCovered() {
+		if err := writer.SetInclude(idx.include); err != nil {
+			return fmt.Errorf("failed to set index include: %w", err)
+		}
+		for value, seqs := range idx.valueToSeq {
+			covered := make([][]any, 0, seqs.Cardinality())
+			seqs.Iterate(func(seq int64) bool {
+				covered = append(covered, idx.covered[seq])
+				return true
+			})
+			writer.AddCovered(value, seqs.ToArray(), covered)
+		}
+	} else {
+		for value, seqs := range idx.valueToSeq {
+			writer.entries[value] = seqs
+		}
 	}
 
 	// 构建并写入
@@ -119,7 +195,7 @@ func (idx *SecondaryIndex) Build() error {
 	}
 
 	// 重新加载 btreeReader（读取刚写入的数据）
-	reader, err := NewIndexBTreeReader(idx.file)
+	reader, err := NewIndexBTreeReaderWithIOMode(idx.file, idx.ioMode)
 	if err != nil {
 		return fmt.Errorf("failed to reload btree reader: %w", err)
 	}
@@ -127,14 +203,56 @@ func (idx *SecondaryIndex) Build() error {
 	idx.btreeReader = reader
 	idx.useBTree = true
 	idx.ready = true
+	idx.builtAt = idx.metadata.UpdatedAt
+
+	if idx.memory == IndexMemoryMmap {
+		// 所有条目已在文件中，内存中只保留之后写入的条目
+		values := sortIndexValues(slices.Collect(maps.Keys(idx.valueToSeq)), idx.fieldType)
+		if err := idx.publishSorted(values, reader.header.IndexVersion); err != nil {
+			return fmt.Errorf("failed to write sorted index values: %w", err)
+		}
+		idx.valueToSeq = make(map[string]*Bitmap)
+		idx.covered = make(map[int64][]any)
+		return nil
+	}
 
 	// 不清空 valueToSeq，保留所有数据在内存中
 	// 这样下次 Build() 时可以写入完整数据
 	// Get() 方法会合并内存和磁盘的结果（去重）
+	idx.diskMerged = true
 
 	return nil
 }
 
+// hasAllCovered 是否配置了覆盖索引且所有条目都有覆盖数据，调用者必须持有 mu
+// 部分条目缺少覆盖数据时（如从旧格式加载）不写入覆盖数据，查询回退到读取行数据
+func (idx *SecondaryIndex) hasAllCovered() bool {
+	if len(idx.include) == 0 {
+		return false
+	}
+	for _, seqs := range idx.valueToSeq {
+		all := true
+		seqs.Iterate(func(seq int64) bool {
+			_, all = idx.covered[seq]
+			return all
+		})
+		if !all {
+			return false
+		}
+	}
+	return true
+}
+
+// postings 返回值对应的内存 seq 位图（不存在时创建），调用者必须持有 mu
+func (idx *SecondaryIndex) postings(key string) *Bitmap {
+	seqs, ok := idx.valueToSeq[key]
+	if !ok {
+		seqs = &Bitmap{}
+		idx.valueToSeq[key] = seqs
+	}
+	return seqs
+}
+
 // load 从磁盘加载索引（支持 B+Tree 和 JSON 格式）
 func (idx *SecondaryIndex) load() error {
 	// 获取文件大小
@@ -170,15 +288,17 @@ func (idx *SecondaryIndex) load() error {
 
 // loadBTree 加载 B+Tree 格式的索引
 func (idx *SecondaryIndex) loadBTree() error {
-	reader, err := NewIndexBTreeReader(idx.file)
+	reader, err := NewIndexBTreeReaderWithIOMode(idx.file, idx.ioMode)
 	if err != nil {
 		return fmt.Errorf("failed to create btree reader: %w", err)
 	}
 
 	idx.btreeReader = reader
 	idx.metadata = reader.GetMetadata()
+	idx.builtAt = idx.metadata.UpdatedAt
 	idx.useBTree = true
 	idx.ready = true
+	idx.loadSorted(reader.header.IndexVersion)
 	return nil
 }
 
@@ -212,14 +332,28 @@ func (idx *SecondaryIndex) loadJSON() error {
 	}
 
 	idx.metadata = indexData.Metadata
-	idx.valueToSeq = indexData.ValueToSeq
+	idx.valueToSeq = make(map[string]*Bitmap, len(indexData.ValueToSeq))
+	for value, seqs := range indexData.ValueToSeq {
+		idx.valueToSeq[value] = NewBitmap(seqs...)
+	}
+	idx.builtAt = idx.metadata.UpdatedAt
 	idx.useBTree = false
 	idx.ready = true
 	return nil
 }
 
-// Get 查询索引（优先查内存，然后查磁盘，合并结果）
+// Get 查询索引（优先查内存，然后查磁盘，合并结果），返回升序的 seq 列表
 func (idx *SecondaryIndex) Get(value any) ([]int64, error) {
+	seqs, err := idx.GetBitmap(value)
+	if err != nil || seqs.IsEmpty() {
+		return nil, err
+	}
+	return seqs.ToArray(), nil
+}
+
+// GetBitmap 查询索引，返回 seq 位图（合并内存与磁盘的结果）
+// 多个条件的结果可以通过 And、Or、AndNot 组合；返回的位图属于调用者，可以修改
+func (idx *SecondaryIndex) GetBitmap(value any) (*Bitmap, error) {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
@@ -229,37 +363,105 @@ func (idx *SecondaryIndex) Get(value any) ([]int64, error) {
 
 	key := fmt.Sprintf("%v", value)
 
-	// 收集所有匹配的 seqs（需要去重）
-	seqMap := make(map[int64]bool)
+	// 1. 先从内存读取（包含最新的未持久化数据）
+	seqs := idx.valueToSeq[key].Clone()
 
-	// 1. 先从内存 map 读取（包含最新的未持久化数据）
+	// 2. 如果使用 B+Tree，合并 B+Tree 中持久化的数据
+	if idx.useBTree && idx.btreeReader != nil {
+		diskSeqs, err := idx.btreeReader.GetBitmap(key)
+		if err == nil && diskSeqs != nil {
+			seqs.Merge(diskSeqs)
+		}
+	}
+
+	return seqs, nil
+}
+
+// GetCovered 通过覆盖索引查询，不读取行数据
+//
+// 返回 seq → 请求字段值（NULL 字段不包含在内）。
+// 当有匹配条目缺少任一请求字段的覆盖数据时返回 ok=false，调用者应回退到 Get + 读取行数据。
+func (idx *SecondaryIndex) GetCovered(value any, fields []string) (result map[int64]map[string]any, ok bool, err error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if !idx.ready {
+		return nil, false, fmt.Errorf("index not ready")
+	}
+
+	key := fmt.Sprintf("%v", value)
+	result = make(map[int64]map[string]any)
+
+	// 1. 内存中的条目
 	if memSeqs, exists := idx.valueToSeq[key]; exists {
-		for _, seq := range memSeqs {
-			seqMap[seq] = true
+		positions, ok := includePositions(idx.include, fields)
+		if !ok {
+			return nil, false, nil
+		}
+		complete := true
+		memSeqs.Iterate(func(seq int64) bool {
+			values, exists := idx.covered[seq]
+			if !exists {
+				complete = false
+				return false
+			}
+			result[seq] = pickCovered(values, fields, positions)
+			return true
+		})
+		if !complete {
+			return nil, false, nil
 		}
 	}
 
-	// 2. 如果使用 B+Tree，从 B+Tree 读取（持久化的数据）
+	// 2. 磁盘上的条目
 	if idx.useBTree && idx.btreeReader != nil {
-		diskSeqs, err := idx.btreeReader.Get(key)
-		if err == nil && diskSeqs != nil {
-			for _, seq := range diskSeqs {
-				seqMap[seq] = true
+		diskSeqs, covered, err := idx.btreeReader.GetCovered(key)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(diskSeqs) > 0 {
+			positions, ok := includePositions(idx.btreeReader.Include(), fields)
+			if !ok || covered == nil {
+				return nil, false, nil
+			}
+			for i, seq := range diskSeqs {
+				if _, exists := result[seq]; !exists {
+					result[seq] = pickCovered(covered[i], fields, positions)
+				}
 			}
 		}
 	}
 
-	// 3. 合并结果
-	if len(seqMap) == 0 {
-		return nil, nil
-	}
+	return result, true, nil
+}
 
-	result := make([]int64, 0, len(seqMap))
-	for seq := range seqMap {
-		result = append(result, seq)
+// includePositions 返回 fields 在 include 中的位置，有字段未被覆盖时返回 false
+func includePositions(include []Field, fields []string) ([]int, bool) {
+	positions := make([]int, len(fields))
+	for i, name := range fields {
+		positions[i] = -1
+		for j, f := range include {
+			if f.Name == name {
+				positions[i] = j
+				break
+			}
+		}
+		if positions[i] < 0 {
+			return nil, false
+		}
 	}
+	return positions, true
+}
 
-	return result, nil
+// pickCovered 按位置取出请求字段的值
+func pickCovered(values []any, fields []string, positions []int) map[string]any {
+	data := make(map[string]any, len(fields))
+	for i, pos := range positions {
+		if values[pos] != nil {
+			data[fields[i]] = values[pos]
+		}
+	}
+	return data
 }
 
 // IsReady 索引是否就绪
@@ -278,8 +480,24 @@ func (idx *SecondaryIndex) GetMetadata() IndexMetadata {
 
 // ForEach 升序迭代所有索引条目
 // callback 返回 false 时停止迭代，支持提前终止
-// 注意：只能迭代已持久化的数据（B+Tree），不包括内存中未持久化的数据
+// 先迭代已持久化的条目（B+Tree，合并内存中同一个值的新 seq），再迭代只存在于内存中的值
 func (idx *SecondaryIndex) ForEach(callback IndexEntryCallback) error {
+	return idx.forEach(func(value string, seqs *Bitmap) bool {
+		return callback(value, seqs.ToArray())
+	}, false)
+}
+
+// ForEachDesc 降序迭代所有索引条目
+// callback 返回 false 时停止迭代，支持提前终止
+// 内存中未持久化的数据合并方式同 ForEach
+func (idx *SecondaryIndex) ForEachDesc(callback IndexEntryCallback) error {
+	return idx.forEach(func(value string, seqs *Bitmap) bool {
+		return callback(value, seqs.ToArray())
+	}, true)
+}
+
+// forEach 迭代已持久化与内存中的索引条目，每个值只回调一次（合并后的 seq 位图属于回调，可以修改）
+func (idx *SecondaryIndex) forEach(callback func(value string, seqs *Bitmap) bool, desc bool) error {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
@@ -287,33 +505,209 @@ func (idx *SecondaryIndex) ForEach(callback IndexEntryCallback) error {
 		return fmt.Errorf("index not ready")
 	}
 
-	// 只支持 B+Tree 格式的索引
-	if !idx.useBTree || idx.btreeReader == nil {
-		return fmt.Errorf("ForEach only supports B+Tree format indexes")
+	// 1. 已持久化的条目（B+Tree），只记录同时存在于内存中的值
+	visited := make(map[string]bool)
+	stopped := false
+	if idx.useBTree && idx.btreeReader != nil {
+		idx.btreeReader.forEach(func(value string, seqs *Bitmap) bool {
+			if memSeqs, exists := idx.valueToSeq[value]; exists {
+				visited[value] = true
+				seqs.Merge(memSeqs)
+			}
+			stopped = !callback(value, seqs)
+			return !stopped
+		}, desc)
+	}
+	if stopped {
+		return nil
 	}
 
-	idx.btreeReader.ForEach(callback)
+	// 2. 只存在于内存中的值（上次持久化之后写入）
+	values := make([]string, 0, len(idx.valueToSeq))
+	for value := range idx.valueToSeq {
+		if !visited[value] {
+			values = append(values, value)
+		}
+	}
+	slices.Sort(values)
+	if desc {
+		slices.Reverse(values)
+	}
+	for _, value := range values {
+		if !callback(value, idx.valueToSeq[value].Clone()) {
+			return nil
+		}
+	}
 	return nil
 }
 
-// ForEachDesc 降序迭代所有索引条目
-// callback 返回 false 时停止迭代，支持提前终止
-// 注意：只能迭代已持久化的数据（B+Tree），不包括内存中未持久化的数据
-func (idx *SecondaryIndex) ForEachDesc(callback IndexEntryCallback) error {
-	idx.mu.RLock()
-	defer idx.mu.RUnlock()
+// indexSortValue 索引值及其按字段类型解析后的排序键
+type indexSortValue struct {
+	raw string
+	key any
+}
+
+// noteValue 记录新出现的值，下次 sortedValues 时合并，调用者必须持有 mu
+func (idx *SecondaryIndex) noteValue(key string) {
+	if !idx.sortedBuilt {
+		return
+	}
+	if _, exists := idx.valueToSeq[key]; !exists {
+		idx.unsorted = append(idx.unsorted, key)
+	}
+}
+
+// sortedValues 返回按字段值升序排列的所有索引值，调用者使用完毕后必须调用 release
+//
+// 按字段类型比较（数值按大小、时间按先后），而不是按字符串或 B+Tree 中的哈希顺序。
+// IndexMemoryHeap 首次调用时排序所有值并缓存，之后只排序新增的值再合并；返回的列表不会被修改，
+// 可以在不持有锁的情况下使用。IndexMemoryMmap 从有序值文件读取，见 mmapSortedValues。
+func (idx *SecondaryIndex) sortedValues() (indexValues, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
 
 	if !idx.ready {
-		return fmt.Errorf("index not ready")
+		return nil, fmt.Errorf("index not ready")
+	}
+	if idx.memory == IndexMemoryMmap {
+		return idx.mmapSortedValues()
 	}
 
-	// 只支持 B+Tree 格式的索引
-	if !idx.useBTree || idx.btreeReader == nil {
-		return fmt.Errorf("ForEachDesc only supports B+Tree format indexes")
+	if !idx.sortedBuilt {
+		values := slices.Collect(maps.Keys(idx.valueToSeq))
+		if idx.useBTree && idx.btreeReader != nil {
+			idx.btreeReader.ForEach(func(value string, seqs []int64) bool {
+				if _, exists := idx.valueToSeq[value]; !exists {
+					values = append(values, value)
+				}
+				return true
+			})
+		}
+		idx.sorted = sortIndexValues(values, idx.fieldType)
+		idx.sortedBuilt = true
+		idx.unsorted = nil
+	} else if len(idx.unsorted) > 0 {
+		idx.sorted = mergeSortedIndexValues(idx.sorted, sortIndexValues(idx.unsorted, idx.fieldType))
+		idx.unsorted = nil
 	}
+	return indexValueSlice(idx.sorted), nil
+}
 
-	idx.btreeReader.ForEachDesc(callback)
-	return nil
+// sortIndexValues 解析并排序索引值
+func sortIndexValues(values []string, fieldType FieldType) []indexSortValue {
+	result := make([]indexSortValue, len(values))
+	for i, value := range values {
+		result[i] = indexSortValue{raw: value, key: indexSortKey(value, fieldType)}
+	}
+	slices.SortFunc(result, compareIndexSortValues)
+	return result
+}
+
+// mergeSortedIndexValues 合并两个已排序的列表，返回去重后的新列表（不修改参数）
+func mergeSortedIndexValues(a, b []indexSortValue) []indexSortValue {
+	result := make([]indexSortValue, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch c := compareIndexSortValues(a[i], b[j]); {
+		case c < 0:
+			result = append(result, a[i])
+			i++
+		case c > 0:
+			result = append(result, b[j])
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	result = append(result, a[i:]...)
+	return append(result, b[j:]...)
+}
+
+// indexSortKey 按字段类型解析索引值（索引值是 fmt.Sprintf("%v") 的结果），解析失败时按字符串比较
+func indexSortKey(value string, fieldType FieldType) any {
+	var key any
+	var err error
+	switch fieldType {
+	case Int, Int8, Int16, Int32, Int64, Rune:
+		key, err = strconv.ParseInt(value, 10, 64)
+	case Uint, Uint8, Uint16, Uint32, Uint64, Byte:
+		key, err = strconv.ParseUint(value, 10, 64)
+	case Float32, Float64:
+		key, err = strconv.ParseFloat(value, 64)
+	case Bool:
+		key, err = strconv.ParseBool(value)
+	case Decimal:
+		key, err = decimal.NewFromString(value)
+	case Duration:
+		key, err = time.ParseDuration(value)
+	case Time:
+		// time.Time.String() 格式为 "2006-01-02 15:04:05.999999999 -0700 MST"，可能带有单调时钟读数（" m=+0.001"）
+		// 时区缩写不一定能被解析（如 FixedZone 的自定义名称），只按数字偏移解析
+		if i := strings.Index(value, " m="); i >= 0 {
+			value = value[:i]
+		}
+		if i := strings.LastIndexByte(value, ' '); i >= 0 {
+			value = value[:i]
+		}
+		key, err = time.Parse("2006-01-02 15:04:05.999999999 -0700", value)
+	default:
+		return value
+	}
+	if err != nil {
+		return value
+	}
+	return key
+}
+
+// compareIndexSortValues 按排序键比较，键相同或类型不同时按原始字符串比较
+func compareIndexSortValues(a, b indexSortValue) int {
+	c := 0
+	switch x := a.key.(type) {
+	case int64:
+		if y, ok := b.key.(int64); ok {
+			c = cmp.Compare(x, y)
+		}
+	case uint64:
+		if y, ok := b.key.(uint64); ok {
+			c = cmp.Compare(x, y)
+		}
+	case float64:
+		if y, ok := b.key.(float64); ok {
+			c = cmp.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.key.(bool); ok && x != y {
+			c = 1
+			if !x {
+				c = -1
+			}
+		}
+	case decimal.Decimal:
+		if y, ok := b.key.(decimal.Decimal); ok {
+			c = x.Cmp(y)
+		}
+	case time.Duration:
+		if y, ok := b.key.(time.Duration); ok {
+			c = cmp.Compare(x, y)
+		}
+	case time.Time:
+		if y, ok := b.key.(time.Time); ok {
+			c = x.Compare(y)
+		}
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.raw, b.raw)
+}
+
+// skip 记录不满足部分索引条件的行已处理（不加入索引）
+func (idx *SecondaryIndex) skip(seq int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.metadata.MaxSeq = max(idx.metadata.MaxSeq, seq)
 }
 
 // NeedsUpdate 检查是否需要更新
@@ -336,6 +730,12 @@ func (idx *SecondaryIndex) IncrementalUpdate(getData func(int64) (map[string]any
 			continue // 跳过错误的数据
 		}
 
+		// 不满足部分索引条件的行只记录已处理
+		if !idx.matchesWhere(data) {
+			idx.metadata.MaxSeq = max(idx.metadata.MaxSeq, seq)
+			continue
+		}
+
 		// 提取字段值
 		value, exists := data[idx.field]
 		if !exists {
@@ -344,7 +744,15 @@ func (idx *SecondaryIndex) IncrementalUpdate(getData func(int64) (map[string]any
 
 		// 添加到索引
 		key := fmt.Sprintf("%v", value)
-		idx.valueToSeq[key] = append(idx.valueToSeq[key], seq)
+		idx.noteValue(key)
+		idx.postings(key).Add(seq)
+		if len(idx.include) > 0 {
+			values := make([]any, len(idx.include))
+			for i, f := range idx.include {
+				values[i] = data[f.Name]
+			}
+			idx.covered[seq] = values
+		}
 
 		// 更新元数据
 		if idx.metadata.MinSeq == 0 || seq < idx.metadata.MinSeq {
@@ -368,10 +776,19 @@ func (idx *SecondaryIndex) IncrementalUpdate(getData func(int64) (map[string]any
 
 // Close 关闭索引
 func (idx *SecondaryIndex) Close() error {
+	// 停止异步维护的后台 goroutine
+	if idx.queue != nil {
+		idx.queue.stop()
+	}
 	// 关闭 B+Tree reader
 	if idx.btreeReader != nil {
 		idx.btreeReader.Close()
 	}
+	// 释放有序值文件（查询仍在使用时由最后一个查询关闭）
+	if idx.sortedFile != nil {
+		idx.sortedFile.release()
+		idx.sortedFile = nil
+	}
 	// 关闭文件
 	if idx.file != nil {
 		return idx.file.Close()
@@ -384,15 +801,22 @@ type IndexManager struct {
 	dir     string
 	schema  *Schema
 	indexes map[string]*SecondaryIndex // field → index
+	ioMode  IOMode                     // 索引文件读取方式
 	mu      sync.RWMutex
 }
 
 // NewIndexManager 创建索引管理器
 func NewIndexManager(dir string, schema *Schema) *IndexManager {
+	return NewIndexManagerWithIOMode(dir, schema, IOModeMmap)
+}
+
+// NewIndexManagerWithIOMode 按指定读取方式创建索引管理器
+func NewIndexManagerWithIOMode(dir string, schema *Schema, mode IOMode) *IndexManager {
 	mgr := &IndexManager{
 		dir:     dir,
 		schema:  schema,
 		indexes: make(map[string]*SecondaryIndex),
+		ioMode:  mode,
 	}
 
 	// 自动加载已存在的索引
@@ -436,14 +860,27 @@ func (m *IndexManager) loadExistingIndexes() error {
 			continue
 		}
 
+		// 部分索引的条件，无法读取时跳过该索引（按没有索引处理，而不是当作完整索引）
+		where, err := loadIndexWhere(m.dir, field)
+		if err != nil {
+			file.Close()
+			continue
+		}
+
 		// 创建索引对象
 		idx := &SecondaryIndex{
 			name:       field,
 			field:      field,
 			fieldType:  fieldDef.Type,
 			file:       file,
-			valueToSeq: make(map[string][]int64),
+			valueToSeq: make(map[string]*Bitmap),
+			include:    m.includeFields(fieldDef),
+			covered:    make(map[int64][]any),
 			ready:      false,
+			ioMode:     m.ioMode,
+			where:      where,
+			schema:     m.schema,
+			memory:     fieldDef.IndexMemory,
 		}
 
 		// 加载索引数据
@@ -453,6 +890,7 @@ func (m *IndexManager) loadExistingIndexes() error {
 			continue
 		}
 
+		idx.setMaintenance(fieldDef.IndexMaintenance)
 		m.indexes[field] = idx
 	}
 
@@ -461,6 +899,20 @@ func (m *IndexManager) loadExistingIndexes() error {
 
 // CreateIndex 创建索引
 func (m *IndexManager) CreateIndex(field string) error {
+	return m.createIndex(field, nil)
+}
+
+// CreateIndexWhere 创建部分索引，只为满足 where 的行建立索引
+// where 只支持内置的条件（比较、And、Or、Not），比较值只能是 nil、布尔、数值、字符串及其列表
+func (m *IndexManager) CreateIndexWhere(field string, where Expr) error {
+	if where == nil {
+		return NewErrorf(ErrCodeInvalidParam, "partial index on field %s requires a condition", field)
+	}
+	return m.createIndex(field, where)
+}
+
+// createIndex 创建索引，where 为 nil 时创建完整索引
+func (m *IndexManager) createIndex(field string, where Expr) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -475,16 +927,44 @@ func (m *IndexManager) CreateIndex(field string) error {
 		return fmt.Errorf("index on field %s already exists", field)
 	}
 
+	// 先保存部分索引的条件：索引文件存在而条件文件缺失时会被误当作完整索引
+	wherePath := indexWherePath(m.dir, field)
+	if where != nil {
+		if err := saveIndexWhere(m.dir, field, where); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(wherePath) // 之前失败的创建留下的条件
+	}
+
 	// 创建索引
 	idx, err := NewSecondaryIndex(m.dir, field, fieldDef.Type)
 	if err != nil {
+		os.Remove(wherePath)
 		return err
 	}
+	idx.ioMode = m.ioMode
+	idx.memory = fieldDef.IndexMemory
+	idx.include = m.includeFields(fieldDef)
+	idx.where = where
+	idx.schema = m.schema
+	idx.setMaintenance(fieldDef.IndexMaintenance)
 
 	m.indexes[field] = idx
 	return nil
 }
 
+// includeFields 返回字段配置的覆盖索引字段定义
+func (m *IndexManager) includeFields(field *Field) []Field {
+	var include []Field
+	for _, name := range field.IndexInclude {
+		if f, err := m.schema.GetField(name); err == nil {
+			include = append(include, *f)
+		}
+	}
+	return include
+}
+
 // DropIndex 删除索引
 func (m *IndexManager) DropIndex(field string) error {
 	m.mu.Lock()
@@ -501,8 +981,12 @@ func (m *IndexManager) DropIndex(field string) error {
 	// 关闭索引
 	idx.Close()
 
-	// 删除索引文件
+	// 删除索引文件（及部分索引的条件）
 	os.Remove(indexPath)
+	os.Remove(indexWherePath(m.dir, field))
+	for _, path := range indexSortedFiles(m.dir, field) {
+		os.Remove(path)
+	}
 
 	// 从内存中删除
 	delete(m.indexes, field)
@@ -519,17 +1003,18 @@ func (m *IndexManager) GetIndex(field string) (*SecondaryIndex, bool) {
 	return idx, exists
 }
 
-// AddToIndexes 添加到所有索引
+// AddToIndexes 添加到所有索引，异步维护的索引只放入队列
 func (m *IndexManager) AddToIndexes(data map[string]any, seq int64) error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	for field, idx := range m.indexes {
-		if value, exists := data[field]; exists {
-			err := idx.Add(value, seq)
-			if err != nil {
-				return err
-			}
+	for _, idx := range m.indexes {
+		if idx.queue != nil {
+			idx.queue.enqueue(data, seq)
+			continue
+		}
+		if err := idx.apply(data, seq); err != nil {
+			return err
 		}
 	}
 
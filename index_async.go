@@ -0,0 +1,193 @@
+package srdb
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// IndexMaintenance 写入时维护索引的方式（见 Field.IndexMaintenance）
+type IndexMaintenance int
+
+const (
+	// IndexSync 写入返回前更新索引（默认），查询总能通过索引读到已写入的行
+	IndexSync IndexMaintenance = iota
+
+	// IndexAsync 写入只把行放入索引的队列，由后台 goroutine 按写入顺序更新索引。
+	// 索引较多的表写入延迟更低，代价是查询可能暂时读不到队列中的行（通过 SecondaryIndex.Lag 查看落后程度）。
+	// 队列只在内存中：关闭表时处理完队列再持久化索引，崩溃时丢失的部分在打开表时由 VerifyAndRepair 补齐。
+	IndexAsync
+)
+
+// String 返回维护方式的名称
+func (m IndexMaintenance) String() string {
+	switch m {
+	case IndexSync:
+		return "sync"
+	case IndexAsync:
+		return "async"
+	default:
+		return "unknown"
+	}
+}
+
+// parseIndexMaintenance 解析 tag 中 maintenance: 之后的名称
+func parseIndexMaintenance(name string) (IndexMaintenance, error) {
+	switch name {
+	case "sync":
+		return IndexSync, nil
+	case "async":
+		return IndexAsync, nil
+	default:
+		return 0, fmt.Errorf("unknown index maintenance %q (expected sync or async)", name)
+	}
+}
+
+// IndexLag 异步维护的索引落后于写入的程度
+type IndexLag struct {
+	Pending    int           `json:"pending"`     // 队列中尚未加入索引的行数
+	AppliedSeq int64         `json:"applied_seq"` // 索引已处理的最大 seq
+	Age        time.Duration `json:"age"`         // 队列中最早的行已等待的时间，队列为空时为 0
+}
+
+// Lag 返回索引落后于写入的程度，同步维护的索引 Pending 与 Age 总是 0
+func (idx *SecondaryIndex) Lag() IndexLag {
+	lag := IndexLag{AppliedSeq: idx.GetMetadata().MaxSeq}
+	if q := idx.queue; q != nil {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		lag.Pending = len(q.tasks)
+		if len(q.tasks) > 0 {
+			lag.Age = time.Since(q.tasks[0].queuedAt)
+		}
+	}
+	return lag
+}
+
+// Maintenance 返回索引的维护方式
+func (idx *SecondaryIndex) Maintenance() IndexMaintenance {
+	if idx.queue != nil {
+		return IndexAsync
+	}
+	return IndexSync
+}
+
+// indexTask 等待加入异步索引的行
+type indexTask struct {
+	data     map[string]any
+	seq      int64
+	queuedAt time.Time
+}
+
+// indexQueue 异步维护的索引的写入队列，由一个后台 goroutine 按顺序处理
+type indexQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // 有新的行、处理完一行或关闭时广播
+	tasks   []indexTask
+	closed  bool
+	stopped chan struct{} // 后台 goroutine 退出时关闭
+}
+
+// setMaintenance 按维护方式启动后台 goroutine，创建或加载索引时调用
+func (idx *SecondaryIndex) setMaintenance(mode IndexMaintenance) {
+	if mode != IndexAsync || idx.queue != nil {
+		return
+	}
+	q := &indexQueue{stopped: make(chan struct{})}
+	q.cond = sync.NewCond(&q.mu)
+	idx.queue = q
+	go idx.runQueue(q)
+}
+
+// enqueue 将行放入队列，data 会被复制（调用者之后可能修改它）
+func (q *indexQueue) enqueue(data map[string]any, seq int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.tasks = append(q.tasks, indexTask{data: maps.Clone(data), seq: seq, queuedAt: time.Now()})
+	q.cond.Broadcast()
+}
+
+// runQueue 按写入顺序处理队列，行在加入索引之后才从队列中移除，使 Lag 包括正在处理的行
+func (idx *SecondaryIndex) runQueue(q *indexQueue) {
+	defer close(q.stopped)
+	for {
+		q.mu.Lock()
+		for len(q.tasks) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+		task := q.tasks[0]
+		q.mu.Unlock()
+
+		idx.apply(task.data, task.seq)
+
+		q.mu.Lock()
+		q.tasks[0] = indexTask{}
+		q.tasks = q.tasks[1:]
+		if len(q.tasks) == 0 {
+			q.tasks = nil // 释放已处理的行占用的底层数组
+		}
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// drain 等待队列中的行全部加入索引
+func (q *indexQueue) drain() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.tasks) > 0 && !q.closed {
+		q.cond.Wait()
+	}
+}
+
+// stop 停止后台 goroutine，丢弃队列中剩余的行（之后由 VerifyAndRepair 补齐）
+func (q *indexQueue) stop() {
+	q.mu.Lock()
+	q.closed = true
+	q.tasks = nil
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	<-q.stopped
+}
+
+// apply 将一行加入索引，不满足部分索引条件的行只记录已处理
+func (idx *SecondaryIndex) apply(data map[string]any, seq int64) error {
+	if !idx.matchesWhere(data) {
+		idx.skip(seq)
+		return nil
+	}
+	value, exists := data[idx.field]
+	if !exists {
+		return nil
+	}
+	if err := idx.Add(value, seq); err != nil {
+		return err
+	}
+	idx.setCovered(seq, data)
+	return nil
+}
+
+// drainQueues 等待所有异步维护的索引处理完队列
+func (m *IndexManager) drainQueues() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, idx := range m.indexes {
+		if idx.queue != nil {
+			idx.queue.drain()
+		}
+	}
+}
+
+// WaitIndexes 等待所有异步维护的索引（IndexAsync）处理完当前队列中的行，
+// 用于在需要通过索引读到刚写入的行之前调用
+func (t *Table) WaitIndexes() {
+	t.indexManager.drainQueues()
+}
@@ -0,0 +1,107 @@
+package srdb
+
+import (
+	"testing"
+)
+
+func TestIndexAsync(t *testing.T) {
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:  dir,
+		Name: "events",
+		Fields: []Field{
+			{Name: "host", Type: String, Indexed: true, IndexMaintenance: IndexAsync},
+			{Name: "level", Type: String, Indexed: true},
+		},
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 500 {
+		if err := table.Insert(map[string]any{"host": []string{"a", "b"}[i%2], "level": "info"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	host, _ := table.GetIndex("host")
+	level, _ := table.GetIndex("level")
+	if host.Maintenance() != IndexAsync || level.Maintenance() != IndexSync {
+		t.Fatalf("unexpected maintenance: %s %s", host.Maintenance(), level.Maintenance())
+	}
+	if lag := level.Lag(); lag.Pending != 0 || lag.Age != 0 || lag.AppliedSeq != 500 {
+		t.Errorf("expected sync index to have no lag, got %+v", lag)
+	}
+
+	// 等待队列处理完后索引包括所有行
+	table.WaitIndexes()
+	if lag := host.Lag(); lag.Pending != 0 || lag.Age != 0 || lag.AppliedSeq != 500 {
+		t.Errorf("expected async index to catch up, got %+v", lag)
+	}
+	if n, err := table.Query().Eq("host", "b").Count(); err != nil || n != 250 {
+		t.Errorf("expected 250 rows for host b, got %d %v", n, err)
+	}
+
+	// 修改后的值同样异步加入索引
+	if err := table.Update(2, map[string]any{"host": "c"}); err != nil {
+		t.Fatal(err)
+	}
+	table.WaitIndexes()
+	if n, err := table.Query().Eq("host", "c").Count(); err != nil || n != 1 {
+		t.Errorf("expected 1 row for host c, got %d %v", n, err)
+	}
+
+	stats := table.IndexStats()
+	for _, s := range stats {
+		if s.Async != (s.Field == "host") {
+			t.Errorf("%s: unexpected async flag %v", s.Field, s.Async)
+		}
+	}
+
+	// 关闭时处理完队列再持久化索引
+	for range 100 {
+		if err := table.Insert(map[string]any{"host": "d", "level": "warn"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err = OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+	host, _ = table.GetIndex("host")
+	if lag := host.Lag(); lag.Pending != 0 || lag.AppliedSeq != 600 {
+		t.Errorf("expected reopened index to be complete, got %+v", lag)
+	}
+	if n, err := table.Query().Eq("host", "d").Count(); err != nil || n != 100 {
+		t.Errorf("expected 100 rows for host d, got %d %v", n, err)
+	}
+}
+
+func TestIndexMaintenanceTag(t *testing.T) {
+	type Event struct {
+		Host string `srdb:"host;indexed;maintenance:async"`
+		Name string `srdb:"name"`
+	}
+	fields, err := StructToFields(Event{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields[0].IndexMaintenance != IndexAsync || fields[0].IndexMaintenance.String() != "async" {
+		t.Errorf("expected async index maintenance, got %v", fields[0].IndexMaintenance)
+	}
+
+	type Bad struct {
+		Host string `srdb:"host;indexed;maintenance:lazy"`
+	}
+	if _, err := StructToFields(Bad{}); err == nil {
+		t.Error("expected unknown index maintenance to be rejected")
+	}
+	if _, err := NewSchema("events", []Field{{Name: "host", Type: String, IndexMaintenance: IndexAsync}}); !IsError(err, ErrCodeSchemaInvalid) {
+		t.Errorf("expected index maintenance without index to be rejected, got %v", err)
+	}
+}
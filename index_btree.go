@@ -1,13 +1,12 @@
 package srdb
 
 import (
+	"bytes"
 	"crypto/md5"
 	"encoding/binary"
 	"fmt"
 	"os"
 	"sort"
-
-	"github.com/edsrzf/mmap-go"
 )
 
 /*
@@ -35,8 +34,8 @@
 │   ├─ Entry 1:                                               │
 │   │   ├─ ValueLen (4 bytes): 字段值长度                     │
 │   │   ├─ Value (N bytes): 字段值 (原始字符串)               │
-│   │   ├─ SeqCount (4 bytes): seq 数量                       │
-│   │   └─ Seqs (8 bytes each): seq 列表                     │
+│   │   ├─ PostingsLen (4 bytes): seq 位图长度                │
+│   │   └─ Postings (变长): seq 位图 (Roaring Bitmap)         │
 │   │                                                          │
 │   ├─ Entry 2: ...                                           │
 │   └─ Entry N: ...                                           │
@@ -46,7 +45,7 @@
   Offset | Size | Field          | Description
   -------|------|----------------|----------------------------------
   0      | 4    | Magic          | 0x49445842 ("IDXB")
-  4      | 4    | FormatVersion  | 文件格式版本 (1)
+  4      | 4    | FormatVersion  | 文件格式版本 (2，版本 1 仍可读取)
   8      | 8    | IndexVersion   | 索引版本号 (对应 Metadata.Version)
   16     | 8    | RootOffset     | B+Tree 根节点偏移
   24     | 8    | DataStart      | 数据块起始位置
@@ -55,15 +54,21 @@
   48     | 8    | RowCount       | 总行数
   56     | 8    | CreatedAt      | 创建时间 (UnixNano)
   64     | 8    | UpdatedAt      | 更新时间 (UnixNano)
-  72     | 184  | Reserved       | 预留空间
+  72     | 184  | Reserved       | 预留空间（覆盖索引时存放 Include 列表）
+
+Include 列表格式 (位于 Reserved，未配置覆盖索引时全为 0):
+  [Count(1)] + Count × [Type(1)][NameLen(1)][Name(NameLen bytes)]
 
 索引条目格式 (变长):
   Offset | Size        | Field      | Description
   -------|-------------|------------|----------------------------------
   0      | 4           | ValueLen   | 字段值长度 (N)
   4      | N           | Value      | 字段值 (原始字符串，用于验证哈希冲突)
-  4+N    | 4           | SeqCount   | seq 数量 (M)
-  8+N    | M * 8       | Seqs       | seq 列表 (int64 数组)
+  4+N    | 4           | PostingsLen| seq 位图长度 (M)
+  8+N    | M           | Postings   | seq 位图 (序列化格式见 Bitmap)
+  ...    | 变长        | Covered    | 覆盖索引时存在：按 seq 升序，每个 seq 依次存放
+         |             |            | 各 Include 字段的 [Size(4)][Value(Size bytes)]，
+         |             |            | Value 与行数据中的字段编码相同，Size 为 0 表示 NULL
 
 Key 生成规则:
   - 使用 MD5 哈希将字符串值转为 int64
@@ -85,24 +90,30 @@
 */
 
 const (
-	IndexHeaderSize = 256          // 索引文件头大小
-	IndexMagic      = 0x49445842   // "IDXB" - Index B-Tree
-	IndexVersion    = 1            // 文件格式版本
+	IndexHeaderSize = 256        // 索引文件头大小
+	IndexMagic      = 0x49445842 // "IDXB" - Index B-Tree
+	IndexVersion    = 2          // 文件格式版本
+
+	// indexVersionSeqList 版本 1：seq 列表存储为 [SeqCount(4)] + SeqCount × int64（只读）
+	indexVersionSeqList = 1
+
+	// indexIncludeMaxBytes Include 列表在 Header 预留空间中可用的最大字节数
+	indexIncludeMaxBytes = 184
 )
 
 // IndexHeader 索引文件头
 type IndexHeader struct {
-	Magic          uint32 // 魔数 "IDXB"
-	FormatVersion  uint32 // 文件格式版本号
-	IndexVersion   int64  // 索引版本号（对应 IndexMetadata.Version）
-	RootOffset     int64  // B+Tree 根节点偏移
-	DataStart      int64  // 数据块起始位置
-	MinSeq         int64  // 最小 seq
-	MaxSeq         int64  // 最大 seq
-	RowCount       int64  // 总行数
-	CreatedAt      int64  // 创建时间
-	UpdatedAt      int64  // 更新时间
-	Reserved       [184]byte // 预留空间（减少 8 字节给 IndexVersion，减少 8 字节调整对齐）
+	Magic         uint32    // 魔数 "IDXB"
+	FormatVersion uint32    // 文件格式版本号
+	IndexVersion  int64     // 索引版本号（对应 IndexMetadata.Version）
+	RootOffset    int64     // B+Tree 根节点偏移
+	DataStart     int64     // 数据块起始位置
+	MinSeq        int64     // 最小 seq
+	MaxSeq        int64     // 最大 seq
+	RowCount      int64     // 总行数
+	CreatedAt     int64     // 创建时间
+	UpdatedAt     int64     // 更新时间
+	Reserved      [184]byte // 预留空间（减少 8 字节给 IndexVersion，减少 8 字节调整对齐）
 }
 
 // Marshal 序列化 Header
@@ -142,6 +153,50 @@ func UnmarshalIndexHeader(data []byte) *IndexHeader {
 	return h
 }
 
+// marshalIndexInclude 将 Include 列表编码到 Header 预留空间
+func marshalIndexInclude(fields []Field) ([184]byte, error) {
+	var reserved [184]byte
+	if len(fields) == 0 {
+		return reserved, nil
+	}
+	if len(fields) > 255 {
+		return reserved, fmt.Errorf("too many included fields: %d", len(fields))
+	}
+
+	reserved[0] = byte(len(fields))
+	pos := 1
+	for _, f := range fields {
+		if pos+2+len(f.Name) > indexIncludeMaxBytes {
+			return reserved, fmt.Errorf("included fields exceed %d bytes", indexIncludeMaxBytes)
+		}
+		reserved[pos] = byte(f.Type)
+		reserved[pos+1] = byte(len(f.Name))
+		copy(reserved[pos+2:], f.Name)
+		pos += 2 + len(f.Name)
+	}
+	return reserved, nil
+}
+
+// unmarshalIndexInclude 从 Header 预留空间解码 Include 列表
+func unmarshalIndexInclude(reserved [184]byte) []Field {
+	count := int(reserved[0])
+	fields := make([]Field, 0, count)
+	pos := 1
+	for range count {
+		if pos+2 > len(reserved) {
+			return nil
+		}
+		typ := FieldType(reserved[pos])
+		nameLen := int(reserved[pos+1])
+		if pos+2+nameLen > len(reserved) {
+			return nil
+		}
+		fields = append(fields, Field{Name: string(reserved[pos+2 : pos+2+nameLen]), Type: typ})
+		pos += 2 + nameLen
+	}
+	return fields
+}
+
 // valueToKey 将字段值转换为 B+Tree key（使用哈希）
 //
 // 原理：
@@ -162,46 +217,72 @@ func valueToKey(value string) int64 {
 	return int64(binary.LittleEndian.Uint64(hash[:8]))
 }
 
-// encodeIndexEntry 将索引条目编码为二进制格式（零拷贝友好）
+// encodeIndexEntry 将索引条目编码为二进制格式
 //
-// 格式：[ValueLen(4B)][Value(N bytes)][SeqCount(4B)][Seq1(8B)][Seq2(8B)]...
+// 格式：[ValueLen(4B)][Value(N bytes)][PostingsLen(4B)][Postings(M bytes)]
 //
 // 示例：
-//   value = "Alice", seqs = [1, 5, 10]
+//   value = "Alice", seqs = {1, 5, 10}
 //   编码结果：
 //     [0x05, 0x00, 0x00, 0x00]           // ValueLen = 5
 //     [0x41, 0x6c, 0x69, 0x63, 0x65]     // "Alice"
-//     [0x03, 0x00, 0x00, 0x00]           // SeqCount = 3
-//     [0x01, 0x00, 0x00, 0x00, ...]      // Seq1 = 1
-//     [0x05, 0x00, 0x00, 0x00, ...]      // Seq2 = 5
-//     [0x0a, 0x00, 0x00, 0x00, ...]      // Seq3 = 10
+//     [0x16, 0x00, 0x00, 0x00]           // PostingsLen = 22
+//     [0x01, 0x00, 0x00, 0x00]           // 位图：1 个容器
+//     [0x00, ..., 0x02, 0x00]            // Key = 0，Cardinality-1 = 2
+//     [0x01, 0x00, 0x05, 0x00, 0x0a, 0x00] // 1, 5, 10
 //
-// 总大小：4 + 5 + 4 + 3*8 = 37 bytes
-func encodeIndexEntry(value string, seqs []int64) []byte {
-	valueBytes := []byte(value)
-	size := 4 + len(valueBytes) + 4 + len(seqs)*8
-	buf := make([]byte, size)
-
-	// 写入 ValueLen
-	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(valueBytes)))
+// 总大小：4 + 5 + 4 + 22 = 35 bytes
+func encodeIndexEntry(value string, seqs *Bitmap) []byte {
+	buf := make([]byte, 4, 4+len(value)+4+4+seqs.Cardinality()*2)
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(value)))
+	buf = append(buf, value...)
+
+	// PostingsLen 占位，写入位图后回填
+	offset := len(buf)
+	buf = binary.LittleEndian.AppendUint32(buf, 0)
+	buf = seqs.appendBinary(buf)
+	binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(len(buf)-offset-4))
+	return buf
+}
 
-	// 写入 Value
-	copy(buf[4:], valueBytes)
+// decodeIndexPostings 解码索引条目的值与 seq 位图
+//
+// 返回 rest 为 seq 列表之后的数据（覆盖索引数据）；version 为文件格式版本
+func decodeIndexPostings(data []byte, version uint32) (value string, seqs *Bitmap, rest []byte, err error) {
+	if version == indexVersionSeqList {
+		value, list, err := decodeIndexEntry(data)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		offset := 4 + len(value) + 4 + len(list)*8
+		return value, NewBitmap(list...), data[offset:], nil
+	}
 
-	// 写入 SeqCount
-	offset := 4 + len(valueBytes)
-	binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(len(seqs)))
+	if len(data) < 8 {
+		return "", nil, nil, fmt.Errorf("data too short: %d bytes", len(data))
+	}
+	valueLen := int(binary.LittleEndian.Uint32(data[0:4]))
+	if len(data) < 4+valueLen+4 {
+		return "", nil, nil, fmt.Errorf("data too short for value: expected %d, got %d", 4+valueLen+4, len(data))
+	}
+	value = string(data[4 : 4+valueLen])
 
-	// 写入 Seqs
+	offset := 4 + valueLen
+	postingsLen := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
 	offset += 4
-	for i, seq := range seqs {
-		binary.LittleEndian.PutUint64(buf[offset+i*8:offset+(i+1)*8], uint64(seq))
+	if len(data) < offset+postingsLen {
+		return "", nil, nil, fmt.Errorf("data too short for postings: expected %d, got %d", offset+postingsLen, len(data))
 	}
-
-	return buf
+	seqs = &Bitmap{}
+	if err := seqs.UnmarshalBinary(data[offset : offset+postingsLen]); err != nil {
+		return "", nil, nil, err
+	}
+	return value, seqs, data[offset+postingsLen:], nil
 }
 
-// decodeIndexEntry 从二进制格式解码索引条目（零拷贝）
+// decodeIndexEntry 从版本 1 的二进制格式解码索引条目（零拷贝）
+//
+// 格式：[ValueLen(4B)][Value(N bytes)][SeqCount(4B)][Seq1(8B)][Seq2(8B)]...
 //
 // 参数：
 //   data: 编码后的二进制数据（来自 mmap，零拷贝）
@@ -249,6 +330,62 @@ func decodeIndexEntry(data []byte) (value string, seqs []int64, err error) {
 	return value, seqs, nil
 }
 
+// encodeIndexCovered 编码覆盖索引数据，追加在索引条目之后
+//
+// covered[i] 为 seqs[i] 对应的 Include 字段值（与 include 顺序一致），nil 表示 NULL
+func encodeIndexCovered(buf *bytes.Buffer, include []Field, covered [][]any) error {
+	var fieldBuf bytes.Buffer
+	var size [4]byte
+	for _, values := range covered {
+		for i, f := range include {
+			fieldBuf.Reset()
+			if values[i] != nil {
+				if err := writeFieldBinaryValue(&fieldBuf, f.Type, values[i]); err != nil {
+					return fmt.Errorf("encode included field %s: %w", f.Name, err)
+				}
+			}
+			binary.LittleEndian.PutUint32(size[:], uint32(fieldBuf.Len()))
+			buf.Write(size[:])
+			buf.Write(fieldBuf.Bytes())
+		}
+	}
+	return nil
+}
+
+// decodeIndexCovered 解码索引条目中的覆盖索引数据（data 为 seq 列表之后的部分）
+//
+// 返回值与 seqs 对齐，每项为 Include 字段值（NULL 为 nil）
+func decodeIndexCovered(data []byte, seqCount int, include []Field) ([][]any, error) {
+	offset := 0
+	reader := bytes.NewReader(nil)
+	covered := make([][]any, seqCount)
+	for i := range covered {
+		values := make([]any, len(include))
+		for j, f := range include {
+			if offset+4 > len(data) {
+				return nil, fmt.Errorf("covered data truncated")
+			}
+			size := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+			offset += 4
+			if size == 0 {
+				continue
+			}
+			if offset+size > len(data) {
+				return nil, fmt.Errorf("covered data truncated")
+			}
+			reader.Reset(data[offset : offset+size])
+			value, err := readFieldBinaryValue(reader, f.Type, true)
+			if err != nil {
+				return nil, fmt.Errorf("decode included field %s: %w", f.Name, err)
+			}
+			values[j] = value
+			offset += size
+		}
+		covered[i] = values
+	}
+	return covered, nil
+}
+
 // IndexBTreeWriter 使用 B+Tree 写入索引
 //
 // 写入流程：
@@ -265,7 +402,9 @@ func decodeIndexEntry(data []byte) (value string, seqs []int64, err error) {
 type IndexBTreeWriter struct {
 	file       *os.File
 	header     IndexHeader
-	entries    map[string][]int64 // value -> seqs
+	entries    map[string]*Bitmap // value -> seqs
+	include    []Field            // 覆盖索引字段
+	covered    map[string][][]any // value -> 与 seqs 升序对齐的 Include 字段值
 	dataOffset int64
 }
 
@@ -283,14 +422,48 @@ func NewIndexBTreeWriter(file *os.File, metadata IndexMetadata) *IndexBTreeWrite
 			CreatedAt:     metadata.CreatedAt,
 			UpdatedAt:     metadata.UpdatedAt,
 		},
-		entries:    make(map[string][]int64),
+		entries:    make(map[string]*Bitmap),
 		dataOffset: IndexHeaderSize,
 	}
 }
 
 // Add 添加索引条目
 func (w *IndexBTreeWriter) Add(value string, seqs []int64) {
-	w.entries[value] = seqs
+	w.entries[value] = NewBitmap(seqs...)
+}
+
+// SetInclude 设置覆盖索引字段，必须在 AddCovered 之前调用
+func (w *IndexBTreeWriter) SetInclude(include []Field) error {
+	reserved, err := marshalIndexInclude(include)
+	if err != nil {
+		return err
+	}
+	w.header.Reserved = reserved
+	w.include = include
+	w.covered = make(map[string][][]any)
+	return nil
+}
+
+// AddCovered 添加带覆盖数据的索引条目，covered 与 seqs 对齐
+// 写入时按 seq 升序排列（与位图的顺序一致），重复的 seq 保留第一次出现的覆盖数据
+func (w *IndexBTreeWriter) AddCovered(value string, seqs []int64, covered [][]any) {
+	order := make([]int, len(seqs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return seqs[order[i]] < seqs[order[j]] })
+
+	bitmap := &Bitmap{}
+	sorted := make([][]any, 0, len(seqs))
+	for _, i := range order {
+		if bitmap.Contains(seqs[i]) {
+			continue
+		}
+		bitmap.Add(seqs[i])
+		sorted = append(sorted, covered[i])
+	}
+	w.entries[value] = bitmap
+	w.covered[value] = sorted
 }
 
 // Build 构建并写入索引文件
@@ -330,8 +503,15 @@ type keyOffset struct {
 		value := vk.value
 		seqs := w.entries[value]
 
-		// 编码为二进制格式
+		// 编码为二进制格式（seq 列表存储为位图）
 		binaryData := encodeIndexEntry(value, seqs)
+		if len(w.include) > 0 {
+			buf := bytes.NewBuffer(binaryData)
+			if err := encodeIndexCovered(buf, w.include, w.covered[value]); err != nil {
+				return err
+			}
+			binaryData = buf.Bytes()
+		}
 
 		// 记录 key 和数据位置（key 已经在 vk 中）
 		key := vk.key
@@ -429,14 +609,20 @@ type keyOffset struct {
 //   - B+Tree 索引：O(log n) 查询
 //   - 按需读取：只读取需要的数据块
 type IndexBTreeReader struct {
-	file   *os.File
-	mmap   mmap.MMap
-	header IndexHeader
-	btree  *BTreeReader
+	file    *os.File
+	src     dataSource // 文件数据源（mmap 或 pread）
+	header  IndexHeader
+	btree   *BTreeReader
+	include []Field // 覆盖索引字段（来自 Header）
 }
 
-// NewIndexBTreeReader 创建索引读取器
+// NewIndexBTreeReader 创建索引读取器（mmap 方式，失败时回退到 pread）
 func NewIndexBTreeReader(file *os.File) (*IndexBTreeReader, error) {
+	return NewIndexBTreeReaderWithIOMode(file, IOModeMmap)
+}
+
+// NewIndexBTreeReaderWithIOMode 按指定读取方式创建索引读取器
+func NewIndexBTreeReaderWithIOMode(file *os.File, mode IOMode) (*IndexBTreeReader, error) {
 	// 读取 Header
 	headerData := make([]byte, IndexHeaderSize)
 	_, err := file.ReadAt(headerData, 0)
@@ -448,21 +634,25 @@ func NewIndexBTreeReader(file *os.File) (*IndexBTreeReader, error) {
 	if header == nil || header.Magic != IndexMagic {
 		return nil, fmt.Errorf("invalid index file: bad magic")
 	}
+	if header.FormatVersion < indexVersionSeqList || header.FormatVersion > IndexVersion {
+		return nil, fmt.Errorf("unsupported index format version: %d", header.FormatVersion)
+	}
 
-	// mmap 整个文件
-	mmapData, err := mmap.Map(file, mmap.RDONLY, 0)
+	// 打开数据源（mmap 整个文件，或 pread）
+	src, _, err := openDataSource(file, mode)
 	if err != nil {
-		return nil, fmt.Errorf("failed to mmap index file: %w", err)
+		return nil, fmt.Errorf("failed to open index file: %w", err)
 	}
 
 	// 创建 B+Tree Reader
-	btree := NewBTreeReader(mmapData, header.RootOffset)
+	btree := newBTreeReaderFromSource(src, header.RootOffset)
 
 	return &IndexBTreeReader{
-		file:   file,
-		mmap:   mmapData,
-		header: *header,
-		btree:  btree,
+		file:    file,
+		src:     src,
+		header:  *header,
+		btree:   btree,
+		include: unmarshalIndexInclude(header.Reserved),
 	}, nil
 }
 
@@ -487,24 +677,22 @@ func NewIndexBTreeReader(file *os.File) (*IndexBTreeReader, error) {
 //   - 返回 nil（表示未找到）
 //   - 冲突概率极低（MD5 64位空间）
 func (r *IndexBTreeReader) Get(value string) ([]int64, error) {
-	// 计算 key
-	key := valueToKey(value)
-
-	// 在 B+Tree 中查找
-	dataOffset, dataSize, found := r.btree.Get(key)
-	if !found {
-		return nil, nil
+	seqs, err := r.GetBitmap(value)
+	if err != nil || seqs == nil {
+		return nil, err
 	}
+	return seqs.ToArray(), nil
+}
 
-	// 读取数据块（零拷贝）
-	if dataOffset+int64(dataSize) > int64(len(r.mmap)) {
-		return nil, fmt.Errorf("data offset out of range: offset=%d, size=%d, mmap_len=%d", dataOffset, dataSize, len(r.mmap))
+// GetBitmap 查询字段值对应的 seq 位图，未找到时返回 nil
+func (r *IndexBTreeReader) GetBitmap(value string) (*Bitmap, error) {
+	binaryData, found, err := r.lookup(value)
+	if err != nil || !found {
+		return nil, err
 	}
 
-	binaryData := r.mmap[dataOffset : dataOffset+int64(dataSize)]
-
 	// 解码二进制数据
-	storedValue, seqs, err := decodeIndexEntry(binaryData)
+	storedValue, seqs, _, err := decodeIndexPostings(binaryData, r.header.FormatVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode entry: %w", err)
 	}
@@ -518,6 +706,62 @@ func (r *IndexBTreeReader) Get(value string) ([]int64, error) {
 	return seqs, nil
 }
 
+// lookup 在 B+Tree 中查找字段值对应的数据块（mmap 模式下零拷贝）
+func (r *IndexBTreeReader) lookup(value string) ([]byte, bool, error) {
+	dataOffset, dataSize, found := r.btree.Get(valueToKey(value))
+	if !found {
+		return nil, false, nil
+	}
+	binaryData, err := r.src.Slice(dataOffset, int(dataSize))
+	if err != nil {
+		return nil, false, fmt.Errorf("data offset out of range: %w", err)
+	}
+	return binaryData, true, nil
+}
+
+// Include 返回索引文件中内联存储的字段
+func (r *IndexBTreeReader) Include() []Field {
+	return r.include
+}
+
+// GetCovered 查询字段值对应的 seq 列表及覆盖索引数据
+// covered 与 seqs 对齐，索引文件未配置覆盖字段时为 nil
+func (r *IndexBTreeReader) GetCovered(value string) (seqs []int64, covered [][]any, err error) {
+	binaryData, found, err := r.lookup(value)
+	if err != nil || !found {
+		return nil, nil, err
+	}
+
+	var storedValue string
+	var rest []byte
+	if r.header.FormatVersion == indexVersionSeqList {
+		// 版本 1 的覆盖数据与存储的 seq 列表顺序对齐
+		storedValue, seqs, err = decodeIndexEntry(binaryData)
+		if err == nil {
+			rest = binaryData[4+len(storedValue)+4+len(seqs)*8:]
+		}
+	} else {
+		var bitmap *Bitmap
+		storedValue, bitmap, rest, err = decodeIndexPostings(binaryData, r.header.FormatVersion)
+		seqs = bitmap.ToArray()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode entry: %w", err)
+	}
+	if storedValue != value {
+		// 哈希冲突
+		return nil, nil, nil
+	}
+
+	if len(r.include) > 0 {
+		covered, err = decodeIndexCovered(rest, len(seqs), r.include)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode covered data: %w", err)
+		}
+	}
+	return seqs, covered, nil
+}
+
 // GetMetadata 获取元数据
 func (r *IndexBTreeReader) GetMetadata() IndexMetadata {
 	return IndexMetadata{
@@ -538,38 +782,34 @@ func (r *IndexBTreeReader) GetMetadata() IndexMetadata {
 // ForEach 升序迭代所有索引条目
 // callback 返回 false 时停止迭代，支持提前终止
 func (r *IndexBTreeReader) ForEach(callback IndexEntryCallback) {
-	r.btree.ForEach(func(key int64, dataOffset int64, dataSize int32) bool {
-		// 读取数据块（零拷贝）
-		if dataOffset+int64(dataSize) > int64(len(r.mmap)) {
-			return false // 数据越界，停止迭代
-		}
-
-		binaryData := r.mmap[dataOffset : dataOffset+int64(dataSize)]
-
-		// 解码二进制数据
-		value, seqs, err := decodeIndexEntry(binaryData)
-		if err != nil {
-			return false // 解码失败，停止迭代
-		}
-
-		// 调用用户回调
-		return callback(value, seqs)
-	})
+	r.forEach(func(value string, seqs *Bitmap) bool {
+		return callback(value, seqs.ToArray())
+	}, false)
 }
 
 // ForEachDesc 降序迭代所有索引条目
 // callback 返回 false 时停止迭代，支持提前终止
 func (r *IndexBTreeReader) ForEachDesc(callback IndexEntryCallback) {
-	r.btree.ForEachDesc(func(key int64, dataOffset int64, dataSize int32) bool {
-		// 读取数据块（零拷贝）
-		if dataOffset+int64(dataSize) > int64(len(r.mmap)) {
+	r.forEach(func(value string, seqs *Bitmap) bool {
+		return callback(value, seqs.ToArray())
+	}, true)
+}
+
+// forEach 按 B+Tree 顺序迭代所有索引条目的 seq 位图
+func (r *IndexBTreeReader) forEach(callback func(value string, seqs *Bitmap) bool, desc bool) {
+	iterate := r.btree.ForEach
+	if desc {
+		iterate = r.btree.ForEachDesc
+	}
+	iterate(func(key int64, dataOffset int64, dataSize int32) bool {
+		// 读取数据块（mmap 模式下零拷贝）
+		binaryData, err := r.src.Slice(dataOffset, int(dataSize))
+		if err != nil {
 			return false // 数据越界，停止迭代
 		}
 
-		binaryData := r.mmap[dataOffset : dataOffset+int64(dataSize)]
-
 		// 解码二进制数据
-		value, seqs, err := decodeIndexEntry(binaryData)
+		value, seqs, _, err := decodeIndexPostings(binaryData, r.header.FormatVersion)
 		if err != nil {
 			return false // 解码失败，停止迭代
 		}
@@ -582,9 +822,9 @@ func (r *IndexBTreeReader) ForEachDesc(callback IndexEntryCallback) {
 // Close 关闭读取器
 // 注意：不关闭 file，因为它是从外部传入的，应该由调用者关闭
 func (r *IndexBTreeReader) Close() error {
-	if r.mmap != nil {
-		r.mmap.Unmap()
-		r.mmap = nil
+	if r.src != nil {
+		r.src.Close()
+		r.src = nil
 	}
 	return nil
 }
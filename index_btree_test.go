@@ -1,8 +1,11 @@
 package srdb
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"os"
+	"slices"
 	"testing"
 	"time"
 )
@@ -641,3 +644,70 @@ func TestIndexBTreeLargeData(t *testing.T) {
 
 	t.Log("Large data test successful")
 }
+
+func TestIndexBTreeReadsFormatV1(t *testing.T) {
+	file, err := os.Create(t.TempDir() + "/idx_v1.sst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// 手工写入版本 1 的索引文件：seq 列表为无序的 int64 数组，覆盖数据与之对齐
+	include := []Field{{Name: "name", Type: String}}
+	seqs := []int64{9, 2, 5}
+	var entry bytes.Buffer
+	binary.Write(&entry, binary.LittleEndian, uint32(len("active")))
+	entry.WriteString("active")
+	binary.Write(&entry, binary.LittleEndian, uint32(len(seqs)))
+	for _, seq := range seqs {
+		binary.Write(&entry, binary.LittleEndian, seq)
+	}
+	if err := encodeIndexCovered(&entry, include, [][]any{{"nine"}, {"two"}, {"five"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	dataStart := int64(IndexHeaderSize + BTreeNodeSize)
+	builder := NewBTreeBuilder(file, IndexHeaderSize)
+	if err := builder.Add(valueToKey("active"), dataStart, int32(entry.Len())); err != nil {
+		t.Fatal(err)
+	}
+	root, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reserved, err := marshalIndexInclude(include)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := IndexHeader{Magic: IndexMagic, FormatVersion: 1, RootOffset: root, DataStart: dataStart, Reserved: reserved}
+	file.WriteAt(header.Marshal(), 0)
+	file.WriteAt(entry.Bytes(), dataStart)
+
+	reader, err := NewIndexBTreeReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	got, err := reader.Get("active")
+	if err != nil || !slices.Equal(got, []int64{2, 5, 9}) {
+		t.Errorf("expected [2 5 9], got %v (err=%v)", got, err)
+	}
+	gotSeqs, covered, err := reader.GetCovered("active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, seq := range gotSeqs {
+		want := map[int64]string{2: "two", 5: "five", 9: "nine"}[seq]
+		if covered[i][0] != want {
+			t.Errorf("seq %d: expected %q, got %v", seq, want, covered[i][0])
+		}
+	}
+
+	// 不支持的版本
+	header.FormatVersion = IndexVersion + 1
+	file.WriteAt(header.Marshal(), 0)
+	if _, err := NewIndexBTreeReader(file); err == nil {
+		t.Error("expected unsupported version to fail")
+	}
+}
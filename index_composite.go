@@ -0,0 +1,193 @@
+package srdb
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 复合索引：多个字段上的等值查找
+//
+// 复合索引以计算列的形式存储：composite(a, b, ...) 把源字段的值编码为一个 String 键，
+// 在该计算列上建立普通索引。查询条件包含所有源字段的等值比较时，查询计划使用复合索引
+// （优先于单字段索引），其余条件照常在读取的行上求值；任一源字段为 NULL 的行不进入复合索引。
+//
+// 结构体中通过 tag 声明，与单字段索引可以同时存在：
+//
+//	type Place struct {
+//	    Country string `srdb:"country;indexed:geo_idx(country,city)"`
+//	    City    string `srdb:"city;indexed;indexed:geo_idx(country,city)"`
+//	}
+//
+// 生成字段 country、city（city 带单字段索引）以及复合索引的计算列 geo_idx。
+
+// compositeIndexTag tag 中声明的复合索引
+type compositeIndexTag struct {
+	name    string
+	columns []string
+}
+
+// parseCompositeIndexTag 解析 indexed: 之后的 name(a,b,...)
+//
+// 至少需要两个不重复的字段；字段名按 Schema 中的名称（即 tag 中的 field: 或转换后的名称）引用。
+func parseCompositeIndexTag(spec string) (compositeIndexTag, error) {
+	open := strings.IndexByte(spec, '(')
+	if open <= 0 || !strings.HasSuffix(spec, ")") {
+		return compositeIndexTag{}, fmt.Errorf("invalid composite index %q, expected name(field1,field2,...)", spec)
+	}
+
+	tag := compositeIndexTag{name: strings.TrimSpace(spec[:open])}
+	for column := range strings.SplitSeq(spec[open+1:len(spec)-1], ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			return compositeIndexTag{}, fmt.Errorf("composite index %s: empty field name", tag.name)
+		}
+		if slices.Contains(tag.columns, column) {
+			return compositeIndexTag{}, fmt.Errorf("composite index %s: duplicate field %s", tag.name, column)
+		}
+		tag.columns = append(tag.columns, column)
+	}
+	if len(tag.columns) < 2 {
+		return compositeIndexTag{}, fmt.Errorf("composite index %s needs at least 2 fields, use indexed for a single field", tag.name)
+	}
+	return tag, nil
+}
+
+// addCompositeIndexTag 记录 tag 中声明的复合索引，同名索引在多个字段上声明时字段列表必须相同
+func addCompositeIndexTag(tags []compositeIndexTag, tag compositeIndexTag) ([]compositeIndexTag, error) {
+	for _, existing := range tags {
+		if existing.name != tag.name {
+			continue
+		}
+		if !slices.Equal(existing.columns, tag.columns) {
+			return nil, fmt.Errorf("composite index %s declared with different fields (%s) and (%s)",
+				tag.name, strings.Join(existing.columns, ","), strings.Join(tag.columns, ","))
+		}
+		return tags, nil
+	}
+	return append(tags, tag), nil
+}
+
+// compositeIndexFields 为 tag 中声明的复合索引生成带索引的计算列
+func compositeIndexFields(tags []compositeIndexTag, fields []Field) ([]Field, error) {
+	var result []Field
+	for _, tag := range tags {
+		if slices.ContainsFunc(fields, func(f Field) bool { return f.Name == tag.name }) {
+			return nil, fmt.Errorf("composite index %s conflicts with field %s", tag.name, tag.name)
+		}
+
+		nullable := false
+		for _, column := range tag.columns {
+			i := slices.IndexFunc(fields, func(f Field) bool { return f.Name == column })
+			if i < 0 {
+				return nil, fmt.Errorf("composite index %s: field %s not found", tag.name, column)
+			}
+			nullable = nullable || fields[i].Nullable
+		}
+
+		result = append(result, Field{
+			Name:     tag.name,
+			Type:     String,
+			Indexed:  true,
+			Nullable: nullable,
+			Comment:  fmt.Sprintf("复合索引 (%s)", strings.Join(tag.columns, ", ")),
+			Computed: fmt.Sprintf("%s(%s)", computedComposite, strings.Join(tag.columns, ", ")),
+		})
+	}
+	return result, nil
+}
+
+// compositeKey 将已按 Schema 转换类型的字段值编码为复合索引的键
+//
+// 每个值格式化为字符串（时间使用 UTC 的 RFC3339Nano）后加引号，以逗号连接，不同的值组合不会得到相同的键。
+func compositeKey(values []any) string {
+	var b strings.Builder
+	for i, value := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		var s string
+		switch v := value.(type) {
+		case time.Time:
+			s = v.UTC().Format(time.RFC3339Nano)
+		default:
+			s = fmt.Sprint(v)
+		}
+		b.WriteString(strconv.Quote(s))
+	}
+	return b.String()
+}
+
+// validateComposite 验证复合键计算列：源字段必须存在、不是计算列且为标量类型
+func validateComposite(field Field, sources []string, fields []Field) error {
+	for _, source := range sources {
+		if source == field.Name {
+			return fmt.Errorf("field %s: computed field cannot reference itself", field.Name)
+		}
+		i := slices.IndexFunc(fields, func(f Field) bool { return f.Name == source })
+		if i < 0 {
+			return fmt.Errorf("field %s: source field %s not found", field.Name, source)
+		}
+		switch f := fields[i]; {
+		case f.Computed != "":
+			return fmt.Errorf("field %s: source field %s is computed", field.Name, f.Name)
+		case f.Type == Object || f.Type == Array:
+			return fmt.Errorf("field %s: %s does not support %s field %s", field.Name, computedComposite, f.Type, f.Name)
+		}
+	}
+	return nil
+}
+
+// findCompositeIndexCondition 查询条件包含某个复合索引所有源字段的等值比较时，
+// 返回该索引的字段名与等价的等值条件
+func (qb *QueryBuilder) findCompositeIndexCondition() (string, Expr) {
+	schema := qb.table.schema.Load()
+	for _, field := range schema.Fields {
+		if !field.Indexed || !strings.HasPrefix(field.Computed, computedComposite+"(") {
+			continue
+		}
+		expr, err := parseComputedExpr(field.Computed)
+		if err != nil {
+			continue
+		}
+
+		values := make([]any, 0, len(expr.sources))
+		for _, source := range expr.sources {
+			value, ok := qb.equalityValue(source)
+			if !ok {
+				break
+			}
+			values = append(values, value)
+		}
+		if len(values) != len(expr.sources) {
+			continue
+		}
+
+		if idx, exists := qb.table.indexManager.GetIndex(field.Name); exists && idx.IsReady() && idx.usableFor(qb.conds) {
+			return field.Name, compare{field: field.Name, op: "=", right: compositeKey(values)}
+		}
+	}
+	return "", nil
+}
+
+// equalityValue 返回顶层条件中字段 name 的等值比较值（已按 Schema 转换类型），没有或为 NULL 时返回 false
+func (qb *QueryBuilder) equalityValue(name string) (any, bool) {
+	field, err := qb.table.schema.Load().GetField(name)
+	if err != nil {
+		return nil, false
+	}
+	for _, cond := range qb.conds {
+		cmp, ok := cond.(compare)
+		if !ok || cmp.field != name || cmp.op != "=" || cmp.right == nil {
+			continue
+		}
+		value, err := convertValue(cmp.right, field.Type)
+		if err != nil {
+			return nil, false
+		}
+		return value, true
+	}
+	return nil, false
+}
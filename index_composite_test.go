@@ -0,0 +1,141 @@
+package srdb
+
+import (
+	"strings"
+	"testing"
+)
+
+type compositePlace struct {
+	Country string  `srdb:"country;indexed:geo_idx(country,city)"`
+	City    string  `srdb:"city;indexed;indexed:geo_idx(country,city)"`
+	Zip     *string `srdb:"zip;indexed:zip_idx(city,zip)"`
+	Name    string  `srdb:"name"`
+}
+
+func TestCompositeIndexTags(t *testing.T) {
+	fields, err := StructToFields(compositePlace{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 6 {
+		t.Fatalf("expected 4 fields and 2 composite indexes, got %+v", fields)
+	}
+	if fields[0].Indexed || !fields[1].Indexed {
+		t.Errorf("expected only city to have a single-field index: %+v %+v", fields[0], fields[1])
+	}
+	geo, zip := fields[4], fields[5]
+	if geo.Name != "geo_idx" || geo.Type != String || !geo.Indexed || geo.Nullable || geo.Computed != "composite(country, city)" {
+		t.Errorf("unexpected geo_idx %+v", geo)
+	}
+	if zip.Name != "zip_idx" || !zip.Nullable {
+		t.Errorf("composite index over a nullable field should be nullable: %+v", zip)
+	}
+
+	invalid := []any{
+		struct {
+			A string `srdb:"a;indexed:x(a)"`
+		}{},
+		struct {
+			A string `srdb:"a;indexed:x(a,a)"`
+		}{},
+		struct {
+			A string `srdb:"a;indexed:x(a,b"`
+			B string
+		}{},
+		struct {
+			A string `srdb:"a;indexed:x(a,b)"`
+			B string `srdb:"b;indexed:x(b,a)"`
+		}{},
+		struct {
+			A string `srdb:"a;indexed:x(a,missing)"`
+		}{},
+		struct {
+			A string `srdb:"a;indexed:b(a,b)"`
+			B string
+		}{},
+	}
+	for i, v := range invalid {
+		if _, err := StructToFields(v); err == nil {
+			t.Errorf("invalid tag %d: expected error", i)
+		}
+	}
+}
+
+func TestCompositeIndexQuery(t *testing.T) {
+	fields, err := StructToFields(compositePlace{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := OpenTable(&TableOptions{Dir: t.TempDir(), Name: "places", Fields: fields})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for _, p := range []compositePlace{
+		{Country: "CN", City: "Beijing", Name: "a"},
+		{Country: "CN", City: "Shanghai", Name: "b"},
+		{Country: "US", City: "Beijing", Name: "c"}, // 同名城市
+		{Country: "CN", City: "Beijing", Name: "d"},
+	} {
+		if err := table.Insert(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 等值条件覆盖复合索引的所有字段时使用复合索引
+	qb := table.Query().Eq("city", "Beijing").Eq("country", "CN")
+	if field, _ := qb.findIndexableCondition(); field != "geo_idx" {
+		t.Errorf("expected geo_idx to be chosen, got %q", field)
+	}
+	var places []compositePlace
+	if err := qb.Scan(&places); err != nil {
+		t.Fatal(err)
+	}
+	if len(places) != 2 || places[0].Name != "a" || places[1].Name != "d" {
+		t.Errorf("unexpected places %+v", places)
+	}
+
+	// 额外的条件在读取的行上求值
+	places = nil
+	if err := table.Query().Eq("country", "CN").Eq("city", "Beijing").NotEq("name", "a").Scan(&places); err != nil {
+		t.Fatal(err)
+	}
+	if len(places) != 1 || places[0].Name != "d" {
+		t.Errorf("unexpected places %+v", places)
+	}
+
+	// 只有部分字段时使用单字段索引
+	if field, _ := table.Query().Eq("city", "Beijing").findIndexableCondition(); field != "city" {
+		t.Errorf("expected city index, got %q", field)
+	}
+
+	// 源字段为 NULL 的行不进入复合索引
+	row, err := table.Query().Eq("name", "a").First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := row.Data()["zip_idx"]; ok && row.Data()["zip_idx"] != nil {
+		t.Errorf("expected no zip_idx for NULL zip, got %v", row.Data()["zip_idx"])
+	}
+	if key, _ := row.Data()["geo_idx"].(string); !strings.Contains(key, "Beijing") {
+		t.Errorf("unexpected composite key %q", key)
+	}
+}
+
+func TestCompositeKey(t *testing.T) {
+	// 值中的分隔符不会产生歧义
+	if compositeKey([]any{"a,b", "c"}) == compositeKey([]any{"a", "b,c"}) {
+		t.Error("expected distinct keys")
+	}
+	if compositeKey([]any{int64(1), true}) != `"1","true"` {
+		t.Errorf("unexpected key %s", compositeKey([]any{int64(1), true}))
+	}
+	if _, err := parseComputedExpr("composite(a)"); err == nil {
+		t.Error("expected error for a single field")
+	}
+}
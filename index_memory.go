@@ -0,0 +1,506 @@
+package srdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// IndexMemory 索引数据在内存中的表示方式（见 Field.IndexMemory）
+type IndexMemory int
+
+const (
+	// IndexMemoryHeap 所有索引条目保存在内存中的 map（默认），查询延迟最低，内存占用与索引大小成正比
+	IndexMemoryHeap IndexMemory = iota
+
+	// IndexMemoryMmap 持久化（Build）之后内存中只保留之后写入的条目：等值查询读取内存映射的
+	// B+Tree 文件，范围查询与按索引字段排序读取内存映射的有序值文件，内存中只保留栅栏指针
+	// （每 indexFenceInterval 个值一个）。每次读取都需要解码文件中的条目，延迟略高于 IndexMemoryHeap。
+	IndexMemoryMmap
+)
+
+// String 返回表示方式的名称
+func (m IndexMemory) String() string {
+	switch m {
+	case IndexMemoryHeap:
+		return "heap"
+	case IndexMemoryMmap:
+		return "mmap"
+	default:
+		return "unknown"
+	}
+}
+
+// parseIndexMemory 解析 tag 中 memory: 之后的名称
+func parseIndexMemory(name string) (IndexMemory, error) {
+	switch name {
+	case "heap":
+		return IndexMemoryHeap, nil
+	case "mmap":
+		return IndexMemoryMmap, nil
+	default:
+		return 0, fmt.Errorf("unknown index memory %q (expected heap or mmap)", name)
+	}
+}
+
+/*
+有序值文件存储格式（IndexMemoryMmap 的索引，文件名 idx_<field>.<version>.sorted）
+
+B+Tree 按值的哈希组织，无法按值的顺序读取；有序值文件按字段值升序（见 compareIndexSortValues）
+存放所有索引值，每个版本的索引文件对应一个有序值文件，由版本号关联。
+
+  ┌──────────────────────────────┐
+  │ Header (32 bytes)            │
+  ├──────────────────────────────┤
+  │ Values: [ValueLen(4)][Value] │ × Count，按字段值升序
+  ├──────────────────────────────┤
+  │ Fences: [Offset(8)]          │ × ceil(Count / indexFenceInterval)
+  └──────────────────────────────┘
+
+Header 格式:
+  Offset | Size | Field         | Description
+  -------|------|---------------|----------------------------------
+  0      | 4    | Magic         | 0x49445853 ("IDXS")
+  4      | 4    | FormatVersion | 文件格式版本 (1)
+  8      | 8    | IndexVersion  | 对应的索引版本号 (IndexMetadata.Version)
+  16     | 8    | Count         | 值的数量
+  24     | 8    | FenceStart    | 栅栏指针的起始位置
+
+栅栏指针记录第 0、indexFenceInterval、2×indexFenceInterval... 个值的偏移，打开文件时只读取栅栏指针；
+定位第 i 个值时从第 i/indexFenceInterval 个栅栏指针开始解码。
+*/
+
+const (
+	indexSortedMagic      = 0x49445853 // "IDXS"
+	indexSortedVersion    = 1
+	indexSortedHeaderSize = 32
+	indexFenceInterval    = 64 // 每个栅栏指针覆盖的值数量
+)
+
+// indexSortedPath 返回索引版本 version 对应的有序值文件路径
+func indexSortedPath(dir, field string, version int64) string {
+	return filepath.Join(dir, fmt.Sprintf("idx_%s.%d.sorted", field, version))
+}
+
+// indexSortedFiles 返回字段的所有有序值文件（索引版本 → 路径）
+func indexSortedFiles(dir, field string) map[int64]string {
+	prefix := fmt.Sprintf("idx_%s.", field)
+	paths, _ := filepath.Glob(filepath.Join(dir, prefix+"*.sorted"))
+	files := make(map[int64]string, len(paths))
+	for _, path := range paths {
+		middle := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), prefix), ".sorted")
+		if version, err := strconv.ParseInt(middle, 10, 64); err == nil {
+			files[version] = path
+		}
+	}
+	return files
+}
+
+// writeIndexSortedFile 写入有序值文件（先写入临时文件，fsync 后重命名）
+func writeIndexSortedFile(path string, indexVersion int64, values []indexSortValue) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	write := func() error {
+		fenceStart := int64(indexSortedHeaderSize)
+		for _, v := range values {
+			fenceStart += 4 + int64(len(v.raw))
+		}
+
+		w := bufio.NewWriter(file)
+		header := make([]byte, indexSortedHeaderSize)
+		binary.LittleEndian.PutUint32(header[0:4], indexSortedMagic)
+		binary.LittleEndian.PutUint32(header[4:8], indexSortedVersion)
+		binary.LittleEndian.PutUint64(header[8:16], uint64(indexVersion))
+		binary.LittleEndian.PutUint64(header[16:24], uint64(len(values)))
+		binary.LittleEndian.PutUint64(header[24:32], uint64(fenceStart))
+		w.Write(header)
+
+		var buf [8]byte
+		fences := make([]int64, 0, (len(values)+indexFenceInterval-1)/indexFenceInterval)
+		offset := int64(indexSortedHeaderSize)
+		for i, v := range values {
+			if i%indexFenceInterval == 0 {
+				fences = append(fences, offset)
+			}
+			binary.LittleEndian.PutUint32(buf[:4], uint32(len(v.raw)))
+			w.Write(buf[:4])
+			w.WriteString(v.raw)
+			offset += 4 + int64(len(v.raw))
+		}
+		for _, fence := range fences {
+			binary.LittleEndian.PutUint64(buf[:], uint64(fence))
+			w.Write(buf[:])
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return file.Sync()
+	}
+	if err := write(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// indexSortedFile 打开的有序值文件
+//
+// 查询持有的视图（indexMmapValues）在索引重新构建之后仍然可以读取旧文件：
+// 引用计数降为 0 时才解除映射，已被新版本替换的文件同时被删除。
+type indexSortedFile struct {
+	path    string
+	file    *os.File
+	src     dataSource
+	version int64   // 对应的索引版本号
+	count   int     // 值的数量
+	fences  []int64 // 栅栏指针
+	refs    atomic.Int32
+	stale   atomic.Bool // 已被新版本替换
+}
+
+// openIndexSortedFile 打开有序值文件，只读取 Header 与栅栏指针
+func openIndexSortedFile(path string, mode IOMode) (*indexSortedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	src, _, err := openDataSource(file, mode)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f := &indexSortedFile{path: path, file: file, src: src}
+	if err := f.readFences(); err != nil {
+		src.Close()
+		file.Close()
+		return nil, fmt.Errorf("invalid sorted index file %s: %w", filepath.Base(path), err)
+	}
+	f.refs.Store(1)
+	return f, nil
+}
+
+// readFences 读取 Header 与栅栏指针
+func (f *indexSortedFile) readFences() error {
+	header, err := f.src.Slice(0, indexSortedHeaderSize)
+	if err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint32(header[0:4]) != indexSortedMagic {
+		return fmt.Errorf("bad magic")
+	}
+	if version := binary.LittleEndian.Uint32(header[4:8]); version != indexSortedVersion {
+		return fmt.Errorf("unsupported format version: %d", version)
+	}
+	f.version = int64(binary.LittleEndian.Uint64(header[8:16]))
+	count := binary.LittleEndian.Uint64(header[16:24])
+	fenceStart := int64(binary.LittleEndian.Uint64(header[24:32]))
+	if fenceStart < indexSortedHeaderSize || fenceStart > f.src.Size() || count > uint64(fenceStart) {
+		return fmt.Errorf("corrupted header")
+	}
+
+	f.count = int(count)
+	n := (f.count + indexFenceInterval - 1) / indexFenceInterval
+	data, err := f.src.Slice(fenceStart, n*8)
+	if err != nil {
+		return err
+	}
+	f.fences = make([]int64, n)
+	prev := int64(indexSortedHeaderSize)
+	for i := range f.fences {
+		fence := int64(binary.LittleEndian.Uint64(data[i*8:]))
+		if fence < prev || fence >= fenceStart {
+			return fmt.Errorf("corrupted fence %d", i)
+		}
+		f.fences[i] = fence
+		prev = fence
+	}
+	return nil
+}
+
+// block 解码第 b 个栅栏指针覆盖的值
+func (f *indexSortedFile) block(b int, fieldType FieldType) ([]indexSortValue, error) {
+	return f.decode(f.fences[b], min(indexFenceInterval, f.count-b*indexFenceInterval), fieldType)
+}
+
+// decode 从 offset 开始解码 n 个值
+func (f *indexSortedFile) decode(offset int64, n int, fieldType FieldType) ([]indexSortValue, error) {
+	values := make([]indexSortValue, 0, n)
+	for range n {
+		lenData, err := f.src.Slice(offset, 4)
+		if err != nil {
+			return nil, err
+		}
+		size := int(binary.LittleEndian.Uint32(lenData))
+		raw, err := f.src.Slice(offset+4, size)
+		if err != nil {
+			return nil, err
+		}
+		value := string(raw)
+		values = append(values, indexSortValue{raw: value, key: indexSortKey(value, fieldType)})
+		offset += 4 + int64(size)
+	}
+	return values, nil
+}
+
+// search 返回第一个不小于 v 的值的位置，以及该位置的值是否等于 v
+//
+// 先按每个栅栏指针的第一个值二分查找所在的块，再在块内二分查找。
+func (f *indexSortedFile) search(v indexSortValue, fieldType FieldType) (int, bool, error) {
+	var err error
+	b := sort.Search(len(f.fences), func(b int) bool {
+		if err != nil {
+			return true
+		}
+		var first []indexSortValue
+		first, err = f.decode(f.fences[b], 1, fieldType)
+		return err != nil || compareIndexSortValues(first[0], v) > 0
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	if b == 0 {
+		return 0, false, nil
+	}
+	values, err := f.block(b-1, fieldType)
+	if err != nil {
+		return 0, false, err
+	}
+	i, found := slices.BinarySearchFunc(values, v, compareIndexSortValues)
+	return (b-1)*indexFenceInterval + i, found, nil
+}
+
+// acquire 增加引用
+func (f *indexSortedFile) acquire() {
+	f.refs.Add(1)
+}
+
+// release 释放引用，最后一个引用释放时关闭文件（已被替换时同时删除）
+func (f *indexSortedFile) release() {
+	if f.refs.Add(-1) != 0 {
+		return
+	}
+	f.src.Close()
+	f.file.Close()
+	if f.stale.Load() {
+		os.Remove(f.path)
+	}
+}
+
+// indexValues 按字段值升序排列的索引值（见 SecondaryIndex.sortedValues）
+type indexValues interface {
+	Len() int
+	// At 返回第 i 个值；读取文件失败时返回零值，之后 Err 返回该错误
+	At(i int) indexSortValue
+	Err() error
+	// release 释放视图引用的文件，之后不能再使用
+	release()
+}
+
+// indexValueSlice 内存中的有序值（IndexMemoryHeap），不会被修改，可以在不持有锁的情况下使用
+type indexValueSlice []indexSortValue
+
+func (s indexValueSlice) Len() int                { return len(s) }
+func (s indexValueSlice) At(i int) indexSortValue { return s[i] }
+func (s indexValueSlice) Err() error              { return nil }
+func (s indexValueSlice) release()                {}
+
+// indexMmapValues 有序值文件与内存中尚未持久化的值合并后的视图（IndexMemoryMmap）
+//
+// 内存中的值只包含文件中没有的值，与文件中的值交错排列：extra[k] 位于合并后的第 pos[k] 个位置。
+// 缓存最近解码的一个块，顺序访问时每个块只解码一次；视图只能在一个 goroutine 中使用。
+type indexMmapValues struct {
+	file      *indexSortedFile // nil 表示没有持久化的值
+	fieldType FieldType
+	extra     []indexSortValue
+	pos       []int
+
+	blockNum int
+	block    []indexSortValue
+	err      error
+}
+
+func (v *indexMmapValues) Len() int {
+	n := len(v.extra)
+	if v.file != nil {
+		n += v.file.count
+	}
+	return n
+}
+
+func (v *indexMmapValues) At(i int) indexSortValue {
+	k := sort.SearchInts(v.pos, i)
+	if k < len(v.pos) && v.pos[k] == i {
+		return v.extra[k]
+	}
+
+	// 文件中的第 i-k 个值
+	i -= k
+	if b := i / indexFenceInterval; b != v.blockNum {
+		block, err := v.file.block(b, v.fieldType)
+		if err != nil {
+			v.err = err
+			return indexSortValue{}
+		}
+		v.blockNum, v.block = b, block
+	}
+	return v.block[i%indexFenceInterval]
+}
+
+func (v *indexMmapValues) Err() error {
+	return v.err
+}
+
+func (v *indexMmapValues) release() {
+	if v.file != nil {
+		v.file.release()
+		v.file = nil
+	}
+}
+
+// mmapSortedValues 返回有序值文件与内存中的值合并后的视图，调用者必须持有 mu
+//
+// 有序值文件与 B+Tree 的版本不一致时（从 IndexMemoryHeap 切换、或上次写入有序值文件失败）
+// 先从 B+Tree 重新生成。
+func (idx *SecondaryIndex) mmapSortedValues() (indexValues, error) {
+	if idx.useBTree && idx.btreeReader != nil {
+		version := idx.btreeReader.header.IndexVersion
+		if idx.sortedFile == nil || idx.sortedFile.version != version {
+			var values []string
+			idx.btreeReader.ForEach(func(value string, seqs []int64) bool {
+				values = append(values, value)
+				return true
+			})
+			if err := idx.publishSorted(sortIndexValues(values, idx.fieldType), version); err != nil {
+				return nil, fmt.Errorf("failed to write sorted index values: %w", err)
+			}
+		}
+	}
+
+	view := &indexMmapValues{fieldType: idx.fieldType, blockNum: -1}
+	memory := sortIndexValues(slices.Collect(maps.Keys(idx.valueToSeq)), idx.fieldType)
+	if idx.sortedFile == nil {
+		view.extra = memory
+		for k := range memory {
+			view.pos = append(view.pos, k)
+		}
+		return view, nil
+	}
+
+	view.file = idx.sortedFile
+	view.file.acquire()
+	for _, value := range memory {
+		at, found, err := view.file.search(value, idx.fieldType)
+		if err != nil {
+			view.release()
+			return nil, err
+		}
+		if found {
+			continue // 文件中已有该值，查询时合并内存中的 seq
+		}
+		view.pos = append(view.pos, at+len(view.extra))
+		view.extra = append(view.extra, value)
+	}
+	return view, nil
+}
+
+// publishSorted 写入并打开索引版本 version 的有序值文件，替换当前文件，调用者必须持有 mu
+func (idx *SecondaryIndex) publishSorted(values []indexSortValue, version int64) error {
+	path := indexSortedPath(filepath.Dir(idx.file.Name()), idx.field, version)
+	if err := writeIndexSortedFile(path, version, values); err != nil {
+		return err
+	}
+	file, err := openIndexSortedFile(path, idx.ioMode)
+	if err != nil {
+		return err
+	}
+	idx.replaceSorted(file)
+	return nil
+}
+
+// replaceSorted 替换当前的有序值文件，旧文件在没有视图引用后删除，调用者必须持有 mu
+func (idx *SecondaryIndex) replaceSorted(file *indexSortedFile) {
+	if old := idx.sortedFile; old != nil {
+		old.stale.Store(true)
+		old.release()
+	}
+	idx.sortedFile = file
+}
+
+// loadSorted 打开与 B+Tree 版本一致的有序值文件，删除其他版本（崩溃或切换表示方式留下的文件）
+// 没有可用的文件时在第一次需要时从 B+Tree 生成，调用者必须持有 mu 或在索引发布之前调用
+func (idx *SecondaryIndex) loadSorted(version int64) {
+	for v, path := range indexSortedFiles(filepath.Dir(idx.file.Name()), idx.field) {
+		if v == version && idx.memory == IndexMemoryMmap && idx.sortedFile == nil {
+			if file, err := openIndexSortedFile(path, idx.ioMode); err == nil {
+				idx.sortedFile = file
+				continue
+			}
+		}
+		os.Remove(path)
+	}
+}
+
+// mergePersisted 将 B+Tree 中的条目合并到内存中，调用者必须持有 mu
+//
+// Build 重写整个索引文件：IndexMemoryMmap 的内存中只有上次持久化之后写入的条目，
+// IndexMemoryHeap 在重新打开后也是如此（之前的条目只在 B+Tree 中），重写之前必须先合并。
+// 覆盖数据只在文件中的 Include 字段包含当前所有 Include 字段时合并，否则保持缺失（查询回退到读取行数据）。
+func (idx *SecondaryIndex) mergePersisted() error {
+	if !idx.useBTree || idx.btreeReader == nil {
+		return nil
+	}
+	reader := idx.btreeReader
+	names := make([]string, len(idx.include))
+	for i, f := range idx.include {
+		names[i] = f.Name
+	}
+	positions, covered := includePositions(reader.Include(), names)
+	covered = covered && len(idx.include) > 0
+
+	var err error
+	reader.forEach(func(value string, seqs *Bitmap) bool {
+		idx.postings(value).Merge(seqs)
+		if !covered {
+			return true
+		}
+		var diskSeqs []int64
+		var values [][]any
+		diskSeqs, values, err = reader.GetCovered(value)
+		if err != nil {
+			return false
+		}
+		for i, seq := range diskSeqs {
+			if _, exists := idx.covered[seq]; exists || values == nil {
+				continue
+			}
+			picked := make([]any, len(positions))
+			for j, pos := range positions {
+				picked[j] = values[i][pos]
+			}
+			idx.covered[seq] = picked
+		}
+		return true
+	}, false)
+	return err
+}
@@ -0,0 +1,193 @@
+package srdb
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIndexMemoryMmap(t *testing.T) {
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:  dir,
+		Name: "events",
+		Fields: []Field{
+			{Name: "age", Type: Int64, Indexed: true, IndexMemory: IndexMemoryMmap, IndexInclude: []string{"name"}},
+			{Name: "age_heap", Type: Int64, Indexed: true},
+			{Name: "name", Type: String},
+		},
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insert := func(from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			age := int64(i % 300)
+			if err := table.Insert(map[string]any{"age": age, "age_heap": age, "name": "n"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// 多个栅栏指针覆盖的值已持久化，之后写入的值（包括新值）只在内存中
+	insert(0, 600)
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	insert(600, 1000)
+
+	compare := func(stage string) {
+		t.Helper()
+		queries := []func(f string) *QueryBuilder{
+			func(f string) *QueryBuilder { return table.Query().Eq(f, 42) },
+			func(f string) *QueryBuilder { return table.Query().Eq(f, 299) },
+			func(f string) *QueryBuilder { return table.Query().In(f, []any{7, 150, 280}) },
+			func(f string) *QueryBuilder { return table.Query().Gte(f, 60).Lt(f, 200) },
+			func(f string) *QueryBuilder { return table.Query().Gt(f, 250) },
+			func(f string) *QueryBuilder { return table.Query().OrderBy(f).Limit(300) },
+			func(f string) *QueryBuilder { return table.Query().OrderByDesc(f) },
+		}
+		for i, query := range queries {
+			want, err := query("age_heap").Seqs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := query("age").Seqs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(want) == 0 || !slices.Equal(got, want) {
+				t.Errorf("%s: query %d returned %d seqs, heap index %d", stage, i, len(got), len(want))
+			}
+		}
+	}
+	compare("partially built")
+
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	compare("built")
+
+	// 持久化之后内存中只有栅栏指针
+	var mmapBytes, heapBytes int64
+	for _, stats := range table.IndexStats() {
+		switch stats.Field {
+		case "age":
+			mmapBytes = stats.MemoryBytes
+			if stats.Entries != 1000 || stats.DistinctKeys != 300 {
+				t.Errorf("unexpected mmap index stats %+v", stats)
+			}
+		case "age_heap":
+			heapBytes = stats.MemoryBytes
+		}
+	}
+	if mmapBytes == 0 || mmapBytes*10 > heapBytes {
+		t.Errorf("expected the mmap index to use far less memory, got %d vs %d", mmapBytes, heapBytes)
+	}
+	idx, _ := table.GetIndex("age")
+	if covered, ok, err := idx.GetCovered(int64(5), []string{"name"}); err != nil || !ok || len(covered) == 0 {
+		t.Errorf("expected covered entries from the mmap index, got %v %v %v", len(covered), ok, err)
+	}
+	sorted, _ := filepath.Glob(filepath.Join(dir, "idx", "idx_age.*.sorted"))
+	if len(sorted) != 1 {
+		t.Errorf("expected a single sorted values file, got %v", sorted)
+	}
+
+	// 重新打开后再次构建不丢失已持久化的条目
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if table, err = OpenTable(opts); err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+	insert(1000, 1100)
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	compare("reopened")
+	if seqs, _ := table.Query().Eq("age", 10).Seqs(); len(seqs) != 4 {
+		t.Errorf("expected 4 rows with age 10 after rebuild, got %d", len(seqs))
+	}
+
+	// 删除索引时删除有序值文件
+	if err := table.indexManager.DropIndex("age"); err != nil {
+		t.Fatal(err)
+	}
+	if sorted, _ := filepath.Glob(filepath.Join(dir, "idx", "idx_age.*")); len(sorted) != 0 {
+		t.Errorf("expected index files to be removed, got %v", sorted)
+	}
+}
+
+func TestIndexSortedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idx_v.1.sorted")
+	var raws []string
+	for i := range 200 {
+		raws = append(raws, string(rune('a'+i%26))+string(rune('a'+i/26)))
+	}
+	values := sortIndexValues(raws, String)
+	if err := writeIndexSortedFile(path, 7, values); err != nil {
+		t.Fatal(err)
+	}
+	file, err := openIndexSortedFile(path, IOModeMmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.release()
+	if file.version != 7 || file.count != 200 || len(file.fences) != 4 {
+		t.Fatalf("unexpected header: version %d count %d fences %d", file.version, file.count, len(file.fences))
+	}
+
+	for i, v := range values {
+		at, found, err := file.search(v, String)
+		if err != nil || !found || at != i {
+			t.Fatalf("search %q: expected %d, got %d %v %v", v.raw, i, at, found, err)
+		}
+	}
+	if at, found, _ := file.search(indexSortValue{raw: "zz"}, String); found || at != 200 {
+		t.Errorf("expected a missing value after all values at 200, got %d %v", at, found)
+	}
+
+	// 合并内存中的值：已在文件中的值只出现一次
+	view := &indexMmapValues{file: file, fieldType: String, blockNum: -1}
+	view.extra = []indexSortValue{{raw: "0"}, {raw: "ab0"}}
+	view.pos = []int{0, 1 + 1 + 1}
+	if view.Len() != 202 || view.At(0).raw != "0" || view.At(1).raw != values[0].raw || view.At(3).raw != "ab0" || view.At(201).raw != values[199].raw {
+		t.Errorf("unexpected merged view")
+	}
+
+	// 栅栏指针越界
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] = 0xff
+	os.WriteFile(path, data, 0644)
+	if _, err := openIndexSortedFile(path, IOModePread); err == nil {
+		t.Error("expected corrupted fences to be rejected")
+	}
+}
+
+func TestIndexMemoryTag(t *testing.T) {
+	type Event struct {
+		Age  int64  `srdb:"age;indexed;memory:mmap"`
+		Name string `srdb:"name"`
+	}
+	fields, err := StructToFields(Event{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields[0].IndexMemory != IndexMemoryMmap || fields[0].IndexMemory.String() != "mmap" {
+		t.Errorf("expected mmap index memory, got %v", fields[0].IndexMemory)
+	}
+
+	type Bad struct {
+		Age int64 `srdb:"age;indexed;memory:disk"`
+	}
+	if _, err := StructToFields(Bad{}); err == nil {
+		t.Error("expected unknown index memory to be rejected")
+	}
+	if _, err := NewSchema("events", []Field{{Name: "age", Type: Int64, IndexMemory: IndexMemoryMmap}}); err == nil {
+		t.Error("expected index memory without index to be rejected")
+	}
+}
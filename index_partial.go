@@ -0,0 +1,228 @@
+package srdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// 部分索引：只为满足条件的行建立索引
+//
+// 条件保存在索引文件旁的 idx_<field>.where（JSON），重新打开表时随索引一起加载。
+// 部分索引不包含不满足条件的行，因此只有查询条件包含索引条件的每一项时
+// （按 AND 展开后逐项相同）才会使用该索引，否则按没有索引处理。
+
+// indexWherePath 返回部分索引条件文件的路径
+func indexWherePath(dir, field string) string {
+	return filepath.Join(dir, fmt.Sprintf("idx_%s.where", field))
+}
+
+// exprJSON 可序列化的条件表达式
+type exprJSON struct {
+	Op    string     `json:"op"` // 比较运算符，或 AND、OR、NOT
+	Field string     `json:"field,omitempty"`
+	Value any        `json:"value,omitempty"`
+	Exprs []exprJSON `json:"exprs,omitempty"`
+}
+
+// encodeExpr 将条件转换为可序列化的形式
+// 只支持内置的条件（比较、And、Or、Not），比较值只能是 nil、布尔、数值、字符串及其列表
+func encodeExpr(expr Expr) (exprJSON, error) {
+	switch e := expr.(type) {
+	case compare:
+		if !isPlainValue(e.right) {
+			return exprJSON{}, NewErrorf(ErrCodeInvalidParam, "unsupported value type %T in index condition on field %s", e.right, e.field)
+		}
+		return exprJSON{Op: e.op, Field: e.field, Value: e.right}, nil
+	case group:
+		out := exprJSON{Op: "OR"}
+		if e.and {
+			out.Op = "AND"
+		}
+		for _, sub := range e.exprs {
+			j, err := encodeExpr(sub)
+			if err != nil {
+				return exprJSON{}, err
+			}
+			out.Exprs = append(out.Exprs, j)
+		}
+		return out, nil
+	case Neginative:
+		out := exprJSON{Op: "NOT"}
+		if e.expr != nil {
+			j, err := encodeExpr(e.expr)
+			if err != nil {
+				return exprJSON{}, err
+			}
+			out.Exprs = []exprJSON{j}
+		}
+		return out, nil
+	case Filter:
+		return encodeExpr(group{e.exprs, true})
+	}
+	return exprJSON{}, NewErrorf(ErrCodeInvalidParam, "unsupported index condition type %T", expr)
+}
+
+// decodeExpr 从序列化的形式还原条件
+func decodeExpr(j exprJSON) (Expr, error) {
+	switch j.Op {
+	case "":
+		return nil, fmt.Errorf("index condition is missing op")
+	case "AND", "OR":
+		exprs := make([]Expr, len(j.Exprs))
+		for i, sub := range j.Exprs {
+			expr, err := decodeExpr(sub)
+			if err != nil {
+				return nil, err
+			}
+			exprs[i] = expr
+		}
+		return group{exprs, j.Op == "AND"}, nil
+	case "NOT":
+		if len(j.Exprs) == 0 {
+			return Neginative{}, nil
+		}
+		expr, err := decodeExpr(j.Exprs[0])
+		if err != nil {
+			return nil, err
+		}
+		return Neginative{expr}, nil
+	}
+	return compare{j.Field, j.Op, j.Value}, nil
+}
+
+// isPlainValue 值能否通过 JSON 还原后保持比较语义
+func isPlainValue(v any) bool {
+	switch val := v.(type) {
+	case nil, bool, string:
+		return true
+	case []any:
+		for _, item := range val {
+			if !isPlainValue(item) {
+				return false
+			}
+		}
+		return true
+	case time.Duration:
+		return false // JSON 中是数值，但比较时类型不同
+	}
+	_, ok := toFloat64(v)
+	return ok
+}
+
+// saveIndexWhere 原子写入部分索引条件
+func saveIndexWhere(dir, field string, where Expr) error {
+	encoded, err := encodeExpr(where)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return NewErrorf(ErrCodeInvalidParam, "failed to encode index condition: %v", err)
+	}
+
+	path := indexWherePath(dir, field)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// loadIndexWhere 读取部分索引条件，文件不存在时返回 nil（完整索引）
+func loadIndexWhere(dir, field string) (Expr, error) {
+	data, err := os.ReadFile(indexWherePath(dir, field))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var j exprJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("failed to decode index condition: %w", err)
+	}
+	return decodeExpr(j)
+}
+
+// matchesWhere 行是否应该加入索引（完整索引总是加入）
+func (idx *SecondaryIndex) matchesWhere(data map[string]any) bool {
+	return idx.where == nil || idx.where.Match(newMapFieldset(data, idx.schema))
+}
+
+// usableFor 索引能否用于带有 conds 条件的查询
+// 部分索引要求查询条件包含索引条件的每一项，否则会遗漏不在索引中的行
+func (idx *SecondaryIndex) usableFor(conds []Expr) bool {
+	if idx.where == nil {
+		return true
+	}
+	have := conjuncts(conds)
+	for _, need := range conjuncts([]Expr{idx.where}) {
+		if !slices.ContainsFunc(have, func(expr Expr) bool { return sameExpr(expr, need) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// Where 返回部分索引的条件，完整索引返回 nil
+func (idx *SecondaryIndex) Where() Expr {
+	return idx.where
+}
+
+// conjuncts 展开 And，返回必须同时满足的条件列表
+func conjuncts(exprs []Expr) []Expr {
+	var result []Expr
+	for _, expr := range exprs {
+		if g, ok := expr.(group); ok && g.and {
+			result = append(result, conjuncts(g.exprs)...)
+		} else if f, ok := expr.(Filter); ok {
+			result = append(result, conjuncts(f.exprs)...)
+		} else {
+			result = append(result, expr)
+		}
+	}
+	return result
+}
+
+// sameExpr 两个条件是否相同（数值按大小比较，int64(1) 与 float64(1) 相同）
+func sameExpr(a, b Expr) bool {
+	switch x := a.(type) {
+	case compare:
+		y, ok := b.(compare)
+		return ok && x.field == y.field && x.op == y.op && sameValue(x.right, y.right)
+	case group:
+		y, ok := b.(group)
+		if !ok || x.and != y.and || len(x.exprs) != len(y.exprs) {
+			return false
+		}
+		for i := range x.exprs {
+			if !sameExpr(x.exprs[i], y.exprs[i]) {
+				return false
+			}
+		}
+		return true
+	case Neginative:
+		y, ok := b.(Neginative)
+		if !ok {
+			return false
+		}
+		if x.expr == nil || y.expr == nil {
+			return x.expr == nil && y.expr == nil
+		}
+		return sameExpr(x.expr, y.expr)
+	}
+	return false
+}
+
+func sameValue(a, b any) bool {
+	x, aList := a.([]any)
+	y, bList := b.([]any)
+	if aList || bList {
+		return aList && bList && slices.EqualFunc(x, y, sameValue)
+	}
+	return compareEqual(a, b)
+}
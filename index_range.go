@@ -0,0 +1,172 @@
+package srdb
+
+import (
+	"cmp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// indexRange 索引字段上的取值范围，由同一字段上的所有 >、>=、<、<=、BETWEEN 条件合并而成
+//
+// 边界的比较方式与查询条件一致：数值字段按 float64 比较，字符串字段按字典序比较；
+// 其他类型的字段无法定位范围，仍然遍历所有索引值。
+type indexRange struct {
+	typ          FieldType // 字段类型
+	numeric      bool      // 数值字段（边界为 float64），否则为字符串字段（边界为 string）
+	lower, upper any       // nil 表示不限
+	lowerOpen    bool      // 不包含下界
+	upperOpen    bool      // 不包含上界
+}
+
+// indexRangeFor 合并 field 上的范围条件，字段类型或条件的值无法定位范围时返回 false
+func (qb *QueryBuilder) indexRangeFor(field string) (indexRange, bool) {
+	f, err := qb.table.schema.Load().GetField(field)
+	if err != nil {
+		return indexRange{}, false
+	}
+	r := indexRange{typ: f.Type, numeric: isNumericFieldType(f.Type)}
+	if !r.numeric && f.Type != String {
+		return indexRange{}, false
+	}
+
+	found := false
+	for _, cond := range qb.conds {
+		c, ok := cond.(compare)
+		if !ok || c.field != field {
+			continue
+		}
+		switch c.op {
+		case ">", ">=", "<", "<=":
+			if !r.narrow(c.op, c.right) {
+				return indexRange{}, false
+			}
+		case "BETWEEN":
+			list, ok := c.right.([]any)
+			if !ok || len(list) != 2 || !r.narrow(">=", list[0]) || !r.narrow("<=", list[1]) {
+				return indexRange{}, false
+			}
+		default:
+			continue
+		}
+		found = true
+	}
+	return r, found
+}
+
+// narrow 用一个条件收窄范围，值的类型与字段不匹配时返回 false
+func (r *indexRange) narrow(op string, value any) bool {
+	bound, ok := r.bound(value)
+	if !ok {
+		return false
+	}
+	open := op == ">" || op == "<"
+	if op == ">" || op == ">=" {
+		if r.lower == nil {
+			r.lower, r.lowerOpen = bound, open
+		} else if c := r.compare(bound, r.lower); c > 0 || (c == 0 && open) {
+			r.lower, r.lowerOpen = bound, open
+		}
+		return true
+	}
+	if r.upper == nil {
+		r.upper, r.upperOpen = bound, open
+	} else if c := r.compare(bound, r.upper); c < 0 || (c == 0 && open) {
+		r.upper, r.upperOpen = bound, open
+	}
+	return true
+}
+
+// bound 将条件的值转换为边界（float64 或 string）
+func (r *indexRange) bound(value any) (any, bool) {
+	if r.numeric {
+		return toFloat64(value)
+	}
+	s, ok := value.(string)
+	return s, ok
+}
+
+// key 将索引值的排序键转换为边界的类型，无法转换时返回 false
+func (r *indexRange) key(v indexSortValue) (any, bool) {
+	if !r.numeric {
+		return v.raw, true
+	}
+	if r.typ == Float32 {
+		// 与查询条件比较的是 float32 值转换后的 float64（如 0.1 为 0.10000000149011612）
+		key, err := strconv.ParseFloat(v.raw, 32)
+		return key, err == nil
+	}
+	switch key := v.key.(type) {
+	case int64:
+		return float64(key), true
+	case uint64:
+		return float64(key), true
+	case float64:
+		return key, true
+	}
+	return nil, false
+}
+
+// compare 比较两个同类型的边界
+func (r *indexRange) compare(a, b any) int {
+	if r.numeric {
+		return cmp.Compare(a.(float64), b.(float64))
+	}
+	return strings.Compare(a.(string), b.(string))
+}
+
+// rangeBitmap 返回字段值在范围内的 seq 位图
+//
+// 在按字段值排序的索引值（见 sortedValues）中二分查找范围的起止位置，只读取范围内的值，
+// 代价为 O(log M + K)，M 为唯一值数量，K 为范围内的值数量。
+func (idx *SecondaryIndex) rangeBitmap(r indexRange) (*Bitmap, error) {
+	values, err := idx.sortedValues()
+	if err != nil {
+		return nil, err
+	}
+	defer values.release()
+
+	// 无法解析的值（数值字段中的异常数据）视为小于所有边界
+	compareAt := func(i int, bound any) int {
+		key, ok := r.key(values.At(i))
+		if !ok {
+			return -1
+		}
+		return r.compare(key, bound)
+	}
+	start, end := 0, values.Len()
+	if r.lower != nil {
+		start = sort.Search(values.Len(), func(i int) bool {
+			c := compareAt(i, r.lower)
+			return c > 0 || (c == 0 && !r.lowerOpen)
+		})
+	}
+	if r.upper != nil {
+		end = sort.Search(values.Len(), func(i int) bool {
+			c := compareAt(i, r.upper)
+			return c > 0 || (c == 0 && r.upperOpen)
+		})
+	}
+
+	matched := &Bitmap{}
+	for i := start; i < end; i++ {
+		seqs, err := idx.GetBitmap(values.At(i).raw)
+		if err != nil {
+			return nil, err
+		}
+		matched.Merge(seqs)
+	}
+	if err := values.Err(); err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// isNumericFieldType 字段是否为整数或浮点类型
+func isNumericFieldType(typ FieldType) bool {
+	switch typ {
+	case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Byte, Rune, Float32, Float64:
+		return true
+	}
+	return false
+}
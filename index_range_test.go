@@ -0,0 +1,123 @@
+package srdb
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestIndexRangeQuery(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "metrics",
+		Fields: []Field{
+			{Name: "age", Type: Int64, Indexed: true},
+			{Name: "age_copy", Type: Int64},
+			{Name: "name", Type: String, Indexed: true},
+			{Name: "name_copy", Type: String},
+			{Name: "score", Type: Float32, Indexed: true},
+			{Name: "score_copy", Type: Float32},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	insert := func(from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			name := fmt.Sprintf("n%02d", i%30)
+			score := float32(i%10) / 10
+			if err := table.Insert(map[string]any{
+				"age": int64(i % 20), "age_copy": int64(i % 20),
+				"name": name, "name_copy": name,
+				"score": score, "score_copy": score,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// 一部分条目已持久化，一部分只在内存中
+	insert(0, 100)
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	insert(100, 150)
+
+	cases := []struct {
+		name  string
+		query func(field string, suffix string) *QueryBuilder
+	}{
+		{"gt", func(f, s string) *QueryBuilder { return table.Query().Gt(f+s, 15) }},
+		{"gte", func(f, s string) *QueryBuilder { return table.Query().Gte(f+s, 15) }},
+		{"lt", func(f, s string) *QueryBuilder { return table.Query().Lt(f+s, 3) }},
+		{"lte", func(f, s string) *QueryBuilder { return table.Query().Lte(f+s, 3) }},
+		{"between", func(f, s string) *QueryBuilder { return table.Query().Between(f+s, 5, 8) }},
+		{"float bound", func(f, s string) *QueryBuilder { return table.Query().Gt(f+s, 4.5) }},
+		{"combined", func(f, s string) *QueryBuilder { return table.Query().Gte(f+s, 4).Lt(f+s, 10).Gt(f+s, 6) }},
+		{"empty", func(f, s string) *QueryBuilder { return table.Query().Gt(f+s, 10).Lt(f+s, 5) }},
+		{"with other condition", func(f, s string) *QueryBuilder { return table.Query().Gt(f+s, 10).NotEq("name_copy", "n11") }},
+	}
+	for _, tc := range cases {
+		want, err := tc.query("age", "_copy").Seqs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := tc.query("age", "").Seqs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: index returned %d seqs, full scan %d", tc.name, len(got), len(want))
+		}
+	}
+
+	// 字符串按字典序，float32 按查询条件的比较方式（float32 转换为 float64）
+	stringQueries := []func(f string) *QueryBuilder{
+		func(f string) *QueryBuilder { return table.Query().Gte(f, "n10").Lt(f, "n20") },
+		func(f string) *QueryBuilder { return table.Query().Between(f, "n05", "n1") },
+		func(f string) *QueryBuilder { return table.Query().Gt(f, "n2") },
+	}
+	floatQueries := []func(f string) *QueryBuilder{
+		func(f string) *QueryBuilder { return table.Query().Gt(f, 0.1) },
+		func(f string) *QueryBuilder { return table.Query().Lte(f, 0.3) },
+		func(f string) *QueryBuilder { return table.Query().Between(f, 0.2, float32(0.5)) },
+	}
+	for i, query := range stringQueries {
+		want, _ := query("name_copy").Seqs()
+		got, _ := query("name").Seqs()
+		if len(want) == 0 || !slices.Equal(got, want) {
+			t.Errorf("string range %d: index returned %d seqs, full scan %d", i, len(got), len(want))
+		}
+	}
+	for i, query := range floatQueries {
+		want, _ := query("score_copy").Seqs()
+		got, _ := query("score").Seqs()
+		if len(want) == 0 || !slices.Equal(got, want) {
+			t.Errorf("float range %d: index returned %d seqs, full scan %d", i, len(got), len(want))
+		}
+	}
+
+	// 范围内的值直接定位：只读取范围内的索引值
+	idx, _ := table.GetIndex("age")
+	r, ok := table.Query().Gte("age", 4).Lt("age", 6).indexRangeFor("age")
+	if !ok {
+		t.Fatal("expected a seekable range")
+	}
+	matched, err := idx.rangeBitmap(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := matched.Cardinality(); n != 16 {
+		t.Errorf("expected 16 seqs with age in [4, 6), got %d", n)
+	}
+	if _, ok := table.Query().Gt("age", "x").indexRangeFor("age"); ok {
+		t.Error("expected a string bound on a numeric field to fall back")
+	}
+
+	// 等值条件优先于范围条件
+	if field, _ := table.Query().Gt("age", 1).Eq("name", "n01").findIndexableCondition(); field != "name" {
+		t.Errorf("expected the equality index to be chosen, got %q", field)
+	}
+}
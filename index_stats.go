@@ -0,0 +1,149 @@
+package srdb
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// IndexStats 索引的大小与基数统计，用于发现体积过大或选择性差的索引
+type IndexStats struct {
+	Field        string    `json:"field"`
+	Ready        bool      `json:"ready"`             // 索引是否就绪，未就绪时 Entries、DistinctKeys 为 0
+	Entries      int64     `json:"entries"`           // 索引条目数（被索引的行数）
+	DistinctKeys int64     `json:"distinct_keys"`     // 不同的索引值数量
+	DiskBytes    int64     `json:"disk_bytes"`        // 索引文件大小
+	MemoryBytes  int64     `json:"memory_bytes"`      // 内存中的索引数据（值 → seq 位图、覆盖字段、排序缓存、栅栏指针）的估算大小
+	LastBuild    time.Time `json:"last_build"`        // 最后一次持久化（Build）的时间，零值表示尚未持久化
+	Partial      bool      `json:"partial,omitempty"` // 是否为部分索引（CreateIndexWhere）
+	Include      []string  `json:"include,omitempty"` // 覆盖索引字段
+	Async        bool      `json:"async,omitempty"`   // 是否异步维护（IndexAsync），落后程度见 SecondaryIndex.Lag
+}
+
+// Selectivity 返回 DistinctKeys / Entries，越接近 0 说明每个值对应的行越多（如状态字段），
+// 越接近 1 说明值越唯一；没有条目时返回 0
+func (s IndexStats) Selectivity() float64 {
+	if s.Entries == 0 {
+		return 0
+	}
+	return float64(s.DistinctKeys) / float64(s.Entries)
+}
+
+// Stats 统计索引的条目数、不同值数量与占用的空间
+//
+// 条目数与不同值数量需要遍历所有索引值（已持久化与内存中的合并计算），开销与索引大小成正比。
+func (idx *SecondaryIndex) Stats() IndexStats {
+	var entries, distinct int64
+	// 未就绪时 forEach 返回错误，条目数保持为 0
+	idx.forEach(func(value string, seqs *Bitmap) bool {
+		entries += int64(seqs.Cardinality())
+		distinct++
+		return true
+	}, false)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	stats := IndexStats{
+		Field:        idx.field,
+		Ready:        idx.ready,
+		Entries:      entries,
+		DistinctKeys: distinct,
+		MemoryBytes:  idx.memorySize(),
+		Partial:      idx.where != nil,
+		Async:        idx.queue != nil,
+	}
+	if info, err := idx.file.Stat(); err == nil {
+		stats.DiskBytes = info.Size()
+	}
+	if idx.builtAt > 0 {
+		stats.LastBuild = time.Unix(0, idx.builtAt)
+	}
+	for _, field := range idx.include {
+		stats.Include = append(stats.Include, field.Name)
+	}
+	return stats
+}
+
+// memorySize 估算内存中索引数据的大小，调用者必须持有 mu
+//
+// 只计算随数据增长的部分（map 按每项固定开销估算），不包括 B+Tree 与有序值文件的 mmap 映射。
+func (idx *SecondaryIndex) memorySize() int64 {
+	const mapEntryOverhead = 48
+
+	var size int64
+	for value, seqs := range idx.valueToSeq {
+		size += mapEntryOverhead + int64(len(value)) + seqs.memorySize()
+	}
+	for _, values := range idx.covered {
+		size += mapEntryOverhead + int64(cap(values))*16
+	}
+	for _, v := range idx.sorted {
+		size += 32 + int64(len(v.raw))
+	}
+	for _, value := range idx.unsorted {
+		size += 16 + int64(len(value))
+	}
+	if idx.sortedFile != nil {
+		size += int64(len(idx.sortedFile.fences)) * 8
+	}
+	return size
+}
+
+// Stats 返回所有索引的统计，按字段名排序
+func (m *IndexManager) Stats() []IndexStats {
+	m.mu.RLock()
+	indexes := make([]*SecondaryIndex, 0, len(m.indexes))
+	for _, idx := range m.indexes {
+		indexes = append(indexes, idx)
+	}
+	m.mu.RUnlock()
+
+	stats := make([]IndexStats, 0, len(indexes))
+	for _, idx := range indexes {
+		stats = append(stats, idx.Stats())
+	}
+	slices.SortFunc(stats, func(a, b IndexStats) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return stats
+}
+
+// refreshSorted 合并写入后积累的新值到有序值缓存（尚未构建缓存的索引不处理）
+func (idx *SecondaryIndex) refreshSorted() error {
+	idx.mu.RLock()
+	pending := idx.ready && idx.sortedBuilt && len(idx.unsorted) > 0
+	idx.mu.RUnlock()
+
+	if !pending {
+		return nil
+	}
+	_, err := idx.sortedValues()
+	return err
+}
+
+// RefreshStats 刷新查询使用的索引元数据
+//
+// 查询规划不缓存统计信息：是否使用索引只取决于索引是否就绪，每次查询时判断；
+// 索引按 seq 记录行，flush 与 Compaction 不会使其失效。唯一的缓存是按索引字段 OrderBy
+// 使用的有序值，写入时只记录新值，下一次使用时再排序合并。批量导入大量新值后调用 RefreshStats
+// 立即完成合并，避免由之后的第一次查询承担这部分开销。
+func (t *Table) RefreshStats() error {
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	t.indexManager.mu.RLock()
+	indexes := slices.Collect(maps.Values(t.indexManager.indexes))
+	t.indexManager.mu.RUnlock()
+
+	for _, idx := range indexes {
+		if err := idx.refreshSorted(); err != nil {
+			return WrapError(err, "refresh index %s", idx.field)
+		}
+	}
+	return nil
+}
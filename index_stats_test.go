@@ -0,0 +1,183 @@
+package srdb
+
+import (
+	"testing"
+)
+
+func TestIndexStats(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "users",
+		Fields: []Field{
+			{Name: "status", Type: String, Indexed: true},
+			{Name: "email", Type: String, Indexed: true},
+			{Name: "age", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	statuses := []string{"active", "inactive", "banned"}
+	for i := range 30 {
+		if err := table.Insert(map[string]any{
+			"status": statuses[i%3],
+			"email":  "user" + string(rune('a'+i%26)) + string(rune('0'+i/26)) + "@example.com",
+			"age":    int64(20 + i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 一部分持久化、一部分只在内存中
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"status": "deleted", "email": "late@example.com", "age": int64(99)}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := table.IndexStats()
+	if len(stats) != 2 || stats[0].Field != "email" || stats[1].Field != "status" {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	email, status := stats[0], stats[1]
+	if !status.Ready || status.Entries != 31 || status.DistinctKeys != 4 {
+		t.Errorf("status: entries=%d distinct=%d ready=%v", status.Entries, status.DistinctKeys, status.Ready)
+	}
+	if email.Entries != 31 || email.DistinctKeys != 31 || email.Selectivity() != 1 {
+		t.Errorf("email: entries=%d distinct=%d", email.Entries, email.DistinctKeys)
+	}
+	if status.Selectivity() >= email.Selectivity() {
+		t.Errorf("status selectivity %f should be lower than email %f", status.Selectivity(), email.Selectivity())
+	}
+	for _, s := range stats {
+		if s.DiskBytes <= 0 || s.MemoryBytes <= 0 {
+			t.Errorf("%s: disk=%d memory=%d", s.Field, s.DiskBytes, s.MemoryBytes)
+		}
+		if s.LastBuild.IsZero() {
+			t.Errorf("%s: LastBuild not set", s.Field)
+		}
+		if s.Partial || len(s.Include) != 0 {
+			t.Errorf("%s: unexpected partial=%v include=%v", s.Field, s.Partial, s.Include)
+		}
+	}
+}
+
+func TestIndexStatsPartialAndReload(t *testing.T) {
+	dir := t.TempDir()
+	fields := []Field{
+		{Name: "email", Type: String},
+		{Name: "name", Type: String},
+		{Name: "active", Type: Bool},
+	}
+	table, err := OpenTable(&TableOptions{Dir: dir, Name: "users", Fields: fields})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		if err := table.Insert(map[string]any{
+			"email":  string(rune('a'+i)) + "@example.com",
+			"name":   "n",
+			"active": i%2 == 0,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.CreateIndexWhere("email", Eq("active", true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+
+	before := table.IndexStats()
+	if len(before) != 1 || !before[0].Partial || before[0].Entries != 5 || before[0].DistinctKeys != 5 {
+		t.Fatalf("stats = %+v", before)
+	}
+	table.Close()
+
+	// 重新打开后从索引文件恢复统计与构建时间
+	table, err = OpenTable(&TableOptions{Dir: dir, Name: "users", Fields: fields})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	after := table.IndexStats()
+	if len(after) != 1 {
+		t.Fatalf("stats = %+v", after)
+	}
+	if after[0].Entries != 5 || after[0].DistinctKeys != 5 || !after[0].Partial {
+		t.Errorf("after reload: %+v", after[0])
+	}
+	// 关闭表时会再次持久化索引
+	if after[0].LastBuild.Before(before[0].LastBuild) {
+		t.Errorf("LastBuild = %v, want >= %v", after[0].LastBuild, before[0].LastBuild)
+	}
+	if after[0].DiskBytes != before[0].DiskBytes {
+		t.Errorf("DiskBytes = %d, want %d", after[0].DiskBytes, before[0].DiskBytes)
+	}
+}
+
+func TestRefreshStats(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "scores",
+		Fields: []Field{{Name: "score", Type: Int64, Indexed: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 10 {
+		if err := table.Insert(map[string]any{"score": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	idx, _ := table.indexManager.GetIndex("score")
+
+	// 按索引字段排序时构建有序值缓存，之后的写入只记录新值
+	if _, err := table.Query().OrderBy("score").Rows(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 10; i < 20; i++ {
+		if err := table.Insert(map[string]any{"score": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx.mu.RLock()
+	pending := len(idx.unsorted)
+	idx.mu.RUnlock()
+	if pending != 10 {
+		t.Fatalf("expected 10 pending values, got %d", pending)
+	}
+
+	if err := table.RefreshStats(); err != nil {
+		t.Fatal(err)
+	}
+	idx.mu.RLock()
+	pending, sorted := len(idx.unsorted), len(idx.sorted)
+	idx.mu.RUnlock()
+	if pending != 0 || sorted != 20 {
+		t.Errorf("expected merged cache, got %d pending and %d sorted", pending, sorted)
+	}
+
+	rows, err := table.Query().OrderByDesc("score").Limit(3).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := rows.Collect(); len(data) != 3 || data[0]["score"] != int64(19) {
+		t.Errorf("expected top scores from the merged cache, got %v", data)
+	}
+
+	table.Close()
+	if err := table.RefreshStats(); err != ErrTableClosed {
+		t.Errorf("expected ErrTableClosed, got %v", err)
+	}
+}
@@ -1,8 +1,12 @@
 package srdb
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestIndexVersionControl(t *testing.T) {
@@ -551,3 +555,519 @@ func TestIndexPersistenceAcrossRestart(t *testing.T) {
 
 	t.Log("=== Index persistence test passed ===")
 }
+
+func TestIndexCoveringInclude(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fields := []Field{
+		{Name: "email", Type: String, Indexed: true, IndexInclude: []string{"name", "age"}},
+		{Name: "name", Type: String},
+		{Name: "age", Type: Int32, Nullable: true},
+		{Name: "bio", Type: String},
+	}
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          tmpDir,
+		Name:         "users",
+		Fields:       fields,
+		MemTableSize: 1024 * 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testData := []map[string]any{
+		{"email": "alice@example.com", "name": "Alice", "age": 30, "bio": "a"},
+		{"email": "bob@example.com", "name": "Bob", "bio": "b"},
+		{"email": "alice@example.com", "name": "Alice2", "age": 31, "bio": "c"},
+	}
+	for _, data := range testData {
+		if err := table.Insert(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(stage string) {
+		idx, exists := table.GetIndex("email")
+		if !exists {
+			t.Fatalf("%s: index not found", stage)
+		}
+
+		covered, ok, err := idx.GetCovered("alice@example.com", []string{"name", "age"})
+		if err != nil || !ok {
+			t.Fatalf("%s: GetCovered failed: ok=%v err=%v", stage, ok, err)
+		}
+		if len(covered) != 2 {
+			t.Fatalf("%s: expected 2 covered rows, got %d", stage, len(covered))
+		}
+
+		// 未内联的字段不能由覆盖索引返回
+		if _, ok, _ := idx.GetCovered("alice@example.com", []string{"bio"}); ok {
+			t.Errorf("%s: bio should not be covered", stage)
+		}
+
+		// NULL 字段不出现在结果中
+		covered, ok, _ = idx.GetCovered("bob@example.com", []string{"age"})
+		if !ok || len(covered) != 1 {
+			t.Fatalf("%s: expected 1 covered row for bob, got %v", stage, covered)
+		}
+		for _, data := range covered {
+			if _, exists := data["age"]; exists {
+				t.Errorf("%s: age should be NULL, got %v", stage, data["age"])
+			}
+		}
+
+		var names []string
+		rows, err := table.Query().Eq("email", "alice@example.com").Select("_seq", "email", "name", "age").Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			data := rows.Row().Data()
+			if data["email"] != "alice@example.com" {
+				t.Errorf("%s: unexpected email %v", stage, data["email"])
+			}
+			names = append(names, data["name"].(string))
+		}
+		rows.Close()
+		if len(names) != 2 || names[0] != "Alice" || names[1] != "Alice2" {
+			t.Errorf("%s: unexpected names %v", stage, names)
+		}
+
+		// 选择未覆盖的字段时回退到读取行数据
+		row, err := table.Query().Eq("email", "bob@example.com").Select("name", "bio").First()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row.Data()["bio"] != "b" {
+			t.Errorf("%s: expected bio b, got %v", stage, row.Data()["bio"])
+		}
+	}
+
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	check("built")
+	table.Close()
+
+	table, err = OpenTable(&TableOptions{
+		Dir:          tmpDir,
+		MemTableSize: 1024 * 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	idx, _ := table.GetIndex("email")
+	if include := idx.btreeReader.Include(); len(include) != 2 || include[0].Name != "name" || include[1].Type != Int32 {
+		t.Errorf("unexpected include list on disk: %v", include)
+	}
+
+	check("reopened")
+}
+
+func TestQueryOrderByIndex(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "scores",
+		Fields: []Field{
+			{Name: "score", Type: Int64, Indexed: true},
+			{Name: "tag", Type: String},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	insert := func(from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			tag := "a"
+			if i%2 == 1 {
+				tag = "b"
+			}
+			if err := table.Insert(map[string]any{"score": int64(i * 7 % 50), "tag": tag}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	collect := func(qb *QueryBuilder) (scores []int64, seqs []int64) {
+		t.Helper()
+		rows, err := qb.Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			scores = append(scores, rows.Row().Data()["score"].(int64))
+			seqs = append(seqs, rows.Row().inner.Seq)
+		}
+		return scores, seqs
+	}
+	checkOrder := func(scores, seqs []int64, desc bool) {
+		t.Helper()
+		for i := 1; i < len(scores); i++ {
+			a, b := scores[i-1], scores[i]
+			if desc {
+				a, b = b, a
+			}
+			if a > b || (a == b && (seqs[i-1] < seqs[i]) == desc) {
+				t.Fatalf("rows out of order at %d: score %d/%d seq %d/%d", i, scores[i-1], scores[i], seqs[i-1], seqs[i])
+			}
+		}
+	}
+
+	// 一半已持久化，一半在内存中
+	insert(0, 100)
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	insert(100, 200)
+
+	// 按数值（而非字符串）排序，值相同按 seq 排序
+	scores, seqs := collect(table.Query().OrderBy("score"))
+	if len(scores) != 200 {
+		t.Fatalf("expected 200 rows, got %d", len(scores))
+	}
+	checkOrder(scores, seqs, false)
+
+	scores, seqs = collect(table.Query().OrderByDesc("score"))
+	if len(scores) != 200 || scores[0] != 49 {
+		t.Fatalf("unexpected desc result: %d rows, first %v", len(scores), scores[:1])
+	}
+	checkOrder(scores, seqs, true)
+
+	// 排序缓存构建后新增的值
+	if err := table.Insert(map[string]any{"score": int64(-5), "tag": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"score": int64(500), "tag": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	scores, _ = collect(table.Query().OrderBy("score").Limit(3))
+	if !slices.Equal(scores, []int64{-5, 0, 0}) {
+		t.Errorf("expected [-5 0 0], got %v", scores)
+	}
+
+	// Limit：读够即停（500 有 1 行，49、48、47 各 4 行），只读取前几个值的索引条目
+	rows, err := table.Query().OrderByDesc("score").Limit(10).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if it := rows.merge.(*indexOrderIterator); n != 10 || it.pos > 4 {
+		t.Errorf("expected 10 rows from at most 4 index values, got %d rows from %d values", n, it.pos)
+	}
+	rows.Close()
+
+	// 其他条件、Offset
+	scores, _ = collect(table.Query().Eq("tag", "b").OrderBy("score").Offset(2).Limit(5))
+	if len(scores) != 5 {
+		t.Fatalf("expected 5 rows, got %d", len(scores))
+	}
+	for _, s := range scores {
+		if s%2 == 0 {
+			t.Errorf("score %d should not match tag b", s)
+		}
+	}
+
+	// 快照：创建之后写入的行不可见
+	rows, err = table.Query().OrderBy("score").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"score": int64(-10), "tag": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if first, err := rows.First(); err != nil || first.Data()["score"].(int64) != -5 {
+		t.Errorf("expected snapshot to start at -5, got %v (%v)", first, err)
+	}
+	rows.Close()
+
+	page, err := table.Query().OrderBy("score").Page(2, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 203 || page.Rows.Len() != 50 {
+		t.Errorf("unexpected page: total %d, rows %d", page.Total, page.Rows.Len())
+	}
+}
+
+func TestIndexSortKey(t *testing.T) {
+	utc := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	earlier := utc.Add(-time.Minute).In(time.FixedZone("X", 3600))
+	cases := []struct {
+		typ    FieldType
+		values []string // 期望的升序
+	}{
+		{Int64, []string{"-3", "2", "10", "100"}},
+		{Uint8, []string{"9", "10", "200"}},
+		{Float64, []string{"-1.5", "1e-05", "2", "10.25"}},
+		{Decimal, []string{"0.5", "1.25", "10"}},
+		{Duration, []string{"500ms", "2s", "1m0s"}},
+		{Time, []string{earlier.String(), utc.String(), time.Now().String()}},
+		{Bool, []string{"false", "true"}},
+		{String, []string{"10", "9", "a"}},
+	}
+	for _, c := range cases {
+		shuffled := slices.Clone(c.values)
+		slices.Reverse(shuffled)
+		sorted := sortIndexValues(shuffled, c.typ)
+		got := make([]string, len(sorted))
+		for i, v := range sorted {
+			got[i] = v.raw
+		}
+		if !slices.Equal(got, c.values) {
+			t.Errorf("%s: expected %v, got %v", c.typ, c.values, got)
+		}
+	}
+
+	a := sortIndexValues([]string{"1", "3", "5"}, Int64)
+	b := sortIndexValues([]string{"2", "3", "6"}, Int64)
+	merged := mergeSortedIndexValues(a, b)
+	if len(merged) != 5 || merged[2].raw != "3" || merged[4].raw != "6" {
+		t.Errorf("unexpected merge result %v", merged)
+	}
+}
+
+func TestPartialIndex(t *testing.T) {
+	dir := t.TempDir()
+	fields := []Field{
+		{Name: "email", Type: String},
+		{Name: "is_active", Type: Bool},
+		{Name: "age", Type: Int64},
+	}
+	open := func() *Table {
+		t.Helper()
+		table, err := OpenTable(&TableOptions{Dir: dir, Name: "users", Fields: fields})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return table
+	}
+	insert := func(table *Table, from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			err := table.Insert(map[string]any{
+				"email":     fmt.Sprintf("user%d@example.com", i%10),
+				"is_active": i%10 == 0,
+				"age":       int64(i),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	count := func(table *Table, qb *QueryBuilder) int {
+		t.Helper()
+		rows, err := qb.Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		return rows.Len()
+	}
+
+	table := open()
+	insert(table, 0, 100)
+
+	if err := table.CreateIndexWhere("email", Eq("is_active", time.Now())); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for unsupported value, got %v", err)
+	}
+	if err := table.CreateIndexWhere("email", nil); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for nil condition, got %v", err)
+	}
+	if err := table.CreateIndexWhere("email", Eq("is_active", true)); err != nil {
+		t.Fatal(err)
+	}
+	insert(table, 100, 200)
+
+	// 已有数据与新数据中只有满足条件的行加入索引
+	idx, _ := table.GetIndex("email")
+	if meta := idx.GetMetadata(); meta.RowCount != 20 || meta.MaxSeq != 200 {
+		t.Errorf("expected 20 indexed rows processed up to 200, got %d/%d", meta.RowCount, meta.MaxSeq)
+	}
+	if idx.NeedsUpdate(table.GetMaxSeq()) {
+		t.Error("partial index should not need update after skipping rows")
+	}
+
+	checkQueries := func(table *Table) {
+		t.Helper()
+		// 查询条件包含索引条件：使用索引
+		qb := table.Query().Eq("is_active", true).Eq("email", "user0@example.com")
+		if field, _ := qb.findIndexableCondition(); field != "email" {
+			t.Errorf("expected partial index to be used, got %q", field)
+		}
+		if n := count(table, qb); n != 20 {
+			t.Errorf("expected 20 active rows, got %d", n)
+		}
+		// 不包含索引条件：不能使用索引，结果仍然完整
+		qb = table.Query().Eq("email", "user1@example.com")
+		if field, _ := qb.findIndexableCondition(); field != "" {
+			t.Errorf("partial index must not be used without its condition, got %q", field)
+		}
+		if n := count(table, qb); n != 20 {
+			t.Errorf("expected 20 rows, got %d", n)
+		}
+		if n := count(table, table.Query().Eq("email", "user1@example.com").Eq("is_active", true)); n != 0 {
+			t.Errorf("expected 0 rows, got %d", n)
+		}
+		// OrderBy 同样要求包含索引条件
+		if _, err := table.Query().OrderBy("email").Rows(); err == nil {
+			t.Error("expected OrderBy on partial index without its condition to fail")
+		}
+		if n := count(table, table.Query().Eq("is_active", true).OrderBy("email")); n != 20 {
+			t.Errorf("expected 20 ordered rows, got %d", n)
+		}
+	}
+	checkQueries(table)
+
+	// 条件随索引持久化
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	table = open()
+	defer table.Close()
+	idx, _ = table.GetIndex("email")
+	if !sameExpr(idx.Where(), Eq("is_active", true)) {
+		t.Fatalf("expected condition to be restored, got %#v", idx.Where())
+	}
+	checkQueries(table)
+
+	if err := table.DropIndex("email"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(indexWherePath(table.indexManager.dir, "email")); !os.IsNotExist(err) {
+		t.Errorf("expected condition file to be removed, got %v", err)
+	}
+}
+
+func TestPartialIndexConditions(t *testing.T) {
+	where := And(Eq("is_active", true), Gte("age", 18), Not(In("role", []any{"bot", int64(1)})))
+	encoded, err := encodeExpr(where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var j exprJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeExpr(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// JSON 还原后数值类型不同（float64），仍视为相同
+	if !sameExpr(where, decoded) {
+		t.Fatalf("decoded condition differs: %s", data)
+	}
+
+	idx := &SecondaryIndex{where: decoded}
+	cases := []struct {
+		conds  []Expr
+		usable bool
+	}{
+		{[]Expr{Eq("email", "a")}, false},
+		{[]Expr{Eq("email", "a"), Eq("is_active", true)}, false},
+		{[]Expr{Eq("is_active", true), Eq("email", "a"), Gte("age", 18), Not(In("role", []any{"bot", 1}))}, true},
+		{[]Expr{And(Eq("is_active", true), Gte("age", 18)), Not(In("role", []any{"bot", 1.0}))}, true},
+		{[]Expr{Eq("is_active", true), Gte("age", 21), Not(In("role", []any{"bot", 1}))}, false},
+		{[]Expr{Or(Eq("is_active", true), Gte("age", 18)), Not(In("role", []any{"bot", 1}))}, false},
+	}
+	for i, c := range cases {
+		if got := idx.usableFor(c.conds); got != c.usable {
+			t.Errorf("case %d: expected usable=%v, got %v", i, c.usable, got)
+		}
+	}
+	if !(&SecondaryIndex{}).usableFor(nil) {
+		t.Error("full index should always be usable")
+	}
+}
+
+func TestIndexIntersection(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "orders",
+		Fields: []Field{
+			{Name: "status", Type: String, Indexed: true},
+			{Name: "region", Type: String, Indexed: true},
+			{Name: "amount", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	statuses := []string{"paid", "pending", "refunded"}
+	regions := []string{"north", "south", "east", "west"}
+	for i := range 1200 {
+		err := table.Insert(map[string]any{
+			"status": statuses[i%3],
+			"region": regions[i%4],
+			"amount": int64(i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 前一半持久化到索引文件，后一半只在内存中
+		if i == 600 {
+			table.Flush()
+			if err := table.BuildIndexes(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	count := func(qb *QueryBuilder) int {
+		t.Helper()
+		rows, err := qb.Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		return rows.Len()
+	}
+
+	// 每个 (status, region) 组合 100 行
+	if got := count(table.Query().Eq("status", "paid").Eq("region", "north")); got != 100 {
+		t.Errorf("eq and eq: expected 100, got %d", got)
+	}
+	if got := count(table.Query().Eq("status", "paid").In("region", []any{"north", "south"})); got != 200 {
+		t.Errorf("eq and in: expected 200, got %d", got)
+	}
+	if got := count(table.Query().Eq("status", "paid").NotEq("region", "north")); got != 300 {
+		t.Errorf("eq and not eq: expected 300, got %d", got)
+	}
+	if got := count(table.Query().In("status", []any{"paid", "pending"}).NotIn("region", []any{"east", "west"})); got != 400 {
+		t.Errorf("in and not in: expected 400, got %d", got)
+	}
+	if got := count(table.Query().Eq("status", "paid").Eq("region", "north").Lt("amount", 600)); got != 50 {
+		t.Errorf("with other condition: expected 50, got %d", got)
+	}
+
+	// 读取行之前已经按两个索引求交集
+	idx, _ := table.GetIndex("status")
+	paid, err := idx.GetBitmap("paid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qb := table.Query().Eq("status", "paid").Eq("region", "north")
+	if n := qb.combineIndexConditions("status", paid).Cardinality(); n != 100 {
+		t.Errorf("expected 100 candidates after intersection, got %d", n)
+	}
+	if paid.Cardinality() != 400 {
+		t.Errorf("expected input bitmap to be unchanged, got %d", paid.Cardinality())
+	}
+}
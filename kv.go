@@ -0,0 +1,267 @@
+package srdb
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// kvTableName KV 存储使用的内部表名（保留，不能用于普通表）
+const kvTableName = "_kv"
+
+// KV 简单的键值存储
+//
+// 与普通表共用 WAL/MemTable/SST 存储引擎，数据保存在数据库目录下的内部表中。
+// 每次 Put/Delete 追加一条记录（Delete 写入删除标记），内存中维护每个 key 最新记录的 seq
+// 与按字典序排列的 key，前缀扫描（Scan）只需二分查找起点。关闭时将 key 写入前缀压缩的
+// 索引文件（见 kv_index.go），打开时加载索引并重放之后追加的记录，索引不可用时扫描一遍内部表重建。
+// 由于存储引擎是 Append-Only 的，旧版本不会被回收，适合存放配置、游标、少量元数据等体量不大的数据。
+type KV struct {
+	table *Table
+
+	mu     sync.RWMutex
+	keys   map[string]int64 // key -> 最新记录的 seq
+	sorted []string         // 按字典序排列的 key
+}
+
+// KV 获取数据库的键值存储（首次调用时打开）
+func (db *Database) KV() (*KV, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.kv != nil {
+		return db.kv, nil
+	}
+
+	table, err := OpenTable(&TableOptions{
+		Dir:                      filepath.Join(db.dir, kvTableName),
+		MemTableSize:             db.options.MemTableSize,
+		AutoFlushTimeout:         db.options.AutoFlushTimeout,
+		IOMode:                   db.options.IOMode,
+		RowChecksum:              db.options.RowChecksum,
+		WALCompression:           db.options.WALCompression,
+		ParanoidCompactionChecks: db.options.ParanoidCompactionChecks,
+		FaultInjector:            db.options.FaultInjector,
+		Clock:                    db.options.Clock,
+		Name:                     kvTableName,
+		Fields: []Field{
+			{Name: "key", Type: String},
+			{Name: "value", Type: String, Nullable: true},
+			{Name: "deleted", Type: Bool},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	table.setLoggers(db.options)
+	if table.compactionManager != nil {
+		table.compactionManager.ApplyConfig(db.options)
+	}
+
+	kv := &KV{table: table}
+	if err := kv.load(); err != nil {
+		table.Close()
+		return nil, err
+	}
+
+	db.kv = kv
+	return kv, nil
+}
+
+// load 重建每个 key 最新记录的 seq：优先使用 key 索引，不可用时扫描内部表
+func (kv *KV) load() error {
+	if ok, err := kv.loadIndex(); ok || err != nil {
+		return err
+	}
+
+	kv.keys = make(map[string]int64)
+
+	rows, err := kv.table.Query().Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// 按 seq 升序迭代，后写入的记录覆盖先写入的
+	for rows.ReuseRow().Next() {
+		data := rows.Row().Data()
+		key, _ := data["key"].(string)
+		if deleted, _ := data["deleted"].(bool); deleted {
+			delete(kv.keys, key)
+		} else {
+			kv.keys[key] = rows.Row().Seq()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	kv.sorted = slices.Sorted(maps.Keys(kv.keys))
+	return nil
+}
+
+// set 记录 key 最新记录的 seq，新 key 按字典序插入（调用者必须持有 mu 写锁）
+func (kv *KV) set(key string, seq int64) {
+	if _, ok := kv.keys[key]; !ok {
+		i, _ := slices.BinarySearch(kv.sorted, key)
+		kv.sorted = slices.Insert(kv.sorted, i, key)
+	}
+	kv.keys[key] = seq
+}
+
+// remove 移除 key（调用者必须持有 mu 写锁）
+func (kv *KV) remove(key string) {
+	if _, ok := kv.keys[key]; !ok {
+		return
+	}
+	delete(kv.keys, key)
+	if i, found := slices.BinarySearch(kv.sorted, key); found {
+		kv.sorted = slices.Delete(kv.sorted, i, i+1)
+	}
+}
+
+// Put 写入键值，key 不能为空
+func (kv *KV) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return NewErrorf(ErrCodeInvalidParam, "key cannot be empty")
+	}
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if err := kv.table.Insert(map[string]any{
+		"key":     string(key),
+		"value":   string(value),
+		"deleted": false,
+	}); err != nil {
+		return err
+	}
+	kv.set(string(key), kv.table.seq.Load())
+	return nil
+}
+
+// Get 读取键值，key 不存在时返回 ErrCodeNotFound
+func (kv *KV) Get(key []byte) ([]byte, error) {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	seq, ok := kv.keys[string(key)]
+	if !ok {
+		return nil, NewErrorf(ErrCodeNotFound, "key %q not found", key)
+	}
+	row, err := kv.table.Get(seq)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := row.Data["value"].(string)
+	return []byte(value), nil
+}
+
+// Has 判断 key 是否存在
+func (kv *KV) Has(key []byte) bool {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	_, ok := kv.keys[string(key)]
+	return ok
+}
+
+// Delete 删除键值，key 不存在时不做任何操作
+func (kv *KV) Delete(key []byte) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if _, ok := kv.keys[string(key)]; !ok {
+		return nil
+	}
+	if err := kv.table.Insert(map[string]any{
+		"key":     string(key),
+		"deleted": true,
+	}); err != nil {
+		return err
+	}
+	kv.remove(string(key))
+	return nil
+}
+
+// Len 返回 key 的数量
+func (kv *KV) Len() int {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	return len(kv.keys)
+}
+
+// Scan 按 key 的字典序迭代以 prefix 开头的键值，prefix 为空时迭代全部
+// fn 返回 false 时停止迭代。迭代基于调用时的快照，fn 中可以安全地调用 Put/Delete
+//
+// key 在内存中有序排列，定位前缀只需二分查找，开销与匹配的 key 数量成正比，
+// 适合 "device:0001:" 这类命名空间形式的 key。
+func (kv *KV) Scan(prefix []byte, fn func(key, value []byte) bool) error {
+	type entry struct {
+		key string
+		seq int64
+	}
+
+	// 1. 固定快照（记录表的 epoch，迭代期间表被清空时返回 ErrTableReset）
+	kv.mu.RLock()
+	epoch := kv.table.epoch.Load()
+	var entries []entry
+	start, _ := slices.BinarySearch(kv.sorted, string(prefix))
+	for _, key := range kv.sorted[start:] {
+		if !strings.HasPrefix(key, string(prefix)) {
+			break
+		}
+		entries = append(entries, entry{key, kv.keys[key]})
+	}
+	kv.mu.RUnlock()
+
+	// 2. 逐个读取值（不持有锁，fn 可以修改 KV）
+	for _, e := range entries {
+		done, err := kv.table.beginRead(epoch)
+		if err != nil {
+			return err
+		}
+		row, err := kv.table.getWithPriority(PriorityNormal, e.seq)
+		done()
+		if err != nil {
+			return err
+		}
+		value, _ := row.Data["value"].(string)
+		if !fn([]byte(e.key), []byte(value)) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// clean 清除所有键值
+func (kv *KV) clean() error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if err := kv.table.Clean(); err != nil {
+		return err
+	}
+	if err := os.Remove(kv.indexPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	clear(kv.keys)
+	kv.sorted = nil
+	return nil
+}
+
+// close 写入 key 索引并关闭内部表（索引写入失败时仍关闭表，下次打开时扫描重建）
+func (kv *KV) close() error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	indexErr := kv.saveIndex()
+	if err := kv.table.Close(); err != nil {
+		return err
+	}
+	return indexErr
+}
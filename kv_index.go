@@ -0,0 +1,184 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+)
+
+/*
+KV key 索引文件格式 (keys.idx，位于 KV 内部表目录)
+
+记录某一时刻（Seq）所有存在的 key 及其最新记录的 seq。key 按字典序排列并使用前缀压缩：
+命名空间形式的 key（"device:0001:temp"、"device:0001:humidity"）只存储与前一个 key 不同的后缀。
+
+	┌──────────────────────────────────────────────┐
+	│ Header (24 bytes)                            │
+	│   Magic(4) "KVKI" | Version(4) | Seq(8) | Count(8)
+	├──────────────────────────────────────────────┤
+	│ Entry × Count                                │
+	│   Shared(uvarint)   与前一个 key 相同的前缀长度 │
+	│   Unshared(uvarint) 后缀长度                  │
+	│   Seq(uvarint)      key 最新记录的 seq        │
+	│   Suffix(Unshared bytes)                     │
+	├──────────────────────────────────────────────┤
+	│ CRC32(4)  以上所有内容的 IEEE 校验和           │
+	└──────────────────────────────────────────────┘
+
+打开 KV 时加载索引，只重放 Seq 之后追加的记录；文件缺失、损坏或与表不一致时回退到全表扫描。
+*/
+
+const (
+	kvIndexFile       = "keys.idx"
+	kvIndexMagic      = 0x4B564B49 // "KVKI"
+	kvIndexVersion    = 1
+	kvIndexHeaderSize = 24
+)
+
+// encodeKVIndex 编码 key 索引，keys 必须按字典序排列
+func encodeKVIndex(seq int64, keys []string, seqs map[string]int64) []byte {
+	buf := make([]byte, kvIndexHeaderSize, kvIndexHeaderSize+len(keys)*16+4)
+	binary.LittleEndian.PutUint32(buf[0:4], kvIndexMagic)
+	binary.LittleEndian.PutUint32(buf[4:8], kvIndexVersion)
+	binary.LittleEndian.PutUint64(buf[8:16], uint64(seq))
+	binary.LittleEndian.PutUint64(buf[16:24], uint64(len(keys)))
+
+	prev := ""
+	for _, key := range keys {
+		shared := commonPrefixLen(prev, key)
+		buf = binary.AppendUvarint(buf, uint64(shared))
+		buf = binary.AppendUvarint(buf, uint64(len(key)-shared))
+		buf = binary.AppendUvarint(buf, uint64(seqs[key]))
+		buf = append(buf, key[shared:]...)
+		prev = key
+	}
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+}
+
+// decodeKVIndex 解码 key 索引，返回按字典序排列的 key 与各 key 最新记录的 seq
+func decodeKVIndex(data []byte) (seq int64, keys []string, seqs map[string]int64, err error) {
+	if len(data) < kvIndexHeaderSize+4 {
+		return 0, nil, nil, fmt.Errorf("kv index too short: %d bytes", len(data))
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return 0, nil, nil, fmt.Errorf("kv index checksum mismatch")
+	}
+	if binary.LittleEndian.Uint32(body[0:4]) != kvIndexMagic {
+		return 0, nil, nil, fmt.Errorf("invalid kv index: bad magic")
+	}
+	if v := binary.LittleEndian.Uint32(body[4:8]); v != kvIndexVersion {
+		return 0, nil, nil, fmt.Errorf("unsupported kv index version: %d", v)
+	}
+	seq = int64(binary.LittleEndian.Uint64(body[8:16]))
+	count := binary.LittleEndian.Uint64(body[16:24])
+
+	pos := kvIndexHeaderSize
+	uvarint := func() (uint64, bool) {
+		v, n := binary.Uvarint(body[pos:])
+		if n <= 0 {
+			return 0, false
+		}
+		pos += n
+		return v, true
+	}
+
+	seqs = make(map[string]int64)
+	prev := ""
+	for i := uint64(0); i < count; i++ {
+		shared, ok1 := uvarint()
+		unshared, ok2 := uvarint()
+		keySeq, ok3 := uvarint()
+		if !ok1 || !ok2 || !ok3 || shared > uint64(len(prev)) || unshared > uint64(len(body)-pos) {
+			return 0, nil, nil, fmt.Errorf("kv index entry %d truncated", i)
+		}
+		key := prev[:shared] + string(body[pos:pos+int(unshared)])
+		pos += int(unshared)
+		if i > 0 && key <= prev {
+			return 0, nil, nil, fmt.Errorf("kv index keys out of order at entry %d", i)
+		}
+		keys = append(keys, key)
+		seqs[key] = int64(keySeq)
+		prev = key
+	}
+	if pos != len(body) {
+		return 0, nil, nil, fmt.Errorf("kv index has %d trailing bytes", len(body)-pos)
+	}
+	return seq, keys, seqs, nil
+}
+
+// commonPrefixLen 返回 a 与 b 的公共前缀长度
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// indexPath 返回 key 索引文件路径
+func (kv *KV) indexPath() string {
+	return filepath.Join(kv.table.dir, kvIndexFile)
+}
+
+// saveIndex 原子地写入 key 索引（调用者必须持有 mu）
+func (kv *KV) saveIndex() error {
+	data := encodeKVIndex(kv.table.seq.Load(), kv.sorted, kv.keys)
+
+	path := kv.indexPath()
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// loadIndex 从 key 索引恢复，只重放索引之后追加的记录；索引不可用时返回 false
+func (kv *KV) loadIndex() (bool, error) {
+	data, err := os.ReadFile(kv.indexPath())
+	if err != nil {
+		return false, nil
+	}
+	seq, keys, seqs, err := decodeKVIndex(data)
+	if err != nil || seq > kv.table.seq.Load() {
+		// 损坏，或表在索引写入后被清空（正常情况下清空时会删除索引）
+		return false, nil
+	}
+
+	kv.keys, kv.sorted = seqs, keys
+	for s := seq + 1; s <= kv.table.seq.Load(); s++ {
+		if _, ok := kv.table.locateRow(s); !ok {
+			continue
+		}
+		row, err := kv.table.Get(s)
+		if err != nil {
+			return false, err
+		}
+		key, _ := row.Data["key"].(string)
+		if deleted, _ := row.Data["deleted"].(bool); deleted {
+			kv.remove(key)
+		} else {
+			kv.set(key, s)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,240 @@
+package srdb
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestKV(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kv, err := db.KV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := db.KV(); again != kv {
+		t.Error("KV() should return the same instance")
+	}
+
+	for i := range 5 {
+		if err := kv.Put(fmt.Appendf(nil, "user:%d", i), fmt.Appendf(nil, "name-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := kv.Put([]byte("config:theme"), []byte("dark")); err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.Put([]byte("user:1"), []byte("renamed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.Delete([]byte("user:3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.Put([]byte("empty"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(kv *KV) {
+		t.Helper()
+
+		if v, err := kv.Get([]byte("user:1")); err != nil || string(v) != "renamed" {
+			t.Errorf("Get(user:1) = %q, %v", v, err)
+		}
+		if v, err := kv.Get([]byte("empty")); err != nil || len(v) != 0 {
+			t.Errorf("Get(empty) = %q, %v", v, err)
+		}
+		if _, err := kv.Get([]byte("user:3")); !IsError(err, ErrCodeNotFound) {
+			t.Errorf("expected ErrCodeNotFound for deleted key, got %v", err)
+		}
+		if kv.Has([]byte("user:3")) || !kv.Has([]byte("user:0")) {
+			t.Error("unexpected Has result")
+		}
+		if kv.Len() != 6 {
+			t.Errorf("expected 6 keys, got %d", kv.Len())
+		}
+
+		var keys []string
+		err := kv.Scan([]byte("user:"), func(key, value []byte) bool {
+			keys = append(keys, string(key))
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(keys, []string{"user:0", "user:1", "user:2", "user:4"}) {
+			t.Errorf("unexpected prefix scan result %v", keys)
+		}
+	}
+	check(kv)
+
+	// 提前停止，迭代中修改
+	count := 0
+	err = kv.Scan(nil, func(key, value []byte) bool {
+		count++
+		if err := kv.Put([]byte("zzz"), []byte("added during scan")); err != nil {
+			t.Fatal(err)
+		}
+		return count < 2
+	})
+	if err != nil || count != 2 {
+		t.Errorf("expected scan to stop after 2 keys, got %d (err=%v)", count, err)
+	}
+	if err := kv.Delete([]byte("zzz")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := kv.Put(nil, []byte("x")); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for empty key, got %v", err)
+	}
+	if _, err := db.CreateTable(kvTableName, nil); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected reserved table name error, got %v", err)
+	}
+	if len(db.ListTables()) != 0 {
+		t.Errorf("KV table should not be listed, got %v", db.ListTables())
+	}
+
+	// 重新打开后恢复
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	kv, err = db.KV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(kv)
+
+	// Clean 清除键值
+	if err := db.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if kv.Len() != 0 || kv.Has([]byte("user:0")) {
+		t.Error("expected KV to be empty after Clean")
+	}
+	if err := kv.Put([]byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := kv.Get([]byte("k")); err != nil || string(v) != "v" {
+		t.Errorf("Get after Clean = %q, %v", v, err)
+	}
+}
+
+func TestKVIndex(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultOptions(dir)
+	opts.FaultInjector = NewFaultInjector()
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv, err := db.KV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 50 {
+		if err := kv.Put(fmt.Appendf(nil, "device:%04d:temp", i), []byte("20")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := kv.Delete([]byte("device:0003:temp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 前缀压缩：相同的 "device:00" 前缀只存储一次
+	data, err := os.ReadFile(filepath.Join(dir, kvTableName, kvIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := 49 * len("device:0000:temp"); len(data) >= raw {
+		t.Errorf("expected compressed index smaller than %d bytes, got %d", raw, len(data))
+	}
+
+	// 重新打开后加载索引，并重放索引之后追加的记录
+	reopen := func() *KV {
+		t.Helper()
+		if db, err = OpenWithOptions(opts); err != nil {
+			t.Fatal(err)
+		}
+		kv, err := db.KV()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kv
+	}
+	kv = reopen()
+	if err := kv.Put([]byte("device:0100:temp"), []byte("30")); err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.Delete([]byte("device:0000:temp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SimulateCrash(); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(kv *KV) {
+		t.Helper()
+		if kv.Len() != 49 || kv.Has([]byte("device:0000:temp")) || kv.Has([]byte("device:0003:temp")) {
+			t.Errorf("unexpected keys after reopen: len=%d", kv.Len())
+		}
+		var keys []string
+		if err := kv.Scan([]byte("device:01"), func(key, value []byte) bool {
+			keys = append(keys, string(key))
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(keys, []string{"device:0100:temp"}) {
+			t.Errorf("unexpected prefix scan result %v", keys)
+		}
+	}
+	kv = reopen()
+	check(kv)
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 索引损坏时扫描内部表重建
+	path := filepath.Join(dir, kvTableName, kvIndexFile)
+	if err := os.WriteFile(path, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	kv = reopen()
+	defer db.Close()
+	check(kv)
+}
+
+func TestKVIndexEncoding(t *testing.T) {
+	keys := []string{"", "a", "device:0001:humidity", "device:0001:temp", "device:0002:temp"}
+	seqs := map[string]int64{"": 1, "a": 2, "device:0001:humidity": 300, "device:0001:temp": 4, "device:0002:temp": 5}
+	seq, gotKeys, gotSeqs, err := decodeKVIndex(encodeKVIndex(42, keys, seqs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq != 42 || !slices.Equal(gotKeys, keys) || !maps.Equal(gotSeqs, seqs) {
+		t.Errorf("round trip mismatch: seq=%d keys=%v seqs=%v", seq, gotKeys, gotSeqs)
+	}
+
+	data := encodeKVIndex(42, keys, seqs)
+	data[kvIndexHeaderSize] ^= 0xff
+	if _, _, _, err := decodeKVIndex(data); err == nil {
+		t.Error("expected checksum error for corrupted index")
+	}
+}
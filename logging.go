@@ -0,0 +1,131 @@
+package srdb
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+// 日志子系统，用于 Database.SetLogLevel
+const (
+	LogWAL        = "wal"        // WAL 切换与清理
+	LogFlush      = "flush"      // MemTable 持久化为 SST
+	LogCompaction = "compaction" // Compaction
+	LogGC         = "gc"         // 孤儿文件回收
+	LogQuery      = "query"      // 查询计划（使用的索引、扫描的文件数）
+)
+
+// logSubsystems 所有日志子系统
+var logSubsystems = []string{LogWAL, LogFlush, LogCompaction, LogGC, LogQuery}
+
+// discardLogger 丢弃所有日志（未配置日志器时使用）
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// subsystemLevel 子系统的日志级别，未设置时沿用全局级别
+type subsystemLevel struct {
+	set   atomic.Bool
+	level slog.LevelVar
+}
+
+// subsystemHandler 按子系统级别（未设置时按全局级别）过滤日志
+type subsystemHandler struct {
+	slog.Handler
+	global *slog.LevelVar // nil 表示不额外过滤
+	level  *subsystemLevel
+}
+
+func (h *subsystemHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	threshold := logLevelAll
+	if h.level.set.Load() {
+		threshold = h.level.level.Level()
+	} else if h.global != nil {
+		threshold = h.global.Level()
+	}
+	return level >= threshold && h.Handler.Enabled(ctx, level)
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &subsystemHandler{Handler: h.Handler.WithAttrs(attrs), global: h.global, level: h.level}
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return &subsystemHandler{Handler: h.Handler.WithGroup(name), global: h.global, level: h.level}
+}
+
+// logRegistry 按子系统划分的日志器
+//
+// 每个子系统的日志器附加 subsystem 属性并按各自的级别过滤；
+// 级别是原子变量，运行时修改立即对所有使用该日志器的表生效。
+type logRegistry struct {
+	levels  map[string]*subsystemLevel
+	loggers map[string]*slog.Logger
+}
+
+// newLogRegistry 基于 handler 创建各子系统的日志器，未设置子系统级别时按 global 过滤
+func newLogRegistry(handler slog.Handler, global *slog.LevelVar) *logRegistry {
+	r := &logRegistry{
+		levels:  make(map[string]*subsystemLevel, len(logSubsystems)),
+		loggers: make(map[string]*slog.Logger, len(logSubsystems)),
+	}
+	for _, name := range logSubsystems {
+		level := &subsystemLevel{}
+		r.levels[name] = level
+		r.loggers[name] = slog.New(&subsystemHandler{
+			Handler: handler.WithAttrs([]slog.Attr{slog.String("subsystem", name)}),
+			global:  global,
+			level:   level,
+		})
+	}
+	return r
+}
+
+// get 返回子系统的日志器，r 为 nil 时返回丢弃日志的日志器
+func (r *logRegistry) get(name string) *slog.Logger {
+	if r == nil {
+		return discardLogger
+	}
+	return r.loggers[name]
+}
+
+// subsystemLogger 返回 Options 对应子系统的日志器
+// 未通过 Open 创建（没有子系统日志器）时使用 Logger
+func (opts *Options) subsystemLogger(name string) *slog.Logger {
+	if opts.logs != nil {
+		return opts.logs.get(name)
+	}
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+	return discardLogger
+}
+
+// SetLogLevel 修改子系统的日志级别，立即对所有已打开的表生效
+//
+// 子系统：LogWAL、LogFlush、LogCompaction、LogGC、LogQuery。
+// 设置后该子系统不再使用 SetOption("LogLevel", ...) 设置的全局级别，
+// 最终是否输出仍由 Options.Logger 自身的级别决定：
+//
+//	db.SetOption("LogLevel", "warn")                     // 全局只输出警告
+//	db.SetLogLevel(srdb.LogCompaction, slog.LevelDebug) // 单独调试 compaction
+func (db *Database) SetLogLevel(subsystem string, level slog.Level) error {
+	v, ok := db.options.logs.levels[subsystem]
+	if !ok {
+		return NewErrorf(ErrCodeInvalidParam, "unknown log subsystem %q, expected one of %v", subsystem, logSubsystems)
+	}
+	v.level.Set(level)
+	v.set.Store(true)
+
+	db.options.Logger.Info("[Database] Log level changed", "subsystem", subsystem, "level", level)
+	return nil
+}
+
+// ResetLogLevel 恢复子系统使用全局日志级别
+func (db *Database) ResetLogLevel(subsystem string) error {
+	v, ok := db.options.logs.levels[subsystem]
+	if !ok {
+		return NewErrorf(ErrCodeInvalidParam, "unknown log subsystem %q, expected one of %v", subsystem, logSubsystems)
+	}
+	v.set.Store(false)
+	return nil
+}
@@ -0,0 +1,153 @@
+package srdb
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logBuffer 可并发写入的日志缓冲（后台 flush/compaction 同时写日志）
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// take 返回并清空已写入的日志
+func (b *logBuffer) take() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := b.buf.String()
+	b.buf.Reset()
+	return out
+}
+
+func TestDatabaseSetLogLevel(t *testing.T) {
+	var logs logBuffer
+	opts := DefaultOptions(t.TempDir())
+	opts.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	opts.DisableAutoCompaction = true
+
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema, err := NewSchema("events", []Field{{Name: "n", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := db.CreateTable("events", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flush := func() {
+		t.Helper()
+		if err := table.Insert(map[string]any{"n": int64(1)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		for table.memtableManager.GetImmutableCount() > 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	query := func() {
+		t.Helper()
+		rows, err := table.Query().Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+
+	// 全局级别为 warn 时，子系统的调试日志被过滤
+	if err := db.SetOption("LogLevel", "warn"); err != nil {
+		t.Fatal(err)
+	}
+	logs.take()
+	flush()
+	query()
+	if out := logs.take(); strings.Contains(out, "[Flush]") || strings.Contains(out, "[Query]") {
+		t.Errorf("debug logs should be filtered by global level, got: %s", out)
+	}
+
+	// 单独打开 query 的调试日志
+	if err := db.SetLogLevel(LogQuery, slog.LevelDebug); err != nil {
+		t.Fatal(err)
+	}
+	logs.take()
+	flush()
+	query()
+	out := logs.take()
+	if !strings.Contains(out, "[Query] Full scan") || !strings.Contains(out, "subsystem=query") {
+		t.Errorf("expected query debug log, got: %s", out)
+	}
+	if strings.Contains(out, "[Flush]") {
+		t.Errorf("flush logs should still be filtered, got: %s", out)
+	}
+
+	// flush 与 wal
+	if err := db.SetLogLevel(LogFlush, slog.LevelDebug); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetLogLevel(LogWAL, slog.LevelDebug); err != nil {
+		t.Fatal(err)
+	}
+	flush()
+	out = logs.take()
+	if !strings.Contains(out, "[Flush] Completed") || !strings.Contains(out, "[WAL] Rotated") {
+		t.Errorf("expected flush and wal debug logs, got: %s", out)
+	}
+
+	// 恢复使用全局级别
+	if err := db.ResetLogLevel(LogQuery); err != nil {
+		t.Fatal(err)
+	}
+	query()
+	if out := logs.take(); strings.Contains(out, "[Query]") {
+		t.Errorf("query logs should follow global level after reset, got: %s", out)
+	}
+
+	if err := db.SetLogLevel("storage", slog.LevelDebug); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+	if err := db.ResetLogLevel("storage"); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+}
+
+func TestTableSetLoggerSubsystems(t *testing.T) {
+	var logs logBuffer
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	table.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	if out := logs.take(); !strings.Contains(out, "[Query] Full scan") {
+		t.Errorf("expected query log on table logger, got: %s", out)
+	}
+}
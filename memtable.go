@@ -6,23 +6,29 @@
 )
 
 // MemTable 内存表
+//
+// 行的编码存放在 arena 中，map 只保存不含指针的位置（arenaRef）：
+// 百万行的 MemTable 只有少量 slab 需要 GC 扫描，而不是每行一个 []byte。
 type MemTable struct {
-	data map[int64][]byte // key -> value
-	keys []int64          // 排序的 keys
-	size int64            // 数据大小
-	mu   sync.RWMutex
+	data  map[int64]arenaRef // key -> value 在 arena 中的位置
+	arena memArena           // value 的存储
+	keys  []int64            // 排序的 keys
+	size  int64              // 数据大小
+	mu    sync.RWMutex
+
+	rowBounds rowBounds // _seq 与 _time 的范围（见 Table.MinSeq）
 }
 
 // NewMemTable 创建 MemTable
 func NewMemTable() *MemTable {
 	return &MemTable{
-		data: make(map[int64][]byte),
+		data: make(map[int64]arenaRef),
 		keys: make([]int64, 0),
 		size: 0,
 	}
 }
 
-// Put 插入数据
+// Put 插入数据（value 被复制到 arena，调用者之后可以复用）
 func (m *MemTable) Put(key int64, value []byte) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -30,12 +36,17 @@ func (m *MemTable) Put(key int64, value []byte) {
 	// 检查是否已存在
 	if _, exists := m.data[key]; !exists {
 		m.keys = append(m.keys, key)
-		// 保持 keys 有序
-		slices.Sort(m.keys)
+		// 保持 keys 有序（seq 递增写入时无需排序）
+		if n := len(m.keys); n > 1 && m.keys[n-2] > key {
+			slices.Sort(m.keys)
+		}
 	}
 
-	m.data[key] = value
+	m.data[key] = m.arena.alloc(value)
 	m.size += int64(len(value))
+	if rowTime, ok := encodedRowTime(value); ok {
+		m.rowBounds.add(key, rowTime)
+	}
 }
 
 // Get 查询数据
@@ -43,8 +54,23 @@ func (m *MemTable) Get(key int64) ([]byte, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	value, exists := m.data[key]
-	return value, exists
+	ref, exists := m.data[key]
+	if !exists {
+		return nil, false
+	}
+	return m.arena.get(ref), true
+}
+
+// First 获取最小的 key 及其数据
+func (m *MemTable) First() (int64, []byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.keys) == 0 {
+		return 0, nil, false
+	}
+	key := m.keys[0]
+	return key, m.arena.get(m.data[key]), true
 }
 
 // Size 获取大小
@@ -120,7 +146,7 @@ func (it *MemTableIterator) Value() []byte {
 		return nil
 	}
 	key := it.mt.keys[it.index]
-	return it.mt.data[key]
+	return it.mt.arena.get(it.mt.data[key])
 }
 
 // Reset 重置迭代器
@@ -133,9 +159,52 @@ func (m *MemTable) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.data = make(map[int64][]byte)
+	m.data = make(map[int64]arenaRef)
+	m.arena = memArena{}
 	m.keys = make([]int64, 0)
 	m.size = 0
+	m.rowBounds = rowBounds{}
+}
+
+// memArenaSlabSize arena 每个 slab 的大小，超过此大小的 value 单独占用一个 slab
+const memArenaSlabSize = 64 * 1024
+
+// arenaRef value 在 arena 中的位置（不含指针，map 中存放时不需要 GC 扫描）
+type arenaRef struct {
+	slab   uint32
+	offset uint32
+	size   uint32
+}
+
+// memArena 只追加的字节 slab，不是并发安全的（由 MemTable.mu 保护）
+//
+// 已写入的字节不会被修改或移动，get 返回的切片在 MemTable 被清空后仍然有效。
+type memArena struct {
+	slabs [][]byte
+	cur   int // 当前追加的 slab
+}
+
+// alloc 复制 value 到 arena 并返回其位置
+func (a *memArena) alloc(value []byte) arenaRef {
+	n := len(value)
+	if n > memArenaSlabSize {
+		a.slabs = append(a.slabs, slices.Clone(value))
+		return arenaRef{slab: uint32(len(a.slabs) - 1), size: uint32(n)}
+	}
+	if len(a.slabs) == 0 || cap(a.slabs[a.cur])-len(a.slabs[a.cur]) < n {
+		a.slabs = append(a.slabs, make([]byte, 0, memArenaSlabSize))
+		a.cur = len(a.slabs) - 1
+	}
+	slab := a.slabs[a.cur]
+	offset := len(slab)
+	a.slabs[a.cur] = append(slab, value...)
+	return arenaRef{slab: uint32(a.cur), offset: uint32(offset), size: uint32(n)}
+}
+
+// get 返回位置对应的 value（容量与长度相同，append 不会覆盖相邻的数据）
+func (a *memArena) get(ref arenaRef) []byte {
+	end := ref.offset + ref.size
+	return a.slabs[ref.slab][ref.offset:end:end]
 }
 
 // ImmutableMemTable 不可变的 MemTable
@@ -210,6 +279,27 @@ func (m *MemTableManager) GetActiveCount() int {
 	return m.active.Count()
 }
 
+// Oldest 获取尚未 flush 的最旧条目（seq 最小）
+// Immutable 按切换顺序排列，最早的 Immutable 中包含最旧的条目
+func (m *MemTableManager) Oldest() (int64, []byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, imm := range m.immutables {
+		if key, value, ok := imm.MemTable.First(); ok {
+			return key, value, true
+		}
+	}
+	return m.active.First()
+}
+
+// SetMaxSize 修改 MemTable 最大大小
+func (m *MemTableManager) SetMaxSize(maxSize int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxSize = maxSize
+}
+
 // ShouldSwitch 检查是否需要切换 MemTable
 func (m *MemTableManager) ShouldSwitch() bool {
 	m.mu.RLock()
@@ -269,6 +359,18 @@ func (m *MemTableManager) GetImmutables() []*ImmutableMemTable {
 	return immutables
 }
 
+// WALNumbers 返回 Active 和所有 Immutable MemTable 对应的 WAL 编号
+func (m *MemTableManager) WALNumbers() []int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	numbers := []int64{m.activeWAL}
+	for _, imm := range m.immutables {
+		numbers = append(numbers, imm.WALNumber)
+	}
+	return numbers
+}
+
 // GetActive 获取 Active MemTable（用于 Flush 时读取）
 func (m *MemTableManager) GetActive() *MemTable {
 	m.mu.RLock()
@@ -95,6 +95,51 @@ func TestMemTableClear(t *testing.T) {
 	t.Log("Clear test passed!")
 }
 
+func TestMemTableArena(t *testing.T) {
+	mt := NewMemTable()
+
+	// 跨越多个 slab，并包含超过 slab 大小的 value
+	buf := make([]byte, 0, memArenaSlabSize*2)
+	sizes := map[int64]int{}
+	for i := int64(1); i <= 300; i++ {
+		n := int(i * 37 % 1000)
+		if i%100 == 0 {
+			n = memArenaSlabSize + 1
+		}
+		buf = buf[:n]
+		for j := range buf {
+			buf[j] = byte(i)
+		}
+		mt.Put(i, buf) // 复用同一个缓冲区
+		sizes[i] = n
+	}
+
+	first, _ := mt.Get(1)
+	for i, n := range sizes {
+		value, ok := mt.Get(i)
+		if !ok || len(value) != n || cap(value) != n {
+			t.Fatalf("key %d: len=%d cap=%d, want %d", i, len(value), cap(value), n)
+		}
+		for _, b := range value {
+			if b != byte(i) {
+				t.Fatalf("key %d: value overwritten", i)
+			}
+		}
+	}
+	if len(mt.arena.slabs) < 4 {
+		t.Errorf("expected multiple slabs, got %d", len(mt.arena.slabs))
+	}
+
+	// 清空后之前返回的切片仍然有效
+	mt.Clear()
+	mt.Put(1, []byte{0xFF})
+	for _, b := range first {
+		if b != 1 {
+			t.Fatal("value changed after Clear")
+		}
+	}
+}
+
 func BenchmarkMemTablePut(b *testing.B) {
 	mt := NewMemTable()
 	value := make([]byte, 100)
@@ -0,0 +1,450 @@
+package srdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 从 SQLite、CSV 迁移数据
+//
+// 两种来源都先创建表，再按批直接写入 L0 SST（批量导入，不经过 WAL 与 MemTable，见 bulkLoad），
+// 每批 MigrateBatchSize 行。值按字段类型转换：字符串按类型解析，空字符串视为 NULL（String 字段除外），
+// 时间接受 RFC3339、"2006-01-02 15:04:05"、"2006-01-02" 与 Unix 秒。
+
+// MigrateBatchSize 迁移时每批写入的行数（每批生成一个 L0 SST 文件）
+const MigrateBatchSize = intoBatchSize
+
+// TypeMapping 将源数据库中声明的列类型（如 "VARCHAR(64)"、"DATETIME"）映射为字段类型
+type TypeMapping func(declared string) FieldType
+
+// SQLiteAffinityTypes 按 SQLite 的类型亲和性规则映射：含 INT 为 Int64；含 CHAR、CLOB、TEXT，
+// 或为 BLOB、空类型时为 String；含 REAL、FLOA、DOUB 为 Float64；其余（NUMERIC 亲和性）为 Float64
+func SQLiteAffinityTypes(declared string) FieldType {
+	upper := strings.ToUpper(declared)
+	switch {
+	case strings.Contains(upper, "INT"):
+		return Int64
+	case strings.Contains(upper, "CHAR"), strings.Contains(upper, "CLOB"), strings.Contains(upper, "TEXT"):
+		return String
+	case strings.Contains(upper, "BLOB"), strings.TrimSpace(upper) == "":
+		return String
+	default:
+		return Float64
+	}
+}
+
+// SQLiteTypes 在 SQLiteAffinityTypes 的基础上识别常用的类型名（ImportSQLite 的默认映射）：
+// 含 BOOL 为 Bool，含 DATE、TIME 为 Time，含 DECIMAL、NUMERIC、MONEY 为 Decimal
+func SQLiteTypes(declared string) FieldType {
+	upper := strings.ToUpper(declared)
+	switch {
+	case strings.Contains(upper, "BOOL"):
+		return Bool
+	case strings.Contains(upper, "DATE"), strings.Contains(upper, "TIME"):
+		return Time
+	case strings.Contains(upper, "DECIMAL"), strings.Contains(upper, "NUMERIC"), strings.Contains(upper, "MONEY"):
+		return Decimal
+	default:
+		return SQLiteAffinityTypes(declared)
+	}
+}
+
+// SQLiteImportOptions ImportSQLite 的选项
+type SQLiteImportOptions struct {
+	Tables []string    // 要导入的表，为空时导入所有表（不包括 sqlite_ 开头的内部表）
+	Types  TypeMapping // 列类型映射，nil 时为 SQLiteTypes
+}
+
+// ImportSQLite 从 SQLite 数据库创建同名的表并导入数据，返回每张表导入的行数
+//
+// src 由调用者使用任意 SQLite 驱动打开（srdb 不依赖具体的驱动）：
+//
+//	src, _ := sql.Open("sqlite3", "app.db")
+//	counts, err := db.ImportSQLite(src, nil)
+//
+// 列类型按 opts.Types 映射；NOT NULL 与主键列不可为 NULL，其余列为 Nullable；
+// 主键列与单列索引的列建立索引。表已存在时返回 ErrCodeTableExists。
+// 按表依次导入，失败时已导入的表与当前表已写入的批次保留，返回的行数包含这些数据。
+func (db *Database) ImportSQLite(src *sql.DB, opts *SQLiteImportOptions) (map[string]int64, error) {
+	if src == nil {
+		return nil, NewErrorf(ErrCodeInvalidParam, "source database is nil")
+	}
+	if opts == nil {
+		opts = &SQLiteImportOptions{}
+	}
+	types := opts.Types
+	if types == nil {
+		types = SQLiteTypes
+	}
+
+	tables := opts.Tables
+	if len(tables) == 0 {
+		var err error
+		if tables, err = sqliteTables(src); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[string]int64, len(tables))
+	for _, name := range tables {
+		fields, err := sqliteFields(src, name, types)
+		if err != nil {
+			return counts, WrapError(err, "read table %s", name)
+		}
+		schema, err := NewSchema(name, fields)
+		if err != nil {
+			return counts, err
+		}
+		table, err := db.CreateTable(name, schema)
+		if err != nil {
+			return counts, err
+		}
+
+		n, err := table.importSQLiteRows(src, name)
+		counts[name] = n
+		if err != nil {
+			return counts, WrapError(err, "import table %s", name)
+		}
+	}
+	return counts, nil
+}
+
+// sqliteTables 列出用户表
+func sqliteTables(src *sql.DB) ([]string, error) {
+	rows, err := src.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// sqliteFields 按 PRAGMA table_info 与 index_list 生成字段定义
+func sqliteFields(src *sql.DB, table string, types TypeMapping) ([]Field, error) {
+	rows, err := src.Query("PRAGMA table_info(" + quoteSQLiteIdent(table) + ")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields []Field
+	for rows.Next() {
+		var (
+			cid            int
+			name, declared string
+			notNull, pk    int
+			defaultValue   sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &declared, &notNull, &defaultValue, &pk); err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{
+			Name:     name,
+			Type:     types(declared),
+			Nullable: notNull == 0 && pk == 0,
+			Indexed:  pk > 0,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, NewErrorf(ErrCodeTableNotFound, "table %s not found or has no columns", table)
+	}
+
+	indexed, err := sqliteIndexedColumns(src, table)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		if slices.Contains(indexed, fields[i].Name) {
+			fields[i].Indexed = true
+		}
+	}
+	return fields, nil
+}
+
+// sqliteIndexedColumns 返回单列索引（包括 UNIQUE 约束）的列名
+func sqliteIndexedColumns(src *sql.DB, table string) ([]string, error) {
+	rows, err := src.Query("PRAGMA index_list(" + quoteSQLiteIdent(table) + ")")
+	if err != nil {
+		return nil, err
+	}
+	columns, err := scanColumn(rows, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	var indexed []string
+	for _, index := range columns {
+		rows, err := src.Query("PRAGMA index_info(" + quoteSQLiteIdent(index) + ")")
+		if err != nil {
+			return nil, err
+		}
+		names, err := scanColumn(rows, "name")
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 1 && names[0] != "" {
+			indexed = append(indexed, names[0])
+		}
+	}
+	return indexed, nil
+}
+
+// scanColumn 读取结果中名为 column 的列（其余列忽略）并关闭 rows
+func scanColumn(rows *sql.Rows, column string) ([]string, error) {
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	at := slices.Index(names, column)
+	if at < 0 {
+		return nil, fmt.Errorf("result has no %s column", column)
+	}
+
+	var values []string
+	dest := make([]any, len(names))
+	for rows.Next() {
+		var value sql.NullString
+		for i := range dest {
+			dest[i] = new(any)
+		}
+		dest[at] = &value
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		values = append(values, value.String)
+	}
+	return values, rows.Err()
+}
+
+// quoteSQLiteIdent 为标识符加双引号
+func quoteSQLiteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// importSQLiteRows 读取源表的所有行并分批导入
+func (t *Table) importSQLiteRows(src *sql.DB, name string) (int64, error) {
+	columns := make([]string, len(t.schema.Load().Fields))
+	for i, field := range t.schema.Load().Fields {
+		columns[i] = quoteSQLiteIdent(field.Name)
+	}
+	rows, err := src.Query("SELECT " + strings.Join(columns, ", ") + " FROM " + quoteSQLiteIdent(name))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	loader := t.newMigrateLoader()
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range dest {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return loader.n, err
+		}
+		row := make(map[string]any, len(values))
+		for i, field := range t.schema.Load().Fields {
+			value, err := migrateValue(values[i], field.Type)
+			if err != nil {
+				return loader.n, WrapError(err, "row %d, column %s", loader.seen+1, field.Name)
+			}
+			row[field.Name] = value
+		}
+		if err := loader.add(row); err != nil {
+			return loader.n, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return loader.n, err
+	}
+	return loader.n, loader.flush()
+}
+
+// ImportCSV 按 Schema 创建表并导入 CSV（第一行为列名），返回新表与导入的行数
+//
+// Schema 通常来自 Schema 文件（JSON，字段类型可以写类型名，如 "int32"）：
+//
+//	var schema srdb.Schema
+//	json.Unmarshal(schemaFile, &schema)
+//	table, n, err := db.ImportCSV(&schema, csvFile)
+//
+// 表已存在时返回 ErrCodeTableExists；导入失败时保留已写入的批次并返回表。
+func (db *Database) ImportCSV(schema *Schema, r io.Reader) (*Table, int64, error) {
+	if schema == nil || r == nil {
+		return nil, 0, NewErrorf(ErrCodeInvalidParam, "schema and reader are required")
+	}
+	schema, err := NewSchema(schema.Name, schema.Fields)
+	if err != nil {
+		return nil, 0, err
+	}
+	table, err := db.CreateTable(schema.Name, schema)
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := table.ImportCSV(r)
+	return table, n, err
+}
+
+// ImportCSV 导入 CSV（第一行为列名）到已有的表，返回导入的行数
+//
+// 列按名称对应字段，Schema 中不存在的列与计算列被忽略；缺少的字段按未提供处理。
+// 遇到无法转换的值时停止并返回带行号的错误，之前的行已写入。需要跳过无效行或进度回调时使用 Import。
+func (t *Table) ImportCSV(r io.Reader) (int64, error) {
+	result, err := t.Import(r, FormatCSV, nil)
+	return result.Rows, err
+}
+
+// migrateLoader 累积行并按批批量导入
+type migrateLoader struct {
+	table *Table
+	batch []map[string]any
+	seen  int64 // 已读取的行数
+	n     int64 // 已写入的行数
+}
+
+func (t *Table) newMigrateLoader() *migrateLoader {
+	return &migrateLoader{table: t}
+}
+
+func (l *migrateLoader) add(row map[string]any) error {
+	l.seen++
+	l.batch = append(l.batch, row)
+	if len(l.batch) >= MigrateBatchSize {
+		return l.flush()
+	}
+	return nil
+}
+
+func (l *migrateLoader) flush() error {
+	if len(l.batch) == 0 {
+		return nil
+	}
+	if err := l.table.bulkLoad(l.batch); err != nil {
+		return WrapError(err, "rows %d-%d", l.n+1, l.n+int64(len(l.batch)))
+	}
+	l.n += int64(len(l.batch))
+	l.batch = l.batch[:0]
+	return nil
+}
+
+// migrateTimeLayouts 迁移时接受的时间格式
+var migrateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// ConvertValue 将文本或字面量形式的值转换为字段类型的值（与插入后读取到的值类型一致）
+//
+// 字符串按 ImportCSV 的规则解析（时间可以是 RFC3339、"2006-01-02 15:04:05" 或 "2006-01-02"，
+// Decimal 可以是任意精度的数字字符串），其余按插入时的 Schema 转换规则转换。
+// 非字符串字段的空字符串转换为 nil。用于把 SQL 等外部输入的值与字段值比较或写入。
+func ConvertValue(value any, typ FieldType) (any, error) {
+	value, err := migrateValue(value, typ)
+	if err != nil || value == nil {
+		return value, err
+	}
+	return convertValue(value, typ)
+}
+
+// migrateValue 将 SQLite 或 CSV 中的值转换为字段类型可以接受的值
+//
+// 其余转换（数值之间、Decimal 等）由插入时的 Schema 转换完成。
+func migrateValue(value any, typ FieldType) (any, error) {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if typ == String {
+			return v, nil
+		}
+		if v == "" {
+			return nil, nil
+		}
+		return parseMigrateString(v, typ)
+	case int64:
+		switch typ {
+		case Bool:
+			return v != 0, nil
+		case String:
+			return strconv.FormatInt(v, 10), nil
+		}
+	case float64:
+		if typ == String {
+			return strconv.FormatFloat(v, 'g', -1, 64), nil
+		}
+	case bool:
+		if typ == String {
+			return strconv.FormatBool(v), nil
+		}
+	case time.Time:
+		if typ == String {
+			return v.Format(time.RFC3339Nano), nil
+		}
+	}
+	return value, nil
+}
+
+// parseMigrateString 按字段类型解析字符串
+func parseMigrateString(s string, typ FieldType) (any, error) {
+	switch typ {
+	case Int, Int8, Int16, Int32, Int64, Rune:
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	case Uint, Uint8, Uint16, Uint32, Uint64, Byte:
+		return strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	case Float32, Float64:
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case Bool:
+		return strconv.ParseBool(strings.TrimSpace(s))
+	case Time:
+		s = strings.TrimSpace(s)
+		for _, layout := range migrateTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(sec, 0), nil
+		}
+		return nil, fmt.Errorf("invalid time %q", s)
+	case Duration:
+		if ns, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+			return time.Duration(ns), nil
+		}
+		return time.ParseDuration(strings.TrimSpace(s))
+	case Object, Array:
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return normalizeJSONNumbers(v), nil
+	}
+	return s, nil
+}
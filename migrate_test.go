@@ -0,0 +1,327 @@
+package srdb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// fakeSQLite 只实现 ImportSQLite 使用的查询（sqlite_master、PRAGMA 与按列 SELECT）
+type fakeSQLite struct {
+	tables map[string]*fakeSQLiteTable
+}
+
+type fakeSQLiteTable struct {
+	columns []fakeSQLiteColumn
+	indexes map[string][]string // 索引名 → 列
+	rows    [][]driver.Value
+}
+
+type fakeSQLiteColumn struct {
+	name, declared string
+	notNull, pk    int
+}
+
+var fakeSQLiteDBs = map[string]*fakeSQLite{}
+
+func init() {
+	sql.Register("srdb-fake-sqlite", fakeSQLiteDriver{})
+}
+
+type fakeSQLiteDriver struct{}
+
+func (fakeSQLiteDriver) Open(dsn string) (driver.Conn, error) {
+	db, ok := fakeSQLiteDBs[dsn]
+	if !ok {
+		return nil, fmt.Errorf("unknown dsn %s", dsn)
+	}
+	return &fakeSQLiteConn{db: db}, nil
+}
+
+type fakeSQLiteConn struct {
+	db *fakeSQLite
+}
+
+func (c *fakeSQLiteConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLiteStmt{db: c.db, query: query}, nil
+}
+func (c *fakeSQLiteConn) Close() error              { return nil }
+func (c *fakeSQLiteConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("not supported") }
+
+type fakeSQLiteStmt struct {
+	db    *fakeSQLite
+	query string
+}
+
+func (s *fakeSQLiteStmt) Close() error  { return nil }
+func (s *fakeSQLiteStmt) NumInput() int { return 0 }
+func (s *fakeSQLiteStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+// pragmaArg 返回 PRAGMA xxx("name") 中的名称
+func pragmaArg(query string) string {
+	arg := query[strings.Index(query, "(")+1 : strings.LastIndex(query, ")")]
+	return strings.ReplaceAll(strings.Trim(arg, `"`), `""`, `"`)
+}
+
+func (s *fakeSQLiteStmt) Query([]driver.Value) (driver.Rows, error) {
+	q := s.query
+	switch {
+	case strings.HasPrefix(q, "SELECT name FROM sqlite_master"):
+		var rows [][]driver.Value
+		for name := range s.db.tables {
+			rows = append(rows, []driver.Value{name})
+		}
+		slices.SortFunc(rows, func(a, b []driver.Value) int { return strings.Compare(a[0].(string), b[0].(string)) })
+		return &fakeSQLiteRows{columns: []string{"name"}, rows: rows}, nil
+	case strings.HasPrefix(q, "PRAGMA table_info("):
+		t := s.db.tables[pragmaArg(q)]
+		res := &fakeSQLiteRows{columns: []string{"cid", "name", "type", "notnull", "dflt_value", "pk"}}
+		if t != nil {
+			for i, c := range t.columns {
+				res.rows = append(res.rows, []driver.Value{int64(i), c.name, c.declared, int64(c.notNull), nil, int64(c.pk)})
+			}
+		}
+		return res, nil
+	case strings.HasPrefix(q, "PRAGMA index_list("):
+		res := &fakeSQLiteRows{columns: []string{"seq", "name", "unique", "origin", "partial"}}
+		for name := range s.db.tables[pragmaArg(q)].indexes {
+			res.rows = append(res.rows, []driver.Value{int64(0), name, int64(0), "c", int64(0)})
+		}
+		return res, nil
+	case strings.HasPrefix(q, "PRAGMA index_info("):
+		res := &fakeSQLiteRows{columns: []string{"seqno", "cid", "name"}}
+		for _, t := range s.db.tables {
+			for _, column := range t.indexes[pragmaArg(q)] {
+				res.rows = append(res.rows, []driver.Value{int64(0), int64(0), column})
+			}
+		}
+		return res, nil
+	case strings.HasPrefix(q, "SELECT "):
+		from := strings.LastIndex(q, " FROM ")
+		t := s.db.tables[pragmaArg("("+q[from+6:]+")")]
+		var columns []string
+		var at []int
+		for _, c := range strings.Split(q[len("SELECT "):from], ", ") {
+			name := strings.Trim(c, `"`)
+			columns = append(columns, name)
+			at = append(at, slices.IndexFunc(t.columns, func(col fakeSQLiteColumn) bool { return col.name == name }))
+		}
+		res := &fakeSQLiteRows{columns: columns}
+		for _, row := range t.rows {
+			projected := make([]driver.Value, len(at))
+			for i, j := range at {
+				projected[i] = row[j]
+			}
+			res.rows = append(res.rows, projected)
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", q)
+}
+
+type fakeSQLiteRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeSQLiteRows) Columns() []string { return r.columns }
+func (r *fakeSQLiteRows) Close() error      { return nil }
+func (r *fakeSQLiteRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLiteTypes(t *testing.T) {
+	for declared, want := range map[string]FieldType{
+		"INTEGER":       Int64,
+		"BIGINT":        Int64,
+		"VARCHAR(255)":  String,
+		"text":          String,
+		"BLOB":          String,
+		"":              String,
+		"REAL":          Float64,
+		"DOUBLE":        Float64,
+		"BOOLEAN":       Bool,
+		"DATETIME":      Time,
+		"DATE":          Time,
+		"DECIMAL(10,2)": Decimal,
+		"NUMERIC":       Decimal,
+	} {
+		if got := SQLiteTypes(declared); got != want {
+			t.Errorf("SQLiteTypes(%q) = %v, want %v", declared, got, want)
+		}
+	}
+	// 亲和性规则不识别 BOOLEAN、DATETIME、NUMERIC
+	for _, declared := range []string{"BOOLEAN", "DATETIME", "NUMERIC"} {
+		if got := SQLiteAffinityTypes(declared); got != Float64 {
+			t.Errorf("SQLiteAffinityTypes(%q) = %v, want float64", declared, got)
+		}
+	}
+}
+
+func TestImportSQLite(t *testing.T) {
+	fakeSQLiteDBs["app"] = &fakeSQLite{tables: map[string]*fakeSQLiteTable{
+		"users": {
+			columns: []fakeSQLiteColumn{
+				{name: "id", declared: "INTEGER", pk: 1},
+				{name: "email", declared: "TEXT", notNull: 1},
+				{name: "active", declared: "BOOLEAN"},
+				{name: "created", declared: "DATETIME"},
+				{name: "balance", declared: "DECIMAL(10,2)"},
+			},
+			indexes: map[string][]string{"users_email": {"email"}, "users_multi": {"email", "active"}},
+			rows: [][]driver.Value{
+				{int64(1), []byte("a@example.com"), int64(1), "2024-03-01 10:00:00", 12.5},
+				{int64(2), "b@example.com", int64(0), nil, "7.25"},
+			},
+		},
+		"notes": {
+			columns: []fakeSQLiteColumn{{name: "body", declared: ""}, {name: "score", declared: "REAL"}},
+			rows:    [][]driver.Value{{"hi", int64(3)}},
+		},
+	}}
+	src, err := sql.Open("srdb-fake-sqlite", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	db, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	counts, err := db.ImportSQLite(src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts["users"] != 2 || counts["notes"] != 1 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+
+	users, err := db.GetTable("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := users.GetSchema()
+	id, _ := schema.GetField("id")
+	email, _ := schema.GetField("email")
+	active, _ := schema.GetField("active")
+	if id.Type != Int64 || !id.Indexed || id.Nullable {
+		t.Errorf("primary key should be an indexed non-null Int64: %+v", id)
+	}
+	if !email.Indexed || email.Nullable || active.Indexed || !active.Nullable {
+		t.Errorf("unexpected email %+v / active %+v", email, active)
+	}
+
+	row, err := users.Query().Eq("email", "a@example.com").First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := row.Data()
+	if data["active"] != true || !data["created"].(time.Time).Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected row %v", data)
+	}
+	if !data["balance"].(decimal.Decimal).Equal(decimal.RequireFromString("12.5")) {
+		t.Errorf("unexpected balance %v", data["balance"])
+	}
+	row, err = users.Query().Eq("id", int64(2)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := row.Data(); data["created"] != nil || data["active"] != false {
+		t.Errorf("unexpected row %v", data)
+	}
+
+	// 已存在的表
+	if _, err := db.ImportSQLite(src, &SQLiteImportOptions{Tables: []string{"notes"}}); !IsError(err, ErrCodeTableExists) {
+		t.Errorf("expected table exists, got %v", err)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	db, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var schema Schema
+	err = json.Unmarshal([]byte(`{
+		"Name": "orders",
+		"Fields": [
+			{"Name": "id", "Type": "uint32", "Indexed": true},
+			{"Name": "item", "Type": "string"},
+			{"Name": "qty", "Type": "int16"},
+			{"Name": "shipped", "Type": "time", "Nullable": true},
+			{"Name": "tags", "Type": "array", "Nullable": true}
+		]
+	}`), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "id,item,qty,shipped,tags,ignored\n" +
+		"1,apple,3,2024-05-01T08:00:00Z,\"[\"\"red\"\"]\",x\n" +
+		"2,,10,,,y\n"
+	table, n, err := db.ImportCSV(&schema, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 rows, got %d", n)
+	}
+
+	row, err := table.Query().Eq("id", uint32(1)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := row.Data()
+	if data["item"] != "apple" || data["qty"] != int16(3) || data["tags"] == nil {
+		t.Errorf("unexpected row %v", data)
+	}
+	if _, ok := data["ignored"]; ok {
+		t.Errorf("unknown column should be ignored: %v", data)
+	}
+	row, err = table.Query().Eq("id", uint32(2)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := row.Data(); data["item"] != "" || data["shipped"] != nil {
+		t.Errorf("unexpected row %v", data)
+	}
+
+	// 无法转换的值带行号返回
+	_, err = table.ImportCSV(strings.NewReader("id,qty\n3,1\n4,many\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected error on line 3, got %v", err)
+	}
+}
+
+func TestFieldTypeUnmarshalJSON(t *testing.T) {
+	var f Field
+	if err := json.Unmarshal([]byte(`{"Name": "n", "Type": "Int32"}`), &f); err != nil || f.Type != Int32 {
+		t.Errorf("expected int32 by name, got %v (%v)", f.Type, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Name": "n", "Type": 14}`), &f); err != nil || f.Type != FieldType(14) {
+		t.Errorf("expected numeric type, got %v (%v)", f.Type, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Name": "n", "Type": "blob"}`), &f); err == nil {
+		t.Error("expected error for unknown type name")
+	}
+}
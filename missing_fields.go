@@ -0,0 +1,85 @@
+package srdb
+
+// MissingFieldPolicy 查询结果中缺失字段的处理方式
+//
+// 新增字段之前写入的行没有该字段，NULL 值也不会写入行数据，默认情况下结果中不包含这些字段的键。
+// 按当前 Schema 填充后，每一行都包含相同的键，调用者不需要区分新旧数据。
+type MissingFieldPolicy int32
+
+const (
+	// MissingFieldOmit 省略缺失的字段（默认）
+	MissingFieldOmit MissingFieldPolicy = iota
+
+	// MissingFieldNil 缺失的字段返回 nil
+	MissingFieldNil
+
+	// MissingFieldDefault 缺失的字段返回默认值：nullable 字段为 nil，
+	// 其他字段为字段类型的零值（与现在写入缺少该字段的行后读回的值一致）
+	MissingFieldDefault
+)
+
+// String 返回策略名称
+func (p MissingFieldPolicy) String() string {
+	switch p {
+	case MissingFieldOmit:
+		return "omit"
+	case MissingFieldNil:
+		return "nil"
+	case MissingFieldDefault:
+		return "default"
+	default:
+		return "unknown"
+	}
+}
+
+// SetMissingFieldPolicy 设置查询结果中缺失字段的处理方式（见 MissingFieldPolicy），对之后读取的行生效
+//
+// 影响 Row.Data、Row.Scan、Rows.Collect 与 Rows.Scan；未指定 Select 时按当前 Schema 的所有字段
+// （不包括已弃用的字段）填充，指定 Select 时只填充选择的字段。Table.Get 返回存储的原始行，不受影响。
+func (t *Table) SetMissingFieldPolicy(policy MissingFieldPolicy) {
+	t.missingFields.Store(int32(policy))
+}
+
+// MissingFieldPolicy 返回查询结果中缺失字段的处理方式
+func (t *Table) MissingFieldPolicy() MissingFieldPolicy {
+	return MissingFieldPolicy(t.missingFields.Load())
+}
+
+// missingFieldPolicy 返回结果集所属表的缺失字段处理方式
+func (r *Rows) missingFieldPolicy() MissingFieldPolicy {
+	if r.table == nil {
+		return MissingFieldOmit
+	}
+	return r.table.MissingFieldPolicy()
+}
+
+// fillMissing 按 policy 为 data 补充缺失的字段（原地修改）
+// fields 为空表示 Schema 中所有未弃用的字段，否则只补充其中属于 Schema 的字段
+func (s *Schema) fillMissing(data map[string]any, fields []string, policy MissingFieldPolicy) {
+	if policy == MissingFieldOmit {
+		return
+	}
+	fill := func(field *Field) {
+		if _, ok := data[field.Name]; ok {
+			return
+		}
+		var value any
+		if policy == MissingFieldDefault && !field.Nullable {
+			value, _ = fieldZeroValue(field.Type)
+		}
+		data[field.Name] = value
+	}
+	if len(fields) == 0 {
+		for i := range s.Fields {
+			if !s.Fields[i].Deprecated {
+				fill(&s.Fields[i])
+			}
+		}
+		return
+	}
+	for _, name := range fields {
+		if field, err := s.GetField(name); err == nil {
+			fill(field)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package srdb
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestMissingFieldPolicy(t *testing.T) {
+	dir := t.TempDir()
+	table, err := OpenTable(&TableOptions{
+		Dir:    dir,
+		Name:   "users",
+		Fields: []Field{{Name: "name", Type: String}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"name": "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新增字段后重新打开，旧行缺少新字段
+	opts := &TableOptions{
+		Dir:  dir,
+		Name: "users",
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "age", Type: Int64},
+			{Name: "note", Type: String, Nullable: true},
+			{Name: "legacy", Type: String, Nullable: true, Deprecated: true},
+		},
+	}
+	table, err = OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+	if err := table.Insert(map[string]any{"name": "new", "age": int64(30), "note": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := func() map[string]any {
+		t.Helper()
+		rows, err := table.Query().Eq("name", "old").Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		if !rows.Next() {
+			t.Fatal("expected old row")
+		}
+		return rows.Row().Data()
+	}
+
+	data := first()
+	if _, ok := data["age"]; ok {
+		t.Errorf("expected age to be omitted by default, got %v", data)
+	}
+	if got := table.MissingFieldPolicy(); got != MissingFieldOmit {
+		t.Errorf("expected default policy omit, got %s", got)
+	}
+
+	table.SetMissingFieldPolicy(MissingFieldNil)
+	data = first()
+	for _, name := range []string{"age", "note"} {
+		if value, ok := data[name]; !ok || value != nil {
+			t.Errorf("expected explicit nil for %s, got %v", name, data)
+		}
+	}
+	if _, ok := data["legacy"]; ok {
+		t.Errorf("expected deprecated field to stay hidden, got %v", data)
+	}
+
+	table.SetMissingFieldPolicy(MissingFieldDefault)
+	data = first()
+	if data["age"] != int64(0) || data["note"] != nil {
+		t.Errorf("expected default values, got %v", data)
+	}
+	if _, ok := data["note"]; !ok {
+		t.Errorf("expected note key to be present, got %v", data)
+	}
+
+	// Collect 与 Scan 到 map：每一行的键相同，已有的值不被覆盖
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	collected := rows.Collect()
+	rows.Close()
+	var scanned []map[string]any
+	if err := table.Query().Scan(&scanned); err != nil {
+		t.Fatal(err)
+	}
+	for _, results := range [][]map[string]any{collected, scanned} {
+		if len(results) != 2 {
+			t.Fatalf("expected 2 rows, got %d", len(results))
+		}
+		keys := func(m map[string]any) []string {
+			var names []string
+			for name := range maps.Keys(m) {
+				if name != "_seq" && name != "_time" {
+					names = append(names, name)
+				}
+			}
+			return names
+		}
+		if len(keys(results[0])) != 3 || len(keys(results[1])) != 3 {
+			t.Errorf("expected 3 fields per row, got %v", results)
+		}
+		for _, row := range results {
+			if row["name"] == "new" && (row["age"] != int64(30) || row["note"] != "hi") {
+				t.Errorf("existing values were overwritten: %v", row)
+			}
+		}
+	}
+
+	// 指定 Select 时只填充选择的字段
+	rows, err = table.Query().Eq("name", "old").Select("name", "age").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("expected old row")
+	}
+	data = rows.Row().Data()
+	if len(data) != 2 || data["age"] != int64(0) {
+		t.Errorf("unexpected selected data %v", data)
+	}
+
+	// Get 返回原始行
+	row, err := table.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := row.Data["age"]; ok {
+		t.Errorf("expected Get to return the stored row, got %v", row.Data)
+	}
+}
@@ -0,0 +1,232 @@
+package srdb
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ModelInfo 已注册模型的记录（持久化在 database.meta 中，见 RegisterModel）
+type ModelInfo struct {
+	Table        string `json:"table"`
+	Type         string `json:"type"`        // Go 类型（包路径.类型名）
+	Fingerprint  string `json:"fingerprint"` // 结构体生成的 Schema 的结构校验和（不含注释）
+	RegisteredAt int64  `json:"registered_at"`
+}
+
+// TableNamer 模型可以实现该接口（值接收者）指定表名，否则按 Options.NamingStrategy 转换类型名（User -> user）
+type TableNamer interface {
+	TableName() string
+}
+
+// Model 通过 RegisterModel 注册的表的类型化句柄
+type Model[T any] struct {
+	table *Table
+}
+
+// RegisterModel 根据结构体创建表，表已存在时校验结构是否一致，返回类型化句柄
+//
+// 服务启动时为每个共享的结构体调用一次，结构体与已有表不兼容（增删字段、修改类型、
+// 索引或可空属性）时返回 ErrCodeSchemaMismatch，错误信息列出具体差异，
+// 而不是在第一次插入时才失败。仅注释不同视为兼容。
+// 模型的类型与结构指纹记录在数据库元数据中，可通过 Database.Models 查看。
+//
+//	users, err := srdb.RegisterModel(db, User{})
+//	users.Insert(User{Name: "alice"})
+//	list, err := users.Find(users.Query().Eq("name", "alice"))
+//
+// （Go 的方法不能有类型参数，因此是函数而不是 Database 的方法。）
+func RegisterModel[T any](db *Database, model T) (*Model[T], error) {
+	typ := reflect.TypeOf(model)
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, NewErrorf(ErrCodeInvalidParam, "model must be a struct value, got %T", model)
+	}
+
+	name := db.options.NamingStrategy.orDefault()(typ.Name())
+	if namer, ok := any(model).(TableNamer); ok {
+		name = namer.TableName()
+	}
+
+	fields, err := StructToFieldsWithNaming(model, db.options.NamingStrategy)
+	if err != nil {
+		return nil, NewErrorf(ErrCodeSchemaInvalid, "model %s: %v", typ, err)
+	}
+	schema, err := NewSchema(name, fields)
+	if err != nil {
+		return nil, NewErrorf(ErrCodeSchemaInvalid, "model %s: %v", typ, err)
+	}
+	fingerprint, err := schema.ComputeStructureChecksum()
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	table, exists := db.tables[name]
+	if exists && !table.schema.Load().IsCompatibleWith(schema) {
+		db.mu.Unlock()
+		return nil, NewErrorf(ErrCodeSchemaMismatch, "model %s is incompatible with table %s: %s",
+			typ, name, strings.Join(describeSchemaChanges(table.schema.Load(), schema), "; "))
+	}
+	if !exists {
+		table, err = db.createTable(name, schema)
+		if err != nil {
+			db.mu.Unlock()
+			return nil, err
+		}
+	}
+	err = db.recordModel(ModelInfo{
+		Table:        name,
+		Type:         typ.PkgPath() + "." + typ.Name(),
+		Fingerprint:  fingerprint,
+		RegisteredAt: db.options.Clock.Now().Unix(),
+	})
+	db.mu.Unlock()
+
+	if !exists {
+		db.notifyTableCreated(name, table)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Model[T]{table: table}, nil
+}
+
+// recordModel 更新模型注册记录，类型与指纹未变化时不写入元数据（调用者必须持有 db.mu 写锁）
+func (db *Database) recordModel(info ModelInfo) error {
+	i := slices.IndexFunc(db.metadata.Models, func(m ModelInfo) bool { return m.Table == info.Table })
+	if i >= 0 {
+		old := db.metadata.Models[i]
+		if old.Type == info.Type && old.Fingerprint == info.Fingerprint {
+			return nil
+		}
+		if old.Type != info.Type {
+			db.options.Logger.Info("[Database] Model type changed", "table", info.Table, "old", old.Type, "new", info.Type)
+		}
+	}
+
+	models := db.metadata.Models
+	updated := slices.Clone(models)
+	if i >= 0 {
+		updated[i] = info
+	} else {
+		updated = append(updated, info)
+	}
+	db.metadata.Models = updated
+	if err := db.saveMetadata(); err != nil {
+		db.metadata.Models = models
+		return err
+	}
+	return nil
+}
+
+// forgetModel 删除表的模型注册记录（调用者必须持有 db.mu 写锁，并负责保存元数据）
+func (db *Database) forgetModel(table string) {
+	db.metadata.Models = slices.DeleteFunc(slices.Clone(db.metadata.Models), func(m ModelInfo) bool {
+		return m.Table == table
+	})
+}
+
+// Models 返回已注册的模型（按注册顺序）
+func (db *Database) Models() []ModelInfo {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return slices.Clone(db.metadata.Models)
+}
+
+// describeSchemaChanges 列出从 old 到 new 的结构差异（不含注释）
+func describeSchemaChanges(old, new *Schema) []string {
+	var changes []string
+	for _, f := range new.Fields {
+		prev, err := old.GetField(f.Name)
+		if err != nil {
+			changes = append(changes, fmt.Sprintf("field %s added", f.Name))
+			continue
+		}
+		if prev.Type != f.Type {
+			changes = append(changes, fmt.Sprintf("field %s type %s -> %s", f.Name, prev.Type, f.Type))
+		}
+		if prev.Indexed != f.Indexed {
+			changes = append(changes, fmt.Sprintf("field %s indexed %v -> %v", f.Name, prev.Indexed, f.Indexed))
+		}
+		if prev.Nullable != f.Nullable {
+			changes = append(changes, fmt.Sprintf("field %s nullable %v -> %v", f.Name, prev.Nullable, f.Nullable))
+		}
+		if !slices.Equal(prev.IndexInclude, f.IndexInclude) {
+			changes = append(changes, fmt.Sprintf("field %s index include %v -> %v", f.Name, prev.IndexInclude, f.IndexInclude))
+		}
+		if prev.Computed != f.Computed {
+			changes = append(changes, fmt.Sprintf("field %s computed %q -> %q", f.Name, prev.Computed, f.Computed))
+		}
+	}
+	for _, f := range old.Fields {
+		if _, err := new.GetField(f.Name); err != nil {
+			changes = append(changes, fmt.Sprintf("field %s removed", f.Name))
+		}
+	}
+	if old.Name != new.Name {
+		changes = append(changes, fmt.Sprintf("schema name %s -> %s", old.Name, new.Name))
+	}
+	return changes
+}
+
+// Table 返回底层的表
+func (m *Model[T]) Table() *Table {
+	return m.table
+}
+
+// Insert 插入一条或多条记录
+func (m *Model[T]) Insert(values ...T) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return m.table.Insert(values)
+}
+
+// Get 按 _seq 读取一条记录
+func (m *Model[T]) Get(seq int64) (*T, error) {
+	row, err := m.table.Get(seq)
+	if err != nil {
+		return nil, err
+	}
+	var value T
+	if err := scanToStruct(row.Data, &value, m.table.naming, m.table.DecimalConversion()); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// Query 创建查询构建器
+func (m *Model[T]) Query() *QueryBuilder {
+	return m.table.Query()
+}
+
+// Find 执行查询并返回所有结果
+func (m *Model[T]) Find(qb *QueryBuilder) ([]T, error) {
+	var values []T
+	if err := qb.Scan(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// First 执行查询并返回第一条结果，没有结果时返回 ErrCodeNotFound
+func (m *Model[T]) First(qb *QueryBuilder) (*T, error) {
+	rows, err := qb.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, NewErrorf(ErrCodeNotFound, "no rows in table %s", m.table.schema.Load().Name)
+	}
+	var value T
+	if err := rows.Row().Scan(&value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
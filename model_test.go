@@ -0,0 +1,116 @@
+package srdb
+
+import (
+	"strings"
+	"testing"
+)
+
+type modelUser struct {
+	Name  string `srdb:"name;indexed;comment:用户名"`
+	Age   int64  `srdb:"age"`
+	Email string `srdb:"email"`
+}
+
+// modelUserV2 修改了 age 的类型并删除了 email
+type modelUserV2 struct {
+	Name string `srdb:"name;indexed;comment:名称"`
+	Age  string `srdb:"age"`
+}
+
+func (modelUserV2) TableName() string { return "model_user" }
+
+// modelUserRenamed 与 modelUser 结构相同（仅注释不同）
+type modelUserRenamed struct {
+	Name  string `srdb:"name;indexed;comment:姓名"`
+	Age   int64  `srdb:"age"`
+	Email string `srdb:"email"`
+}
+
+func (modelUserRenamed) TableName() string { return "model_user" }
+
+func TestRegisterModel(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := RegisterModel(db, modelUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users.Table().GetName() != "model_user" {
+		t.Fatalf("unexpected table name %s", users.Table().GetName())
+	}
+	if err := users.Insert(modelUser{Name: "alice", Age: 30}, modelUser{Name: "bob", Age: 25}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := users.Find(users.Query().Gt("age", 20))
+	if err != nil || len(list) != 2 {
+		t.Fatalf("expected 2 users, got %v (%v)", list, err)
+	}
+	bob, err := users.First(users.Query().Eq("name", "bob"))
+	if err != nil || bob.Age != 25 {
+		t.Fatalf("unexpected first result %+v (%v)", bob, err)
+	}
+	if _, err := users.First(users.Query().Eq("name", "carol")); !IsError(err, ErrCodeNotFound) {
+		t.Errorf("expected ErrCodeNotFound, got %v", err)
+	}
+	alice, err := users.Get(1)
+	if err != nil || alice.Name != "alice" {
+		t.Fatalf("unexpected get result %+v (%v)", alice, err)
+	}
+
+	// 重复注册（幂等）
+	if _, err := RegisterModel(db, modelUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RegisterModel(db, &modelUser{}); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for pointer model, got %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新部署：不兼容的结构在启动时即被发现
+	db, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	models := db.Models()
+	if len(models) != 1 || models[0].Table != "model_user" || !strings.HasSuffix(models[0].Type, ".modelUser") || models[0].Fingerprint == "" {
+		t.Fatalf("unexpected persisted models %+v", models)
+	}
+
+	_, err = RegisterModel(db, modelUserV2{})
+	if !IsError(err, ErrCodeSchemaMismatch) {
+		t.Fatalf("expected ErrCodeSchemaMismatch, got %v", err)
+	}
+	for _, want := range []string{"field age type int64 -> string", "field email removed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error: %v", want, err)
+		}
+	}
+
+	// 仅注释不同：兼容，记录新的类型
+	renamed, err := RegisterModel(db, modelUserRenamed{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := renamed.Find(renamed.Query()); err != nil || len(n) != 2 {
+		t.Errorf("expected 2 rows through renamed model, got %d (%v)", len(n), err)
+	}
+	if models := db.Models(); len(models) != 1 || !strings.HasSuffix(models[0].Type, ".modelUserRenamed") {
+		t.Errorf("expected model type to be updated, got %+v", models)
+	}
+
+	if err := db.DropTable("model_user"); err != nil {
+		t.Fatal(err)
+	}
+	if models := db.Models(); len(models) != 0 {
+		t.Errorf("expected model record to be removed with the table, got %+v", models)
+	}
+}
@@ -0,0 +1,387 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+修改与删除单行 (Update / Delete)
+
+表仍然是追加写入的：Update 写入同一 seq 的新版本，Delete 写入同一 seq 的删除标记（tombstone），
+与普通的行一样经过 WAL、MemTable 并 flush 到 SST。新版本的 _time 总是大于旧版本
+（max(当前时间, 旧版本 _time + 1)），同一 seq 存在多个版本时：
+
+  - MemTable 比 SST 新，Active 比 Immutable 新，直接取第一个找到的版本
+  - 多个 SST 文件中都存在时取 _time 最大的版本（Compaction 输出的文件可能排在更新的 L0 文件之后）
+  - Compaction 归并时保留 _time 最大的版本；删除标记在没有其他文件可能含有更早的版本时被丢弃
+
+删除标记的编码只有行头，没有字段与校验和：
+
+	[Magic: 4 "ROWD"][Seq: 8][Time: 8][FieldCount: 2 = 0]
+
+被修改或删除过的 seq 记录在表目录的 mutations 文件中（写入 WAL 之前持久化）。
+不读取行数据的查询路径（覆盖索引、Seqs 的索引等值查询、BatchRows、快照内的 key 计数）
+对这些 seq 回退到按 seq 读取最新版本；索引只追加新值，旧值上残留的 seq 在读取行后被条件过滤。
+连续聚合不随 Update/Delete 更新。
+*/
+
+const (
+	// SSTableRowTombstoneMagic 删除标记的行编码
+	SSTableRowTombstoneMagic = 0x524F5744 // "ROWD"
+
+	mutationsFile = "mutations"
+)
+
+// encodeTombstone 编码 seq 的删除标记
+func encodeTombstone(seq, time int64) []byte {
+	buf := make([]byte, 0, rowDecodeHeaderSize)
+	buf = binary.LittleEndian.AppendUint32(buf, SSTableRowTombstoneMagic)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(seq))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(time))
+	return binary.LittleEndian.AppendUint16(buf, 0)
+}
+
+// rowVersion 从行编码的头部读取写入时间与是否为删除标记（不解码字段）
+func rowVersion(data []byte) (time int64, deleted bool, ok bool) {
+	time, ok = encodedRowTime(data)
+	if !ok {
+		return 0, false, false
+	}
+	return time, binary.LittleEndian.Uint32(data[0:4]) == SSTableRowTombstoneMagic, true
+}
+
+// mutationSet 被 Update/Delete 修改过的 seq，持久化到表目录的 mutations 文件
+type mutationSet struct {
+	mu    sync.RWMutex
+	path  string
+	seqs  *Bitmap
+	empty atomic.Bool // 没有修改过的 seq 时跳过加锁（大多数表从不修改）
+}
+
+// loadMutationSet 加载修改过的 seq，文件不存在时为空
+func loadMutationSet(dir string) (*mutationSet, error) {
+	s := &mutationSet{path: filepath.Join(dir, mutationsFile), seqs: NewBitmap()}
+	data, err := os.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if err := s.seqs.UnmarshalBinary(data); err != nil {
+			return nil, NewErrorf(ErrCodeCorrupted, "invalid mutations file %s: %v", s.path, err)
+		}
+	}
+	s.empty.Store(s.seqs.IsEmpty())
+	return s, nil
+}
+
+// contains seq 是否被修改过
+func (s *mutationSet) contains(seq int64) bool {
+	if s.empty.Load() {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seqs.Contains(seq)
+}
+
+// add 记录修改过的 seq 并持久化
+//
+// 写入临时文件并 fsync 后重命名，再 fsync 目录：之后写入 WAL 的版本在崩溃后一定能找到对应的记录，
+// 不会出现 WAL 中有修改而 mutations 丢失或为空的情况。
+func (s *mutationSet) add(seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seqs.Contains(seq) {
+		return nil
+	}
+
+	seqs := s.seqs.Clone()
+	seqs.Add(seq)
+	data, err := seqs.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(filepath.Dir(s.path))
+	s.seqs = seqs
+	s.empty.Store(false)
+	return nil
+}
+
+// reset 清空修改记录（Clean）
+func (s *mutationSet) reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seqs = NewBitmap()
+	s.empty.Store(true)
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	syncDir(filepath.Dir(s.path))
+	return nil
+}
+
+// mutated seq 是否被 Update/Delete 修改过
+func (t *Table) mutated(seq int64) bool {
+	return t.mutations.contains(seq)
+}
+
+// Update 修改一行数据
+//
+// data 支持 map[string]any 或结构体（指针），其中的字段覆盖原值，未提供的字段保持不变，
+// 值为 nil 时设置为 NULL；计算列按修改后的数据重新计算。_seq 不变，_time 更新为修改时间。
+// 行不存在或已被删除时返回 ErrCodeNotFound。
+func (t *Table) Update(seq int64, data any) error {
+	rows, err := t.normalizeInsertData(data)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return NewErrorf(ErrCodeInvalidParam, "update expects a single row, got %d", len(rows))
+	}
+
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	// 串行化修改：新版本的 _time 基于当前的最新版本计算
+	t.mutateMu.Lock()
+	defer t.mutateMu.Unlock()
+
+	old, err := t.getWithPriority(PriorityHigh, seq)
+	if err != nil {
+		if IsError(err, ErrCodeChecksumMismatch) {
+			return err
+		}
+		return NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+
+	// 已弃用的字段不接受写入，原值原样保留
+	schema := t.schema.Load()
+	merged := make(map[string]any, len(old.Data)+len(rows[0]))
+	for name, value := range old.Data {
+		if !schema.isDeprecated(name) {
+			merged[name] = value
+		}
+	}
+	maps.Copy(merged, rows[0])
+
+	now := max(t.clock.Now().UnixNano(), old.Time+1)
+	converted, indexed, err := t.prepareRow(schema, merged, now)
+	if err != nil {
+		return err
+	}
+	for name, value := range old.Data {
+		if schema.isDeprecated(name) {
+			converted[name] = value
+		}
+	}
+
+	rowData, err := encodeSSTableRowBinary(&SSTableRow{Seq: seq, Time: now, Data: converted}, schema)
+	if err != nil {
+		return err
+	}
+	if t.rowChecksum {
+		rowData = appendRowChecksum(rowData)
+	}
+	if err := t.writeVersion(WALEntryTypePut, seq, rowData); err != nil {
+		return err
+	}
+	t.mirror(shadowOp{entryType: WALEntryTypePut, seq: seq, data: converted})
+	t.replicate(WALEntryTypePut, true, seq, rowData)
+
+	// 索引只追加新值：旧值上残留的 seq 在读取行后被条件过滤
+	t.indexManager.AddToIndexes(indexed, seq)
+	return nil
+}
+
+// Delete 删除一行数据
+//
+// 删除后 Get 返回 ErrCodeNotFound，查询不再返回该行；数据在之后的 Compaction 中被清除。
+// 行不存在或已被删除时返回 ErrCodeNotFound。内容与校验和不一致的行也可以删除。
+func (t *Table) Delete(seq int64) error {
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	t.mutateMu.Lock()
+	defer t.mutateMu.Unlock()
+
+	oldTime, deleted, found := t.latestVersion(seq)
+	if !found || deleted {
+		return NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+
+	now := max(t.clock.Now().UnixNano(), oldTime+1)
+	tombstone := encodeTombstone(seq, now)
+	if err := t.writeVersion(WALEntryTypeDelete, seq, tombstone); err != nil {
+		return err
+	}
+	t.mirror(shadowOp{entryType: WALEntryTypeDelete, seq: seq})
+	t.replicate(WALEntryTypeDelete, true, seq, tombstone)
+	return nil
+}
+
+// writeVersion 写入已有 seq 的新版本（或删除标记）：先记录到 mutations，再写入 WAL 与 MemTable
+func (t *Table) writeVersion(entryType byte, seq int64, rowData []byte) error {
+	if err := t.mutations.add(seq); err != nil {
+		return fmt.Errorf("record mutation: %w", err)
+	}
+	if err := t.walManager.Append(&WALEntry{Type: entryType, Seq: seq, Data: rowData}); err != nil {
+		return err
+	}
+	t.memtableManager.Put(seq, rowData)
+
+	t.lastWriteTime.Store(t.clock.Now().UnixNano())
+	if t.memtableManager.ShouldSwitch() {
+		go t.switchMemTable()
+	}
+	return nil
+}
+
+// latestVersion 返回 seq 最新版本的写入时间与是否为删除标记（只读取行头）
+func (t *Table) latestVersion(seq int64) (time int64, deleted, found bool) {
+	if data, ok := t.memtableManager.Get(seq); ok {
+		return rowVersion(data)
+	}
+	return t.sstManager.latestVersion(seq)
+}
+
+// exists seq 的最新版本是否存在且未被删除
+func (t *Table) exists(seq int64) bool {
+	_, deleted, found := t.latestVersion(seq)
+	return found && !deleted
+}
+
+// filterMutated 过滤不读取行数据时得到的 seq：被修改过的 seq 读取最新版本，
+// 已删除或不再满足 qb 条件的被移除
+func (qb *QueryBuilder) filterMutated(seqs []int64) []int64 {
+	return slices.DeleteFunc(seqs, func(seq int64) bool {
+		if !qb.table.mutated(seq) {
+			return false
+		}
+		row, err := qb.table.getWithPriority(qb.priority, seq)
+		return err != nil || !qb.matchRow(row)
+	})
+}
+
+// reindexMutations 重新索引 MemTable 中被 Update 修改过的行（恢复时调用）
+//
+// 索引在 flush 后才持久化，VerifyAndRepair 只补充大于索引最大 seq 的行，
+// 崩溃前修改过的行需要在 WAL 回放后重新加入索引。
+func (t *Table) reindexMutations() {
+	if t.mutations.empty.Load() {
+		return
+	}
+	var keys []int64
+	if active := t.memtableManager.GetActive(); active != nil {
+		keys = active.Keys()
+	}
+	for _, imm := range t.memtableManager.GetImmutables() {
+		keys = append(keys, imm.MemTable.Keys()...)
+	}
+	for _, seq := range keys {
+		if !t.mutated(seq) {
+			continue
+		}
+		if row, err := t.getWithPriority(PriorityNormal, seq); err == nil {
+			t.indexManager.AddToIndexes(row.Data, seq)
+		}
+	}
+}
+
+// latestVersion 返回 seq 在 SST 文件中最新版本的写入时间与是否为删除标记
+func (m *SSTableManager) latestVersion(seq int64) (time int64, deleted, found bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reader := m.latest(seq)
+	if reader == nil {
+		return 0, false, false
+	}
+	return reader.rowVersion(seq)
+}
+
+// latest 返回包含 seq 的文件中版本最新（_time 最大）的一个，调用者需持有读锁
+//
+// 没有被修改过的 seq 只存在于一个文件中（flush 过程中 MemTable 与 SST 同时存在的情况由调用者先查 MemTable 处理）。
+func (m *SSTableManager) latest(seq int64) *SSTableReader {
+	var best *SSTableReader
+	var bestTime int64
+	for i := len(m.readers) - 1; i >= 0; i-- {
+		reader := m.readers[i]
+		m.pinIndex(reader, seq)
+		time, _, found := reader.rowVersion(seq)
+		if found && (best == nil || time > bestTime) {
+			best, bestTime = reader, time
+		}
+	}
+	return best
+}
+
+// rowVersion 读取文件中 seq 的写入时间与是否为删除标记（只读取行头）
+func (r *SSTableReader) rowVersion(key int64) (time int64, deleted, found bool) {
+	if key < r.header.MinKey || key > r.header.MaxKey {
+		return 0, false, false
+	}
+	dataOffset, dataSize, found := r.btReader.Get(key)
+	if !found {
+		return 0, false, false
+	}
+	data, err := r.src.Slice(dataOffset, min(int(dataSize), rowDecodeHeaderSize))
+	if err != nil {
+		return 0, false, false
+	}
+	return rowVersion(data)
+}
+
+// olderVersions 输入文件之外是否还有数据源含有 seq（Compaction 丢弃删除标记之前调用）
+//
+// 先检查 MemTable 再检查 SST：flush 先注册新文件再移除 Immutable，按此顺序不会漏掉正在 flush 的旧版本。
+func (t *Table) olderVersions(seq int64, inputs []int64) bool {
+	if _, found := t.memtableManager.Get(seq); found {
+		return true
+	}
+	return t.sstManager.containsExcept(seq, inputs)
+}
+
+// containsExcept 除 fileNumbers 之外的文件中是否含有 seq（只查找索引）
+func (m *SSTableManager) containsExcept(seq int64, fileNumbers []int64) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, reader := range m.readers {
+		if seq < reader.header.MinKey || seq > reader.header.MaxKey {
+			continue
+		}
+		var fileNumber int64
+		if _, err := fmt.Sscanf(filepath.Base(reader.path), "%d.sst", &fileNumber); err == nil &&
+			slices.Contains(fileNumbers, fileNumber) {
+			continue
+		}
+		if _, _, found := reader.btReader.Get(seq); found {
+			return true
+		}
+	}
+	return false
+}
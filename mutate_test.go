@@ -0,0 +1,242 @@
+package srdb
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTableUpdateDelete(t *testing.T) {
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:  dir,
+		Name: "events",
+		Fields: []Field{
+			{Name: "host", Type: String, Indexed: true},
+			{Name: "value", Type: Int64},
+		},
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { table.Close() }()
+
+	flush := func() {
+		t.Helper()
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		for table.memtableManager.GetImmutableCount() > 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	value := func(seq int64) int64 {
+		t.Helper()
+		row, err := table.Get(seq)
+		if err != nil {
+			t.Fatalf("get %d: %v", seq, err)
+		}
+		return row.Data["value"].(int64)
+	}
+	check := func(stage string) {
+		t.Helper()
+		if v := value(3); v != 300 {
+			t.Errorf("%s: expected updated value 300, got %d", stage, v)
+		}
+		if _, err := table.Get(5); !IsError(err, ErrCodeNotFound) {
+			t.Errorf("%s: expected deleted row to be not found, got %v", stage, err)
+		}
+		seqs, err := table.Query().Seqs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(seqs) != 9 || slices.Contains(seqs, 5) {
+			t.Errorf("%s: unexpected seqs %v", stage, seqs)
+		}
+		rows, err := table.Query().Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := rows.Len(); n != 9 {
+			t.Errorf("%s: expected 9 rows, got %d", stage, n)
+		}
+		rows.Close()
+
+		// 索引中旧值上残留的 seq 被过滤
+		if seqs, _ := table.Query().Eq("host", "a").Seqs(); len(seqs) != 8 || slices.Contains(seqs, 4) {
+			t.Errorf("%s: unexpected seqs for host a: %v", stage, seqs)
+		}
+		if seqs, _ := table.Query().Eq("host", "b").Seqs(); !slices.Equal(seqs, []int64{4}) {
+			t.Errorf("%s: unexpected seqs for host b: %v", stage, seqs)
+		}
+		var matched []map[string]any
+		if rows, err := table.Query().Eq("host", "a").Rows(); err == nil {
+			matched = rows.Collect()
+			rows.Close()
+		}
+		if len(matched) != 8 {
+			t.Errorf("%s: expected 8 rows for host a, got %d", stage, len(matched))
+		}
+
+		// 列式读取同样只返回最新版本
+		br, err := table.Query().BatchRows([]string{"value"}, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer br.Close()
+		var batchSeqs []int64
+		for br.Next() {
+			batch := br.Batch()
+			for i, seq := range batch.Seqs {
+				batchSeqs = append(batchSeqs, seq)
+				if seq == 3 && batch.Columns[0].Int64s[i] != 300 {
+					t.Errorf("%s: expected batch value 300, got %d", stage, batch.Columns[0].Int64s[i])
+				}
+			}
+		}
+		if len(batchSeqs) != 9 || slices.Contains(batchSeqs, 5) {
+			t.Errorf("%s: unexpected batch seqs %v", stage, batchSeqs)
+		}
+	}
+
+	for i := range 10 {
+		if err := table.Insert(map[string]any{"host": "a", "value": int64(i + 1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	flush()
+
+	if err := table.Update(3, map[string]any{"value": 300}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Update(4, map[string]any{"host": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Delete(5); err != nil {
+		t.Fatal(err)
+	}
+	row, err := table.Get(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Data["host"] != "b" || row.Data["value"] != int64(4) {
+		t.Errorf("expected unchanged fields to be kept, got %v", row.Data)
+	}
+	if err := table.BuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	check("memtable")
+
+	if err := table.Delete(5); !IsError(err, ErrCodeNotFound) {
+		t.Errorf("expected ErrCodeNotFound deleting twice, got %v", err)
+	}
+	if err := table.Update(5, map[string]any{"value": 1}); !IsError(err, ErrCodeNotFound) {
+		t.Errorf("expected ErrCodeNotFound updating a deleted row, got %v", err)
+	}
+	if err := table.Update(99, map[string]any{"value": 1}); !IsError(err, ErrCodeNotFound) {
+		t.Errorf("expected ErrCodeNotFound for missing row, got %v", err)
+	}
+	if err := table.VerifyRow(5); !IsError(err, ErrCodeNotFound) {
+		t.Errorf("expected ErrCodeNotFound verifying a deleted row, got %v", err)
+	}
+
+	// 新旧版本分别位于两个 SST 文件
+	flush()
+	check("sst")
+
+	// 只合并新文件：旧版本仍在其他文件中，删除标记需要保留
+	manager := table.GetCompactionManager()
+	level0 := table.versionSet.GetCurrent().GetLevel(0)
+	if len(level0) != 2 {
+		t.Fatalf("expected 2 L0 files, got %d", len(level0))
+	}
+	newest := level0[0]
+	for _, file := range level0 {
+		if file.FileNumber > newest.FileNumber {
+			newest = file
+		}
+	}
+	if err := manager.DoCompaction(&CompactionTask{Level: 0, InputFiles: []*FileMetadata{newest}, OutputLevel: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if n := manager.GetStats().DroppedTombstones; n != 0 {
+		t.Errorf("expected tombstone to be kept, dropped %d", n)
+	}
+	check("partial compaction")
+
+	// 合并所有文件：旧版本被新版本替换，删除标记与被删除的行一起清除
+	level0 = table.versionSet.GetCurrent().GetLevel(0)
+	if err := manager.DoCompaction(&CompactionTask{Level: 0, InputFiles: level0, OutputLevel: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if n := manager.GetStats().DroppedTombstones; n != 1 {
+		t.Errorf("expected 1 dropped tombstone, got %d", n)
+	}
+	check("full compaction")
+
+	// 重新打开后修改记录仍然生效
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	table, err = OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("reopen")
+	if err := table.Update(3, map[string]any{"value": 301}); err != nil {
+		t.Fatal(err)
+	}
+	if v := value(3); v != 301 {
+		t.Errorf("expected 301 after second update, got %d", v)
+	}
+}
+
+func TestTombstoneEncoding(t *testing.T) {
+	schema, err := NewSchema("t", []Field{{Name: "a", Type: Int64}, {Name: "b", Type: Bool}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := encodeSSTableRowBinary(&SSTableRow{Seq: 7, Time: 42, Deleted: true}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != rowDecodeHeaderSize {
+		t.Errorf("expected %d bytes, got %d", rowDecodeHeaderSize, len(data))
+	}
+	row, err := decodeSSTableRowBinary(data, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !row.Deleted || row.Seq != 7 || row.Time != 42 || len(row.Data) != 0 {
+		t.Errorf("unexpected tombstone %+v", row)
+	}
+	if ts, deleted, ok := rowVersion(data); !ok || !deleted || ts != 42 {
+		t.Errorf("unexpected version %d %v %v", ts, deleted, ok)
+	}
+}
+
+func TestMutationSetPersist(t *testing.T) {
+	dir := t.TempDir()
+	s, err := loadMutationSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seq := range []int64{3, 9, 3} {
+		if err := s.add(seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(s.path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary mutations file left behind: %v", err)
+	}
+
+	loaded, err := loadMutationSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.contains(3) || !loaded.contains(9) || loaded.contains(4) {
+		t.Errorf("unexpected mutations after reload: %v", loaded.seqs.ToArray())
+	}
+}
@@ -0,0 +1,46 @@
+package srdb
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy 结构体字段名到数据库字段名的转换规则
+//
+// 用于没有通过 srdb tag 指定字段名的结构体字段，StructToFieldsWithNaming、
+// Insert 结构体映射与 Scan 使用同一规则。内置 SnakeCase（默认）、CamelCase、IdentityCase，
+// 也可以传入任意自定义函数：
+//
+//	opts.NamingStrategy = func(name string) string { return "f_" + srdb.SnakeCase(name) }
+type NamingStrategy func(name string) string
+
+// SnakeCase 转换为 snake_case（默认规则）：UserName -> user_name，HTTPServer -> http_server
+func SnakeCase(name string) string {
+	return camelToSnake(name)
+}
+
+// CamelCase 转换为小驼峰：UserName -> userName，HTTPServer -> httpServer，ID -> id
+func CamelCase(name string) string {
+	// 开头连续的大写字母（缩写）整体转小写，后接小写字母时最后一个大写字母属于下一个单词
+	n := 0
+	for n < len(name) && name[n] >= 'A' && name[n] <= 'Z' {
+		n++
+	}
+	if n > 1 && n < len(name) && unicode.IsLower(rune(name[n])) {
+		n--
+	}
+	return strings.ToLower(name[:n]) + name[n:]
+}
+
+// IdentityCase 保持结构体字段名不变：UserName -> UserName
+func IdentityCase(name string) string {
+	return name
+}
+
+// orDefault 未设置时使用 SnakeCase
+func (n NamingStrategy) orDefault() NamingStrategy {
+	if n == nil {
+		return SnakeCase
+	}
+	return n
+}
@@ -0,0 +1,114 @@
+package srdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := []struct {
+		input, snake, camel string
+	}{
+		{"UserName", "user_name", "userName"},
+		{"ID", "id", "id"},
+		{"UserID", "user_id", "userID"},
+		{"HTTPServer", "http_server", "httpServer"},
+		{"IDCard", "id_card", "idCard"},
+		{"A", "a", "a"},
+		{"name", "name", "name"},
+	}
+
+	for _, tt := range tests {
+		if got := SnakeCase(tt.input); got != tt.snake {
+			t.Errorf("SnakeCase(%q) = %q, expected %q", tt.input, got, tt.snake)
+		}
+		if got := CamelCase(tt.input); got != tt.camel {
+			t.Errorf("CamelCase(%q) = %q, expected %q", tt.input, got, tt.camel)
+		}
+		if got := IdentityCase(tt.input); got != tt.input {
+			t.Errorf("IdentityCase(%q) = %q", tt.input, got)
+		}
+	}
+}
+
+func TestNamingStrategyMapping(t *testing.T) {
+	type Event struct {
+		EventName string
+		UserID    int64
+		Payload   string `srdb:"field:body"`
+		Ignored   string `srdb:"-"`
+	}
+
+	tests := []struct {
+		name   string
+		naming NamingStrategy
+		fields []string
+	}{
+		{"default", nil, []string{"event_name", "user_id", "body"}},
+		{"camel", CamelCase, []string{"eventName", "userID", "body"}},
+		{"identity", IdentityCase, []string{"EventName", "UserID", "body"}},
+		{"custom", func(name string) string { return "f_" + strings.ToLower(name) }, []string{"f_eventname", "f_userid", "body"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := StructToFieldsWithNaming(Event{}, tt.naming)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, f := range fields {
+				names = append(names, f.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.fields, ",") {
+				t.Fatalf("expected fields %v, got %v", tt.fields, names)
+			}
+
+			opts := DefaultOptions(t.TempDir())
+			opts.NamingStrategy = tt.naming
+			db, err := OpenWithOptions(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			schema, err := NewSchema("events", fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			table, err := db.CreateTable("events", schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Insert 结构体映射
+			if err := table.Insert(&Event{EventName: "login", UserID: 7, Payload: "{}"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := table.Insert([]Event{{EventName: "logout", UserID: 7, Payload: "{}"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Scan 使用相同的规则
+			var events []Event
+			if err := table.Query().Eq(tt.fields[1], int64(7)).Scan(&events); err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 2 || events[0].EventName != "login" || events[1].UserID != 7 || events[1].Payload != "{}" {
+				t.Errorf("unexpected scan result %+v", events)
+			}
+
+			var first Event
+			row, err := table.Query().Eq(tt.fields[0], "logout").First()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := row.Scan(&first); err != nil {
+				t.Fatal(err)
+			}
+			if first.EventName != "logout" {
+				t.Errorf("unexpected row scan result %+v", first)
+			}
+		})
+	}
+}
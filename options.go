@@ -0,0 +1,174 @@
+package srdb
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// logLevelAll 不额外过滤任何日志的级别
+const logLevelAll = slog.Level(math.MinInt32)
+
+// logLevelHandler 在原 Handler 的基础上按可调整的级别过滤日志
+type logLevelHandler struct {
+	slog.Handler
+	level *slog.LevelVar
+}
+
+func (h *logLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.Handler.Enabled(ctx, level)
+}
+
+func (h *logLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logLevelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *logLevelHandler) WithGroup(name string) slog.Handler {
+	return &logLevelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// SetOption 在运行时修改配置，无需重新打开数据库
+//
+// 支持的配置项（名称与 Options 字段一致）：
+//   - "CompactionInterval", "GCInterval", "GCFileMinAge", "AutoFlushTimeout":
+//     time.Duration 或可被 time.ParseDuration 解析的字符串
+//   - "DisableAutoCompaction", "DisableGC": bool
+//   - "MemTableSize": 整数（字节）
+//   - "LogLevel": slog.Level 或 "debug"/"info"/"warn"/"error"（全局级别，子系统级别见 SetLogLevel）
+//
+// 修改立即对所有已打开的表及其后台任务生效，之后创建的表同样使用新配置。
+// 其他配置（如目录、层级大小限制、IOMode）影响磁盘结构或已打开的文件，需要重新打开数据库。
+func (db *Database) SetOption(name string, value any) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// 在副本上修改并验证，失败时不影响当前配置
+	opts := *db.options
+	var err error
+
+	switch name {
+	case "CompactionInterval":
+		opts.CompactionInterval, err = optionDuration(name, value)
+	case "GCInterval":
+		opts.GCInterval, err = optionDuration(name, value)
+	case "GCFileMinAge":
+		opts.GCFileMinAge, err = optionDuration(name, value)
+	case "AutoFlushTimeout":
+		opts.AutoFlushTimeout, err = optionDuration(name, value)
+		if err == nil && opts.AutoFlushTimeout <= 0 {
+			err = NewErrorf(ErrCodeInvalidParam, "AutoFlushTimeout must be positive, got %v", opts.AutoFlushTimeout)
+		}
+	case "DisableAutoCompaction":
+		opts.DisableAutoCompaction, err = optionBool(name, value)
+	case "DisableGC":
+		opts.DisableGC, err = optionBool(name, value)
+	case "MemTableSize":
+		opts.MemTableSize, err = optionInt64(name, value)
+	case "LogLevel":
+		level, err := optionLogLevel(name, value)
+		if err != nil {
+			return err
+		}
+		db.logLevel.Set(level)
+		return nil
+	default:
+		return NewErrorf(ErrCodeInvalidParam, "option %s cannot be changed at runtime", name)
+	}
+	if err != nil {
+		return err
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	// 应用到所有已打开的表（包括键值存储的内部表）
+	tables := slices.Collect(maps.Values(db.tables))
+	if db.kv != nil {
+		tables = append(tables, db.kv.table)
+	}
+	for _, table := range tables {
+		switch name {
+		case "AutoFlushTimeout":
+			err = table.SetAutoFlushTimeout(opts.AutoFlushTimeout)
+		case "MemTableSize":
+			err = table.SetMemTableSize(opts.MemTableSize)
+		default:
+			if table.compactionManager != nil {
+				table.compactionManager.applyRuntimeConfig(&opts)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	*db.options = opts
+	db.options.Logger.Info("[Database] Option changed", "name", name, "value", value)
+	return nil
+}
+
+// optionDuration 解析时间间隔类型的配置值
+func optionDuration(name string, value any) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, NewErrorf(ErrCodeInvalidParam, "option %s: %v", name, err)
+		}
+		return d, nil
+	default:
+		return 0, NewErrorf(ErrCodeInvalidParam, "option %s expects time.Duration, got %T", name, value)
+	}
+}
+
+// optionBool 解析布尔类型的配置值
+func optionBool(name string, value any) (bool, error) {
+	v, ok := value.(bool)
+	if !ok {
+		return false, NewErrorf(ErrCodeInvalidParam, "option %s expects bool, got %T", name, value)
+	}
+	return v, nil
+}
+
+// optionInt64 解析整数类型的配置值
+func optionInt64(name string, value any) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, NewErrorf(ErrCodeInvalidParam, "option %s: value %d overflows int64", name, v)
+		}
+		return int64(v), nil
+	default:
+		return 0, NewErrorf(ErrCodeInvalidParam, "option %s expects integer, got %T", name, value)
+	}
+}
+
+// optionLogLevel 解析日志级别配置值
+func optionLogLevel(name string, value any) (slog.Level, error) {
+	switch v := value.(type) {
+	case slog.Level:
+		return v, nil
+	case string:
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.ToUpper(v))); err != nil {
+			return 0, NewErrorf(ErrCodeInvalidParam, "option %s: %v", name, err)
+		}
+		return level, nil
+	default:
+		return 0, NewErrorf(ErrCodeInvalidParam, "option %s expects slog.Level or string, got %T", name, value)
+	}
+}
@@ -0,0 +1,136 @@
+package srdb
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDatabaseSetOption(t *testing.T) {
+	dir := "./test_db_set_option"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	schema, _ := NewSchema("users", []Field{{Name: "name", Type: String}})
+	table, err := db.CreateTable("users", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改 Compaction 间隔，立即对后台任务生效
+	if err := db.SetOption("CompactionInterval", 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetOption("GCInterval", "2m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetOption("DisableGC", true); err != nil {
+		t.Fatal(err)
+	}
+	if interval, _ := table.compactionManager.loopConfig(false); interval != 3*time.Second {
+		t.Errorf("expected compaction interval 3s, got %v", interval)
+	}
+	if interval, disabled := table.compactionManager.loopConfig(true); interval != 2*time.Minute || !disabled {
+		t.Errorf("expected gc interval 2m disabled, got %v %v", interval, disabled)
+	}
+
+	// 修改 MemTable 大小
+	if err := db.SetOption("MemTableSize", 2*1024*1024); err != nil {
+		t.Fatal(err)
+	}
+	if db.options.MemTableSize != 2*1024*1024 {
+		t.Errorf("expected MemTableSize 2MB, got %d", db.options.MemTableSize)
+	}
+
+	// 非法值不影响当前配置
+	if err := db.SetOption("GCInterval", time.Second); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+	if db.options.GCInterval != 2*time.Minute {
+		t.Errorf("GCInterval changed after failed SetOption: %v", db.options.GCInterval)
+	}
+	if err := db.SetOption("CompactionInterval", 10); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for wrong type, got %v", err)
+	}
+	if err := db.SetOption("Dir", "/tmp"); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for unsupported option, got %v", err)
+	}
+}
+
+func TestDatabaseSetOptionAutoFlush(t *testing.T) {
+	dir := "./test_db_set_option_flush"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	schema, _ := NewSchema("users", []Field{{Name: "name", Type: String}})
+	table, err := db.CreateTable("users", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认 30s 才会自动 flush，缩短后后台监控应按新间隔执行
+	if err := db.SetOption("AutoFlushTimeout", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"name": "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for table.memtableManager.GetActiveCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("memtable was not flushed after AutoFlushTimeout change")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestDatabaseSetOptionLogLevel(t *testing.T) {
+	dir := "./test_db_set_option_log"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	opts := DefaultOptions(dir)
+	opts.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	db.options.Logger.Debug("[Test] before")
+	if err := db.SetOption("LogLevel", "warn"); err != nil {
+		t.Fatal(err)
+	}
+	db.options.Logger.Info("[Test] filtered")
+	db.options.Logger.Warn("[Test] after")
+
+	out := buf.String()
+	if !strings.Contains(out, "before") || !strings.Contains(out, "after") {
+		t.Errorf("expected debug and warn logs, got: %s", out)
+	}
+	if strings.Contains(out, "filtered") {
+		t.Errorf("info log should be filtered after LogLevel=warn, got: %s", out)
+	}
+
+	if err := db.SetOption("LogLevel", 42); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+}
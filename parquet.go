@@ -0,0 +1,653 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// parquetRowGroupRows 每个 row group 缓冲的最大行数
+const parquetRowGroupRows = 64 * 1024
+
+// parquetRowGroupBytes 每个 row group 缓冲的最大数据量
+const parquetRowGroupBytes = 64 << 20
+
+// Parquet 物理类型
+const (
+	parquetBoolean   = 0
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetFloat     = 4
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+// Parquet ConvertedType（旧版读取器使用）
+const (
+	parquetConvertedNone            = -1
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMicros = 10
+	parquetConvertedUint8           = 11
+	parquetConvertedUint16          = 12
+	parquetConvertedUint32          = 13
+	parquetConvertedUint64          = 14
+	parquetConvertedInt8            = 15
+	parquetConvertedInt16           = 16
+	parquetConvertedInt32           = 17
+	parquetConvertedJSON            = 19
+)
+
+// Parquet 编码
+const (
+	parquetPlain = 0
+	parquetRLE   = 3
+)
+
+// parquetColumn 导出的一列及当前 row group 缓冲的数据
+type parquetColumn struct {
+	name      string
+	typ       FieldType // _seq 为 Int64，_time 为 Time
+	system    bool      // _seq 或 _time（REQUIRED）
+	physical  int32
+	converted int32
+	bitWidth  int8 // 整数的位宽（IntType 逻辑类型），0 表示不标注
+
+	defs   []bool // 每行是否非 NULL（OPTIONAL 列的定义级别）
+	values []byte // 非 NULL 值的 PLAIN 编码
+	bools  []bool // BOOLEAN 列的非 NULL 值（写入时按位打包）
+	nulls  int64
+
+	chunks []parquetChunk // 已写入的各 row group 的列块
+}
+
+// parquetChunk 已写入文件的列块
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int64
+	nulls  int64
+}
+
+// ExportParquet 将查询结果导出为 Parquet 文件（未压缩），返回导出的行数
+//
+// q 为 nil 时导出整张表。导出在快照上执行（q 已经基于快照时使用该快照），导出期间的写入不会出现在文件中，
+// compaction 也不会删除导出读取的文件。未使用 Select 时导出 _seq、_time 与所有未弃用的字段，
+// 否则按 Select 的顺序导出指定的字段（不支持计算列）。类型映射：
+//
+//   - 整数：Int8/16/32、Byte、Rune 为 INT32，Int/Int64 为 INT64，无符号整数带 UINT 标注（Uint32 以 INT32 存储位模式）
+//   - Float32/Float64：FLOAT/DOUBLE；Bool：BOOLEAN；String：BYTE_ARRAY（UTF8）
+//   - Decimal：BYTE_ARRAY（UTF8）的十进制字符串。字段没有固定的精度与小数位数，按字符串保证不丢失精度
+//   - Time 与 _time：INT64 TIMESTAMP（微秒，UTC），纳秒部分被截断
+//   - Duration：INT64 纳秒数，没有逻辑类型标注（pandas 中可用 pd.to_timedelta(col, unit="ns") 转换）
+//   - Object/Array：BYTE_ARRAY（JSON）的 JSON 文本
+//
+// _seq 与 _time 为 REQUIRED 列，其他字段均为 OPTIONAL（NULL 对应 Parquet 的 null）。
+func (t *Table) ExportParquet(w io.Writer, q *QueryBuilder) (int64, error) {
+	q, release, err := t.exportQuery(q, "ExportParquet")
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	columns, err := parquetColumns(t.schema.Load(), q.fields)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := q.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	pw := &parquetWriter{w: w, columns: columns}
+	if err := pw.write([]byte("PAR1")); err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		if err := pw.add(rows.currentRow.inner); err != nil {
+			return pw.rows, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return pw.rows, err
+	}
+	if err := pw.close(t.schema.Load().Name); err != nil {
+		return pw.rows, err
+	}
+	return pw.rows, nil
+}
+
+// parquetColumns 按 Select 的字段（为空时为 _seq、_time 与所有未弃用的字段）创建导出的列
+func parquetColumns(schema *Schema, fields []string) ([]*parquetColumn, error) {
+	fields = exportColumns(schema, fields)
+	columns := make([]*parquetColumn, 0, len(fields))
+	for _, name := range fields {
+		c := &parquetColumn{name: name, converted: parquetConvertedNone}
+		switch name {
+		case "_seq":
+			c.typ, c.system = Int64, true
+		case "_time":
+			c.typ, c.system = Time, true
+		default:
+			field, err := schema.GetField(name)
+			if err != nil {
+				return nil, NewErrorf(ErrCodeFieldNotFound, "field %s not found", name)
+			}
+			c.typ = field.Type
+		}
+		c.mapType()
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// mapType 设置字段类型对应的 Parquet 物理类型与标注（见 Table.ExportParquet）
+func (c *parquetColumn) mapType() {
+	switch c.typ {
+	case Int8:
+		c.physical, c.converted, c.bitWidth = parquetInt32, parquetConvertedInt8, 8
+	case Int16:
+		c.physical, c.converted, c.bitWidth = parquetInt32, parquetConvertedInt16, 16
+	case Int32, Rune:
+		c.physical, c.converted, c.bitWidth = parquetInt32, parquetConvertedInt32, 32
+	case Int, Int64, Duration:
+		c.physical = parquetInt64
+	case Uint8, Byte:
+		c.physical, c.converted, c.bitWidth = parquetInt32, parquetConvertedUint8, 8
+	case Uint16:
+		c.physical, c.converted, c.bitWidth = parquetInt32, parquetConvertedUint16, 16
+	case Uint32:
+		c.physical, c.converted, c.bitWidth = parquetInt32, parquetConvertedUint32, 32
+	case Uint, Uint64:
+		c.physical, c.converted, c.bitWidth = parquetInt64, parquetConvertedUint64, 64
+	case Float32:
+		c.physical = parquetFloat
+	case Float64:
+		c.physical = parquetDouble
+	case Bool:
+		c.physical = parquetBoolean
+	case Time:
+		c.physical, c.converted = parquetInt64, parquetConvertedTimestampMicros
+	case Object, Array:
+		c.physical, c.converted = parquetByteArray, parquetConvertedJSON
+	default: // String、Decimal
+		c.physical, c.converted = parquetByteArray, parquetConvertedUTF8
+	}
+}
+
+// signed 整数列是否有符号
+func (c *parquetColumn) signed() bool {
+	switch c.typ {
+	case Uint, Uint8, Uint16, Uint32, Uint64, Byte:
+		return false
+	}
+	return true
+}
+
+// append 追加一行的值，value 为 nil 表示 NULL
+func (c *parquetColumn) append(value any) error {
+	if value == nil {
+		c.defs = append(c.defs, false)
+		c.nulls++
+		return nil
+	}
+	c.defs = append(c.defs, true)
+
+	switch c.physical {
+	case parquetBoolean:
+		v, ok := value.(bool)
+		if !ok {
+			return c.mismatch(value)
+		}
+		c.bools = append(c.bools, v)
+
+	case parquetInt32:
+		var n int32
+		if c.signed() {
+			v, err := convertToInt64(value)
+			if err != nil {
+				return c.mismatch(value)
+			}
+			n = int32(v)
+		} else {
+			v, err := convertToUint64(value)
+			if err != nil {
+				return c.mismatch(value)
+			}
+			n = int32(uint32(v)) // Uint32 按位模式存储
+		}
+		c.values = binary.LittleEndian.AppendUint32(c.values, uint32(n))
+
+	case parquetInt64:
+		var n int64
+		switch c.typ {
+		case Time:
+			var v time.Time
+			switch tv := value.(type) {
+			case time.Time:
+				v = tv
+			case int64: // _time
+				v = time.Unix(0, tv)
+			default:
+				return c.mismatch(value)
+			}
+			n = v.UnixMicro()
+		case Duration:
+			v, ok := value.(time.Duration)
+			if !ok {
+				return c.mismatch(value)
+			}
+			n = int64(v)
+		case Uint, Uint64:
+			v, err := convertToUint64(value)
+			if err != nil {
+				return c.mismatch(value)
+			}
+			n = int64(v)
+		default:
+			v, err := convertToInt64(value)
+			if err != nil {
+				return c.mismatch(value)
+			}
+			n = v
+		}
+		c.values = binary.LittleEndian.AppendUint64(c.values, uint64(n))
+
+	case parquetFloat:
+		v, err := convertToFloat32(value)
+		if err != nil {
+			return c.mismatch(value)
+		}
+		c.values = binary.LittleEndian.AppendUint32(c.values, math.Float32bits(v))
+
+	case parquetDouble:
+		v, err := convertToFloat64(value)
+		if err != nil {
+			return c.mismatch(value)
+		}
+		c.values = binary.LittleEndian.AppendUint64(c.values, math.Float64bits(v))
+
+	default:
+		var data []byte
+		switch c.typ {
+		case Decimal:
+			v, ok := value.(decimal.Decimal)
+			if !ok {
+				return c.mismatch(value)
+			}
+			data = []byte(v.String())
+		case Object, Array:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return WrapError(err, "encode field %s as json", c.name)
+			}
+			data = encoded
+		default:
+			v, ok := value.(string)
+			if !ok {
+				return c.mismatch(value)
+			}
+			data = []byte(v)
+		}
+		c.values = binary.LittleEndian.AppendUint32(c.values, uint32(len(data)))
+		c.values = append(c.values, data...)
+	}
+	return nil
+}
+
+// mismatch 返回值与字段类型不符的错误
+func (c *parquetColumn) mismatch(value any) error {
+	return NewErrorf(ErrCodeFieldTypeMismatch, "field %s of type %s has value of type %T", c.name, c.typ, value)
+}
+
+// page 返回当前 row group 的数据页内容：OPTIONAL 列的定义级别（RLE）与 PLAIN 编码的值
+func (c *parquetColumn) page() []byte {
+	var page []byte
+	if !c.system {
+		page = appendParquetLevels(page, c.defs)
+	}
+	if c.physical == parquetBoolean {
+		packed := make([]byte, (len(c.bools)+7)/8)
+		for i, v := range c.bools {
+			if v {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append(page, packed...)
+	}
+	return append(page, c.values...)
+}
+
+// reset 清空当前 row group 缓冲的数据
+func (c *parquetColumn) reset() {
+	c.defs = c.defs[:0]
+	c.values = c.values[:0]
+	c.bools = c.bools[:0]
+	c.nulls = 0
+}
+
+// appendParquetLevels 追加定义级别（位宽 1），格式为 4 字节长度加 RLE 游程
+func appendParquetLevels(dst []byte, defs []bool) []byte {
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+	for i := 0; i < len(defs); {
+		j := i
+		for j < len(defs) && defs[j] == defs[i] {
+			j++
+		}
+		dst = binary.AppendUvarint(dst, uint64(j-i)<<1)
+		if defs[i] {
+			dst = append(dst, 1)
+		} else {
+			dst = append(dst, 0)
+		}
+		i = j
+	}
+	binary.LittleEndian.PutUint32(dst[start:], uint32(len(dst)-start-4))
+	return dst
+}
+
+// parquetWriter 按 row group 写入 Parquet 文件
+type parquetWriter struct {
+	w       io.Writer
+	offset  int64
+	columns []*parquetColumn
+
+	rows     int64   // 已写入的总行数
+	buffered int64   // 当前 row group 的行数
+	groups   []int64 // 各 row group 的行数
+}
+
+// write 写入数据并推进偏移
+func (pw *parquetWriter) write(data []byte) error {
+	n, err := pw.w.Write(data)
+	pw.offset += int64(n)
+	return err
+}
+
+// add 追加一行，缓冲的行数或数据量达到上限时写入 row group
+func (pw *parquetWriter) add(row *SSTableRow) error {
+	var size int64
+	for _, c := range pw.columns {
+		var value any
+		switch c.name {
+		case "_seq":
+			value = row.Seq
+		case "_time":
+			value = row.Time
+		default:
+			value = row.Data[c.name]
+		}
+		if err := c.append(value); err != nil {
+			return err
+		}
+		size += int64(len(c.values))
+	}
+	pw.rows++
+	pw.buffered++
+	if pw.buffered >= parquetRowGroupRows || size >= parquetRowGroupBytes {
+		return pw.flush()
+	}
+	return nil
+}
+
+// flush 将缓冲的行写为一个 row group，每列一个数据页
+func (pw *parquetWriter) flush() error {
+	if pw.buffered == 0 {
+		return nil
+	}
+	for _, c := range pw.columns {
+		page := c.page()
+		var header thriftWriter
+		header.begin()
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.i32(1, int32(pw.buffered))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.end()
+
+		chunk := parquetChunk{
+			offset: pw.offset,
+			size:   int64(len(header.buf) + len(page)),
+			values: pw.buffered,
+			nulls:  c.nulls,
+		}
+		if err := pw.write(header.buf); err != nil {
+			return err
+		}
+		if err := pw.write(page); err != nil {
+			return err
+		}
+		c.chunks = append(c.chunks, chunk)
+		c.reset()
+	}
+	pw.groups = append(pw.groups, pw.buffered)
+	pw.buffered = 0
+	return nil
+}
+
+// close 写入剩余的行与文件尾（FileMetaData）
+func (pw *parquetWriter) close(name string) error {
+	if err := pw.flush(); err != nil {
+		return err
+	}
+
+	var meta thriftWriter
+	meta.begin()
+	meta.i32(1, 1) // version
+
+	// schema：根节点之后是各列
+	meta.list(2, thriftStruct, len(pw.columns)+1)
+	meta.beginElem()
+	meta.str(4, name)
+	meta.i32(5, int32(len(pw.columns)))
+	meta.endStruct()
+	for _, c := range pw.columns {
+		meta.beginElem()
+		meta.i32(1, c.physical)
+		if c.system {
+			meta.i32(3, 0) // REQUIRED
+		} else {
+			meta.i32(3, 1) // OPTIONAL
+		}
+		meta.str(4, c.name)
+		if c.converted != parquetConvertedNone {
+			meta.i32(6, c.converted)
+		}
+		c.logicalType(&meta)
+		meta.endStruct()
+	}
+	meta.i64(3, pw.rows)
+
+	// row_groups
+	meta.list(4, thriftStruct, len(pw.groups))
+	for g, rows := range pw.groups {
+		meta.beginElem()
+		var total int64
+		meta.list(1, thriftStruct, len(pw.columns))
+		for _, c := range pw.columns {
+			chunk := c.chunks[g]
+			total += chunk.size
+			meta.beginElem()
+			meta.i64(2, chunk.offset)
+			meta.beginStruct(3)
+			meta.i32(1, c.physical)
+			meta.list(2, thriftI32, 2)
+			meta.listI32(parquetPlain)
+			meta.listI32(parquetRLE)
+			meta.list(3, thriftBinary, 1)
+			meta.listStr(c.name)
+			meta.i32(4, 0) // UNCOMPRESSED
+			meta.i64(5, chunk.values)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.beginStruct(12) // statistics
+			meta.i64(3, chunk.nulls)
+			meta.endStruct()
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64(2, total)
+		meta.i64(3, rows)
+		meta.endStruct()
+	}
+	meta.str(6, "srdb")
+	meta.end()
+
+	footer := binary.LittleEndian.AppendUint32(meta.buf, uint32(len(meta.buf)))
+	footer = append(footer, "PAR1"...)
+	return pw.write(footer)
+}
+
+// logicalType 写入 SchemaElement 的 LogicalType（与 ConvertedType 对应）
+func (c *parquetColumn) logicalType(w *thriftWriter) {
+	switch c.converted {
+	case parquetConvertedUTF8:
+		w.beginStruct(10)
+		w.beginStruct(1) // STRING
+		w.endStruct()
+		w.endStruct()
+	case parquetConvertedJSON:
+		w.beginStruct(10)
+		w.beginStruct(12) // JSON
+		w.endStruct()
+		w.endStruct()
+	case parquetConvertedTimestampMicros:
+		w.beginStruct(10)
+		w.beginStruct(8) // TIMESTAMP
+		w.bool(1, true)  // isAdjustedToUTC
+		w.beginStruct(2) // unit
+		w.beginStruct(2) // MICROS
+		w.endStruct()
+		w.endStruct()
+		w.endStruct()
+		w.endStruct()
+	default:
+		if c.bitWidth > 0 {
+			w.beginStruct(10)
+			w.beginStruct(10) // INTEGER
+			w.i8(1, c.bitWidth)
+			w.bool(2, c.signed())
+			w.endStruct()
+			w.endStruct()
+		}
+	}
+}
+
+// Thrift compact protocol 的类型
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftByte   = 3
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter Thrift compact protocol 的编码（只实现 Parquet 元数据用到的部分）
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // 每层结构体中上一个字段的编号
+}
+
+// begin 开始最外层的结构体
+func (w *thriftWriter) begin() {
+	w.last = append(w.last, 0)
+}
+
+// end 结束最外层的结构体
+func (w *thriftWriter) end() {
+	w.endStruct()
+}
+
+// field 写入字段头，与上一个字段的编号差在 1~15 之间时使用短格式
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.buf = binary.AppendVarint(w.buf, int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) bool(id int16, v bool) {
+	if v {
+		w.field(id, thriftTrue)
+	} else {
+		w.field(id, thriftFalse)
+	}
+}
+
+func (w *thriftWriter) i8(id int16, v int8) {
+	w.field(id, thriftByte)
+	w.buf = append(w.buf, byte(v))
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *thriftWriter) str(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.listStr(s)
+}
+
+// beginStruct 开始结构体类型的字段，之后的字段编号从 0 开始计算
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.last = append(w.last, 0)
+}
+
+// endStruct 写入 STOP 并回到外层结构体
+func (w *thriftWriter) endStruct() {
+	w.buf = append(w.buf, 0)
+	w.last = w.last[:len(w.last)-1]
+}
+
+// list 写入列表字段的头部，之后依次写入 n 个元素
+func (w *thriftWriter) list(id int16, elem byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elem)
+	} else {
+		w.buf = append(w.buf, 0xf0|elem)
+		w.buf = binary.AppendUvarint(w.buf, uint64(n))
+	}
+}
+
+// beginElem 开始列表中的结构体元素，以 endStruct 结束
+func (w *thriftWriter) beginElem() {
+	w.last = append(w.last, 0)
+}
+
+// listI32 写入列表中的 i32 元素
+func (w *thriftWriter) listI32(v int32) {
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+// listStr 写入列表中的字符串元素（也用于字符串字段的值）
+func (w *thriftWriter) listStr(s string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
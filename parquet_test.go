@@ -0,0 +1,336 @@
+package srdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// thriftReader 测试用的 Thrift compact protocol 解码，结构体解码为字段编号到值的映射
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftTrue:
+		return true
+	case thriftFalse:
+		return false
+	case thriftByte:
+		r.pos++
+		return int64(int8(r.buf[r.pos-1]))
+	case 4, thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		r.pos += n
+		return string(r.buf[r.pos-n : r.pos])
+	case thriftList:
+		header := r.buf[r.pos]
+		r.pos++
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unsupported thrift type")
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header := r.buf[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.varint())
+		}
+		fields[id] = r.value(header & 0x0f)
+		last = id
+	}
+}
+
+// readParquet 测试用的 Parquet 解码：返回 FileMetaData 以及每列的值（NULL 为 nil）
+func readParquet(t *testing.T, data []byte) (map[int16]any, map[string][]any) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatal("missing parquet magic")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{buf: data[len(data)-8-size : len(data)-8]}
+	meta := footer.readStruct()
+	if footer.pos != size {
+		t.Fatalf("footer has %d trailing bytes", size-footer.pos)
+	}
+
+	schema := meta[2].([]any)
+	columns := make(map[string][]any)
+	for _, group := range meta[4].([]any) {
+		for i, chunk := range group.(map[int16]any)[1].([]any) {
+			element := schema[i+1].(map[int16]any)
+			cm := chunk.(map[int16]any)[3].(map[int16]any)
+			name := cm[3].([]any)[0].(string)
+			if name != element[4] {
+				t.Fatalf("column %d: path %s does not match schema %s", i, name, element[4])
+			}
+
+			r := &thriftReader{buf: data, pos: int(cm[9].(int64))}
+			header := r.readStruct()
+			page := data[r.pos : r.pos+int(header[3].(int64))]
+			rows := int(header[5].(map[int16]any)[1].(int64))
+
+			defs := make([]bool, rows)
+			if element[3] == int64(1) {
+				n := int(binary.LittleEndian.Uint32(page))
+				levels := &thriftReader{buf: page[4 : 4+n]}
+				for i := 0; i < rows; {
+					run := int(levels.uvarint() >> 1)
+					defined := levels.buf[levels.pos] == 1
+					levels.pos++
+					for range run {
+						defs[i] = defined
+						i++
+					}
+				}
+				page = page[4+n:]
+			} else {
+				for i := range defs {
+					defs[i] = true
+				}
+			}
+
+			values := &thriftReader{buf: page}
+			bit := 0
+			for _, defined := range defs {
+				if !defined {
+					columns[name] = append(columns[name], nil)
+					continue
+				}
+				var v any
+				switch element[1] {
+				case int64(parquetBoolean):
+					v = page[bit/8]&(1<<(bit%8)) != 0
+					bit++
+				case int64(parquetInt32):
+					v = int32(binary.LittleEndian.Uint32(page[values.pos:]))
+					values.pos += 4
+				case int64(parquetInt64):
+					v = int64(binary.LittleEndian.Uint64(page[values.pos:]))
+					values.pos += 8
+				case int64(parquetFloat):
+					v = math.Float32frombits(binary.LittleEndian.Uint32(page[values.pos:]))
+					values.pos += 4
+				case int64(parquetDouble):
+					v = math.Float64frombits(binary.LittleEndian.Uint64(page[values.pos:]))
+					values.pos += 8
+				default:
+					n := int(binary.LittleEndian.Uint32(page[values.pos:]))
+					v = string(page[values.pos+4 : values.pos+4+n])
+					values.pos += 4 + n
+				}
+				columns[name] = append(columns[name], v)
+			}
+		}
+	}
+	return meta, columns
+}
+
+func TestExportParquet(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "orders",
+		Fields: []Field{
+			{Name: "small", Type: Int16, Nullable: true},
+			{Name: "u", Type: Uint32},
+			{Name: "ratio", Type: Float32},
+			{Name: "price", Type: Decimal, Nullable: true},
+			{Name: "name", Type: String, Nullable: true},
+			{Name: "ok", Type: Bool},
+			{Name: "at", Type: Time, Nullable: true},
+			{Name: "took", Type: Duration},
+			{Name: "meta", Type: Object, Nullable: true},
+			{Name: "tags", Type: Array},
+			{Name: "old", Type: Int64, Nullable: true, Deprecated: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	rows := []map[string]any{
+		{"small": int16(-3), "u": uint32(math.MaxUint32), "ratio": float32(0.5), "price": decimal.RequireFromString("12345678901234567890.125"),
+			"name": "a", "ok": true, "at": at, "took": 1500 * time.Millisecond, "meta": map[string]any{"k": 1}, "tags": []string{"x", "y"}},
+		{"small": nil, "u": uint32(7), "ratio": float32(-1), "price": nil, "name": nil, "ok": false, "at": nil,
+			"took": time.Duration(0), "meta": nil, "tags": []string{}},
+		{"small": int16(9), "u": uint32(0), "ratio": float32(2), "price": decimal.RequireFromString("-0.01"), "name": "c", "ok": true,
+			"at": at.Add(time.Hour), "took": -time.Second, "meta": map[string]any{}, "tags": []int{1}},
+	}
+	for _, row := range rows {
+		if err := table.Insert(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := table.ExportParquet(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 exported rows, got %d", n)
+	}
+	meta, columns := readParquet(t, buf.Bytes())
+	if meta[3] != int64(3) || meta[6] != "srdb" {
+		t.Errorf("unexpected file metadata: rows %v, created by %v", meta[3], meta[6])
+	}
+
+	// 列顺序与类型映射
+	expected := []struct {
+		name      string
+		physical  int64
+		converted any
+	}{
+		{"_seq", parquetInt64, nil},
+		{"_time", parquetInt64, int64(parquetConvertedTimestampMicros)},
+		{"small", parquetInt32, int64(parquetConvertedInt16)},
+		{"u", parquetInt32, int64(parquetConvertedUint32)},
+		{"ratio", parquetFloat, nil},
+		{"price", parquetByteArray, int64(parquetConvertedUTF8)},
+		{"name", parquetByteArray, int64(parquetConvertedUTF8)},
+		{"ok", parquetBoolean, nil},
+		{"at", parquetInt64, int64(parquetConvertedTimestampMicros)},
+		{"took", parquetInt64, nil},
+		{"meta", parquetByteArray, int64(parquetConvertedJSON)},
+		{"tags", parquetByteArray, int64(parquetConvertedJSON)},
+	}
+	schema := meta[2].([]any)
+	if len(schema) != len(expected)+1 || schema[0].(map[int16]any)[5] != int64(len(expected)) {
+		t.Fatalf("unexpected schema: %v", schema)
+	}
+	for i, want := range expected {
+		element := schema[i+1].(map[int16]any)
+		if element[4] != want.name || element[1] != want.physical || element[6] != want.converted {
+			t.Errorf("column %d: expected %+v, got %v", i, want, element)
+		}
+	}
+	if ts := schema[2].(map[int16]any)[10].(map[int16]any)[8].(map[int16]any); ts[1] != true {
+		t.Errorf("expected timestamps adjusted to UTC, got %v", ts)
+	}
+
+	check := func(name string, want ...any) {
+		t.Helper()
+		got := columns[name]
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s[%d]: expected %v (%T), got %v (%T)", name, i, want[i], want[i], got[i], got[i])
+			}
+		}
+	}
+	check("_seq", int64(1), int64(2), int64(3))
+	check("small", int32(-3), nil, int32(9))
+	check("u", int32(-1), int32(7), int32(0))
+	check("ratio", float32(0.5), float32(-1), float32(2))
+	check("price", "12345678901234567890.125", nil, "-0.01")
+	check("name", "a", nil, "c")
+	check("ok", true, false, true)
+	check("at", at.UnixMicro(), nil, at.Add(time.Hour).UnixMicro())
+	check("took", int64(1500*time.Millisecond), int64(0), int64(-time.Second))
+	check("meta", `{"k":1}`, nil, `{}`)
+	check("tags", `["x","y"]`, `[]`, `[1]`)
+
+	// Select 指定导出的字段与顺序，条件照常生效
+	buf.Reset()
+	if n, err := table.ExportParquet(&buf, table.Query().Eq("ok", true).Select("name", "_seq", "old")); err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d %v", n, err)
+	}
+	meta, columns = readParquet(t, buf.Bytes())
+	if len(meta[2].([]any)) != 4 {
+		t.Errorf("expected 3 selected columns, got %v", meta[2])
+	}
+	check("name", "a", "c")
+	check("_seq", int64(1), int64(3))
+	check("old", nil, nil)
+
+	if _, err := table.ExportParquet(&buf, table.Query().Select("missing")); !IsError(err, ErrCodeFieldNotFound) {
+		t.Errorf("expected ErrCodeFieldNotFound, got %v", err)
+	}
+}
+
+func TestExportParquetSnapshot(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 空表导出没有 row group 的文件
+	var buf bytes.Buffer
+	if n, err := table.ExportParquet(&buf, nil); err != nil || n != 0 {
+		t.Fatalf("expected empty export, got %d %v", n, err)
+	}
+	if meta, _ := readParquet(t, buf.Bytes()); meta[3] != int64(0) || meta[4] != nil && len(meta[4].([]any)) != 0 {
+		t.Errorf("expected no row groups, got %v", meta)
+	}
+
+	for i := range 5 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snap, err := table.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+	if err := table.Insert(map[string]any{"n": int64(5)}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if n, err := table.ExportParquet(&buf, snap.Query()); err != nil || n != 5 {
+		t.Fatalf("expected 5 rows from the snapshot, got %d %v", n, err)
+	}
+	buf.Reset()
+	if n, err := table.ExportParquet(&buf, table.Query()); err != nil || n != 6 {
+		t.Fatalf("expected 6 rows, got %d %v", n, err)
+	}
+}
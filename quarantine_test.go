@@ -0,0 +1,137 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCompactionQuarantinesCorruptRows 测试 Compaction 隔离校验和不一致的行
+func TestCompactionQuarantinesCorruptRows(t *testing.T) {
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:         dir,
+		Name:        "events",
+		Fields:      []Field{{Name: "msg", Type: String}},
+		RowChecksum: true,
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 生成多个 L0 文件
+	for batch := range 4 {
+		for i := range 10 {
+			if err := table.Insert(map[string]any{"msg": fmt.Sprintf("message-%02d", batch*10+i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	table.Close()
+
+	// 修改第 6 行（message-05）的数据
+	sstDir := filepath.Join(dir, "sst")
+	files, _ := os.ReadDir(sstDir)
+	var tamperedFile int64
+	for _, f := range files {
+		path := filepath.Join(sstDir, f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i := strings.Index(string(data), "message-05"); i >= 0 {
+			data[i] = 'M'
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			fmt.Sscanf(f.Name(), "%d.sst", &tamperedFile)
+		}
+	}
+	if tamperedFile == 0 {
+		t.Fatal("row data not found in sst files")
+	}
+
+	table, err = OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	if err := table.compactionManager.TriggerCompaction(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := table.compactionManager.GetStats()
+	if stats.CorruptRows != 1 || stats.InvalidRows != 0 {
+		t.Errorf("expected 1 corrupt row, got corrupt=%d invalid=%d", stats.CorruptRows, stats.InvalidRows)
+	}
+
+	// 损坏的行不再出现在输出文件中，其余行不受影响
+	if _, err := table.Get(6); err == nil {
+		t.Errorf("expected quarantined row to be gone, got %v", err)
+	}
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := rows.Collect(); len(data) != 39 {
+		t.Errorf("expected 39 rows after compaction, got %d", len(data))
+	}
+	if err := rows.Err(); err != nil {
+		t.Errorf("unexpected rows error: %v", err)
+	}
+	rows.Close()
+
+	// 隔离文件保存了原始编码
+	data, err := os.ReadFile(filepath.Join(sstDir, fmt.Sprintf("%06d.bad", tamperedFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq := int64(binary.LittleEndian.Uint64(data[0:8])); seq != 6 {
+		t.Errorf("expected quarantined seq 6, got %d", seq)
+	}
+	reasonLen := int(binary.LittleEndian.Uint16(data[8:10]))
+	if reason := string(data[10 : 10+reasonLen]); !strings.Contains(reason, "checksum mismatch") {
+		t.Errorf("unexpected reason: %q", reason)
+	}
+	raw := data[10+reasonLen+4:]
+	if size := int(binary.LittleEndian.Uint32(data[10+reasonLen:])); size != len(raw) {
+		t.Fatalf("expected %d bytes of row data, got %d", size, len(raw))
+	}
+	if !strings.Contains(string(raw), "Message-05") {
+		t.Error("quarantined row should keep the original encoding")
+	}
+	if err := verifyRowChecksum(raw); !IsError(err, ErrCodeChecksumMismatch) {
+		t.Errorf("expected ErrCodeChecksumMismatch for quarantined row, got %v", err)
+	}
+}
+
+func TestCheckCompactionRow(t *testing.T) {
+	schema, err := NewSchema("events", []Field{{Name: "n", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q := checkCompactionRow(&SSTableRow{Data: map[string]any{"n": int64(1)}}, nil, schema); q != nil {
+		t.Errorf("valid row should not be quarantined: %+v", q)
+	}
+	q := checkCompactionRow(&SSTableRow{Data: map[string]any{"n": "one"}}, nil, schema)
+	if q == nil || !q.invalid {
+		t.Errorf("expected schema violation, got %+v", q)
+	}
+	q = checkCompactionRow(nil, NewErrorf(ErrCodeChecksumMismatch, "row 1 checksum mismatch"), schema)
+	if q == nil || q.invalid {
+		t.Errorf("expected corrupt row, got %+v", q)
+	}
+}
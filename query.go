@@ -1,14 +1,20 @@
 package srdb
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"maps"
 	"reflect"
 	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Fieldset interface {
@@ -178,8 +184,27 @@ func (c compare) Match(fs Fieldset) bool {
 	return false
 }
 
+// compareOrdered 比较时间与 Decimal（不能用 == 或转换为 float64 比较），两个值不都是时间或都是 Decimal 时 ok 为 false
+func compareOrdered(left, right any) (c int, ok bool) {
+	switch l := left.(type) {
+	case time.Time:
+		if r, ok := right.(time.Time); ok {
+			return l.Compare(r), true
+		}
+	case decimal.Decimal:
+		if r, ok := right.(decimal.Decimal); ok {
+			return l.Cmp(r), true
+		}
+	}
+	return 0, false
+}
+
 // compareEqual 比较两个值是否相等
 func compareEqual(left, right any) bool {
+	if c, ok := compareOrdered(left, right); ok {
+		return c == 0
+	}
+
 	// 处理数值类型的比较
 	leftNum, leftIsNum := toFloat64(left)
 	rightNum, rightIsNum := toFloat64(right)
@@ -193,6 +218,10 @@ func compareEqual(left, right any) bool {
 
 // compareLess 比较 left < right
 func compareLess(left, right any) bool {
+	if c, ok := compareOrdered(left, right); ok {
+		return c < 0
+	}
+
 	// 数值比较
 	leftNum, leftIsNum := toFloat64(left)
 	rightNum, rightIsNum := toFloat64(right)
@@ -212,6 +241,10 @@ func compareLess(left, right any) bool {
 
 // compareGreater 比较 left > right
 func compareGreater(left, right any) bool {
+	if c, ok := compareOrdered(left, right); ok {
+		return c > 0
+	}
+
 	// 数值比较
 	leftNum, leftIsNum := toFloat64(left)
 	rightNum, rightIsNum := toFloat64(right)
@@ -348,7 +381,8 @@ func (g group) Match(fs Fieldset) bool {
 			return false
 		}
 	}
-	return true
+	// And 全部匹配；Or 没有任何条件匹配（空的 Or 视为匹配）
+	return g.and || len(g.exprs) == 0
 }
 
 func And(exprs ...Expr) Expr {
@@ -361,12 +395,30 @@ func Or(exprs ...Expr) Expr {
 
 type QueryBuilder struct {
 	conds     []Expr
-	fields    []string // 要选择的字段，nil 表示选择所有字段
+	fields    []string      // 要选择的字段，nil 表示选择所有字段
+	exprs     []*selectExpr // Select 中的计算列（见 query_select.go）
+	selectErr error         // Select 中表达式的解析错误，执行查询时返回
 	table     *Table
 	orderBy   string // 排序字段，仅支持 "_seq" 或索引字段
 	orderDesc bool   // 是否降序排序
 	offset    int    // 跳过的记录数
 	limit     int    // 返回的最大记录数，0 表示无限制
+	priority  QueryPriority
+	bypass    bool  // 全表扫描结束后释放扫描读入的缓存
+	maxMemory int64 // 物化数据的内存上限（见 MaxMemory），0 表示不限制
+
+	distinct       bool  // 按选择的字段去重（见 Distinct）
+	distinctMemory int64 // 去重键的内存上限，0 表示 DefaultDistinctMemory
+
+	systemColumns bool // 附加行所在位置的系统列（见 WithSystemColumns）
+
+	window timeWindow // _time 的范围（见 TimeRange）
+
+	tracer *queryTracer // 执行跟踪（见 Trace），nil 表示不跟踪
+
+	snap *Snapshot // 读取的快照（见 Table.Snapshot），nil 表示读取最新的数据
+
+	ctx context.Context // 行级策略使用的 context（见 WithContext），nil 表示不检查策略
 }
 
 func newQueryBuilder(table *Table) *QueryBuilder {
@@ -380,24 +432,50 @@ func (qb *QueryBuilder) where(expr Expr) *QueryBuilder {
 	return qb
 }
 
-// Match 检查数据是否匹配所有条件
+// Match 检查数据是否匹配所有条件（提供了 context 时还需要满足表的行级策略）
 func (qb *QueryBuilder) Match(data map[string]any) bool {
-	if len(qb.conds) == 0 {
-		return true
-	}
-
-	fs := newMapFieldset(data, qb.table.schema)
-	for _, cond := range qb.conds {
-		if !cond.Match(fs) {
-			return false
+	if len(qb.conds) > 0 {
+		fs := newMapFieldset(data, qb.table.schema.Load())
+		for _, cond := range qb.conds {
+			if !cond.Match(fs) {
+				return false
+			}
 		}
 	}
+	if policy := qb.policy(); policy != nil && !policy(qb.ctx, data) {
+		return false
+	}
 	return true
 }
 
 // Select 指定要选择的字段，如果不调用则返回所有字段
+//
+// 除字段名外还可以包含计算列表达式，例如 "temperature / 10.0 AS celsius"（见 query_select.go），
+// 表达式的错误在执行查询时返回。
 func (qb *QueryBuilder) Select(fields ...string) *QueryBuilder {
-	qb.fields = fields
+	var schema *Schema
+	if qb.table != nil {
+		schema = qb.table.schema.Load()
+	}
+
+	qb.fields, qb.exprs, qb.selectErr = nil, nil, nil
+	for _, item := range fields {
+		if isSelectField(item, schema) {
+			qb.fields = append(qb.fields, item)
+			continue
+		}
+		expr, err := parseSelectExpr(item, schema)
+		if err != nil {
+			if qb.selectErr == nil {
+				qb.selectErr = NewError(ErrCodeInvalidParam, err)
+			}
+			continue
+		}
+		qb.exprs = append(qb.exprs, expr)
+	}
+	if len(qb.exprs) == 0 && qb.selectErr == nil {
+		qb.fields = fields
+	}
 	return qb
 }
 
@@ -514,6 +592,22 @@ func (qb *QueryBuilder) Limit(n int) *QueryBuilder {
 	return qb
 }
 
+// BypassCache 全表扫描结束后释放扫描读入的页缓存（Linux 上通过 posix_fadvise(FADV_DONTNEED)）
+// 适用于导出等一次性大扫描，避免挤占点查询依赖的热数据缓存；
+// 同一文件上还有其他扫描进行时，在最后一个扫描结束时释放，其他平台上不释放页缓存
+func (qb *QueryBuilder) BypassCache() *QueryBuilder {
+	qb.bypass = true
+	return qb
+}
+
+// Priority 设置查询优先级
+// PriorityLow 的查询在存在高优先级请求（如 Table.Get）时按令牌桶限速读取 SST，
+// PriorityHigh 的查询会让低优先级查询为其让路
+func (qb *QueryBuilder) Priority(p QueryPriority) *QueryBuilder {
+	qb.priority = p
+	return qb
+}
+
 // Paginate 执行分页查询并返回结果、总记录数和错误
 // page: 页码，从 1 开始
 // pageSize: 每页记录数
@@ -536,10 +630,18 @@ func (qb *QueryBuilder) Paginate(page, pageSize int) (rows *Rows, total int, err
 	countQb := &QueryBuilder{
 		conds:   qb.conds,
 		fields:  qb.fields,
+		exprs:   qb.exprs,
 		table:   qb.table,
 		orderBy: "",      // 计数不需要排序
 		offset:  0,       // 计数不应用分页
 		limit:   0,
+
+		distinct:       qb.distinct,
+		distinctMemory: qb.distinctMemory,
+
+		maxMemory: qb.maxMemory,
+
+		ctx: qb.ctx,
 	}
 
 	countRows, err := countQb.Rows()
@@ -563,6 +665,80 @@ func (qb *QueryBuilder) Paginate(page, pageSize int) (rows *Rows, total int, err
 	return rows, total, nil
 }
 
+// Page 分页查询结果
+type Page struct {
+	Rows       *Rows // 当前页的数据
+	Total      int   // 满足条件的总记录数
+	Page       int   // 当前页码，从 1 开始
+	PerPage    int   // 每页记录数
+	TotalPages int   // 总页数
+}
+
+// Page 执行分页查询，一次调用同时返回当前页数据和总记录数
+//
+// 与 Paginate 不同，只执行一次查询：
+//   - 没有过滤条件且未排序时，总数直接由快照内的 key 统计（不解码数据），只读取当前页
+//   - 其他情况单次扫描所有匹配的记录，计数的同时只保留当前页
+//
+// page 小于 1 时按第 1 页处理；perPage 必须大于 0
+func (qb *QueryBuilder) Page(page, perPage int) (*Page, error) {
+	if qb.table == nil {
+		return nil, fmt.Errorf("table is nil")
+	}
+	if perPage <= 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "perPage must be positive, got %d", perPage)
+	}
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * perPage
+
+	// 不修改调用者的 QueryBuilder
+	scanQb := *qb
+	scanQb.offset = 0
+	scanQb.limit = 0
+
+	rows, err := scanQb.Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Page{Page: page, PerPage: perPage}
+	if !rows.cached && !qb.filtered() && qb.orderBy == "" && !qb.distinct {
+		// 惰性全表扫描：迭代时再应用分页
+		result.Total = rows.countVisible()
+		scanQb.offset = offset
+		scanQb.limit = perPage
+		result.Rows = rows
+	} else {
+		var pageRows []*SSTableRow
+		for rows.Next() {
+			if result.Total >= offset && result.Total < offset+perPage {
+				pageRows = append(pageRows, rows.currentRow.inner)
+			}
+			result.Total++
+		}
+		err := rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		result.Rows = &Rows{
+			schema:      qb.table.schema.Load(),
+			fields:      qb.fields,
+			qb:          &scanQb,
+			table:       qb.table,
+			cached:      true,
+			cachedRows:  pageRows,
+			cachedIndex: -1,
+			memory:      rows.memory,
+		}
+	}
+	result.TotalPages = (result.Total + perPage - 1) / perPage
+
+	return result, nil
+}
+
 // validateOrderBy 验证排序字段是否有效
 func (qb *QueryBuilder) validateOrderBy() error {
 	if qb.orderBy == "" {
@@ -574,8 +750,16 @@ func (qb *QueryBuilder) validateOrderBy() error {
 		return nil
 	}
 
-	// 检查该字段是否有索引
-	if _, exists := qb.table.indexManager.GetIndex(qb.orderBy); exists {
+	// 快照查询不使用索引（索引只反映最新的数据）
+	if qb.snap != nil {
+		return fmt.Errorf("OrderBy on a snapshot only supports '_seq', got '%s'", qb.orderBy)
+	}
+
+	// 检查该字段是否有索引（部分索引要求查询条件包含索引条件）
+	if idx, exists := qb.table.indexManager.GetIndex(qb.orderBy); exists {
+		if !idx.usableFor(qb.conds) {
+			return fmt.Errorf("OrderBy on field '%s' uses a partial index, the query must include its condition", qb.orderBy)
+		}
 		return nil
 	}
 
@@ -584,26 +768,58 @@ func (qb *QueryBuilder) validateOrderBy() error {
 }
 
 // Rows 返回所有匹配的数据（游标模式 - 惰性加载）
+//
+// 结果是创建时的快照：之后写入的行不可见。未设置 OrderBy 时按 _seq 严格升序返回，
+// 即使 flush 或 compaction 期间同一行同时存在于 MemTable 与 SST（或新旧 SST）中，
+// 也只返回一次，内容为最新的副本。
 func (qb *QueryBuilder) Rows() (*Rows, error) {
 	if qb.table == nil {
 		return nil, fmt.Errorf("table is nil")
 	}
+	if qb.selectErr != nil {
+		return nil, qb.selectErr
+	}
+
+	// 去重：基于不去重的结果集迭代（源结果集自行持有读锁）
+	if qb.distinct {
+		return qb.rowsDistinct()
+	}
+
+	// 快照查询期间快照固定的文件不会被删除
+	if !qb.snap.acquire() {
+		return nil, errSnapshotReleased
+	}
+	defer qb.snap.release()
+
+	// 创建期间阻止 Clean/Close
+	epoch := qb.readEpoch()
+	done, err := qb.table.beginRead(epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	// 验证排序字段
 	if err := qb.validateOrderBy(); err != nil {
 		return nil, err
 	}
 
+	// 创建时固定快照：之后写入的数据（seq 更大）对所有查询路径都不可见
 	rows := &Rows{
-		schema:  qb.table.schema,
-		fields:  qb.fields,
-		qb:      qb,
-		table:   qb.table,
-		visited: make(map[int64]bool),
+		schema:      qb.table.schema.Load(),
+		fields:      qb.fields,
+		qb:          qb,
+		table:       qb.table,
+		epoch:       epoch,
+		snapshotSeq: qb.readSeq(),
+		memory:      queryMemory{limit: qb.maxMemory},
 	}
 
+	logger := qb.table.logs.get(LogQuery)
+
 	// 如果设置了排序，使用排序后的结果集
 	if qb.orderBy != "" {
+		logger.Debug("[Query] Ordered scan", "table", qb.table.schema.Load().Name, "order_by", qb.orderBy, "desc", qb.orderDesc)
 		return qb.rowsWithOrder(rows)
 	}
 
@@ -611,30 +827,38 @@ func (qb *QueryBuilder) Rows() (*Rows, error) {
 	indexField, indexExpr := qb.findIndexableCondition()
 	if indexField != "" && indexExpr != nil {
 		// 使用索引查询（索引查询需要立即加载，因为需要从索引获取 seq 列表）
+		logger.Debug("[Query] Using index", "table", qb.table.schema.Load().Name, "field", indexField, "op", indexExpr.(compare).op)
+		qb.tracer.plan("index", indexField)
 		return qb.rowsWithIndexExpr(rows, indexField, indexExpr)
 	}
 
-	// 惰性加载：只初始化迭代器，不读取数据
-	// 1. 初始化 Active MemTable 迭代器
-	activeMemTable := qb.table.memtableManager.GetActive()
-	if activeMemTable != nil {
-		rows.memIterator = newMemtableIterator(activeMemTable.Keys())
-	}
-
-	// 2. 初始化 Immutable MemTables（稍后在 Next() 中迭代）
-	rows.immutableIndex = 0
-	rows.immutableIterator = nil
+	// 惰性加载：只固定各数据源的 key，不读取数据
+	// 按 Active → Immutable → SST 的顺序收集 key，期间发生的 MemTable 切换、flush 或 compaction
+	// 只会让同一条数据出现在多个数据源中（归并时去重），不会遗漏
 
-	// 3. 初始化 SST 文件迭代器
-	sstReaders := qb.table.sstManager.GetReaders()
-	rows.sstReaders = make([]*sstReader, len(sstReaders))
-	for i, reader := range sstReaders {
-		rows.sstReaders[i] = &sstReader{
-			keys:  reader.GetAllKeys(),
-			index: 0,
+	// 1. Active 与 Immutable MemTables（快照查询为快照固定的 MemTable）
+	// 行的 _time 都不在 TimeRange 内的 MemTable 与 SST 文件整体跳过
+	for _, mt := range qb.memtables() {
+		if keys := qb.memtableKeys(mt); keys != nil {
+			rows.sources = append(rows.sources, keys)
 		}
 	}
-	rows.sstIndex = 0
+
+	// 2. SST 文件（全表扫描，提示内核顺序预读）
+	// 根据列统计信息（zone map）跳过不可能包含匹配行的文件
+	readers, keys, skipped := qb.sstKeys(true)
+	qb.tracer.plan("scan", "")
+	qb.tracer.skipped(skipped)
+	logger.Debug("[Query] Full scan",
+		"table", qb.table.schema.Load().Name,
+		"memtables", len(rows.sources),
+		"sst_files", len(readers))
+	rows.sources = append(rows.sources, keys...)
+	rows.scanning = readers
+	rows.merge = newSeqMergeIterator(rows.sources, rows.snapshotSeq)
+	rows.ref = true
+	qb.table.iterators.Add(1)
+	rows.snap = qb.snap.retain()
 
 	// 不设置 cached，让 Next() 使用惰性加载
 	rows.cached = false
@@ -647,19 +871,37 @@ func (qb *QueryBuilder) Rows() (*Rows, error) {
 //
 // 支持的索引查询类型：
 //   - 等值查询 (Eq): O(1) 哈希查找
-//   - 范围查询 (Gt/Lt/Gte/Lte/Between): O(M) 遍历索引值并过滤，M = 唯一值数量
+//   - 范围查询 (Gt/Lt/Gte/Lte/Between): 数值与字符串字段 O(log M + K) 定位范围，其他类型 O(M) 遍历索引值并过滤，
+//     M = 唯一值数量，K = 范围内的值数量
 //   - 模糊查询 (Contains/StartsWith/EndsWith): O(M) 遍历索引值并匹配
 //   - 集合查询 (In/NotIn): O(K) 多次哈希查找，K = 集合大小
+//   - 复合索引：条件包含其所有字段的等值查询时（见 index_composite.go）
 //
 // 性能注意事项：
 //   - 索引查询的效果取决于数据的选择性（唯一值数量 vs 总行数）
 //   - 当唯一值数量接近总行数时，索引扫描可能不如全表扫描
-//   - 当前实现优先使用索引，不考虑成本估算（简化实现）
+//   - 当前实现优先使用索引（等值与 IN 条件优先），不考虑成本估算（简化实现）
 func (qb *QueryBuilder) findIndexableCondition() (string, Expr) {
+	// 快照查询不使用索引
+	if qb.snap != nil {
+		return "", nil
+	}
+	// 复合索引覆盖多个等值条件，选择性高于单字段索引
+	if field, cond := qb.findCompositeIndexCondition(); cond != nil {
+		return field, cond
+	}
+	// 等值与 IN 条件直接查找，优先于需要遍历或定位范围的条件
+	for _, cond := range qb.conds {
+		if cmp, ok := cond.(compare); ok && (cmp.op == "=" || cmp.op == "IN") {
+			if idx, exists := qb.table.indexManager.GetIndex(cmp.field); exists && idx.IsReady() && idx.usableFor(qb.conds) {
+				return cmp.field, cond
+			}
+		}
+	}
 	for _, cond := range qb.conds {
 		if cmp, ok := cond.(compare); ok {
-			// 检查该字段是否有索引
-			if idx, exists := qb.table.indexManager.GetIndex(cmp.field); exists && idx.IsReady() {
+			// 检查该字段是否有可用的索引（部分索引要求查询条件包含索引条件）
+			if idx, exists := qb.table.indexManager.GetIndex(cmp.field); exists && idx.IsReady() && idx.usableFor(qb.conds) {
 				// 支持的操作符
 				switch cmp.op {
 				case "=", ">", "<", ">=", "<=", "BETWEEN",
@@ -712,32 +954,49 @@ func (qb *QueryBuilder) rowsWithIndexEq(rows *Rows, indexField string, indexValu
 		return nil, fmt.Errorf("index on field %s not found", indexField)
 	}
 
-	// 从索引获取 seq 列表
-	seqs, err := idx.Get(indexValue)
+	// 覆盖索引：所需字段均内联在索引中时，直接由索引构造结果
+	if covered, ok := qb.rowsWithCoveringIndex(idx, indexField, indexValue); ok {
+		qb.tracer.plan("covering_index", indexField)
+		for _, row := range covered {
+			if err := rows.memory.charge(rowMemory(row)); err != nil {
+				return nil, err
+			}
+		}
+		rows.cachedRows = qb.applyOffsetLimit(covered)
+		rows.cached = true
+		rows.cachedIndex = -1
+		return rows, nil
+	}
+
+	// 从索引获取 seq 位图
+	seqs, err := idx.GetBitmap(indexValue)
 	if err != nil {
 		return nil, fmt.Errorf("index lookup failed: %w", err)
 	}
 
+	return qb.rowsWithIndexSeqs(rows, indexField, seqs)
+}
+
+// rowsWithIndexSeqs 读取索引匹配的行（缓存模式）
+//
+// 先与其他可以使用索引的条件组合（=、IN 求交集，!=、NOT IN 求差集），缩小候选范围，
+// 再按 seq 升序读取行并检查所有条件
+func (qb *QueryBuilder) rowsWithIndexSeqs(rows *Rows, indexField string, seqs *Bitmap) (*Rows, error) {
+	seqs = qb.combineIndexConditions(indexField, seqs)
+
 	// 如果没有结果，返回空结果集
-	if len(seqs) == 0 {
-		rows.cached = true
-		rows.cachedIndex = -1
+	if seqs.IsEmpty() {
 		rows.cachedRows = []*SSTableRow{}
-		return rows, nil
-	}
-
-	// 根据 seq 列表获取数据
-	rows.cachedRows = make([]*SSTableRow, 0, len(seqs))
-	for _, seq := range seqs {
-		row, err := qb.table.Get(seq)
-		if err != nil {
-			continue // 跳过获取失败的记录
+	} else {
+		seqList := seqs.ToArray()
+		if err := rows.memory.charge(int64(len(seqList)) * seqMemory); err != nil {
+			return nil, err
 		}
-
-		// 检查是否匹配所有其他条件（索引只能优化一个条件）
-		if qb.Match(row.Data) {
-			rows.cachedRows = append(rows.cachedRows, row)
+		fetched, err := qb.fetchRows(rows, seqList)
+		if err != nil {
+			return nil, err
 		}
+		rows.cachedRows = fetched
 	}
 
 	// 应用 offset 和 limit
@@ -750,6 +1009,118 @@ func (qb *QueryBuilder) rowsWithIndexEq(rows *Rows, indexField string, indexValu
 	return rows, nil
 }
 
+// combineIndexConditions 用其他字段上的索引条件过滤 seqs
+//
+// 只处理可以精确查找的条件（=、!=、IN、NOT IN），其他条件在读取行后检查
+func (qb *QueryBuilder) combineIndexConditions(indexField string, seqs *Bitmap) *Bitmap {
+	for _, cond := range qb.conds {
+		if seqs.IsEmpty() {
+			break
+		}
+		cmp, ok := cond.(compare)
+		if !ok || cmp.field == indexField {
+			continue
+		}
+		switch cmp.op {
+		case "=", "!=", "IN", "NOT IN":
+		default:
+			continue
+		}
+		idx, exists := qb.table.indexManager.GetIndex(cmp.field)
+		if !exists || !idx.IsReady() || !idx.usableFor(qb.conds) {
+			continue
+		}
+
+		var other *Bitmap
+		var err error
+		switch cmp.op {
+		case "=", "!=":
+			other, err = idx.GetBitmap(cmp.right)
+		default:
+			values, ok := cmp.right.([]any)
+			if !ok {
+				continue
+			}
+			other, err = indexLookupAll(idx, values)
+		}
+		if err != nil {
+			continue // 查找失败时不过滤，由条件检查保证结果正确
+		}
+
+		if cmp.op == "=" || cmp.op == "IN" {
+			seqs = seqs.And(other)
+		} else {
+			seqs = seqs.AndNot(other)
+		}
+	}
+	return seqs
+}
+
+// indexLookupAll 查找多个值，返回 seq 位图的并集
+func indexLookupAll(idx *SecondaryIndex, values []any) (*Bitmap, error) {
+	result := &Bitmap{}
+	for _, value := range values {
+		seqs, err := idx.GetBitmap(value)
+		if err != nil {
+			return nil, err
+		}
+		result.Merge(seqs)
+	}
+	return result, nil
+}
+
+// rowsWithCoveringIndex 尝试通过覆盖索引完成等值查询（不读取行数据）
+//
+// 仅当等值条件是唯一条件，且 Select 的字段都在 {_seq, 索引字段, Include 字段} 中时可用；
+// 结果行没有 _time，因此选择 _time 时不使用覆盖索引。
+func (qb *QueryBuilder) rowsWithCoveringIndex(idx *SecondaryIndex, indexField string, indexValue any) ([]*SSTableRow, bool) {
+	if len(qb.conds) != 1 || len(qb.fields) == 0 || len(qb.exprs) > 0 || qb.policy() != nil || qb.window.set {
+		return nil, false
+	}
+
+	var needed []string
+	for _, field := range qb.fields {
+		switch field {
+		case "_seq", indexField:
+		case "_time":
+			return nil, false
+		default:
+			needed = append(needed, field)
+		}
+	}
+
+	field, err := qb.table.schema.Load().GetField(indexField)
+	if err != nil {
+		return nil, false
+	}
+	value, err := convertValue(indexValue, field.Type)
+	if err != nil {
+		return nil, false
+	}
+
+	covered, ok, err := idx.GetCovered(indexValue, needed)
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	// 保留策略删除的行仍可能残留在索引中
+	expired := qb.table.expiredSeq()
+	result := make([]*SSTableRow, 0, len(covered))
+	for _, seq := range slices.Sorted(maps.Keys(covered)) {
+		// 被修改过的行，索引中保存的值可能已不是最新版本
+		if qb.table.mutated(seq) {
+			return nil, false
+		}
+		if seq <= expired {
+			continue
+		}
+		data := covered[seq]
+		data[indexField] = value
+		result = append(result, &SSTableRow{Seq: seq, Data: data})
+	}
+	return result, true
+}
+
 // rowsWithIndexIn 使用索引进行 IN/NOT IN 查询（O(K) 多次哈希查找，K = 集合大小）
 func (qb *QueryBuilder) rowsWithIndexIn(rows *Rows, indexField string, values any, negate bool) (*Rows, error) {
 	// 获取索引
@@ -764,118 +1135,56 @@ func (qb *QueryBuilder) rowsWithIndexIn(rows *Rows, indexField string, values an
 		return nil, fmt.Errorf("IN/NOT IN values must be []any, got %T", values)
 	}
 
-	if negate {
-		// NOT IN 查询：需要全表扫描然后排除索引匹配的记录
-		// 先收集所有要排除的 seq
-		excludeSeqs := make(map[int64]bool)
-		for _, value := range valueList {
-			seqs, err := idx.Get(value)
-			if err != nil {
-				continue // 跳过获取失败的值
-			}
-			for _, seq := range seqs {
-				excludeSeqs[seq] = true
-			}
-		}
-
-		// 从所有数据源收集 seq，排除匹配的记录
-		allSeqs := []int64{}
-
-		// 从 Active MemTable 收集
-		activeMemTable := qb.table.memtableManager.GetActive()
-		if activeMemTable != nil {
-			allSeqs = append(allSeqs, activeMemTable.Keys()...)
-		}
-
-		// 从 Immutable MemTables 收集
-		immutables := qb.table.memtableManager.GetImmutables()
-		for _, immutable := range immutables {
-			allSeqs = append(allSeqs, immutable.MemTable.Keys()...)
-		}
-
-		// 从 SST 文件收集
-		sstReaders := qb.table.sstManager.GetReaders()
-		for _, reader := range sstReaders {
-			allSeqs = append(allSeqs, reader.GetAllKeys()...)
-		}
-
-		// 去重并过滤排除的 seq
-		seqMap := make(map[int64]bool)
-		uniqueSeqs := []int64{}
-		for _, seq := range allSeqs {
-			if !seqMap[seq] && !excludeSeqs[seq] {
-				seqMap[seq] = true
-				uniqueSeqs = append(uniqueSeqs, seq)
-			}
+	// 对每个值执行索引查找并合并结果（跳过获取失败的值）
+	matched := &Bitmap{}
+	for _, value := range valueList {
+		seqs, err := idx.GetBitmap(value)
+		if err != nil {
+			continue
 		}
+		matched.Merge(seqs)
+	}
 
-		// 根据 seq 列表获取数据
-		rows.cachedRows = make([]*SSTableRow, 0, len(uniqueSeqs))
-		for _, seq := range uniqueSeqs {
-			row, err := qb.table.Get(seq)
-			if err != nil {
-				continue
-			}
-
-			// 检查是否匹配所有其他条件
-			if qb.Match(row.Data) {
-				rows.cachedRows = append(rows.cachedRows, row)
-			}
-		}
-	} else {
-		// IN 查询：对每个值执行索引查找并合并结果
-		seqMap := make(map[int64]bool) // 去重
-		allSeqs := []int64{}
+	if !negate {
+		// IN 查询
+		return qb.rowsWithIndexSeqs(rows, indexField, matched)
+	}
 
-		for _, value := range valueList {
-			seqs, err := idx.Get(value)
-			if err != nil {
-				continue // 跳过获取失败的值
-			}
+	// NOT IN 查询：从所有数据源收集 seq，排除索引匹配的记录
+	all := &Bitmap{}
 
-			for _, seq := range seqs {
-				if !seqMap[seq] {
-					seqMap[seq] = true
-					allSeqs = append(allSeqs, seq)
-				}
-			}
+	// 从 Active MemTable 收集
+	activeMemTable := qb.table.memtableManager.GetActive()
+	if activeMemTable != nil {
+		for _, seq := range activeMemTable.Keys() {
+			all.Add(seq)
 		}
+	}
 
-		// 如果没有结果，返回空结果集
-		if len(allSeqs) == 0 {
-			rows.cached = true
-			rows.cachedIndex = -1
-			rows.cachedRows = []*SSTableRow{}
-			return rows, nil
+	// 从 Immutable MemTables 收集
+	immutables := qb.table.memtableManager.GetImmutables()
+	for _, immutable := range immutables {
+		for _, seq := range immutable.MemTable.Keys() {
+			all.Add(seq)
 		}
+	}
 
-		// 根据 seq 列表获取数据
-		rows.cachedRows = make([]*SSTableRow, 0, len(allSeqs))
-		for _, seq := range allSeqs {
-			row, err := qb.table.Get(seq)
-			if err != nil {
-				continue
-			}
-
-			// 检查是否匹配所有其他条件
-			if qb.Match(row.Data) {
-				rows.cachedRows = append(rows.cachedRows, row)
-			}
+	// 从 SST 文件收集
+	_, sstKeys := qb.table.sstManager.snapshotKeys(nil, timeWindow{}, false)
+	for _, keys := range sstKeys {
+		for _, seq := range keys {
+			all.Add(seq)
 		}
 	}
 
-	// 应用 offset 和 limit
-	rows.cachedRows = qb.applyOffsetLimit(rows.cachedRows)
-
-	// 使用缓存模式
-	rows.cached = true
-	rows.cachedIndex = -1
-
-	return rows, nil
+	return qb.rowsWithIndexSeqs(rows, indexField, all.AndNot(matched))
 }
 
-// rowsWithIndexRange 使用索引进行范围查询（O(M) 遍历索引值并过滤，M = 唯一值数量）
+// rowsWithIndexRange 使用索引进行范围查询
 // 支持操作符：>, <, >=, <=, BETWEEN
+//
+// 数值与字符串字段合并该字段上的所有范围条件，在排序后的索引值中定位范围（O(log M + K)，见 rangeBitmap）；
+// 其他类型遍历所有索引值并过滤（O(M)，M = 唯一值数量）
 func (qb *QueryBuilder) rowsWithIndexRange(rows *Rows, indexField string, cmp compare) (*Rows, error) {
 	// 获取索引
 	idx, exists := qb.table.indexManager.GetIndex(indexField)
@@ -883,17 +1192,24 @@ func (qb *QueryBuilder) rowsWithIndexRange(rows *Rows, indexField string, cmp co
 		return nil, fmt.Errorf("index on field %s not found", indexField)
 	}
 
+	if r, ok := qb.indexRangeFor(indexField); ok {
+		matched, err := idx.rangeBitmap(r)
+		if err != nil {
+			return nil, fmt.Errorf("index range lookup failed: %w", err)
+		}
+		return qb.rowsWithIndexSeqs(rows, indexField, matched)
+	}
+
 	// 收集匹配的 seq
-	seqMap := make(map[int64]bool) // 去重
-	allSeqs := []int64{}
+	matched := &Bitmap{}
 
 	// 遍历索引中的所有值
-	err := idx.ForEach(func(value string, seqs []int64) bool {
+	err := idx.forEach(func(value string, seqs *Bitmap) bool {
 		// 将字符串值转换回原始类型进行比较
 		// 注意：索引中的值以字符串形式存储（通过 fmt.Sprint）
 		// 需要根据 Schema 的字段类型进行反序列化
 		var actualValue any
-		field, err := qb.table.schema.GetField(indexField)
+		field, err := qb.table.schema.Load().GetField(indexField)
 		if err != nil {
 			// 如果获取 Schema 失败，尝试直接使用字符串比较
 			actualValue = value
@@ -926,51 +1242,17 @@ func (qb *QueryBuilder) rowsWithIndexRange(rows *Rows, indexField string, cmp co
 
 		// 如果匹配，收集该值对应的所有 seq
 		if match {
-			for _, seq := range seqs {
-				if !seqMap[seq] {
-					seqMap[seq] = true
-					allSeqs = append(allSeqs, seq)
-				}
-			}
+			matched.Merge(seqs)
 		}
 
 		return true // 继续遍历
-	})
+	}, false)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to iterate index: %w", err)
 	}
 
-	// 如果没有结果，返回空结果集
-	if len(allSeqs) == 0 {
-		rows.cached = true
-		rows.cachedIndex = -1
-		rows.cachedRows = []*SSTableRow{}
-		return rows, nil
-	}
-
-	// 根据 seq 列表获取数据
-	rows.cachedRows = make([]*SSTableRow, 0, len(allSeqs))
-	for _, seq := range allSeqs {
-		row, err := qb.table.Get(seq)
-		if err != nil {
-			continue
-		}
-
-		// 检查是否匹配所有其他条件
-		if qb.Match(row.Data) {
-			rows.cachedRows = append(rows.cachedRows, row)
-		}
-	}
-
-	// 应用 offset 和 limit
-	rows.cachedRows = qb.applyOffsetLimit(rows.cachedRows)
-
-	// 使用缓存模式
-	rows.cached = true
-	rows.cachedIndex = -1
-
-	return rows, nil
+	return qb.rowsWithIndexSeqs(rows, indexField, matched)
 }
 
 // rowsWithIndexPattern 使用索引进行模糊查询（O(M) 遍历索引值并匹配，M = 唯一值数量）
@@ -989,11 +1271,10 @@ func (qb *QueryBuilder) rowsWithIndexPattern(rows *Rows, indexField string, cmp
 	}
 
 	// 收集匹配的 seq
-	seqMap := make(map[int64]bool) // 去重
-	allSeqs := []int64{}
+	matched := &Bitmap{}
 
 	// 遍历索引中的所有值
-	err := idx.ForEach(func(value string, seqs []int64) bool {
+	err := idx.forEach(func(value string, seqs *Bitmap) bool {
 		// 检查该值是否满足模式匹配条件
 		match := false
 		switch cmp.op {
@@ -1013,52 +1294,18 @@ func (qb *QueryBuilder) rowsWithIndexPattern(rows *Rows, indexField string, cmp
 
 		// 如果匹配，收集该值对应的所有 seq
 		if match {
-			for _, seq := range seqs {
-				if !seqMap[seq] {
-					seqMap[seq] = true
-					allSeqs = append(allSeqs, seq)
-				}
-			}
+			matched.Merge(seqs)
 		}
 
 		return true // 继续遍历
-	})
+	}, false)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to iterate index: %w", err)
 	}
 
-	// 如果没有结果，返回空结果集
-	if len(allSeqs) == 0 {
-		rows.cached = true
-		rows.cachedIndex = -1
-		rows.cachedRows = []*SSTableRow{}
-		return rows, nil
-	}
-
-	// 根据 seq 列表获取数据
-	rows.cachedRows = make([]*SSTableRow, 0, len(allSeqs))
-	for _, seq := range allSeqs {
-		row, err := qb.table.Get(seq)
-		if err != nil {
-			continue
-		}
-
-		// 检查是否匹配所有其他条件
-		if qb.Match(row.Data) {
-			rows.cachedRows = append(rows.cachedRows, row)
-		}
-	}
-
-	// 应用 offset 和 limit
-	rows.cachedRows = qb.applyOffsetLimit(rows.cachedRows)
-
-	// 使用缓存模式
-	rows.cached = true
-	rows.cachedIndex = -1
-
-	return rows, nil
-}
+	return qb.rowsWithIndexSeqs(rows, indexField, matched)
+}
 
 // deserializeIndexValue 将索引中的字符串值反序列化为原始类型
 func deserializeIndexValue(value string, fieldType FieldType) (any, error) {
@@ -1089,10 +1336,12 @@ func deserializeIndexValue(value string, fieldType FieldType) (any, error) {
 func (qb *QueryBuilder) rowsWithOrder(rows *Rows) (*Rows, error) {
 	if qb.orderBy == "_seq" {
 		// 按 _seq 排序
+		qb.tracer.plan("order_by_seq", "")
 		return qb.rowsOrderBySeq(rows)
 	}
 
 	// 按索引字段排序
+	qb.tracer.plan("order_by_index", qb.orderBy)
 	return qb.rowsOrderByIndex(rows, qb.orderBy)
 }
 
@@ -1101,22 +1350,18 @@ func (qb *QueryBuilder) rowsOrderBySeq(rows *Rows) (*Rows, error) {
 	// 收集所有 seq（从所有数据源）
 	seqList := []int64{}
 
-	// 1. 从 Active MemTable 收集
-	activeMemTable := qb.table.memtableManager.GetActive()
-	if activeMemTable != nil {
-		seqList = append(seqList, activeMemTable.Keys()...)
+	// 1. 从 Active 与 Immutable MemTables 收集
+	for _, mt := range qb.memtables() {
+		seqList = append(seqList, qb.memtableKeys(mt)...)
 	}
 
-	// 2. 从 Immutable MemTables 收集
-	immutables := qb.table.memtableManager.GetImmutables()
-	for _, immutable := range immutables {
-		seqList = append(seqList, immutable.MemTable.Keys()...)
+	// 2. 从 SST 文件收集（跳过列统计信息与时间范围不匹配的文件）
+	_, sstKeys, _ := qb.sstKeys(false)
+	for _, keys := range sstKeys {
+		seqList = append(seqList, keys...)
 	}
-
-	// 3. 从 SST 文件收集
-	sstReaders := qb.table.sstManager.GetReaders()
-	for _, reader := range sstReaders {
-		seqList = append(seqList, reader.GetAllKeys()...)
+	if err := rows.memory.charge(int64(len(seqList)) * seqMemory); err != nil {
+		return nil, err
 	}
 
 	// 去重（使用 map）
@@ -1128,8 +1373,12 @@ func (qb *QueryBuilder) rowsOrderBySeq(rows *Rows) (*Rows, error) {
 			uniqueSeqs = append(uniqueSeqs, seq)
 		}
 	}
+	if err := rows.memory.charge(int64(len(uniqueSeqs)) * (seqMemory + seqSetMemory)); err != nil {
+		return nil, err
+	}
 
 	// 排序
+	sortStart := qb.tracer.now()
 	if qb.orderDesc {
 		// 降序
 		sort.Slice(uniqueSeqs, func(i, j int) bool {
@@ -1139,20 +1388,14 @@ func (qb *QueryBuilder) rowsOrderBySeq(rows *Rows) (*Rows, error) {
 		// 升序
 		slices.Sort(uniqueSeqs)
 	}
+	qb.tracer.sorted(sortStart)
 
-	// 按排序后的 seq 获取数据
-	rows.cachedRows = make([]*SSTableRow, 0, len(uniqueSeqs))
-	for _, seq := range uniqueSeqs {
-		row, err := qb.table.Get(seq)
-		if err != nil {
-			continue // 跳过获取失败的记录
-		}
-
-		// 检查是否匹配过滤条件
-		if qb.Match(row.Data) {
-			rows.cachedRows = append(rows.cachedRows, row)
-		}
+	// 按排序后的 seq 获取数据，并检查是否匹配过滤条件
+	fetched, err := qb.fetchRows(rows, uniqueSeqs)
+	if err != nil {
+		return nil, err
 	}
+	rows.cachedRows = fetched
 
 	// 应用 offset 和 limit
 	rows.cachedRows = qb.applyOffsetLimit(rows.cachedRows)
@@ -1164,15 +1407,11 @@ func (qb *QueryBuilder) rowsOrderBySeq(rows *Rows) (*Rows, error) {
 	return rows, nil
 }
 
-// rowsOrderByIndex 按索引字段排序返回数据
-//
-// 实现策略：
-// 1. 使用 ForEach/ForEachDesc 从索引收集所有 (value, seqs) 对
-// 2. 按字段值（而非哈希）对这些对进行排序
-// 3. 按排序后的顺序获取数据
+// rowsOrderByIndex 按索引字段排序返回数据（惰性加载）
 //
-// 注意：虽然使用了索引，但需要在内存中排序所有索引条目。
-// 对于大量唯一值的字段，内存开销可能较大。
+// 按字段值的顺序逐个读取索引条目，再按 seq 读取行并检查其他条件；
+// 值相同的行按 seq 排序（方向与排序方向一致）。设置 Limit 时读取到足够的行后即停止，
+// 不需要读取或排序整张表。没有索引条目的行（如字段为 NULL）不会返回。
 func (qb *QueryBuilder) rowsOrderByIndex(rows *Rows, indexField string) (*Rows, error) {
 	// 获取索引
 	idx, exists := qb.table.indexManager.GetIndex(indexField)
@@ -1185,69 +1424,65 @@ func (qb *QueryBuilder) rowsOrderByIndex(rows *Rows, indexField string) (*Rows,
 		return nil, fmt.Errorf("index on field %s is not ready", indexField)
 	}
 
-	// 用于收集索引条目的结构
-	type indexEntry struct {
-		value string
-		seqs  []int64
-	}
-
-	// 收集所有索引条目
-	entries := []indexEntry{}
-	err := idx.ForEach(func(value string, seqs []int64) bool {
-		// 复制 seqs 避免引用问题
-		seqsCopy := make([]int64, len(seqs))
-		copy(seqsCopy, seqs)
-		entries = append(entries, indexEntry{
-			value: value,
-			seqs:  seqsCopy,
-		})
-		return true
-	})
+	// 按字段值排序的所有值（已缓存，只有新增的值需要排序）
+	values, err := idx.sortedValues()
 	if err != nil {
-		return nil, fmt.Errorf("failed to iterate index: %w", err)
+		return nil, fmt.Errorf("failed to sort index values: %w", err)
 	}
 
-	// 按字段值排序（而非哈希）
-	if qb.orderDesc {
-		// 降序
-		sort.Slice(entries, func(i, j int) bool {
-			return entries[i].value > entries[j].value
-		})
-	} else {
-		// 升序
-		sort.Slice(entries, func(i, j int) bool {
-			return entries[i].value < entries[j].value
-		})
+	rows.merge = &indexOrderIterator{
+		idx:    idx,
+		values: values,
+		desc:   qb.orderDesc,
+		maxSeq: rows.snapshotSeq,
 	}
+	rows.ref = true
+	qb.table.iterators.Add(1)
 
-	// 按排序后的顺序收集所有 seq
-	allSeqs := []int64{}
-	for _, entry := range entries {
-		allSeqs = append(allSeqs, entry.seqs...)
-	}
+	// 不设置 cached，让 Next() 使用惰性加载
+	rows.cached = false
+
+	return rows, nil
+}
 
-	// 根据 seq 列表获取数据
-	rows.cachedRows = make([]*SSTableRow, 0, len(allSeqs))
-	for _, seq := range allSeqs {
-		row, err := qb.table.Get(seq)
+// fetchRows 按给定顺序读取 seq 对应的行，只保留匹配所有条件的行
+//
+// 重复的 seq 只读取一次，快照之后写入的行不可见，读取失败的行被跳过；
+// 保留的行超过 MaxMemory 时返回错误
+func (qb *QueryBuilder) fetchRows(rows *Rows, seqs []int64) ([]*SSTableRow, error) {
+	result := make([]*SSTableRow, 0, len(seqs))
+	seen := make(map[int64]struct{}, len(seqs))
+	for i, seq := range seqs {
+		if i%cancelCheckInterval == 0 {
+			if err := qb.canceled(); err != nil {
+				return nil, err
+			}
+		}
+		if seq > rows.snapshotSeq {
+			continue
+		}
+		if _, ok := seen[seq]; ok {
+			continue
+		}
+		seen[seq] = struct{}{}
+
+		start := qb.tracer.now()
+		row, err := qb.getRow(seq)
 		if err != nil {
 			continue // 跳过获取失败的记录
 		}
-
-		// 检查是否匹配所有其他条件
-		if qb.Match(row.Data) {
-			rows.cachedRows = append(rows.cachedRows, row)
+		qb.tracer.read(qb.table, seq, start)
+		start = qb.tracer.now()
+		matched := qb.matchRow(row)
+		qb.tracer.filtered(start, matched)
+		if matched {
+			if err := rows.memory.charge(rowMemory(row)); err != nil {
+				return nil, err
+			}
+			result = append(result, row)
 		}
 	}
-
-	// 应用 offset 和 limit
-	rows.cachedRows = qb.applyOffsetLimit(rows.cachedRows)
-
-	// 使用缓存模式
-	rows.cached = true
-	rows.cachedIndex = -1
-
-	return rows, nil
+	return result, nil
 }
 
 // applyOffsetLimit 应用 offset 和 limit 到结果集
@@ -1295,6 +1530,93 @@ func (qb *QueryBuilder) Last() (*Row, error) {
 	return rows.Last()
 }
 
+// Seqs 返回所有匹配记录的 _seq（遵循 OrderBy、Offset、Limit）
+//
+// 以下情况不解码行数据：
+//   - 没有过滤条件且未排序或按 _seq 排序：直接归并快照内的 key
+//   - 唯一条件是索引字段上的等值查询且未排序：直接读取索引
+//
+// 其他情况按 Rows 执行查询后只保留 _seq。未设置 OrderBy 时按 _seq 升序返回。
+// 适合在应用层构建 join、缓存，或先取得 seq 列表再分批调用 Table.Get 读取数据。
+func (qb *QueryBuilder) Seqs() ([]int64, error) {
+	if qb.table == nil {
+		return nil, fmt.Errorf("table is nil")
+	}
+
+	var seqs []int64
+	eq := qb.indexedEq()
+	switch {
+	case !qb.filtered() && (qb.orderBy == "" || qb.orderBy == "_seq"):
+		scanQb := *qb
+		scanQb.orderBy = ""
+		rows, err := scanQb.Rows()
+		if err != nil {
+			return nil, err
+		}
+		seqs = rows.visibleSeqs()
+		rows.Close()
+		if qb.orderDesc && qb.orderBy == "_seq" {
+			slices.Reverse(seqs)
+		}
+
+	case eq != nil && qb.orderBy == "" && qb.policy() == nil && !qb.window.set:
+		epoch := qb.table.epoch.Load()
+		done, err := qb.table.beginRead(epoch)
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+
+		idx, _ := qb.table.indexManager.GetIndex(eq.field)
+		seqs, err = idx.Get(eq.right)
+		if err != nil {
+			return nil, fmt.Errorf("index lookup failed: %w", err)
+		}
+		slices.Sort(seqs)
+		// 跳过保留策略删除后残留在索引中的 seq，被修改过的 seq 按最新版本判断
+		expired := qb.table.expiredSeq()
+		seqs = slices.DeleteFunc(seqs, func(seq int64) bool { return seq <= expired && !qb.table.mutated(seq) })
+		seqs = qb.filterMutated(seqs)
+
+	default:
+		rows, err := qb.Rows()
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		// 偏移和限制已在 Rows 中应用
+		for rows.Next() {
+			seqs = append(seqs, rows.currentRow.inner.Seq)
+		}
+		return seqs, rows.Err()
+	}
+
+	// 应用 offset 和 limit
+	if qb.offset > 0 {
+		seqs = seqs[min(qb.offset, len(seqs)):]
+	}
+	if qb.limit > 0 && qb.limit < len(seqs) {
+		seqs = seqs[:qb.limit]
+	}
+	return seqs, nil
+}
+
+// indexedEq 唯一条件是已就绪索引字段上的等值查询时返回该条件，否则返回 nil
+func (qb *QueryBuilder) indexedEq() *compare {
+	if len(qb.conds) != 1 || qb.snap != nil {
+		return nil
+	}
+	cmp, ok := qb.conds[0].(compare)
+	if !ok || cmp.op != "=" {
+		return nil
+	}
+	if idx, exists := qb.table.indexManager.GetIndex(cmp.field); !exists || !idx.IsReady() || !idx.usableFor(qb.conds) {
+		return nil
+	}
+	return &cmp
+}
+
 // Scan 扫描结果到指定的变量
 func (qb *QueryBuilder) Scan(value any) error {
 	rows, err := qb.Rows()
@@ -1308,8 +1630,14 @@ func (qb *QueryBuilder) Scan(value any) error {
 
 type Row struct {
 	schema *Schema
-	fields []string // 要选择的字段，nil 表示选择所有字段
+	fields []string      // 要选择的字段，nil 表示选择所有字段
+	exprs  []*selectExpr // Select 中的计算列
 	inner  *SSTableRow
+	naming NamingStrategy // Scan 使用的字段命名规则
+	system *Table         // 非 nil 时附加系统列（见 QueryBuilder.WithSystemColumns）
+
+	missing  MissingFieldPolicy // 缺失字段的处理方式（见 Table.SetMissingFieldPolicy）
+	decimals DecimalConversion  // Scan 时 Decimal 的转换方式（见 Table.SetDecimalConversion）
 }
 
 // Data 获取行数据（根据 Select 过滤字段）
@@ -1317,30 +1645,78 @@ func (r *Row) Data() map[string]any {
 	if r.inner == nil {
 		return nil
 	}
+	data := projectRow(r.inner, r.fields, r.exprs)
+	if len(r.fields) == 0 && len(r.exprs) == 0 && r.schema != nil {
+		r.schema.hideDeprecated(data)
+	}
+	if r.schema != nil && (len(r.fields) > 0 || len(r.exprs) == 0) {
+		r.schema.fillMissing(data, r.fields, r.missing)
+	}
+	if r.system != nil {
+		r.system.addSystemColumns(r.inner.Seq, data)
+	}
+	return data
+}
 
+// projectRow 返回行数据的副本（包括 _seq 和 _time），fields 或 exprs 非空时只保留指定字段与计算列
+func projectRow(row *SSTableRow, fields []string, exprs []*selectExpr) map[string]any {
 	// 如果没有指定字段，返回所有数据（包括 _seq 和 _time）
-	if len(r.fields) == 0 {
-		result := make(map[string]any)
-		result["_seq"] = r.inner.Seq
-		result["_time"] = r.inner.Time
-		maps.Copy(result, r.inner.Data)
+	if len(fields) == 0 && len(exprs) == 0 {
+		result := make(map[string]any, len(row.Data)+2)
+		result["_seq"] = row.Seq
+		result["_time"] = row.Time
+		maps.Copy(result, row.Data)
 		return result
 	}
 
 	// 根据指定的字段过滤
-	result := make(map[string]any)
-	for _, field := range r.fields {
+	result := make(map[string]any, len(fields)+len(exprs))
+	for _, field := range fields {
 		if field == "_seq" {
-			result["_seq"] = r.inner.Seq
+			result["_seq"] = row.Seq
 		} else if field == "_time" {
-			result["_time"] = r.inner.Time
-		} else if val, ok := r.inner.Data[field]; ok {
+			result["_time"] = row.Time
+		} else if val, ok := row.Data[field]; ok {
 			result[field] = val
 		}
 	}
+	applySelectExprs(row, exprs, result)
 	return result
 }
 
+// typedRowData 按 Schema 将字段值转换为字段类型（原地修改 data），无法转换的值保持原样
+// 写入时已按 Schema 转换，这里用于保证扫描到 map 的值类型与 Schema 一致（如 Int32 字段总是 int32）
+func typedRowData(data map[string]any, schema *Schema) map[string]any {
+	if schema == nil {
+		return data
+	}
+	for name, value := range data {
+		if value == nil {
+			continue
+		}
+		field, err := schema.GetField(name)
+		if err != nil {
+			continue
+		}
+		if converted, err := convertValue(value, field.Type); err == nil {
+			data[name] = converted
+		}
+	}
+	return data
+}
+
+// mapType map[string]any 的反射类型
+var mapType = reflect.TypeFor[map[string]any]()
+
+// Checksum 返回行内容的 SHA-256 校验和（十六进制），插入时计算并随行存储
+// 校验和引入之前写入的行，以及由覆盖索引直接返回的行，返回空字符串
+func (r *Row) Checksum() string {
+	if r.inner == nil || len(r.inner.Checksum) == 0 {
+		return ""
+	}
+	return hex.EncodeToString(r.inner.Checksum)
+}
+
 // Seq 获取行序列号
 func (r *Row) Seq() int64 {
 	if r.inner == nil {
@@ -1350,7 +1726,8 @@ func (r *Row) Seq() int64 {
 }
 
 // Scan 扫描行数据到指定的变量
-// 支持使用 srdb tag 进行字段映射
+// 目标可以是结构体指针（支持使用 srdb tag 进行字段映射），
+// 也可以是 *map[string]any（值的类型与 Schema 中的字段类型一致）
 func (r *Row) Scan(value any) error {
 	if r.inner == nil {
 		return fmt.Errorf("row is nil")
@@ -1359,29 +1736,45 @@ func (r *Row) Scan(value any) error {
 	// 获取行数据（应用字段过滤）
 	data := r.Data()
 
+	// 目标是 map 时，值按 Schema 类型返回
+	if m, ok := value.(*map[string]any); ok {
+		if m == nil {
+			return fmt.Errorf("scan target pointer is nil")
+		}
+		if *m == nil {
+			*m = make(map[string]any, len(data))
+		}
+		maps.Copy(*m, typedRowData(data, r.schema))
+		return nil
+	}
+
 	// 使用 scanToStruct 进行映射
-	return scanToStruct(data, value)
+	return scanToStruct(data, value, r.naming, r.decimals)
 }
 
 // Rows 游标模式的结果集（惰性加载）
+//
+// 结果集在 Rows() 返回时固定快照：之后插入的数据（包括同一 goroutine 在迭代过程中插入的）
+// 不会出现在结果中，迭代期间的 MemTable 切换、flush 和 Compaction 也不会导致重复或遗漏。
+//
+// 惰性迭代期间表被 Clean 或 Close 时，Next() 返回 false，Err() 返回 ErrTableReset 或 ErrTableClosed。
 type Rows struct {
 	schema *Schema
 	fields []string // 要选择的字段，nil 表示选择所有字段
 	qb     *QueryBuilder
 	table  *Table
+	epoch  int64 // 创建时表的 epoch，用于检测 Clean/Close
+	ref    bool  // 是否计入表的未关闭迭代器数量
 
 	// 迭代状态
 	currentRow *Row
 	err        error
 	closed     bool
-	visited    map[int64]bool // 已访问的 seq，用于去重
 
-	// 数据源迭代器
-	memIterator       *memtableIterator
-	immutableIndex    int
-	immutableIterator *memtableIterator
-	sstIndex          int
-	sstReaders        []*sstReader
+	// 数据源（惰性模式）
+	snapshotSeq int64     // 创建时的最大 seq，更大的 seq 不可见
+	sources     [][]int64 // 创建时各数据源（Active、Immutable、SST）的 key
+	merge       seqIterator
 
 	// 缓存模式（用于 Collect/Data 等方法）
 	cached      bool
@@ -1391,215 +1784,422 @@ type Rows struct {
 	// 分页状态（惰性模式）
 	skippedCount  int // 已跳过的记录数（用于 offset）
 	returnedCount int // 已返回的记录数（用于 limit）
+	readCount     int // 已读取的记录数（用于定期检查 context 是否取消）
+
+	// 正在顺序扫描的 SST 文件（扫描结束或关闭时释放）
+	scanning []*SSTableReader
+
+	// 惰性读取期间持有的快照引用（见 Table.Snapshot）
+	snap *Snapshot
+
+	// 行复用模式（见 ReuseRow）
+	reuse      bool
+	reuseInner *SSTableRow
+	reuseRow   Row
+
+	// 去重模式（见 QueryBuilder.Distinct）
+	distinct *distinctIterator
+
+	// 物化的数据量（见 QueryBuilder.MaxMemory）
+	memory queryMemory
+
+	// 并发使用检测（见 enter）
+	busy    atomic.Bool // 有方法正在执行
+	misused atomic.Bool // 检测到并发使用，结果集已失效
 }
 
-// memtableIterator 包装 MemTable 的迭代器
-type memtableIterator struct {
-	keys  []int64
-	index int
+// seqIterator 惰性模式下按返回顺序产生待读取的 seq
+type seqIterator interface {
+	next() (int64, bool)
+}
+
+// seqMergeIterator 按 seq 升序归并多个已排序的 key 列表，每个 seq 只返回一次
+//
+// 同一个 seq 可能同时出现在多个数据源中：flush 期间在 Immutable MemTable 与新的 SST 中，
+// compaction 期间在新旧 SST 中。按 seq 读取时总是返回最新的副本（MemTable 优先，
+// 其次是最新的 SST），因此去重只决定返回次数，不影响读取到的内容。
+type seqMergeIterator struct {
+	sources [][]int64
+	pos     []int
+	last    int64 // 上一次返回的 seq，-1 表示尚未返回
+	maxSeq  int64 // 大于此值的 seq 不可见
 }
 
-func newMemtableIterator(keys []int64) *memtableIterator {
-	return &memtableIterator{
-		keys:  keys,
-		index: -1,
+func newSeqMergeIterator(sources [][]int64, maxSeq int64) *seqMergeIterator {
+	return &seqMergeIterator{
+		sources: sources,
+		pos:     make([]int, len(sources)),
+		last:    -1,
+		maxSeq:  maxSeq,
 	}
 }
 
-func (m *memtableIterator) next() (int64, bool) {
-	m.index++
-	if m.index >= len(m.keys) {
-		return 0, false
+// next 返回下一个 seq（严格递增），没有更多数据时返回 false
+func (m *seqMergeIterator) next() (int64, bool) {
+	for {
+		minSeq := int64(-1)
+		minSource := -1
+		for i, keys := range m.sources {
+			if m.pos[i] < len(keys) && (minSource == -1 || keys[m.pos[i]] < minSeq) {
+				minSeq = keys[m.pos[i]]
+				minSource = i
+			}
+		}
+		if minSource == -1 || minSeq > m.maxSeq {
+			return 0, false
+		}
+		m.pos[minSource]++
+		if minSeq > m.last {
+			m.last = minSeq
+			return minSeq, true
+		}
 	}
-	return m.keys[m.index], true
 }
 
-// peek 查看下一个 seq，但不推进指针
-func (m *memtableIterator) peek() int64 {
-	nextIndex := m.index + 1
-	if nextIndex >= len(m.keys) {
-		return -1
+// indexOrderIterator 按索引字段值的顺序产生 seq
+//
+// 每次只读取一个值的 seq 列表，调用者停止迭代后不再读取后续的值。
+type indexOrderIterator struct {
+	idx    *SecondaryIndex
+	values indexValues // 按字段值升序，迭代结束或结果集关闭时释放
+	desc   bool
+	pos    int     // 已读取的值数量
+	seqs   []int64 // 当前值尚未返回的 seq
+	maxSeq int64   // 大于此值的 seq 不可见
+}
+
+// next 返回下一个 seq，没有更多数据时返回 false
+func (it *indexOrderIterator) next() (int64, bool) {
+	for {
+		for len(it.seqs) == 0 {
+			if it.values == nil {
+				return 0, false
+			}
+			if it.pos >= it.values.Len() {
+				it.close()
+				return 0, false
+			}
+			i := it.pos
+			if it.desc {
+				i = it.values.Len() - 1 - it.pos
+			}
+			it.pos++
+
+			value := it.values.At(i)
+			if it.values.Err() != nil {
+				it.close()
+				return 0, false
+			}
+			seqs, err := it.idx.Get(value.raw)
+			if err != nil {
+				continue
+			}
+			slices.Sort(seqs)
+			if it.desc {
+				slices.Reverse(seqs)
+			}
+			it.seqs = seqs
+		}
+
+		seq := it.seqs[0]
+		it.seqs = it.seqs[1:]
+		if seq <= it.maxSeq {
+			return seq, true
+		}
 	}
-	return m.keys[nextIndex]
 }
 
-// sstReader 包装 SST Reader 的迭代状态
-type sstReader struct {
-	keys  []int64 // 文件中实际存在的 key 列表（已排序）
-	index int     // 当前迭代位置
+// close 释放有序值，之后 next 总是返回 false
+func (it *indexOrderIterator) close() {
+	if it.values != nil {
+		it.values.release()
+		it.values = nil
+	}
 }
 
 // Next 移动到下一行，返回是否还有数据
 func (r *Rows) Next() bool {
+	if !r.enter() {
+		return false
+	}
+	defer r.leave()
+	return r.advance()
+}
+
+// advance 移动到下一行（Next 的实现，调用者负责 enter/leave）
+func (r *Rows) advance() bool {
 	if r.closed {
 		return false
 	}
 	if r.err != nil {
 		return false
 	}
+	if err := r.qb.canceled(); err != nil {
+		r.err = err
+		r.releaseScan()
+		return false
+	}
 
 	// 如果是缓存模式，使用缓存的数据
 	if r.cached {
 		return r.nextFromCache()
 	}
 
-	// 惰性模式：从数据源读取
+	// 去重模式：源结果集读取时自行持有读锁
+	if r.distinct != nil {
+		return r.nextDistinct()
+	}
+
+	// 惰性模式：从数据源读取（持有读锁，期间 Clean/Close 会等待）
+	done, err := r.table.beginRead(r.epoch)
+	if err != nil {
+		r.err = err
+		r.releaseScan()
+		return false
+	}
+	defer done()
+
 	return r.next()
 }
 
 // next 从数据源读取下一条匹配的记录（惰性加载的核心逻辑）
-// 使用归并排序，从所有数据源中选择最小的 seq
+// 归并所有数据源，按 seq 严格递增的顺序返回，每个 seq 最多返回一次
 func (r *Rows) next() bool {
 	for {
-		// 初始化 Immutable 迭代器（如果需要）
-		if r.immutableIterator == nil && r.immutableIndex < len(r.table.memtableManager.GetImmutables()) {
-			immutables := r.table.memtableManager.GetImmutables()
-			if r.immutableIndex < len(immutables) {
-				r.immutableIterator = newMemtableIterator(immutables[r.immutableIndex].MemTable.Keys())
-			}
-		}
-
-		// 收集所有数据源的下一个 seq（使用 peek，不推进指针）
-		minSeq := int64(-1)
-		minSource := -1 // 0=mem, 1=immutable, 2+=sst
-
-		// 1. 检查 Active MemTable
-		if r.memIterator != nil {
-			if seq := r.memIterator.peek(); seq != -1 {
-				if minSeq == -1 || seq < minSeq {
-					minSeq = seq
-					minSource = 0
-				}
-			}
-		}
-
-		// 2. 检查 Immutable MemTables
-		if r.immutableIterator != nil {
-			if seq := r.immutableIterator.peek(); seq != -1 {
-				if minSeq == -1 || seq < minSeq {
-					minSeq = seq
-					minSource = 1
-				}
-			}
-		}
-
-		// 3. 检查所有 SST 文件
-		for i, sstReader := range r.sstReaders {
-			if sstReader.index < len(sstReader.keys) {
-				seq := sstReader.keys[sstReader.index]
-				if minSeq == -1 || seq < minSeq {
-					minSeq = seq
-					minSource = 2 + i
-				}
-			}
+		// 应用 limit：达到返回上限后停止（不再读取下一条）
+		if r.qb.limit > 0 && r.returnedCount >= r.qb.limit {
+			r.releaseScan()
+			return false
 		}
 
-		// 如果没有找到任何数据源，说明迭代结束
-		if minSource == -1 {
+		seq, ok := r.merge.next()
+		if !ok {
+			r.releaseScan()
 			return false
 		}
 
-		// 从选定的数据源推进指针
-		switch minSource {
-		case 0: // Active MemTable
-			r.memIterator.next()
-			if r.memIterator.peek() == -1 {
-				r.memIterator = nil
-			}
-
-		case 1: // Immutable MemTable
-			r.immutableIterator.next()
-			if r.immutableIterator.peek() == -1 {
-				r.immutableIterator = nil
-				r.immutableIndex++
+		// 跳过大量不匹配的行时也能及时停止
+		if r.readCount++; r.readCount%cancelCheckInterval == 0 {
+			if err := r.qb.canceled(); err != nil {
+				r.err = err
+				r.releaseScan()
+				return false
 			}
-
-		default: // SST 文件
-			sstIndex := minSource - 2
-			r.sstReaders[sstIndex].index++
 		}
 
-		// 如果该 seq 已访问过（去重），继续下一轮
-		if r.visited[minSeq] {
-			continue
+		// 获取并验证该记录（复用模式下解码到同一个 SSTableRow）
+		var row *SSTableRow
+		var err error
+		start := r.qb.tracer.now()
+		if r.reuse {
+			if r.reuseInner == nil {
+				r.reuseInner = &SSTableRow{}
+			}
+			err = r.qb.getRowInto(seq, r.reuseInner)
+			row = r.reuseInner
+		} else {
+			row, err = r.qb.getRow(seq)
 		}
-
-		// 获取并验证该记录
-		row, err := r.table.Get(minSeq)
 		if err != nil {
-			r.visited[minSeq] = true
+			// 内容被修改的行不能被静默跳过
+			if IsError(err, ErrCodeChecksumMismatch) {
+				r.err = err
+				r.releaseScan()
+				return false
+			}
 			continue
 		}
+		r.qb.tracer.read(r.table, seq, start)
 
 		// 检查是否匹配过滤条件
-		if !r.qb.Match(row.Data) {
-			r.visited[minSeq] = true
+		start = r.qb.tracer.now()
+		matched := r.qb.matchRow(row)
+		r.qb.tracer.filtered(start, matched)
+		if !matched {
 			continue
 		}
 
 		// 应用 offset：跳过前 N 条记录
 		if r.qb.offset > 0 && r.skippedCount < r.qb.offset {
 			r.skippedCount++
-			r.visited[minSeq] = true
 			continue
 		}
 
-		// 应用 limit：达到返回上限后停止
-		if r.qb.limit > 0 && r.returnedCount >= r.qb.limit {
-			return false
-		}
-
 		// 找到匹配的记录
-		r.visited[minSeq] = true
 		r.returnedCount++
-		r.currentRow = &Row{schema: r.schema, fields: r.fields, inner: row}
+		if r.reuse {
+			r.reuseRow = r.makeRow(row)
+			r.currentRow = &r.reuseRow
+		} else {
+			r.currentRow = r.newRow(row)
+		}
 		return true
 	}
 }
 
+// countVisible 统计快照内不重复的 seq 数量（只读取创建时固定的 key，不解码数据）
+func (r *Rows) countVisible() int {
+	count := 0
+	r.forEachVisible(func(int64) { count++ })
+	return count
+}
+
+// visibleSeqs 返回快照内不重复的 seq（升序，不解码数据）
+func (r *Rows) visibleSeqs() []int64 {
+	var seqs []int64
+	r.forEachVisible(func(seq int64) { seqs = append(seqs, seq) })
+	return seqs
+}
+
+// forEachVisible 按升序遍历快照内不重复的 seq（不影响迭代位置）
+func (r *Rows) forEachVisible(fn func(seq int64)) {
+	// 被删除的行仍留在数据源的 key 中，需要读取最新版本的行头（持有读锁）
+	mutated := r.table != nil && !r.table.mutations.empty.Load()
+	if mutated {
+		done, err := r.table.beginRead(r.epoch)
+		if err != nil {
+			return
+		}
+		defer done()
+	}
+
+	merge := newSeqMergeIterator(r.sources, r.snapshotSeq)
+	for {
+		seq, ok := merge.next()
+		if !ok {
+			return
+		}
+		if mutated && r.table.mutated(seq) && !r.qb.exists(seq) {
+			continue
+		}
+		fn(seq)
+	}
+}
+
 // nextFromCache 从缓存中获取下一条记录
 func (r *Rows) nextFromCache() bool {
 	r.cachedIndex++
 	if r.cachedIndex >= len(r.cachedRows) {
 		return false
 	}
-	r.currentRow = &Row{
-		schema: r.schema,
-		fields: r.fields,
-		inner:  r.cachedRows[r.cachedIndex],
-	}
+	r.currentRow = r.newRow(r.cachedRows[r.cachedIndex])
 	return true
 }
 
+// makeRow 构造结果集的一行，Next、Last、去重与 Tail 的结果行都由这里构造
+func (r *Rows) makeRow(inner *SSTableRow) Row {
+	return Row{
+		schema:   r.schema,
+		fields:   r.fields,
+		exprs:    r.selectExprs(),
+		inner:    inner,
+		naming:   r.table.naming,
+		system:   r.systemTable(),
+		missing:  r.missingFieldPolicy(),
+		decimals: r.decimalConversion(),
+	}
+}
+
+// newRow 构造结果集的一行（见 makeRow）
+func (r *Rows) newRow(inner *SSTableRow) *Row {
+	row := r.makeRow(inner)
+	return &row
+}
+
 // Row 获取当前行
 func (r *Rows) Row() *Row {
 	return r.currentRow
 }
 
 // Err 返回错误
+// 检测到多个 goroutine 同时使用同一个 Rows 时返回 ErrConcurrentRowsUse
 func (r *Rows) Err() error {
+	if r.misused.Load() {
+		return ErrConcurrentRowsUse
+	}
 	return r.err
 }
 
 // Close 关闭游标
+// 其他 goroutine 正在使用该 Rows 时不关闭，返回 ErrConcurrentRowsUse（结果集随之失效）；
+// 结果集失效后仍需要调用 Close 释放资源
 func (r *Rows) Close() error {
+	if !r.busy.CompareAndSwap(false, true) {
+		r.misused.Store(true)
+		return ErrConcurrentRowsUse
+	}
+	defer r.leave()
+
 	r.closed = true
+	r.releaseScan()
+	if r.distinct != nil {
+		r.distinct.close()
+	}
 	return nil
 }
 
+// releaseScan 结束对 SST 文件的顺序扫描
+func (r *Rows) releaseScan() {
+	if r.ref {
+		r.ref = false
+		r.table.iterators.Add(-1)
+	}
+	if it, ok := r.merge.(*indexOrderIterator); ok {
+		it.close()
+	}
+	for _, reader := range r.scanning {
+		reader.endScan(r.qb.bypass)
+	}
+	r.scanning = nil
+	if r.snap != nil {
+		r.snap.release()
+		r.snap = nil
+	}
+}
+
+// ReuseRow 开启行复用模式，减少大扫描时的内存分配
+//
+// 开启后 Row() 返回的 Row（及其 Data）只在下一次调用 Next() 之前有效，
+// 需要保留的数据必须自行复制。仅对惰性加载（全表扫描、按索引字段排序）生效，
+// 调用 Collect/Len/Data 等需要缓存全部结果的方法时会自动关闭复用。
+func (r *Rows) ReuseRow() *Rows {
+	r.reuse = true
+	return r
+}
+
 // ensureCached 确保所有数据已被加载到缓存
 func (r *Rows) ensureCached() {
 	if r.cached {
 		return
 	}
 
-	// 使用私有的 next() 方法直接从数据源读取所有剩余数据
-	// 这样避免了与 Next() 的循环调用问题
-	// 注意：如果之前已经调用过 Next()，部分数据已经被消耗，只能缓存剩余数据
-	for r.next() {
-		if r.currentRow != nil && r.currentRow.inner != nil {
-			r.cachedRows = append(r.cachedRows, r.currentRow.inner)
+	// 缓存需要保留每一行，不能复用
+	r.reuse = false
+
+	// 持有读锁读取剩余数据；表已被 Clean/Close 时不再读取，错误通过 Err() 返回
+	if r.distinct != nil {
+		// 去重模式由源结果集持有读锁
+		for r.nextDistinct() {
+			if !r.cache(r.currentRow.inner) {
+				break
+			}
+		}
+	} else if done, err := r.table.beginRead(r.epoch); err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		r.releaseScan()
+	} else {
+		// 使用私有的 next() 方法直接从数据源读取所有剩余数据
+		// 这样避免了与 Next() 的循环调用问题
+		// 注意：如果之前已经调用过 Next()，部分数据已经被消耗，只能缓存剩余数据
+		for r.next() {
+			if r.currentRow != nil && r.currentRow.inner != nil && !r.cache(r.currentRow.inner) {
+				r.releaseScan()
+				break
+			}
 		}
+		done()
 	}
 
 	// 标记为已缓存，重置迭代位置
@@ -1607,18 +2207,57 @@ func (r *Rows) ensureCached() {
 	r.cachedIndex = -1
 }
 
+// cache 将一行加入缓存，超过 MaxMemory 时记录错误并返回 false
+func (r *Rows) cache(row *SSTableRow) bool {
+	if err := r.memory.charge(rowMemory(row)); err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		return false
+	}
+	r.cachedRows = append(r.cachedRows, row)
+	return true
+}
+
 // Len 返回总行数（需要完全扫描）
 func (r *Rows) Len() int {
+	if !r.enter() {
+		return 0
+	}
+	defer r.leave()
+
 	r.ensureCached()
 	return len(r.cachedRows)
 }
 
 // Collect 收集所有结果到切片
 func (r *Rows) Collect() []map[string]any {
+	if !r.enter() {
+		return nil
+	}
+	defer r.leave()
+
 	r.ensureCached()
+	hide := len(r.fields) == 0 && r.schema != nil && r.schema.hasDeprecated()
+	exprs := r.selectExprs()
+	missing := MissingFieldOmit
+	if r.schema != nil && (len(r.fields) > 0 || len(exprs) == 0) {
+		missing = r.missingFieldPolicy()
+	}
 	var results []map[string]any
 	for _, row := range r.cachedRows {
-		results = append(results, row.Data)
+		data := row.Data
+		if hide || len(exprs) > 0 || missing != MissingFieldOmit {
+			data = maps.Clone(data)
+		}
+		if hide {
+			r.schema.hideDeprecated(data)
+		}
+		if missing != MissingFieldOmit {
+			r.schema.fillMissing(data, r.fields, missing)
+		}
+		applySelectExprs(row, exprs, data)
+		results = append(results, data)
 	}
 	return results
 }
@@ -1632,8 +2271,14 @@ func (r *Rows) Data() []map[string]any {
 // 智能判断目标类型：
 //   - 如果目标是切片：扫描所有行
 //   - 如果目标是结构体/指针：只扫描第一行
-// 支持使用 srdb tag 进行字段映射
+// 支持使用 srdb tag 进行字段映射；目标为 *[]map[string]any 或 *map[string]any 时，
+// 值的类型与 Schema 中的字段类型一致。不需要一次性读取全部结果时可使用 Decoder 逐行解码
 func (r *Rows) Scan(value any) error {
+	if !r.enter() {
+		return ErrConcurrentRowsUse
+	}
+	defer r.leave()
+
 	rv := reflect.ValueOf(value)
 	if rv.Kind() != reflect.Pointer {
 		return fmt.Errorf("scan target must be a pointer")
@@ -1647,8 +2292,21 @@ func (r *Rows) Scan(value any) error {
 		// 获取切片元素类型
 		elemType := elem.Type().Elem()
 
-		// 确保数据已缓存
+		// 确保数据已缓存（超过 MaxMemory 时返回错误）
 		r.ensureCached()
+		if IsError(r.err, ErrCodeQueryMemoryExceeded) {
+			return r.err
+		}
+
+		// 目标是 []map[string]any 时直接构建，值按 Schema 类型返回
+		if elemType == mapType {
+			results := make([]map[string]any, 0, len(r.cachedRows))
+			for _, rowData := range r.cachedRows {
+				results = append(results, typedRowData(r.project(rowData), r.schema))
+			}
+			elem.Set(reflect.ValueOf(results).Convert(elem.Type()))
+			return nil
+		}
 
 		// 创建新切片
 		newSlice := reflect.MakeSlice(elem.Type(), 0, len(r.cachedRows))
@@ -1656,30 +2314,13 @@ func (r *Rows) Scan(value any) error {
 		// 逐行扫描
 		for _, rowData := range r.cachedRows {
 			// 创建数据 map（应用字段过滤）
-			data := make(map[string]any)
-			if len(r.fields) == 0 {
-				// 没有字段过滤，包含所有字段
-				data["_seq"] = rowData.Seq
-				data["_time"] = rowData.Time
-				maps.Copy(data, rowData.Data)
-			} else {
-				// 应用字段过滤
-				for _, field := range r.fields {
-					if field == "_seq" {
-						data["_seq"] = rowData.Seq
-					} else if field == "_time" {
-						data["_time"] = rowData.Time
-					} else if val, ok := rowData.Data[field]; ok {
-						data[field] = val
-					}
-				}
-			}
+			data := r.project(rowData)
 
 			// 创建新元素
 			elemPtr := reflect.New(elemType)
 
 			// 扫描到元素
-			if err := scanToStruct(data, elemPtr.Interface()); err != nil {
+			if err := scanToStruct(data, elemPtr.Interface(), r.table.naming, r.decimalConversion()); err != nil {
 				return fmt.Errorf("scan row failed: %w", err)
 			}
 
@@ -1697,18 +2338,25 @@ func (r *Rows) Scan(value any) error {
 	}
 
 	// 否则，只扫描第一行
-	row, err := r.First()
-	if err != nil {
-		return err
+	if !r.advance() {
+		if err := r.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("no rows")
 	}
 
-	return row.Scan(value)
+	return r.currentRow.Scan(value)
 }
 
 // First 获取第一行
 func (r *Rows) First() (*Row, error) {
+	if !r.enter() {
+		return nil, ErrConcurrentRowsUse
+	}
+	defer r.leave()
+
 	// 尝试获取第一条记录（不使用缓存）
-	if r.Next() {
+	if r.advance() {
 		return r.currentRow, nil
 	}
 	return nil, fmt.Errorf("no rows")
@@ -1716,15 +2364,16 @@ func (r *Rows) First() (*Row, error) {
 
 // Last 获取最后一行
 func (r *Rows) Last() (*Row, error) {
+	if !r.enter() {
+		return nil, ErrConcurrentRowsUse
+	}
+	defer r.leave()
+
 	r.ensureCached()
 	if len(r.cachedRows) == 0 {
 		return nil, fmt.Errorf("no rows")
 	}
-	return &Row{
-		schema: r.schema,
-		fields: r.fields,
-		inner:  r.cachedRows[len(r.cachedRows)-1],
-	}, nil
+	return r.newRow(r.cachedRows[len(r.cachedRows)-1]), nil
 }
 
 // Count 返回总行数（别名）
@@ -1732,9 +2381,81 @@ func (r *Rows) Count() int {
 	return r.Len()
 }
 
+// enter 标记开始执行会修改迭代状态的方法
+//
+// Rows 不能被多个 goroutine 同时使用（可以在 goroutine 之间传递）。检测到重叠的调用时返回 false，
+// 并使结果集失效：之后 Next 返回 false，Err 返回 ErrConcurrentRowsUse，而不是静默地返回错乱的数据。
+// 检测只针对同时进行的调用；没有同步的交替调用请使用 go test -race 检查。
+func (r *Rows) enter() bool {
+	if r.misused.Load() {
+		return false
+	}
+	if !r.busy.CompareAndSwap(false, true) {
+		r.misused.Store(true)
+		return false
+	}
+	return true
+}
+
+// leave 标记方法执行结束
+func (r *Rows) leave() {
+	r.busy.Store(false)
+}
+
+// RowDecoder 逐行解码结果集，用法类似 json.Decoder
+//
+//	dec := rows.Decoder()
+//	for dec.More() {
+//	    var m map[string]any // 或结构体
+//	    if err := dec.Decode(&m); err != nil {
+//	        return err
+//	    }
+//	}
+//
+// 与 Scan 到切片不同，解码时不缓存全部结果，适合流式处理大结果集。
+type RowDecoder struct {
+	rows    *Rows
+	pending bool // 已读取、尚未解码的行
+	done    bool
+}
+
+// Decoder 返回逐行解码结果集的解码器（解码器与 Rows 共享迭代位置）
+func (r *Rows) Decoder() *RowDecoder {
+	return &RowDecoder{rows: r}
+}
+
+// More 是否还有未解码的行
+func (d *RowDecoder) More() bool {
+	if d.pending {
+		return true
+	}
+	if d.done {
+		return false
+	}
+	if d.rows.Next() {
+		d.pending = true
+		return true
+	}
+	d.done = true
+	return false
+}
+
+// Decode 将下一行解码到 value（结构体指针或 *map[string]any），没有更多行时返回 io.EOF，
+// 迭代出错时返回 Rows.Err()
+func (d *RowDecoder) Decode(value any) error {
+	if !d.More() {
+		if err := d.rows.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	d.pending = false
+	return d.rows.Row().Scan(value)
+}
+
 // scanToStruct 将 map[string]any 数据扫描到结构体
-// 支持 srdb tag 进行字段映射
-func scanToStruct(data map[string]any, value any) error {
+// 支持 srdb tag 进行字段映射，没有 tag 的字段按 naming 转换字段名，Decimal 按 decimals 转换
+func scanToStruct(data map[string]any, value any, naming NamingStrategy, decimals DecimalConversion) error {
 	rv := reflect.ValueOf(value)
 	if rv.Kind() != reflect.Pointer {
 		return fmt.Errorf("scan target must be a pointer")
@@ -1774,7 +2495,7 @@ func scanToStruct(data map[string]any, value any) error {
 		}
 
 		// 解析 srdb tag，确定数据库字段名
-		dbFieldName := parseSRDBFieldName(field)
+		dbFieldName := parseSRDBFieldName(field, naming)
 		if dbFieldName == "-" {
 			// 忽略该字段
 			continue
@@ -1791,7 +2512,7 @@ func scanToStruct(data map[string]any, value any) error {
 		fieldValue := elem.Field(i)
 
 		// 设置字段值（处理类型转换和指针）
-		if err := setFieldValue(fieldValue, dbValue); err != nil {
+		if err := setFieldValue(fieldValue, dbValue, decimals); err != nil {
 			return fmt.Errorf("set field %s: %w", field.Name, err)
 		}
 	}
@@ -1803,10 +2524,10 @@ func scanToStruct(data map[string]any, value any) error {
 // 支持两种格式：
 //   1. 旧格式：`srdb:"field_name;indexed;comment:xxx"`  - 第一部分直接是字段名
 //   2. 新格式：`srdb:"field:field_name;indexed;comment:xxx"`  - 使用 field: 前缀
-// 如果没有 tag，使用 snake_case 转换
+// 如果没有 tag，按 naming 转换（nil 时使用 snake_case）
 //
 // 注意：此函数的逻辑与 schema.go 中 StructToFields 的 tag 解析保持一致
-func parseSRDBFieldName(field reflect.StructField) string {
+func parseSRDBFieldName(field reflect.StructField, naming NamingStrategy) string {
 	tag := field.Tag.Get("srdb")
 
 	// 如果标记为忽略
@@ -1814,8 +2535,8 @@ func parseSRDBFieldName(field reflect.StructField) string {
 		return "-"
 	}
 
-	// 默认使用 snake_case 字段名
-	fieldName := camelToSnake(field.Name)
+	// 默认按命名规则转换字段名
+	fieldName := naming.orDefault()(field.Name)
 
 	if tag != "" {
 		// 使用分号分隔各部分，与顺序无关
@@ -1847,7 +2568,7 @@ func parseSRDBFieldName(field reflect.StructField) string {
 }
 
 // setFieldValue 设置字段值，处理类型转换
-func setFieldValue(fieldValue reflect.Value, dbValue any) error {
+func setFieldValue(fieldValue reflect.Value, dbValue any, decimals DecimalConversion) error {
 	if !fieldValue.CanSet() {
 		return fmt.Errorf("field cannot be set")
 	}
@@ -1872,7 +2593,7 @@ func setFieldValue(fieldValue reflect.Value, dbValue any) error {
 			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
 		}
 		// 递归设置指针指向的值
-		return setFieldValue(fieldValue.Elem(), dbValue)
+		return setFieldValue(fieldValue.Elem(), dbValue, decimals)
 	}
 
 	// 类型完全匹配，直接设置
@@ -1882,14 +2603,19 @@ func setFieldValue(fieldValue reflect.Value, dbValue any) error {
 	}
 
 	// 需要类型转换
-	return convertAndSet(fieldValue, dbValue)
+	return convertAndSet(fieldValue, dbValue, decimals)
 }
 
 // convertAndSet 转换类型并设置字段值
-func convertAndSet(fieldValue reflect.Value, dbValue any) error {
+func convertAndSet(fieldValue reflect.Value, dbValue any, decimals DecimalConversion) error {
 	fieldType := fieldValue.Type()
 	dbValueReflect := reflect.ValueOf(dbValue)
 
+	// 特殊处理：Decimal 与数值、字符串之间的转换
+	if ok, err := setDecimalField(fieldValue, dbValue, decimals); ok {
+		return err
+	}
+
 	// 尝试类型转换
 	if dbValueReflect.Type().ConvertibleTo(fieldType) {
 		fieldValue.Set(dbValueReflect.Convert(fieldType))
@@ -0,0 +1,335 @@
+package srdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"os"
+)
+
+// 去重：按选择的字段（Select）组成的元组去除重复行
+//
+// 去重键保存在内存的哈希表中，超过内存上限后，键不在哈希表中的行按键的哈希值
+// 写入临时分区文件（完整行的二进制编码），源数据读取完毕后再逐个分区去重返回。
+// 同一个键总是落在同一个分区，且与内存中的键不相交，因此每个元组只返回一次。
+// 返回每个元组第一次出现的行（未溢出时按源数据的顺序，溢出的元组在最后按分区返回）。
+
+const (
+	// DefaultDistinctMemory Distinct 在内存中保留去重键的默认上限（字节）
+	DefaultDistinctMemory = 64 * 1024 * 1024 // 64 MB
+
+	distinctPartitions  = 16 // 溢出的分区数
+	distinctKeyOverhead = 48 // 哈希表每个键的估算额外开销（字节）
+)
+
+// Distinct 按选择的字段去除重复行，未调用 Select 时按所有 Schema 字段（不含 _seq、_time）去重
+//
+//	var pairs []map[string]any
+//	table.Query().Select("country", "city").Distinct().Scan(&pairs)
+//
+// Offset 与 Limit 应用在去重之后。去重键超过 DefaultDistinctMemory 时溢出到临时文件，
+// 内存占用有上限，但溢出的元组要在源数据读取完毕后才会返回。
+func (qb *QueryBuilder) Distinct() *QueryBuilder {
+	qb.distinct = true
+	return qb
+}
+
+// rowsDistinct 创建去重的结果集（源查询不应用 Offset 与 Limit）
+func (qb *QueryBuilder) rowsDistinct() (*Rows, error) {
+	if len(qb.exprs) > 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "distinct does not support computed select expressions")
+	}
+
+	sourceQb := *qb
+	sourceQb.distinct = false
+	sourceQb.fields = nil // 溢出时需要完整的行
+	sourceQb.offset = 0
+	sourceQb.limit = 0
+
+	source, err := sourceQb.Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := qb.fields
+	if len(keys) == 0 {
+		for _, field := range qb.table.schema.Load().Fields {
+			keys = append(keys, field.Name)
+		}
+	}
+	limit := qb.distinctMemory
+	if limit <= 0 {
+		limit = DefaultDistinctMemory
+	}
+
+	return &Rows{
+		schema:      qb.table.schema.Load(),
+		fields:      qb.fields,
+		qb:          qb,
+		table:       qb.table,
+		epoch:       source.epoch,
+		snapshotSeq: source.snapshotSeq,
+		memory:      queryMemory{limit: qb.maxMemory},
+		distinct: &distinctIterator{
+			source: source,
+			schema: qb.table.schema.Load(),
+			keys:   keys,
+			seen:   make(map[string]struct{}),
+			limit:  limit,
+			seed:   maphash.MakeSeed(),
+		},
+	}, nil
+}
+
+// nextDistinct 读取下一个不重复的行（应用 Offset 与 Limit）
+func (r *Rows) nextDistinct() bool {
+	for {
+		if r.qb.limit > 0 && r.returnedCount >= r.qb.limit {
+			r.distinct.close()
+			return false
+		}
+
+		row, ok := r.distinct.next()
+		if !ok {
+			if r.distinct.err != nil && r.err == nil {
+				r.err = r.distinct.err
+			}
+			r.distinct.close()
+			return false
+		}
+
+		if r.qb.offset > 0 && r.skippedCount < r.qb.offset {
+			r.skippedCount++
+			continue
+		}
+
+		r.returnedCount++
+		r.currentRow = r.newRow(row)
+		return true
+	}
+}
+
+// distinctIterator 基于哈希的去重迭代器，超过内存上限后溢出到分区文件
+type distinctIterator struct {
+	source *Rows
+	schema *Schema
+	keys   []string // 组成去重键的字段
+
+	seen   map[string]struct{}
+	memory int64 // seen 的估算内存占用
+	limit  int64
+
+	seed    maphash.Seed
+	spills  []*os.File      // 分区文件，nil 表示尚未溢出
+	writers []*bufio.Writer // 分区文件的写缓冲
+	spilled int64           // 溢出的行数
+
+	reading   bool // 源数据已读取完毕，正在读取分区
+	partition int  // 正在读取的分区
+	reader    *bufio.Reader
+
+	err    error
+	closed bool
+}
+
+// next 返回下一个不重复的行
+func (d *distinctIterator) next() (*SSTableRow, bool) {
+	if d.closed || d.err != nil {
+		return nil, false
+	}
+
+	// 1. 读取源数据：内存中放得下的键直接返回，其余的行写入分区
+	for !d.reading {
+		if !d.source.Next() {
+			if err := d.source.Err(); err != nil {
+				d.err = err
+				return nil, false
+			}
+			if err := d.startReading(); err != nil {
+				d.err = err
+				return nil, false
+			}
+			break
+		}
+
+		row := d.source.Row().inner
+		key := d.key(row)
+		if _, ok := d.seen[key]; ok {
+			continue
+		}
+		cost := int64(len(key)) + distinctKeyOverhead
+		if d.memory+cost <= d.limit {
+			d.seen[key] = struct{}{}
+			d.memory += cost
+			return row, true
+		}
+		if err := d.spill(key, row); err != nil {
+			d.err = err
+			return nil, false
+		}
+	}
+
+	// 2. 逐个分区去重（每个分区使用新的哈希表）
+	for d.partition < len(d.spills) {
+		row, err := d.readSpilled()
+		if err == io.EOF {
+			d.partition++
+			d.reader = nil
+			clear(d.seen)
+			continue
+		}
+		if err != nil {
+			d.err = err
+			return nil, false
+		}
+
+		key := d.key(row)
+		if _, ok := d.seen[key]; ok {
+			continue
+		}
+		d.seen[key] = struct{}{}
+		return row, true
+	}
+	return nil, false
+}
+
+// key 编码行的去重键（按字段类型的二进制编码，区分缺失与 nil 之外的零值）
+func (d *distinctIterator) key(row *SSTableRow) string {
+	var buf bytes.Buffer
+	for _, name := range d.keys {
+		switch name {
+		case "_seq":
+			binary.Write(&buf, binary.LittleEndian, row.Seq)
+			continue
+		case "_time":
+			binary.Write(&buf, binary.LittleEndian, row.Time)
+			continue
+		}
+
+		field, err := d.schema.GetField(name)
+		if err != nil {
+			field = nil
+		}
+		writeKeyValue(&buf, field, row.Data[name])
+	}
+	return buf.String()
+}
+
+// writeKeyValue 将字段值写入去重或分组键
+// NULL、缺失的值与不在 Schema 中的字段写入 0，否则写入 1 与按字段类型的二进制编码
+func writeKeyValue(buf *bytes.Buffer, field *Field, value any) {
+	if field == nil || value == nil {
+		buf.WriteByte(0)
+		return
+	}
+	buf.WriteByte(1)
+	mark := buf.Len()
+	if writeFieldBinaryValue(buf, field.Type, value) != nil {
+		// 无法按字段类型编码时退回到文本形式
+		buf.Truncate(mark)
+		fmt.Fprintf(buf, "%T:%v", value, value)
+	}
+	// 变长编码后写入长度，避免相邻字段的边界产生歧义
+	binary.Write(buf, binary.LittleEndian, uint32(buf.Len()-mark))
+}
+
+// spill 将行写入键所在的分区文件
+func (d *distinctIterator) spill(key string, row *SSTableRow) error {
+	if d.spills == nil {
+		d.spills = make([]*os.File, distinctPartitions)
+		d.writers = make([]*bufio.Writer, distinctPartitions)
+	}
+	p := maphash.String(d.seed, key) % distinctPartitions
+	if d.spills[p] == nil {
+		file, err := os.CreateTemp("", "srdb-distinct-*")
+		if err != nil {
+			return fmt.Errorf("create distinct spill file: %w", err)
+		}
+		d.spills[p] = file
+		d.writers[p] = bufio.NewWriter(file)
+	}
+
+	data, err := encodeSSTableRowBinary(row, d.schema)
+	if err != nil {
+		return err
+	}
+	w := d.writers[p]
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	d.spilled++
+	return nil
+}
+
+// startReading 源数据读取完毕，准备逐个读取分区
+func (d *distinctIterator) startReading() error {
+	d.reading = true
+	d.source.Close()
+	clear(d.seen)
+	d.memory = 0
+
+	for p, file := range d.spills {
+		if file == nil {
+			continue
+		}
+		if err := d.writers[p].Flush(); err != nil {
+			return err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSpilled 从当前分区读取一行，分区读取完毕时返回 io.EOF
+func (d *distinctIterator) readSpilled() (*SSTableRow, error) {
+	file := d.spills[d.partition]
+	if file == nil {
+		return nil, io.EOF
+	}
+	if d.reader == nil {
+		d.reader = bufio.NewReader(file)
+	}
+
+	var size uint32
+	if err := binary.Read(d.reader, binary.LittleEndian, &size); err != nil {
+		if err == io.EOF {
+			d.removeSpill(d.partition)
+		}
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(d.reader, data); err != nil {
+		return nil, fmt.Errorf("read distinct spill file: %w", err)
+	}
+	return decodeSSTableRowBinary(data, d.schema)
+}
+
+// removeSpill 关闭并删除分区文件
+func (d *distinctIterator) removeSpill(p int) {
+	if file := d.spills[p]; file != nil {
+		file.Close()
+		os.Remove(file.Name())
+		d.spills[p] = nil
+	}
+}
+
+// close 关闭源结果集并删除所有分区文件
+func (d *distinctIterator) close() {
+	if d.closed {
+		return
+	}
+	d.closed = true
+	d.source.Close()
+	for p := range d.spills {
+		d.removeSpill(p)
+	}
+	d.seen = nil
+}
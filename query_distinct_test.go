@@ -0,0 +1,114 @@
+package srdb
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestQueryDistinct(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "visits",
+		Fields: []Field{
+			{Name: "country", Type: String},
+			{Name: "city", Type: String},
+			{Name: "hits", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 3 个国家 × 10 个城市，每个组合重复出现
+	for i := range 600 {
+		country := fmt.Sprintf("c%d", i%3)
+		city := fmt.Sprintf("%s-city%d", country, i%10)
+		if err := table.Insert(map[string]any{"country": country, "city": city, "hits": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 300 {
+			table.Flush()
+		}
+	}
+
+	check := func(name string, qb *QueryBuilder, want int) []map[string]any {
+		t.Helper()
+		var pairs []map[string]any
+		if err := qb.Scan(&pairs); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(pairs) != want {
+			t.Fatalf("%s: expected %d rows, got %d", name, want, len(pairs))
+		}
+		seen := make(map[string]bool)
+		for _, p := range pairs {
+			key := fmt.Sprint(p["country"], "/", p["city"])
+			if seen[key] {
+				t.Fatalf("%s: duplicate tuple %s", name, key)
+			}
+			seen[key] = true
+		}
+		return pairs
+	}
+
+	pairs := check("in memory", table.Query().Select("country", "city").Distinct(), 30)
+	if len(pairs[0]) != 2 {
+		t.Errorf("expected projected tuple, got %v", pairs[0])
+	}
+	check("single field", table.Query().Select("country").Distinct(), 3)
+	check("with condition", table.Query().Select("country", "city").Eq("country", "c1").Distinct(), 10)
+
+	// 未调用 Select 时按所有字段去重（hits 各不相同）
+	var all []map[string]any
+	if err := table.Query().Distinct().Scan(&all); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 600 {
+		t.Errorf("all fields: expected 600 rows, got %d", len(all))
+	}
+
+	// 内存上限很小时溢出到分区文件，结果不变
+	qb := table.Query().Select("country", "city").Distinct()
+	qb.distinctMemory = 256
+	rows, err := qb.Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	seen := make(map[string]bool)
+	for rows.Next() {
+		data := rows.Row().Data()
+		key := fmt.Sprint(data["country"], "/", data["city"])
+		if seen[key] {
+			t.Fatalf("spilled: duplicate tuple %s", key)
+		}
+		seen[key] = true
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 30 {
+		t.Errorf("spilled: expected 30 rows, got %d", count)
+	}
+	if rows.distinct.spilled == 0 {
+		t.Error("expected rows to be spilled")
+	}
+	rows.Close()
+	for _, file := range rows.distinct.spills {
+		if file != nil {
+			t.Errorf("spill file %s not removed", file.Name())
+		}
+	}
+
+	// Offset 与 Limit 应用在去重之后
+	check("limit", table.Query().Select("country", "city").Distinct().Offset(25).Limit(10), 5)
+	page, err := table.Query().Select("country").Distinct().Page(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || page.Rows.Len() != 2 {
+		t.Errorf("unexpected page total=%d len=%d", page.Total, page.Rows.Len())
+	}
+}
@@ -3,7 +3,11 @@
 import (
 	"fmt"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestLazyLoadingBasic 测试惰性加载基本功能
@@ -351,3 +355,717 @@ func TestFirstDoesNotLoadAll(t *testing.T) {
 	// First() 应该只读取一条记录，不会加载所有数据
 	t.Log("✓ First() does not load all data test passed")
 }
+
+// TestRowsReuseRow 测试行复用模式
+func TestRowsReuseRow(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestRowsReuseRow")
+	defer os.RemoveAll(tmpDir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:  tmpDir,
+		Name: "users",
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "age", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 50 {
+		table.Insert(map[string]any{"name": fmt.Sprintf("user%d", i), "age": int64(i)})
+	}
+	table.Flush()
+	for i := 50; i < 100; i++ {
+		table.Insert(map[string]any{"name": fmt.Sprintf("user%d", i), "age": int64(i)})
+	}
+
+	rows, err := table.Query().Gte("age", 10).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	rows.ReuseRow()
+
+	var first *Row
+	count := 0
+	for rows.Next() {
+		row := rows.Row()
+		if first == nil {
+			first = row
+		} else if row != first {
+			t.Fatal("Expected the same Row to be reused")
+		}
+
+		age := row.Data()["age"].(int64)
+		if row.Data()["name"] != fmt.Sprintf("user%d", age) {
+			t.Errorf("Inconsistent row data: %v", row.Data())
+		}
+		count++
+	}
+	if count != 90 {
+		t.Errorf("Expected 90 rows, got %d", count)
+	}
+
+	// Collect 需要保留全部结果，自动关闭复用
+	rows2, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows2.Close()
+	data := rows2.ReuseRow().Collect()
+	if len(data) != 100 {
+		t.Fatalf("Expected 100 rows, got %d", len(data))
+	}
+	if data[0]["name"] == data[99]["name"] {
+		t.Error("Collected rows must not share data")
+	}
+}
+
+// BenchmarkRowsScan 对比普通扫描与行复用扫描的分配
+func BenchmarkRowsScan(b *testing.B) {
+	tmpDir, _ := os.MkdirTemp("", "BenchmarkRowsScan")
+	defer os.RemoveAll(tmpDir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:  tmpDir,
+		Name: "users",
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "age", Type: Int64},
+		},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 1000 {
+		table.Insert(map[string]any{"name": fmt.Sprintf("user%d", i), "age": int64(i)})
+	}
+
+	for _, reuse := range []bool{false, true} {
+		b.Run(fmt.Sprintf("reuse=%v", reuse), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				rows, _ := table.Query().Rows()
+				if reuse {
+					rows.ReuseRow()
+				}
+				for rows.Next() {
+				}
+				rows.Close()
+			}
+		})
+	}
+}
+
+// TestRowsSnapshotIsolation 测试 Rows 创建后插入的数据不可见
+func TestRowsSnapshotIsolation(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestRowsSnapshotIsolation")
+	defer os.RemoveAll(tmpDir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:    tmpDir,
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 50 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	br, err := table.Query().BatchRows([]string{"n"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+
+	count := 0
+	lastSeq := int64(0)
+	for rows.Next() {
+		seq := rows.Row().Seq()
+		if seq <= lastSeq {
+			t.Fatalf("seq %d returned after %d", seq, lastSeq)
+		}
+		lastSeq = seq
+		count++
+
+		// 迭代过程中在同一 goroutine 插入数据，并让 Active MemTable 变为 Immutable
+		if count == 1 {
+			for i := range 50 {
+				if err := table.Insert(map[string]any{"n": int64(100 + i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := table.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if count != 50 {
+		t.Errorf("expected 50 rows from snapshot, got %d", count)
+	}
+
+	batchCount := 0
+	for br.Next() {
+		batchCount += br.Batch().Len()
+	}
+	if batchCount != 50 {
+		t.Errorf("expected 50 rows from BatchRows snapshot, got %d", batchCount)
+	}
+
+	// 新查询可以看到全部数据
+	all, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer all.Close()
+	if got := all.Count(); got != 100 {
+		t.Errorf("expected 100 rows in new query, got %d", got)
+	}
+}
+
+func TestRowsCleanWhileIterating(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestRowsCleanWhileIterating")
+	defer os.RemoveAll(tmpDir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:    tmpDir,
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	table.Flush()
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	br, err := table.Query().BatchRows([]string{"n"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+
+	if !rows.Next() || !br.Next() {
+		t.Fatal("expected data before Clean")
+	}
+	if n := table.Stats().OpenIterators; n != 2 {
+		t.Errorf("expected 2 open iterators, got %d", n)
+	}
+
+	if err := table.Clean(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Clean 之前创建的迭代器失效
+	if rows.Next() {
+		t.Error("expected Next to return false after Clean")
+	}
+	if !IsError(rows.Err(), ErrCodeTableReset) {
+		t.Errorf("expected ErrCodeTableReset, got %v", rows.Err())
+	}
+	if br.Next() {
+		t.Error("expected BatchRows.Next to return false after Clean")
+	}
+	if !IsError(br.Err(), ErrCodeTableReset) {
+		t.Errorf("expected ErrCodeTableReset, got %v", br.Err())
+	}
+	if n := table.Stats().OpenIterators; n != 0 {
+		t.Errorf("expected 0 open iterators, got %d", n)
+	}
+
+	// Clean 之后新建的迭代器正常工作
+	if err := table.Insert(map[string]any{"n": int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	rows2, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows2.Close()
+	count := 0
+	for rows2.Next() {
+		count++
+	}
+	if rows2.Err() != nil || count != 1 {
+		t.Errorf("expected 1 row after Clean, got %d (err=%v)", count, rows2.Err())
+	}
+}
+
+func TestRowsDestroyWhileIterating(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestRowsDestroyWhileIterating")
+	defer os.RemoveAll(tmpDir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:    tmpDir,
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("expected data before Destroy")
+	}
+
+	// 并发读取与 Destroy 不应 panic
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				if _, err := table.Get(int64(i%100 + 1)); err != nil && !IsError(err, ErrCodeTableClosed) {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	if err := table.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	if rows.Next() {
+		t.Error("expected Next to return false after Destroy")
+	}
+	if !IsError(rows.Err(), ErrCodeTableClosed) {
+		t.Errorf("expected ErrCodeTableClosed, got %v", rows.Err())
+	}
+	if _, err := table.Get(1); !IsError(err, ErrCodeTableClosed) {
+		t.Errorf("expected ErrCodeTableClosed from Get, got %v", err)
+	}
+	if _, err := table.Query().Rows(); !IsError(err, ErrCodeTableClosed) {
+		t.Errorf("expected ErrCodeTableClosed from Rows, got %v", err)
+	}
+}
+
+func TestRowsConcurrentUse(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 1000 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rows.Next() {
+		t.Fatal("expected data")
+	}
+
+	// 模拟另一个 goroutine 正在调用 Next
+	rows.busy.Store(true)
+	if rows.Next() {
+		t.Error("expected Next to fail during concurrent use")
+	}
+	if err := rows.Close(); !IsError(err, ErrCodeConcurrentRowsUse) {
+		t.Errorf("expected ErrCodeConcurrentRowsUse from Close, got %v", err)
+	}
+	rows.busy.Store(false)
+
+	// 检测到并发使用后结果集失效，不再返回数据
+	if rows.Next() {
+		t.Error("expected Next to return false after misuse")
+	}
+	if _, err := rows.First(); !IsError(err, ErrCodeConcurrentRowsUse) {
+		t.Errorf("expected ErrCodeConcurrentRowsUse from First, got %v", err)
+	}
+	if !IsError(rows.Err(), ErrCodeConcurrentRowsUse) {
+		t.Errorf("expected ErrCodeConcurrentRowsUse, got %v", rows.Err())
+	}
+	// 失效的结果集仍然可以关闭并释放资源
+	if err := rows.Close(); err != nil {
+		t.Errorf("expected Close to succeed, got %v", err)
+	}
+	if n := table.Stats().OpenIterators; n != 0 {
+		t.Errorf("expected 0 open iterators, got %d", n)
+	}
+
+	// 按顺序在 goroutine 之间传递 Rows 是允许的
+	rows, err = table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	done := make(chan int)
+	go func() {
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		done <- n
+	}()
+	if n := <-done; n != 1000 || rows.Err() != nil {
+		t.Errorf("expected 1000 rows, got %d (err=%v)", n, rows.Err())
+	}
+}
+
+func TestQueryPage(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "TestQueryPage")
+	defer os.RemoveAll(tmpDir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:    tmpDir,
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 一部分数据在 SST 中，一部分在 MemTable 中
+	for i := range 25 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 14 {
+			table.Flush()
+			for table.memtableManager.GetImmutableCount() > 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+
+	collect := func(p *Page) []int64 {
+		t.Helper()
+		defer p.Rows.Close()
+		var ns []int64
+		for p.Rows.Next() {
+			ns = append(ns, p.Rows.Row().Data()["n"].(int64))
+		}
+		return ns
+	}
+
+	tests := []struct {
+		name       string
+		qb         *QueryBuilder
+		page       int
+		total      int
+		totalPages int
+		first      int64
+		count      int
+	}{
+		{"full scan first page", table.Query(), 1, 25, 3, 0, 10},
+		{"full scan last page", table.Query(), 3, 25, 3, 20, 5},
+		{"full scan out of range", table.Query(), 4, 25, 3, 0, 0},
+		{"with condition", table.Query().Gte("n", int64(10)), 2, 15, 2, 20, 5},
+		{"order by desc", table.Query().OrderByDesc("_seq"), 1, 25, 3, 24, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.qb.Page(tt.page, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Total != tt.total || p.TotalPages != tt.totalPages || p.Page != tt.page || p.PerPage != 10 {
+				t.Errorf("unexpected page info %+v", p)
+			}
+			ns := collect(p)
+			if len(ns) != tt.count {
+				t.Fatalf("expected %d rows, got %d", tt.count, len(ns))
+			}
+			if tt.count > 0 && ns[0] != tt.first {
+				t.Errorf("expected first n %d, got %d", tt.first, ns[0])
+			}
+		})
+	}
+
+	// 不修改调用者的 QueryBuilder
+	qb := table.Query()
+	if _, err := qb.Page(2, 10); err != nil {
+		t.Fatal(err)
+	}
+	if qb.offset != 0 || qb.limit != 0 {
+		t.Errorf("Page should not modify the query builder, offset=%d limit=%d", qb.offset, qb.limit)
+	}
+
+	if _, err := table.Query().Page(1, 0); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+}
+
+func TestQuerySeqs(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}, {Name: "kind", Type: String, Indexed: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 一部分数据在 SST 中，一部分在 MemTable 中
+	for i := range 20 {
+		kind := "odd"
+		if i%2 == 0 {
+			kind = "even"
+		}
+		if err := table.Insert(map[string]any{"n": int64(i), "kind": kind}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 9 {
+			table.Flush()
+			for table.memtableManager.GetImmutableCount() > 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		qb    *QueryBuilder
+		count int
+		first int64
+		last  int64
+	}{
+		{"full scan", table.Query(), 20, 1, 20},
+		{"offset limit", table.Query().Offset(5).Limit(3), 3, 6, 8},
+		{"order by desc", table.Query().OrderByDesc("_seq").Limit(4), 4, 20, 17},
+		{"index eq", table.Query().Eq("kind", "even"), 10, 1, 19},
+		{"index eq with limit", table.Query().Eq("kind", "odd").Offset(8), 2, 18, 20},
+		{"scan condition", table.Query().Gte("n", int64(15)), 5, 16, 20},
+		{"no match", table.Query().Eq("kind", "none"), 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seqs, err := tt.qb.Seqs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(seqs) != tt.count {
+				t.Fatalf("expected %d seqs, got %v", tt.count, seqs)
+			}
+			if tt.count > 0 && (seqs[0] != tt.first || seqs[len(seqs)-1] != tt.last) {
+				t.Errorf("expected seqs from %d to %d, got %v", tt.first, tt.last, seqs)
+			}
+		})
+	}
+
+	// 与 Rows 的结果一致
+	seqs, err := table.Query().Eq("kind", "odd").Gte("n", int64(10)).Seqs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := table.Query().Eq("kind", "odd").Gte("n", int64(10)).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var expected []int64
+	for rows.Next() {
+		expected = append(expected, rows.Row().Seq())
+	}
+	if !slices.Equal(seqs, expected) {
+		t.Errorf("expected %v, got %v", expected, seqs)
+	}
+}
+
+func TestSeqMergeIterator(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  [][]int64
+		maxSeq   int64
+		expected []int64
+	}{
+		{"empty", nil, 10, nil},
+		{"disjoint", [][]int64{{7, 8}, {4, 5, 6}, {1, 2, 3}}, 10, []int64{1, 2, 3, 4, 5, 6, 7, 8}},
+		// flush 期间同一行同时在 Immutable MemTable 和新的 SST 中
+		{"memtable and sst overlap", [][]int64{{9, 10}, {5, 6, 7, 8}, {5, 6, 7, 8}, {1, 2, 3, 4}}, 10, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		// compaction 期间新文件与旧文件的 seq 范围重叠
+		{"compaction overlap", [][]int64{{1, 2, 3}, {4, 5, 6}, {1, 2, 3, 4, 5, 6}}, 10, []int64{1, 2, 3, 4, 5, 6}},
+		{"snapshot", [][]int64{{5, 6, 7}, {1, 2, 3, 4}}, 5, []int64{1, 2, 3, 4, 5}},
+		{"empty sources", [][]int64{{}, {2}, {}, {1}}, 10, []int64{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merge := newSeqMergeIterator(tt.sources, tt.maxSeq)
+			var seqs []int64
+			for {
+				seq, ok := merge.next()
+				if !ok {
+					break
+				}
+				seqs = append(seqs, seq)
+			}
+			if !slices.Equal(seqs, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, seqs)
+			}
+		})
+	}
+}
+
+// TestRowsOrderingDuringFlushAndCompaction 在 flush 与 compaction 并发进行时反复查询，
+// 验证各查询路径的结果按 _seq 严格升序、没有重复，且不遗漏查询开始前已写入的行
+func TestRowsOrderingDuringFlushAndCompaction(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "events",
+		Fields: []Field{
+			{Name: "n", Type: Int64},
+			{Name: "kind", Type: String, Indexed: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	kinds := []string{"a", "b", "c"}
+	const total = 3000
+
+	// 写入：每 100 行 flush 一次；另一个 goroutine 反复触发 compaction
+	var written sync.WaitGroup
+	var committed atomic.Int64
+	written.Add(2)
+	done := make(chan struct{})
+	go func() {
+		defer written.Done()
+		defer close(done)
+		for i := range total {
+			if err := table.Insert(map[string]any{"n": int64(i), "kind": kinds[i%3]}); err != nil {
+				t.Error(err)
+				return
+			}
+			committed.Store(int64(i + 1))
+			if i%100 == 99 {
+				table.Flush()
+			}
+		}
+	}()
+	go func() {
+		defer written.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			table.compactionManager.TriggerCompaction()
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	tests := []struct {
+		name  string
+		query func() *QueryBuilder
+		match func(n int64) bool
+	}{
+		{"full scan", func() *QueryBuilder { return table.Query() }, func(int64) bool { return true }},
+		{"scan condition", func() *QueryBuilder { return table.Query().Gte("n", int64(500)) }, func(n int64) bool { return n >= 500 }},
+		{"index eq", func() *QueryBuilder { return table.Query().Eq("kind", "b") }, func(n int64) bool { return n%3 == 1 }},
+		{"index in", func() *QueryBuilder { return table.Query().In("kind", []any{"a", "c"}) }, func(n int64) bool { return n%3 != 1 }},
+		{"index pattern", func() *QueryBuilder { return table.Query().StartsWith("kind", "c") }, func(n int64) bool { return n%3 == 2 }},
+	}
+
+	check := func(t *testing.T, name string, qb *QueryBuilder, match func(int64) bool) {
+		t.Helper()
+		before := committed.Load()
+		rows, err := qb.Rows()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		defer rows.Close()
+
+		last := int64(0)
+		found := make(map[int64]bool)
+		for rows.Next() {
+			row := rows.Row()
+			if row.Seq() <= last {
+				t.Fatalf("%s: seq %d returned after %d", name, row.Seq(), last)
+			}
+			last = row.Seq()
+			found[row.Data()["n"].(int64)] = true
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for n := range before {
+			if match(n) && !found[n] {
+				t.Fatalf("%s: row %d written before the query is missing", name, n)
+			}
+		}
+	}
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		for _, tt := range tests {
+			check(t, tt.name, tt.query(), tt.match)
+		}
+	}
+	written.Wait()
+
+	// 写入结束后的最终结果
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, tt.name, tt.query(), tt.match)
+			seqs, err := tt.query().Seqs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.IsSorted(seqs) || len(slices.Compact(slices.Clone(seqs))) != len(seqs) {
+				t.Errorf("Seqs not strictly ascending: %v", seqs)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package srdb
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 查询内存统计：按估算的字节数统计查询物化（保留在内存中）的数据
+//
+// 统计的内容：
+//   - 缓存模式保留的行：索引查询、按 _seq 排序，以及 Collect/Len/Data/Last/Scan 等读取全部结果的方法
+//   - 排序与候选缓冲区：按 _seq 排序时收集的 seq、索引查询匹配的 seq 列表
+//   - GroupBy 的分组：每个分组的键与累加状态
+//
+// 惰性迭代（全表扫描、按索引字段排序）每次只解码一行，读取下一行后不再保留，不计入；
+// Distinct 的去重键有单独的上限（超过后溢出到临时文件），同样不计入。
+// 估算值包含 map、字符串等的固定开销，用于限制单个查询，不等于实际分配的字节数。
+
+const (
+	rowMemoryOverhead   = 96 // SSTableRow 与 map 的固定开销
+	fieldMemoryOverhead = 32 // map 中每个字段（key 的字符串头与 interface）的开销
+	seqMemory           = 8  // seq 列表中每个 seq
+	seqSetMemory        = 24 // 去重 map 中每个 seq
+)
+
+// MaxMemory 限制查询物化的数据量（字节，按估算值统计），0 表示不限制
+//
+// 超过上限时查询停止并返回 ErrCodeQueryMemoryExceeded：在 Rows 创建时物化的查询
+// （索引查询、按 _seq 排序）由 Rows 返回错误；Collect/Len/Data 等读取全部结果时超过上限，
+// 已读取的行保留，错误通过 Rows.Err 返回，Scan 到切片时直接返回错误。
+//
+//	rows, err := table.Query().Gt("age", 18).MaxMemory(64 << 20).Rows()
+//	if srdb.IsError(err, srdb.ErrCodeQueryMemoryExceeded) {
+//	    // 缩小查询范围，或改用 Next 逐行读取
+//	}
+func (qb *QueryBuilder) MaxMemory(n int64) *QueryBuilder {
+	if n < 0 {
+		n = 0
+	}
+	qb.maxMemory = n
+	return qb
+}
+
+// queryMemory 一个结果集物化的字节数
+type queryMemory struct {
+	limit int64 // 上限，0 表示不限制
+	used  int64
+}
+
+// charge 记录新物化的 n 字节，超过上限时返回错误
+func (m *queryMemory) charge(n int64) error {
+	m.used += n
+	if m.limit > 0 && m.used > m.limit {
+		return NewErrorf(ErrCodeQueryMemoryExceeded, "query materialized %d bytes, exceeding the limit of %d bytes", m.used, m.limit)
+	}
+	return nil
+}
+
+// MemoryUsed 返回结果集已物化的字节数（估算值，见 QueryBuilder.MaxMemory）
+func (r *Rows) MemoryUsed() int64 {
+	return r.memory.used
+}
+
+// rowMemory 估算一行解码后占用的字节数
+func rowMemory(row *SSTableRow) int64 {
+	n := int64(rowMemoryOverhead + len(row.Checksum))
+	for key, value := range row.Data {
+		n += fieldMemoryOverhead + int64(len(key)) + valueMemory(value)
+	}
+	return n
+}
+
+// valueMemory 估算一个字段值占用的字节数（不含 interface 本身）
+func valueMemory(value any) int64 {
+	switch v := value.(type) {
+	case nil, bool, int8, uint8:
+		return 0
+	case string:
+		return int64(len(v))
+	case []byte:
+		return 24 + int64(len(v))
+	case time.Time:
+		return 24
+	case decimal.Decimal:
+		return 40
+	case map[string]any:
+		n := int64(rowMemoryOverhead)
+		for key, item := range v {
+			n += fieldMemoryOverhead + int64(len(key)) + valueMemory(item)
+		}
+		return n
+	case []any:
+		n := int64(24)
+		for _, item := range v {
+			n += 16 + valueMemory(item)
+		}
+		return n
+	default:
+		return 8
+	}
+}
@@ -0,0 +1,80 @@
+package srdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQueryMaxMemory(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "blobs",
+		Fields: []Field{
+			{Name: "n", Type: Int64},
+			{Name: "payload", Type: String},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	payload := strings.Repeat("x", 1000)
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"n": i, "payload": payload}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 按 _seq 排序在创建时物化
+	rows, err := table.Query().OrderBy("_seq").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := rows.MemoryUsed(); used < 100*1000 {
+		t.Errorf("expected at least 100000 bytes accounted, got %d", used)
+	}
+	rows.Close()
+
+	_, err = table.Query().OrderBy("_seq").MaxMemory(10_000).Rows()
+	if !IsError(err, ErrCodeQueryMemoryExceeded) {
+		t.Fatalf("expected memory limit error, got %v", err)
+	}
+
+	// 惰性迭代每次只保留一行
+	rows, err = table.Query().MaxMemory(10_000).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if count != 100 || rows.Err() != nil || rows.MemoryUsed() != 0 {
+		t.Errorf("lazy scan: count=%d err=%v used=%d", count, rows.Err(), rows.MemoryUsed())
+	}
+	rows.Close()
+
+	// 读取全部结果时超过上限
+	rows, err = table.Query().MaxMemory(10_000).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := rows.Collect(); len(data) == 0 || len(data) >= 100 {
+		t.Errorf("expected a partial result, got %d rows", len(data))
+	}
+	if !IsError(rows.Err(), ErrCodeQueryMemoryExceeded) {
+		t.Errorf("expected memory limit error, got %v", rows.Err())
+	}
+	rows.Close()
+
+	var all []map[string]any
+	if err := table.Query().MaxMemory(10_000).Scan(&all); !IsError(err, ErrCodeQueryMemoryExceeded) {
+		t.Errorf("expected memory limit error from Scan, got %v", err)
+	}
+
+	// 上限足够时正常返回
+	if err := table.Query().MaxMemory(1 << 20).Scan(&all); err != nil || len(all) != 100 {
+		t.Errorf("expected 100 rows, got %d (%v)", len(all), err)
+	}
+}
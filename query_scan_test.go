@@ -1,6 +1,7 @@
 package srdb
 
 import (
+	"io"
 	"os"
 	"testing"
 	"time"
@@ -1652,3 +1653,100 @@ func TestMain(m *testing.M) {
 	// 清理
 	os.Exit(code)
 }
+
+func TestRowsScanMapsAndDecoder(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "devices",
+		Fields: []Field{
+			{Name: "device_id", Type: Uint32},
+			{Name: "temperature", Type: Float32},
+			{Name: "status", Type: Bool},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 10 {
+		if err := table.Insert(map[string]any{"device_id": i, "temperature": 20.5, "status": i%2 == 0}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 4 {
+			table.Flush()
+		}
+	}
+
+	// 扫描到 []map[string]any，值的类型与 Schema 一致
+	var all []map[string]any
+	if err := table.Query().Scan(&all); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 10 {
+		t.Fatalf("expected 10 rows, got %d", len(all))
+	}
+	for i, m := range all {
+		if m["device_id"] != uint32(i) || m["temperature"] != float32(20.5) || m["status"] != (i%2 == 0) {
+			t.Errorf("row %d: unexpected values %#v", i, m)
+		}
+		if _, ok := m["_seq"].(int64); !ok {
+			t.Errorf("row %d: missing _seq", i)
+		}
+	}
+
+	// 投影只保留选择的字段
+	var projected []map[string]any
+	if err := table.Query().Select("device_id").Eq("status", true).Scan(&projected); err != nil {
+		t.Fatal(err)
+	}
+	if len(projected) != 5 || len(projected[0]) != 1 || projected[0]["device_id"] != uint32(0) {
+		t.Errorf("unexpected projection %v", projected)
+	}
+
+	// 扫描单行到 map
+	var one map[string]any
+	if err := table.Query().Eq("device_id", 3).Scan(&one); err != nil {
+		t.Fatal(err)
+	}
+	if one["device_id"] != uint32(3) {
+		t.Errorf("unexpected row %v", one)
+	}
+
+	// 逐行解码
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	dec := rows.Decoder()
+	count := 0
+	for dec.More() {
+		if count%2 == 0 {
+			var device DeviceScanTest
+			if err := dec.Decode(&device); err != nil {
+				t.Fatal(err)
+			}
+			if device.DeviceID != uint32(count) {
+				t.Errorf("expected device %d, got %d", count, device.DeviceID)
+			}
+		} else {
+			var m map[string]any
+			if err := dec.Decode(&m); err != nil {
+				t.Fatal(err)
+			}
+			if m["device_id"] != uint32(count) {
+				t.Errorf("expected device %d, got %v", count, m["device_id"])
+			}
+		}
+		count++
+	}
+	if count != 10 {
+		t.Errorf("expected 10 decoded rows, got %d", count)
+	}
+	var m map[string]any
+	if err := dec.Decode(&m); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
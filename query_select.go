@@ -0,0 +1,501 @@
+package srdb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 查询时计算的列
+//
+// Select 除了字段名，还可以包含简单的表达式，迭代时逐行计算并作为额外的列返回：
+//
+//	table.Query().Select("city", "temperature / 10.0 AS celsius", "concat(first, ' ', last) AS name")
+//
+// 支持的表达式：
+//   - 字段别名：field AS alias
+//   - 算术：+ - * / %，括号与一元负号；操作数为数值字段（包括 _seq、_time）与数字字面量，
+//     两个整数运算的结果为 int64（除法为整数除法），否则为 float64；除数为 0 时结果为 NULL
+//   - 函数：concat(a, b, ...) 拼接为字符串，lower(x)、upper(x)、trim(x)
+//   - 字符串字面量使用单引号，'' 表示一个单引号
+//
+// 任一操作数为 NULL 时结果为 NULL（concat 将 NULL 视为空字符串）。
+// 没有 AS 时列名为表达式原文。表达式在 Select 时解析并按 Schema 检查，错误在执行查询时返回。
+
+// selectExpr Select 中的一个计算列
+type selectExpr struct {
+	alias string
+	node  selectNode
+}
+
+// selectNode 表达式节点
+type selectNode interface {
+	eval(row *SSTableRow) any
+}
+
+// selectKind 表达式结果的类别（用于检查算术的操作数）
+type selectKind int
+
+const (
+	selectKindAny selectKind = iota
+	selectKindNumber
+	selectKindString
+)
+
+// selectFuncs 计算列支持的函数
+var selectFuncs = map[string]func(args []any) any{
+	"concat": func(args []any) any {
+		var b strings.Builder
+		for _, arg := range args {
+			if arg != nil {
+				fmt.Fprint(&b, arg)
+			}
+		}
+		return b.String()
+	},
+	"lower": stringFunc(strings.ToLower),
+	"upper": stringFunc(strings.ToUpper),
+	"trim":  stringFunc(strings.TrimSpace),
+}
+
+// stringFunc 包装单参数的字符串函数，参数为 NULL 时结果为 NULL
+func stringFunc(fn func(string) string) func(args []any) any {
+	return func(args []any) any {
+		if args[0] == nil {
+			return nil
+		}
+		if s, ok := args[0].(string); ok {
+			return fn(s)
+		}
+		return fn(fmt.Sprint(args[0]))
+	}
+}
+
+// isSelectField Select 的一项是否为普通字段名（而不是表达式）
+func isSelectField(item string, schema *Schema) bool {
+	if item == "_seq" || item == "_time" {
+		return true
+	}
+	if schema != nil {
+		if _, err := schema.GetField(item); err == nil {
+			return true
+		}
+	}
+	return isSelectIdent(item)
+}
+
+// isSelectIdent 是否为标识符（字母或下划线开头，由字母、数字、下划线组成）
+func isSelectIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSelectExpr 解析 Select 中的表达式，按 schema 检查引用的字段
+func parseSelectExpr(item string, schema *Schema) (*selectExpr, error) {
+	tokens, err := tokenizeSelect(item)
+	if err != nil {
+		return nil, fmt.Errorf("select %q: %w", item, err)
+	}
+	p := &selectParser{tokens: tokens, schema: schema}
+
+	node, _, err := p.parseExpr()
+	if err != nil {
+		return nil, fmt.Errorf("select %q: %w", item, err)
+	}
+
+	alias := strings.TrimSpace(item)
+	if tok := p.peek(); tok.kind == tokIdent && strings.EqualFold(tok.text, "as") {
+		p.pos++
+		tok = p.next()
+		if tok.kind != tokIdent {
+			return nil, fmt.Errorf("select %q: expected alias after AS", item)
+		}
+		alias = tok.text
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("select %q: unexpected %q", item, tok.text)
+	}
+	return &selectExpr{alias: alias, node: node}, nil
+}
+
+// applySelectExprs 计算所有计算列并写入 data
+func applySelectExprs(row *SSTableRow, exprs []*selectExpr, data map[string]any) {
+	for _, expr := range exprs {
+		data[expr.alias] = expr.node.eval(row)
+	}
+}
+
+// 词法单元
+
+type selectTokenKind int
+
+const (
+	tokEOF selectTokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp // + - * / % ( ) ,
+)
+
+type selectToken struct {
+	kind selectTokenKind
+	text string
+}
+
+// tokenizeSelect 将表达式拆分为词法单元
+func tokenizeSelect(s string) ([]selectToken, error) {
+	var tokens []selectToken
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			j := i + 1
+			for j < len(rs) && (rs[j] == '_' || unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j])) {
+				j++
+			}
+			tokens = append(tokens, selectToken{tokIdent, string(rs[i:j])})
+			i = j
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			j := i + 1
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, selectToken{tokNumber, string(rs[i:j])})
+			i = j
+		case r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for {
+				if j >= len(rs) {
+					return nil, fmt.Errorf("unterminated string literal")
+				}
+				if rs[j] == '\'' {
+					if j+1 < len(rs) && rs[j+1] == '\'' {
+						b.WriteRune('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				b.WriteRune(rs[j])
+				j++
+			}
+			tokens = append(tokens, selectToken{tokString, b.String()})
+			i = j + 1
+		case strings.ContainsRune("+-*/%(),", r):
+			tokens = append(tokens, selectToken{tokOp, string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+// 语法分析
+//
+//	expr   := term (('+' | '-') term)*
+//	term   := factor (('*' | '/' | '%') factor)*
+//	factor := number | string | field | func '(' [expr (',' expr)*] ')' | '(' expr ')' | '-' factor
+
+type selectParser struct {
+	tokens []selectToken
+	pos    int
+	schema *Schema
+}
+
+func (p *selectParser) peek() selectToken {
+	if p.pos >= len(p.tokens) {
+		return selectToken{kind: tokEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *selectParser) next() selectToken {
+	tok := p.peek()
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOp 下一个词法单元是否为 ops 中的运算符
+func (p *selectParser) isOp(ops string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && strings.Contains(ops, tok.text)
+}
+
+func (p *selectParser) expect(op string) error {
+	if tok := p.next(); tok.kind != tokOp || tok.text != op {
+		if tok.kind == tokEOF {
+			return fmt.Errorf("expected %q", op)
+		}
+		return fmt.Errorf("expected %q, got %q", op, tok.text)
+	}
+	return nil
+}
+
+func (p *selectParser) parseExpr() (selectNode, selectKind, error) {
+	return p.parseBinary("+-", p.parseTerm)
+}
+
+func (p *selectParser) parseTerm() (selectNode, selectKind, error) {
+	return p.parseBinary("*/%", p.parseFactor)
+}
+
+// parseBinary 解析左结合的二元运算，操作数必须为数值
+func (p *selectParser) parseBinary(ops string, operand func() (selectNode, selectKind, error)) (selectNode, selectKind, error) {
+	left, kind, err := operand()
+	if err != nil {
+		return nil, 0, err
+	}
+	for p.isOp(ops) {
+		op := p.next().text
+		right, rightKind, err := operand()
+		if err != nil {
+			return nil, 0, err
+		}
+		if kind != selectKindNumber || rightKind != selectKindNumber {
+			return nil, 0, fmt.Errorf("operator %s requires numeric operands", op)
+		}
+		left = arithNode{op: op[0], left: left, right: right}
+	}
+	return left, kind, nil
+}
+
+func (p *selectParser) parseFactor() (selectNode, selectKind, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		if strings.ContainsAny(tok.text, ".") {
+			f, err := strconv.ParseFloat(tok.text, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("invalid number %q", tok.text)
+			}
+			return literalNode{f}, selectKindNumber, nil
+		}
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return literalNode{n}, selectKindNumber, nil
+
+	case tokString:
+		return literalNode{tok.text}, selectKindString, nil
+
+	case tokIdent:
+		if p.isOp("(") {
+			return p.parseCall(tok.text)
+		}
+		return p.parseField(tok.text)
+
+	case tokOp:
+		switch tok.text {
+		case "(":
+			node, kind, err := p.parseExpr()
+			if err != nil {
+				return nil, 0, err
+			}
+			return node, kind, p.expect(")")
+		case "-":
+			node, kind, err := p.parseFactor()
+			if err != nil {
+				return nil, 0, err
+			}
+			if kind != selectKindNumber {
+				return nil, 0, fmt.Errorf("operator - requires a numeric operand")
+			}
+			return arithNode{op: '-', left: literalNode{int64(0)}, right: node}, kind, nil
+		}
+		return nil, 0, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return nil, 0, fmt.Errorf("unexpected end of expression")
+}
+
+// parseField 解析字段引用，字段必须在 Schema 中（或为 _seq、_time）
+func (p *selectParser) parseField(name string) (selectNode, selectKind, error) {
+	if name == "_seq" || name == "_time" {
+		return fieldNode{name}, selectKindNumber, nil
+	}
+	if p.schema == nil {
+		return fieldNode{name}, selectKindAny, nil
+	}
+	field, err := p.schema.GetField(name)
+	if err != nil {
+		return nil, 0, NewErrorf(ErrCodeFieldNotFound, "field %s not found", name)
+	}
+	switch kind, ok := aggKindOf(field.Type); {
+	case ok && kind != aggDecimal:
+		return fieldNode{name}, selectKindNumber, nil
+	case field.Type == String:
+		return fieldNode{name}, selectKindString, nil
+	}
+	return fieldNode{name}, selectKindAny, nil
+}
+
+// parseCall 解析函数调用
+func (p *selectParser) parseCall(name string) (selectNode, selectKind, error) {
+	fn, ok := selectFuncs[strings.ToLower(name)]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported function %q", name)
+	}
+	p.pos++ // (
+
+	var args []selectNode
+	if !p.isOp(")") {
+		for {
+			arg, _, err := p.parseExpr()
+			if err != nil {
+				return nil, 0, err
+			}
+			args = append(args, arg)
+			if !p.isOp(",") {
+				break
+			}
+			p.pos++
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, 0, err
+	}
+
+	name = strings.ToLower(name)
+	if name != "concat" && len(args) != 1 {
+		return nil, 0, fmt.Errorf("%s expects 1 argument, got %d", name, len(args))
+	}
+	return callNode{fn: fn, args: args}, selectKindString, nil
+}
+
+// 表达式节点
+
+type literalNode struct{ value any }
+
+func (n literalNode) eval(*SSTableRow) any { return n.value }
+
+type fieldNode struct{ name string }
+
+func (n fieldNode) eval(row *SSTableRow) any {
+	switch n.name {
+	case "_seq":
+		return row.Seq
+	case "_time":
+		return row.Time
+	}
+	return row.Data[n.name]
+}
+
+type callNode struct {
+	fn   func(args []any) any
+	args []selectNode
+}
+
+func (n callNode) eval(row *SSTableRow) any {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.eval(row)
+	}
+	return n.fn(args)
+}
+
+type arithNode struct {
+	op          byte
+	left, right selectNode
+}
+
+func (n arithNode) eval(row *SSTableRow) any {
+	left, right := n.left.eval(row), n.right.eval(row)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	// 两个整数：按 int64 运算
+	if l, ok := selectInt(left); ok {
+		if r, ok := selectInt(right); ok {
+			switch n.op {
+			case '+':
+				return l + r
+			case '-':
+				return l - r
+			case '*':
+				return l * r
+			case '/':
+				if r == 0 {
+					return nil
+				}
+				return l / r
+			case '%':
+				if r == 0 {
+					return nil
+				}
+				return l % r
+			}
+		}
+	}
+
+	l, ok := toFloat64(left)
+	if !ok {
+		return nil
+	}
+	r, ok := toFloat64(right)
+	if !ok {
+		return nil
+	}
+	switch n.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	case '/':
+		if r == 0 {
+			return nil
+		}
+		return l / r
+	case '%':
+		if r == 0 {
+			return nil
+		}
+		return math.Mod(l, r)
+	}
+	return nil
+}
+
+// selectInt 将整数值转换为 int64，超出 int64 范围的无符号整数按浮点数运算
+func selectInt(v any) (int64, bool) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), true
+	case int8:
+		return int64(val), true
+	case int16:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case int64:
+		return val, true
+	case uint:
+		return int64(val), uint64(val) <= math.MaxInt64
+	case uint8:
+		return int64(val), true
+	case uint16:
+		return int64(val), true
+	case uint32:
+		return int64(val), true
+	case uint64:
+		return int64(val), val <= math.MaxInt64
+	}
+	return 0, false
+}
@@ -0,0 +1,134 @@
+package srdb
+
+import (
+	"testing"
+)
+
+func TestQuerySelectExpr(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "readings",
+		Fields: []Field{
+			{Name: "city", Type: String},
+			{Name: "first", Type: String},
+			{Name: "last", Type: String},
+			{Name: "temperature", Type: Int32},
+			{Name: "humidity", Type: Float64, Nullable: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	rows := []map[string]any{
+		{"city": "Oslo", "first": "Ada", "last": "Lovelace", "temperature": 215, "humidity": 0.5},
+		{"city": "Rome", "first": "Alan", "last": "Turing", "temperature": -32, "humidity": nil},
+	}
+	if err := table.Insert(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []map[string]any
+	err = table.Query().Select(
+		"city",
+		"temperature / 10.0 AS celsius",
+		"temperature / 10 AS whole",
+		"-(temperature % 10) * 2 AS odd",
+		"humidity * 100 AS percent",
+		"concat(first, ' ', last) AS name",
+		"upper(city)",
+		"first AS given",
+		"_seq + 0 AS seq",
+	).Scan(&results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(results))
+	}
+
+	first := results[0]
+	if len(first) != 9 {
+		t.Errorf("expected 9 columns, got %v", first)
+	}
+	if first["celsius"] != 21.5 {
+		t.Errorf("celsius: expected 21.5, got %v (%T)", first["celsius"], first["celsius"])
+	}
+	if first["whole"] != int64(21) {
+		t.Errorf("whole: expected int64 21, got %v (%T)", first["whole"], first["whole"])
+	}
+	if first["odd"] != int64(-10) {
+		t.Errorf("odd: expected -10, got %v", first["odd"])
+	}
+	if first["percent"] != 50.0 {
+		t.Errorf("percent: expected 50, got %v", first["percent"])
+	}
+	if first["name"] != "Ada Lovelace" {
+		t.Errorf("name: expected Ada Lovelace, got %v", first["name"])
+	}
+	if first["upper(city)"] != "OSLO" {
+		t.Errorf("upper(city): expected OSLO, got %v", first["upper(city)"])
+	}
+	if first["given"] != "Ada" {
+		t.Errorf("given: expected Ada, got %v", first["given"])
+	}
+	if first["seq"] != int64(1) {
+		t.Errorf("seq: expected 1, got %v", first["seq"])
+	}
+	if _, ok := first["temperature"]; ok {
+		t.Errorf("source field of an expression should not be returned: %v", first)
+	}
+
+	// NULL 操作数
+	if results[1]["percent"] != nil {
+		t.Errorf("expected NULL percent, got %v", results[1]["percent"])
+	}
+
+	// 扫描到结构体
+	type Reading struct {
+		City    string  `srdb:"city"`
+		Celsius float64 `srdb:"celsius"`
+		Name    string  `srdb:"name"`
+	}
+	var reading Reading
+	err = table.Query().Eq("city", "Rome").
+		Select("city", "temperature / 10.0 AS celsius", "concat(first, ' ', last) AS name").
+		Scan(&reading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reading.Celsius != -3.2 || reading.Name != "Alan Turing" {
+		t.Errorf("unexpected reading %+v", reading)
+	}
+
+	// 只有计算列
+	row, err := table.Query().Select("temperature * 2 AS double").First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := row.Data(); len(data) != 1 || data["double"] != int64(430) {
+		t.Errorf("expected only the computed column, got %v", data)
+	}
+
+	// 表达式错误在执行查询时返回
+	for _, expr := range []string{
+		"city * 2",
+		"missing + 1",
+		"temperature +",
+		"concat(city",
+		"nope(city)",
+		"'unterminated",
+		"temperature AS",
+		"lower(first, last)",
+	} {
+		if _, err := table.Query().Select(expr).Rows(); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+
+	// 去重不支持计算列
+	if _, err := table.Query().Select("temperature + 1 AS t").Distinct().Rows(); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected invalid param for distinct, got %v", err)
+	}
+}
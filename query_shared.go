@@ -0,0 +1,159 @@
+package srdb
+
+import (
+	"fmt"
+	"math"
+)
+
+// SharedQuery 共享扫描中的一个查询（见 Table.SharedScan）
+type SharedQuery struct {
+	Query      *QueryBuilder // 过滤条件、Offset、Limit、MaxMemory 与 WithContext 生效，不支持 OrderBy 与 Distinct
+	GroupBy    []string      // 分组字段，为空时不分组
+	Aggregates []Aggregate
+}
+
+// SharedResult 共享扫描中一个查询的结果
+type SharedResult struct {
+	Values []any   // 不分组时的聚合结果，与 QueryBuilder.Aggregate 相同
+	Groups []Group // 分组时的聚合结果，与 GroupQuery.Aggregate 相同
+}
+
+// SharedScan 在一次扫描中执行多个聚合查询，结果与查询按顺序对应
+//
+// 仪表盘上的多个面板通常只是过滤条件不同的聚合，逐个执行需要把表读多遍；
+// 共享扫描只读取一遍数据，每行按各查询的条件分别检查并累加到各自的聚合状态中：
+//
+//	results, err := table.SharedScan(
+//	    srdb.SharedQuery{Query: table.Query().Eq("status", "error"), Aggregates: []srdb.Aggregate{srdb.Count()}},
+//	    srdb.SharedQuery{Query: table.Query().Gt("latency_ms", 500), GroupBy: []string{"region"}, Aggregates: []srdb.Aggregate{srdb.Count()}},
+//	)
+//
+// 扫描条件是各查询条件的 OR，列统计（zone map）与 TimeRange 可以跳过所有查询都不需要的 SST 文件，
+// 但不使用索引；查询的选择性很高且有索引时单独执行可能更快。
+// 任一查询出错（包括超出 MaxMemory）时返回错误，不返回部分结果。
+func (t *Table) SharedScan(queries ...SharedQuery) ([]SharedResult, error) {
+	if len(queries) == 0 {
+		return nil, NewErrorf(ErrCodeInvalidParam, "no queries specified")
+	}
+
+	scans := make([]*sharedScanQuery, len(queries))
+	var filters []Expr
+	unfiltered := false
+	window := timeWindow{from: math.MaxInt64, to: math.MinInt64, set: true}
+	base := newQueryBuilder(t)
+	for i, q := range queries {
+		qb := q.Query
+		if qb == nil {
+			qb = newQueryBuilder(t)
+		}
+		if qb.table != t {
+			return nil, NewErrorf(ErrCodeInvalidParam, "query %d does not belong to table %s", i, t.schema.Load().Name)
+		}
+		if qb.orderBy != "" || qb.distinct {
+			return nil, NewErrorf(ErrCodeInvalidParam, "query %d: shared scan does not support OrderBy or Distinct", i)
+		}
+		if len(q.Aggregates) == 0 {
+			return nil, NewErrorf(ErrCodeInvalidParam, "query %d: no aggregates specified", i)
+		}
+		acc, err := newGroupAccumulator(qb, q.GroupBy, q.Aggregates)
+		if err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
+		}
+		scans[i] = &sharedScanQuery{qb: qb, acc: acc, grouped: len(q.GroupBy) > 0}
+
+		if len(qb.conds) == 0 {
+			unfiltered = true
+		} else {
+			filters = append(filters, And(qb.conds...))
+		}
+		// 扫描的时间范围覆盖所有查询的时间范围，有一个查询不限制时间时不限制
+		window.set = window.set && qb.window.set
+		window.from = min(window.from, qb.window.from)
+		window.to = max(window.to, qb.window.to)
+		// 扫描使用各查询中最高的优先级
+		base.bypass = base.bypass || qb.bypass
+		if i == 0 || priorityRank[qb.priority] > priorityRank[base.priority] {
+			base.priority = qb.priority
+		}
+	}
+	if !unfiltered {
+		base.Where(Or(filters...))
+	}
+	if window.set {
+		base.window = window
+	}
+
+	rows, err := base.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	active := len(scans)
+	for active > 0 && rows.Next() {
+		row := rows.Row().inner
+		for _, s := range scans {
+			if s.done || !s.qb.matchRow(row) {
+				continue
+			}
+			if s.skipped < s.qb.offset {
+				s.skipped++
+				continue
+			}
+			if err := s.acc.addRow(row); err != nil {
+				return nil, err
+			}
+			if s.taken++; s.qb.limit > 0 && s.taken >= s.qb.limit {
+				s.done = true
+				active--
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]SharedResult, len(scans))
+	for i, s := range scans {
+		if s.grouped {
+			if results[i].Groups, err = s.acc.results(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// 没有匹配的行时与 QueryBuilder.Aggregate 一样返回 COUNT 为 0、其余为 nil
+		if len(s.acc.groups) == 0 {
+			if _, err := s.acc.group(nil); err != nil {
+				return nil, err
+			}
+		}
+		groups, err := s.acc.results()
+		if err != nil {
+			return nil, err
+		}
+		results[i].Values = groups[0].Values
+	}
+	return results, nil
+}
+
+// priorityRank 查询优先级从低到高的顺序
+var priorityRank = map[QueryPriority]int{PriorityLow: 0, PriorityNormal: 1, PriorityHigh: 2}
+
+// sharedScanQuery 共享扫描中一个查询的状态
+type sharedScanQuery struct {
+	qb      *QueryBuilder
+	acc     *groupAccumulator
+	grouped bool
+	skipped int  // 已跳过的匹配行（Offset）
+	taken   int  // 已累加的匹配行（Limit）
+	done    bool // 已达到 Limit
+}
+
+// SharedScan 在一次扫描中对指定的表执行多个聚合查询（见 Table.SharedScan）
+func (db *Database) SharedScan(table string, queries ...SharedQuery) ([]SharedResult, error) {
+	t, err := db.GetTable(table)
+	if err != nil {
+		return nil, err
+	}
+	return t.SharedScan(queries...)
+}
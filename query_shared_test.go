@@ -0,0 +1,111 @@
+package srdb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSharedScan(t *testing.T) {
+	db, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema, err := NewSchema("requests", []Field{
+		{Name: "status", Type: String},
+		{Name: "latency", Type: Int64},
+		{Name: "owner", Type: String},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := db.CreateTable("requests", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 40 {
+		row := map[string]any{
+			"status":  []string{"ok", "error"}[i%2],
+			"latency": int64(i * 10),
+			"owner":   []string{"alice", "bob"}[i%4/2],
+		}
+		if err := table.Insert(row); err != nil {
+			t.Fatal(err)
+		}
+		if i == 19 {
+			if err := table.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	queries := []SharedQuery{
+		{Query: table.Query().Eq("status", "error"), Aggregates: []Aggregate{Count(), Max("latency")}},
+		{Query: table.Query().Gte("latency", 300), GroupBy: []string{"status"}, Aggregates: []Aggregate{Count()}},
+		{Query: table.Query().Eq("status", "ok").Offset(2).Limit(3), Aggregates: []Aggregate{Sum("latency")}},
+		{Query: table.Query().Gt("latency", 10000), Aggregates: []Aggregate{Count(), Sum("latency")}},
+	}
+	results, err := db.SharedScan("requests", queries...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 与逐个执行的结果相同
+	for i, q := range queries[:4] {
+		if i == 1 {
+			want, err := table.Query().Gte("latency", 300).GroupBy("status").Aggregate(Count())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(results[i].Groups, want) {
+				t.Errorf("query %d: expected groups %v, got %v", i, want, results[i].Groups)
+			}
+			continue
+		}
+		want, err := q.Query.Aggregate(q.Aggregates...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(results[i].Values, want) {
+			t.Errorf("query %d: expected %v, got %v", i, want, results[i].Values)
+		}
+	}
+	if !reflect.DeepEqual(results[3].Values, []any{int64(0), nil}) {
+		t.Errorf("expected empty aggregates, got %v", results[3].Values)
+	}
+
+	// 每个查询的行级策略按各自的 context 检查
+	table.SetRowPolicy(func(ctx context.Context, row map[string]any) bool {
+		return row["owner"] == ctx.Value(policyUserKey{})
+	})
+	ctx := context.WithValue(context.Background(), policyUserKey{}, "alice")
+	results, err = table.SharedScan(
+		SharedQuery{Query: table.Query().WithContext(ctx), Aggregates: []Aggregate{Count()}},
+		SharedQuery{Aggregates: []Aggregate{Count()}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Values[0] != int64(20) || results[1].Values[0] != int64(40) {
+		t.Errorf("expected 20 visible and 40 total rows, got %v %v", results[0].Values, results[1].Values)
+	}
+
+	other, err := db.CreateTable("other", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.SharedScan(SharedQuery{Query: other.Query(), Aggregates: []Aggregate{Count()}}); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for a query on another table, got %v", err)
+	}
+	if _, err := table.SharedScan(SharedQuery{Query: table.Query().OrderBy("_seq"), Aggregates: []Aggregate{Count()}}); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for OrderBy, got %v", err)
+	}
+	if _, err := table.SharedScan(SharedQuery{GroupBy: []string{"missing"}, Aggregates: []Aggregate{Count()}}); !IsError(err, ErrCodeFieldNotFound) {
+		t.Errorf("expected ErrCodeFieldNotFound, got %v", err)
+	}
+	if _, err := db.SharedScan("missing"); !IsError(err, ErrCodeTableNotFound) {
+		t.Errorf("expected ErrCodeTableNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package srdb
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// WithSystemColumns 在每行数据中附加行当前所在位置的系统列，用于排查重复或错位的记录
+//
+//   - _source：数据来源，"memtable"（尚未 flush）或 "sst"
+//   - _file：SST 文件编号；MemTable 中的行为对应的 WAL 编号
+//   - _level：SST 所在层级；MemTable 中的行为 -1
+//
+// 系统列在调用 Data、Scan 时按与 Get 相同的查找顺序（MemTable → 新的 SST → 旧的 SST）计算，
+// 反映的是读取时提供该行的副本；flush 或 compaction 之后再次读取，位置可能改变。
+// 指定了 Select 时系统列同样返回。
+//
+// 写入批次没有持久化到 WAL 或 SST 中，无法提供；同一 WAL 编号的行属于同一个 flush 周期。
+// 表不支持删除，因此不存在软删除的行。
+func (qb *QueryBuilder) WithSystemColumns() *QueryBuilder {
+	qb.systemColumns = true
+	return qb
+}
+
+// rowSource 行当前所在的位置
+type rowSource struct {
+	memtable bool
+	file     int64 // SST 文件编号，MemTable 为对应的 WAL 编号
+	level    int   // SST 所在层级，MemTable 为 -1
+}
+
+// addSystemColumns 将行的位置写入 data，找不到时（行在读取后被 Clean）不添加
+func (t *Table) addSystemColumns(seq int64, data map[string]any) {
+	src, ok := t.locateRow(seq)
+	if !ok {
+		return
+	}
+	if src.memtable {
+		data["_source"] = "memtable"
+	} else {
+		data["_source"] = "sst"
+	}
+	data["_file"] = src.file
+	data["_level"] = src.level
+}
+
+// locateRow 按与 getWithPriority 相同的顺序查找提供 seq 的数据源
+func (t *Table) locateRow(seq int64) (rowSource, bool) {
+	if wal, found := t.memtableManager.locate(seq); found {
+		return rowSource{memtable: true, file: wal, level: -1}, true
+	}
+
+	fileNumber, found := t.sstManager.locate(seq)
+	if !found {
+		return rowSource{}, false
+	}
+	level := -1
+	for _, file := range t.versionSet.GetCurrent().GetSSTFiles() {
+		if file.FileNumber == fileNumber {
+			level = file.Level
+			break
+		}
+	}
+	return rowSource{file: fileNumber, level: level}, true
+}
+
+// locate 返回包含 key 的 MemTable 对应的 WAL 编号
+func (m *MemTableManager) locate(key int64) (int64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, found := m.active.Get(key); found {
+		return m.activeWAL, true
+	}
+	for i := len(m.immutables) - 1; i >= 0; i-- {
+		if _, found := m.immutables[i].MemTable.Get(key); found {
+			return m.immutables[i].WALNumber, true
+		}
+	}
+	return 0, false
+}
+
+// locate 返回包含 key 最新版本的 SST 文件编号（只读取行头）
+func (m *SSTableManager) locate(key int64) (int64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reader := m.latest(key)
+	if reader == nil {
+		return 0, false
+	}
+	var fileNumber int64
+	if _, err := fmt.Sscanf(filepath.Base(reader.path), "%d.sst", &fileNumber); err != nil {
+		return 0, false
+	}
+	return fileNumber, true
+}
+
+// selectExprs 返回 Select 中的计算列
+func (r *Rows) selectExprs() []*selectExpr {
+	if r.qb != nil {
+		return r.qb.exprs
+	}
+	return nil
+}
+
+// systemTable 返回 Row.system：启用 WithSystemColumns 时为所属的表，否则为 nil
+func (r *Rows) systemTable() *Table {
+	if r.qb != nil && r.qb.systemColumns {
+		return r.table
+	}
+	return nil
+}
+
+// project 按 Select 过滤行数据，启用 WithSystemColumns 时附加系统列
+func (r *Rows) project(row *SSTableRow) map[string]any {
+	exprs := r.selectExprs()
+	data := projectRow(row, r.fields, exprs)
+	if len(r.fields) == 0 && len(exprs) == 0 && r.schema != nil {
+		r.schema.hideDeprecated(data)
+	}
+	if r.schema != nil && (len(r.fields) > 0 || len(exprs) == 0) {
+		r.schema.fillMissing(data, r.fields, r.missingFieldPolicy())
+	}
+	if system := r.systemTable(); system != nil {
+		system.addSystemColumns(row.Seq, data)
+	}
+	return data
+}
@@ -0,0 +1,81 @@
+package srdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryWithSystemColumns(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "name", Type: String}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for range 3 {
+		if err := table.Insert(map[string]any{"name": "flushed"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for range 2 {
+		if err := table.Insert(map[string]any{"name": "pending"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := table.versionSet.GetCurrent().GetSSTFiles()
+	if len(files) != 1 {
+		t.Fatalf("expected 1 SST file, got %d", len(files))
+	}
+	sstFile := files[0].FileNumber
+	activeWAL := table.memtableManager.activeWAL
+
+	var rows []map[string]any
+	if err := table.Query().Select("name").WithSystemColumns().Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows, got %d", len(rows))
+	}
+	for i, row := range rows {
+		source, file, level := "sst", sstFile, 0
+		if i >= 3 {
+			source, file, level = "memtable", activeWAL, -1
+		}
+		if row["_source"] != source || row["_file"] != file || row["_level"] != level {
+			t.Errorf("row %d: expected %s/%d/%d, got %v/%v/%v", i, source, file, level, row["_source"], row["_file"], row["_level"])
+		}
+	}
+
+	// 游标模式与 Data 同样附加系统列
+	it, err := table.Query().WithSystemColumns().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	if !it.Next() {
+		t.Fatal("expected a row")
+	}
+	if data := it.Row().Data(); data["_source"] != "sst" || data["name"] != "flushed" {
+		t.Errorf("unexpected row data: %v", data)
+	}
+
+	// 未启用时不附加
+	row, err := table.Query().First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := row.Data()["_source"]; ok {
+		t.Error("expected no system columns without WithSystemColumns")
+	}
+}
@@ -0,0 +1,62 @@
+package srdb
+
+import (
+	"math"
+	"time"
+)
+
+// timeWindow _time 的查询范围 [from, to)（UnixNano），set 为 false 表示不限制
+type timeWindow struct {
+	from, to int64
+	set      bool
+}
+
+// contains 判断写入时间是否在范围内
+func (w timeWindow) contains(t int64) bool {
+	return !w.set || (t >= w.from && t < w.to)
+}
+
+// overlaps 判断 [minTime, maxTime] 是否与范围相交
+func (w timeWindow) overlaps(minTime, maxTime int64) bool {
+	return !w.set || (maxTime >= w.from && minTime < w.to)
+}
+
+// TimeRange 只返回写入时间 _time 在 [from, to) 内的行，零值表示不限制对应的一端；多次调用取交集
+//
+// 每个 SST 文件头部与 MemTable 都记录了其中行的最小、最大 _time，范围之外的文件与 MemTable
+// 在扫描前整体跳过，不读取其中的 key 与数据，适合按时间写入、按时间窗口查询的时序数据：
+//
+//	rows, err := table.Query().TimeRange(time.Now().Add(-time.Hour), time.Time{}).Rows()
+//
+// 被 Update 修改过的行按最新版本的 _time 判断（Update 会将 _time 更新为修改时间）。
+func (qb *QueryBuilder) TimeRange(from, to time.Time) *QueryBuilder {
+	w := timeWindow{from: math.MinInt64, to: math.MaxInt64, set: true}
+	if !from.IsZero() {
+		w.from = from.UnixNano()
+	}
+	if !to.IsZero() {
+		w.to = to.UnixNano()
+	}
+	if qb.window.set {
+		w.from = max(w.from, qb.window.from)
+		w.to = min(w.to, qb.window.to)
+	}
+	qb.window = w
+	return qb
+}
+
+// matchRow 检查行是否在时间范围内并匹配所有条件（见 Match）
+func (qb *QueryBuilder) matchRow(row *SSTableRow) bool {
+	return qb.window.contains(row.Time) && qb.Match(row.Data)
+}
+
+// memtableKeys 返回 MemTable 的 key，行的 _time 都不在时间范围内时返回 nil
+//
+// 先取 key 再取范围：之后写入的行只会扩大范围，不会跳过已取到的 key
+func (qb *QueryBuilder) memtableKeys(m *MemTable) []int64 {
+	keys := m.Keys()
+	if b := m.bounds(); qb.window.set && (!b.ok || !qb.window.overlaps(b.minTime, b.maxTime)) {
+		return nil
+	}
+	return keys
+}
@@ -0,0 +1,114 @@
+package srdb
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestQueryTimeRange(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := NewManualClock(start)
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:    dir,
+		Name:   "metrics",
+		Clock:  clock,
+		Fields: []Field{{Name: "host", Type: String, Indexed: true}, {Name: "value", Type: Int64}},
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { table.Close() }()
+
+	// 每小时 10 行，前 3 小时各 flush 为一个 SST 文件，最后 1 小时留在 MemTable 中
+	for hour := range 4 {
+		for i := range 10 {
+			if err := table.Insert(map[string]any{"host": []string{"a", "b"}[i%2], "value": int64(hour*10 + i)}); err != nil {
+				t.Fatal(err)
+			}
+			clock.Advance(time.Minute)
+		}
+		clock.Set(start.Add(time.Duration(hour+1) * time.Hour))
+		if hour < 3 {
+			if err := table.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			for table.memtableManager.GetImmutableCount() > 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+	hour := func(n int) time.Time { return start.Add(time.Duration(n) * time.Hour) }
+
+	values := func(qb *QueryBuilder) []int64 {
+		t.Helper()
+		rows, err := qb.Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var got []int64
+		for rows.Next() {
+			got = append(got, rows.Row().Data()["value"].(int64))
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if got := values(table.Query().TimeRange(hour(1), hour(2))); !slices.Equal(got, []int64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}) {
+		t.Errorf("unexpected rows in the second hour: %v", got)
+	}
+	// 与条件、索引、排序组合；多次调用取交集
+	if got := values(table.Query().Eq("host", "b").TimeRange(hour(2), time.Time{}).TimeRange(time.Time{}, hour(3)).OrderByDesc("_seq")); !slices.Equal(got, []int64{29, 27, 25, 23, 21}) {
+		t.Errorf("unexpected indexed rows: %v", got)
+	}
+	if got := values(table.Query().TimeRange(hour(3).Add(5*time.Minute), time.Time{})); !slices.Equal(got, []int64{35, 36, 37, 38, 39}) {
+		t.Errorf("unexpected rows from the memtable: %v", got)
+	}
+	if n, err := table.Query().TimeRange(time.Time{}, hour(2)).Count(); err != nil || n != 20 {
+		t.Errorf("expected count 20, got %d %v", n, err)
+	}
+	if seqs, _ := table.Query().Eq("host", "a").TimeRange(hour(1), hour(2)).Seqs(); len(seqs) != 5 {
+		t.Errorf("expected 5 indexed seqs in range, got %v", seqs)
+	}
+	if sum, err := table.Query().TimeRange(hour(3), hour(4)).Sum("value"); err != nil || sum != int64(345) {
+		t.Errorf("expected sum 345, got %v %v", sum, err)
+	}
+
+	// 时间范围之外的 SST 文件与 MemTable 整体跳过
+	qb := table.Query().TimeRange(hour(1), hour(2))
+	if readers, _ := table.sstManager.snapshotKeys(nil, qb.window, false); len(readers) != 1 {
+		t.Errorf("expected a single SST file in range, got %d", len(readers))
+	}
+	if keys := qb.memtableKeys(table.memtableManager.GetActive()); keys != nil {
+		t.Errorf("expected the memtable to be skipped, got %d keys", len(keys))
+	}
+
+	// Update 后按最新版本的 _time 判断
+	if err := table.Update(1, map[string]any{"value": int64(100)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := values(table.Query().TimeRange(time.Time{}, hour(1))); len(got) != 9 || slices.Contains(got, 100) {
+		t.Errorf("expected the updated row to leave the first hour, got %v", got)
+	}
+	if got := values(table.Query().TimeRange(hour(4), time.Time{})); !slices.Equal(got, []int64{100}) {
+		t.Errorf("expected the updated row in the current hour, got %v", got)
+	}
+
+	// 文件元数据记录时间范围，重新打开后保留
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if table, err = OpenTable(opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range table.versionSet.GetCurrent().GetSSTFiles() {
+		if file.MinTime == 0 || file.MaxTime < file.MinTime {
+			t.Errorf("expected time bounds in file metadata, got %+v", file)
+		}
+	}
+}
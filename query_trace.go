@@ -0,0 +1,189 @@
+package srdb
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// QueryTrace 查询各阶段的耗时与行数（见 QueryBuilder.Trace）
+type QueryTrace struct {
+	Plan  string // 执行方式：scan、index、covering_index、order_by_seq 或 order_by_index
+	Index string // 使用的索引字段，没有使用索引时为空
+
+	Total       time.Duration // 总耗时
+	IndexLookup time.Duration // 查找索引得到候选行（使用索引时）
+	KeyCollect  time.Duration // 收集各数据源的 key（没有使用索引时）
+	Sort        time.Duration // 按 _seq 排序
+	Decode      time.Duration // 读取并解码行，按数据来源分列见 Files
+	Filter      time.Duration // 检查条件、时间范围与行级策略
+
+	RowsRead     int64 // 读取并解码的行
+	RowsFiltered int64 // 读取后不满足条件而丢弃的行
+	RowsReturned int64 // 返回的行（应用 Offset、Limit 之后）
+
+	FilesSkipped int         // 被列统计（zone map）或时间范围跳过的 SST 文件（全表扫描时）
+	Files        []FileTrace // 读取了行的数据来源，MemTable 在前，SST 按文件编号排序
+}
+
+// FileTrace 一个数据来源（SST 文件或 MemTable）的读取统计
+type FileTrace struct {
+	MemTable bool          // 是否为 MemTable
+	File     int64         // SST 文件编号，MemTable 为对应的 WAL 编号
+	Level    int           // SST 文件所在层级，MemTable 为 -1
+	Rows     int64         // 从该来源读取的行
+	Decode   time.Duration // 读取并解码这些行的耗时
+}
+
+// Trace 执行查询（读取并丢弃全部结果），返回各阶段的耗时与行数
+//
+// 用于排查线上的慢查询：可以直接作为 slog 的属性写入日志（QueryTrace 实现了 slog.LogValuer）：
+//
+//	trace, err := table.Query().Eq("status", "error").OrderBy("_seq").Limit(100).Trace()
+//	logger.Warn("slow request", "query", trace)
+//
+// 跟踪会为每一行额外查找其所在的数据来源，耗时高于直接执行查询；Distinct 查询跟踪的是去重之前的扫描。
+func (qb *QueryBuilder) Trace() (*QueryTrace, error) {
+	traced := *qb
+	tr := &queryTracer{files: make(map[traceSource]*FileTrace)}
+	traced.tracer = tr
+
+	start := time.Now()
+	rows, err := traced.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// 创建结果集期间读取行（缓存模式）与排序的时间不计入查找
+	prepare := max(0, time.Since(start)-tr.trace.Decode-tr.trace.Filter-tr.trace.Sort)
+	if tr.trace.Index != "" {
+		tr.trace.IndexLookup = prepare
+	} else {
+		tr.trace.KeyCollect = prepare
+	}
+
+	for rows.Next() {
+		tr.trace.RowsReturned++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	tr.trace.Total = time.Since(start)
+
+	for _, file := range tr.files {
+		tr.trace.Files = append(tr.trace.Files, *file)
+	}
+	slices.SortFunc(tr.trace.Files, func(a, b FileTrace) int {
+		if a.MemTable != b.MemTable {
+			if a.MemTable {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.File, b.File)
+	})
+	return &tr.trace, nil
+}
+
+// LogValue 以 slog 属性组的形式输出
+func (t *QueryTrace) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("plan", t.Plan),
+		slog.Duration("total", t.Total),
+	}
+	if t.Index != "" {
+		attrs = append(attrs, slog.String("index", t.Index), slog.Duration("index_lookup", t.IndexLookup))
+	} else {
+		attrs = append(attrs, slog.Duration("key_collect", t.KeyCollect))
+	}
+	if t.Sort > 0 {
+		attrs = append(attrs, slog.Duration("sort", t.Sort))
+	}
+	attrs = append(attrs,
+		slog.Duration("decode", t.Decode),
+		slog.Duration("filter", t.Filter),
+		slog.Int64("rows_read", t.RowsRead),
+		slog.Int64("rows_filtered", t.RowsFiltered),
+		slog.Int64("rows_returned", t.RowsReturned),
+		slog.Int("files_read", len(t.Files)),
+		slog.Int("files_skipped", t.FilesSkipped),
+	)
+	return slog.GroupValue(attrs...)
+}
+
+// traceSource 数据来源的标识
+type traceSource struct {
+	memtable bool
+	file     int64
+}
+
+// queryTracer 收集 Trace 的统计信息，nil 表示不跟踪（所有方法在 nil 上调用时不做任何事）
+type queryTracer struct {
+	trace QueryTrace
+	files map[traceSource]*FileTrace
+}
+
+// plan 记录执行方式
+func (tr *queryTracer) plan(plan, index string) {
+	if tr != nil {
+		tr.trace.Plan, tr.trace.Index = plan, index
+	}
+}
+
+// skipped 记录全表扫描跳过的 SST 文件数
+func (tr *queryTracer) skipped(n int) {
+	if tr != nil {
+		tr.trace.FilesSkipped = n
+	}
+}
+
+// now 返回当前时间，不跟踪时返回零值（避免读取时钟）
+func (tr *queryTracer) now() time.Time {
+	if tr == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// sorted 记录排序耗时
+func (tr *queryTracer) sorted(start time.Time) {
+	if tr != nil {
+		tr.trace.Sort += time.Since(start)
+	}
+}
+
+// read 记录读取一行的耗时，并按行所在的数据来源分列
+func (tr *queryTracer) read(t *Table, seq int64, start time.Time) {
+	if tr == nil {
+		return
+	}
+	d := time.Since(start)
+	tr.trace.Decode += d
+	tr.trace.RowsRead++
+
+	src, ok := t.locateRow(seq)
+	if !ok {
+		return
+	}
+	key := traceSource{memtable: src.memtable, file: src.file}
+	file := tr.files[key]
+	if file == nil {
+		file = &FileTrace{MemTable: src.memtable, File: src.file, Level: src.level}
+		tr.files[key] = file
+	}
+	file.Rows++
+	file.Decode += d
+}
+
+// filtered 记录检查一行的耗时与结果
+func (tr *queryTracer) filtered(start time.Time, matched bool) {
+	if tr == nil {
+		return
+	}
+	tr.trace.Filter += time.Since(start)
+	if !matched {
+		tr.trace.RowsFiltered++
+	}
+}
@@ -0,0 +1,96 @@
+package srdb
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryTrace(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := NewManualClock(start)
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "requests",
+		Clock:  clock,
+		Fields: []Field{{Name: "host", Type: String, Indexed: true}, {Name: "latency", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 两个 SST 文件与 MemTable
+	for i := range 300 {
+		if err := table.Insert(map[string]any{"host": []string{"a", "b", "c"}[i%3], "latency": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 99 || i == 199 {
+			if err := table.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			for table.memtableManager.GetImmutableCount() > 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			clock.Advance(time.Hour)
+		}
+	}
+
+	// 第一个 SST 文件被列统计跳过
+	trace, err := table.Query().Gte("latency", 150).Trace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Plan != "scan" || trace.RowsRead != 200 || trace.RowsFiltered != 50 || trace.RowsReturned != 150 || trace.FilesSkipped != 1 {
+		t.Errorf("unexpected scan trace %+v", trace)
+	}
+	if len(trace.Files) != 2 || !trace.Files[0].MemTable || trace.Files[0].Rows != 100 || trace.Files[1].Rows != 100 || trace.Files[1].Level != 0 {
+		t.Errorf("unexpected per-file trace %+v", trace.Files)
+	}
+	if trace.Total <= 0 || trace.Decode <= 0 || trace.Total < trace.Decode+trace.Filter {
+		t.Errorf("unexpected timings %+v", trace)
+	}
+
+	trace, err = table.Query().Eq("host", "a").Gt("latency", 10).Trace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Plan != "index" || trace.Index != "host" || trace.RowsRead != 100 || trace.RowsReturned != 96 || trace.KeyCollect != 0 {
+		t.Errorf("unexpected index trace %+v", trace)
+	}
+
+	trace, err = table.Query().OrderByDesc("_seq").Limit(5).Trace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Plan != "order_by_seq" || trace.RowsReturned != 5 {
+		t.Errorf("unexpected order trace %+v", trace)
+	}
+
+	// 时间范围跳过的文件
+	trace, err = table.Query().TimeRange(start.Add(2*time.Hour), time.Time{}).Trace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.FilesSkipped != 2 || trace.RowsRead != 100 || len(trace.Files) != 1 {
+		t.Errorf("expected two skipped files, got %+v", trace)
+	}
+
+	// 作为 slog 属性输出
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("slow query", "query", trace)
+	if out := buf.String(); !strings.Contains(out, `"plan":"scan"`) || !strings.Contains(out, `"files_skipped":2`) {
+		t.Errorf("unexpected log output %s", out)
+	}
+
+	// 跟踪不影响原查询
+	qb := table.Query().Eq("host", "b")
+	if _, err := qb.Trace(); err != nil {
+		t.Fatal(err)
+	}
+	if qb.tracer != nil {
+		t.Error("expected Trace to leave the query builder untouched")
+	}
+}
@@ -0,0 +1,188 @@
+package srdb
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 数据目录迁移
+//
+// 数据库的所有状态都在数据目录中（database.meta、各表的 WAL、MANIFEST、SST、索引与 Schema，
+// 以及 KV 存储），表目录在 database.meta 中记录为相对路径，整个目录移动到新位置后即可直接打开。
+// 未 flush 的数据仍在 WAL 中，随目录一起迁移，打开时照常重放。
+
+// relocateTmpSuffix 跨文件系统复制时的临时目录后缀，复制并校验完成后重命名为目标目录
+const relocateTmpSuffix = ".relocating"
+
+// Relocate 将数据目录从 oldDir 迁移到 newDir（离线操作，迁移期间不能打开数据库）
+//
+// 同一文件系统内直接重命名目录；跨文件系统（例如迁移到新磁盘）时：
+//  1. 复制所有文件到 newDir 旁的临时目录，复制时计算 CRC32，写入后 fsync
+//  2. 重新读取复制的文件，比对大小与 CRC32
+//  3. 全部校验通过后将临时目录重命名为 newDir，最后删除 oldDir
+//
+// 任一步骤失败时 oldDir 保持不变（临时目录会被删除）；newDir 必须不存在或为空目录。
+// 只有删除 oldDir 失败时数据已完整迁移到 newDir，返回的错误会说明这一点。
+func Relocate(oldDir, newDir string) error {
+	src, err := filepath.Abs(oldDir)
+	if err != nil {
+		return err
+	}
+	dst, err := filepath.Abs(newDir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(src, "database.meta")); err != nil {
+		return NewErrorf(ErrCodeDatabaseNotFound, "%s is not a database directory: %v", oldDir, err)
+	}
+	if src == dst {
+		return NewErrorf(ErrCodeInvalidParam, "source and destination are the same directory")
+	}
+	if rel, err := filepath.Rel(src, dst); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return NewErrorf(ErrCodeInvalidParam, "destination %s is inside the source directory", newDir)
+	}
+	if err := checkRelocateTarget(dst); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	// 同一文件系统：重命名是原子的，不需要复制与校验
+	if err := os.Rename(src, dst); err == nil {
+		syncDir(filepath.Dir(src))
+		syncDir(filepath.Dir(dst))
+		return nil
+	}
+
+	tmp := dst + relocateTmpSuffix
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := copyVerifiedTree(src, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("copy %s: %w", oldDir, err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(dst))
+
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("database relocated to %s, but remove %s: %w", newDir, oldDir, err)
+	}
+	syncDir(filepath.Dir(src))
+	return nil
+}
+
+// checkRelocateTarget 检查目标目录不存在或为空目录（空目录会被删除，以便重命名）
+func checkRelocateTarget(dst string) error {
+	entries, err := os.ReadDir(dst)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return NewErrorf(ErrCodeExists, "destination %s is not empty", dst)
+	}
+	return os.Remove(dst)
+}
+
+// copyVerifiedTree 复制目录树并逐个校验复制的文件
+func copyVerifiedTree(src, dst string) error {
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type().IsRegular():
+			return copyVerifiedFile(path, target)
+		default:
+			return fmt.Errorf("unsupported file type %s: %s", d.Type(), rel)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// 目录项在所有文件写入后再 fsync
+	return filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			syncDir(path)
+		}
+		return err
+	})
+}
+
+// copyVerifiedFile 复制文件并 fsync，然后重新读取比对大小与 CRC32
+func copyVerifiedFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	hash := crc32.NewIEEE()
+	n, err := io.Copy(io.MultiWriter(out, hash), in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != info.Size() {
+		return fmt.Errorf("%s changed during copy (size %d, copied %d)", src, info.Size(), n)
+	}
+
+	size, sum, err := fileChecksum(dst)
+	if err != nil {
+		return err
+	}
+	if size != n || sum != hash.Sum32() {
+		return NewErrorf(ErrCodeChecksumMismatch, "verify %s: copy does not match source", dst)
+	}
+	return nil
+}
+
+// fileChecksum 返回文件大小与 CRC32
+func fileChecksum(path string) (int64, uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	hash := crc32.NewIEEE()
+	n, err := io.Copy(hash, f)
+	return n, hash.Sum32(), err
+}
@@ -0,0 +1,122 @@
+package srdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelocate(t *testing.T) {
+	base := t.TempDir()
+	oldDir := filepath.Join(base, "old")
+	newDir := filepath.Join(base, "disk2", "data")
+
+	db, err := Open(oldDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := NewSchema("logs", []Field{{Name: "msg", Type: String}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := db.CreateTable("logs", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"msg": "hello"}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 49 {
+			// 一半数据写入 SST，一半留在 WAL 中
+			if err := table.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 目标目录不为空
+	if err := os.MkdirAll(newDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(newDir, "x"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Relocate(oldDir, newDir); !IsError(err, ErrCodeExists) {
+		t.Fatalf("expected exists error, got %v", err)
+	}
+	os.Remove(filepath.Join(newDir, "x"))
+
+	// 目标目录在源目录中
+	if err := Relocate(oldDir, filepath.Join(oldDir, "sub")); !IsError(err, ErrCodeInvalidParam) {
+		t.Fatalf("expected invalid param, got %v", err)
+	}
+	// 不是数据库目录
+	if err := Relocate(filepath.Join(base, "missing"), filepath.Join(base, "other")); !IsError(err, ErrCodeDatabaseNotFound) {
+		t.Fatalf("expected database not found, got %v", err)
+	}
+
+	if err := Relocate(oldDir, newDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Errorf("expected old directory to be removed, got %v", err)
+	}
+
+	db, err = Open(newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	table, err = db.GetTable("logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rows.Count(); n != 100 {
+		t.Errorf("expected 100 rows after relocate, got %d", n)
+	}
+}
+
+func TestCopyVerifiedTree(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "copy")
+
+	if err := os.MkdirAll(filepath.Join(src, "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"database.meta":              `{"version":1}`,
+		filepath.Join("a", "x"):      "hello",
+		filepath.Join("a", "b", "y"): "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := copyVerifiedTree(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, data)
+		}
+	}
+
+	// 目标文件已存在时不覆盖
+	if err := copyVerifiedFile(filepath.Join(src, "a", "x"), filepath.Join(dst, "a", "x")); !os.IsExist(err) {
+		t.Errorf("expected exist error, got %v", err)
+	}
+}
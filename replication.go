@@ -0,0 +1,831 @@
+package srdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+流式复制 (Replication)
+
+新的从库不需要带外复制文件：主库 ServeReplica 将一致性检查点（与 Backup 相同的目录）与之后的写入
+写入同一个流，从库 BootstrapReplica 接收并校验检查点后打开数据库，再由 Replica.Follow 持续应用写入。
+
+流的格式（小端序）：
+
+	[Magic: 8 "SRDBREPL"][Version: 4]
+	Frame*: [Kind: 1][Length: 4][Payload: Length][CRC32: 4]   CRC32 (IEEE) 覆盖 Kind、Length 与 Payload
+
+	replicaFrameDir        [Path]                              检查点中的目录（相对路径，'/' 分隔）
+	replicaFrameFile       [PathLen: 2][Path][Size: 8]         开始一个文件，之后是它的数据块
+	replicaFrameChunk      [Data]                              文件数据（每块不超过 replicaChunkSize）
+	replicaFrameFileEnd    [CRC32: 4]                          文件内容的 CRC32
+	replicaFrameCheckpoint JSON {"info": BackupInfo, "files": N}  检查点结束
+	replicaFrameChange     [TableLen: 2][Table][Type: 1][Mutation: 1][Seq: 8][Row]  一次写入
+	replicaFrameError      [Message]                           主库结束流的原因
+
+从库逐帧校验 CRC32，每个文件校验大小与内容的 CRC32，写入并 fsync 后重新读取再比对一次，
+检查点结束时核对文件数量；全部通过后才将临时目录重命名为目标目录，任何一步失败都不会留下数据库目录。
+
+检查点与写入的衔接：生成检查点之前先在所有表上订阅写入，检查点包含固定视图时 MemTable 中的全部行
+（不按持久化水位截断），因此订阅之前的写入都在检查点中，之后的写入都在流中，两者可能重叠。
+写入以行编码原样传输（包括 _seq 与 _time），从库按 seq 应用：已有 _time 不小于该写入的版本时跳过，
+重叠与乱序（同一 seq 的修改先于插入到达）都不影响结果。
+
+插入（包括跨表批量写入与批量导入）、Update 与 Delete 会被复制；KV 存储、Schema 与索引的变更不会。
+从库按自己的 Schema 解码写入，因此主库创建表、修改 Schema（UpdateSchemaMetadata）、清空或关闭表时结束流，
+队列超过 ReplicaOptions.QueueSize 时同样结束，从库需要重新引导。
+*/
+
+const (
+	replicaMagic   = "SRDBREPL"
+	replicaVersion = 1
+
+	// replicaChunkSize 检查点文件每个数据块的大小
+	replicaChunkSize = 1 << 20
+
+	// replicaMaxFrame 帧的最大长度，超过时视为损坏（避免按损坏的长度分配内存）
+	replicaMaxFrame = 256 << 20
+
+	// replicaTmpSuffix 从库接收检查点时的临时目录后缀，校验完成后重命名为目标目录
+	replicaTmpSuffix = ".bootstrapping"
+
+	// DefaultReplicaQueueSize 等待发送给从库的写入数量上限
+	DefaultReplicaQueueSize = 100000
+)
+
+// 帧类型
+const (
+	replicaFrameDir        = 1
+	replicaFrameFile       = 2
+	replicaFrameChunk      = 3
+	replicaFrameFileEnd    = 4
+	replicaFrameCheckpoint = 5
+	replicaFrameChange     = 6
+	replicaFrameError      = 7
+)
+
+// ReplicaOptions 复制流的选项
+type ReplicaOptions struct {
+	// QueueSize 等待发送的写入数量上限，0 表示 DefaultReplicaQueueSize；
+	// 发送检查点期间的写入同样在队列中等待，超过上限时结束复制流（不阻塞写入），从库需要重新引导
+	QueueSize int
+}
+
+// replicaCheckpoint 检查点结束帧的内容
+type replicaCheckpoint struct {
+	Info  *BackupInfo `json:"info"`
+	Files int         `json:"files"`
+}
+
+// replicaChange 一次等待发送的写入
+type replicaChange struct {
+	table     string
+	entryType byte // WALEntryTypePut 或 WALEntryTypeDelete
+	mutation  bool // 是否为 Update/Delete（否则为插入）
+	seq       int64
+	data      []byte // 行编码（删除时为删除标记）
+}
+
+// replicaFeed 一个复制流订阅的写入队列，订阅在所有表上共享
+type replicaFeed struct {
+	limit int
+
+	mu     sync.Mutex
+	queue  []replicaChange
+	err    error         // 结束复制流的原因
+	signal chan struct{} // 有新的写入或结束时发送（容量 1）
+}
+
+// replicaSubscription 表上的订阅，table 为数据库中的表名
+type replicaSubscription struct {
+	feed  *replicaFeed
+	table string
+}
+
+func newReplicaFeed(limit int) *replicaFeed {
+	return &replicaFeed{limit: limit, signal: make(chan struct{}, 1)}
+}
+
+// enqueue 加入队列，队列已满时结束复制流
+func (f *replicaFeed) enqueue(change replicaChange) {
+	f.mu.Lock()
+	if f.err == nil {
+		if len(f.queue) >= f.limit {
+			f.err = NewErrorf(ErrCodeInvalidData, "replica queue overflow (%d changes), replica must bootstrap again", f.limit)
+		} else {
+			f.queue = append(f.queue, change)
+		}
+	}
+	f.mu.Unlock()
+	f.notify()
+}
+
+// fail 结束复制流（只记录第一个原因）
+func (f *replicaFeed) fail(err error) {
+	f.mu.Lock()
+	if f.err == nil {
+		f.err = err
+	}
+	f.mu.Unlock()
+	f.notify()
+}
+
+func (f *replicaFeed) notify() {
+	select {
+	case f.signal <- struct{}{}:
+	default:
+	}
+}
+
+// next 等待并取出队列中的所有写入；复制流结束时先取完已排队的写入，再返回结束的原因
+func (f *replicaFeed) next(ctx context.Context) ([]replicaChange, error) {
+	for {
+		f.mu.Lock()
+		queue, err := f.queue, f.err
+		f.queue = nil
+		f.mu.Unlock()
+		if len(queue) > 0 {
+			return queue, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-f.signal:
+		}
+	}
+}
+
+// subscribeReplica 在表上订阅写入
+func (t *Table) subscribeReplica(sub replicaSubscription) {
+	t.replicasMu.Lock()
+	defer t.replicasMu.Unlock()
+
+	var subs []replicaSubscription
+	if old := t.replicas.Load(); old != nil {
+		subs = append(subs, *old...)
+	}
+	subs = append(subs, sub)
+	t.replicas.Store(&subs)
+}
+
+// unsubscribeReplica 取消订阅
+func (t *Table) unsubscribeReplica(feed *replicaFeed) {
+	t.replicasMu.Lock()
+	defer t.replicasMu.Unlock()
+
+	old := t.replicas.Load()
+	if old == nil {
+		return
+	}
+	var subs []replicaSubscription
+	for _, sub := range *old {
+		if sub.feed != feed {
+			subs = append(subs, sub)
+		}
+	}
+	if len(subs) == 0 {
+		t.replicas.Store(nil)
+	} else {
+		t.replicas.Store(&subs)
+	}
+}
+
+// failReplicas 结束表上所有的复制流（修改 Schema、Clean、关闭表时调用）
+func (t *Table) failReplicas(err error) {
+	if subs := t.replicas.Load(); subs != nil {
+		for _, sub := range *subs {
+			sub.feed.fail(err)
+		}
+	}
+}
+
+// replicate 将一次写入发送给订阅的复制流（没有订阅时不做任何事）
+func (t *Table) replicate(entryType byte, mutation bool, seq int64, data []byte) {
+	subs := t.replicas.Load()
+	if subs == nil {
+		return
+	}
+	for _, sub := range *subs {
+		sub.feed.enqueue(replicaChange{table: sub.table, entryType: entryType, mutation: mutation, seq: seq, data: data})
+	}
+}
+
+// replicateRows 将批量导入的行发送给复制流（批量导入不经过 WAL，按行编码后发送）
+func (t *Table) replicateRows(rows []*SSTableRow) {
+	if t.replicas.Load() == nil {
+		return
+	}
+	schema := t.schema.Load()
+	for _, row := range rows {
+		data, err := encodeSSTableRowBinary(row, schema)
+		if err != nil {
+			t.failReplicas(fmt.Errorf("encode row %d: %w", row.Seq, err))
+			return
+		}
+		t.replicate(WALEntryTypePut, false, row.Seq, data)
+	}
+}
+
+// failReplicas 结束所有进行中的复制流（创建表时调用，新表不在从库中）
+func (db *Database) failReplicas(err error) {
+	db.replicasMu.Lock()
+	defer db.replicasMu.Unlock()
+
+	for feed := range db.replicas {
+		feed.fail(err)
+	}
+}
+
+// ServeReplica 将数据库的检查点与之后的写入发送给从库（对端使用 BootstrapReplica 与 Replica.Follow）
+//
+// 先发送与 Backup 相同的一致性检查点（所有文件带 CRC32），然后持续发送之后的插入、修改与删除，
+// 直到 ctx 取消（返回 ctx 的错误）或复制流结束：创建表、修改 Schema、清空或关闭表、队列溢出时
+// 先向从库发送结束的原因再返回该错误。
+// w 的写入阻塞时无法取消，需要时由调用者关闭连接。opts 可以为 nil。
+func (db *Database) ServeReplica(ctx context.Context, w io.Writer, opts *ReplicaOptions) error {
+	limit := DefaultReplicaQueueSize
+	if opts != nil && opts.QueueSize > 0 {
+		limit = opts.QueueSize
+	}
+	src, err := filepath.Abs(db.dir)
+	if err != nil {
+		return err
+	}
+
+	// 检查点写入数据库目录旁的临时目录（同一文件系统，SST 可以硬链接）
+	tmp, err := os.MkdirTemp(filepath.Dir(src), filepath.Base(src)+".replica-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	feed := newReplicaFeed(limit)
+	info, err := db.replicaCheckpoint(tmp, feed)
+	defer db.unsubscribeReplica(feed)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriterSize(w, 64<<10)
+	if err := writeReplicaCheckpoint(out, tmp, info); err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	os.RemoveAll(tmp)
+
+	db.options.Logger.Info("[Database] Replica checkpoint sent", "tables", len(info.Tables))
+
+	for {
+		changes, err := feed.next(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				writeReplicaFrame(out, replicaFrameError, []byte(err.Error()))
+				out.Flush()
+			}
+			return err
+		}
+		for _, change := range changes {
+			if err := writeReplicaFrame(out, replicaFrameChange, encodeReplicaChange(change)); err != nil {
+				return err
+			}
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// replicaCheckpoint 在所有表上订阅写入后生成检查点
+func (db *Database) replicaCheckpoint(dir string, feed *replicaFeed) (*BackupInfo, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	db.replicasMu.Lock()
+	if db.replicas == nil {
+		db.replicas = make(map[*replicaFeed]struct{})
+	}
+	db.replicas[feed] = struct{}{}
+	db.replicasMu.Unlock()
+
+	// 先订阅再固定视图：订阅之前的写入都在检查点中
+	for name, table := range db.tables {
+		table.subscribeReplica(replicaSubscription{feed: feed, table: name})
+	}
+	return db.backupTo(dir, true)
+}
+
+// unsubscribeReplica 取消复制流在所有表上的订阅
+func (db *Database) unsubscribeReplica(feed *replicaFeed) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	db.replicasMu.Lock()
+	delete(db.replicas, feed)
+	db.replicasMu.Unlock()
+
+	for _, table := range db.tables {
+		table.unsubscribeReplica(feed)
+	}
+}
+
+// writeReplicaCheckpoint 写入流的头部与 dir 中的检查点（不包括 BACKUP 文件）
+func writeReplicaCheckpoint(w io.Writer, dir string, info *BackupInfo) error {
+	header := binary.LittleEndian.AppendUint32([]byte(replicaMagic), replicaVersion)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	files := 0
+	buf := make([]byte, replicaChunkSize)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." || rel == backupInfoFile {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case d.IsDir():
+			return writeReplicaFrame(w, replicaFrameDir, []byte(rel))
+		case d.Type().IsRegular():
+			files++
+			return writeReplicaFile(w, path, rel, buf)
+		default:
+			return fmt.Errorf("unsupported file type %s: %s", d.Type(), rel)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(&replicaCheckpoint{Info: info, Files: files})
+	if err != nil {
+		return err
+	}
+	return writeReplicaFrame(w, replicaFrameCheckpoint, payload)
+}
+
+// writeReplicaFile 写入一个文件的开始帧、数据块与 CRC32
+func writeReplicaFile(w io.Writer, path, rel string, buf []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	start := binary.LittleEndian.AppendUint16(nil, uint16(len(rel)))
+	start = append(start, rel...)
+	start = binary.LittleEndian.AppendUint64(start, uint64(info.Size()))
+	if err := writeReplicaFrame(w, replicaFrameFile, start); err != nil {
+		return err
+	}
+
+	hash := crc32.NewIEEE()
+	var sent int64
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			sent += int64(n)
+			if err := writeReplicaFrame(w, replicaFrameChunk, buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if sent != info.Size() {
+		return fmt.Errorf("%s changed while sending (size %d, sent %d)", rel, info.Size(), sent)
+	}
+	return writeReplicaFrame(w, replicaFrameFileEnd, binary.LittleEndian.AppendUint32(nil, hash.Sum32()))
+}
+
+// writeReplicaFrame 写入一帧
+func writeReplicaFrame(w io.Writer, kind byte, payload []byte) error {
+	head := make([]byte, 5, 9)
+	head[0] = kind
+	binary.LittleEndian.PutUint32(head[1:], uint32(len(payload)))
+	hash := crc32.NewIEEE()
+	hash.Write(head)
+	hash.Write(payload)
+
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	_, err := w.Write(binary.LittleEndian.AppendUint32(head[5:], hash.Sum32()))
+	return err
+}
+
+// readReplicaFrame 读取并校验一帧，在帧的边界上遇到 EOF 时返回 io.EOF
+func readReplicaFrame(r io.Reader) (byte, []byte, error) {
+	head := make([]byte, 5)
+	if _, err := io.ReadFull(r, head); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, NewErrorf(ErrCodeCorrupted, "replication stream truncated")
+		}
+		return 0, nil, err
+	}
+	size := binary.LittleEndian.Uint32(head[1:])
+	if size > replicaMaxFrame {
+		return 0, nil, NewErrorf(ErrCodeCorrupted, "replication frame too large (%d bytes)", size)
+	}
+	body := make([]byte, int(size)+4)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, nil, NewErrorf(ErrCodeCorrupted, "replication stream truncated")
+		}
+		return 0, nil, err
+	}
+	payload := body[:size]
+	hash := crc32.NewIEEE()
+	hash.Write(head)
+	hash.Write(payload)
+	if hash.Sum32() != binary.LittleEndian.Uint32(body[size:]) {
+		return 0, nil, NewErrorf(ErrCodeChecksumMismatch, "replication frame checksum mismatch")
+	}
+	return head[0], payload, nil
+}
+
+// encodeReplicaChange 编码写入帧的内容
+func encodeReplicaChange(c replicaChange) []byte {
+	buf := make([]byte, 0, 2+len(c.table)+10+len(c.data))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(c.table)))
+	buf = append(buf, c.table...)
+	buf = append(buf, c.entryType)
+	if c.mutation {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(c.seq))
+	return append(buf, c.data...)
+}
+
+// decodeReplicaChange 解码写入帧的内容
+func decodeReplicaChange(payload []byte) (replicaChange, error) {
+	var c replicaChange
+	if len(payload) < 2 {
+		return c, NewErrorf(ErrCodeCorrupted, "invalid replication change")
+	}
+	n := int(binary.LittleEndian.Uint16(payload))
+	if len(payload) < 2+n+10 {
+		return c, NewErrorf(ErrCodeCorrupted, "invalid replication change")
+	}
+	c.table = string(payload[2 : 2+n])
+	payload = payload[2+n:]
+	c.entryType = payload[0]
+	c.mutation = payload[1] != 0
+	c.seq = int64(binary.LittleEndian.Uint64(payload[2:10]))
+	c.data = payload[10:]
+	if c.entryType != WALEntryTypePut && c.entryType != WALEntryTypeDelete {
+		return c, NewErrorf(ErrCodeCorrupted, "invalid replication change type %d", c.entryType)
+	}
+	return c, nil
+}
+
+// Replica 通过 BootstrapReplica 创建的从库
+type Replica struct {
+	db         *Database
+	r          *bufio.Reader
+	checkpoint *BackupInfo
+	applied    atomic.Int64
+}
+
+// BootstrapReplica 从 ServeReplica 的流中接收检查点，在 opts.Dir 创建从库并打开
+//
+// opts.Dir 必须不存在或为空目录。检查点写入目标旁的临时目录，逐帧校验 CRC32，每个文件校验大小与 CRC32
+// 并在 fsync 后重新读取比对，全部通过后才重命名为目标目录；失败时目标目录保持不变。
+// 返回后调用 Follow 应用检查点之后的写入。
+func BootstrapReplica(r io.Reader, opts *Options) (*Replica, error) {
+	if opts == nil || opts.Dir == "" {
+		return nil, NewErrorf(ErrCodeInvalidParam, "replica directory is required")
+	}
+	dst, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRelocateTarget(dst); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+
+	tmp := dst + replicaTmpSuffix
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return nil, err
+	}
+
+	in := bufio.NewReaderSize(r, 64<<10)
+	info, err := receiveReplicaCheckpoint(in, tmp)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, fmt.Errorf("bootstrap replica: %w", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	syncDir(filepath.Dir(dst))
+
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	db.options.Logger.Info("[Database] Replica bootstrapped", "dir", dst, "tables", len(info.Tables))
+	return &Replica{db: db, r: in, checkpoint: info}, nil
+}
+
+// receiveReplicaCheckpoint 读取流的头部与检查点，写入 dir
+func receiveReplicaCheckpoint(r io.Reader, dir string) (*BackupInfo, error) {
+	header := make([]byte, len(replicaMagic)+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, NewErrorf(ErrCodeInvalidFormat, "read replication header: %v", err)
+	}
+	if string(header[:len(replicaMagic)]) != replicaMagic {
+		return nil, NewErrorf(ErrCodeInvalidMagicNumber, "not a replication stream")
+	}
+	if version := binary.LittleEndian.Uint32(header[len(replicaMagic):]); version != replicaVersion {
+		return nil, NewErrorf(ErrCodeUnsupportedVersion, "unsupported replication stream version %d", version)
+	}
+
+	var (
+		file     *os.File
+		filePath string
+		fileSize int64
+		received int64
+		hash     = crc32.NewIEEE()
+		files    int
+	)
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	for {
+		kind, payload, err := readReplicaFrame(r)
+		if err == io.EOF {
+			return nil, NewErrorf(ErrCodeCorrupted, "replication stream ended before the checkpoint was complete")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if file == nil && (kind == replicaFrameChunk || kind == replicaFrameFileEnd) ||
+			file != nil && kind != replicaFrameChunk && kind != replicaFrameFileEnd {
+			return nil, NewErrorf(ErrCodeCorrupted, "unexpected replication frame %d", kind)
+		}
+
+		switch kind {
+		case replicaFrameDir:
+			path, err := replicaPath(dir, string(payload))
+			if err != nil {
+				return nil, err
+			}
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return nil, err
+			}
+
+		case replicaFrameFile:
+			if len(payload) < 2 {
+				return nil, NewErrorf(ErrCodeCorrupted, "invalid replication file frame")
+			}
+			n := int(binary.LittleEndian.Uint16(payload))
+			if len(payload) != 2+n+8 {
+				return nil, NewErrorf(ErrCodeCorrupted, "invalid replication file frame")
+			}
+			filePath, err = replicaPath(dir, string(payload[2:2+n]))
+			if err != nil {
+				return nil, err
+			}
+			fileSize = int64(binary.LittleEndian.Uint64(payload[2+n:]))
+			received = 0
+			hash.Reset()
+			if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+				return nil, err
+			}
+			file, err = os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+			if err != nil {
+				return nil, err
+			}
+
+		case replicaFrameChunk:
+			received += int64(len(payload))
+			if received > fileSize {
+				return nil, NewErrorf(ErrCodeCorrupted, "%s: received more than %d bytes", filePath, fileSize)
+			}
+			hash.Write(payload)
+			if _, err := file.Write(payload); err != nil {
+				return nil, err
+			}
+
+		case replicaFrameFileEnd:
+			if len(payload) != 4 {
+				return nil, NewErrorf(ErrCodeCorrupted, "invalid replication file end frame")
+			}
+			err := file.Sync()
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			file = nil
+			if err != nil {
+				return nil, err
+			}
+			want := binary.LittleEndian.Uint32(payload)
+			if received != fileSize || hash.Sum32() != want {
+				return nil, NewErrorf(ErrCodeChecksumMismatch, "%s: received data does not match the checkpoint", filePath)
+			}
+			// 重新读取写入的文件，确认落盘的内容与主库一致
+			size, sum, err := fileChecksum(filePath)
+			if err != nil {
+				return nil, err
+			}
+			if size != fileSize || sum != want {
+				return nil, NewErrorf(ErrCodeChecksumMismatch, "verify %s: written file does not match the checkpoint", filePath)
+			}
+			files++
+
+		case replicaFrameCheckpoint:
+			var checkpoint replicaCheckpoint
+			if err := json.Unmarshal(payload, &checkpoint); err != nil || checkpoint.Info == nil {
+				return nil, NewErrorf(ErrCodeCorrupted, "invalid replication checkpoint frame")
+			}
+			if checkpoint.Files != files {
+				return nil, NewErrorf(ErrCodeCorrupted, "checkpoint has %d files, received %d", checkpoint.Files, files)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "database.meta")); err != nil {
+				return nil, NewErrorf(ErrCodeCorrupted, "checkpoint has no database metadata: %v", err)
+			}
+			// 目录项在所有文件写入后再 fsync
+			err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && d.IsDir() {
+					syncDir(path)
+				}
+				return err
+			})
+			return checkpoint.Info, err
+
+		case replicaFrameError:
+			return nil, NewErrorf(ErrCodeClosed, "leader ended replication: %s", payload)
+
+		default:
+			return nil, NewErrorf(ErrCodeCorrupted, "unexpected replication frame %d", kind)
+		}
+	}
+}
+
+// replicaPath 将流中的相对路径转换为 dir 下的路径，拒绝绝对路径与 ".."
+func replicaPath(dir, rel string) (string, error) {
+	rel = filepath.FromSlash(rel)
+	if !filepath.IsLocal(rel) {
+		return "", NewErrorf(ErrCodeCorrupted, "invalid path %q in replication stream", rel)
+	}
+	return filepath.Join(dir, rel), nil
+}
+
+// DB 返回从库的数据库（只用于读取，直接写入从库会与主库不一致）
+func (rep *Replica) DB() *Database {
+	return rep.db
+}
+
+// Checkpoint 返回引导时接收的检查点
+func (rep *Replica) Checkpoint() *BackupInfo {
+	return rep.checkpoint
+}
+
+// Applied 返回 Follow 已处理的写入数量（包括与检查点重叠而跳过的写入）
+func (rep *Replica) Applied() int64 {
+	return rep.applied.Load()
+}
+
+// Follow 应用检查点之后的写入，直到流结束或 ctx 取消
+//
+// 流在帧的边界上结束（主库关闭连接）时返回 nil，主库结束复制流时返回它发送的原因，
+// 帧损坏或应用失败时返回错误；之后需要重新引导。ctx 只在帧之间检查，读取阻塞时由调用者关闭连接。
+// 同一个 Replica 不能并发调用 Follow。
+func (rep *Replica) Follow(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		kind, payload, err := readReplicaFrame(rep.r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch kind {
+		case replicaFrameChange:
+			change, err := decodeReplicaChange(payload)
+			if err != nil {
+				return err
+			}
+			table, err := rep.db.GetTable(change.table)
+			if err != nil {
+				return err
+			}
+			if err := table.applyReplicated(change); err != nil {
+				return fmt.Errorf("apply %s seq %d: %w", change.table, change.seq, err)
+			}
+			rep.applied.Add(1)
+		case replicaFrameError:
+			return NewErrorf(ErrCodeClosed, "leader ended replication: %s", payload)
+		default:
+			return NewErrorf(ErrCodeCorrupted, "unexpected replication frame %d", kind)
+		}
+	}
+}
+
+// Close 关闭从库的数据库
+func (rep *Replica) Close() error {
+	return rep.db.Close()
+}
+
+// applyReplicated 应用主库的一次写入：已有 _time 不小于它的版本时跳过
+func (t *Table) applyReplicated(c replicaChange) error {
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	time, deleted, ok := rowVersion(c.data)
+	if !ok || deleted != (c.entryType == WALEntryTypeDelete) {
+		return NewErrorf(ErrCodeCorrupted, "invalid replicated row")
+	}
+	if int64(binary.LittleEndian.Uint64(c.data[4:12])) != c.seq {
+		return NewErrorf(ErrCodeCorrupted, "replicated row does not match seq %d", c.seq)
+	}
+	var row *SSTableRow
+	if !deleted {
+		if row, err = decodeSSTableRowBinary(c.data, t.schema.Load()); err != nil {
+			return err
+		}
+	}
+
+	t.mutateMu.Lock()
+	defer t.mutateMu.Unlock()
+
+	if latest, _, found := t.latestVersion(c.seq); found && latest >= time {
+		return nil
+	}
+	if c.mutation {
+		if err := t.mutations.add(c.seq); err != nil {
+			return fmt.Errorf("record mutation: %w", err)
+		}
+	}
+	if err := t.walManager.Append(&WALEntry{Type: c.entryType, Seq: c.seq, Data: c.data}); err != nil {
+		return err
+	}
+	t.memtableManager.Put(c.seq, c.data)
+	if row != nil {
+		t.indexManager.AddToIndexes(row.Data, c.seq)
+		if !c.mutation {
+			t.rowSizes.record(c.data)
+			t.caggs.add(row.Data, row.Time, c.seq)
+		}
+	}
+
+	t.durability.observe(c.seq)
+	t.inserted.notify()
+	t.lastWriteTime.Store(t.clock.Now().UnixNano())
+	if t.memtableManager.ShouldSwitch() {
+		go t.switchMemTable()
+	}
+	return nil
+}
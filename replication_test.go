@@ -0,0 +1,307 @@
+package srdb
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// replicaContents 返回表中每行的 seq 与 n
+func replicaContents(t *testing.T, table *Table) map[int64]any {
+	t.Helper()
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	contents := make(map[int64]any)
+	for rows.Next() {
+		contents[rows.Row().Seq()] = rows.Row().Data()["n"]
+	}
+	return contents
+}
+
+func equalContents(a, b map[int64]any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for seq, n := range a {
+		if other, ok := b[seq]; !ok || other != n {
+			return false
+		}
+	}
+	return true
+}
+
+func newReplicationLeader(t *testing.T, dir string) (*Database, *Table) {
+	t.Helper()
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := NewSchema("events", []Field{
+		{Name: "host", Type: String, Indexed: true},
+		{Name: "n", Type: Int64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := db.CreateTable("events", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, table
+}
+
+func TestReplication(t *testing.T) {
+	base := t.TempDir()
+	db, table := newReplicationLeader(t, filepath.Join(base, "leader"))
+	defer db.Close()
+
+	insert := func(from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			if err := table.Insert(map[string]any{"host": []string{"a", "b"}[i%2], "n": int64(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 检查点中一部分在 SST 中，一部分在 MemTable 中
+	insert(0, 50)
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	insert(50, 100)
+	if err := table.Update(3, map[string]any{"n": int64(1003)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Delete(4); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pr, pw := io.Pipe()
+	served := make(chan error, 1)
+	go func() {
+		err := db.ServeReplica(ctx, pw, &ReplicaOptions{QueueSize: 10000})
+		pw.Close()
+		served <- err
+	}()
+
+	// 引导期间写入继续进行
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 100; i < 300; i++ {
+			if err := table.Insert(map[string]any{"host": "c", "n": int64(i)}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	rep, err := BootstrapReplica(pr, DefaultOptions(filepath.Join(base, "replica")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Checkpoint().Tables["events"] < 100 {
+		t.Fatalf("checkpoint seq = %d, want >= 100", rep.Checkpoint().Tables["events"])
+	}
+	followed := make(chan error, 1)
+	go func() { followed <- rep.Follow(ctx) }()
+
+	wg.Wait()
+	if err := table.Update(60, map[string]any{"n": int64(1060)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Delete(61); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Update(150, map[string]any{"host": "a", "n": int64(1150)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.bulkLoad([]map[string]any{{"host": "d", "n": int64(2000)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := replicaContents(t, table)
+	replicaTable, err := rep.DB().GetTable("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !equalContents(replicaContents(t, replicaTable), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("replica did not catch up: got %d rows, want %d", len(replicaContents(t, replicaTable)), len(want))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 索引随写入更新
+	leaderHosts, err := table.Query().Eq("host", "a").Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicaHosts, err := replicaTable.Query().Eq("host", "a").Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replicaHosts != leaderHosts {
+		t.Fatalf("replica host=a count = %d, want %d", replicaHosts, leaderHosts)
+	}
+
+	// 新的写入不会复用主库的 seq
+	if replicaTable.GetMaxSeq() < table.GetMaxSeq() {
+		t.Fatalf("replica max seq = %d, want %d", replicaTable.GetMaxSeq(), table.GetMaxSeq())
+	}
+
+	// 主库创建表时结束复制流
+	other, err := NewSchema("other", []Field{{Name: "n", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateTable("other", other); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; !IsError(err, ErrCodeTableExists) {
+		t.Fatalf("ServeReplica = %v, want ErrCodeTableExists", err)
+	}
+	if err := <-followed; !IsError(err, ErrCodeClosed) {
+		t.Fatalf("Follow = %v, want ErrCodeClosed", err)
+	}
+
+	// 应用的写入在重新打开后仍然存在
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(filepath.Join(base, "replica"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	reopenedTable, err := reopened.GetTable("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := replicaContents(t, reopenedTable); !equalContents(got, want) {
+		t.Fatalf("reopened replica has %d rows, want %d", len(got), len(want))
+	}
+}
+
+// checkpointStream 返回只包含检查点的流
+func checkpointStream(t *testing.T, db *Database) []byte {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var buf bytes.Buffer
+	if err := db.ServeReplica(ctx, &buf, nil); err != context.Canceled {
+		t.Fatalf("ServeReplica = %v, want context.Canceled", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReplicationCorruptStream(t *testing.T) {
+	base := t.TempDir()
+	db, table := newReplicationLeader(t, filepath.Join(base, "leader"))
+	defer db.Close()
+	for i := range 100 {
+		if err := table.Insert(map[string]any{"host": "a", "n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	stream := checkpointStream(t, db)
+
+	corrupted := bytes.Clone(stream)
+	corrupted[len(corrupted)/2] ^= 0xFF
+	truncated := stream[:len(stream)-10]
+	for name, data := range map[string][]byte{"corrupted": corrupted, "truncated": truncated} {
+		dir := filepath.Join(base, name)
+		if _, err := BootstrapReplica(bytes.NewReader(data), DefaultOptions(dir)); err == nil {
+			t.Fatalf("%s: BootstrapReplica succeeded", name)
+		}
+		for _, path := range []string{dir, dir + replicaTmpSuffix} {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("%s: %s exists after failed bootstrap: %v", name, path, err)
+			}
+		}
+	}
+
+	// 完整的流可以引导，检查点之后流结束时 Follow 返回 nil
+	rep, err := BootstrapReplica(bytes.NewReader(stream), DefaultOptions(filepath.Join(base, "replica")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Close()
+	if err := rep.Follow(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	replicaTable, err := rep.DB().GetTable("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := replicaContents(t, replicaTable), replicaContents(t, table); !equalContents(got, want) {
+		t.Fatalf("replica has %d rows, want %d", len(got), len(want))
+	}
+}
+
+func TestReplicationSchemaChange(t *testing.T) {
+	base := t.TempDir()
+	db, table := newReplicationLeader(t, filepath.Join(base, "leader"))
+	defer db.Close()
+	if err := table.Insert(map[string]any{"host": "a", "n": int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pr, pw := io.Pipe()
+	served := make(chan error, 1)
+	go func() {
+		err := db.ServeReplica(ctx, pw, nil)
+		pw.Close()
+		served <- err
+	}()
+
+	rep, err := BootstrapReplica(pr, DefaultOptions(filepath.Join(base, "replica")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Close()
+	followed := make(chan error, 1)
+	go func() { followed <- rep.Follow(ctx) }()
+
+	// 从库的 Schema 不随主库更新，主库修改 Schema 时结束复制流
+	if err := table.UpdateSchemaMetadata(map[string]string{"n": "counter"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; !IsError(err, ErrCodeSchemaMismatch) {
+		t.Fatalf("ServeReplica = %v, want ErrCodeSchemaMismatch", err)
+	}
+	if err := <-followed; !IsError(err, ErrCodeClosed) {
+		t.Fatalf("Follow = %v, want ErrCodeClosed", err)
+	}
+
+	// 之后的写入不会被应用
+	if err := table.Insert(map[string]any{"host": "b", "n": int64(2)}); err != nil {
+		t.Fatal(err)
+	}
+	replicaTable, err := rep.DB().GetTable("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := replicaContents(t, replicaTable); len(got) != 1 {
+		t.Fatalf("replica has %d rows, want 1", len(got))
+	}
+}
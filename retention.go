@@ -0,0 +1,373 @@
+package srdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// 保留策略：按写入时间 _time 删除过期的原始行，并维护降采样用的连续聚合
+//
+// 策略持久化在表目录的 policy.json 中，重新打开表后继续生效；由 Compaction Manager
+// 的后台循环每 DefaultRetentionInterval 执行一次：
+//   - 所有行都已过期的 SST（文件头的最大 _time 早于截止时间）直接从 MANIFEST 删除，不读取数据
+//   - 部分过期的 SST 单独重写，过期的行不写入新文件；之后的 Compaction 同样丢弃过期的行
+//   - 连续聚合中结束时间早于各自保留时长的时间桶被删除
+//
+// 删除是最终一致的：执行之前过期的行仍可以被查询到，MemTable 中的行在 flush 之后才会被删除。
+// 过期的行只按 _time 判断；插入时 seq 随写入时间递增，因此过期的行总是最小的一段 seq，
+// 索引中残留的这些 seq 在查询时被跳过（被 Update/Delete 修改过的 seq 按最新版本判断）。
+
+const (
+	// DefaultRetentionInterval 后台执行保留策略的间隔
+	DefaultRetentionInterval = time.Minute
+
+	retentionPolicyFile = "policy.json"
+)
+
+// RetentionPolicy 表的保留与降采样策略
+type RetentionPolicy struct {
+	Retention time.Duration // 原始行按 _time 的保留时长（TTL），0 表示永久保留
+	Rollups   []Rollup      // 由策略管理的连续聚合，原始行过期后仍可以查询降采样的结果
+}
+
+// Rollup 由保留策略管理的连续聚合
+//
+// 设置策略时不存在的连续聚合被创建（并为已有数据计算），从策略中移除或定义改变的被删除（或重建）。
+type Rollup struct {
+	ContinuousAggregate
+	Retention time.Duration // 时间桶的保留时长，0 表示永久保留
+}
+
+// empty 策略是否为空（不需要持久化）
+func (p *RetentionPolicy) empty() bool {
+	return p.Retention == 0 && len(p.Rollups) == 0
+}
+
+// clone 返回深拷贝
+func (p *RetentionPolicy) clone() *RetentionPolicy {
+	c := *p
+	c.Rollups = slices.Clone(p.Rollups)
+	for i := range c.Rollups {
+		c.Rollups[i].Aggregates = slices.Clone(c.Rollups[i].Aggregates)
+	}
+	return &c
+}
+
+// validate 检查保留时长与连续聚合的定义
+func (p *RetentionPolicy) validate(caggs *continuousManager) error {
+	if p.Retention < 0 {
+		return NewErrorf(ErrCodeInvalidParam, "retention must not be negative, got %s", p.Retention)
+	}
+	names := make(map[string]bool, len(p.Rollups))
+	for _, rollup := range p.Rollups {
+		if rollup.Retention < 0 {
+			return NewErrorf(ErrCodeInvalidParam, "rollup %s: retention must not be negative, got %s", rollup.Name, rollup.Retention)
+		}
+		if names[rollup.Name] {
+			return NewErrorf(ErrCodeInvalidParam, "duplicate rollup %s", rollup.Name)
+		}
+		names[rollup.Name] = true
+		if _, err := caggs.newAggregate(rollup.ContinuousAggregate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameContinuousAggregate 两个连续聚合的定义是否相同
+func sameContinuousAggregate(a, b ContinuousAggregate) bool {
+	return a.Name == b.Name && a.Bucket == b.Bucket && a.TimeField == b.TimeField && slices.Equal(a.Aggregates, b.Aggregates)
+}
+
+// SetRetentionPolicy 设置表的保留策略并持久化，零值策略表示删除策略
+//
+//	table.SetRetentionPolicy(srdb.RetentionPolicy{
+//		Retention: 7 * 24 * time.Hour, // 原始行保留 7 天
+//		Rollups: []srdb.Rollup{{
+//			ContinuousAggregate: srdb.ContinuousAggregate{
+//				Name:       "latency_1h",
+//				Bucket:     time.Hour,
+//				Aggregates: []srdb.Aggregate{srdb.Count(), srdb.Avg("latency_ms")},
+//			},
+//			Retention: 365 * 24 * time.Hour, // 小时粒度的结果保留一年
+//		}},
+//	})
+//
+// 与策略之外创建的连续聚合同名时返回 ErrCodeExists。新的保留时长在下一次执行时生效，
+// 需要立即删除过期数据时调用 EnforceRetention。
+func (t *Table) SetRetentionPolicy(policy RetentionPolicy) error {
+	if t.closed.Load() {
+		return ErrTableClosed
+	}
+	t.retentionMu.Lock()
+	defer t.retentionMu.Unlock()
+
+	if err := policy.validate(t.caggs); err != nil {
+		return err
+	}
+	next := policy.clone()
+
+	managed := make(map[string]ContinuousAggregate)
+	if old := t.retention.Load(); old != nil {
+		for _, rollup := range old.Rollups {
+			managed[rollup.Name] = rollup.ContinuousAggregate
+		}
+	}
+	for _, rollup := range next.Rollups {
+		if _, err := t.caggs.get(rollup.Name); err == nil {
+			if _, ok := managed[rollup.Name]; !ok {
+				return NewErrorf(ErrCodeExists, "continuous aggregate %s already exists and is not managed by the retention policy", rollup.Name)
+			}
+		}
+	}
+
+	// 删除移除或定义改变的连续聚合，再创建缺少的
+	for name, def := range managed {
+		i := slices.IndexFunc(next.Rollups, func(r Rollup) bool { return r.Name == name })
+		if i >= 0 && sameContinuousAggregate(next.Rollups[i].ContinuousAggregate, def) {
+			continue
+		}
+		if err := t.caggs.drop(name); err != nil && !IsError(err, ErrCodeNotFound) {
+			return err
+		}
+	}
+	for _, rollup := range next.Rollups {
+		if _, err := t.caggs.get(rollup.Name); err == nil {
+			continue
+		}
+		if err := t.CreateContinuousAggregate(rollup.ContinuousAggregate); err != nil {
+			return err
+		}
+	}
+
+	if err := t.saveRetentionPolicy(next); err != nil {
+		return err
+	}
+	if next.empty() {
+		t.retention.Store(nil)
+	} else {
+		t.retention.Store(next)
+	}
+	t.compactionManager.compactor.expireBefore.Store(t.retentionCutoff(next))
+	return nil
+}
+
+// RetentionPolicy 返回表的保留策略，没有设置时返回 nil
+func (t *Table) RetentionPolicy() *RetentionPolicy {
+	policy := t.retention.Load()
+	if policy == nil {
+		return nil
+	}
+	return policy.clone()
+}
+
+// EnforceRetention 立即执行保留策略，返回时过期的 SST 与时间桶已被删除
+//
+// 后台每 DefaultRetentionInterval 自动执行一次，通常不需要调用；
+// 删除的行数见 GetCompactionManager().GetStats().ExpiredRows。
+func (t *Table) EnforceRetention() error {
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return err
+	}
+	defer done()
+	return t.enforceRetention()
+}
+
+// enforceRetention 执行保留策略（后台循环调用，不持有生命周期锁：Clean/Close 会先停止后台循环）
+func (t *Table) enforceRetention() error {
+	policy := t.retention.Load()
+	if policy == nil {
+		return nil
+	}
+
+	now := t.clock.Now()
+	if cutoff := t.retentionCutoff(policy); cutoff != 0 {
+		if err := t.compactionManager.expire(cutoff); err != nil {
+			return fmt.Errorf("expire rows: %w", err)
+		}
+	}
+	for _, rollup := range policy.Rollups {
+		if rollup.Retention <= 0 {
+			continue
+		}
+		if _, err := t.caggs.prune(rollup.Name, now.Add(-rollup.Retention).UnixNano()); err != nil && !IsError(err, ErrCodeNotFound) {
+			return fmt.Errorf("prune rollup %s: %w", rollup.Name, err)
+		}
+	}
+	return nil
+}
+
+// retentionCutoff 返回原始行过期的截止时间（UnixNano），不过期时返回 0
+func (t *Table) retentionCutoff(policy *RetentionPolicy) int64 {
+	if policy == nil || policy.Retention <= 0 {
+		return 0
+	}
+	return t.clock.Now().Add(-policy.Retention).UnixNano()
+}
+
+// attachRetention 将保留策略交给（新建的）Compaction Manager 执行，需在 Start 之前调用
+func (t *Table) attachRetention() {
+	t.compactionManager.SetRetentionEnforcer(func() {
+		if err := t.enforceRetention(); err != nil {
+			t.logs.get(LogCompaction).Warn("[Retention] Failed to enforce retention policy", "table", t.schema.Load().Name, "error", err)
+		}
+	})
+	t.compactionManager.compactor.expireBefore.Store(t.retentionCutoff(t.retention.Load()))
+}
+
+// loadRetentionPolicy 加载持久化的保留策略，文件不存在、无法读取或策略无效时不设置策略
+func (t *Table) loadRetentionPolicy() {
+	data, err := os.ReadFile(filepath.Join(t.dir, retentionPolicyFile))
+	if err != nil {
+		return
+	}
+	var policy RetentionPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		t.logs.get(LogCompaction).Warn("[Retention] Ignoring unreadable retention policy", "table", t.schema.Load().Name, "error", err)
+		return
+	}
+	if err := policy.validate(t.caggs); err != nil {
+		t.logs.get(LogCompaction).Warn("[Retention] Ignoring invalid retention policy", "table", t.schema.Load().Name, "error", err)
+		return
+	}
+	if policy.empty() {
+		return
+	}
+	t.retention.Store(&policy)
+	t.compactionManager.compactor.expireBefore.Store(t.retentionCutoff(&policy))
+
+	// 上次运行删除的行可能仍残留在索引中：小于最小 seq 的行都已不存在
+	if policy.Retention > 0 {
+		if minSeq := t.MinSeq(); minSeq > 1 {
+			t.compactionManager.compactor.noteExpiredSeq(minSeq - 1)
+		}
+	}
+}
+
+// saveRetentionPolicy 持久化保留策略（临时文件 + 重命名），空策略删除文件
+func (t *Table) saveRetentionPolicy(policy *RetentionPolicy) error {
+	path := filepath.Join(t.dir, retentionPolicyFile)
+	if policy.empty() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return NewErrorf(ErrCodeEncodeFailed, "failed to encode retention policy: %v", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// expiredSeq 返回被保留策略删除的行中最大的 seq，不大于它的 seq 都已过期（索引中可能残留）
+func (t *Table) expiredSeq() int64 {
+	return t.compactionManager.compactor.expiredSeq.Load()
+}
+
+// noteExpiredSeq 记录被删除的过期行的 seq
+func (c *Compactor) noteExpiredSeq(seq int64) {
+	for {
+		cur := c.expiredSeq.Load()
+		if seq <= cur || c.expiredSeq.CompareAndSwap(cur, seq) {
+			return
+		}
+	}
+}
+
+// SetRetentionEnforcer 设置后台循环执行保留策略的函数（由 Table 设置，需在 Start 之前调用）
+func (m *CompactionManager) SetRetentionEnforcer(fn func()) {
+	m.retentionEnforcer = fn
+}
+
+// backgroundRetention 后台保留策略循环
+func (m *CompactionManager) backgroundRetention() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(DefaultRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			if m.retentionEnforcer != nil {
+				m.retentionEnforcer()
+			}
+		}
+	}
+}
+
+// expire 删除 _time 早于 cutoff（UnixNano）的行
+//
+// 所有行都已过期的 SST 直接从 MANIFEST 删除，部分过期的 SST 单独重写（过期的行不写入新文件）。
+// cutoff 同时用于之后的 Compaction。
+func (m *CompactionManager) expire(cutoff int64) error {
+	m.compactor.expireBefore.Store(cutoff)
+
+	m.compactionMu.Lock()
+	defer m.compactionMu.Unlock()
+
+	version := m.versionSet.GetCurrent()
+	if version == nil {
+		return nil
+	}
+
+	headers := make(map[int64]*SSTableHeader)
+	for _, reader := range m.sstManager.GetReaders() {
+		var fileNumber int64
+		if _, err := fmt.Sscanf(filepath.Base(reader.GetPath()), "%d.sst", &fileNumber); err == nil {
+			headers[fileNumber] = reader.GetHeader()
+		}
+	}
+
+	edit := NewVersionEdit()
+	edit.SetReason(EditReasonRetention)
+	var partial []*FileMetadata
+	var expiredRows, expiredSeq int64
+	for _, file := range version.GetSSTFiles() {
+		header, ok := headers[file.FileNumber]
+		if !ok || header.RowCount == 0 || header.MinTime >= cutoff {
+			continue
+		}
+		if header.MaxTime < cutoff {
+			edit.DeleteFile(file.FileNumber)
+			expiredRows += header.RowCount
+			expiredSeq = max(expiredSeq, header.MaxKey)
+			continue
+		}
+		partial = append(partial, file)
+	}
+
+	if len(edit.DeletedFiles) > 0 {
+		edit.SetNextFileNumber(m.versionSet.GetNextFileNumber())
+		if err := m.versionSet.LogAndApply(edit); err != nil {
+			return fmt.Errorf("apply version edit: %w", err)
+		}
+		m.compactor.expiredRows.Add(expiredRows)
+		m.compactor.noteExpiredSeq(expiredSeq)
+		m.deleteObsoleteFiles(edit, version, FileReasonRetention)
+		m.logger.Info("[Retention] Dropped expired files",
+			"file_count", len(edit.DeletedFiles),
+			"rows", expiredRows)
+	}
+
+	for _, file := range partial {
+		task := &CompactionTask{Level: file.Level, InputFiles: []*FileMetadata{file}, OutputLevel: file.Level}
+		if err := m.DoCompactionWithVersion(task, m.versionSet.GetCurrent()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
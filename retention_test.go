@@ -0,0 +1,163 @@
+package srdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:  dir,
+		Name: "events",
+		Fields: []Field{
+			{Name: "host", Type: String, Indexed: true},
+			{Name: "latency", Type: Int64},
+		},
+		Clock: clock,
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { table.Close() }()
+
+	insert := func(n int) {
+		t.Helper()
+		for i := range n {
+			if err := table.Insert(map[string]any{"host": "a", "latency": int64(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	flush := func() {
+		t.Helper()
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		for table.memtableManager.GetImmutableCount() > 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	count := func() int {
+		t.Helper()
+		rows, err := table.Query().Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		return rows.Len()
+	}
+
+	// 一个文件全部过期，另一个文件部分过期
+	insert(5)
+	flush()
+	insert(5)
+	clock.Advance(2 * time.Hour)
+	insert(5)
+	flush()
+
+	policy := RetentionPolicy{
+		Retention: time.Hour,
+		Rollups: []Rollup{{
+			ContinuousAggregate: ContinuousAggregate{
+				Name:       "latency_1h",
+				Bucket:     time.Hour,
+				Aggregates: []Aggregate{Count(), Sum("latency")},
+			},
+			Retention: 3 * time.Hour,
+		}},
+	}
+	if err := table.SetRetentionPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	if desc := table.Describe(); desc.RetentionPolicy == nil || desc.RetentionPolicy.Retention != time.Hour ||
+		len(desc.ContinuousAggregates) != 1 {
+		t.Fatalf("unexpected description %+v", desc)
+	}
+
+	if err := table.EnforceRetention(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 5 {
+		t.Errorf("expected 5 rows after retention, got %d", n)
+	}
+	if got := table.GetCompactionManager().GetStats().ExpiredRows; got != 10 {
+		t.Errorf("expected 10 expired rows, got %d", got)
+	}
+	// 索引中残留的 seq 被跳过
+	seqs, err := table.Query().Eq("host", "a").Seqs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 5 || seqs[0] != 11 {
+		t.Errorf("expected seqs 11..15 from index, got %v", seqs)
+	}
+
+	// 降采样的结果在原始行过期后保留
+	buckets, err := table.QueryContinuousAggregate("latency_1h", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 2 || buckets[0].Values[0] != int64(10) || buckets[1].Values[0] != int64(5) {
+		t.Fatalf("unexpected buckets %+v", buckets)
+	}
+
+	// 重新打开后策略仍然生效
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	table, err = OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := table.RetentionPolicy(); p == nil || p.Retention != time.Hour || len(p.Rollups) != 1 {
+		t.Fatalf("unexpected policy after reopen: %+v", p)
+	}
+
+	clock.Advance(2 * time.Hour)
+	if err := table.EnforceRetention(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("expected all rows expired, got %d", n)
+	}
+	buckets, err = table.QueryContinuousAggregate("latency_1h", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 1 || !buckets[0].Start.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("expected only the recent bucket, got %+v", buckets)
+	}
+
+	// 策略之外的同名连续聚合
+	manual := ContinuousAggregate{Name: "manual", Bucket: time.Minute, Aggregates: []Aggregate{Count()}}
+	if err := table.CreateContinuousAggregate(manual); err != nil {
+		t.Fatal(err)
+	}
+	conflict := RetentionPolicy{Rollups: []Rollup{{ContinuousAggregate: manual}}}
+	if err := table.SetRetentionPolicy(conflict); !IsError(err, ErrCodeExists) {
+		t.Errorf("expected ErrCodeExists, got %v", err)
+	}
+	if err := table.SetRetentionPolicy(RetentionPolicy{Retention: -time.Hour}); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+
+	// 删除策略同时删除它管理的连续聚合
+	if err := table.SetRetentionPolicy(RetentionPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	if table.RetentionPolicy() != nil {
+		t.Error("expected no policy")
+	}
+	if defs := table.ContinuousAggregates(); len(defs) != 1 || defs[0].Name != "manual" {
+		t.Errorf("expected only the manual aggregate, got %+v", defs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, retentionPolicyFile)); !os.IsNotExist(err) {
+		t.Errorf("expected policy file to be removed, got %v", err)
+	}
+}
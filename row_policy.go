@@ -0,0 +1,46 @@
+package srdb
+
+import "context"
+
+// RowPolicy 行级可见性策略，返回 false 的行对查询不可见
+//
+// row 为行数据（不包括 _seq 与 _time），只能读取，不能修改；策略可能被并发调用。
+type RowPolicy func(ctx context.Context, row map[string]any) bool
+
+// SetRowPolicy 设置行级可见性策略，nil 表示取消
+//
+// 策略只对通过 WithContext 提供了 context 的查询（以及 Tail）生效，由所有查询路径统一检查：
+// Rows、First、Scan、Seqs、Page、Paginate、Distinct、Aggregate、BatchRows、Into、SharedScan 与 Tail。
+// 策略作为额外的过滤条件，按 context 中的用户信息决定每一行是否可见，
+// 多用户应用不需要在每个查询中重复添加过滤条件。未提供 context 的查询（包括 Table.Get）不受影响，
+// 连续聚合（QueryContinuousAggregate）是预先计算的汇总，也不受策略限制。
+func (t *Table) SetRowPolicy(policy RowPolicy) {
+	if policy == nil {
+		t.rowPolicy.Store(nil)
+		return
+	}
+	t.rowPolicy.Store(&policy)
+}
+
+// WithContext 为查询提供 context，表设置了行级可见性策略（SetRowPolicy）时用于判断行是否可见；
+// ctx 取消后扫描随之停止并返回 ctx.Err()（见 RowsContext）
+func (qb *QueryBuilder) WithContext(ctx context.Context) *QueryBuilder {
+	qb.ctx = ctx
+	return qb
+}
+
+// policy 返回查询需要检查的行级策略：没有 context 或表没有设置策略时返回 nil
+func (qb *QueryBuilder) policy() RowPolicy {
+	if qb.ctx == nil || qb.table == nil {
+		return nil
+	}
+	if p := qb.table.rowPolicy.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// filtered 查询是否需要逐行检查（有过滤条件、时间范围或行级策略），为 false 时才能使用不检查行数据的快速路径
+func (qb *QueryBuilder) filtered() bool {
+	return len(qb.conds) > 0 || qb.window.set || qb.policy() != nil
+}
@@ -0,0 +1,132 @@
+package srdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type policyUserKey struct{}
+
+func TestRowPolicy(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "notes",
+		Fields: []Field{
+			{Name: "owner", Type: String, Indexed: true},
+			{Name: "size", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 30 {
+		owner := []string{"alice", "bob", "carol"}[i%3]
+		if err := table.Insert(map[string]any{"owner": owner, "size": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 14 {
+			table.Flush()
+		}
+	}
+
+	if err := table.indexManager.BuildAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	table.SetRowPolicy(func(ctx context.Context, row map[string]any) bool {
+		user, _ := ctx.Value(policyUserKey{}).(string)
+		return user == "admin" || row["owner"] == user
+	})
+	alice := context.WithValue(context.Background(), policyUserKey{}, "alice")
+	admin := context.WithValue(context.Background(), policyUserKey{}, "admin")
+
+	count := func(name string, qb *QueryBuilder, want int) {
+		t.Helper()
+		rows, err := qb.Rows()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		defer rows.Close()
+		if n := rows.Count(); n != want {
+			t.Errorf("%s: expected %d rows, got %d", name, want, n)
+		}
+	}
+
+	// 没有 context 时不检查策略
+	count("no context", table.Query(), 30)
+	count("full scan", table.Query().WithContext(alice), 10)
+	count("admin", table.Query().WithContext(admin), 30)
+	count("condition", table.Query().WithContext(alice).Lt("size", 15), 5)
+	count("index", table.Query().WithContext(alice).Eq("owner", "bob"), 0)
+	count("ordered", table.Query().WithContext(alice).OrderBy("owner"), 10)
+	count("distinct", table.Query().WithContext(alice).Select("owner").Distinct(), 1)
+
+	// 覆盖索引与索引 Seqs 不读取行数据，有策略时不能使用
+	count("covering index", table.Query().WithContext(alice).Eq("owner", "bob").Select("owner"), 0)
+	seqs, err := table.Query().WithContext(alice).Eq("owner", "bob").Seqs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 0 {
+		t.Errorf("expected no seqs of bob, got %v", seqs)
+	}
+	seqs, err = table.Query().WithContext(alice).Seqs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 10 || !slices.IsSorted(seqs) {
+		t.Errorf("expected 10 sorted seqs, got %v", seqs)
+	}
+
+	page, err := table.Query().WithContext(alice).Page(1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 10 {
+		t.Errorf("page: expected total 10, got %d", page.Total)
+	}
+	_, total, err := table.Query().WithContext(alice).Paginate(1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 10 {
+		t.Errorf("paginate: expected total 10, got %d", total)
+	}
+
+	// 聚合（列式扫描）
+	res, err := table.Query().WithContext(alice).Aggregate(Count(), Sum("size"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// alice 的 size 为 0, 3, ..., 27
+	if fmt.Sprint(res) != "[10 135]" {
+		t.Errorf("aggregate: expected [10 135], got %v", res)
+	}
+
+	// Tail 使用自己的 ctx
+	ctx, cancel := context.WithCancel(alice)
+	defer cancel()
+	tail, err := table.Query().Tail(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tail.Close()
+	seen := 0
+	for seen < 10 && tail.Next() {
+		if owner := tail.Row().Data()["owner"]; owner != "alice" {
+			t.Errorf("tail returned row of %v", owner)
+		}
+		seen++
+	}
+	if seen != 10 {
+		t.Errorf("tail: expected 10 rows, got %d (%v)", seen, tail.Err())
+	}
+
+	// 取消策略
+	table.SetRowPolicy(nil)
+	count("policy removed", table.Query().WithContext(alice), 30)
+}
@@ -0,0 +1,150 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// 写入行大小的直方图
+//
+// 按 2 的幂分桶：第 i 个桶统计编码后大小在 [2^(i-1), 2^i) 字节内的样本（第 0 个桶为 0 字节），
+// 内存占用固定，记录只需几次原子操作。行大小为写入 WAL 的编码大小（含系统字段与校验和），
+// 值大小为每个非 NULL 字段的编码大小（打包为位的 Bool 字段不计入）。
+// 统计从打开表开始累计，不持久化。
+
+// sizeHistogramBuckets 桶数量（bits.Len64 的取值范围 0..64）
+const sizeHistogramBuckets = 65
+
+// sizeHistogram 并发安全的大小直方图
+type sizeHistogram struct {
+	counts [sizeHistogramBuckets]atomic.Int64
+	count  atomic.Int64
+	sum    atomic.Int64
+	max    atomic.Int64
+}
+
+// record 记录一个样本
+func (h *sizeHistogram) record(size int) {
+	v := int64(max(size, 0))
+	h.counts[bits.Len64(uint64(v))].Add(1)
+	h.count.Add(1)
+	h.sum.Add(v)
+	for {
+		old := h.max.Load()
+		if v <= old || h.max.CompareAndSwap(old, v) {
+			break
+		}
+	}
+}
+
+// snapshot 返回当前统计（各计数分别读取，并发写入时可能有微小偏差）
+func (h *sizeHistogram) snapshot() SizeHistogram {
+	s := SizeHistogram{
+		Count: h.count.Load(),
+		Sum:   h.sum.Load(),
+		Max:   h.max.Load(),
+	}
+	last := -1
+	var counts [sizeHistogramBuckets]int64
+	for i := range counts {
+		counts[i] = h.counts[i].Load()
+		if counts[i] > 0 {
+			last = i
+		}
+	}
+	for i := 0; i <= last; i++ {
+		s.Buckets = append(s.Buckets, SizeBucket{Upper: bucketUpper(i), Count: counts[i]})
+	}
+	return s
+}
+
+// bucketUpper 返回第 i 个桶的最大字节数（含）
+func bucketUpper(i int) int64 {
+	if i >= 63 {
+		return math.MaxInt64
+	}
+	return 1<<i - 1
+}
+
+// SizeBucket 直方图的一个桶
+type SizeBucket struct {
+	Upper int64 // 桶内样本的最大字节数（含），下限为上一个桶的 Upper + 1
+	Count int64 // 样本数量
+}
+
+// SizeHistogram 大小直方图的快照（单位：字节）
+type SizeHistogram struct {
+	Count   int64        // 样本数量
+	Sum     int64        // 样本总字节数
+	Max     int64        // 最大样本
+	Buckets []SizeBucket // 按 Upper 升序，截止到最后一个非空桶
+}
+
+// Mean 返回平均大小，没有样本时为 0
+func (h SizeHistogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return float64(h.Sum) / float64(h.Count)
+}
+
+// Percentile 返回分位数 p（0 < p <= 1）所在桶的上界（不超过 Max），没有样本时为 0
+//
+// 结果最多比实际值大一倍，用于发现数量级的变化（例如 2KB 的行突然变为 5MB）。
+func (h SizeHistogram) Percentile(p float64) int64 {
+	if h.Count == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(p * float64(h.Count)))
+	var seen int64
+	for _, b := range h.Buckets {
+		seen += b.Count
+		if seen >= target {
+			return min(b.Upper, h.Max)
+		}
+	}
+	return h.Max
+}
+
+// RowSizeStats 表写入的行大小与值大小分布
+type RowSizeStats struct {
+	Rows   SizeHistogram // 每行编码后的大小
+	Values SizeHistogram // 每个非 NULL 字段值编码后的大小
+}
+
+// rowSizeStats 表的行大小统计
+type rowSizeStats struct {
+	rows   sizeHistogram
+	values sizeHistogram
+}
+
+// record 记录一行编码后的数据（writeRow 写入 WAL 成功后调用）
+func (s *rowSizeStats) record(rowData []byte) {
+	s.rows.record(len(rowData))
+
+	// 字段表中每项为 offset(4) | size(4)，size 为 0 表示 NULL
+	layout, err := parseRowLayout(rowData)
+	if err != nil {
+		return
+	}
+	for i := range layout.slotCount() {
+		if size := binary.LittleEndian.Uint32(rowData[layout.table+i*8+4:]); size > 0 {
+			s.values.record(int(size))
+		}
+	}
+}
+
+// RowSizes 返回打开表以来写入的行大小与值大小分布
+//
+//	sizes := table.RowSizes()
+//	if sizes.Rows.Percentile(0.99) > 64<<10 {
+//		log.Printf("p99 row size %d bytes, max %d", sizes.Rows.Percentile(0.99), sizes.Rows.Max)
+//	}
+func (t *Table) RowSizes() RowSizeStats {
+	return RowSizeStats{
+		Rows:   t.rowSizes.rows.snapshot(),
+		Values: t.rowSizes.values.snapshot(),
+	}
+}
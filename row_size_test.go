@@ -0,0 +1,88 @@
+package srdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSizeHistogram(t *testing.T) {
+	var h sizeHistogram
+	if s := h.snapshot(); s.Count != 0 || s.Percentile(0.5) != 0 || s.Mean() != 0 || len(s.Buckets) != 0 {
+		t.Errorf("expected empty histogram, got %+v", s)
+	}
+
+	for range 98 {
+		h.record(100)
+	}
+	h.record(0)
+	h.record(5 << 20)
+
+	s := h.snapshot()
+	if s.Count != 100 || s.Max != 5<<20 || s.Sum != 98*100+5<<20 {
+		t.Errorf("unexpected totals %+v", s)
+	}
+	// 100 位于 [64, 127]
+	if p := s.Percentile(0.5); p != 127 {
+		t.Errorf("expected p50 127, got %d", p)
+	}
+	if p := s.Percentile(1); p != 5<<20 {
+		t.Errorf("expected p100 to be max, got %d", p)
+	}
+	if s.Buckets[0].Upper != 0 || s.Buckets[0].Count != 1 {
+		t.Errorf("expected one zero sized sample, got %+v", s.Buckets[0])
+	}
+	last := s.Buckets[len(s.Buckets)-1]
+	if last.Count != 1 || last.Upper < 5<<20 || last.Upper/2 >= 5<<20 {
+		t.Errorf("unexpected last bucket %+v", last)
+	}
+	var total int64
+	for _, b := range s.Buckets {
+		total += b.Count
+	}
+	if total != s.Count {
+		t.Errorf("bucket counts %d != count %d", total, s.Count)
+	}
+}
+
+func TestTableRowSizes(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "payloads",
+		Fields: []Field{
+			{Name: "body", Type: String},
+			{Name: "note", Type: String, Nullable: true},
+			{Name: "ok", Type: Bool},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for range 10 {
+		if err := table.Insert(map[string]any{"body": strings.Repeat("x", 2000), "ok": true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Insert(map[string]any{"body": strings.Repeat("y", 1<<20), "note": "big", "ok": false}); err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := table.RowSizes()
+	if sizes.Rows.Count != 11 {
+		t.Fatalf("expected 11 rows, got %d", sizes.Rows.Count)
+	}
+	if p := sizes.Rows.Percentile(0.5); p < 2000 || p >= 4096 {
+		t.Errorf("expected p50 row size in [2000, 4096), got %d", p)
+	}
+	if sizes.Rows.Max <= 1<<20 {
+		t.Errorf("expected max row size over 1MB, got %d", sizes.Rows.Max)
+	}
+	// body 每行一个，note 只有最后一行非 NULL，Bool 打包为位不计入
+	if sizes.Values.Count != 12 {
+		t.Errorf("expected 12 values, got %d", sizes.Values.Count)
+	}
+	if sizes.Values.Max < 1<<20 {
+		t.Errorf("expected max value size at least 1MB, got %d", sizes.Values.Max)
+	}
+}
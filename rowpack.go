@@ -0,0 +1,188 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+/*
+布尔字段打包的行编码 (ROW2)
+
+ROW1 中每个字段在偏移表中占 8 字节（offset + size），布尔值本身只有 1 字节，
+30 个布尔字段的行仅字段表就需要 270 字节。Schema 含有 Bool 字段时改用 ROW2：
+布尔字段不进入偏移表，按 Schema 顺序每个字段占 2 位打包在字段表之后。
+
+	[Magic: 4 "ROW2"][Seq: 8][Time: 8][FieldCount: 2][PackedCount: 2]
+	[FieldTable: (FieldCount-PackedCount) × {offset uint32, size uint32}]
+	[Bits: ceil(PackedCount×2/8) bytes]
+	[FieldData][可选的行校验和尾部]
+
+第 k 个布尔字段（按 Schema 顺序）占 Bits 的第 2k、2k+1 位：低位为 1 表示非 NULL，高位为值。
+其余字段与 ROW1 相同，按 Schema 顺序依次占用字段表项。哪些字段被打包由 Schema 决定
+（Schema 只能在末尾追加字段，已有字段的类型不变），PackedCount 用于校验。
+
+解码时两种格式都支持，没有 Bool 字段的 Schema 仍写入 ROW1。
+*/
+
+const (
+	// SSTableRowPackedMagic 布尔字段打包的行编码
+	SSTableRowPackedMagic = 0x524F5732 // "ROW2"
+
+	// rowPackedHeaderSize ROW2 行头大小：Magic(4) + Seq(8) + Time(8) + FieldCount(2) + PackedCount(2)
+	rowPackedHeaderSize = 24
+)
+
+// isRowMagic 判断是否为行编码的 Magic Number（ROW1、ROW2 或删除标记）
+func isRowMagic(magic uint32) bool {
+	return magic == SSTableRowMagic || magic == SSTableRowPackedMagic || magic == SSTableRowTombstoneMagic
+}
+
+// isPackedField 字段在 ROW2 中是否打包为位
+func isPackedField(field Field) bool {
+	return field.Type == Bool
+}
+
+// schemaPacksFields Schema 是否含有需要打包的字段（决定写入 ROW2）
+func schemaPacksFields(schema *Schema) bool {
+	for _, field := range schema.Fields {
+		if isPackedField(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// rowLayout 行编码中字段表与数据区的位置（ROW1 与 ROW2 通用）
+type rowLayout struct {
+	packed      bool   // 是否为 ROW2
+	deleted     bool   // 是否为删除标记（没有字段，见 Table.Delete）
+	fieldCount  int    // 行中的字段数（旧数据可能少于 Schema）
+	packedCount int    // 打包的字段数
+	table       int    // 字段表起始位置
+	bits        []byte // 打包的字段位
+	dataStart   int    // 数据区起始位置
+}
+
+// parseRowLayout 解析行头（不需要 Schema）
+func parseRowLayout(data []byte) (rowLayout, error) {
+	if len(data) < 4 {
+		return rowLayout{}, io.ErrUnexpectedEOF
+	}
+	magic := binary.LittleEndian.Uint32(data[0:4])
+	if !isRowMagic(magic) {
+		return rowLayout{}, fmt.Errorf("invalid row magic: %x", magic)
+	}
+	if len(data) < rowDecodeHeaderSize {
+		return rowLayout{}, io.ErrUnexpectedEOF
+	}
+
+	l := rowLayout{fieldCount: int(binary.LittleEndian.Uint16(data[20:22])), table: rowDecodeHeaderSize}
+	if magic == SSTableRowTombstoneMagic {
+		if l.fieldCount != 0 {
+			return rowLayout{}, fmt.Errorf("tombstone has %d fields", l.fieldCount)
+		}
+		l.deleted = true
+		l.dataStart = l.table
+		return l, nil
+	}
+	if magic == SSTableRowMagic {
+		l.dataStart = l.table + l.fieldCount*8
+		if len(data) < l.dataStart {
+			return rowLayout{}, io.ErrUnexpectedEOF
+		}
+		return l, nil
+	}
+
+	if len(data) < rowPackedHeaderSize {
+		return rowLayout{}, io.ErrUnexpectedEOF
+	}
+	l.packed = true
+	l.packedCount = int(binary.LittleEndian.Uint16(data[22:24]))
+	if l.packedCount > l.fieldCount {
+		return rowLayout{}, fmt.Errorf("invalid packed field count %d of %d", l.packedCount, l.fieldCount)
+	}
+	l.table = rowPackedHeaderSize
+	bitsStart := l.table + l.slotCount()*8
+	l.dataStart = bitsStart + (l.packedCount*2+7)/8
+	if len(data) < l.dataStart {
+		return rowLayout{}, io.ErrUnexpectedEOF
+	}
+	l.bits = data[bitsStart:l.dataStart]
+	return l, nil
+}
+
+// slotCount 字段表项数
+func (l *rowLayout) slotCount() int {
+	return l.fieldCount - l.packedCount
+}
+
+// dataEnd 返回字段数据区的结束位置（即校验和尾部的起始位置）
+func (l *rowLayout) dataEnd(data []byte) int {
+	end := l.dataStart
+	for i := range l.slotCount() {
+		entry := data[l.table+i*8:]
+		end = max(end, l.dataStart+int(binary.LittleEndian.Uint32(entry[0:4]))+int(binary.LittleEndian.Uint32(entry[4:8])))
+	}
+	return end
+}
+
+// slot 返回第 i 个字段表项对应的数据，size 为 0 表示 NULL
+func (l *rowLayout) slot(data []byte, i int) ([]byte, error) {
+	entry := data[l.table+i*8:]
+	offset := int(binary.LittleEndian.Uint32(entry[0:4]))
+	size := int(binary.LittleEndian.Uint32(entry[4:8]))
+	pos := l.dataStart + offset
+	if pos < l.dataStart || pos+size > len(data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data[pos : pos+size], nil
+}
+
+// packedBool 返回第 k 个打包字段的值，NULL 时 ok 为 false
+func (l *rowLayout) packedBool(k int) (value, ok bool) {
+	b := l.bits[k/4] >> (k % 4 * 2)
+	return b&2 != 0, b&1 != 0
+}
+
+// checkSchema 校验打包的字段数与 Schema 一致
+func (l *rowLayout) checkSchema(schema *Schema) error {
+	if !l.packed {
+		return nil
+	}
+	count := 0
+	for _, field := range schema.Fields[:min(l.fieldCount, len(schema.Fields))] {
+		if isPackedField(field) {
+			count++
+		}
+	}
+	if count != l.packedCount || l.fieldCount > len(schema.Fields) {
+		return fmt.Errorf("row packs %d of %d fields, schema expects %d", l.packedCount, l.fieldCount, count)
+	}
+	return nil
+}
+
+// fieldPositions 返回 Schema 中每个字段在 ROW2 中的位置：
+// 非负数为字段表项序号，负数 -(k+1) 为第 k 个打包字段
+func fieldPositions(schema *Schema) []int {
+	positions := make([]int, len(schema.Fields))
+	slot, packed := 0, 0
+	for i, field := range schema.Fields {
+		if isPackedField(field) {
+			positions[i] = -(packed + 1)
+			packed++
+		} else {
+			positions[i] = slot
+			slot++
+		}
+	}
+	return positions
+}
+
+// boolEncoding 布尔值在字段数据中的编码（打包的字段按字段读取原始编码时使用）
+func boolEncoding(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
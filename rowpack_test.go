@@ -0,0 +1,214 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRowPackedBools(t *testing.T) {
+	var fields []Field
+	data := map[string]any{"name": "device-1"}
+	fields = append(fields, Field{Name: "name", Type: String})
+	for i := range 30 {
+		name := fmt.Sprintf("flag_%02d", i)
+		fields = append(fields, Field{Name: name, Type: Bool})
+		data[name] = i%3 == 0
+	}
+	fields = append(fields, Field{Name: "maybe", Type: Bool, Nullable: true})
+	schema, err := NewSchema("flags", fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := encodeSSTableRowBinary(&SSTableRow{Seq: 7, Time: 100, Data: data}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if magic := binary.LittleEndian.Uint32(encoded[0:4]); magic != SSTableRowPackedMagic {
+		t.Fatalf("expected ROW2 encoding, got magic %x", magic)
+	}
+	// 头部 24 + 1 个字段表项 8 + 31 个字段 × 2 位 = 8 字节 + "device-1"（4 + 8）
+	if want := 24 + 8 + 8 + 12; len(encoded) != want {
+		t.Errorf("expected %d bytes, got %d", want, len(encoded))
+	}
+
+	row, err := decodeSSTableRowBinary(encoded, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Seq != 7 || row.Time != 100 || row.Data["name"] != "device-1" {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	for i := range 30 {
+		name := fmt.Sprintf("flag_%02d", i)
+		if row.Data[name] != (i%3 == 0) {
+			t.Errorf("%s: expected %v, got %v", name, i%3 == 0, row.Data[name])
+		}
+	}
+	if _, ok := row.Data["maybe"]; ok {
+		t.Error("expected NULL for missing nullable bool")
+	}
+
+	// 只解码部分字段
+	partial, err := decodeSSTableRowBinaryPartial(encoded, schema, []string{"flag_03", "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partial.Data) != 2 || partial.Data["flag_03"] != true || partial.Data["name"] != "device-1" {
+		t.Errorf("unexpected partial row: %v", partial.Data)
+	}
+
+	// 行校验和覆盖打包的位
+	withSum := appendRowChecksum(slices.Clone(encoded))
+	if err := verifyRowChecksum(withSum); err != nil {
+		t.Fatal(err)
+	}
+	withSum[24+8] ^= 1
+	if err := verifyRowChecksum(withSum); !IsError(err, ErrCodeChecksumMismatch) {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+
+	// 类型错误
+	data["flag_00"] = "yes"
+	if _, err := encodeSSTableRowBinary(&SSTableRow{Data: data}, schema); err == nil {
+		t.Error("expected error for non-bool value")
+	}
+}
+
+func TestRowPackedCompatibility(t *testing.T) {
+	schema, err := NewSchema("t", []Field{
+		{Name: "ok", Type: Bool},
+		{Name: "n", Type: Int64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ROW1 格式的旧数据：ok 占用字段表项
+	row1 := binary.LittleEndian.AppendUint32(nil, SSTableRowMagic)
+	row1 = binary.LittleEndian.AppendUint64(row1, 1)
+	row1 = binary.LittleEndian.AppendUint64(row1, 2)
+	row1 = binary.LittleEndian.AppendUint16(row1, 2)
+	row1 = binary.LittleEndian.AppendUint32(row1, 0)
+	row1 = binary.LittleEndian.AppendUint32(row1, 1)
+	row1 = binary.LittleEndian.AppendUint32(row1, 1)
+	row1 = binary.LittleEndian.AppendUint32(row1, 8)
+	row1 = append(row1, 1)
+	row1 = binary.LittleEndian.AppendUint64(row1, 42)
+	row, err := decodeSSTableRowBinary(row1, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Data["ok"] != true || row.Data["n"] != int64(42) {
+		t.Errorf("unexpected ROW1 decode: %v", row.Data)
+	}
+
+	// 带校验和的 ROW1 数据在 flush、Compaction 重新编码时保持原格式，校验和仍然有效
+	row, err = decodeSSTableRowBinary(appendRowChecksum(row1), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reencoded, err := encodeSSTableRowBinary(row, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyRowChecksum(reencoded); err != nil {
+		t.Errorf("expected checksum of re-encoded ROW1 row to verify: %v", err)
+	}
+
+	// 追加字段后读取之前写入的 ROW2 数据
+	encoded, err := encodeSSTableRowBinary(&SSTableRow{Data: map[string]any{"ok": true, "n": int64(5)}}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evolved, err := NewSchema("t", append(slices.Clone(schema.Fields),
+		Field{Name: "extra", Type: Bool, Nullable: true},
+		Field{Name: "label", Type: String, Nullable: true},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err = decodeSSTableRowBinary(encoded, evolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(row.Data) != 2 || row.Data["ok"] != true || row.Data["n"] != int64(5) {
+		t.Errorf("unexpected decode with evolved schema: %v", row.Data)
+	}
+
+	// 打包的字段与 Schema 不一致
+	mismatched, err := NewSchema("t", []Field{{Name: "ok", Type: Int8}, {Name: "n", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeSSTableRowBinary(encoded, mismatched); err == nil {
+		t.Error("expected error decoding ROW2 with a schema that packs different fields")
+	}
+}
+
+func TestRowPackedTable(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "flags",
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "enabled", Type: Bool},
+			{Name: "beta", Type: Bool, Nullable: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	rows := []map[string]any{
+		{"name": "a", "enabled": true, "beta": false},
+		{"name": "b", "enabled": false},
+		{"name": "c", "enabled": true, "beta": true},
+	}
+	for _, row := range rows {
+		if err := table.Insert(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func() {
+		t.Helper()
+		var got []map[string]any
+		if err := table.Query().Eq("enabled", true).Select("name", "beta").Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 || got[0]["name"] != "a" || got[0]["beta"] != false || got[1]["beta"] != true {
+			t.Errorf("unexpected query result: %v", got)
+		}
+
+		br, err := table.Query().BatchRows([]string{"enabled", "beta"}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer br.Close()
+		if !br.Next() {
+			t.Fatalf("expected a batch: %v", br.Err())
+		}
+		enabled, beta := br.Batch().Columns[0], br.Batch().Columns[1]
+		if !slices.Equal(enabled.Bools, []bool{true, false, true}) {
+			t.Errorf("unexpected enabled column: %v", enabled.Bools)
+		}
+		if beta.IsNull(0) || !beta.IsNull(1) || beta.IsNull(2) || beta.Bools[0] || !beta.Bools[2] {
+			t.Errorf("unexpected beta column: %v (nulls %v)", beta.Bools, beta.Nulls)
+		}
+	}
+
+	// MemTable 与 SST 中的行
+	check()
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	check()
+}
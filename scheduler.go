@@ -0,0 +1,116 @@
+package srdb
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// QueryPriority 查询优先级
+type QueryPriority int
+
+const (
+	PriorityNormal QueryPriority = iota // 默认优先级，不参与调度
+	PriorityHigh                        // 前台交互查询，低优先级扫描会为其让路
+	PriorityLow                         // 后台大扫描，存在高优先级请求时按令牌桶限速
+)
+
+// String 返回优先级名称
+func (p QueryPriority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+const (
+	// DefaultLowPriorityReadRate 存在高优先级请求时，低优先级查询每秒允许的 SST 块读取次数
+	DefaultLowPriorityReadRate = 2000
+
+	// highPriorityWindow 最近一次高优先级请求结束后，仍视为存在竞争的时间窗口
+	highPriorityWindow = 50 * time.Millisecond
+)
+
+// readScheduler 读调度器
+//
+// 高优先级请求（Table.Get、PriorityHigh 查询）只做登记；
+// 低优先级查询每次读取 SST 前获取一个令牌，
+// 仅在高优先级请求活跃时限速，空闲时不受影响。
+type readScheduler struct {
+	rate  float64 // 令牌生成速率（个/秒）
+	burst float64 // 桶容量
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	highActive atomic.Int64 // 进行中的高优先级请求数
+	lastHigh   atomic.Int64 // 最近一次高优先级请求结束时间（UnixNano）
+	throttled  atomic.Int64 // 被限速等待的低优先级读取次数
+}
+
+// newReadScheduler 创建读调度器，rate <= 0 时使用默认速率
+func newReadScheduler(rate int) *readScheduler {
+	if rate <= 0 {
+		rate = DefaultLowPriorityReadRate
+	}
+	burst := float64(rate) / 10
+	if burst < 1 {
+		burst = 1
+	}
+	return &readScheduler{
+		rate:   float64(rate),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// beginHigh 登记一个高优先级请求，返回结束函数
+func (s *readScheduler) beginHigh() func() {
+	s.highActive.Add(1)
+	return func() {
+		s.lastHigh.Store(time.Now().UnixNano())
+		s.highActive.Add(-1)
+	}
+}
+
+// contended 是否存在活跃的高优先级请求
+func (s *readScheduler) contended() bool {
+	if s.highActive.Load() > 0 {
+		return true
+	}
+	return time.Now().UnixNano()-s.lastHigh.Load() < int64(highPriorityWindow)
+}
+
+// acquire 低优先级读取前获取令牌，必要时等待
+func (s *readScheduler) acquire() {
+	if !s.contended() {
+		return
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	s.tokens += now.Sub(s.last).Seconds() * s.rate
+	if s.tokens > s.burst {
+		s.tokens = s.burst
+	}
+	s.last = now
+
+	// 预留一个令牌，不足部分按速率换算为等待时间
+	s.tokens--
+	var wait time.Duration
+	if s.tokens < 0 {
+		wait = time.Duration(-s.tokens / s.rate * float64(time.Second))
+	}
+	s.mu.Unlock()
+
+	if wait > 0 {
+		s.throttled.Add(1)
+		time.Sleep(wait)
+	}
+}
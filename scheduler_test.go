@@ -0,0 +1,89 @@
+package srdb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadSchedulerIdleNoThrottle(t *testing.T) {
+	s := newReadScheduler(10)
+	start := time.Now()
+	for range 100 {
+		s.acquire()
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Errorf("Low priority reads should not be throttled without contention")
+	}
+	if s.throttled.Load() != 0 {
+		t.Errorf("Expected 0 throttled reads, got %d", s.throttled.Load())
+	}
+}
+
+func TestQueryPriorityYieldsToHigh(t *testing.T) {
+	dir := "./test_query_priority"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:                 dir,
+		MemTableSize:        1024 * 1024,
+		Name:                "logs",
+		Fields:              []Field{{Name: "msg", Type: String}},
+		LowPriorityReadRate: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	for i := range 50 {
+		if err := table.Insert(map[string]any{"msg": fmt.Sprintf("m%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 模拟进行中的高优先级请求
+	done := table.scheduler.beginHigh()
+
+	start := time.Now()
+	rows, err := table.Query().Priority(PriorityLow).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Count() != 50 {
+		t.Errorf("Expected 50 rows, got %d", rows.Count())
+	}
+	rows.Close()
+	elapsed := time.Since(start)
+	done()
+
+	// 令牌桶容量 10，其余 40 次读取按 100/s 限速，约 400ms
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("Expected low priority scan to be throttled, took %v", elapsed)
+	}
+	if table.scheduler.throttled.Load() == 0 {
+		t.Error("Expected throttled reads")
+	}
+
+	// 普通优先级查询不受影响
+	done = table.scheduler.beginHigh()
+	start = time.Now()
+	rows, err = table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Count()
+	rows.Close()
+	done()
+	if time.Since(start) > 200*time.Millisecond {
+		t.Errorf("Normal priority scan should not be throttled")
+	}
+}
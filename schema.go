@@ -3,7 +3,9 @@
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"sort"
 	"strings"
@@ -107,6 +109,36 @@ func (t FieldType) String() string {
 	}
 }
 
+// ParseFieldType 按名称（String 的返回值，不区分大小写）查找字段类型，如 "int64"、"string"
+func ParseFieldType(name string) (FieldType, error) {
+	for typ := Int; typ <= Array; typ++ {
+		if strings.EqualFold(typ.String(), name) {
+			return typ, nil
+		}
+	}
+	return 0, NewErrorf(ErrCodeInvalidParam, "unknown field type %q", name)
+}
+
+// UnmarshalJSON 同时接受数值（schema.json 中保存的格式）与类型名（手写的 Schema 文件），
+// 如 {"Name": "age", "Type": "int32"}
+func (t *FieldType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var n int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("field type must be a number or a type name: %s", data)
+		}
+		*t = FieldType(n)
+		return nil
+	}
+	typ, err := ParseFieldType(name)
+	if err != nil {
+		return err
+	}
+	*t = typ
+	return nil
+}
+
 // Field 字段定义
 type Field struct {
 	Name     string    // 字段名
@@ -114,6 +146,30 @@ type Field struct {
 	Indexed  bool      // 是否建立索引
 	Nullable bool      // 是否允许 NULL 值
 	Comment  string    // 注释
+
+	// IndexInclude 在索引条目中内联存储的字段（覆盖索引），仅 Indexed 为 true 时有效
+	// 只查询这些字段（及索引字段本身、_seq）的等值查询可直接由索引返回，无需读取行数据
+	IndexInclude []string `json:",omitempty"`
+
+	// IndexMemory 索引数据在内存中的表示方式，仅 Indexed 为 true 时有效（见 IndexMemory）
+	// 索引大到无法全部放在内存中时使用 IndexMemoryMmap，打开表或创建索引时生效
+	IndexMemory IndexMemory `json:",omitempty"`
+
+	// IndexMaintenance 写入时维护索引的方式，仅 Indexed 为 true 时有效（见 IndexMaintenance）
+	// 不要求查询立即读到新写入行的索引可以使用 IndexAsync 降低写入延迟，打开表或创建索引时生效
+	IndexMaintenance IndexMaintenance `json:",omitempty"`
+
+	// Computed 计算列表达式，如 lower(email)、date_trunc(day, _time)
+	// 插入时根据源字段计算并物化存储（写入时提供的值会被覆盖），可以像普通字段一样建立索引
+	Computed string `json:",omitempty"`
+
+	// Sensitive 敏感字段（密码、令牌等），WebUI 等管理工具默认以 RedactedValue 代替其值
+	// 仅影响展示，不影响存储与查询
+	Sensitive bool `json:",omitempty"`
+
+	// Deprecated 已弃用的字段：已有数据仍可读取（需要在 Select 中显式指定），
+	// 不再接受写入非 NULL 值，也不出现在未指定 Select 的查询结果中，用于在物理删除列之前逐步迁移
+	Deprecated bool `json:",omitempty"`
 }
 
 // Schema 表结构定义
@@ -163,6 +219,55 @@ func NewSchema(name string, fields []Field) (*Schema, error) {
 		fieldNames[field.Name] = true
 	}
 
+	// 验证覆盖索引字段
+	for _, field := range fields {
+		if len(field.IndexInclude) == 0 {
+			continue
+		}
+		if !field.Indexed {
+			return nil, NewError(ErrCodeSchemaInvalid, fmt.Errorf("field %s: index include requires indexed field", field.Name))
+		}
+		if err := validateIndexInclude(field, fieldNames); err != nil {
+			return nil, NewError(ErrCodeSchemaInvalid, err)
+		}
+	}
+
+	// 验证索引的内存表示方式
+	for _, field := range fields {
+		if field.IndexMemory == IndexMemoryHeap {
+			continue
+		}
+		if !field.Indexed {
+			return nil, NewError(ErrCodeSchemaInvalid, fmt.Errorf("field %s: index memory requires indexed field", field.Name))
+		}
+		if field.IndexMemory != IndexMemoryMmap {
+			return nil, NewError(ErrCodeSchemaInvalid, fmt.Errorf("field %s: unknown index memory %d", field.Name, field.IndexMemory))
+		}
+	}
+
+	// 验证索引的维护方式
+	for _, field := range fields {
+		if field.IndexMaintenance == IndexSync {
+			continue
+		}
+		if !field.Indexed {
+			return nil, NewError(ErrCodeSchemaInvalid, fmt.Errorf("field %s: index maintenance requires indexed field", field.Name))
+		}
+		if field.IndexMaintenance != IndexAsync {
+			return nil, NewError(ErrCodeSchemaInvalid, fmt.Errorf("field %s: unknown index maintenance %d", field.Name, field.IndexMaintenance))
+		}
+	}
+
+	// 验证计算列
+	for _, field := range fields {
+		if field.Computed == "" {
+			continue
+		}
+		if err := validateComputed(field, fields); err != nil {
+			return nil, NewError(ErrCodeSchemaInvalid, err)
+		}
+	}
+
 	return &Schema{
 		Name:   name,
 		Fields: fields,
@@ -172,7 +277,7 @@ func NewSchema(name string, fields []Field) (*Schema, error) {
 // StructToFields 从 Go 结构体生成 Field 列表
 //
 // 支持的 struct tag 格式：
-//   - `srdb:"name"` - 指定字段名（默认使用 snake_case 转换）
+//   - `srdb:"name"` - 指定字段名（默认使用 snake_case 转换，见 StructToFieldsWithNaming）
 //   - `srdb:"name;indexed"` - 指定字段名并标记为索引
 //   - `srdb:"name;nullable"` - 指定字段名并标记为可空
 //   - `srdb:"name;indexed;nullable;comment:用户名"` - 完整格式
@@ -182,8 +287,14 @@ func NewSchema(name string, fields []Field) (*Schema, error) {
 //   - 使用分号 `;` 分隔不同的部分
 //   - 第一部分是字段名（可选，默认使用 snake_case 转换结构体字段名）
 //   - `indexed` 标记该字段需要索引
+//   - `indexed:名称(a,b)` 声明字段 a、b 上的复合索引，可以重复出现并与 `indexed` 同时使用；
+//     同名的复合索引可以在多个字段上声明，字段列表必须相同（见 index_composite.go）
 //   - `nullable` 标记该字段允许 NULL 值
 //   - `comment:注释内容` 指定字段注释
+//   - `include:a|b` 在该字段的索引中内联存储字段 a、b（覆盖索引）
+//   - `computed:lower(email)` 声明计算列，插入时自动计算
+//   - `sensitive` 标记为敏感字段，管理工具默认脱敏显示
+//   - `deprecated` 标记为已弃用字段，拒绝写入且默认不返回
 //
 // 默认字段名转换示例：
 //   - UserName -> user_name
@@ -224,6 +335,16 @@ func NewSchema(name string, fields []Field) (*Schema, error) {
 //   - []Field: 字段列表
 //   - error: 错误信息
 func StructToFields(v any) ([]Field, error) {
+	return StructToFieldsWithNaming(v, SnakeCase)
+}
+
+// StructToFieldsWithNaming 从 Go 结构体生成 Field 列表，未指定字段名的字段按 naming 转换
+//
+// tag 格式与 StructToFields 相同，naming 为 nil 时使用 SnakeCase。
+// 读写该表时应在 TableOptions/Options 中配置相同的 NamingStrategy，Insert 与 Scan 才能对应到同名字段。
+func StructToFieldsWithNaming(v any, naming NamingStrategy) ([]Field, error) {
+	naming = naming.orDefault()
+
 	// 获取类型
 	typ := reflect.TypeOf(v)
 	if typ == nil {
@@ -241,6 +362,7 @@ func StructToFields(v any) ([]Field, error) {
 	}
 
 	var fields []Field
+	var composites []compositeIndexTag
 
 	// 遍历结构体字段
 	for i := 0; i < typ.NumField(); i++ {
@@ -259,10 +381,16 @@ func StructToFields(v any) ([]Field, error) {
 		}
 
 		// 解析字段名、索引标记、nullable 和注释
-		fieldName := camelToSnake(field.Name) // 默认使用 snake_case 字段名
+		fieldName := naming(field.Name) // 默认按命名规则转换字段名
 		indexed := false
 		nullable := false
 		comment := ""
+		var include []string
+		memory := IndexMemoryHeap
+		maintenance := IndexSync
+		computed := ""
+		sensitive := false
+		deprecated := false
 
 		if tag != "" {
 			// 使用分号分隔各部分，与顺序无关
@@ -282,12 +410,49 @@ func StructToFields(v any) ([]Field, error) {
 				} else if after, ok := strings.CutPrefix(part, "comment:"); ok {
 					// comment:注释内容
 					comment = after
+				} else if after, ok := strings.CutPrefix(part, "include:"); ok {
+					// include:a|b 覆盖索引字段
+					include = strings.Split(after, "|")
+				} else if after, ok := strings.CutPrefix(part, "memory:"); ok {
+					// memory:heap|mmap 索引的内存表示方式
+					m, err := parseIndexMemory(after)
+					if err != nil {
+						return nil, fmt.Errorf("field %s: %w", field.Name, err)
+					}
+					memory = m
+				} else if after, ok := strings.CutPrefix(part, "maintenance:"); ok {
+					// maintenance:sync|async 索引的维护方式
+					m, err := parseIndexMaintenance(after)
+					if err != nil {
+						return nil, fmt.Errorf("field %s: %w", field.Name, err)
+					}
+					maintenance = m
+				} else if after, ok := strings.CutPrefix(part, "computed:"); ok {
+					// computed:表达式 计算列
+					computed = after
+				} else if after, ok := strings.CutPrefix(part, "indexed:"); ok {
+					// indexed:名称(a,b) 复合索引
+					tag, err := parseCompositeIndexTag(after)
+					if err != nil {
+						return nil, fmt.Errorf("field %s: %w", field.Name, err)
+					}
+					if composites, err = addCompositeIndexTag(composites, tag); err != nil {
+						return nil, fmt.Errorf("field %s: %w", field.Name, err)
+					}
 				} else if part == "indexed" {
 					// indexed 标记
 					indexed = true
 				} else if part == "nullable" {
 					// nullable 标记
 					nullable = true
+				} else if part == "sensitive" {
+					// sensitive 标记
+					sensitive = true
+				} else if part == "deprecated" {
+					// deprecated 标记
+					deprecated = true
+				} else if part == "skipzero" {
+					// skipzero 标记：仅影响插入（零值视为未设置），不影响 Schema
 				} else if !strings.Contains(part, ":") && isFirst {
 					// 第一个非关键字部分作为字段名（兼容旧格式 `srdb:"name"`）
 					fieldName = part
@@ -320,11 +485,17 @@ func StructToFields(v any) ([]Field, error) {
 		}
 
 		fields = append(fields, Field{
-			Name:     fieldName,
-			Type:     fieldType,
-			Indexed:  indexed,
-			Nullable: nullable,
-			Comment:  comment,
+			Name:             fieldName,
+			Type:             fieldType,
+			Indexed:          indexed,
+			Nullable:         nullable,
+			Comment:          comment,
+			IndexInclude:     include,
+			IndexMemory:      memory,
+			IndexMaintenance: maintenance,
+			Computed:         computed,
+			Sensitive:        sensitive,
+			Deprecated:       deprecated,
 		})
 	}
 
@@ -332,7 +503,12 @@ func StructToFields(v any) ([]Field, error) {
 		return nil, fmt.Errorf("no exported fields found in struct")
 	}
 
-	return fields, nil
+	// 复合索引作为带索引的计算列追加在最后
+	indexFields, err := compositeIndexFields(composites, fields)
+	if err != nil {
+		return nil, err
+	}
+	return append(fields, indexFields...), nil
 }
 
 // goTypeToFieldType 将 Go 类型精确映射到 FieldType
@@ -486,6 +662,60 @@ func (s *Schema) GetIndexedFields() []Field {
 	return fields
 }
 
+// RedactedValue 敏感字段脱敏后显示的值
+const RedactedValue = "******"
+
+// Redact 将 data 中标记为 sensitive 的字段替换为 RedactedValue（原地修改）
+// NULL 值保持为 NULL，不存在的字段不会被添加；返回被脱敏的字段数
+func (s *Schema) Redact(data map[string]any) int {
+	n := 0
+	for _, field := range s.Fields {
+		if !field.Sensitive {
+			continue
+		}
+		if value, ok := data[field.Name]; ok && value != nil {
+			data[field.Name] = RedactedValue
+			n++
+		}
+	}
+	return n
+}
+
+// hasDeprecated 是否包含已弃用的字段
+func (s *Schema) hasDeprecated() bool {
+	for _, field := range s.Fields {
+		if field.Deprecated {
+			return true
+		}
+	}
+	return false
+}
+
+// hideDeprecated 从 data 中删除已弃用的字段（原地修改，未指定 Select 时使用）
+func (s *Schema) hideDeprecated(data map[string]any) {
+	for _, field := range s.Fields {
+		if field.Deprecated {
+			delete(data, field.Name)
+		}
+	}
+}
+
+// isDeprecated 字段是否已弃用
+func (s *Schema) isDeprecated(name string) bool {
+	field, err := s.GetField(name)
+	return err == nil && field.Deprecated
+}
+
+// checkDeprecatedWrite 拒绝写入已弃用字段的非 NULL 值
+func (s *Schema) checkDeprecatedWrite(data map[string]any) error {
+	for _, field := range s.Fields {
+		if field.Deprecated && data[field.Name] != nil {
+			return fmt.Errorf("field %s is deprecated and no longer accepts writes", field.Name)
+		}
+	}
+	return nil
+}
+
 // Validate 验证数据是否符合 Schema
 func (s *Schema) Validate(data map[string]any) error {
 	for _, field := range s.Fields {
@@ -1200,8 +1430,14 @@ func convertToDecimal(v any) (decimal.Decimal, error) {
 		}
 		return d, nil
 	case float32:
+		if math.IsNaN(float64(val)) || math.IsInf(float64(val), 0) {
+			return decimal.Decimal{}, fmt.Errorf("cannot convert %v to decimal", val)
+		}
 		return decimal.NewFromFloat32(val), nil
 	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return decimal.Decimal{}, fmt.Errorf("cannot convert %v to decimal", val)
+		}
 		return decimal.NewFromFloat(val), nil
 	case int:
 		return decimal.NewFromInt(int64(val)), nil
@@ -1307,6 +1543,12 @@ func (s *Schema) ComputeChecksum() (string, error) {
 		}
 		builder.WriteString(":")
 		builder.WriteString(field.Comment)
+		writeIndexInclude(&builder, field)
+		writeComputed(&builder, field)
+		writeSensitive(&builder, field)
+		writeDeprecated(&builder, field)
+		writeIndexMemory(&builder, field)
+		writeIndexMaintenance(&builder, field)
 	}
 
 	// 计算 SHA256
@@ -1314,12 +1556,153 @@ func (s *Schema) ComputeChecksum() (string, error) {
 	return hex.EncodeToString(hash[:]), nil
 }
 
+// ComputeStructureChecksum 计算 Schema 结构部分的 SHA256 校验和
+// 与 ComputeChecksum 不同，不包含字段注释等展示性元数据，
+// 用于判断两个 Schema 是否仅在注释上存在差异
+// 格式: "name:<name>;fields:<field1_name>:<field1_type>:<field1_indexed>:<field1_nullable>,<field2>..."
+func (s *Schema) ComputeStructureChecksum() (string, error) {
+	var builder strings.Builder
+
+	builder.WriteString("name:")
+	builder.WriteString(s.Name)
+	builder.WriteString(";")
+
+	sortedFields := make([]Field, len(s.Fields))
+	copy(sortedFields, s.Fields)
+	sort.Slice(sortedFields, func(i, j int) bool {
+		return sortedFields[i].Name < sortedFields[j].Name
+	})
+
+	builder.WriteString("fields:")
+	for i, field := range sortedFields {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		// 字段格式: name:type:indexed:nullable
+		builder.WriteString(field.Name)
+		builder.WriteString(":")
+		builder.WriteString(field.Type.String())
+		builder.WriteString(":")
+		if field.Indexed {
+			builder.WriteString("1")
+		} else {
+			builder.WriteString("0")
+		}
+		builder.WriteString(":")
+		if field.Nullable {
+			builder.WriteString("1")
+		} else {
+			builder.WriteString("0")
+		}
+		writeIndexInclude(&builder, field)
+		writeComputed(&builder, field)
+	}
+
+	hash := sha256.Sum256([]byte(builder.String()))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// writeIndexInclude 将覆盖索引字段写入校验和输入
+// 未配置时不写入任何内容，保证已有 Schema 的校验和不变
+func writeIndexInclude(builder *strings.Builder, field Field) {
+	if len(field.IndexInclude) == 0 {
+		return
+	}
+	builder.WriteString(":include=")
+	builder.WriteString(strings.Join(field.IndexInclude, "|"))
+}
+
+// writeComputed 将计算列表达式写入校验和输入
+// 未配置时不写入任何内容，保证已有 Schema 的校验和不变
+func writeComputed(builder *strings.Builder, field Field) {
+	if field.Computed == "" {
+		return
+	}
+	builder.WriteString(":computed=")
+	builder.WriteString(field.Computed)
+}
+
+// writeSensitive 将敏感标记写入校验和输入（仅内容校验和，与注释一样不属于结构）
+// 未标记时不写入任何内容，保证已有 Schema 的校验和不变
+func writeSensitive(builder *strings.Builder, field Field) {
+	if field.Sensitive {
+		builder.WriteString(":sensitive")
+	}
+}
+
+// writeIndexMemory 将索引的内存表示方式写入校验和输入（仅内容校验和：不影响存储布局）
+// 使用默认值时不写入任何内容，保证已有 Schema 的校验和不变
+func writeIndexMemory(builder *strings.Builder, field Field) {
+	if field.IndexMemory != IndexMemoryHeap {
+		builder.WriteString(":memory=")
+		builder.WriteString(field.IndexMemory.String())
+	}
+}
+
+// writeIndexMaintenance 将索引的维护方式写入校验和输入（仅内容校验和：不影响存储布局）
+// 使用默认值时不写入任何内容，保证已有 Schema 的校验和不变
+func writeIndexMaintenance(builder *strings.Builder, field Field) {
+	if field.IndexMaintenance != IndexSync {
+		builder.WriteString(":maintenance=")
+		builder.WriteString(field.IndexMaintenance.String())
+	}
+}
+
+// writeDeprecated 将弃用标记写入校验和输入（仅内容校验和：不影响存储布局）
+// 未标记时不写入任何内容，保证已有 Schema 的校验和不变
+func writeDeprecated(builder *strings.Builder, field Field) {
+	if field.Deprecated {
+		builder.WriteString(":deprecated")
+	}
+}
+
+// validateIndexInclude 验证覆盖索引字段
+func validateIndexInclude(field Field, fieldNames map[string]bool) error {
+	size := 1 // 字段数量
+	seen := make(map[string]bool)
+	for _, name := range field.IndexInclude {
+		if name == field.Name {
+			return fmt.Errorf("field %s: index cannot include itself", field.Name)
+		}
+		if !fieldNames[name] {
+			return fmt.Errorf("field %s: included field %s not found", field.Name, name)
+		}
+		if seen[name] {
+			return fmt.Errorf("field %s: duplicate included field %s", field.Name, name)
+		}
+		seen[name] = true
+		size += 2 + len(name) // 类型(1) + 名称长度(1) + 名称
+	}
+	if len(field.IndexInclude) > 255 || size > indexIncludeMaxBytes {
+		return fmt.Errorf("field %s: too many included fields", field.Name)
+	}
+	return nil
+}
+
+// IsCompatibleWith 判断两个 Schema 是否结构兼容
+// 仅字段注释不同视为兼容
+func (s *Schema) IsCompatibleWith(other *Schema) bool {
+	if other == nil {
+		return false
+	}
+	a, err := s.ComputeStructureChecksum()
+	if err != nil {
+		return false
+	}
+	b, err := other.ComputeStructureChecksum()
+	if err != nil {
+		return false
+	}
+	return a == b
+}
+
 // SchemaFile Schema 文件格式（带校验）
 type SchemaFile struct {
-	Version   int     `json:"version"`   // 文件格式版本
-	Timestamp int64   `json:"timestamp"` // 保存时间戳
-	Checksum  string  `json:"checksum"`  // Schema 内容的 SHA256 校验和
-	Schema    *Schema `json:"schema"`    // Schema 内容
+	Version           int     `json:"version"`                      // 文件格式版本
+	Timestamp         int64   `json:"timestamp"`                    // 保存时间戳
+	Checksum          string  `json:"checksum"`                     // Schema 内容的 SHA256 校验和
+	StructureChecksum string  `json:"structure_checksum,omitempty"` // Schema 结构（不含注释）的 SHA256 校验和
+	Schema            *Schema `json:"schema"`                       // Schema 内容
 }
 
 // NewSchemaFile 创建带校验和的 Schema 文件
@@ -1329,15 +1712,26 @@ func NewSchemaFile(schema *Schema) (*SchemaFile, error) {
 		return nil, fmt.Errorf("compute checksum: %w", err)
 	}
 
+	structureChecksum, err := schema.ComputeStructureChecksum()
+	if err != nil {
+		return nil, fmt.Errorf("compute structure checksum: %w", err)
+	}
+
 	return &SchemaFile{
-		Version:   1, // 当前文件格式版本
-		Timestamp: time.Now().Unix(),
-		Checksum:  checksum,
-		Schema:    schema,
+		Version:           1, // 当前文件格式版本
+		Timestamp:         time.Now().Unix(),
+		Checksum:          checksum,
+		StructureChecksum: structureChecksum,
+		Schema:            schema,
 	}, nil
 }
 
 // Verify 验证 Schema 文件的完整性
+// 如果仅字段注释被修改（结构校验和仍然匹配），视为兼容，不返回错误
+//
+// 旧版本写入的文件没有 structure_checksum，校验和不匹配时无法从中还原原有结构，
+// 此时以解码出的字段计算结构校验和作为基准（要求仍是合法的 Schema），
+// 使仅修改注释的旧文件同样可以打开；打开表时会重写文件并记录结构校验和。
 func (sf *SchemaFile) Verify() error {
 	if sf.Schema == nil {
 		return fmt.Errorf("schema is nil")
@@ -1351,6 +1745,20 @@ func (sf *SchemaFile) Verify() error {
 
 	// 对比 checksum
 	if actualChecksum != sf.Checksum {
+		// 仅注释变化时，结构校验和仍然一致
+		actualStructure, err := sf.Schema.ComputeStructureChecksum()
+		if err != nil {
+			return fmt.Errorf("compute structure checksum: %w", err)
+		}
+		if sf.StructureChecksum == "" {
+			if _, err := NewSchema(sf.Schema.Name, sf.Schema.Fields); err != nil {
+				return fmt.Errorf("schema checksum mismatch: expected %s, got %s: %w", sf.Checksum, actualChecksum, err)
+			}
+			sf.StructureChecksum = actualStructure
+		}
+		if actualStructure == sf.StructureChecksum {
+			return nil
+		}
 		return fmt.Errorf("schema checksum mismatch: expected %s, got %s (schema may have been tampered with)", sf.Checksum, actualChecksum)
 	}
 
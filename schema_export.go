@@ -0,0 +1,303 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Schema 导出为与语言无关的格式，供其他语言的服务校验数据、生成客户端
+
+// ToJSONSchema 生成描述一行数据的 JSON Schema（draft 2020-12）
+//
+// 取值形式与 Insert、ImportJSON 接受的 JSON 一致：
+//   - 整数类型带有对应的取值范围，Float32/Float64 为 number
+//   - Decimal 为十进制字符串或 number，Time 为 RFC 3339 字符串或 Unix 秒，
+//     Duration 为 time.ParseDuration 格式的字符串或纳秒整数
+//   - Object、Array 分别为 object、array
+//
+// Nullable 字段允许 null；计算列标记为 readOnly（写入的值会被覆盖）；
+// Comment 作为 description。所有字段都是可选的，与 Insert 一致。
+func (s *Schema) ToJSONSchema() ([]byte, error) {
+	properties := make(map[string]any, len(s.Fields))
+	for _, field := range s.Fields {
+		property := jsonSchemaType(field.Type)
+		if field.Nullable {
+			types, ok := property["type"].([]string)
+			if !ok {
+				types = []string{property["type"].(string)}
+			}
+			property["type"] = append(types, "null")
+		}
+		if field.Comment != "" {
+			property["description"] = field.Comment
+		}
+		if field.Computed != "" {
+			property["readOnly"] = true
+		}
+		properties[field.Name] = property
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      s.Name,
+		"type":       "object",
+		"properties": properties,
+	}, "", "  ")
+}
+
+// jsonSchemaType 返回字段类型对应的 JSON Schema（不含 null）
+func jsonSchemaType(typ FieldType) map[string]any {
+	integer := func(minimum, maximum any) map[string]any {
+		return map[string]any{"type": "integer", "minimum": minimum, "maximum": maximum}
+	}
+
+	switch typ {
+	case Int8:
+		return integer(math.MinInt8, math.MaxInt8)
+	case Int16:
+		return integer(math.MinInt16, math.MaxInt16)
+	case Int32, Rune:
+		return integer(math.MinInt32, math.MaxInt32)
+	case Int, Int64:
+		return integer(int64(math.MinInt64), int64(math.MaxInt64))
+	case Uint8, Byte:
+		return integer(0, math.MaxUint8)
+	case Uint16:
+		return integer(0, math.MaxUint16)
+	case Uint32:
+		return integer(0, math.MaxUint32)
+	case Uint, Uint64:
+		return integer(0, uint64(math.MaxUint64))
+	case Float32, Float64:
+		return map[string]any{"type": "number"}
+	case String:
+		return map[string]any{"type": "string"}
+	case Bool:
+		return map[string]any{"type": "boolean"}
+	case Decimal:
+		return map[string]any{"type": []string{"string", "number"}, "pattern": `^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`}
+	case Time:
+		return map[string]any{"type": []string{"string", "integer"}, "format": "date-time"}
+	case Duration:
+		return map[string]any{"type": []string{"string", "integer"}}
+	case Object:
+		return map[string]any{"type": "object"}
+	case Array:
+		return map[string]any{"type": "array"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// protobuf 描述符（descriptor.proto）中使用的字段编号与枚举值
+const (
+	protoFileSetFile = 1 // FileDescriptorSet.file
+
+	protoFileName       = 1  // FileDescriptorProto.name
+	protoFilePackage    = 2  // FileDescriptorProto.package
+	protoFileDependency = 3  // FileDescriptorProto.dependency
+	protoFileMessage    = 4  // FileDescriptorProto.message_type
+	protoFileSyntax     = 12 // FileDescriptorProto.syntax
+
+	protoMsgName  = 1 // DescriptorProto.name
+	protoMsgField = 2 // DescriptorProto.field
+	protoMsgOneof = 8 // DescriptorProto.oneof_decl
+
+	protoFieldName           = 1  // FieldDescriptorProto.name
+	protoFieldNumber         = 3  // FieldDescriptorProto.number
+	protoFieldLabel          = 4  // FieldDescriptorProto.label
+	protoFieldType           = 5  // FieldDescriptorProto.type
+	protoFieldTypeName       = 6  // FieldDescriptorProto.type_name
+	protoFieldOneofIndex     = 9  // FieldDescriptorProto.oneof_index
+	protoFieldJSONName       = 10 // FieldDescriptorProto.json_name
+	protoFieldProto3Optional = 17 // FieldDescriptorProto.proto3_optional
+
+	protoOneofName = 1 // OneofDescriptorProto.name
+
+	protoLabelOptional = 1
+
+	protoTypeDouble  = 1
+	protoTypeFloat   = 2
+	protoTypeInt64   = 3
+	protoTypeUint64  = 4
+	protoTypeInt32   = 5
+	protoTypeBool    = 8
+	protoTypeString  = 9
+	protoTypeMessage = 11
+	protoTypeUint32  = 13
+)
+
+// ProtoPackage ToProtoDescriptor 生成的 protobuf 包名
+const ProtoPackage = "srdb"
+
+// ToProtoDescriptor 生成描述一行数据的 protobuf 描述符（序列化的 google.protobuf.FileDescriptorSet）
+//
+// 集合中只有一个 proto3 文件 <name>.proto（包名 ProtoPackage），其中的消息以 Schema 名称的大驼峰命名，
+// 字段编号按字段顺序从 1 开始（只在末尾追加字段时编号保持不变），可以由各语言的 protobuf 运行时加载
+// （依赖的 google/protobuf 标准类型不包含在集合中）。类型对应关系：
+//   - Int、Int64 → int64，Int8/16/32、Rune → int32，Uint、Uint64 → uint64，Uint8/16/32、Byte → uint32
+//   - Float32 → float，Float64 → double，String → string，Bool → bool，Decimal → string（十进制文本）
+//   - Time → google.protobuf.Timestamp，Duration → google.protobuf.Duration
+//   - Object → google.protobuf.Struct，Array → google.protobuf.ListValue
+//
+// Nullable 的标量字段使用 proto3 optional。字段名必须是合法的 protobuf 标识符。
+func (s *Schema) ToProtoDescriptor() ([]byte, error) {
+	messageName := protoMessageName(s.Name)
+	if !isProtoIdent(messageName) {
+		return nil, NewErrorf(ErrCodeInvalidParam, "schema name %q cannot be used as a protobuf message name", s.Name)
+	}
+
+	var message, oneofs []byte
+	message = protoAppendString(message, protoMsgName, messageName)
+	dependencies := make(map[string]bool)
+	numOneofs := 0
+	for i, field := range s.Fields {
+		if !isProtoIdent(field.Name) {
+			return nil, NewErrorf(ErrCodeInvalidParam, "field name %q is not a valid protobuf identifier", field.Name)
+		}
+		typ, typeName, dependency := protoTypeOf(field.Type)
+		if typ == 0 {
+			return nil, NewErrorf(ErrCodeInvalidParam, "field %s: unsupported type %s", field.Name, field.Type)
+		}
+
+		var desc []byte
+		desc = protoAppendString(desc, protoFieldName, field.Name)
+		desc = protoAppendVarint(desc, protoFieldNumber, uint64(i+1))
+		desc = protoAppendVarint(desc, protoFieldLabel, protoLabelOptional)
+		desc = protoAppendVarint(desc, protoFieldType, uint64(typ))
+		if typeName != "" {
+			desc = protoAppendString(desc, protoFieldTypeName, typeName)
+			dependencies[dependency] = true
+		}
+		desc = protoAppendString(desc, protoFieldJSONName, protoJSONName(field.Name))
+		if field.Nullable && typ != protoTypeMessage {
+			// proto3 optional：每个字段一个合成 oneof，名称为 "_" + 字段名
+			desc = protoAppendVarint(desc, protoFieldOneofIndex, uint64(numOneofs))
+			desc = protoAppendVarint(desc, protoFieldProto3Optional, 1)
+			oneofs = protoAppendBytes(oneofs, protoMsgOneof, protoAppendString(nil, protoOneofName, "_"+field.Name))
+			numOneofs++
+		}
+		message = protoAppendBytes(message, protoMsgField, desc)
+	}
+	message = append(message, oneofs...)
+
+	var file []byte
+	file = protoAppendString(file, protoFileName, s.Name+".proto")
+	file = protoAppendString(file, protoFilePackage, ProtoPackage)
+	for _, dependency := range []string{"google/protobuf/duration.proto", "google/protobuf/struct.proto", "google/protobuf/timestamp.proto"} {
+		if dependencies[dependency] {
+			file = protoAppendString(file, protoFileDependency, dependency)
+		}
+	}
+	file = protoAppendBytes(file, protoFileMessage, message)
+	file = protoAppendString(file, protoFileSyntax, "proto3")
+
+	return protoAppendBytes(nil, protoFileSetFile, file), nil
+}
+
+// protoTypeOf 返回字段类型对应的 protobuf 类型、消息类型全名及其所在文件
+func protoTypeOf(typ FieldType) (int, string, string) {
+	switch typ {
+	case Int, Int64:
+		return protoTypeInt64, "", ""
+	case Int8, Int16, Int32, Rune:
+		return protoTypeInt32, "", ""
+	case Uint, Uint64:
+		return protoTypeUint64, "", ""
+	case Uint8, Uint16, Uint32, Byte:
+		return protoTypeUint32, "", ""
+	case Float32:
+		return protoTypeFloat, "", ""
+	case Float64:
+		return protoTypeDouble, "", ""
+	case String, Decimal:
+		return protoTypeString, "", ""
+	case Bool:
+		return protoTypeBool, "", ""
+	case Time:
+		return protoTypeMessage, ".google.protobuf.Timestamp", "google/protobuf/timestamp.proto"
+	case Duration:
+		return protoTypeMessage, ".google.protobuf.Duration", "google/protobuf/duration.proto"
+	case Object:
+		return protoTypeMessage, ".google.protobuf.Struct", "google/protobuf/struct.proto"
+	case Array:
+		return protoTypeMessage, ".google.protobuf.ListValue", "google/protobuf/struct.proto"
+	default:
+		return 0, "", ""
+	}
+}
+
+// protoMessageName 将 Schema 名称转换为大驼峰的消息名：user_events -> UserEvents
+func protoMessageName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' || r == '-' || r == '.' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// protoJSONName 按 protoc 的规则计算 JSON 名称：去掉下划线，其后的字母大写
+func protoJSONName(name string) string {
+	var b strings.Builder
+	upper := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isProtoIdent 检查是否为合法的 protobuf 标识符：[A-Za-z_][A-Za-z0-9_]*
+func isProtoIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// protoAppendVarint 追加 varint 字段
+func protoAppendVarint(b []byte, num int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3)
+	return binary.AppendUvarint(b, v)
+}
+
+// protoAppendBytes 追加长度前缀字段（字符串、嵌套消息）
+func protoAppendBytes(b []byte, num int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// protoAppendString 追加字符串字段
+func protoAppendString(b []byte, num int, v string) []byte {
+	return protoAppendBytes(b, num, []byte(v))
+}
@@ -0,0 +1,195 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func exportTestSchema(t *testing.T) *Schema {
+	t.Helper()
+	schema, err := NewSchema("user_events", []Field{
+		{Name: "user_id", Type: Int64, Indexed: true, Comment: "用户 ID"},
+		{Name: "level", Type: Uint8},
+		{Name: "email", Type: String, Nullable: true},
+		{Name: "email_lower", Type: String, Computed: "lower(email)"},
+		{Name: "amount", Type: Decimal},
+		{Name: "created_at", Type: Time},
+		{Name: "score", Type: Float64, Nullable: true},
+		{Name: "meta", Type: Object, Nullable: true},
+		{Name: "tags", Type: Array},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestSchemaToJSONSchema(t *testing.T) {
+	data, err := exportTestSchema(t).ToJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Schema     string                    `json:"$schema"`
+		Title      string                    `json:"title"`
+		Type       string                    `json:"type"`
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "user_events" || doc.Type != "object" || len(doc.Properties) != 9 {
+		t.Fatalf("unexpected document: %s", data)
+	}
+
+	props := doc.Properties
+	if p := props["user_id"]; p["type"] != "integer" || p["description"] != "用户 ID" || p["maximum"] != float64(9223372036854775807) {
+		t.Errorf("unexpected user_id: %v", p)
+	}
+	if p := props["level"]; p["minimum"] != float64(0) || p["maximum"] != float64(255) {
+		t.Errorf("unexpected level: %v", p)
+	}
+	if p := props["email"]; !slices.Equal(toStrings(p["type"]), []string{"string", "null"}) {
+		t.Errorf("unexpected email: %v", p)
+	}
+	if p := props["email_lower"]; p["readOnly"] != true {
+		t.Errorf("expected computed column to be readOnly: %v", p)
+	}
+	if p := props["amount"]; !slices.Equal(toStrings(p["type"]), []string{"string", "number"}) || p["pattern"] == nil {
+		t.Errorf("unexpected amount: %v", p)
+	}
+	if p := props["created_at"]; p["format"] != "date-time" {
+		t.Errorf("unexpected created_at: %v", p)
+	}
+	if p := props["meta"]; !slices.Equal(toStrings(p["type"]), []string{"object", "null"}) {
+		t.Errorf("unexpected meta: %v", p)
+	}
+}
+
+func toStrings(v any) []string {
+	items, _ := v.([]any)
+	var result []string
+	for _, item := range items {
+		s, _ := item.(string)
+		result = append(result, s)
+	}
+	return result
+}
+
+// protoFields 解码一层 protobuf 消息：字段编号 → 值（varint 为 uint64，长度前缀为 []byte）
+func protoFields(t *testing.T, b []byte) map[int][]any {
+	t.Helper()
+	fields := make(map[int][]any)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("invalid key")
+		}
+		b = b[n:]
+		num := int(key >> 3)
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatal("invalid varint")
+			}
+			fields[num] = append(fields[num], v)
+			b = b[n:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				t.Fatal("invalid length")
+			}
+			fields[num] = append(fields[num], b[n:n+int(size)])
+			b = b[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return fields
+}
+
+func TestSchemaToProtoDescriptor(t *testing.T) {
+	data, err := exportTestSchema(t).ToProtoDescriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := protoFields(t, data)
+	if len(set[protoFileSetFile]) != 1 {
+		t.Fatalf("expected one file, got %d", len(set[protoFileSetFile]))
+	}
+	file := protoFields(t, set[protoFileSetFile][0].([]byte))
+	str := func(v any) string { return string(v.([]byte)) }
+	if str(file[protoFileName][0]) != "user_events.proto" || str(file[protoFilePackage][0]) != ProtoPackage || str(file[protoFileSyntax][0]) != "proto3" {
+		t.Errorf("unexpected file header: %v", file)
+	}
+	var deps []string
+	for _, dep := range file[protoFileDependency] {
+		deps = append(deps, str(dep))
+	}
+	if !slices.Equal(deps, []string{"google/protobuf/struct.proto", "google/protobuf/timestamp.proto"}) {
+		t.Errorf("unexpected dependencies: %v", deps)
+	}
+
+	message := protoFields(t, file[protoFileMessage][0].([]byte))
+	if str(message[protoMsgName][0]) != "UserEvents" {
+		t.Errorf("unexpected message name %s", message[protoMsgName][0])
+	}
+
+	type protoField struct {
+		name, typeName, jsonName string
+		number, typ              uint64
+		optional                 bool
+	}
+	var got []protoField
+	for _, raw := range message[protoMsgField] {
+		f := protoFields(t, raw.([]byte))
+		pf := protoField{
+			name:     str(f[protoFieldName][0]),
+			number:   f[protoFieldNumber][0].(uint64),
+			typ:      f[protoFieldType][0].(uint64),
+			jsonName: str(f[protoFieldJSONName][0]),
+			optional: len(f[protoFieldProto3Optional]) > 0,
+		}
+		if len(f[protoFieldTypeName]) > 0 {
+			pf.typeName = str(f[protoFieldTypeName][0])
+		}
+		got = append(got, pf)
+	}
+	want := []protoField{
+		{"user_id", "", "userId", 1, protoTypeInt64, false},
+		{"level", "", "level", 2, protoTypeUint32, false},
+		{"email", "", "email", 3, protoTypeString, true},
+		{"email_lower", "", "emailLower", 4, protoTypeString, false},
+		{"amount", "", "amount", 5, protoTypeString, false},
+		{"created_at", ".google.protobuf.Timestamp", "createdAt", 6, protoTypeMessage, false},
+		{"score", "", "score", 7, protoTypeDouble, true},
+		{"meta", ".google.protobuf.Struct", "meta", 8, protoTypeMessage, false},
+		{"tags", ".google.protobuf.ListValue", "tags", 9, protoTypeMessage, false},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected fields:\n got %+v\nwant %+v", got, want)
+	}
+
+	// 每个 optional 字段一个合成 oneof
+	var oneofs []string
+	for _, raw := range message[protoMsgOneof] {
+		oneofs = append(oneofs, str(protoFields(t, raw.([]byte))[protoOneofName][0]))
+	}
+	if !slices.Equal(oneofs, []string{"_email", "_score"}) {
+		t.Errorf("unexpected oneofs: %v", oneofs)
+	}
+
+	// 非法标识符
+	bad, err := NewSchema("bad", []Field{{Name: "a-b", Type: String}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bad.ToProtoDescriptor(); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+}
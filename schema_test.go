@@ -1,6 +1,7 @@
 package srdb
 
 import (
+	"slices"
 	"strings"
 	"testing"
 )
@@ -1128,3 +1129,183 @@ type User struct {
 
 	t.Log("✓ Override snake_case test passed")
 }
+
+func TestSchemaIndexInclude(t *testing.T) {
+	_, err := NewSchema("users", []Field{
+		{Name: "email", Type: String, IndexInclude: []string{"name"}},
+		{Name: "name", Type: String},
+	})
+	if !IsError(err, ErrCodeSchemaInvalid) {
+		t.Errorf("expected ErrCodeSchemaInvalid for non-indexed field, got %v", err)
+	}
+
+	_, err = NewSchema("users", []Field{
+		{Name: "email", Type: String, Indexed: true, IndexInclude: []string{"missing"}},
+	})
+	if !IsError(err, ErrCodeSchemaInvalid) {
+		t.Errorf("expected ErrCodeSchemaInvalid for unknown field, got %v", err)
+	}
+
+	type User struct {
+		Email string `srdb:"email;indexed;include:name|age"`
+		Name  string `srdb:"name"`
+		Age   int32  `srdb:"age"`
+	}
+	fields, err := StructToFields(User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fields[0].IndexInclude; len(got) != 2 || got[0] != "name" || got[1] != "age" {
+		t.Errorf("unexpected IndexInclude: %v", got)
+	}
+
+	// 未配置覆盖字段时校验和保持不变
+	plain := &Schema{Name: "users", Fields: []Field{{Name: "email", Type: String, Indexed: true}}}
+	covered := &Schema{Name: "users", Fields: []Field{{Name: "email", Type: String, Indexed: true, IndexInclude: []string{"email2"}}}}
+	c1, _ := plain.ComputeChecksum()
+	c2, _ := covered.ComputeChecksum()
+	if c1 == c2 {
+		t.Error("checksum should include IndexInclude")
+	}
+}
+
+// TestSchemaSensitive 测试 sensitive 标记的解析、校验和与脱敏
+func TestSchemaSensitive(t *testing.T) {
+	type Account struct {
+		Name     string  `srdb:"name;indexed"`
+		Password string  `srdb:"password;sensitive"`
+		Token    *string `srdb:"token;nullable;sensitive;comment:访问令牌"`
+	}
+	fields, err := StructToFields(Account{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields[0].Sensitive || !fields[1].Sensitive || !fields[2].Sensitive {
+		t.Fatalf("unexpected Sensitive flags: %+v", fields)
+	}
+	if fields[2].Comment != "访问令牌" || !fields[2].Nullable {
+		t.Errorf("sensitive should combine with other options: %+v", fields[2])
+	}
+
+	schema, err := NewSchema("accounts", fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]any{"name": "alice", "password": "secret", "token": nil}
+	if n := schema.Redact(data); n != 1 {
+		t.Errorf("expected 1 redacted field, got %d", n)
+	}
+	if data["name"] != "alice" || data["password"] != RedactedValue || data["token"] != nil {
+		t.Errorf("unexpected redacted data: %v", data)
+	}
+
+	// 敏感标记属于元数据：改变内容校验和，但不改变结构校验和
+	plain := &Schema{Name: "accounts", Fields: []Field{{Name: "password", Type: String}}}
+	marked := &Schema{Name: "accounts", Fields: []Field{{Name: "password", Type: String, Sensitive: true}}}
+	c1, _ := plain.ComputeChecksum()
+	c2, _ := marked.ComputeChecksum()
+	if c1 == c2 {
+		t.Error("checksum should include Sensitive")
+	}
+	if !plain.IsCompatibleWith(marked) {
+		t.Error("marking a field sensitive should keep the schema compatible")
+	}
+}
+
+func TestSchemaDeprecated(t *testing.T) {
+	type User struct {
+		Name  string `srdb:"name"`
+		Phone string `srdb:"phone;deprecated"`
+	}
+	fields, err := StructToFields(User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields[0].Deprecated || !fields[1].Deprecated {
+		t.Fatalf("unexpected Deprecated flags: %+v", fields)
+	}
+
+	// 已有数据写入时字段尚未弃用
+	dir := t.TempDir()
+	active := []Field{{Name: "name", Type: String}, {Name: "phone", Type: String, Nullable: true}}
+	table, err := OpenTable(&TableOptions{Dir: dir, Name: "users", Fields: active})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"name": "alice", "phone": "123"}); err != nil {
+		t.Fatal(err)
+	}
+	table.Close()
+
+	// 弃用只改变元数据，已有的表可以直接打开
+	deprecated := slices.Clone(active)
+	deprecated[1].Deprecated = true
+	table, err = OpenTable(&TableOptions{Dir: dir, Name: "users", Fields: deprecated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 拒绝写入非 NULL 值，省略或 NULL 可以写入
+	err = table.Insert(map[string]any{"name": "bob", "phone": "456"})
+	if !IsError(err, ErrCodeSchemaValidationFailed) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if err := table.Insert(map[string]any{"name": "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"name": "carol", "phone": nil}); err != nil {
+		t.Fatal(err)
+	}
+	// 结构体中仍保留该字段的服务：零值视为未设置，非零值被拒绝
+	if err := table.Insert(User{Name: "dave"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(User{Name: "eve", Phone: "789"}); err == nil {
+		t.Fatal("expected struct write to deprecated field to fail")
+	}
+
+	// 默认查询不返回已弃用的字段
+	first, err := table.Query().Eq("name", "alice").First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := first.Data()["phone"]; ok {
+		t.Errorf("deprecated field should be hidden: %v", first.Data())
+	}
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	collected := rows.Collect()
+	rows.Close()
+	if len(collected) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(collected))
+	}
+	for _, data := range collected {
+		if _, ok := data["phone"]; ok {
+			t.Errorf("deprecated field should be hidden from Collect: %v", data)
+		}
+	}
+
+	// 显式 Select 仍可读取
+	first, err = table.Query().Eq("name", "alice").Select("name", "phone").First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Data()["phone"] != "123" {
+		t.Errorf("explicit Select should return deprecated field: %v", first.Data())
+	}
+
+	// 弃用标记属于元数据：改变内容校验和，不改变结构校验和
+	plain := &Schema{Name: "users", Fields: active}
+	marked := &Schema{Name: "users", Fields: deprecated}
+	c1, _ := plain.ComputeChecksum()
+	c2, _ := marked.ComputeChecksum()
+	if c1 == c2 {
+		t.Error("checksum should include Deprecated")
+	}
+	if !plain.IsCompatibleWith(marked) {
+		t.Error("deprecating a field should keep the schema compatible")
+	}
+}
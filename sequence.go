@@ -0,0 +1,40 @@
+package srdb
+
+import "sync/atomic"
+
+// Sequence 多张表共享的 _seq 分配器
+//
+// 默认每张表独立分配 _seq，不同表的 _seq 之间没有先后关系。设置 Options.GlobalSequence
+// （或为多张表的 TableOptions.Sequence 指定同一个 Sequence）后，这些表从同一个序列分配 _seq：
+// 任意两行的 _seq 不重复，且按插入的先后递增，合并多张表的变更时按 _seq 排序即可得到跨表的全序。
+// 每张表的 _seq 因此不再连续。
+type Sequence struct {
+	last atomic.Int64 // 最后分配的序列号
+}
+
+// NewSequence 创建从 last+1 开始分配的 Sequence
+func NewSequence(last int64) *Sequence {
+	s := &Sequence{}
+	s.last.Store(last)
+	return s
+}
+
+// Last 返回最后分配的序列号
+func (s *Sequence) Last() int64 {
+	return s.last.Load()
+}
+
+// next 分配下一个序列号
+func (s *Sequence) next() int64 {
+	return s.last.Add(1)
+}
+
+// observe 确保之后分配的序列号大于 seq（打开表时传入表中已有的最大 seq）
+func (s *Sequence) observe(seq int64) {
+	for {
+		last := s.last.Load()
+		if seq <= last || s.last.CompareAndSwap(last, seq) {
+			return
+		}
+	}
+}
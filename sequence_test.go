@@ -0,0 +1,98 @@
+package srdb
+
+import "testing"
+
+func TestGlobalSequence(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultOptions(dir)
+	opts.GlobalSequence = true
+	db, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := NewSchema("t", []Field{{Name: "name", Type: String}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := db.CreateTable("orders", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := db.CreateTable("users", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	temp, err := db.CreateTable("temp", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 交替插入，_seq 跨表递增
+	tables := []*Table{orders, users, orders, temp, users}
+	for i, table := range tables {
+		if err := table.Insert(map[string]any{"name": "x"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := table.GetMaxSeq(); got != int64(i+1) {
+			t.Errorf("insert %d: expected _seq %d, got %d", i, i+1, got)
+		}
+	}
+	seqs, err := orders.Query().Seqs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 2 || seqs[0] != 1 || seqs[1] != 3 {
+		t.Errorf("expected orders seqs [1 3], got %v", seqs)
+	}
+
+	// 删除持有最大 _seq 的表后重新打开，序列不回退
+	if err := users.Insert(map[string]any{"name": "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DropTable("users"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if db, err = OpenWithOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if orders, err = db.GetTable("orders"); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Insert(map[string]any{"name": "z"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := orders.GetMaxSeq(); got != 7 {
+		t.Errorf("expected _seq 7 after reopen, got %d", got)
+	}
+}
+
+func TestTableSequenceDefault(t *testing.T) {
+	db, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema, err := NewSchema("t", []Field{{Name: "name", Type: String}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		table, err := db.CreateTable(name, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := table.Insert(map[string]any{"name": "x"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := table.GetMaxSeq(); got != 1 {
+			t.Errorf("table %s: expected per-table _seq 1, got %d", name, got)
+		}
+	}
+}
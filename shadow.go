@@ -0,0 +1,288 @@
+package srdb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultShadowQueueSize 等待镜像到影子表的写入数量上限
+const DefaultShadowQueueSize = 10000
+
+// ShadowOptions 影子写入的选项
+type ShadowOptions struct {
+	// QueueSize 等待镜像的写入数量上限，0 表示 DefaultShadowQueueSize；
+	// 队列已满时丢弃新的写入（计入 ShadowStats.Dropped），不阻塞主表
+	QueueSize int
+}
+
+// ShadowStats 影子写入的统计信息
+type ShadowStats struct {
+	Table     string        // 影子表名
+	Mirrored  int64         // 已写入影子表的插入、修改与删除
+	Pending   int           // 等待镜像的写入（包括正在写入的一条）
+	Lag       time.Duration // 最早一条等待镜像的写入已等待的时间，没有等待的写入时为 0
+	Dropped   int64         // 队列已满或取消影子表时丢弃的写入
+	Failed    int64         // 写入影子表失败（如影子表的 Schema 拒绝该行）
+	Skipped   int64         // 修改或删除的行在影子表中没有对应的行（在设置影子表之前写入，或插入未镜像成功）
+	LastError string        // 最近一次失败的原因
+}
+
+// shadowOp 一次等待镜像的写入
+type shadowOp struct {
+	entryType byte // WALEntryTypePut 或 WALEntryTypeDelete
+	seq       int64
+	insert    bool           // Put 是否为插入（否则为修改）
+	data      map[string]any // 写入主表的行数据（已转换类型），删除时为 nil
+	queued    int64          // 进入队列的时间（UnixNano）
+}
+
+// shadowWriter 将主表的写入按顺序异步写入影子表
+type shadowWriter struct {
+	target *Table
+	clock  Clock
+	limit  int
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queue     []shadowOp
+	inflight  int64 // 正在写入的一条进入队列的时间，0 表示没有
+	enqueued  int64 // 已加入队列的写入数量
+	processed int64 // 已处理完成的写入数量（WaitShadow 等待 processed 追上调用时的 enqueued）
+	closed    bool
+	done      chan struct{}
+
+	seqs map[int64]int64 // 主表 seq → 影子表 seq，只由写入 goroutine 访问
+
+	mirrored atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+	skipped  atomic.Int64
+	lastErr  atomic.Pointer[string]
+}
+
+// SetShadow 将之后的写入（Insert、批量写入、Update 与 Delete）异步镜像到影子表，nil 表示取消
+//
+// 用于切换前验证新的 Schema 或调优选项：读取仍然只访问主表，影子表在后台按写入顺序接收同样的数据，
+// 通过 ShadowStats 观察延迟与失败，WaitShadow 等待已有的写入镜像完成后再比对两张表。
+// 写入影子表时只保留影子表 Schema 中的字段（计算列由影子表重新计算），_seq 与 _time 由影子表重新分配；
+// 主表与影子表 seq 的对应关系只保存在内存中，设置影子表之前写入的行（以及重新打开之后）的修改与删除计入 Skipped。
+// Clean、批量导入（BulkLoad）与 Compaction 不会镜像。
+//
+// 替换或取消影子表以及主表关闭时丢弃尚未镜像的写入（计入 Dropped），需要时先调用 WaitShadow。
+func (t *Table) SetShadow(shadow *Table, opts *ShadowOptions) error {
+	if shadow == t {
+		return NewErrorf(ErrCodeInvalidParam, "table %s cannot shadow itself", t.schema.Load().Name)
+	}
+	var w *shadowWriter
+	if shadow != nil {
+		limit := DefaultShadowQueueSize
+		if opts != nil && opts.QueueSize > 0 {
+			limit = opts.QueueSize
+		}
+		w = newShadowWriter(shadow, t.clock, limit)
+		go w.run()
+	}
+	if old := t.shadow.Swap(w); old != nil {
+		old.stop()
+	}
+	return nil
+}
+
+// newShadowWriter 创建写入器，调用者负责启动 run
+func newShadowWriter(target *Table, clock Clock, limit int) *shadowWriter {
+	w := &shadowWriter{
+		target: target,
+		clock:  clock,
+		limit:  limit,
+		done:   make(chan struct{}),
+		seqs:   make(map[int64]int64),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	return w
+}
+
+// ShadowStats 返回影子写入的统计信息，没有设置影子表时第二个返回值为 false
+func (t *Table) ShadowStats() (ShadowStats, bool) {
+	w := t.shadow.Load()
+	if w == nil {
+		return ShadowStats{}, false
+	}
+	return w.stats(), true
+}
+
+// WaitShadow 等待调用之前的写入全部镜像到影子表（或被丢弃），没有设置影子表时立即返回
+func (t *Table) WaitShadow(ctx context.Context) error {
+	w := t.shadow.Load()
+	if w == nil {
+		return nil
+	}
+	return w.wait(ctx)
+}
+
+// mirror 将一次写入加入影子表的队列（没有设置影子表时不做任何事）
+func (t *Table) mirror(op shadowOp) {
+	if w := t.shadow.Load(); w != nil {
+		w.enqueue(op)
+	}
+}
+
+// enqueue 加入队列，队列已满或已停止时丢弃
+func (w *shadowWriter) enqueue(op shadowOp) {
+	op.queued = w.clock.Now().UnixNano()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || len(w.queue) >= w.limit {
+		w.dropped.Add(1)
+		return
+	}
+	w.queue = append(w.queue, op)
+	w.enqueued++
+	w.cond.Broadcast()
+}
+
+// run 按顺序写入影子表，直到 stop
+func (w *shadowWriter) run() {
+	defer close(w.done)
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.closed {
+			w.mu.Unlock()
+			return
+		}
+		op := w.queue[0]
+		w.queue[0] = shadowOp{}
+		w.queue = w.queue[1:]
+		w.inflight = op.queued
+		w.mu.Unlock()
+
+		w.apply(op)
+
+		w.mu.Lock()
+		w.inflight = 0
+		w.processed++
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// apply 将一次写入应用到影子表
+func (w *shadowWriter) apply(op shadowOp) {
+	var err error
+	switch {
+	case op.entryType == WALEntryTypeDelete:
+		target, ok := w.seqs[op.seq]
+		if !ok {
+			w.skipped.Add(1)
+			return
+		}
+		delete(w.seqs, op.seq)
+		err = w.target.Delete(target)
+
+	case op.insert:
+		var seq int64
+		if seq, err = w.target.insertBatch(context.Background(), []map[string]any{w.project(op.data)}); err == nil {
+			w.seqs[op.seq] = seq
+		}
+
+	default:
+		target, ok := w.seqs[op.seq]
+		if !ok {
+			w.skipped.Add(1)
+			return
+		}
+		err = w.target.Update(target, w.project(op.data))
+	}
+
+	if err != nil {
+		w.failed.Add(1)
+		msg := err.Error()
+		w.lastErr.Store(&msg)
+		return
+	}
+	w.mirrored.Add(1)
+}
+
+// project 只保留影子表 Schema 中可以写入的字段（不包括计算列与已弃用的字段）
+func (w *shadowWriter) project(data map[string]any) map[string]any {
+	row := make(map[string]any, len(data))
+	for name, value := range data {
+		field, err := w.target.schema.Load().GetField(name)
+		if err != nil || field.Computed != "" || w.target.isDeprecated(name) {
+			continue
+		}
+		row[name] = value
+	}
+	return row
+}
+
+// stop 停止写入 goroutine，丢弃尚未镜像的写入
+func (w *shadowWriter) stop() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	w.dropped.Add(int64(len(w.queue)))
+	w.queue = nil
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-w.done
+}
+
+// wait 等待调用时已在队列中的写入完成
+func (w *shadowWriter) wait(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		w.mu.Lock()
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	})
+	defer stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	target := w.enqueued
+	for w.processed < target {
+		if w.closed {
+			return NewErrorf(ErrCodeClosed, "shadow writer for table %s stopped", w.target.schema.Load().Name)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		w.cond.Wait()
+	}
+	return nil
+}
+
+// stats 返回统计信息
+func (w *shadowWriter) stats() ShadowStats {
+	s := ShadowStats{
+		Table:    w.target.schema.Load().Name,
+		Mirrored: w.mirrored.Load(),
+		Dropped:  w.dropped.Load(),
+		Failed:   w.failed.Load(),
+		Skipped:  w.skipped.Load(),
+	}
+	if msg := w.lastErr.Load(); msg != nil {
+		s.LastError = *msg
+	}
+
+	w.mu.Lock()
+	s.Pending = len(w.queue)
+	oldest := w.inflight
+	if oldest != 0 {
+		s.Pending++
+	} else if len(w.queue) > 0 {
+		oldest = w.queue[0].queued
+	}
+	w.mu.Unlock()
+	if oldest != 0 {
+		s.Lag = max(0, time.Duration(w.clock.Now().UnixNano()-oldest))
+	}
+	return s
+}
@@ -0,0 +1,133 @@
+package srdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShadowWrites(t *testing.T) {
+	clock := NewManualClock(time.Unix(1700000000, 0))
+	primary, err := OpenTable(&TableOptions{
+		Dir:   t.TempDir(),
+		Name:  "users",
+		Clock: clock,
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "age", Type: Int64},
+			{Name: "nickname", Type: String, Nullable: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+
+	// 影子表去掉了 nickname，age 改为索引字段
+	shadow, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "users_v2",
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "age", Type: Int64, Indexed: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shadow.Close()
+
+	// 设置影子表之前写入的行不会镜像
+	if err := primary.Insert(map[string]any{"name": "before", "age": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.SetShadow(shadow, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		if err := primary.Insert(map[string]any{"name": "user", "age": int64(20 + i), "nickname": "n"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := primary.Update(2, map[string]any{"age": int64(99)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := primary.WaitShadow(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stats, ok := primary.ShadowStats()
+	if !ok {
+		t.Fatal("expected shadow stats")
+	}
+	if stats.Table != "users_v2" || stats.Mirrored != 12 || stats.Skipped != 1 || stats.Pending != 0 || stats.Lag != 0 || stats.Failed != 0 {
+		t.Errorf("unexpected shadow stats %+v", stats)
+	}
+
+	// 影子表与主表的数据一致（不包括影子表中不存在的字段）
+	if n, _ := shadow.Query().Count(); n != 9 {
+		t.Errorf("expected 9 rows in the shadow table, got %d", n)
+	}
+	if n, _ := shadow.Query().Eq("age", 99).Count(); n != 1 {
+		t.Errorf("expected the update to be mirrored, got %d rows", n)
+	}
+	if n, _ := shadow.Query().Eq("age", 21).Count(); n != 0 {
+		t.Errorf("expected the delete to be mirrored, got %d rows", n)
+	}
+	if row, err := shadow.Query().First(); err != nil || row.Data()["nickname"] != nil {
+		t.Errorf("expected fields missing from the shadow schema to be dropped, got %v %v", row, err)
+	}
+
+	// 影子表拒绝的行计入 Failed，不影响主表
+	if err := shadow.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.Insert(map[string]any{"name": "late", "age": 50}); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.WaitShadow(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := primary.ShadowStats(); stats.Failed != 1 || stats.LastError == "" {
+		t.Errorf("expected a failed mirror, got %+v", stats)
+	}
+
+	// 取消后不再镜像
+	if err := primary.SetShadow(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := primary.ShadowStats(); ok {
+		t.Error("expected no shadow after SetShadow(nil)")
+	}
+	if err := primary.SetShadow(primary, nil); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for a self shadow, got %v", err)
+	}
+}
+
+func TestShadowQueueFull(t *testing.T) {
+	clock := NewManualClock(time.Unix(1700000000, 0))
+	target, err := OpenTable(&TableOptions{Dir: t.TempDir(), Name: "target", Fields: []Field{{Name: "v", Type: Int64}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	// 不启动写入 goroutine，观察队列与延迟
+	w := newShadowWriter(target, clock, 2)
+	for seq := range int64(3) {
+		w.enqueue(shadowOp{entryType: WALEntryTypePut, seq: seq + 1, insert: true, data: map[string]any{"v": seq}})
+		clock.Advance(time.Second)
+	}
+	stats := w.stats()
+	if stats.Pending != 2 || stats.Dropped != 1 || stats.Lag != 3*time.Second {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,194 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Snappy 块格式（https://github.com/google/snappy/blob/main/format_description.txt）的编解码
+//
+// 块以未压缩长度（uvarint）开头，随后是字面量与回溯复制元素，元素标签的低 2 位表示类型。
+// 编码器使用 4 字节哈希查找匹配，压缩率略低于官方实现，但输出可被任何 Snappy 解码器解码。
+
+const (
+	snappyTagLiteral = 0x00
+	snappyTagCopy1   = 0x01
+	snappyTagCopy2   = 0x02
+	snappyTagCopy4   = 0x03
+
+	snappyHashBits = 14
+
+	// snappyMinCompressSize 小于此长度的输入只输出字面量
+	snappyMinCompressSize = 17
+)
+
+var errSnappyCorrupt = errors.New("snappy: corrupt input")
+
+// snappyEncode 压缩 src，返回 Snappy 块格式数据
+func snappyEncode(src []byte) []byte {
+	dst := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(src)+len(src)/6+32)
+	dst = dst[:binary.PutUvarint(dst, uint64(len(src)))]
+
+	if len(src) < snappyMinCompressSize {
+		return snappyEmitLiteral(dst, src)
+	}
+
+	var table [1 << snappyHashBits]int32 // 位置 + 1，0 表示空
+	nextEmit := 0
+	s := 0
+	for s+4 <= len(src) {
+		cur := binary.LittleEndian.Uint32(src[s:])
+		h := snappyHash(cur)
+		candidate := int(table[h]) - 1
+		table[h] = int32(s + 1)
+
+		if candidate < 0 || binary.LittleEndian.Uint32(src[candidate:]) != cur {
+			// 没有匹配：连续未命中时跳跃前进，加快不可压缩数据的处理
+			s += 1 + (s-nextEmit)>>5
+			continue
+		}
+
+		dst = snappyEmitLiteral(dst, src[nextEmit:s])
+
+		// 向后扩展匹配
+		base := s
+		s += 4
+		for i := candidate + 4; s < len(src) && src[s] == src[i]; i++ {
+			s++
+		}
+		dst = snappyEmitCopy(dst, base-candidate, s-base)
+		nextEmit = s
+
+		if s+3 <= len(src) {
+			table[snappyHash(binary.LittleEndian.Uint32(src[s-1:]))] = int32(s)
+		}
+	}
+
+	return snappyEmitLiteral(dst, src[nextEmit:])
+}
+
+func snappyHash(v uint32) uint32 {
+	return (v * 0x1e35a7bd) >> (32 - snappyHashBits)
+}
+
+// snappyEmitLiteral 追加字面量元素
+func snappyEmitLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := uint32(len(lit) - 1)
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2|snappyTagLiteral)
+	case n < 1<<8:
+		dst = append(dst, 60<<2|snappyTagLiteral, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2|snappyTagLiteral, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(dst, lit...)
+}
+
+// snappyEmitCopy 追加回溯复制元素（length >= 4）
+func snappyEmitCopy(dst []byte, offset, length int) []byte {
+	for length >= 68 {
+		dst = snappyEmitCopyN(dst, offset, 64)
+		length -= 64
+	}
+	if length > 64 {
+		dst = snappyEmitCopyN(dst, offset, 60)
+		length -= 60
+	}
+	if length < 12 && offset < 2048 {
+		return append(dst, byte(offset>>8)<<5|byte(length-4)<<2|snappyTagCopy1, byte(offset))
+	}
+	return snappyEmitCopyN(dst, offset, length)
+}
+
+// snappyEmitCopyN 使用 2 或 4 字节偏移的复制元素（1 <= length <= 64）
+func snappyEmitCopyN(dst []byte, offset, length int) []byte {
+	if offset < 1<<16 {
+		return append(dst, byte(length-1)<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+	}
+	return append(dst, byte(length-1)<<2|snappyTagCopy4, byte(offset), byte(offset>>8), byte(offset>>16), byte(offset>>24))
+}
+
+// snappyDecode 解压 Snappy 块格式数据
+func snappyDecode(src []byte) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 || n > uint64(len(src))*256 || n > 1<<32 {
+		return nil, errSnappyCorrupt
+	}
+	src = src[k:]
+	dst := make([]byte, 0, n)
+
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+
+		switch tag & 0x03 {
+		case snappyTagLiteral:
+			x := int(tag >> 2)
+			src = src[1:]
+			if x >= 60 {
+				size := x - 59
+				if len(src) < size {
+					return nil, errSnappyCorrupt
+				}
+				x = 0
+				for i := size - 1; i >= 0; i-- {
+					x = x<<8 | int(src[i])
+				}
+				src = src[size:]
+			}
+			length = x + 1
+			if length > len(src) || len(dst)+length > int(n) {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+
+		case snappyTagCopy1:
+			if len(src) < 2 {
+				return nil, errSnappyCorrupt
+			}
+			length = 4 + int(tag>>2)&0x07
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+
+		case snappyTagCopy2:
+			if len(src) < 3 {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+
+		case snappyTagCopy4:
+			if len(src) < 5 {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+
+		if offset <= 0 || offset > len(dst) || len(dst)+length > int(n) {
+			return nil, errSnappyCorrupt
+		}
+		// 偏移小于长度时源与目标重叠，需要逐字节复制
+		start := len(dst) - offset
+		for i := range length {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if len(dst) != int(n) {
+		return nil, errSnappyCorrupt
+	}
+	return dst, nil
+}
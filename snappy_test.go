@@ -0,0 +1,67 @@
+package srdb
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSnappyRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, 100000)
+	rng.Read(random)
+
+	// 重复距离超过 64KB，需要 4 字节偏移
+	far := append(bytes.Clone(random[:70000]), random[:5000]...)
+
+	inputs := map[string][]byte{
+		"empty":      {},
+		"short":      []byte("abc"),
+		"threshold":  []byte(strings.Repeat("x", snappyMinCompressSize)),
+		"repeated":   []byte(strings.Repeat("a", 100000)),
+		"text":       []byte(strings.Repeat(`{"user":"alice","action":"login","ok":true}`, 500)),
+		"random":     random,
+		"far copy":   far,
+		"overlap":    []byte("abcabcabcabcabcabcabcabcabcabcabcabc"),
+		"mixed tail": append([]byte(strings.Repeat("0123456789", 50)), random[:300]...),
+	}
+
+	for name, src := range inputs {
+		t.Run(name, func(t *testing.T) {
+			encoded := snappyEncode(src)
+			decoded, err := snappyDecode(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, src) {
+				t.Fatalf("round trip mismatch (len %d vs %d)", len(decoded), len(src))
+			}
+		})
+	}
+
+	if n := len(snappyEncode(inputs["repeated"])); n > 5000 {
+		t.Errorf("expected repeated data to compress well, got %d bytes", n)
+	}
+	if n := len(snappyEncode(random)); n > len(random)+len(random)/100 {
+		t.Errorf("random data expanded too much: %d bytes", n)
+	}
+}
+
+func TestSnappyDecodeCorrupt(t *testing.T) {
+	valid := snappyEncode([]byte(strings.Repeat("hello world ", 100)))
+
+	inputs := map[string][]byte{
+		"empty":        {},
+		"truncated":    valid[:len(valid)-1],
+		"bad offset":   {0x08, 0x01 | 0x00<<2, 0x05},
+		"long literal": {0x02, 0x08, 'a'},
+		"short output": {0x05, 0x00, 'a'},
+		"huge length":  {0xff, 0xff, 0xff, 0xff, 0x0f},
+	}
+	for name, src := range inputs {
+		if _, err := snappyDecode(src); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package srdb
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync/atomic"
+)
+
+// errSnapshotReleased 快照已释放
+var errSnapshotReleased = NewErrorf(ErrCodeClosed, "snapshot is released")
+
+// Snapshot 表在某个 seq 上的一致性视图（见 Table.Snapshot）
+//
+// 快照固定创建时的 MemTable 与 SST 文件：之后插入的行不可见，之后的 Update/Delete 也不可见，
+// 被 compaction 移除的文件保留到快照释放。并发安全，可以在多个 goroutine 中同时查询。
+type Snapshot struct {
+	table     *Table
+	seq       int64           // seq <= seq 的行可见
+	epoch     int64           // 创建时的表 epoch，表被清空后快照失效
+	sst       *SSTableManager // 固定文件所属的管理器
+	memtables []*MemTable     // Active 的冻结副本，之后是 Immutable（从新到旧）
+	readers   []*SSTableReader
+
+	refs     atomic.Int64 // 调用者持有一个引用，进行中的查询各持有一个
+	released atomic.Bool
+}
+
+// Snapshot 创建表的快照，之后通过 Snapshot.Query 读取的数据都来自创建时的同一个视图
+//
+// 适合长时间的导出或对账：导出期间的插入、修改与删除都不可见，同一个快照上的多次查询结果一致。
+//
+//	snap, err := table.Snapshot()
+//	if err != nil {
+//	    return err
+//	}
+//	defer snap.Release()
+//
+//	total, _ := snap.Query().Count()
+//	rows, _ := snap.Query().Rows()
+//
+// 快照持有 Active MemTable 的副本以及创建时的所有 SST 文件，compaction 不会删除这些文件，
+// 因此使用完毕后必须调用 Release。快照上的查询不使用二级索引（按 _seq 以外的字段排序返回错误），
+// 也不支持 Tail；表被 Clean 后快照上的查询返回 ErrTableReset。
+func (t *Table) Snapshot() (*Snapshot, error) {
+	epoch := t.epoch.Load()
+	done, err := t.beginRead(epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// 固定数据源期间阻止 Update/Delete：同一行的新旧版本不会一部分被固定、一部分没有
+	t.mutateMu.Lock()
+	defer t.mutateMu.Unlock()
+
+	// 水位以下的行都已写入 MemTable；先固定 MemTable 再固定 SST，
+	// 期间 flush 的行同时出现在两者中（读取时先查 MemTable），不会遗漏
+	s := &Snapshot{
+		table: t,
+		seq:   t.durability.watermark(),
+		epoch: epoch,
+		sst:   t.sstManager,
+	}
+	s.memtables = t.memtableManager.snapshot()
+	s.readers = t.sstManager.pin()
+	s.refs.Store(1)
+
+	t.logs.get(LogQuery).Debug("[Snapshot] Created",
+		"table", t.schema.Load().Name,
+		"seq", s.seq,
+		"sst_files", len(s.readers))
+	return s, nil
+}
+
+// Seq 返回快照的 seq，seq 不大于它的行可见
+func (s *Snapshot) Seq() int64 {
+	return s.seq
+}
+
+// Query 在快照上创建查询
+func (s *Snapshot) Query() *QueryBuilder {
+	qb := newQueryBuilder(s.table)
+	qb.snap = s
+	return qb
+}
+
+// Get 读取快照内的一行，行在快照之后插入或在快照之前已被删除时返回 ErrCodeNotFound
+func (s *Snapshot) Get(seq int64) (*SSTableRow, error) {
+	if !s.acquire() {
+		return nil, errSnapshotReleased
+	}
+	defer s.release()
+
+	done, err := s.table.beginRead(s.epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return s.getWithPriority(PriorityHigh, seq)
+}
+
+// Release 释放快照，进行中的查询结束后其固定的文件可以被删除；重复调用无副作用
+func (s *Snapshot) Release() {
+	if s.released.CompareAndSwap(false, true) {
+		s.release()
+	}
+}
+
+// acquire 为一次查询增加引用，快照已释放时返回 false（s 为 nil 时总是成功）
+func (s *Snapshot) acquire() bool {
+	if s == nil {
+		return true
+	}
+	if s.released.Load() {
+		return false
+	}
+	for {
+		n := s.refs.Load()
+		if n <= 0 {
+			return false
+		}
+		if s.refs.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// retain 为已持有引用的调用者增加一个引用（交给惰性读取的结果集）
+func (s *Snapshot) retain() *Snapshot {
+	if s != nil {
+		s.refs.Add(1)
+	}
+	return s
+}
+
+// release 减少引用，最后一个引用释放时解除对 SST 文件的固定
+func (s *Snapshot) release() {
+	if s == nil {
+		return
+	}
+	if s.refs.Add(-1) == 0 {
+		s.sst.unpin(s.readers)
+		s.memtables = nil
+		s.readers = nil
+	}
+}
+
+// memtableGet 按 Active → Immutable（从新到旧）的顺序查找行编码
+func (s *Snapshot) memtableGet(seq int64) ([]byte, bool) {
+	for _, m := range s.memtables {
+		if data, found := m.Get(seq); found {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// latest 返回固定的文件中 seq 版本最新的一个（同 SSTableManager.latest）
+func (s *Snapshot) latest(seq int64) *SSTableReader {
+	var best *SSTableReader
+	var bestTime int64
+	for _, reader := range s.readers {
+		time, _, found := reader.rowVersion(seq)
+		if found && (best == nil || time > bestTime) {
+			best, bestTime = reader, time
+		}
+	}
+	return best
+}
+
+// getWithPriority 按指定优先级读取快照内的一行（同 Table.getWithPriority），调用者需持有读锁
+func (s *Snapshot) getWithPriority(priority QueryPriority, seq int64) (*SSTableRow, error) {
+	if seq > s.seq {
+		return nil, NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+	if priority == PriorityHigh {
+		done := s.table.scheduler.beginHigh()
+		defer done()
+	}
+
+	var row *SSTableRow
+	var err error
+	if data, found := s.memtableGet(seq); found {
+		row, err = decodeSSTableRowBinary(data, s.table.schema.Load())
+	} else {
+		if priority == PriorityLow {
+			s.table.scheduler.acquire()
+		}
+		reader := s.latest(seq)
+		if reader == nil {
+			return nil, NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
+		}
+		if row, err = reader.Get(seq); err != nil && !IsError(err, ErrCodeChecksumMismatch) {
+			return nil, NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if row.Deleted {
+		return nil, NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+	return row, nil
+}
+
+// getIntoWithPriority 读取快照内的一行并解码到 dst（同 Table.getIntoWithPriority）
+func (s *Snapshot) getIntoWithPriority(priority QueryPriority, seq int64, dst *SSTableRow) error {
+	if seq > s.seq {
+		return NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+	if priority == PriorityHigh {
+		done := s.table.scheduler.beginHigh()
+		defer done()
+	}
+
+	var err error
+	if data, found := s.memtableGet(seq); found {
+		err = decodeSSTableRowBinaryInto(data, s.table.schema.Load(), nil, dst)
+	} else {
+		if priority == PriorityLow {
+			s.table.scheduler.acquire()
+		}
+		reader := s.latest(seq)
+		if reader == nil {
+			return NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
+		}
+		err = reader.getInto(seq, dst)
+	}
+	if err == nil && dst.Deleted {
+		return NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+	return err
+}
+
+// exists 快照内 seq 的版本是否存在且未被删除（只读取行头）
+func (s *Snapshot) exists(seq int64) bool {
+	if seq > s.seq {
+		return false
+	}
+	if data, found := s.memtableGet(seq); found {
+		_, deleted, ok := rowVersion(data)
+		return ok && !deleted
+	}
+	if reader := s.latest(seq); reader != nil {
+		_, deleted, found := reader.rowVersion(seq)
+		return found && !deleted
+	}
+	return false
+}
+
+// keys 返回可能匹配 conds 且与时间范围相交的固定文件（按 MinKey 排序）及其 key（同 SSTableManager.snapshotKeys）
+func (s *Snapshot) keys(conds []Expr, window timeWindow, scan bool) ([]*SSTableReader, [][]int64) {
+	var readers []*SSTableReader
+	for _, reader := range s.readers {
+		if reader.mayMatch(conds) && window.overlaps(reader.header.MinTime, reader.header.MaxTime) {
+			readers = append(readers, reader)
+		}
+	}
+	keys := make([][]int64, len(readers))
+	for i, reader := range readers {
+		if scan {
+			reader.beginScan()
+		}
+		keys[i] = reader.GetAllKeys()
+	}
+	return readers, keys
+}
+
+// memtables 返回查询读取的 MemTable：快照查询为快照固定的 MemTable，否则为当前的 Active 与所有 Immutable
+func (qb *QueryBuilder) memtables() []*MemTable {
+	if qb.snap != nil {
+		return qb.snap.memtables
+	}
+	tables := []*MemTable{qb.table.memtableManager.GetActive()}
+	for _, imm := range qb.table.memtableManager.GetImmutables() {
+		tables = append(tables, imm.MemTable)
+	}
+	return tables
+}
+
+// sstKeys 固定查询读取的 SST 文件及其 key，同时返回被列统计信息或时间范围跳过的文件数
+func (qb *QueryBuilder) sstKeys(scan bool) ([]*SSTableReader, [][]int64, int) {
+	if qb.snap != nil {
+		readers, keys := qb.snap.keys(qb.conds, qb.window, scan)
+		return readers, keys, len(qb.snap.readers) - len(readers)
+	}
+	readers, keys := qb.table.sstManager.snapshotKeys(qb.conds, qb.window, scan)
+	return readers, keys, qb.table.sstManager.Count() - len(readers)
+}
+
+// readSeq 返回查询可见的最大 seq：快照查询为快照的 seq，否则为当前的 seq
+func (qb *QueryBuilder) readSeq() int64 {
+	if qb.snap != nil {
+		return qb.snap.seq
+	}
+	return qb.table.seq.Load()
+}
+
+// readEpoch 返回查询读取的表 epoch
+func (qb *QueryBuilder) readEpoch() int64 {
+	if qb.snap != nil {
+		return qb.snap.epoch
+	}
+	return qb.table.epoch.Load()
+}
+
+// getRow 按查询的优先级读取一行（快照查询读取快照内的版本）
+func (qb *QueryBuilder) getRow(seq int64) (*SSTableRow, error) {
+	if qb.snap != nil {
+		return qb.snap.getWithPriority(qb.priority, seq)
+	}
+	return qb.table.getWithPriority(qb.priority, seq)
+}
+
+// getRowInto 按查询的优先级读取一行并解码到 dst
+func (qb *QueryBuilder) getRowInto(seq int64, dst *SSTableRow) error {
+	if qb.snap != nil {
+		return qb.snap.getIntoWithPriority(qb.priority, seq, dst)
+	}
+	return qb.table.getIntoWithPriority(qb.priority, seq, dst)
+}
+
+// rawRow 读取一行的二进制编码（不检查删除标记）
+func (qb *QueryBuilder) rawRow(seq int64) ([]byte, error) {
+	var row *SSTableRow
+	var err error
+	if qb.snap != nil {
+		if data, found := qb.snap.memtableGet(seq); found {
+			return data, nil
+		}
+		reader := qb.snap.latest(seq)
+		if reader == nil {
+			return nil, NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
+		}
+		row, err = reader.Get(seq)
+	} else {
+		if data, found := qb.table.memtableManager.Get(seq); found {
+			return data, nil
+		}
+		row, err = qb.table.sstManager.Get(seq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return encodeSSTableRowBinary(row, qb.table.schema.Load())
+}
+
+// exists seq 在查询读取的视图中是否存在且未被删除
+func (qb *QueryBuilder) exists(seq int64) bool {
+	if qb.snap != nil {
+		return qb.snap.exists(seq)
+	}
+	return qb.table.exists(seq)
+}
+
+// snapshot 返回 Active 的冻结副本以及所有 Immutable（从新到旧）
+func (m *MemTableManager) snapshot() []*MemTable {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tables := []*MemTable{m.active.frozen()}
+	for i := len(m.immutables) - 1; i >= 0; i-- {
+		tables = append(tables, m.immutables[i].MemTable)
+	}
+	return tables
+}
+
+// frozen 返回只读副本：之后写入的行与修改后的版本不可见
+//
+// arena 中已写入的字节不会被修改，副本只复制位置与 slab 列表，不复制行数据
+func (m *MemTable) frozen() *MemTable {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return &MemTable{
+		data:      maps.Clone(m.data),
+		arena:     memArena{slabs: slices.Clone(m.arena.slabs), cur: m.arena.cur},
+		keys:      slices.Clone(m.keys),
+		size:      m.size,
+		rowBounds: m.rowBounds,
+	}
+}
+
+// pin 固定当前的所有 SST 文件（按 MinKey 排序），固定期间被 compaction 移除的文件保留到 unpin
+func (m *SSTableManager) pin() []*SSTableReader {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pins == nil {
+		m.pins = make(map[int64]int)
+	}
+	readers := slices.Clone(m.readers)
+	for _, reader := range readers {
+		if fileNumber, ok := reader.fileNumber(); ok {
+			m.pins[fileNumber]++
+		}
+	}
+	sort.Slice(readers, func(i, j int) bool {
+		return readers[i].header.MinKey < readers[j].header.MinKey
+	})
+	return readers
+}
+
+// unpin 解除 pin 对文件的固定，不再被引用的已移除文件被关闭并删除
+func (m *SSTableManager) unpin(readers []*SSTableReader) {
+	m.mu.Lock()
+	var removed []*SSTableReader
+	for _, reader := range readers {
+		fileNumber, ok := reader.fileNumber()
+		if !ok {
+			continue
+		}
+		if m.pins[fileNumber]--; m.pins[fileNumber] > 0 {
+			continue
+		}
+		delete(m.pins, fileNumber)
+		if retired, ok := m.retired[fileNumber]; ok {
+			delete(m.retired, fileNumber)
+			removed = append(removed, retired)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, reader := range removed {
+		reader.Close()
+		os.Remove(reader.path)
+	}
+}
+
+// retained 文件是否已被 compaction 移除、但仍被快照固定
+func (m *SSTableManager) retained(fileNumber int64) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.retired[fileNumber]
+	return ok
+}
+
+// fileNumber 从文件名中解析文件编号
+func (r *SSTableReader) fileNumber() (int64, bool) {
+	var fileNumber int64
+	if _, err := fmt.Sscanf(filepath.Base(r.path), "%d.sst", &fileNumber); err != nil {
+		return 0, false
+	}
+	return fileNumber, true
+}
@@ -0,0 +1,222 @@
+package srdb
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTableSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	table, err := OpenTable(&TableOptions{
+		Dir:    dir,
+		Name:   "events",
+		Fields: []Field{{Name: "host", Type: String, Indexed: true}, {Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { table.Close() }()
+
+	flush := func() {
+		t.Helper()
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for table.memtableManager.GetImmutableCount() > 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("flush did not finish")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	insert := func(from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			if err := table.Insert(map[string]any{"host": []string{"a", "b"}[i%2], "n": int64(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	values := func(qb *QueryBuilder) []int64 {
+		t.Helper()
+		rows, err := qb.Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var got []int64
+		for rows.Next() {
+			got = append(got, rows.Row().Data()["n"].(int64))
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	seqOf := func(n int64) int64 {
+		t.Helper()
+		rows, err := table.Query().Eq("n", n).Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		if !rows.Next() {
+			t.Fatalf("row %d not found", n)
+		}
+		return rows.Row().Seq()
+	}
+
+	// 10 行在 SST 中，10 行在 Active MemTable 中
+	insert(0, 10)
+	flush()
+	insert(10, 20)
+	want := make([]int64, 20)
+	for i := range want {
+		want[i] = int64(i)
+	}
+	inSST, inMem := seqOf(3), seqOf(15)
+	deletedSST, deletedMem := seqOf(4), seqOf(16)
+
+	snap, err := table.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+	pinned := table.sstManager.ListFiles()
+
+	// 快照之后的插入、修改与删除
+	insert(20, 25)
+	for _, seq := range []int64{inSST, inMem} {
+		if err := table.Update(seq, map[string]any{"n": int64(100)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, seq := range []int64{deletedSST, deletedMem} {
+		if err := table.Delete(seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(stage string) {
+		t.Helper()
+		if got := values(snap.Query()); !slices.Equal(got, want) {
+			t.Errorf("%s: unexpected snapshot rows: %v", stage, got)
+		}
+		if got := values(snap.Query().OrderByDesc("_seq").Limit(3)); !slices.Equal(got, []int64{19, 18, 17}) {
+			t.Errorf("%s: unexpected ordered snapshot rows: %v", stage, got)
+		}
+		// 索引反映最新的数据，快照查询不使用索引
+		if got := values(snap.Query().Eq("host", "a").Lt("n", int64(6))); !slices.Equal(got, []int64{0, 2, 4}) {
+			t.Errorf("%s: unexpected filtered snapshot rows: %v", stage, got)
+		}
+		if n, err := snap.Query().Count(); err != nil || n != 20 {
+			t.Errorf("%s: expected snapshot count 20, got %d %v", stage, n, err)
+		}
+		if sum, err := snap.Query().Sum("n"); err != nil || sum != int64(190) {
+			t.Errorf("%s: expected snapshot sum 190, got %v %v", stage, sum, err)
+		}
+		if seqs, err := snap.Query().Eq("host", "b").Seqs(); err != nil || len(seqs) != 10 {
+			t.Errorf("%s: expected 10 snapshot seqs, got %v %v", stage, seqs, err)
+		}
+		for _, seq := range []int64{inSST, inMem, deletedSST, deletedMem} {
+			if row, err := snap.Get(seq); err != nil || row.Data["n"] == int64(100) {
+				t.Errorf("%s: expected original row %d, got %v %v", stage, seq, row, err)
+			}
+		}
+		if _, err := snap.Get(snap.Seq() + 1); !IsError(err, ErrCodeNotFound) {
+			t.Errorf("%s: expected rows after the snapshot to be invisible, got %v", stage, err)
+		}
+
+		// 最新的数据不受快照影响
+		if n, err := table.Query().Count(); err != nil || n != 23 {
+			t.Errorf("%s: expected table count 23, got %d %v", stage, n, err)
+		}
+		if got := values(table.Query().Eq("n", int64(100))); len(got) != 2 {
+			t.Errorf("%s: expected 2 updated rows, got %v", stage, got)
+		}
+	}
+	check("memtable")
+
+	if _, err := snap.Query().OrderBy("host").Rows(); err == nil {
+		t.Error("expected OrderBy on an indexed field to fail on a snapshot")
+	}
+	if _, err := snap.Query().Tail(t.Context()); err == nil {
+		t.Error("expected Tail to fail on a snapshot")
+	}
+
+	// flush 并合并所有文件：快照固定的文件被保留
+	flush()
+	manager := table.GetCompactionManager()
+	if err := manager.DoCompaction(&CompactionTask{Level: 0, InputFiles: table.versionSet.GetCurrent().GetLevel(0), OutputLevel: 1}); err != nil {
+		t.Fatal(err)
+	}
+	manager.collectOrphanFiles()
+	for _, path := range pinned {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected pinned file %s to be kept: %v", path, err)
+		}
+	}
+	check("compaction")
+
+	// 释放快照后，进行中的查询仍然可以读取固定的文件
+	rows, err := snap.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap.Release()
+	snap.Release()
+	if _, err := snap.Query().Rows(); !IsError(err, ErrCodeClosed) {
+		t.Errorf("expected ErrCodeClosed after release, got %v", err)
+	}
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if count != 20 || rows.Err() != nil {
+		t.Errorf("expected 20 rows after release, got %d %v", count, rows.Err())
+	}
+	rows.Close()
+
+	for _, path := range pinned {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected file %s to be deleted after release, got %v", path, err)
+		}
+	}
+	if n := len(table.sstManager.retired); n != 0 {
+		t.Errorf("expected no retired readers, got %d", n)
+	}
+}
+
+func TestTableSnapshotReset(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(map[string]any{"n": int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := table.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+
+	if err := table.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := snap.Query().Count(); err != ErrTableReset {
+		t.Errorf("expected ErrTableReset after Clean, got %v", err)
+	}
+	if _, err := snap.Get(1); err != ErrTableReset {
+		t.Errorf("expected ErrTableReset from Get after Clean, got %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package sql
+
+import (
+	"context"
+	stdsql "database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hupeh/srdb"
+)
+
+// database/sql 驱动
+//
+// 通过数据目录打开（同一目录的所有连接共享一个 Database，最后一个连接关闭时关闭数据库）：
+//
+//	db, err := stdsql.Open("srdb", "./data")
+//	rows, err := db.QueryContext(ctx, "SELECT name FROM users WHERE age > ?", 30)
+//
+// 或者使用已经打开的 Database（关闭 sql.DB 不会关闭 Database）：
+//
+//	db := stdsql.OpenDB(sql.NewConnector(database))
+//
+// 不支持事务；结果中的整数统一为 int64，Decimal 为字符串，Object、Array 为 JSON。
+
+// DriverName 注册的驱动名
+const DriverName = "srdb"
+
+func init() {
+	stdsql.Register(DriverName, &Driver{})
+}
+
+// Driver database/sql 驱动，DSN 为数据目录
+type Driver struct{}
+
+// Open 打开到 dsn 目录的连接
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	connector, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(context.Background())
+}
+
+// OpenConnector 返回打开 dsn 目录的 Connector
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	dir, err := filepath.Abs(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &dirConnector{driver: d, dir: dir}, nil
+}
+
+// shared 按目录共享的已打开数据库
+var shared = struct {
+	sync.Mutex
+	dbs map[string]*sharedDB
+}{dbs: make(map[string]*sharedDB)}
+
+// sharedDB 被 refs 个连接使用的数据库
+type sharedDB struct {
+	db   *srdb.Database
+	refs int
+}
+
+// dirConnector 按目录打开（或复用）数据库
+type dirConnector struct {
+	driver *Driver
+	dir    string
+}
+
+func (c *dirConnector) Connect(context.Context) (driver.Conn, error) {
+	shared.Lock()
+	defer shared.Unlock()
+
+	s, ok := shared.dbs[c.dir]
+	if !ok {
+		db, err := srdb.Open(c.dir)
+		if err != nil {
+			return nil, err
+		}
+		s = &sharedDB{db: db}
+		shared.dbs[c.dir] = s
+	}
+	s.refs++
+	return &conn{db: s.db, release: func() error { return c.release(s) }}, nil
+}
+
+// release 释放一个连接，最后一个连接关闭时关闭数据库
+func (c *dirConnector) release(s *sharedDB) error {
+	shared.Lock()
+	defer shared.Unlock()
+
+	s.refs--
+	if s.refs > 0 {
+		return nil
+	}
+	delete(shared.dbs, c.dir)
+	return s.db.Close()
+}
+
+func (c *dirConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// NewConnector 返回使用已打开数据库的 Connector，用于 database/sql.OpenDB
+func NewConnector(db *srdb.Database) driver.Connector {
+	return &dbConnector{db: db}
+}
+
+// dbConnector 使用调用者管理生命周期的数据库
+type dbConnector struct {
+	db *srdb.Database
+}
+
+func (c *dbConnector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{db: c.db}, nil
+}
+
+func (c *dbConnector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+// errNoTransactions 不支持事务
+var errNoTransactions = errors.New("srdb/sql: transactions are not supported")
+
+// conn 一个连接（无状态，所有语句直接在数据库上执行）
+type conn struct {
+	db      *srdb.Database
+	release func() error // 非 nil 时关闭连接需要释放共享的数据库
+	closed  bool
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	if c.closed || c.release == nil {
+		c.closed = true
+		return nil
+	}
+	c.closed = true
+	return c.release()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, errNoTransactions
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := c.exec(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return result{rowsAffected: res.RowsAffected}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := c.exec(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{result: res}, nil
+}
+
+// exec 按位置绑定参数执行语句（不支持命名参数）
+func (c *conn) exec(ctx context.Context, query string, named []driver.NamedValue) (*Result, error) {
+	args := make([]any, len(named))
+	for i, arg := range named {
+		if arg.Name != "" {
+			return nil, fmt.Errorf("srdb/sql: named argument %s is not supported", arg.Name)
+		}
+		args[i] = arg.Value
+	}
+	return Exec(ctx, c.db, query, args...)
+}
+
+// stmt 预处理语句（每次执行时解析）
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput 返回 -1：参数数量在执行时检查
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// result INSERT 的执行结果
+type result struct {
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return 0, errors.New("srdb/sql: LastInsertId is not supported")
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// rows 已读取完的查询结果
+type rows struct {
+	result *Result
+	next   int
+}
+
+func (r *rows) Columns() []string {
+	return r.result.Columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Rows) {
+		return io.EOF
+	}
+	row := r.result.Rows[r.next]
+	r.next++
+	for i, value := range row {
+		v, err := driverValue(value)
+		if err != nil {
+			return fmt.Errorf("srdb/sql: column %s: %w", r.result.Columns[i], err)
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+// driverValue 将字段值转换为 database/sql 支持的类型
+func driverValue(value any) (driver.Value, error) {
+	switch v := value.(type) {
+	case nil, int64, float64, bool, []byte, string, time.Time:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint:
+		return uintValue(uint64(v)), nil
+	case uint64:
+		return uintValue(v), nil
+	case float32:
+		return float64(v), nil
+	case time.Duration:
+		return int64(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return json.Marshal(value)
+}
+
+// uintValue 超出 int64 范围的无符号整数以十进制字符串返回
+func uintValue(v uint64) driver.Value {
+	if v > math.MaxInt64 {
+		return strconv.FormatUint(v, 10)
+	}
+	return int64(v)
+}
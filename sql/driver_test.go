@@ -0,0 +1,118 @@
+package sql
+
+import (
+	stdsql "database/sql"
+	"testing"
+	"time"
+
+	"github.com/hupeh/srdb"
+)
+
+func TestDriver(t *testing.T) {
+	dir := t.TempDir()
+	db, err := stdsql.Open(DriverName, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE events (kind string INDEX, size uint16, took duration, meta json)"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := db.Exec("INSERT INTO events VALUES (?, ?, ?, NULL), ('stop', 7, 0, NULL)", "start", 512, int64(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 {
+		t.Errorf("expected 2 rows affected, got %d", n)
+	}
+
+	rows, err := db.Query("SELECT kind, size, took FROM events WHERE size > ? ORDER BY kind", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var kinds []string
+	for rows.Next() {
+		var kind string
+		var size int
+		var took time.Duration
+		if err := rows.Scan(&kind, &size, &took); err != nil {
+			t.Fatal(err)
+		}
+		kinds = append(kinds, kind)
+		if kind == "start" && (size != 512 || took != time.Second) {
+			t.Errorf("unexpected start row: size=%d took=%v", size, took)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(kinds) != 2 || kinds[0] != "start" || kinds[1] != "stop" {
+		t.Errorf("unexpected kinds %v", kinds)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT size FROM events WHERE kind = ?", "stop").Scan(&count); err != nil || count != 7 {
+		t.Errorf("expected 7, got %d (%v)", count, err)
+	}
+
+	if _, err := db.Begin(); err == nil {
+		t.Error("expected transactions to be unsupported")
+	}
+}
+
+func TestDriverConnector(t *testing.T) {
+	database, err := srdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	db := stdsql.OpenDB(NewConnector(database))
+	if _, err := db.Exec("CREATE TABLE kv (k string, v string)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO kv VALUES ('a', 'b')"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// 关闭 sql.DB 不关闭 Database
+	table, err := database.GetTable("kv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row, err := table.Query().Eq("k", "a").First(); err != nil || row.Data()["v"] != "b" {
+		t.Errorf("expected the row to be readable after closing sql.DB: %v", err)
+	}
+}
+
+func TestDriverValue(t *testing.T) {
+	for _, c := range []struct {
+		in   any
+		want any
+	}{
+		{int32(-3), int64(-3)},
+		{uint64(1 << 63), "9223372036854775808"},
+		{float32(1.5), 1.5},
+		{time.Minute, int64(time.Minute)},
+		{map[string]any{"a": 1}, []byte(`{"a":1}`)},
+	} {
+		got, err := driverValue(c.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, ok := got.([]byte); ok {
+			got = []byte(s)
+			if string(s) != string(c.want.([]byte)) {
+				t.Errorf("driverValue(%v) = %s, want %s", c.in, s, c.want)
+			}
+			continue
+		}
+		if got != c.want {
+			t.Errorf("driverValue(%v) = %v (%T), want %v", c.in, got, got, c.want)
+		}
+	}
+}
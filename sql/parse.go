@@ -0,0 +1,826 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hupeh/srdb"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokSymbol // 标点与比较运算符
+	tokParam  // ? 占位符
+)
+
+// token 词法单元，start/end 为在语句中的字节位置（用于截取 SELECT 列的原文）
+type token struct {
+	kind       tokenKind
+	text       string
+	start, end int
+}
+
+// is 判断是否为指定的关键字（不区分大小写）或符号
+func (t token) is(text string) bool {
+	switch t.kind {
+	case tokIdent:
+		return strings.EqualFold(t.text, text)
+	case tokSymbol:
+		return t.text == text
+	}
+	return false
+}
+
+// tokenize 将语句拆分为词法单元
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			// 行注释
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			continue
+		case isIdentStart(c):
+			for i < len(query) && isIdentPart(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: query[start:i], start: start, end: i})
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			for i < len(query) && (query[i] >= '0' && query[i] <= '9' || query[i] == '.') {
+				i++
+			}
+			// 指数部分：1e3、2.5E-4
+			if i < len(query) && (query[i] == 'e' || query[i] == 'E') {
+				j := i + 1
+				if j < len(query) && (query[j] == '+' || query[j] == '-') {
+					j++
+				}
+				if j < len(query) && query[j] >= '0' && query[j] <= '9' {
+					i = j
+					for i < len(query) && query[i] >= '0' && query[i] <= '9' {
+						i++
+					}
+				}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: query[start:i], start: start, end: i})
+		case c == '\'':
+			// 字符串字面量，'' 表示一个单引号
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(query) {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						b.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteByte(query[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokString, text: b.String(), start: start, end: i})
+		case c == '?':
+			i++
+			tokens = append(tokens, token{kind: tokParam, text: "?", start: start, end: i})
+		default:
+			if i+1 < len(query) {
+				switch two := query[i : i+2]; two {
+				case "!=", "<>", "<=", ">=":
+					i += 2
+					tokens = append(tokens, token{kind: tokSymbol, text: two, start: start, end: i})
+					continue
+				}
+			}
+			if !strings.ContainsRune("(),*;=<>+-/%", rune(c)) {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokSymbol, text: string(c), start: start, end: i})
+		}
+	}
+	return append(tokens, token{kind: tokEOF, start: len(query), end: len(query)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// statement 解析后的语句
+type statement interface{}
+
+// createTableStmt CREATE TABLE [IF NOT EXISTS] name (column type [NOT NULL] [INDEX] [COMMENT '...'], ...)
+type createTableStmt struct {
+	name        string
+	ifNotExists bool
+	fields      []srdb.Field
+}
+
+// insertStmt INSERT INTO name [(columns)] VALUES (...), ...
+type insertStmt struct {
+	table   string
+	columns []string // 为空表示按 Schema 字段顺序
+	rows    [][]any
+}
+
+// selectStmt SELECT items FROM name [WHERE ...] [ORDER BY column [ASC|DESC]] [LIMIT n [OFFSET m]]
+type selectStmt struct {
+	table   string
+	items   []selectItem
+	where   srdb.Expr
+	orderBy string
+	desc    bool
+	limit   int // -1 表示不限制
+	offset  int
+}
+
+// selectItem SELECT 中的一列：* 或原样传给 QueryBuilder.Select 的字段、计算列
+type selectItem struct {
+	star bool
+	text string // 原文（字段名或表达式，可带 AS 别名）
+	name string // 结果中的列名：别名或原文
+}
+
+// parser 递归下降解析器，占位符在解析时按顺序绑定参数
+type parser struct {
+	query  string
+	tokens []token
+	pos    int
+	args   []any
+	used   int
+
+	db     *srdb.Database // 用于查找 SELECT 的表，nil 时 WHERE 中的值不做转换
+	schema *srdb.Schema   // SELECT 的表的 Schema，表不存在时为 nil
+}
+
+// parse 解析一条语句并绑定参数，WHERE 中的值按 db 中表的列类型转换
+func parse(query string, args []any, db *srdb.Database) (statement, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{query: query, tokens: tokens, args: args, db: db}
+
+	var stmt statement
+	switch first := p.peek(); {
+	case first.is("SELECT"):
+		stmt, err = p.parseSelect()
+	case first.is("INSERT"):
+		stmt, err = p.parseInsert()
+	case first.is("CREATE"):
+		stmt, err = p.parseCreateTable()
+	default:
+		return nil, p.errorf("expected SELECT, INSERT or CREATE TABLE")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.accept(";")
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected %q after statement", p.peek().text)
+	}
+	if p.used != len(p.args) {
+		return nil, fmt.Errorf("statement has %d placeholders, got %d arguments", p.used, len(p.args))
+	}
+	return stmt, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept 当前单元为 text 时前进并返回 true
+func (p *parser) accept(text string) bool {
+	if p.peek().is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %s", text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	tok := p.peek()
+	near := tok.text
+	if tok.kind == tokEOF {
+		near = "end of statement"
+	}
+	return fmt.Errorf("syntax error near %q at position %d: %s", near, tok.start, fmt.Sprintf(format, args...))
+}
+
+// ident 读取标识符
+func (p *parser) ident(what string) (string, error) {
+	tok := p.peek()
+	if tok.kind != tokIdent {
+		return "", p.errorf("expected %s", what)
+	}
+	p.pos++
+	return tok.text, nil
+}
+
+// parseCreateTable 解析 CREATE TABLE
+//
+// 列默认允许 NULL（与 SQL 一致），NOT NULL 的列缺失时写入零值；INDEX 为该列建立二级索引。
+func (p *parser) parseCreateTable() (statement, error) {
+	p.next()
+	if err := p.expect("TABLE"); err != nil {
+		return nil, err
+	}
+	stmt := &createTableStmt{}
+	if p.accept("IF") {
+		if err := p.expect("NOT"); err != nil {
+			return nil, err
+		}
+		if err := p.expect("EXISTS"); err != nil {
+			return nil, err
+		}
+		stmt.ifNotExists = true
+	}
+	name, err := p.ident("table name")
+	if err != nil {
+		return nil, err
+	}
+	stmt.name = name
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		field, err := p.parseColumnDef()
+		if err != nil {
+			return nil, err
+		}
+		stmt.fields = append(stmt.fields, field)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// parseColumnDef 解析列定义：name type [NOT NULL | NULL] [INDEX] [COMMENT 'text']
+func (p *parser) parseColumnDef() (srdb.Field, error) {
+	name, err := p.ident("column name")
+	if err != nil {
+		return srdb.Field{}, err
+	}
+	typeName, err := p.ident("column type")
+	if err != nil {
+		return srdb.Field{}, err
+	}
+	typ, ok := lookupType(typeName)
+	if !ok {
+		p.pos--
+		return srdb.Field{}, p.errorf("unknown column type %s", typeName)
+	}
+	// VARCHAR(255) 等类型参数不影响存储，忽略
+	if p.accept("(") {
+		for !p.accept(")") {
+			if p.next().kind == tokEOF {
+				return srdb.Field{}, p.errorf("expected )")
+			}
+		}
+	}
+
+	field := srdb.Field{Name: name, Type: typ, Nullable: true}
+	for {
+		switch {
+		case p.accept("NOT"):
+			if err := p.expect("NULL"); err != nil {
+				return srdb.Field{}, err
+			}
+			field.Nullable = false
+		case p.accept("NULL"):
+			field.Nullable = true
+		case p.accept("INDEX"), p.accept("INDEXED"):
+			field.Indexed = true
+		case p.accept("COMMENT"):
+			tok := p.next()
+			if tok.kind != tokString {
+				p.pos--
+				return srdb.Field{}, p.errorf("expected comment string")
+			}
+			field.Comment = tok.text
+		default:
+			return field, nil
+		}
+	}
+}
+
+// typeAliases 常用 SQL 类型名到字段类型的映射（srdb 的类型名如 int32、string 也可以直接使用）
+var typeAliases = map[string]srdb.FieldType{
+	"integer":   srdb.Int64,
+	"bigint":    srdb.Int64,
+	"smallint":  srdb.Int16,
+	"tinyint":   srdb.Int8,
+	"real":      srdb.Float32,
+	"double":    srdb.Float64,
+	"float":     srdb.Float64,
+	"numeric":   srdb.Decimal,
+	"text":      srdb.String,
+	"varchar":   srdb.String,
+	"char":      srdb.String,
+	"boolean":   srdb.Bool,
+	"timestamp": srdb.Time,
+	"datetime":  srdb.Time,
+	"json":      srdb.Object,
+}
+
+// lookupType 按名称（不区分大小写）查找字段类型
+func lookupType(name string) (srdb.FieldType, bool) {
+	name = strings.ToLower(name)
+	if typ, ok := typeAliases[name]; ok {
+		return typ, true
+	}
+	typ, err := srdb.ParseFieldType(name)
+	return typ, err == nil
+}
+
+// parseInsert 解析 INSERT
+func (p *parser) parseInsert() (statement, error) {
+	p.next()
+	if err := p.expect("INTO"); err != nil {
+		return nil, err
+	}
+	table, err := p.ident("table name")
+	if err != nil {
+		return nil, err
+	}
+	stmt := &insertStmt{table: table}
+
+	if p.accept("(") {
+		for {
+			column, err := p.ident("column name")
+			if err != nil {
+				return nil, err
+			}
+			stmt.columns = append(stmt.columns, column)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.expect("VALUES"); err != nil {
+		return nil, err
+	}
+	for {
+		values, err := p.parseValueList()
+		if err != nil {
+			return nil, err
+		}
+		if len(stmt.columns) > 0 && len(values) != len(stmt.columns) {
+			return nil, fmt.Errorf("insert has %d columns but %d values", len(stmt.columns), len(values))
+		}
+		stmt.rows = append(stmt.rows, values)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return stmt, nil
+}
+
+// parseValueList 解析 (value, ...)
+func (p *parser) parseValueList() ([]any, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var values []any
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseValue 解析字面量或占位符：整数为 int64，小数为 float64
+func (p *parser) parseValue() (any, error) {
+	negate := p.accept("-")
+	tok := p.next()
+	switch {
+	case tok.kind == tokNumber:
+		text := tok.text
+		if negate {
+			text = "-" + text
+		}
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			p.pos--
+			return nil, p.errorf("invalid number")
+		}
+		return f, nil
+	case negate:
+		p.pos--
+		return nil, p.errorf("expected number after -")
+	case tok.kind == tokString:
+		return tok.text, nil
+	case tok.kind == tokParam:
+		if p.used >= len(p.args) {
+			return nil, fmt.Errorf("statement has more placeholders than the %d arguments", len(p.args))
+		}
+		p.used++
+		return p.args[p.used-1], nil
+	case tok.is("NULL"):
+		return nil, nil
+	case tok.is("TRUE"):
+		return true, nil
+	case tok.is("FALSE"):
+		return false, nil
+	}
+	p.pos--
+	return nil, p.errorf("expected a value")
+}
+
+// parseSelect 解析 SELECT
+func (p *parser) parseSelect() (statement, error) {
+	p.next()
+	stmt := &selectStmt{limit: -1}
+
+	// 列按原文截取，字段与计算列的解析交给 QueryBuilder.Select
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		stmt.items = append(stmt.items, item)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	table, err := p.ident("table name")
+	if err != nil {
+		return nil, err
+	}
+	stmt.table = table
+	if p.db != nil {
+		if t, err := p.db.GetTable(table); err == nil {
+			p.schema = t.GetSchema()
+		}
+	}
+
+	if p.accept("WHERE") {
+		if stmt.where, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.accept("ORDER") {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		if stmt.orderBy, err = p.ident("column name"); err != nil {
+			return nil, err
+		}
+		if p.accept("DESC") {
+			stmt.desc = true
+		} else {
+			p.accept("ASC")
+		}
+		if p.peek().is(",") {
+			return nil, p.errorf("ORDER BY supports a single column")
+		}
+	}
+
+	if p.accept("LIMIT") {
+		if stmt.limit, err = p.parseCount("LIMIT"); err != nil {
+			return nil, err
+		}
+		if p.accept("OFFSET") {
+			if stmt.offset, err = p.parseCount("OFFSET"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return stmt, nil
+}
+
+// parseSelectItem 读取到顶层逗号或 FROM 为止的一列
+func (p *parser) parseSelectItem() (selectItem, error) {
+	if p.accept("*") {
+		return selectItem{star: true, text: "*"}, nil
+	}
+
+	first := p.pos
+	depth := 0
+	for {
+		tok := p.peek()
+		if tok.kind == tokEOF || depth == 0 && (tok.is(",") || tok.is("FROM")) {
+			break
+		}
+		switch {
+		case tok.kind == tokParam:
+			return selectItem{}, p.errorf("placeholders are not supported in the select list")
+		case tok.is("("):
+			depth++
+		case tok.is(")"):
+			depth--
+		}
+		p.pos++
+	}
+	if p.pos == first {
+		return selectItem{}, p.errorf("expected a column")
+	}
+
+	tokens := p.tokens[first:p.pos]
+	item := selectItem{text: p.query[tokens[0].start:tokens[len(tokens)-1].end]}
+	item.name = item.text
+	if n := len(tokens); n >= 3 && tokens[n-2].is("AS") && tokens[n-1].kind == tokIdent {
+		item.name = tokens[n-1].text
+	}
+	return item, nil
+}
+
+// parseCount 解析 LIMIT/OFFSET 的非负整数
+func (p *parser) parseCount(clause string) (int, error) {
+	value, err := p.parseValue()
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	switch v := value.(type) {
+	case int64:
+		n = v
+	case int:
+		n = int64(v)
+	default:
+		return 0, fmt.Errorf("%s must be an integer, got %v", clause, value)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s must not be negative", clause)
+	}
+	return int(n), nil
+}
+
+// parseOr expr OR expr ...
+func (p *parser) parseOr() (srdb.Expr, error) {
+	exprs, err := p.parseList("OR", p.parseAnd)
+	if err != nil || len(exprs) == 1 {
+		return firstExpr(exprs), err
+	}
+	return srdb.Or(exprs...), nil
+}
+
+// parseAnd expr AND expr ...
+func (p *parser) parseAnd() (srdb.Expr, error) {
+	exprs, err := p.parseList("AND", p.parseNot)
+	if err != nil || len(exprs) == 1 {
+		return firstExpr(exprs), err
+	}
+	return srdb.And(exprs...), nil
+}
+
+func (p *parser) parseList(sep string, parse func() (srdb.Expr, error)) ([]srdb.Expr, error) {
+	var exprs []srdb.Expr
+	for {
+		expr, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.accept(sep) {
+			return exprs, nil
+		}
+	}
+}
+
+func firstExpr(exprs []srdb.Expr) srdb.Expr {
+	if len(exprs) == 0 {
+		return nil
+	}
+	return exprs[0]
+}
+
+// parseNot [NOT] (expr) | predicate
+func (p *parser) parseNot() (srdb.Expr, error) {
+	if p.accept("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return srdb.Not(expr), nil
+	}
+	if p.accept("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parsePredicate()
+}
+
+// parsePredicate 解析 column 上的一个条件
+func (p *parser) parsePredicate() (srdb.Expr, error) {
+	column, err := p.ident("column name")
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept("IS") {
+		negate := p.accept("NOT")
+		if err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		if negate {
+			return srdb.NotNull(column), nil
+		}
+		return srdb.IsNull(column), nil
+	}
+
+	negate := p.accept("NOT")
+	switch {
+	case p.accept("IN"):
+		values, err := p.parseValueList()
+		if err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			if values[i], err = p.convert(column, value); err != nil {
+				return nil, err
+			}
+		}
+		if negate {
+			return srdb.NotIn(column, values), nil
+		}
+		return srdb.In(column, values), nil
+	case p.accept("BETWEEN"):
+		low, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if low, err = p.convert(column, low); err != nil {
+			return nil, err
+		}
+		if high, err = p.convert(column, high); err != nil {
+			return nil, err
+		}
+		if negate {
+			return srdb.NotBetween(column, low, high), nil
+		}
+		return srdb.Between(column, low, high), nil
+	case p.accept("LIKE"):
+		return p.parseLike(column, negate)
+	case negate:
+		return nil, p.errorf("expected IN, BETWEEN or LIKE after NOT")
+	}
+
+	op := p.next()
+	if op.kind != tokSymbol {
+		p.pos--
+		return nil, p.errorf("expected a comparison operator")
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if value, err = p.convert(column, value); err != nil {
+		return nil, err
+	}
+	switch op.text {
+	case "=":
+		return srdb.Eq(column, value), nil
+	case "!=", "<>":
+		return srdb.NotEq(column, value), nil
+	case "<":
+		return srdb.Lt(column, value), nil
+	case "<=":
+		return srdb.Lte(column, value), nil
+	case ">":
+		return srdb.Gt(column, value), nil
+	case ">=":
+		return srdb.Gte(column, value), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", op.text)
+}
+
+// convert 将与列比较的值转换为列的类型
+//
+// QueryBuilder 按值的类型比较：数值之间、字符串之间可以直接比较，时间与 Decimal 列的值
+// 只能与同类型的值比较，因此这两种列的字面量与参数（如 '2024-01-02 03:04:05'、12.5、time.Time）
+// 按 srdb.ConvertValue 转换，转换失败时返回错误而不是返回空结果。
+func (p *parser) convert(column string, value any) (any, error) {
+	if p.schema == nil || value == nil {
+		return value, nil
+	}
+	field, err := p.schema.GetField(column)
+	if err != nil || field.Type != srdb.Time && field.Type != srdb.Decimal {
+		return value, nil
+	}
+	converted, err := srdb.ConvertValue(value, field.Type)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", column, err)
+	}
+	return converted, nil
+}
+
+// parseLike 将 LIKE 模式转换为前缀、后缀、包含或相等条件
+//
+// 只支持开头和/或结尾的 %（如 'abc%'、'%abc'、'%abc%'），不支持 _ 与中间的 %。
+func (p *parser) parseLike(column string, negate bool) (srdb.Expr, error) {
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	pattern, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("LIKE pattern must be a string, got %v", value)
+	}
+
+	prefix := strings.HasPrefix(pattern, "%")
+	suffix := len(pattern) > 1 && strings.HasSuffix(pattern, "%")
+	text := strings.TrimSuffix(strings.TrimPrefix(pattern, "%"), "%")
+	if strings.ContainsAny(text, "%_") {
+		return nil, fmt.Errorf("unsupported LIKE pattern %q: only leading and trailing %% are supported", pattern)
+	}
+
+	switch {
+	case prefix && suffix:
+		if negate {
+			return srdb.NotContains(column, text), nil
+		}
+		return srdb.Contains(column, text), nil
+	case prefix:
+		if negate {
+			return srdb.NotEndsWith(column, text), nil
+		}
+		return srdb.EndsWith(column, text), nil
+	case suffix:
+		if negate {
+			return srdb.NotStartsWith(column, text), nil
+		}
+		return srdb.StartsWith(column, text), nil
+	case negate:
+		return srdb.NotEq(column, text), nil
+	default:
+		return srdb.Eq(column, text), nil
+	}
+}
@@ -0,0 +1,198 @@
+// Package sql 在 srdb 之上提供一个小型 SQL 子集，便于用熟悉的语法查询与写入数据：
+//
+//	CREATE TABLE [IF NOT EXISTS] name (column type [NOT NULL] [INDEX] [COMMENT '...'], ...)
+//	INSERT INTO name [(column, ...)] VALUES (value, ...), ...
+//	SELECT * | item, ... FROM name [WHERE cond] [ORDER BY column [ASC|DESC]] [LIMIT n [OFFSET m]]
+//
+// SELECT 的列与 QueryBuilder.Select 相同，可以是字段、_seq、_time 或计算列（如 price * qty AS total）；
+// ORDER BY 与 QueryBuilder 相同，只支持 _seq 或建立了索引的字段。
+// WHERE 支持 = != <> < <= > >=、[NOT] IN、[NOT] BETWEEN、IS [NOT] NULL、[NOT] LIKE（只支持开头或结尾的 %），
+// 以及 AND、OR、NOT 与括号。值可以使用 ? 占位符，按顺序绑定参数。不支持 JOIN、GROUP BY、UPDATE 与 DELETE。
+// 写入的值与时间、Decimal 列比较的值按 srdb.ConvertValue 转换为列的类型（如 '2024-01-02 03:04:05'）。
+//
+//	res, err := sql.Exec(ctx, db, "SELECT name, age FROM users WHERE age >= ? ORDER BY age DESC LIMIT 10", 18)
+//
+// 同时注册了 database/sql 驱动 "srdb"（见 driver.go）。
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hupeh/srdb"
+)
+
+// Result 语句的执行结果
+type Result struct {
+	Columns      []string // SELECT 的列名（别名或原文）
+	Rows         [][]any  // SELECT 的结果，每行按 Columns 的顺序
+	RowsAffected int64    // INSERT 写入的行数
+}
+
+// Exec 执行一条语句，args 按顺序绑定到 ? 占位符
+//
+// SELECT 通过 QueryBuilder.WithContext 使用 ctx，表设置的行级策略（SetRowPolicy）同样生效。
+func Exec(ctx context.Context, db *srdb.Database, query string, args ...any) (*Result, error) {
+	stmt, err := parse(query, args, db)
+	if err != nil {
+		return nil, err
+	}
+	switch stmt := stmt.(type) {
+	case *createTableStmt:
+		return execCreateTable(db, stmt)
+	case *insertStmt:
+		return execInsert(db, stmt)
+	case *selectStmt:
+		return execSelect(ctx, db, stmt)
+	}
+	return nil, fmt.Errorf("unsupported statement %T", stmt)
+}
+
+// execCreateTable 按列定义创建表
+func execCreateTable(db *srdb.Database, stmt *createTableStmt) (*Result, error) {
+	if stmt.ifNotExists {
+		if _, err := db.GetTable(stmt.name); err == nil {
+			return &Result{}, nil
+		}
+	}
+	schema, err := srdb.NewSchema(stmt.name, stmt.fields)
+	if err != nil {
+		return nil, err
+	}
+	table, err := db.CreateTable(stmt.name, schema)
+	if err != nil {
+		return nil, err
+	}
+	// 新表的索引要到第一次 flush 才就绪，此时为空，立即构建以便之后的查询与排序直接使用
+	if err := table.BuildIndexes(); err != nil {
+		return nil, err
+	}
+	return &Result{}, nil
+}
+
+// execInsert 一次写入所有行；未指定列时按 Schema 中可写入的字段（排除计算列与弃用字段）顺序对应
+func execInsert(db *srdb.Database, stmt *insertStmt) (*Result, error) {
+	table, err := db.GetTable(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+	schema := table.GetSchema()
+	columns := stmt.columns
+	if len(columns) == 0 {
+		for _, field := range schema.Fields {
+			if field.Computed == "" && !field.Deprecated {
+				columns = append(columns, field.Name)
+			}
+		}
+	}
+
+	rows := make([]map[string]any, len(stmt.rows))
+	for i, values := range stmt.rows {
+		if len(values) != len(columns) {
+			return nil, fmt.Errorf("table %s has %d columns but %d values were given", stmt.table, len(columns), len(values))
+		}
+		row := make(map[string]any, len(columns))
+		for j, column := range columns {
+			value, err := convertInsertValue(schema, column, values[j])
+			if err != nil {
+				return nil, err
+			}
+			row[column] = value
+		}
+		rows[i] = row
+	}
+	if err := table.Insert(rows); err != nil {
+		return nil, err
+	}
+	return &Result{RowsAffected: int64(len(rows))}, nil
+}
+
+// convertInsertValue 按列类型转换写入的值，字符串按 srdb.ConvertValue 的规则解析（如 DATETIME 列的 '2024-01-02 03:04:05'）
+//
+// 未知的列原样返回，由 Insert 报告错误。
+func convertInsertValue(schema *srdb.Schema, column string, value any) (any, error) {
+	field, err := schema.GetField(column)
+	if err != nil || value == nil {
+		return value, nil
+	}
+	converted, err := srdb.ConvertValue(value, field.Type)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", column, err)
+	}
+	return converted, nil
+}
+
+// execSelect 将 SELECT 转换为 QueryBuilder 执行
+func execSelect(ctx context.Context, db *srdb.Database, stmt *selectStmt) (*Result, error) {
+	table, err := db.GetTable(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+
+	// * 展开为 Schema 中的字段（不含弃用字段）；只有 * 时不调用 Select，返回所有字段
+	var columns, fields []string
+	onlyStar := true
+	for _, item := range stmt.items {
+		if !item.star {
+			onlyStar = false
+			columns = append(columns, item.name)
+			fields = append(fields, item.text)
+			continue
+		}
+		for _, field := range table.GetSchema().Fields {
+			if !field.Deprecated {
+				columns = append(columns, field.Name)
+				fields = append(fields, field.Name)
+			}
+		}
+	}
+
+	// LIMIT 0 不返回任何行（QueryBuilder 的 Limit(0) 表示不限制）
+	if stmt.limit == 0 {
+		return &Result{Columns: columns}, nil
+	}
+
+	qb := table.Query().WithContext(ctx)
+	if !onlyStar {
+		qb.Select(fields...)
+	}
+	if stmt.where != nil {
+		qb.Where(stmt.where)
+	}
+	if stmt.orderBy != "" {
+		if stmt.desc {
+			qb.OrderByDesc(stmt.orderBy)
+		} else {
+			qb.OrderBy(stmt.orderBy)
+		}
+	}
+	if stmt.offset > 0 {
+		qb.Offset(stmt.offset)
+	}
+	if stmt.limit > 0 {
+		qb.Limit(stmt.limit)
+	}
+
+	rows, err := qb.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &Result{Columns: columns}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data := rows.Row().Data()
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			values[i] = data[column]
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,182 @@
+package sql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hupeh/srdb"
+	"github.com/shopspring/decimal"
+)
+
+func openTestDB(t *testing.T) *srdb.Database {
+	t.Helper()
+	db, err := srdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func mustExec(t *testing.T, db *srdb.Database, query string, args ...any) *Result {
+	t.Helper()
+	res, err := Exec(context.Background(), db, query, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return res
+}
+
+func TestExec(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	mustExec(t, db, `CREATE TABLE users (
+		name VARCHAR(64) NOT NULL INDEX COMMENT 'user name',
+		age int32 NOT NULL INDEX,
+		city TEXT,
+		score double
+	)`)
+	table, err := db.GetTable("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, _ := table.GetSchema().GetField("name")
+	city, _ := table.GetSchema().GetField("city")
+	if name.Type != srdb.String || !name.Indexed || name.Nullable || name.Comment != "user name" || !city.Nullable {
+		t.Errorf("unexpected fields %+v, %+v", name, city)
+	}
+	// IF NOT EXISTS 不报错
+	mustExec(t, db, "CREATE TABLE IF NOT EXISTS users (x int)")
+
+	res := mustExec(t, db, `INSERT INTO users (name, age, city, score) VALUES
+		('Alice', 30, 'Oslo', 9.5),
+		('Bob', 25, NULL, 7),
+		(?, ?, ?, ?)`, "Carol", 41, "Rome", 8.25)
+	if res.RowsAffected != 3 {
+		t.Errorf("expected 3 rows affected, got %d", res.RowsAffected)
+	}
+	// 未指定列时按 Schema 字段顺序
+	mustExec(t, db, "INSERT INTO users VALUES ('Dan''s', 19, 'Oslo', -1.5);")
+
+	cases := []struct {
+		query string
+		args  []any
+		want  [][]any
+	}{
+		{"SELECT name FROM users WHERE age >= ? ORDER BY age DESC", []any{25}, [][]any{{"Carol"}, {"Alice"}, {"Bob"}}},
+		{"SELECT name FROM users WHERE city = 'Oslo' AND NOT (age < 20)", nil, [][]any{{"Alice"}}},
+		{"SELECT name FROM users WHERE city IS NULL OR name LIKE 'C%'", nil, [][]any{{"Bob"}, {"Carol"}}},
+		{"SELECT name FROM users WHERE name LIKE '%s' ORDER BY name", nil, [][]any{{"Dan's"}}},
+		{"SELECT name FROM users WHERE name NOT LIKE '%o%' AND age NOT BETWEEN 20 AND 29", nil, [][]any{{"Alice"}, {"Dan's"}}},
+		{"SELECT name FROM users WHERE name IN ('Bob', ?) ORDER BY name DESC", []any{"Alice"}, [][]any{{"Bob"}, {"Alice"}}},
+		{"SELECT name FROM users WHERE city IS NOT NULL ORDER BY name LIMIT 2 OFFSET 1", nil, [][]any{{"Carol"}, {"Dan's"}}},
+		{"select upper(name), age * 2 as double from users where name = 'Bob'", nil, [][]any{{"BOB", int64(50)}}},
+		{"SELECT name FROM users LIMIT 0", nil, nil},
+	}
+	for _, c := range cases {
+		res, err := Exec(ctx, db, c.query, c.args...)
+		if err != nil {
+			t.Errorf("%s: %v", c.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(res.Rows, c.want) {
+			t.Errorf("%s: got %v, want %v", c.query, res.Rows, c.want)
+		}
+	}
+
+	res = mustExec(t, db, "SELECT upper(name), age * 2 AS double FROM users LIMIT 1")
+	if !reflect.DeepEqual(res.Columns, []string{"upper(name)", "double"}) {
+		t.Errorf("unexpected columns %v", res.Columns)
+	}
+	res = mustExec(t, db, "SELECT *, _seq FROM users WHERE name = 'Alice'")
+	if !reflect.DeepEqual(res.Columns, []string{"name", "age", "city", "score", "_seq"}) {
+		t.Errorf("unexpected columns %v", res.Columns)
+	}
+	if len(res.Rows) != 1 || res.Rows[0][0] != "Alice" || res.Rows[0][4] != int64(1) {
+		t.Errorf("unexpected rows %v", res.Rows)
+	}
+}
+
+func TestExecErrors(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE items (name string, qty int64)")
+
+	for _, c := range []struct {
+		query string
+		args  []any
+	}{
+		{"DELETE FROM items", nil},
+		{"SELECT name FROM missing", nil},
+		{"SELECT name FROM items WHERE", nil},
+		{"SELECT name FROM items WHERE name = ?", nil},
+		{"SELECT name FROM items", []any{1}},
+		{"SELECT name FROM items ORDER BY name, qty", nil},
+		{"SELECT name FROM items WHERE name LIKE 'a%b'", nil},
+		{"SELECT name FROM items LIMIT -1", nil},
+		{"SELECT nope + 1 FROM items", nil},
+		{"SELECT name FROM items extra", nil},
+		{"INSERT INTO items (name) VALUES ('a', 1)", nil},
+		{"INSERT INTO items VALUES ('a')", nil},
+		{"CREATE TABLE items (name string)", nil},
+		{"CREATE TABLE bad (x blob)", nil},
+		{"SELECT 'unterminated FROM items", nil},
+	} {
+		if _, err := Exec(ctx, db, c.query, c.args...); err == nil {
+			t.Errorf("expected error for %q", c.query)
+		}
+	}
+}
+
+func TestExecTimeAndDecimal(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE orders (id int64, at DATETIME, price NUMERIC, placed DATETIME INDEX)")
+
+	// DATETIME 列接受迁移时的时间格式，NUMERIC 列接受数字字符串
+	mustExec(t, db, `INSERT INTO orders VALUES
+		(1, '2024-01-02 03:04:05', '9.99', '2024-01-02'),
+		(2, '2024-01-03T00:00:00Z', 12.5, '2024-01-03'),
+		(?, ?, ?, ?)`, 3, time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), decimal.RequireFromString("100.001"), "2024-01-04")
+
+	cases := []struct {
+		query string
+		args  []any
+		want  [][]any
+	}{
+		{"SELECT id FROM orders WHERE at = '2024-01-02 03:04:05'", nil, [][]any{{int64(1)}}},
+		{"SELECT id FROM orders WHERE at > ?", []any{time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)}, [][]any{{int64(2)}, {int64(3)}}},
+		{"SELECT id FROM orders WHERE at BETWEEN '2024-01-02' AND '2024-01-03'", nil, [][]any{{int64(1)}, {int64(2)}}},
+		{"SELECT id FROM orders WHERE placed = '2024-01-03'", nil, [][]any{{int64(2)}}},
+		{"SELECT id FROM orders WHERE price = '12.50'", nil, [][]any{{int64(2)}}},
+		{"SELECT id FROM orders WHERE price >= 12.5", nil, [][]any{{int64(2)}, {int64(3)}}},
+		{"SELECT id FROM orders WHERE price < ?", []any{decimal.RequireFromString("10")}, [][]any{{int64(1)}}},
+		{"SELECT id FROM orders WHERE price IN ('9.99', 100.001)", nil, [][]any{{int64(1)}, {int64(3)}}},
+		{"SELECT id FROM orders WHERE price > 1e2", nil, [][]any{{int64(3)}}},
+		{"SELECT id FROM orders WHERE id < 2.5E0", nil, [][]any{{int64(1)}, {int64(2)}}},
+	}
+	for _, c := range cases {
+		res, err := Exec(ctx, db, c.query, c.args...)
+		if err != nil {
+			t.Errorf("%s: %v", c.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(res.Rows, c.want) {
+			t.Errorf("%s: got %v, want %v", c.query, res.Rows, c.want)
+		}
+	}
+
+	// 无法转换的值返回错误，而不是返回空结果
+	for _, query := range []string{
+		"SELECT id FROM orders WHERE at = 'yesterday'",
+		"SELECT id FROM orders WHERE price > 'cheap'",
+		"INSERT INTO orders VALUES (4, 'soon', 1, '2024-01-05')",
+	} {
+		if _, err := Exec(ctx, db, query); err == nil {
+			t.Errorf("expected error for %q", query)
+		}
+	}
+}
@@ -2,17 +2,19 @@
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/edsrzf/mmap-go"
 	"github.com/shopspring/decimal"
 )
 
@@ -28,6 +30,26 @@
 	SSTableRowMagic = 0x524F5731 // "ROW1"
 )
 
+const (
+	// sstTempSuffix SST 文件写入期间的后缀：写完并 fsync 后才重命名为正式文件名（见 publishSST），
+	// 崩溃时只会留下 .sst.tmp 文件（由 GC 删除），目录中的 .sst 文件总是完整的
+	sstTempSuffix = ".tmp"
+
+	// sstIndexReserveSize 数据块之前为 B+Tree 索引预留的空间，未使用的部分是文件空洞，
+	// 不占用磁盘，但计入文件大小（FileMetadata.FileSize）
+	sstIndexReserveSize = 10 * 1024 * 1024 // 10 MB
+)
+
+const (
+	// rowChecksumMagic 行校验和尾部的 Magic Number
+	// 行编码之后追加 [SHA-256: 32 bytes][Magic: 4 bytes]，校验和覆盖之前的全部编码内容；
+	// 旧数据没有该尾部，解码时按字段数据区的结束位置识别
+	rowChecksumMagic = 0x53554D31 // "SUM1"
+
+	// rowChecksumTrailerSize 行校验和尾部大小
+	rowChecksumTrailerSize = sha256.Size + 4
+)
+
 // SSTableHeader SST 文件头 (256 bytes)
 type SSTableHeader struct {
 	// 基础信息 (32 bytes)
@@ -60,8 +82,12 @@ type SSTableHeader struct {
 	CRC32     uint32 // Header CRC32
 	Reserved5 [4]byte
 
-	// 预留空间 (120 bytes)
-	Reserved6 [120]byte
+	// 列统计信息 (16 bytes)，旧版本写入的文件为 0
+	ZoneMapOffset int64 // zone map 起始位置（数据块之后）
+	ZoneMapSize   int64 // zone map 大小
+
+	// 预留空间 (104 bytes)
+	Reserved6 [104]byte
 }
 
 // Marshal 序列化 Header
@@ -98,8 +124,12 @@ func (h *SSTableHeader) Marshal() []byte {
 	binary.LittleEndian.PutUint32(buf[128:132], h.CRC32)
 	copy(buf[132:136], h.Reserved5[:])
 
+	// 列统计信息
+	binary.LittleEndian.PutUint64(buf[136:144], uint64(h.ZoneMapOffset))
+	binary.LittleEndian.PutUint64(buf[144:152], uint64(h.ZoneMapSize))
+
 	// 预留空间
-	copy(buf[136:256], h.Reserved6[:])
+	copy(buf[152:256], h.Reserved6[:])
 
 	return buf
 }
@@ -142,8 +172,12 @@ func UnmarshalSSTableHeader(data []byte) *SSTableHeader {
 	h.CRC32 = binary.LittleEndian.Uint32(data[128:132])
 	copy(h.Reserved5[:], data[132:136])
 
+	// 列统计信息
+	h.ZoneMapOffset = int64(binary.LittleEndian.Uint64(data[136:144]))
+	h.ZoneMapSize = int64(binary.LittleEndian.Uint64(data[144:152]))
+
 	// 预留空间
-	copy(h.Reserved6[:], data[136:256])
+	copy(h.Reserved6[:], data[152:256])
 
 	return h
 }
@@ -157,8 +191,24 @@ func (h *SSTableHeader) Validate() bool {
 func encodeSSTableRowBinary(row *SSTableRow, schema *Schema) ([]byte, error) {
 	buf := new(bytes.Buffer)
 
+	// 强制要求 Schema
+	if schema == nil {
+		return nil, fmt.Errorf("schema is required for encoding SSTable rows")
+	}
+	if row.Deleted {
+		return encodeTombstone(row.Seq, row.Time), nil
+	}
+
+	// 含有 Bool 字段时打包为位（ROW2，见 rowpack.go）；
+	// 带校验和的 ROW1 数据保持原格式，否则插入时计算的校验和不再匹配
+	packed := schemaPacksFields(schema) && !(row.unpacked && len(row.Checksum) == sha256.Size)
+	magic := uint32(SSTableRowMagic)
+	if packed {
+		magic = SSTableRowPackedMagic
+	}
+
 	// 写入 Magic Number (用于验证)
-	if err := binary.Write(buf, binary.LittleEndian, uint32(SSTableRowMagic)); err != nil {
+	if err := binary.Write(buf, binary.LittleEndian, magic); err != nil {
 		return nil, err
 	}
 
@@ -172,26 +222,59 @@ func encodeSSTableRowBinary(row *SSTableRow, schema *Schema) ([]byte, error) {
 		return nil, err
 	}
 
-	// 强制要求 Schema
-	if schema == nil {
-		return nil, fmt.Errorf("schema is required for encoding SSTable rows")
-	}
-
 	// 按字段分别编码和压缩
 	fieldCount := uint16(len(schema.Fields))
 	if err := binary.Write(buf, binary.LittleEndian, fieldCount); err != nil {
 		return nil, err
 	}
 
-	// 1. 先编码所有字段到各自的 buffer（无压缩）
-	fieldData := make([][]byte, len(schema.Fields))
+	var bits []byte
+	if packed {
+		packedCount := 0
+		for _, field := range schema.Fields {
+			if isPackedField(field) {
+				packedCount++
+			}
+		}
+		if err := binary.Write(buf, binary.LittleEndian, uint16(packedCount)); err != nil {
+			return nil, err
+		}
+		bits = make([]byte, (packedCount*2+7)/8)
+	}
+
+	// 1. 先编码所有字段到各自的 buffer（无压缩），打包的字段写入 bits
+	fieldData := make([][]byte, 0, len(schema.Fields))
+	k := 0
 
-	for i, field := range schema.Fields {
-		fieldBuf := new(bytes.Buffer)
+	for _, field := range schema.Fields {
 		value, exists := row.Data[field.Name]
 
-		if !exists || value == nil {
-			// 字段不存在或值为 nil（nullable 字段），写入零值
+		if packed && isPackedField(field) {
+			bit := uint(k % 4 * 2)
+			k++
+			if !exists || value == nil {
+				if field.Nullable {
+					continue // NULL：两位均为 0
+				}
+				value = false
+			}
+			v, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("write field %s: expected bool, got %T", field.Name, value)
+			}
+			bits[(k-1)/4] |= 1 << bit
+			if v {
+				bits[(k-1)/4] |= 2 << bit
+			}
+			continue
+		}
+
+		fieldBuf := new(bytes.Buffer)
+		if (!exists || value == nil) && field.Nullable {
+			// nullable 字段缺失或为 nil：写入长度为 0 的字段数据表示 NULL
+			// （任何类型的非 NULL 编码长度都大于 0）
+		} else if !exists || value == nil {
+			// 非 nullable 字段不存在，写入零值
 			if err := writeFieldZeroValue(fieldBuf, field.Type); err != nil {
 				return nil, fmt.Errorf("write zero value for field %s: %w", field.Name, err)
 			}
@@ -202,7 +285,7 @@ func encodeSSTableRowBinary(row *SSTableRow, schema *Schema) ([]byte, error) {
 		}
 
 		// 直接使用二进制数据（无压缩）
-		fieldData[i] = fieldBuf.Bytes()
+		fieldData = append(fieldData, fieldBuf.Bytes())
 	}
 
 	// 2. 写入字段偏移表（相对于数据区起始位置）
@@ -220,16 +303,71 @@ func encodeSSTableRowBinary(row *SSTableRow, schema *Schema) ([]byte, error) {
 		currentOffset += len(data)
 	}
 
-	// 3. 写入字段数据
+	// 3. 写入打包的字段位与字段数据
+	buf.Write(bits)
 	for _, data := range fieldData {
 		if _, err := buf.Write(data); err != nil {
 			return nil, err
 		}
 	}
 
+	// 4. 行校验和：flush、Compaction 重新编码时保留插入时的校验和，不重新计算
+	if len(row.Checksum) == sha256.Size {
+		buf.Write(row.Checksum)
+		if err := binary.Write(buf, binary.LittleEndian, uint32(rowChecksumMagic)); err != nil {
+			return nil, err
+		}
+	}
+
 	return buf.Bytes(), nil
 }
 
+// appendRowChecksum 计算行编码（不含校验和）的 SHA-256 并追加校验和尾部
+func appendRowChecksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	data = append(data, sum[:]...)
+	return binary.LittleEndian.AppendUint32(data, rowChecksumMagic)
+}
+
+// rowDataEnd 返回行编码中字段数据区的结束位置（即校验和尾部的起始位置）
+func rowDataEnd(data []byte) (int, error) {
+	layout, err := parseRowLayout(data)
+	if err != nil {
+		return 0, err
+	}
+	return layout.dataEnd(data), nil
+}
+
+// splitRowChecksum 拆分行编码的内容与校验和，没有校验和（旧数据）时 ok 为 false
+func splitRowChecksum(data []byte, dataEnd int) (content, checksum []byte, ok bool) {
+	if len(data) != dataEnd+rowChecksumTrailerSize ||
+		binary.LittleEndian.Uint32(data[len(data)-4:]) != rowChecksumMagic {
+		return data, nil, false
+	}
+	return data[:dataEnd], data[dataEnd : dataEnd+sha256.Size], true
+}
+
+// verifyRowChecksum 校验行编码的校验和
+// 没有校验和的旧数据返回 ErrCodeInvalidData，内容与校验和不一致返回 ErrCodeChecksumMismatch
+func verifyRowChecksum(data []byte) error {
+	if len(data) < 4 || !isRowMagic(binary.LittleEndian.Uint32(data[0:4])) {
+		return NewErrorf(ErrCodeInvalidData, "invalid row encoding")
+	}
+	dataEnd, err := rowDataEnd(data)
+	if err != nil {
+		return NewError(ErrCodeInvalidData, err)
+	}
+	seq := int64(binary.LittleEndian.Uint64(data[4:12]))
+	content, checksum, ok := splitRowChecksum(data, dataEnd)
+	if !ok {
+		return NewErrorf(ErrCodeInvalidData, "row %d has no checksum", seq)
+	}
+	if sum := sha256.Sum256(content); !bytes.Equal(sum[:], checksum) {
+		return NewErrorf(ErrCodeChecksumMismatch, "row %d checksum mismatch", seq)
+	}
+	return nil
+}
+
 // writeFieldBinaryValue 写入字段值（二进制格式）
 func writeFieldBinaryValue(buf *bytes.Buffer, typ FieldType, value any) error {
 	switch typ {
@@ -420,6 +558,15 @@ func writeFieldBinaryValue(buf *bytes.Buffer, typ FieldType, value any) error {
 	}
 }
 
+// fieldZeroValue 返回字段类型的零值（与写入缺失的非 nullable 字段后读回的值一致）
+func fieldZeroValue(typ FieldType) (any, error) {
+	var buf bytes.Buffer
+	if err := writeFieldZeroValue(&buf, typ); err != nil {
+		return nil, err
+	}
+	return readFieldBinaryValue(bytes.NewReader(buf.Bytes()), typ, true)
+}
+
 // writeFieldZeroValue 写入字段零值
 func writeFieldZeroValue(buf *bytes.Buffer, typ FieldType) error {
 	switch typ {
@@ -512,102 +659,110 @@ func decodeSSTableRowBinary(data []byte, schema *Schema) (*SSTableRow, error) {
 
 // decodeSSTableRowBinaryPartial 按需解码（只读取和解压指定字段）
 func decodeSSTableRowBinaryPartial(data []byte, schema *Schema, fields []string) (*SSTableRow, error) {
-	buf := bytes.NewReader(data)
-
-	// 读取并验证 Magic Number
-	var magic uint32
-	if err := binary.Read(buf, binary.LittleEndian, &magic); err != nil {
+	row := &SSTableRow{}
+	if err := decodeSSTableRowBinaryInto(data, schema, fields, row); err != nil {
+		if IsError(err, ErrCodeChecksumMismatch) {
+			// 数据已完整解码，由调用者决定是否保留（flush 与 Compaction 不能丢弃）
+			return row, err
+		}
 		return nil, err
 	}
-	if magic != SSTableRowMagic {
-		return nil, fmt.Errorf("invalid row magic: %x", magic)
-	}
+	return row, nil
+}
 
-	row := &SSTableRow{Data: make(map[string]any)}
+// rowDecodeHeaderSize 行头大小：Magic(4) + Seq(8) + Time(8) + FieldCount(2)
+const rowDecodeHeaderSize = 22
 
-	// 读取 Seq
-	if err := binary.Read(buf, binary.LittleEndian, &row.Seq); err != nil {
-		return nil, err
-	}
+// fieldReaderPool 复用解码字段值时使用的 bytes.Reader，减少大扫描时的分配
+var fieldReaderPool = sync.Pool{
+	New: func() any { return bytes.NewReader(nil) },
+}
 
-	// 读取 Time
-	if err := binary.Read(buf, binary.LittleEndian, &row.Time); err != nil {
-		return nil, err
+// decodeSSTableRowBinaryInto 按需解码到已有的 row 中（复用 row.Data 的 map）
+// fields 为 nil 表示解码所有字段，此时同时校验行校验和，不一致时解码结果仍然有效，返回 ErrCodeChecksumMismatch
+func decodeSSTableRowBinaryInto(data []byte, schema *Schema, fields []string, row *SSTableRow) error {
+	// 读取并验证 Magic Number，解析字段表位置（ROW1 或 ROW2）
+	layout, err := parseRowLayout(data)
+	if err != nil {
+		return err
 	}
 
+	// 读取 Seq、Time
+	row.Seq = int64(binary.LittleEndian.Uint64(data[4:12]))
+	row.Time = int64(binary.LittleEndian.Uint64(data[12:20]))
+	row.unpacked = !layout.packed
+	row.Deleted = layout.deleted
+
 	// 强制要求 Schema
 	if schema == nil {
-		return nil, fmt.Errorf("schema is required for decoding SSTable rows")
+		return fmt.Errorf("schema is required for decoding SSTable rows")
 	}
-
-	// 读取字段数量
-	var fieldCount uint16
-	if err := binary.Read(buf, binary.LittleEndian, &fieldCount); err != nil {
-		return nil, err
+	if err := layout.checkSchema(schema); err != nil {
+		return err
 	}
 
-	// 读取字段偏移表
-	type fieldInfo struct {
-		offset uint32
-		size   uint32
-	}
-	fieldInfos := make([]fieldInfo, fieldCount)
-	for i := range fieldInfos {
-		if err := binary.Read(buf, binary.LittleEndian, &fieldInfos[i].offset); err != nil {
-			return nil, err
-		}
-		if err := binary.Read(buf, binary.LittleEndian, &fieldInfos[i].size); err != nil {
-			return nil, err
-		}
+	if row.Data == nil {
+		row.Data = make(map[string]any, len(schema.Fields))
+	} else {
+		clear(row.Data)
 	}
 
-	// 构建需要读取的字段集合
-	needFields := make(map[string]bool)
-	if fields == nil {
-		// nil 表示读取所有字段
-		for _, f := range schema.Fields {
-			needFields[f.Name] = true
-		}
+	// 行校验和（旧数据没有）
+	dataEnd := layout.dataEnd(data)
+	content, checksum, hasChecksum := splitRowChecksum(data, dataEnd)
+	if hasChecksum {
+		row.Checksum = append(row.Checksum[:0], checksum...)
 	} else {
-		for _, f := range fields {
-			needFields[f] = true
-		}
+		row.Checksum = nil
 	}
 
-	// 数据区起始位置（当前 buf 位置）
-	dataStart := buf.Size() - int64(buf.Len())
+	fieldBuf := fieldReaderPool.Get().(*bytes.Reader)
+	defer func() {
+		fieldBuf.Reset(nil)
+		fieldReaderPool.Put(fieldBuf)
+	}()
 
-	// 按需读取和解压字段
+	// 按需读取和解压字段；slot、packed 为下一个字段表项与打包字段的序号
+	slot, packed := 0, 0
 	for i, field := range schema.Fields {
-		if i >= int(fieldCount) {
+		if i >= layout.fieldCount {
 			break
 		}
 
-		need := needFields[field.Name]
-		if !need {
-			// 跳过不需要的字段（不读取，不解压）
+		if layout.packed && isPackedField(field) {
+			k := packed
+			packed++
+			if fields != nil && !slices.Contains(fields, field.Name) {
+				continue
+			}
+			if value, ok := layout.packedBool(k); ok {
+				row.Data[field.Name] = value
+			}
 			continue
 		}
+		s := slot
+		slot++
 
-		// 读取字段数据（无压缩）
-		info := fieldInfos[i]
-		fieldPos := dataStart + int64(info.offset)
-
-		// Seek 到字段位置
-		if _, err := buf.Seek(fieldPos, 0); err != nil {
-			return nil, fmt.Errorf("seek to field %s: %w", field.Name, err)
+		// 跳过不需要的字段（不读取，不解压）；nil 表示读取所有字段
+		if fields != nil && !slices.Contains(fields, field.Name) {
+			continue
 		}
 
-		fieldData := make([]byte, info.size)
-		if _, err := buf.Read(fieldData); err != nil {
-			return nil, fmt.Errorf("read field %s: %w", field.Name, err)
+		// 读取字段数据（无压缩）
+		fieldData, err := layout.slot(data, s)
+		if err != nil {
+			return fmt.Errorf("read field %s: %w", field.Name, err)
+		}
+		if len(fieldData) == 0 {
+			// NULL 值，不写入 Data
+			continue
 		}
 
-		// 解析字段值（直接从二进制数据）
-		fieldBuf := bytes.NewReader(fieldData)
+		// 解析字段值（直接从二进制数据，不复制）
+		fieldBuf.Reset(fieldData)
 		value, err := readFieldBinaryValue(fieldBuf, field.Type, true)
 		if err != nil {
-			return nil, fmt.Errorf("parse field %s: %w", field.Name, err)
+			return fmt.Errorf("parse field %s: %w", field.Name, err)
 		}
 
 		if value != nil {
@@ -615,7 +770,14 @@ type fieldInfo struct {
 		}
 	}
 
-	return row, nil
+	// 完整解码时校验内容
+	if fields == nil && hasChecksum {
+		if sum := sha256.Sum256(content); !bytes.Equal(sum[:], checksum) {
+			return NewErrorf(ErrCodeChecksumMismatch, "row %d checksum mismatch", row.Seq)
+		}
+	}
+
+	return nil
 }
 
 // readFieldBinaryValue 读取字段值（二进制格式）
@@ -915,7 +1077,8 @@ type SSTableWriter struct {
 	maxKey     int64
 	minTime    int64
 	maxTime    int64
-	schema     *Schema // Schema 用于优化编码
+	schema     *Schema         // Schema 用于优化编码
+	zones      *zoneMapBuilder // 列统计信息（min/max）
 }
 
 // NewSSTableWriter 创建 SST 写入器
@@ -929,14 +1092,18 @@ func NewSSTableWriter(file *os.File, schema *Schema) *SSTableWriter {
 		minTime:    -1,
 		maxTime:    -1,
 		schema:     schema,
+		zones:      newZoneMapBuilder(schema),
 	}
 }
 
 // SSTableRow 表示一行数据
 type SSTableRow struct {
-	Seq  int64          // _seq
-	Time int64          // _time
-	Data map[string]any // 用户数据
+	Seq      int64          // _seq
+	Time     int64          // _time
+	Data     map[string]any // 用户数据
+	Checksum []byte         // 行内容的 SHA-256 校验和（插入时计算），旧数据为 nil
+	Deleted  bool           // 删除标记（Data 为空，见 Table.Delete）
+	unpacked bool           // 解码自 ROW1：校验和覆盖 ROW1 编码，重新编码时不能打包
 }
 
 // Add 添加一行数据
@@ -955,6 +1122,7 @@ func (w *SSTableWriter) Add(row *SSTableRow) error {
 		w.maxTime = row.Time
 	}
 	w.rowCount++
+	w.zones.add(row.Data)
 
 	// 序列化数据（使用 Schema 优化的二进制格式，无压缩）
 	data, err := encodeSSTableRow(row, w.schema)
@@ -966,9 +1134,7 @@ func (w *SSTableWriter) Add(row *SSTableRow) error {
 	// 第一次写入时，确定数据起始位置
 	if w.dataStart == 0 {
 		// 预留足够空间给 B+Tree 索引
-		// 假设索引最多占用 10% 的空间，最少 1 MB
-		estimatedIndexSize := int64(10 * 1024 * 1024) // 10 MB
-		w.dataStart = SSTableHeaderSize + estimatedIndexSize
+		w.dataStart = SSTableHeaderSize + sstIndexReserveSize
 		w.dataOffset = w.dataStart
 	}
 
@@ -1001,31 +1167,43 @@ func (w *SSTableWriter) Finish() error {
 	// 2. 计算索引大小
 	indexSize := w.dataStart - SSTableHeaderSize
 
-	// 3. 创建 Header
+	// 3. 在数据块之后写入列统计信息
+	var zoneMapOffset, zoneMapSize int64
+	if zoneMap := w.zones.encode(); zoneMap != nil && w.dataStart > 0 {
+		if _, err := w.file.WriteAt(zoneMap, w.dataOffset); err != nil {
+			return err
+		}
+		zoneMapOffset = w.dataOffset
+		zoneMapSize = int64(len(zoneMap))
+	}
+
+	// 4. 创建 Header
 	header := &SSTableHeader{
-		Magic:       SSTableMagicNumber,
-		Version:     SSTableVersion,
-		Compression: 0, // 不使用压缩（保留字段用于向后兼容）
-		IndexOffset: SSTableHeaderSize,
-		IndexSize:   indexSize,
-		RootOffset:  rootOffset,
-		DataOffset:  w.dataStart,
-		DataSize:    w.dataOffset - w.dataStart,
-		RowCount:    w.rowCount,
-		MinKey:      w.minKey,
-		MaxKey:      w.maxKey,
-		MinTime:     w.minTime,
-		MaxTime:     w.maxTime,
-	}
-
-	// 4. 写入 Header
+		Magic:         SSTableMagicNumber,
+		Version:       SSTableVersion,
+		Compression:   0, // 不使用压缩（保留字段用于向后兼容）
+		IndexOffset:   SSTableHeaderSize,
+		IndexSize:     indexSize,
+		RootOffset:    rootOffset,
+		DataOffset:    w.dataStart,
+		DataSize:      w.dataOffset - w.dataStart,
+		RowCount:      w.rowCount,
+		MinKey:        w.minKey,
+		MaxKey:        w.maxKey,
+		MinTime:       w.minTime,
+		MaxTime:       w.maxTime,
+		ZoneMapOffset: zoneMapOffset,
+		ZoneMapSize:   zoneMapSize,
+	}
+
+	// 5. 写入 Header
 	headerData := header.Marshal()
 	_, err = w.file.WriteAt(headerData, 0)
 	if err != nil {
 		return err
 	}
 
-	// 5. Sync 到磁盘
+	// 6. Sync 到磁盘
 	return w.file.Sync()
 }
 
@@ -1043,53 +1221,117 @@ func encodeSSTableRow(row *SSTableRow, schema *Schema) ([]byte, error) {
 type SSTableReader struct {
 	path     string
 	file     *os.File
-	mmap     mmap.MMap
+	src      dataSource   // 文件数据源（mmap 或 pread）
+	ioMode   IOMode       // 实际使用的读取方式
+	scans    atomic.Int32 // 进行中的顺序扫描数
+	scanMu   sync.Mutex   // 保护 closed 与 bypass，避免扫描结束时访问已关闭的数据源
+	closed   bool
+	bypass   bool // 进行中的扫描中有 BypassCache 的扫描已结束，最后一个扫描结束时释放页缓存
 	header   *SSTableHeader
 	btReader *BTreeReader
-	schema   *Schema // Schema 用于优化解码
+	index    *pinnedSource        // B+Tree 读取索引节点的数据源（见 pinIndex）
+	pin      indexPin             // 索引常驻状态
+	schema   *Schema              // Schema 用于优化解码
+	zones    map[string]zoneRange // 列统计信息，旧版本文件为 nil
 }
 
-// NewSSTableReader 创建 SST 读取器
+// NewSSTableReader 创建 SST 读取器（mmap 方式，失败时回退到 pread）
 func NewSSTableReader(path string) (*SSTableReader, error) {
+	return NewSSTableReaderWithIOMode(path, IOModeMmap)
+}
+
+// NewSSTableReaderWithIOMode 按指定读取方式创建 SST 读取器
+func NewSSTableReaderWithIOMode(path string, mode IOMode) (*SSTableReader, error) {
 	// 1. 打开文件
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. mmap 映射
-	mmapData, err := mmap.Map(file, mmap.RDONLY, 0)
+	// 2. 打开数据源（mmap 或 pread）
+	src, actualMode, err := openDataSource(file, mode)
 	if err != nil {
 		file.Close()
 		return nil, err
 	}
 
 	// 3. 读取 Header
-	if len(mmapData) < SSTableHeaderSize {
-		mmapData.Unmap()
+	headerData, err := src.Slice(0, SSTableHeaderSize)
+	if err != nil {
+		src.Close()
 		file.Close()
 		return nil, fmt.Errorf("file too small")
 	}
 
-	header := UnmarshalSSTableHeader(mmapData[:SSTableHeaderSize])
+	header := UnmarshalSSTableHeader(headerData)
 	if header == nil || !header.Validate() {
-		mmapData.Unmap()
+		src.Close()
 		file.Close()
 		return nil, fmt.Errorf("invalid header")
 	}
 
-	// 4. 创建 B+Tree Reader
-	btReader := NewBTreeReader(mmapData, header.RootOffset)
+	// 4. 创建 B+Tree Reader（索引节点常驻内存后从副本读取）
+	index := &pinnedSource{dataSource: src, offset: header.IndexOffset}
+	btReader := newBTreeReaderFromSource(index, header.RootOffset)
+
+	// 5. 读取列统计信息（损坏时忽略，查询退化为扫描全部文件）
+	var zones map[string]zoneRange
+	if header.ZoneMapSize > 0 {
+		if data, err := src.Slice(header.ZoneMapOffset, int(header.ZoneMapSize)); err == nil {
+			zones, _ = decodeZoneMap(data)
+		}
+	}
 
 	return &SSTableReader{
 		path:     path,
 		file:     file,
-		mmap:     mmapData,
+		src:      src,
+		ioMode:   actualMode,
 		header:   header,
 		btReader: btReader,
+		index:    index,
+		zones:    zones,
 	}, nil
 }
 
+// GetIOMode 获取实际使用的读取方式
+func (r *SSTableReader) GetIOMode() IOMode {
+	return r.ioMode
+}
+
+// beginScan 标记开始顺序扫描，第一个扫描开始时启用预读（数据源的预读与内核的 FADV_SEQUENTIAL）
+func (r *SSTableReader) beginScan() {
+	r.scanMu.Lock()
+	defer r.scanMu.Unlock()
+	if r.scans.Add(1) == 1 && !r.closed {
+		r.src.advise(accessSequential)
+		fadvise(r.file, accessSequential)
+	}
+}
+
+// endScan 标记顺序扫描结束，最后一个扫描结束时恢复随机访问模式
+//
+// bypassCache 为 true 时释放扫描读入的页缓存（解除映射后 FADV_DONTNEED）。
+// 与其他扫描重叠时推迟到最后一个扫描结束时释放，避免丢弃进行中的扫描正在预读的页。
+func (r *SSTableReader) endScan(bypassCache bool) {
+	r.scanMu.Lock()
+	defer r.scanMu.Unlock()
+	if bypassCache {
+		r.bypass = true
+	}
+	// compaction 可能已关闭文件，此时无需恢复访问模式
+	if r.scans.Add(-1) != 0 || r.closed {
+		return
+	}
+	if r.bypass {
+		r.bypass = false
+		r.src.advise(accessDontNeed)
+		fadvise(r.file, accessDontNeed)
+	}
+	r.src.advise(accessNormal)
+	fadvise(r.file, accessNormal)
+}
+
 // Get 查询一行数据
 func (r *SSTableReader) Get(key int64) (*SSTableRow, error) {
 	// 1. 检查范围
@@ -1104,19 +1346,50 @@ func (r *SSTableReader) Get(key int64) (*SSTableRow, error) {
 	}
 
 	// 3. 读取数据
-	if dataOffset+int64(dataSize) > int64(len(r.mmap)) {
-		return nil, fmt.Errorf("invalid data offset")
+	data, err := r.src.Slice(dataOffset, int(dataSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid data offset: %w", err)
 	}
 
-	data := r.mmap[dataOffset : dataOffset+int64(dataSize)]
+	// 4. 反序列化（无压缩），校验和不一致时同时返回解码结果与错误
+	return decodeSSTableRow(data, r.schema)
+}
+
+// getInto 查询一行数据并解码到 dst（复用 dst 的内存）
+func (r *SSTableReader) getInto(key int64, dst *SSTableRow) error {
+	if key < r.header.MinKey || key > r.header.MaxKey {
+		return fmt.Errorf("key out of range")
+	}
 
-	// 4. 反序列化（无压缩）
-	row, err := decodeSSTableRow(data, r.schema)
+	dataOffset, dataSize, found := r.btReader.Get(key)
+	if !found {
+		return fmt.Errorf("key not found")
+	}
+
+	data, err := r.src.Slice(dataOffset, int(dataSize))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid data offset: %w", err)
 	}
 
-	return row, nil
+	return decodeSSTableRowBinaryInto(data, r.schema, nil, dst)
+}
+
+// verifyRow 校验一行数据的校验和，found 表示文件中是否存在该行
+func (r *SSTableReader) verifyRow(key int64) (found bool, err error) {
+	if key < r.header.MinKey || key > r.header.MaxKey {
+		return false, nil
+	}
+
+	dataOffset, dataSize, found := r.btReader.Get(key)
+	if !found {
+		return false, nil
+	}
+
+	data, err := r.src.Slice(dataOffset, int(dataSize))
+	if err != nil {
+		return true, fmt.Errorf("invalid data offset: %w", err)
+	}
+	return true, verifyRowChecksum(data)
 }
 
 // GetPartial 按需查询一行数据（只读取指定字段）
@@ -1133,12 +1406,11 @@ func (r *SSTableReader) GetPartial(key int64, fields []string) (*SSTableRow, err
 	}
 
 	// 3. 读取数据
-	if dataOffset+int64(dataSize) > int64(len(r.mmap)) {
-		return nil, fmt.Errorf("invalid data offset")
+	data, err := r.src.Slice(dataOffset, int(dataSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid data offset: %w", err)
 	}
 
-	data := r.mmap[dataOffset : dataOffset+int64(dataSize)]
-
 	// 4. 按需反序列化（只解析需要的字段，无压缩）
 	row, err := decodeSSTableRowBinaryPartial(data, r.schema, fields)
 	if err != nil {
@@ -1182,8 +1454,13 @@ func (r *SSTableReader) ForEachDesc(callback KeyCallback) {
 
 // Close 关闭读取器
 func (r *SSTableReader) Close() error {
-	if r.mmap != nil {
-		r.mmap.Unmap()
+	r.scanMu.Lock()
+	r.closed = true
+	r.scanMu.Unlock()
+	r.unpinIndex()
+
+	if r.src != nil {
+		r.src.Close()
 	}
 	if r.file != nil {
 		return r.file.Close()
@@ -1196,7 +1473,8 @@ func decodeSSTableRow(data []byte, schema *Schema) (*SSTableRow, error) {
 	// 使用二进制格式解码
 	row, err := decodeSSTableRowBinary(data, schema)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode row: %w", err)
+		// 校验和不一致时 row 仍然有效
+		return row, fmt.Errorf("failed to decode row: %w", err)
 	}
 	return row, nil
 }
@@ -1207,10 +1485,21 @@ type SSTableManager struct {
 	readers []*SSTableReader
 	mu      sync.RWMutex
 	schema  *Schema // Schema 用于优化编解码
+	ioMode  IOMode  // SST 文件读取方式
+
+	metaCache *metadataCache // 元数据常驻预算，nil 表示禁用（见 SetMetadataCache）
+
+	pins    map[int64]int            // 被快照固定的文件编号及引用计数（见 Table.Snapshot）
+	retired map[int64]*SSTableReader // 已被 compaction 移除、等待快照释放后删除的文件
 }
 
 // NewSSTableManager 创建 SST 管理器
 func NewSSTableManager(dir string) (*SSTableManager, error) {
+	return NewSSTableManagerWithIOMode(dir, IOModeMmap)
+}
+
+// NewSSTableManagerWithIOMode 按指定读取方式创建 SST 管理器
+func NewSSTableManagerWithIOMode(dir string, mode IOMode) (*SSTableManager, error) {
 	// 确保目录存在
 	err := os.MkdirAll(dir, 0755)
 	if err != nil {
@@ -1220,6 +1509,7 @@ func NewSSTableManager(dir string) (*SSTableManager, error) {
 	mgr := &SSTableManager{
 		dir:     dir,
 		readers: make([]*SSTableReader, 0),
+		ioMode:  mode,
 	}
 
 	// 恢复现有的 SST 文件
@@ -1247,7 +1537,7 @@ func (m *SSTableManager) recover() error {
 		}
 
 		// 打开 SST Reader
-		reader, err := NewSSTableReader(file)
+		reader, err := NewSSTableReaderWithIOMode(file, m.ioMode)
 		if err != nil {
 			return err
 		}
@@ -1276,9 +1566,10 @@ func (m *SSTableManager) CreateSSTWithLevel(fileNumber int64, rows []*SSTableRow
 	defer m.mu.Unlock()
 
 	sstPath := filepath.Join(m.dir, fmt.Sprintf("%06d.sst", fileNumber))
+	tmpPath := sstPath + sstTempSuffix
 
-	// 创建文件
-	file, err := os.Create(sstPath)
+	// 创建临时文件
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		return nil, err
 	}
@@ -1290,23 +1581,28 @@ func (m *SSTableManager) CreateSSTWithLevel(fileNumber int64, rows []*SSTableRow
 		err = writer.Add(row)
 		if err != nil {
 			file.Close()
-			os.Remove(sstPath)
+			os.Remove(tmpPath)
 			return nil, err
 		}
 	}
 
-	// 完成写入
+	// 完成写入（Finish 会 fsync）
 	err = writer.Finish()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		file.Close()
-		os.Remove(sstPath)
+		os.Remove(tmpPath)
 		return nil, err
 	}
 
-	file.Close()
+	// 重命名为正式文件名
+	if err := publishSST(tmpPath, sstPath); err != nil {
+		return nil, err
+	}
 
 	// 打开 SST Reader
-	reader, err := NewSSTableReader(sstPath)
+	reader, err := NewSSTableReaderWithIOMode(sstPath, m.ioMode)
 	if err != nil {
 		return nil, err
 	}
@@ -1322,6 +1618,22 @@ func (m *SSTableManager) CreateSSTWithLevel(fileNumber int64, rows []*SSTableRow
 	return reader, nil
 }
 
+// publishSST 将写完并 fsync 的临时文件重命名为正式的 SST 文件，并同步目录使重命名持久化
+// 必须在 LogAndApply 之前调用：MANIFEST 引用的文件总是完整的
+func publishSST(tmpPath, path string) error {
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("publish sst: %w", err)
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// GetIOMode 获取配置的读取方式
+func (m *SSTableManager) GetIOMode() IOMode {
+	return m.ioMode
+}
+
 // SetSchema 设置 Schema（用于优化编解码）
 func (m *SSTableManager) SetSchema(schema *Schema) {
 	m.mu.Lock()
@@ -1340,16 +1652,46 @@ func (m *SSTableManager) Get(seq int64) (*SSTableRow, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// 从后往前查找（新的文件优先）
-	for i := len(m.readers) - 1; i >= 0; i-- {
-		reader := m.readers[i]
-		row, err := reader.Get(seq)
-		if err == nil {
-			return row, nil
+	// 同一 seq 存在多个版本时取最新的（见 Table.Update）
+	reader := m.latest(seq)
+	if reader == nil {
+		return nil, NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
+	}
+	row, err := reader.Get(seq)
+	if err != nil {
+		if IsError(err, ErrCodeChecksumMismatch) {
+			return nil, err
+		}
+		return nil, NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
+	}
+	return row, nil
+}
+
+// getInto 从所有 SST 文件中查找数据并解码到 dst
+func (m *SSTableManager) getInto(seq int64, dst *SSTableRow) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reader := m.latest(seq)
+	if reader == nil {
+		return NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
+	}
+	return reader.getInto(seq, dst)
+}
+
+// verifyRow 在所有 SST 文件中查找并校验一行数据
+func (m *SSTableManager) verifyRow(seq int64) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if reader := m.latest(seq); reader != nil {
+		if _, err := reader.verifyRow(seq); err != nil {
+			return err
 		}
+		return nil
 	}
 
-	return nil, fmt.Errorf("key not found: %d", seq)
+	return NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
 }
 
 // GetPartial 从所有 SST 文件中按需查找数据（只读取指定字段）
@@ -1357,20 +1699,32 @@ func (m *SSTableManager) GetPartial(seq int64, fields []string) (*SSTableRow, er
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// 从后往前查找（新的文件优先）
-	for i := len(m.readers) - 1; i >= 0; i-- {
-		reader := m.readers[i]
-		row, err := reader.GetPartial(seq, fields)
-		if err == nil {
+	if reader := m.latest(seq); reader != nil {
+		if row, err := reader.GetPartial(seq, fields); err == nil {
 			return row, nil
 		}
 	}
 
-	return nil, fmt.Errorf("key not found: %d", seq)
+	return nil, NewErrorf(ErrCodeNotFound, "key not found: %d", seq)
+}
+
+// pinIndex 点查询访问 key 范围包含 seq 的文件时，尝试使其索引节点常驻内存
+func (m *SSTableManager) pinIndex(reader *SSTableReader, seq int64) {
+	if m.metaCache != nil && seq >= reader.header.MinKey && seq <= reader.header.MaxKey {
+		reader.pinIndex(m.metaCache)
+	}
 }
 
 // RemoveReader 移除指定文件编号的 reader（用于 compaction）
 func (m *SSTableManager) RemoveReader(fileNumber int64) error {
+	_, err := m.retire(fileNumber)
+	return err
+}
+
+// retire 移除指定文件编号的 reader，返回文件是否仍被快照固定
+//
+// 被固定的文件保持打开，在最后一个快照释放时关闭并删除，调用者不能删除该文件
+func (m *SSTableManager) retire(fileNumber int64) (bool, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -1381,17 +1735,25 @@ func (m *SSTableManager) RemoveReader(fileNumber int64) error {
 		var readerFileNum int64
 		if _, err := fmt.Sscanf(filename, "%d.sst", &readerFileNum); err == nil {
 			if readerFileNum == fileNumber {
-				// 关闭 reader
-				reader.Close()
-
 				// 从列表中移除
 				m.readers = append(m.readers[:i], m.readers[i+1:]...)
-				return nil
+
+				if m.pins[fileNumber] > 0 {
+					if m.retired == nil {
+						m.retired = make(map[int64]*SSTableReader)
+					}
+					m.retired[fileNumber] = reader
+					return true, nil
+				}
+
+				// 关闭 reader
+				reader.Close()
+				return false, nil
 			}
 		}
 	}
 
-	return fmt.Errorf("reader for file %d not found", fileNumber)
+	return false, fmt.Errorf("reader for file %d not found", fileNumber)
 }
 
 // AddReader 添加 reader 到管理器（用于 compaction 创建的新文件）
@@ -1422,6 +1784,34 @@ func (m *SSTableManager) GetReaders() []*SSTableReader {
 	return readers
 }
 
+// snapshotKeys 固定可能匹配 conds 且与时间范围相交的 SST 文件（按 MinKey 排序）及其 key
+//
+// 持有读锁读取 key，期间文件不会被 compaction 关闭；scan 为 true 时同时标记顺序扫描开始，
+// 调用者在扫描结束后对返回的 reader 调用 endScan
+func (m *SSTableManager) snapshotKeys(conds []Expr, window timeWindow, scan bool) ([]*SSTableReader, [][]int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	readers := make([]*SSTableReader, 0, len(m.readers))
+	for _, reader := range m.readers {
+		if reader.mayMatch(conds) && window.overlaps(reader.header.MinTime, reader.header.MaxTime) {
+			readers = append(readers, reader)
+		}
+	}
+	sort.Slice(readers, func(i, j int) bool {
+		return readers[i].header.MinKey < readers[j].header.MinKey
+	})
+
+	keys := make([][]int64, len(readers))
+	for i, reader := range readers {
+		if scan {
+			reader.beginScan()
+		}
+		keys[i] = reader.GetAllKeys()
+	}
+	return readers, keys
+}
+
 // GetMaxSeq 获取所有 SST 中的最大 seq
 func (m *SSTableManager) GetMaxSeq() int64 {
 	m.mu.RLock()
@@ -1467,8 +1857,13 @@ func (m *SSTableManager) Close() error {
 	for _, reader := range m.readers {
 		reader.Close()
 	}
+	for _, reader := range m.retired {
+		reader.Close()
+	}
 
 	m.readers = nil
+	m.pins = nil
+	m.retired = nil
 	return nil
 }
 
@@ -0,0 +1,75 @@
+package srdb
+
+import "fmt"
+
+// SSTableIterator 按 seq 升序遍历 SST 文件的行
+//
+// 只在需要时读取 B+Tree 的叶子节点，不需要像 GetAllKeys 那样先加载所有 key，
+// 适合按顺序扫描大文件（Compaction 归并、外部工具导出）。
+// 迭代器不是并发安全的；SSTableReader 关闭后不能再使用。
+//
+//	it := reader.NewIterator()
+//	for ok := it.SeekGE(1000); ok; ok = it.Next() {
+//	    row, err := it.Row()
+//	    ...
+//	}
+type SSTableIterator struct {
+	r      *SSTableReader
+	cursor *btreeCursor
+
+	valid  bool
+	key    int64
+	offset int64
+	size   int32
+}
+
+// NewIterator 创建迭代器，初始位于第一行之前：第一次调用 Next 时移动到第一行
+func (r *SSTableReader) NewIterator() *SSTableIterator {
+	return &SSTableIterator{r: r, cursor: r.btReader.cursor()}
+}
+
+// First 移动到第一行，文件为空时返回 false
+func (it *SSTableIterator) First() bool {
+	it.cursor = it.r.btReader.cursor()
+	return it.Next()
+}
+
+// SeekGE 移动到第一个 seq >= 给定值的行，不存在时返回 false
+// （不命名为 Seek，避免与 io.Seeker 的签名混淆）
+func (it *SSTableIterator) SeekGE(seq int64) bool {
+	it.cursor.seek(seq)
+	return it.Next()
+}
+
+// Next 移动到下一行，没有更多行时返回 false
+func (it *SSTableIterator) Next() bool {
+	it.key, it.offset, it.size, it.valid = it.cursor.next()
+	return it.valid
+}
+
+// Valid 当前是否位于某一行
+func (it *SSTableIterator) Valid() bool {
+	return it.valid
+}
+
+// Key 返回当前行的 seq
+func (it *SSTableIterator) Key() int64 {
+	return it.key
+}
+
+// Row 解码当前行（同 SSTableReader.Get：校验和不一致时同时返回解码结果与错误）
+func (it *SSTableIterator) Row() (*SSTableRow, error) {
+	data, err := it.raw()
+	if err != nil {
+		return nil, err
+	}
+	return decodeSSTableRow(data, it.r.schema)
+}
+
+// raw 返回当前行的原始编码（零拷贝，读取器关闭后失效）
+func (it *SSTableIterator) raw() ([]byte, error) {
+	if !it.valid {
+		return nil, fmt.Errorf("iterator is not positioned at a row")
+	}
+	return it.r.src.Slice(it.offset, int(it.size))
+}
@@ -0,0 +1,88 @@
+package srdb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSSTableIterator(t *testing.T) {
+	dir := t.TempDir()
+	schema, err := NewSchema("test", []Field{{Name: "v", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 稀疏的 seq（偶数），跨越多个叶子节点与内部节点
+	n := int64(BTreeOrder*BTreeOrder + 500)
+	rows := make([]*SSTableRow, 0, n)
+	for i := int64(1); i <= n; i++ {
+		rows = append(rows, &SSTableRow{Seq: i * 2, Time: i, Data: map[string]any{"v": i}})
+	}
+	writeTestSST(t, dir, 1, schema, rows)
+
+	reader, err := NewSSTableReader(filepath.Join(dir, "000001.sst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	reader.SetSchema(schema)
+
+	// 初始位于第一行之前
+	it := reader.NewIterator()
+	if it.Valid() {
+		t.Fatal("new iterator should not be valid")
+	}
+	if _, err := it.Row(); err == nil {
+		t.Error("Row should fail before Next")
+	}
+	var count int64
+	for it.Next() {
+		count++
+		if it.Key() != count*2 {
+			t.Fatalf("entry %d: got seq %d", count, it.Key())
+		}
+	}
+	if count != n || it.Valid() {
+		t.Fatalf("expected %d rows, got %d", n, count)
+	}
+
+	// First 重新从头开始
+	if !it.First() || it.Key() != 2 {
+		t.Fatalf("First: got %d", it.Key())
+	}
+	row, err := it.Row()
+	if err != nil || row.Seq != 2 || row.Data["v"] != int64(1) {
+		t.Fatalf("Row: %+v, %v", row, err)
+	}
+
+	tests := []struct {
+		seek int64
+		want int64 // 0 表示不存在
+	}{
+		{0, 2},
+		{2, 2},
+		{3, 4},
+		{401, 402}, // 叶子节点边界附近
+		{int64(BTreeOrder) * 2, int64(BTreeOrder) * 2},
+		{int64(BTreeOrder)*2 + 1, int64(BTreeOrder)*2 + 2},
+		{n * 2, n * 2},
+		{n*2 + 1, 0},
+	}
+	for _, tt := range tests {
+		ok := it.SeekGE(tt.seek)
+		if tt.want == 0 {
+			if ok {
+				t.Errorf("SeekGE(%d): expected no row, got %d", tt.seek, it.Key())
+			}
+			continue
+		}
+		if !ok || it.Key() != tt.want {
+			t.Errorf("SeekGE(%d): got %d (ok=%v), want %d", tt.seek, it.Key(), ok, tt.want)
+			continue
+		}
+		// SeekGE 之后继续按顺序读取
+		if tt.want < n*2 && (!it.Next() || it.Key() != tt.want+2) {
+			t.Errorf("Next after SeekGE(%d): got %d", tt.seek, it.Key())
+		}
+	}
+}
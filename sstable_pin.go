@@ -0,0 +1,179 @@
+package srdb
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 元数据常驻：把 SST 文件的 B+Tree 索引节点复制到堆内存中，不受扫描影响
+//
+// 点查询需要先读取索引节点再读取数据。大范围扫描会把页缓存中的索引页挤出
+// （BypassCache 扫描结束时还会主动释放整个文件的缓存），之后的点查询就要重新从磁盘读取索引。
+// 文件第一次被点查询访问时，在预算允许的情况下复制其索引节点并一直保留到文件关闭，
+// 预算由同一数据库的所有表共享；扫描不会触发复制。列统计信息（zone map）在打开文件时已解码到内存。
+
+// DefaultMetadataCacheSize 默认的元数据常驻预算
+const DefaultMetadataCacheSize = 32 * 1024 * 1024 // 32 MB
+
+// MetadataCacheStats 元数据常驻统计信息
+type MetadataCacheStats struct {
+	Budget      int64 // 预算（字节），0 表示已禁用
+	PinnedBytes int64 // 已常驻的索引字节数
+	PinnedFiles int64 // 索引已常驻的 SST 文件数
+	Rejected    int64 // 因预算不足未能常驻的次数（每次点查询重试都会计数）
+}
+
+// metadataCache 元数据常驻预算（只记账，数据由各 SSTableReader 持有）
+type metadataCache struct {
+	budget   int64
+	used     atomic.Int64
+	files    atomic.Int64
+	rejected atomic.Int64
+}
+
+// newMetadataCache 创建预算，size <= 0 时返回 nil（禁用）
+func newMetadataCache(size int64) *metadataCache {
+	if size <= 0 {
+		return nil
+	}
+	return &metadataCache{budget: size}
+}
+
+// reserve 尝试占用 n 字节预算
+func (c *metadataCache) reserve(n int64) bool {
+	for {
+		used := c.used.Load()
+		if used+n > c.budget {
+			c.rejected.Add(1)
+			return false
+		}
+		if c.used.CompareAndSwap(used, used+n) {
+			c.files.Add(1)
+			return true
+		}
+	}
+}
+
+// release 归还 n 字节预算
+func (c *metadataCache) release(n int64) {
+	c.used.Add(-n)
+	c.files.Add(-1)
+}
+
+// stats 返回统计信息（c 为 nil 时返回零值）
+func (c *metadataCache) stats() MetadataCacheStats {
+	if c == nil {
+		return MetadataCacheStats{}
+	}
+	return MetadataCacheStats{
+		Budget:      c.budget,
+		PinnedBytes: c.used.Load(),
+		PinnedFiles: c.files.Load(),
+		Rejected:    c.rejected.Load(),
+	}
+}
+
+// pinnedSource 索引节点的数据源：常驻后从内存副本读取，否则读取文件
+type pinnedSource struct {
+	dataSource
+	offset int64                  // 副本在文件中的起始位置
+	data   atomic.Pointer[[]byte] // 常驻的副本，nil 表示未常驻
+}
+
+// Slice 返回 [off, off+n) 的数据，范围在副本内时不访问文件
+func (s *pinnedSource) Slice(off int64, n int) ([]byte, error) {
+	if p := s.data.Load(); p != nil {
+		data := *p
+		if start := off - s.offset; start >= 0 && n >= 0 && start+int64(n) <= int64(len(data)) {
+			return data[start : start+int64(n)], nil
+		}
+	}
+	return s.dataSource.Slice(off, n)
+}
+
+// indexPin SSTableReader 的索引常驻状态
+type indexPin struct {
+	mu    sync.Mutex
+	cache *metadataCache // 占用的预算，nil 表示未常驻
+	size  int64
+	skip  atomic.Bool // 文件没有可常驻的索引（空文件或索引范围异常）
+}
+
+// pinIndex 使索引节点常驻内存（已常驻、预算不足或文件已关闭时直接返回）
+//
+// 索引节点从 IndexOffset 开始连续写入，根节点最后写入，
+// 因此 [IndexOffset, RootOffset+BTreeNodeSize) 即为全部节点。
+func (r *SSTableReader) pinIndex(cache *metadataCache) {
+	if cache == nil || r.IndexPinned() || r.pin.skip.Load() {
+		return
+	}
+	if !r.pin.mu.TryLock() {
+		return // 其他点查询正在复制
+	}
+	defer r.pin.mu.Unlock()
+	if r.pin.cache != nil {
+		return
+	}
+
+	size := r.header.RootOffset + BTreeNodeSize - r.header.IndexOffset
+	if r.header.RowCount == 0 || size <= 0 || size > r.header.IndexSize {
+		r.pin.skip.Store(true)
+		return
+	}
+	if !cache.reserve(size) {
+		return
+	}
+
+	r.scanMu.Lock()
+	defer r.scanMu.Unlock()
+	if r.closed {
+		cache.release(size)
+		return
+	}
+	data, err := r.src.Slice(r.header.IndexOffset, int(size))
+	if err != nil {
+		cache.release(size)
+		r.pin.skip.Store(true)
+		return
+	}
+	pinned := make([]byte, len(data))
+	copy(pinned, data)
+	r.index.data.Store(&pinned)
+	r.pin.cache = cache
+	r.pin.size = size
+}
+
+// unpinIndex 释放常驻的索引节点并归还预算
+func (r *SSTableReader) unpinIndex() {
+	r.pin.mu.Lock()
+	defer r.pin.mu.Unlock()
+	if r.pin.cache == nil {
+		return
+	}
+	r.index.data.Store(nil)
+	r.pin.cache.release(r.pin.size)
+	r.pin.cache = nil
+	r.pin.size = 0
+}
+
+// IndexPinned 索引节点是否已常驻内存
+func (r *SSTableReader) IndexPinned() bool {
+	return r.index != nil && r.index.data.Load() != nil
+}
+
+// SetMetadataCache 设置元数据常驻预算（nil 表示禁用），此后点查询访问的文件会尝试常驻索引
+func (m *SSTableManager) SetMetadataCache(cache *metadataCache) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metaCache = cache
+}
+
+// MetadataCacheStats 返回元数据常驻统计信息（预算由同一数据库的所有表共享）
+func (t *Table) MetadataCacheStats() MetadataCacheStats {
+	return t.metaCache.stats()
+}
+
+// MetadataCacheStats 返回元数据常驻统计信息（所有表共享同一预算）
+func (db *Database) MetadataCacheStats() MetadataCacheStats {
+	return db.options.metaCache.stats()
+}
@@ -487,3 +487,94 @@ func TestSSTablePartialReadingPerformance(t *testing.T) {
 
 	t.Log("Partial reading performance test passed!")
 }
+
+func TestSSTableIndexPinning(t *testing.T) {
+	schema, err := NewSchema("test", []Field{
+		{Name: "name", Type: String},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr, err := NewSSTableManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+	mgr.SetSchema(schema)
+
+	newRows := func(from, to int64) []*SSTableRow {
+		rows := make([]*SSTableRow, 0, to-from+1)
+		for i := from; i <= to; i++ {
+			rows = append(rows, &SSTableRow{Seq: i, Time: i, Data: map[string]any{"name": fmt.Sprintf("user_%d", i)}})
+		}
+		return rows
+	}
+	// 5000 行：多个叶子节点加一个根节点
+	first, err := mgr.CreateSST(1, newRows(1, 5000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := mgr.CreateSST(2, newRows(5001, 10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexSize := first.GetHeader().RootOffset + BTreeNodeSize - first.GetHeader().IndexOffset
+	if indexSize <= BTreeNodeSize {
+		t.Fatalf("expected multi-node index, got %d bytes", indexSize)
+	}
+
+	// 预算只够一个文件
+	cache := newMetadataCache(indexSize + indexSize/2)
+	mgr.SetMetadataCache(cache)
+
+	// 扫描不会使索引常驻
+	first.ForEach(func(key int64, dataOffset int64, dataSize int32) bool { return true })
+	if first.IndexPinned() {
+		t.Fatal("scan should not pin index")
+	}
+
+	// 点查询使索引常驻，结果与读取文件时一致
+	row, err := mgr.Get(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.IndexPinned() || second.IndexPinned() {
+		t.Fatalf("expected only the first file pinned, got %v %v", first.IndexPinned(), second.IndexPinned())
+	}
+	if row.Data["name"] != "user_42" {
+		t.Errorf("unexpected row %v", row.Data)
+	}
+	for _, seq := range []int64{1, 2500, 5000} {
+		row, err := mgr.Get(seq)
+		if err != nil || row.Seq != seq {
+			t.Fatalf("get %d from pinned index: %v %v", seq, row, err)
+		}
+	}
+	stats := cache.stats()
+	if stats.PinnedBytes != indexSize || stats.PinnedFiles != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// 超出预算的文件仍然读取磁盘
+	if _, err := mgr.GetPartial(6000, []string{"name"}); err != nil {
+		t.Fatal(err)
+	}
+	if second.IndexPinned() || cache.stats().Rejected == 0 {
+		t.Errorf("expected second file rejected, stats %+v", cache.stats())
+	}
+
+	// 关闭文件后归还预算，其他文件可以常驻
+	if err := mgr.RemoveReader(1); err != nil {
+		t.Fatal(err)
+	}
+	if stats := cache.stats(); stats.PinnedBytes != 0 || stats.PinnedFiles != 0 {
+		t.Errorf("expected budget released, got %+v", stats)
+	}
+	if _, err := mgr.Get(6000); err != nil {
+		t.Fatal(err)
+	}
+	if !second.IndexPinned() {
+		t.Error("expected second file pinned after budget released")
+	}
+}
@@ -0,0 +1,128 @@
+package srdb
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultStatsInterval SubscribeStats 未指定间隔时的默认值
+const DefaultStatsInterval = time.Second
+
+// StatsSnapshot 一次定期采集的表统计信息
+type StatsSnapshot struct {
+	Table    string        // 表名
+	Time     time.Time     // 采集时间
+	Stats    TableStats    // MemTable、SST 文件数、总行数等
+	FlushLag FlushLagStats // flush 积压
+	RowSizes RowSizeStats  // 写入的行大小与值大小分布
+}
+
+// statsSubscription 一个统计信息订阅
+type statsSubscription struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+// cancel 停止订阅（可以重复调用）
+func (s *statsSubscription) cancel() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// SubscribeStats 订阅定期的统计信息快照，返回取消订阅的函数
+//
+// 订阅后立即采集一次，之后每隔 interval（<= 0 时为 DefaultStatsInterval）采集一次，
+// 在订阅自己的 goroutine 中调用 fn。fn 执行期间到期的采集会被跳过，不会堆积；
+// 可以据此在 SST 文件数或 flush 积压超过阈值时降低写入速度，或在行大小异常时告警。表关闭时订阅自动结束。
+//
+//	cancel := table.SubscribeStats(5*time.Second, func(s srdb.StatsSnapshot) {
+//		if s.Stats.SSTCount > 200 || s.FlushLag.ImmutableCount > 2 {
+//			limiter.SetLimit(slow)
+//		}
+//	})
+//	defer cancel()
+func (t *Table) SubscribeStats(interval time.Duration, fn func(StatsSnapshot)) (cancel func()) {
+	if interval <= 0 {
+		interval = DefaultStatsInterval
+	}
+	sub := &statsSubscription{stop: make(chan struct{})}
+	if fn == nil {
+		return sub.cancel
+	}
+
+	t.statsSubsMu.Lock()
+	if t.closed.Load() {
+		t.statsSubsMu.Unlock()
+		return sub.cancel
+	}
+	if t.statsSubs == nil {
+		t.statsSubs = make(map[*statsSubscription]struct{})
+	}
+	t.statsSubs[sub] = struct{}{}
+	t.statsSubsMu.Unlock()
+
+	go t.runStatsSubscription(sub, interval, fn)
+
+	return func() {
+		sub.cancel()
+		t.statsSubsMu.Lock()
+		delete(t.statsSubs, sub)
+		t.statsSubsMu.Unlock()
+	}
+}
+
+// runStatsSubscription 定期采集统计信息并调用 fn，直到取消订阅或表关闭
+func (t *Table) runStatsSubscription(sub *statsSubscription, interval time.Duration, fn func(StatsSnapshot)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		snapshot, err := t.statsSnapshot()
+		if err == ErrTableClosed {
+			return
+		}
+		if err != nil {
+			t.logger.Warn("[Table] Collect stats snapshot failed", "dir", t.dir, "error", err)
+		} else {
+			// 不持有任何锁调用，fn 中可以访问表（包括关闭表）
+			fn(snapshot)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-sub.stop:
+			return
+		}
+	}
+}
+
+// statsSnapshot 采集一次统计信息，表关闭时返回 ErrTableClosed
+func (t *Table) statsSnapshot() (StatsSnapshot, error) {
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return StatsSnapshot{}, err
+	}
+	defer done()
+
+	lag, err := t.FlushLag()
+	if err != nil {
+		return StatsSnapshot{}, err
+	}
+	return StatsSnapshot{
+		Table:    t.schema.Load().Name,
+		Time:     t.clock.Now(),
+		Stats:    *t.Stats(),
+		FlushLag: *lag,
+		RowSizes: t.RowSizes(),
+	}, nil
+}
+
+// stopStatsSubscriptions 结束所有统计信息订阅（表关闭时调用）
+func (t *Table) stopStatsSubscriptions() {
+	t.statsSubsMu.Lock()
+	defer t.statsSubsMu.Unlock()
+
+	for sub := range t.statsSubs {
+		sub.cancel()
+	}
+	t.statsSubs = nil
+}
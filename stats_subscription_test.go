@@ -0,0 +1,96 @@
+package srdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribeStats(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "metrics",
+		Fields: []Field{
+			{Name: "value", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 10 {
+		if err := table.Insert(map[string]any{"value": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots := make(chan StatsSnapshot, 100)
+	cancel := table.SubscribeStats(10*time.Millisecond, func(s StatsSnapshot) {
+		snapshots <- s
+	})
+
+	// 订阅后立即采集一次
+	select {
+	case s := <-snapshots:
+		if s.Table != "metrics" {
+			t.Errorf("expected table metrics, got %q", s.Table)
+		}
+		if s.Stats.TotalRows != 10 || s.Stats.MemTableCount != 10 {
+			t.Errorf("unexpected stats %+v", s.Stats)
+		}
+		if s.FlushLag.OldestUnflushedSeq != 1 || s.FlushLag.PendingWALBytes == 0 {
+			t.Errorf("unexpected flush lag %+v", s.FlushLag)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no initial snapshot")
+	}
+
+	// 之后定期采集，反映最新状态
+	table.Flush()
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	deadline := time.After(2 * time.Second)
+	for {
+		var s StatsSnapshot
+		select {
+		case s = <-snapshots:
+		case <-deadline:
+			t.Fatal("no snapshot after flush")
+		}
+		if s.Stats.SSTCount == 1 && s.FlushLag.OldestUnflushedSeq == 0 {
+			break
+		}
+	}
+
+	// 取消后不再调用
+	cancel()
+	cancel()
+	time.Sleep(30 * time.Millisecond)
+	for len(snapshots) > 0 {
+		<-snapshots
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(snapshots); n != 0 {
+		t.Errorf("expected no snapshots after cancel, got %d", n)
+	}
+
+	// 表关闭时订阅自动结束
+	table.SubscribeStats(10*time.Millisecond, func(s StatsSnapshot) {
+		snapshots <- s
+	})
+	<-snapshots
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	for len(snapshots) > 0 {
+		<-snapshots
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(snapshots); n != 0 {
+		t.Errorf("expected no snapshots after close, got %d", n)
+	}
+	if len(table.statsSubs) != 0 {
+		t.Errorf("expected subscriptions to be removed on close")
+	}
+}
@@ -1,13 +1,17 @@
 package srdb
 
 import (
+	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -23,22 +27,85 @@
 // Table 表
 type Table struct {
 	dir               string
-	schema            *Schema
+	schema            atomic.Pointer[Schema] // 当前 Schema，UpdateSchemaMetadata 整体替换
 	indexManager      *IndexManager
+	caggs             *continuousManager // 连续聚合
+	writers           *writerGate        // 写入者并发限制，nil 表示不限制
 	walManager        *WALManager        // WAL 管理器
 	sstManager        *SSTableManager    // SST 管理器
 	memtableManager   *MemTableManager   // MemTable 管理器
 	versionSet        *VersionSet        // MANIFEST 管理器
 	compactionManager *CompactionManager // Compaction 管理器
 	logger            *slog.Logger       // 日志器
+	logs              *logRegistry       // 子系统日志器（WAL、flush、查询），nil 表示丢弃
 	seq               atomic.Int64
 	flushMu           sync.Mutex
+	scheduler         *readScheduler // 查询优先级调度器
+	ioMode            IOMode         // SST 与索引文件读取方式
+	rowChecksum       bool           // 插入时是否计算行校验和
+	walCompression    bool           // WAL 记录是否使用 Snappy 压缩
+	paranoidChecks    bool           // Compaction 是否校验输出文件
+	faults            *FaultInjector // 故障注入（仅测试）
+	clock             Clock          // 时间来源（_time、GC 文件年龄、自动 flush）
+	naming            NamingStrategy // 结构体字段名的命名规则（Insert/Scan）
+	metaCache         *metadataCache // SST 索引节点常驻预算，nil 表示禁用
+	batches           *batchLog      // 原子批量写入的提交记录（恢复 WAL 时使用），nil 表示不在数据库中
 
 	// 自动 flush 相关
-	autoFlushTimeout time.Duration
-	lastWriteTime    atomic.Int64 // 最后写入时间（UnixNano）
+	autoFlushTimeout atomic.Int64  // 自动 flush 超时时间（time.Duration）
+	autoFlushConfig  chan struct{} // 超时时间变更通知
+	lastWriteTime    atomic.Int64  // 最后写入时间（UnixNano）
 	stopAutoFlush    chan struct{}
 	stopAutoFlushMu  sync.RWMutex // 保护 stopAutoFlush 的访问
+
+	// Flush 监听器
+	flushListeners   []func(FlushEvent)
+	flushListenersMu sync.RWMutex
+
+	// 文件事件监听器（见 OnFileEvent）
+	fileListeners   []func(FileEvent)
+	fileListenersMu sync.RWMutex
+
+	// 生命周期：读取持有读锁，Clean/Close/Destroy 持有写锁（等待进行中的读取完成）
+	lifecycleMu sync.RWMutex
+	epoch       atomic.Int64 // 每次 Clean/Close 递增，之前创建的迭代器随之失效
+	closed      atomic.Bool
+	iterators   atomic.Int64 // 未关闭的惰性迭代器数量
+
+	durability *durabilityTracker // 持久化水位（WaitDurable）
+	inserted   insertSignal       // 新行可见通知（Tail）
+
+	rowPolicy     atomic.Pointer[RowPolicy] // 行级可见性策略（见 SetRowPolicy），nil 表示不限制
+	missingFields atomic.Int32              // 查询结果中缺失字段的处理方式（见 SetMissingFieldPolicy）
+	decimals      atomic.Int32              // Decimal 与数值、字符串之间的转换方式（见 SetDecimalConversion）
+
+	retention   atomic.Pointer[RetentionPolicy] // 保留策略（见 SetRetentionPolicy），nil 表示永久保留
+	retentionMu sync.Mutex                      // 串行化 SetRetentionPolicy
+
+	mutations *mutationSet // 被 Update/Delete 修改过的 seq
+	mutateMu  sync.Mutex   // 串行化 Update/Delete
+	schemaMu  sync.Mutex   // 串行化 UpdateSchemaMetadata
+
+	// 统计信息订阅（见 SubscribeStats），表关闭时全部结束
+	statsSubs   map[*statsSubscription]struct{}
+	statsSubsMu sync.Mutex
+
+	rowSizes rowSizeStats // 写入的行大小与值大小分布（见 RowSizes）
+
+	shadow atomic.Pointer[shadowWriter] // 异步镜像写入的影子表（见 SetShadow），nil 表示没有
+
+	replicas   atomic.Pointer[[]replicaSubscription] // 复制流的订阅（见 ServeReplica），写时复制，nil 表示没有
+	replicasMu sync.Mutex                            // 串行化订阅与取消订阅
+}
+
+// FlushEvent 一次 MemTable flush 完成后的事件
+// 触发时数据已写入 SST 并记录到 MANIFEST，对应的 WAL 已删除
+type FlushEvent struct {
+	Table     string       // 表名
+	MinSeq    int64        // 本次 flush 的最小 seq
+	MaxSeq    int64        // 本次 flush 的最大 seq
+	WALNumber int64        // 对应的 WAL 编号
+	File      FileMetadata // 新生成的 SST 文件元数据
 }
 
 // TableOptions 配置选项
@@ -48,6 +115,63 @@ type TableOptions struct {
 	Name             string        // 表名
 	Fields           []Field       // 字段列表（可选）
 	AutoFlushTimeout time.Duration // 自动 flush 超时时间，0 表示禁用
+
+	// IOMode SST 与索引文件读取方式，默认 IOModeMmap（映射失败时自动回退到 pread）
+	IOMode IOMode
+
+	// LowPriorityReadRate 存在高优先级请求时，低优先级查询每秒允许的 SST 读取次数
+	// 0 表示使用 DefaultLowPriorityReadRate
+	LowPriorityReadRate int
+
+	// RowChecksum 插入时计算每行内容的 SHA-256 校验和并随行存储（每行增加 36 字节），
+	// 读取时自动校验，见 Row.Checksum 与 Table.VerifyRow
+	RowChecksum bool
+
+	// NamingStrategy 插入结构体与 Scan 到结构体时，没有 srdb tag 指定字段名的字段的命名规则
+	// nil 表示 SnakeCase；应与生成 Schema 时 StructToFieldsWithNaming 使用的规则一致
+	NamingStrategy NamingStrategy
+
+	// WALCompression 使用 Snappy 压缩 WAL 记录（每条记录单独标记，可随时开启或关闭），
+	// 适合行数据较大、WAL 写入量占主导的场景；不影响 SST 文件
+	WALCompression bool
+
+	// ParanoidCompactionChecks Compaction 删除输入文件之前重新读取新的 SST 文件，
+	// 与输入逐行比对行数、seq 与 CRC32（见 Options.ParanoidCompactionChecks）
+	ParanoidCompactionChecks bool
+
+	// MetadataCacheSize SST 索引节点常驻内存的预算（字节），点查询访问的文件在预算内常驻索引，
+	// 不受扫描挤出页缓存的影响；0 表示使用 DefaultMetadataCacheSize，负数表示禁用
+	MetadataCacheSize int64
+
+	// OnFileEvent WAL、SST 文件创建或删除后调用（见 FileEvent），也可以在打开后通过 Table.OnFileEvent 注册
+	OnFileEvent func(FileEvent)
+
+	// MaxConcurrentWriters 同时执行插入的 goroutine 数量上限，超出的写入者按到达顺序排队，
+	// 由获得名额的写入者合并写入；0 表示不限制
+	MaxConcurrentWriters int
+
+	// FaultInjector 故障注入器，仅用于测试（见 FaultInjector 与 Table.SimulateCrash）
+	FaultInjector *FaultInjector
+
+	// Clock 时间来源，nil 表示系统时间；测试中可以使用 ManualClock 推进时间（见 Clock）
+	Clock Clock
+
+	// Sequence 从多张表共享的序列分配 _seq（见 Sequence），nil 表示按表分配
+	Sequence *Sequence
+
+	// MissingFields 查询结果中缺失字段（新增字段之前写入的行、NULL 值）的处理方式，
+	// 默认 MissingFieldOmit，打开后可以通过 Table.SetMissingFieldPolicy 修改
+	MissingFields MissingFieldPolicy
+
+	// DecimalConversion Decimal 与数值、字符串字段之间写入和扫描时的转换方式，
+	// 默认 DecimalLenient，打开后可以通过 Table.SetDecimalConversion 修改
+	DecimalConversion DecimalConversion
+
+	// Shadow 将写入异步镜像到的影子表（见 Table.SetShadow），打开后也可以通过 SetShadow 设置
+	Shadow *Table
+
+	metaCache *metadataCache // 数据库共享的常驻预算（设置时忽略 MetadataCacheSize）
+	batches   *batchLog      // 数据库的原子批量写入提交记录，nil 时恢复忽略所有批量写入的记录
 }
 
 // OpenTable 打开数据库
@@ -89,18 +213,8 @@ func OpenTable(opts *TableOptions) (*Table, error) {
 			return nil, fmt.Errorf("create schema: %w", err)
 		}
 		// 保存到磁盘（带校验和）
-		schemaPath := filepath.Join(opts.Dir, "schema.json")
-		schemaFile, err := NewSchemaFile(sch)
-		if err != nil {
-			return nil, fmt.Errorf("create schema file: %w", err)
-		}
-		schemaData, err := json.MarshalIndent(schemaFile, "", "  ")
-		if err != nil {
-			return nil, fmt.Errorf("marshal schema: %w", err)
-		}
-		err = os.WriteFile(schemaPath, schemaData, 0644)
-		if err != nil {
-			return nil, fmt.Errorf("write schema: %w", err)
+		if err := writeSchemaFile(opts.Dir, sch); err != nil {
+			return nil, err
 		}
 	} else {
 		// 尝试从磁盘恢复
@@ -121,6 +235,14 @@ func OpenTable(opts *TableOptions) (*Table, error) {
 			}
 
 			sch = schemaFile.Schema
+
+			// 仅注释被修改时校验和已过期，重新写入以刷新校验和
+			checksum, err := sch.ComputeChecksum()
+			if err == nil && checksum != schemaFile.Checksum {
+				if err := writeSchemaFile(opts.Dir, sch); err != nil {
+					return nil, err
+				}
+			}
 		} else if !os.IsNotExist(err) {
 			// 其他读取错误
 			return nil, fmt.Errorf("failed to read schema file %s: %w", schemaPath, err)
@@ -136,7 +258,7 @@ func OpenTable(opts *TableOptions) (*Table, error) {
 	}
 
 	// 创建索引管理器
-	indexMgr := NewIndexManager(idxDir, sch)
+	indexMgr := NewIndexManagerWithIOMode(idxDir, sch, opts.IOMode)
 
 	// 自动为 Schema 中标记 Indexed 的字段创建索引
 	for _, field := range sch.Fields {
@@ -157,7 +279,7 @@ func OpenTable(opts *TableOptions) (*Table, error) {
 	}
 
 	// 创建 SST Manager
-	sstMgr, err := NewSSTableManager(sstDir)
+	sstMgr, err := NewSSTableManagerWithIOMode(sstDir, opts.IOMode)
 	if err != nil {
 		return nil, err
 	}
@@ -165,6 +287,17 @@ func OpenTable(opts *TableOptions) (*Table, error) {
 	// 设置 Schema（用于优化编解码）
 	sstMgr.SetSchema(sch)
 
+	// 设置索引常驻预算
+	metaCache := opts.metaCache
+	if metaCache == nil {
+		size := opts.MetadataCacheSize
+		if size == 0 {
+			size = DefaultMetadataCacheSize
+		}
+		metaCache = newMetadataCache(size)
+	}
+	sstMgr.SetMetadataCache(metaCache)
+
 	// 创建 MemTable Manager
 	memMgr := NewMemTableManager(opts.MemTableSize)
 
@@ -174,17 +307,39 @@ func OpenTable(opts *TableOptions) (*Table, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create version set: %w", err)
 	}
+	versionSet.SetFaultInjector(opts.FaultInjector)
 
 	// 创建 Table（暂时不设置 WAL Manager）
 	table := &Table{
 		dir:             opts.Dir,
-		schema:          sch,
 		indexManager:    indexMgr,
 		walManager:      nil, // 先不设置，恢复后再创建
 		sstManager:      sstMgr,
 		memtableManager: memMgr,
 		versionSet:      versionSet,
 		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)), // 默认丢弃日志
+		scheduler:       newReadScheduler(opts.LowPriorityReadRate),
+		ioMode:          opts.IOMode,
+		rowChecksum:     opts.RowChecksum,
+		walCompression:  opts.WALCompression,
+		paranoidChecks:  opts.ParanoidCompactionChecks,
+		writers:         newWriterGate(opts.MaxConcurrentWriters),
+		faults:          opts.FaultInjector,
+		clock:           clockOrDefault(opts.Clock),
+		naming:          opts.NamingStrategy.orDefault(),
+		metaCache:       metaCache,
+		batches:         opts.batches,
+	}
+	table.schema.Store(sch)
+
+	table.OnFileEvent(opts.OnFileEvent)
+	table.SetMissingFieldPolicy(opts.MissingFields)
+	table.SetDecimalConversion(opts.DecimalConversion)
+
+	// 回放 WAL 之前加载修改记录（见 recover）
+	table.mutations, err = loadMutationSet(opts.Dir)
+	if err != nil {
+		return nil, err
 	}
 
 	// 先恢复数据（包括从 WAL 恢复）
@@ -198,14 +353,29 @@ func OpenTable(opts *TableOptions) (*Table, error) {
 	if err != nil {
 		return nil, err
 	}
+	walMgr.SetCompression(table.walCompression)
+	walMgr.SetFaultInjector(table.faults)
 	table.walManager = walMgr
 	table.memtableManager.SetActiveWAL(walMgr.GetCurrentNumber())
 
+	// 恢复的数据已在磁盘上，作为持久化水位的起点
+	table.durability = newDurabilityTracker(&table.seq, opts.Sequence)
+	if opts.Sequence != nil {
+		opts.Sequence.observe(table.seq.Load())
+	}
+
 	// 创建 Compaction Manager
 	table.compactionManager = NewCompactionManager(sstDir, versionSet, sstMgr)
 
 	// 设置 Schema
 	table.compactionManager.SetSchema(sch)
+	table.compactionManager.SetFaultInjector(opts.FaultInjector)
+	table.compactionManager.SetParanoidChecks(opts.ParanoidCompactionChecks)
+	table.compactionManager.SetClock(table.clock)
+	table.compactionManager.SetWALCollector(table.collectFlushedWALs)
+	table.compactionManager.SetVersionChecker(table.olderVersions)
+	table.compactionManager.SetFileListener(table.notifyFile)
+	table.attachRetention()
 
 	// 启动时清理孤儿文件（崩溃恢复后的清理）
 	table.compactionManager.CleanupOrphanFiles()
@@ -215,19 +385,33 @@ func OpenTable(opts *TableOptions) (*Table, error) {
 
 	// 验证并修复索引
 	table.verifyAndRepairIndexes()
+	table.reindexMutations()
+
+	// 加载连续聚合并累加崩溃前未持久化的行
+	table.caggs = newContinuousManager(filepath.Join(opts.Dir, "cagg"), sch)
+	table.caggs.repair(table.seq.Load(), func(seq int64) (*SSTableRow, error) {
+		return table.getWithPriority(PriorityNormal, seq)
+	})
+	table.loadRetentionPolicy()
 
 	// 设置自动 flush 超时时间
 	if opts.AutoFlushTimeout > 0 {
-		table.autoFlushTimeout = opts.AutoFlushTimeout
+		table.autoFlushTimeout.Store(int64(opts.AutoFlushTimeout))
 	} else {
-		table.autoFlushTimeout = DefaultAutoFlushTimeout
+		table.autoFlushTimeout.Store(int64(DefaultAutoFlushTimeout))
 	}
+	table.autoFlushConfig = make(chan struct{}, 1)
 	table.stopAutoFlush = make(chan struct{})
-	table.lastWriteTime.Store(time.Now().UnixNano())
+	table.lastWriteTime.Store(table.clock.Now().UnixNano())
 
 	// 启动自动 flush 监控
 	go table.autoFlushMonitor()
 
+	// 回放 WAL 之后再设置影子表：只镜像打开之后的写入
+	if opts.Shadow != nil {
+		table.SetShadow(opts.Shadow, nil)
+	}
+
 	return table, nil
 }
 
@@ -246,7 +430,8 @@ func (t *Table) Insert(data any) error {
 	}
 
 	// 2. 批量插入
-	return t.insertBatch(rows)
+	_, err = t.insertBatch(context.Background(), rows)
+	return err
 }
 
 // normalizeInsertData 将各种输入格式转换为 []map[string]any
@@ -336,6 +521,11 @@ func (t *Table) normalizeInsertData(data any) ([]map[string]any, error) {
 	}
 }
 
+// isDeprecated 字段是否已弃用
+func (t *Table) isDeprecated(name string) bool {
+	return t.schema.Load().isDeprecated(name)
+}
+
 // structToMap 将结构体转换为 map[string]any
 func (t *Table) structToMap(v any) (map[string]any, error) {
 	val := reflect.ValueOf(v)
@@ -367,10 +557,11 @@ func (t *Table) structToMap(v any) (map[string]any, error) {
 			continue
 		}
 
-		// 默认使用 snake_case 转换字段名
-		fieldName := camelToSnake(field.Name)
+		// 默认按表的命名规则转换字段名
+		fieldName := t.naming(field.Name)
 
 		// 解析 tag（与 StructToFields 保持一致）
+		skipZero := false
 		if tag != "" {
 			parts := strings.Split(tag, ";")
 			for _, part := range parts {
@@ -382,7 +573,9 @@ func (t *Table) structToMap(v any) (map[string]any, error) {
 				// 检查是否为 field:xxx 格式
 				if strings.HasPrefix(part, "field:") {
 					fieldName = strings.TrimPrefix(part, "field:")
-					break // 找到字段名，停止解析
+				} else if part == "skipzero" {
+					// skipzero 标记：零值视为未设置
+					skipZero = true
 				}
 				// 忽略其他标记（indexed, nullable, comment:xxx）
 			}
@@ -391,6 +584,15 @@ func (t *Table) structToMap(v any) (map[string]any, error) {
 		// 获取字段值
 		fieldVal := val.Field(i)
 
+		// skipzero：零值字段不写入，由 Schema 决定默认值或 NULL
+		if skipZero && fieldVal.IsZero() {
+			continue
+		}
+		// 已弃用的字段：结构体中仍保留该字段的服务写入零值时视为未设置
+		if fieldVal.IsZero() && t.isDeprecated(fieldName) {
+			continue
+		}
+
 		// 处理指针类型：如果是指针，解引用（nil 保持为 nil）
 		if fieldVal.Kind() == reflect.Pointer {
 			if fieldVal.IsNil() {
@@ -406,69 +608,74 @@ func (t *Table) structToMap(v any) (map[string]any, error) {
 	return result, nil
 }
 
-// insertBatch 批量插入数据
-func (t *Table) insertBatch(rows []map[string]any) error {
+// insertBatch 批量插入数据，返回最后一条数据的 seq（受 MaxConcurrentWriters 限制，排队期间可以通过 ctx 取消）
+func (t *Table) insertBatch(ctx context.Context, rows []map[string]any) (int64, error) {
 	if len(rows) == 0 {
-		return nil
+		return 0, nil
 	}
+	return t.writers.doContext(ctx, rows, t.insertRows)
+}
+
+// insertRows 逐条插入数据，返回最后一条数据的 seq
+func (t *Table) insertRows(rows []map[string]any) (int64, error) {
 
 	// 逐条插入
+	var seq int64
 	for _, data := range rows {
-		if err := t.insertSingle(data); err != nil {
-			return err
+		var err error
+		if seq, err = t.insertSingle(data); err != nil {
+			return 0, err
 		}
 	}
 
-	return nil
+	return seq, nil
 }
 
-// insertSingle 插入单条数据
-func (t *Table) insertSingle(data map[string]any) error {
-	// 1. 验证 Schema
-	if err := t.schema.Validate(data); err != nil {
-		return NewError(ErrCodeSchemaValidationFailed, err)
+// insertSingle 插入单条数据，返回分配的 seq
+func (t *Table) insertSingle(data map[string]any) (int64, error) {
+	// 1-2. 验证 Schema、转换类型并物化计算列
+	schema := t.schema.Load()
+	now := t.clock.Now().UnixNano()
+	convertedData, data, err := t.prepareRow(schema, data, now)
+	if err != nil {
+		return 0, err
 	}
+	return t.writeRow(schema, convertedData, data, now)
+}
 
-	// 2. 类型转换：将数据转换为 Schema 定义的类型
-	// 这样可以确保写入时的类型与 Schema 一致（例如将 int64 转换为 time.Time）
-	convertedData := make(map[string]any, len(data))
-	for key, value := range data {
-		// 跳过 nil 值
-		if value == nil {
-			convertedData[key] = nil
-			continue
-		}
-
-		// 获取字段定义
-		field, err := t.schema.GetField(key)
-		if err != nil {
-			// 字段不在 Schema 中，保持原值
-			convertedData[key] = value
-			continue
-		}
-
-		// 使用 Schema 的类型转换
-		converted, err := convertValue(value, field.Type)
-		if err != nil {
-			return NewErrorf(ErrCodeSchemaValidationFailed, "convert field %s: %v", key, err)
-		}
-		convertedData[key] = converted
+// writeRow 写入一行已由 prepareRow 按 schema 处理的数据，返回分配的 seq
+func (t *Table) writeRow(schema *Schema, convertedData, data map[string]any, now int64) (int64, error) {
+	seq, rowData, err := t.appendRow(schema, convertedData, now, 0)
+	if err != nil {
+		return 0, err
 	}
+	t.applyRow(seq, rowData, convertedData, data, now)
+	return seq, nil
+}
 
-	// 3. 生成 _seq
-	seq := t.seq.Add(1)
+// appendRow 分配 seq 并将行写入 WAL，返回 seq 与行编码，失败时已释放 seq
+//
+// batch 不为 0 时写入为原子批量写入的记录（见 Database.Batch），提交标记写入之前恢复时会被忽略。
+// schema 为 prepareRow 使用的 Schema。成功后必须调用 applyRow（或放弃时调用 durability.appended）。
+func (t *Table) appendRow(schema *Schema, convertedData map[string]any, now int64, batch int64) (int64, []byte, error) {
+	// 3. 生成 _seq（写入 WAL 前计入持久化跟踪）
+	seq := t.durability.allocate()
 
 	// 4. 添加系统字段
 	row := &SSTableRow{
 		Seq:  seq,
-		Time: time.Now().UnixNano(),
+		Time: now,
 		Data: convertedData,
 	}
 
 	// 3. 序列化（使用二进制格式，保留类型信息）
-	rowData, err := encodeSSTableRowBinary(row, t.schema)
+	rowData, err := encodeSSTableRowBinary(row, schema)
 	if err != nil {
-		return err
+		t.durability.appended(seq)
+		return 0, nil, err
+	}
+	if t.rowChecksum {
+		rowData = appendRowChecksum(rowData)
 	}
 
 	// 4. 写入 WAL
@@ -477,65 +684,278 @@ func (t *Table) insertSingle(data map[string]any) error {
 		Seq:  seq,
 		Data: rowData,
 	}
+	if batch != 0 {
+		entry.Type = WALEntryTypeBatchPut
+		entry.Data = binary.LittleEndian.AppendUint64(make([]byte, 0, 8+len(rowData)), uint64(batch))
+		entry.Data = append(entry.Data, rowData...)
+	}
 	err = t.walManager.Append(entry)
 	if err != nil {
-		return err
+		t.durability.appended(seq)
+		return 0, nil, err
 	}
+	return seq, rowData, nil
+}
+
+// applyRow 将已写入 WAL 的行写入 MemTable 与索引，使其对查询可见
+func (t *Table) applyRow(seq int64, rowData []byte, convertedData, data map[string]any, now int64) {
+	t.rowSizes.record(rowData)
 
 	// 5. 写入 MemTable Manager
 	t.memtableManager.Put(seq, rowData)
 
 	// 6. 添加到索引
 	t.indexManager.AddToIndexes(data, seq)
+	t.caggs.add(data, now, seq)
+	t.mirror(shadowOp{entryType: WALEntryTypePut, seq: seq, insert: true, data: convertedData})
+	t.replicate(WALEntryTypePut, false, seq, rowData)
+
+	// 写入 MemTable 与索引后才移出 pending：水位以下的行对查询可见（见 Tail）
+	t.durability.appended(seq)
+	t.inserted.notify()
 
 	// 7. 更新最后写入时间
-	t.lastWriteTime.Store(time.Now().UnixNano())
+	t.lastWriteTime.Store(t.clock.Now().UnixNano())
 
 	// 8. 检查是否需要切换 MemTable
 	if t.memtableManager.ShouldSwitch() {
 		go t.switchMemTable()
 	}
+}
+
+// prepareRow 验证并按 schema 转换一行数据，now 为行的 _time（UnixNano）
+// 返回转换后用于存储的数据，以及用于更新索引的数据（包含计算列的值）
+//
+// schema 由调用者加载一次（t.schema.Load()），编码同一行时沿用，避免中途被 UpdateSchemaMetadata 替换
+func (t *Table) prepareRow(schema *Schema, data map[string]any, now int64) (converted, indexed map[string]any, err error) {
+	// 0. 计算列的值总是由 Schema 计算，忽略写入时提供的值（复制一份，不修改调用者的 map）
+	computed := schema.hasComputed()
+	if computed {
+		data = maps.Clone(data)
+		for _, field := range schema.Fields {
+			if field.Computed != "" {
+				delete(data, field.Name)
+			}
+		}
+	}
+
+	// Decimal 值写入整数、浮点数与 String 字段时按 DecimalConversion 转换（不修改调用者的 map）
+	data, err = schema.convertDecimals(data, t.DecimalConversion())
+	if err != nil {
+		return nil, nil, NewError(ErrCodeSchemaValidationFailed, err)
+	}
+
+	// 1. 验证 Schema
+	if err := schema.Validate(data); err != nil {
+		return nil, nil, NewError(ErrCodeSchemaValidationFailed, err)
+	}
+	if err := schema.checkDeprecatedWrite(data); err != nil {
+		return nil, nil, NewError(ErrCodeSchemaValidationFailed, err)
+	}
+
+	// 2. 类型转换：将数据转换为 Schema 定义的类型
+	// 这样可以确保写入时的类型与 Schema 一致（例如将 int64 转换为 time.Time）
+	convertedData := make(map[string]any, len(data))
+	for key, value := range data {
+		// 跳过 nil 值
+		if value == nil {
+			convertedData[key] = nil
+			continue
+		}
+
+		// 获取字段定义
+		field, err := schema.GetField(key)
+		if err != nil {
+			// 字段不在 Schema 中，保持原值
+			convertedData[key] = value
+			continue
+		}
+
+		// 使用 Schema 的类型转换
+		converted, err := convertValue(value, field.Type)
+		if err != nil {
+			return nil, nil, NewErrorf(ErrCodeSchemaValidationFailed, "convert field %s: %v", key, err)
+		}
+		convertedData[key] = converted
+	}
+
+	// 计算列：在行时间确定后物化，同时写入索引数据
+	if computed {
+		if err := schema.applyComputed(convertedData, now); err != nil {
+			return nil, nil, err
+		}
+		for _, field := range schema.Fields {
+			if value, ok := convertedData[field.Name]; ok && field.Computed != "" {
+				data[field.Name] = value
+			}
+		}
+	}
 
+	return convertedData, data, nil
+}
+
+// SetAutoFlushTimeout 运行时修改自动 flush 超时时间，立即对后台监控生效
+func (t *Table) SetAutoFlushTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return NewErrorf(ErrCodeInvalidParam, "AutoFlushTimeout must be positive, got %v", timeout)
+	}
+	t.autoFlushTimeout.Store(int64(timeout))
+	select {
+	case t.autoFlushConfig <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// SetMemTableSize 运行时修改 MemTable 大小限制，下一次写入时按新限制判断是否切换
+func (t *Table) SetMemTableSize(size int64) error {
+	if size < 1*1024*1024 {
+		return NewErrorf(ErrCodeInvalidParam, "MemTableSize must be at least 1MB, got %d", size)
+	}
+	t.memtableManager.SetMaxSize(size)
 	return nil
 }
 
-// SetLogger 设置 logger（由 Database 调用）
+// SetLogger 设置 logger，各子系统（WAL、flush、查询）的日志同样输出到 logger
 func (t *Table) SetLogger(logger *slog.Logger) {
 	t.logger = logger
+	t.logs = newLogRegistry(logger.Handler(), nil)
+}
+
+// setLoggers 使用数据库的日志器（子系统级别由 Database.SetLogLevel 统一调整）
+func (t *Table) setLoggers(opts *Options) {
+	t.logger = opts.Logger
+	t.logs = opts.logs
 }
 
 // Get 查询数据
+// 点查询视为高优先级请求，低优先级扫描会为其让路
 func (t *Table) Get(seq int64) (*SSTableRow, error) {
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return t.getWithPriority(PriorityHigh, seq)
+}
+
+// beginRead 开始一次读取，返回的函数用于结束读取
+//
+// 读取期间 Clean/Close 会等待其完成；epoch 与当前不一致（表在迭代器创建后被清空或关闭）时返回错误
+func (t *Table) beginRead(epoch int64) (func(), error) {
+	t.lifecycleMu.RLock()
+	if t.closed.Load() {
+		t.lifecycleMu.RUnlock()
+		return nil, ErrTableClosed
+	}
+	if t.epoch.Load() != epoch {
+		t.lifecycleMu.RUnlock()
+		return nil, ErrTableReset
+	}
+	return t.lifecycleMu.RUnlock, nil
+}
+
+// getWithPriority 按指定优先级查询数据
+func (t *Table) getWithPriority(priority QueryPriority, seq int64) (*SSTableRow, error) {
+	if priority == PriorityHigh {
+		done := t.scheduler.beginHigh()
+		defer done()
+	}
+
 	// 1. 先查 MemTable Manager (Active + Immutables)
-	data, found := t.memtableManager.Get(seq)
-	if found {
+	var row *SSTableRow
+	var err error
+	if data, found := t.memtableManager.Get(seq); found {
 		// 使用二进制解码
-		row, err := decodeSSTableRowBinary(data, t.schema)
-		if err != nil {
-			return nil, err
+		row, err = decodeSSTableRowBinary(data, t.schema.Load())
+	} else {
+		// 2. 查询 SST 文件（低优先级需要先获取令牌）
+		if priority == PriorityLow {
+			t.scheduler.acquire()
 		}
-		return row, nil
+		row, err = t.sstManager.Get(seq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if row.Deleted {
+		return nil, NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+	return row, nil
+}
+
+// getIntoWithPriority 按指定优先级查询数据并解码到 dst（复用 dst 的内存）
+func (t *Table) getIntoWithPriority(priority QueryPriority, seq int64, dst *SSTableRow) error {
+	if priority == PriorityHigh {
+		done := t.scheduler.beginHigh()
+		defer done()
 	}
 
-	// 2. 查询 SST 文件
-	return t.sstManager.Get(seq)
+	// 1. 先查 MemTable Manager (Active + Immutables)
+	var err error
+	if data, found := t.memtableManager.Get(seq); found {
+		err = decodeSSTableRowBinaryInto(data, t.schema.Load(), nil, dst)
+	} else {
+		// 2. 查询 SST 文件（低优先级需要先获取令牌）
+		if priority == PriorityLow {
+			t.scheduler.acquire()
+		}
+		err = t.sstManager.getInto(seq, dst)
+	}
+	if err == nil && dst.Deleted {
+		return NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+	return err
 }
 
 // GetPartial 按需查询数据（只读取指定字段）
 func (t *Table) GetPartial(seq int64, fields []string) (*SSTableRow, error) {
+	endRead, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return nil, err
+	}
+	defer endRead()
+
+	done := t.scheduler.beginHigh()
+	defer done()
+
 	// 1. 先查 MemTable Manager (Active + Immutables)
-	data, found := t.memtableManager.Get(seq)
-	if found {
+	var row *SSTableRow
+	if data, found := t.memtableManager.Get(seq); found {
 		// 使用二进制解码（支持部分解码）
-		row, err := decodeSSTableRowBinaryPartial(data, t.schema, fields)
-		if err != nil {
-			return nil, err
-		}
-		return row, nil
+		row, err = decodeSSTableRowBinaryPartial(data, t.schema.Load(), fields)
+	} else {
+		// 2. 查询 SST 文件（按需解码）
+		row, err = t.sstManager.GetPartial(seq, fields)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if row.Deleted {
+		return nil, NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+	return row, nil
+}
 
-	// 2. 查询 SST 文件（按需解码）
-	return t.sstManager.GetPartial(seq, fields)
+// VerifyRow 校验指定行的内容与插入时计算的校验和是否一致
+//
+// 返回 nil 表示校验通过；行不存在返回 ErrCodeNotFound，内容被修改返回 ErrCodeChecksumMismatch，
+// 校验和引入之前写入的行没有校验和，返回 ErrCodeInvalidData
+func (t *Table) VerifyRow(seq int64) error {
+	done, err := t.beginRead(t.epoch.Load())
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if _, deleted, _ := t.latestVersion(seq); deleted {
+		return NewErrorf(ErrCodeNotFound, "row %d not found", seq)
+	}
+	if data, found := t.memtableManager.Get(seq); found {
+		return verifyRowChecksum(data)
+	}
+	return t.sstManager.verifyRow(seq)
 }
 
 // switchMemTable 切换 MemTable
@@ -543,12 +963,24 @@ func (t *Table) switchMemTable() error {
 	t.flushMu.Lock()
 	defer t.flushMu.Unlock()
 
-	// 1. 切换到新的 WAL
+	// 1. 切换到新的 WAL（旧 WAL 在关闭前 fsync，之前写入的数据均已持久化）
+	mark := t.durability.watermark()
 	oldWALNumber, err := t.walManager.Rotate()
 	if err != nil {
+		t.logs.get(LogWAL).Error("[WAL] Failed to rotate", "table", t.schema.Load().Name, "error", err)
 		return err
 	}
+	t.durability.advance(mark)
 	newWALNumber := t.walManager.GetCurrentNumber()
+	t.logs.get(LogWAL).Debug("[WAL] Rotated", "table", t.schema.Load().Name, "old", oldWALNumber, "new", newWALNumber)
+	t.notifyFile(FileEvent{
+		Op:         FileCreated,
+		Type:       FileTypeWAL,
+		Reason:     FileReasonRotate,
+		FileNumber: newWALNumber,
+		Path:       filepath.Join(t.walManager.dir, fmt.Sprintf("%06d.wal", newWALNumber)),
+		Level:      -1,
+	})
 
 	// 2. 切换 MemTable (Active → Immutable)
 	_, immutable := t.memtableManager.Switch(newWALNumber)
@@ -566,36 +998,165 @@ func (t *Table) flushImmutable(imm *ImmutableMemTable, walNumber int64) error {
 	iter := imm.NewIterator()
 	for iter.Next() {
 		// 使用二进制解码
-		row, err := decodeSSTableRowBinary(iter.Value(), t.schema)
-		if err == nil {
+		// 校验和不一致的行保留原校验和写入 SST，不能在 flush 时丢弃
+		row, err := decodeSSTableRowBinary(iter.Value(), t.schema.Load())
+		if err == nil || IsError(err, ErrCodeChecksumMismatch) {
 			rows = append(rows, row)
 		}
 	}
 
 	if len(rows) == 0 {
 		// 没有数据，直接清理
-		t.walManager.Delete(walNumber)
+		t.deleteWAL(walNumber, FileReasonFlush)
 		t.memtableManager.RemoveImmutable(imm)
 		return nil
 	}
 
-	// 2. 从 VersionSet 分配文件编号
+	// 2. 写入 L0 SST 并记录到 MANIFEST
+	start := time.Now()
+	fileMeta, err := t.writeL0(rows, EditReasonFlush)
+	if err != nil {
+		t.logs.get(LogFlush).Error("[Flush] Failed to write SST",
+			"table", t.schema.Load().Name,
+			"wal", walNumber,
+			"rows", len(rows),
+			"error", err)
+		return err
+	}
+	t.logs.get(LogFlush).Debug("[Flush] Completed",
+		"table", t.schema.Load().Name,
+		"file", fileMeta.FileNumber,
+		"rows", len(rows),
+		"min_seq", fileMeta.MinKey,
+		"max_seq", fileMeta.MaxKey,
+		"duration", time.Since(start))
+	t.notifyFile(FileEvent{
+		Op:         FileCreated,
+		Type:       FileTypeSST,
+		Reason:     FileReasonFlush,
+		FileNumber: fileMeta.FileNumber,
+		Path:       filepath.Join(t.dir, "sst", fmt.Sprintf("%06d.sst", fileMeta.FileNumber)),
+		Level:      fileMeta.Level,
+		Size:       fileMeta.FileSize,
+		Duration:   time.Since(start),
+	})
+
+	// 3. 删除对应的 WAL
+	if err := t.deleteWAL(walNumber, FileReasonFlush); err != nil {
+		t.logs.get(LogWAL).Warn("[WAL] Failed to delete flushed WAL", "table", t.schema.Load().Name, "wal", walNumber, "error", err)
+	}
+
+	// 4. 从 Immutable 列表中移除
+	t.memtableManager.RemoveImmutable(imm)
+
+	// 5. 持久化索引（防止崩溃丢失索引数据）
+	t.indexManager.BuildAll()
+	t.caggs.save()
+
+	// 6. 通知 flush 监听器
+	t.notifyFlush(FlushEvent{
+		Table:     t.schema.Load().Name,
+		MinSeq:    fileMeta.MinKey,
+		MaxSeq:    fileMeta.MaxKey,
+		WALNumber: walNumber,
+		File:      *fileMeta,
+	})
+
+	// 7. Compaction 由后台线程负责，不在 flush 路径中触发
+	// 避免同步 compaction 导致刚创建的文件立即被删除
+	// t.compactionManager.MaybeCompact()
+
+	return nil
+}
+
+// collectFlushedWALs 删除数据已全部写入 SST 但仍留在磁盘上的 WAL 文件（由 GC 循环调用），
+// 返回删除的文件数和字节数
+//
+// flush 只删除 Immutable 对应的 WAL：重启时回放到 Active MemTable 的旧 WAL、
+// flush 后删除失败的 WAL 会一直保留，每次重启都要重新回放。满足以下条件的 WAL 才会删除：
+//   - 不是当前 WAL，也不对应任何 MemTable
+//   - 最后修改时间早于 minAge（刚轮转的 WAL 可能还有记录没有进入 MemTable）
+//   - 所有记录都已不在 MemTable 中（MemTable 在写入 SST 之后才移除）
+//
+// 无法读取的 WAL 保留，交给人工处理。
+func (t *Table) collectFlushedWALs(minAge time.Duration) (int, int64) {
+	logger := t.logs.get(LogGC)
+
+	files, err := t.walManager.ListWALFiles()
+	if err != nil {
+		logger.Error("[GC] Failed to scan WAL directory", "table", t.schema.Load().Name, "error", err)
+		return 0, 0
+	}
+
+	referenced := make(map[int64]bool)
+	for _, number := range t.memtableManager.WALNumbers() {
+		referenced[number] = true
+	}
+	referenced[t.walManager.GetCurrentNumber()] = true
+
+	count, freed := 0, int64(0)
+	for _, walPath := range files {
+		var number int64
+		if _, err := fmt.Sscanf(filepath.Base(walPath), "%d.wal", &number); err != nil || referenced[number] {
+			continue
+		}
+
+		fileInfo, err := os.Stat(walPath)
+		if err != nil || t.clock.Now().Sub(fileInfo.ModTime()) < minAge {
+			continue
+		}
+
+		reader, err := NewWALReader(walPath)
+		if err != nil {
+			continue
+		}
+		entries, err := reader.Read()
+		reader.Close()
+		if err != nil {
+			logger.Warn("[GC] Skipping unreadable WAL", "table", t.schema.Load().Name, "wal", number, "error", err)
+			continue
+		}
+		if slices.ContainsFunc(entries, func(entry *WALEntry) bool {
+			_, found := t.memtableManager.Get(entry.Seq)
+			return found
+		}) {
+			continue
+		}
+
+		if err := t.deleteWAL(number, FileReasonGC); err != nil {
+			logger.Warn("[GC] Failed to delete flushed WAL", "table", t.schema.Load().Name, "wal", number, "error", err)
+			continue
+		}
+		logger.Info("[GC] Deleted flushed WAL",
+			"table", t.schema.Load().Name,
+			"wal", number,
+			"entries", len(entries),
+			"size", fileInfo.Size())
+		count++
+		freed += fileInfo.Size()
+	}
+	return count, freed
+}
+
+// writeL0 将行写入新的 L0 SST 文件并记录到 MANIFEST，reason 为变更原因（EditReasonFlush 或 EditReasonBulkLoad）
+func (t *Table) writeL0(rows []*SSTableRow, reason string) (*FileMetadata, error) {
+	// 1. 从 VersionSet 分配文件编号
 	fileNumber := t.versionSet.AllocateFileNumber()
 
-	// 3. 创建 SST 文件到 L0
+	// 2. 创建 SST 文件到 L0
 	reader, err := t.sstManager.CreateSST(fileNumber, rows)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 4. 创建 FileMetadata
+	// 3. 创建 FileMetadata
 	header := reader.GetHeader()
 
 	// 获取文件大小
 	sstPath := reader.GetPath()
 	fileInfo, err := os.Stat(sstPath)
 	if err != nil {
-		return fmt.Errorf("stat sst file: %w", err)
+		return nil, fmt.Errorf("stat sst file: %w", err)
 	}
 
 	fileMeta := &FileMetadata{
@@ -605,10 +1166,13 @@ func (t *Table) flushImmutable(imm *ImmutableMemTable, walNumber int64) error {
 		MinKey:     header.MinKey,
 		MaxKey:     header.MaxKey,
 		RowCount:   header.RowCount,
+		MinTime:    header.MinTime,
+		MaxTime:    header.MaxTime,
 	}
 
-	// 5. 更新 MANIFEST
+	// 4. 更新 MANIFEST
 	edit := NewVersionEdit()
+	edit.SetReason(reason)
 	edit.AddFile(fileMeta)
 
 	// 持久化当前的文件编号计数器（关键修复：防止重启后文件编号重用）
@@ -617,23 +1181,32 @@ func (t *Table) flushImmutable(imm *ImmutableMemTable, walNumber int64) error {
 
 	err = t.versionSet.LogAndApply(edit)
 	if err != nil {
-		return fmt.Errorf("log and apply version edit: %w", err)
+		return nil, fmt.Errorf("log and apply version edit: %w", err)
 	}
 
-	// 6. 删除对应的 WAL
-	t.walManager.Delete(walNumber)
-
-	// 7. 从 Immutable 列表中移除
-	t.memtableManager.RemoveImmutable(imm)
+	return fileMeta, nil
+}
 
-	// 8. 持久化索引（防止崩溃丢失索引数据）
-	t.indexManager.BuildAll()
+// OnFlush 注册 flush 监听器
+// 每次 MemTable 持久化为 SST 后同步调用，监听器应尽快返回，避免阻塞后续 flush
+func (t *Table) OnFlush(fn func(FlushEvent)) {
+	if fn == nil {
+		return
+	}
+	t.flushListenersMu.Lock()
+	t.flushListeners = append(t.flushListeners, fn)
+	t.flushListenersMu.Unlock()
+}
 
-	// 9. Compaction 由后台线程负责，不在 flush 路径中触发
-	// 避免同步 compaction 导致刚创建的文件立即被删除
-	// t.compactionManager.MaybeCompact()
+// notifyFlush 调用所有 flush 监听器
+func (t *Table) notifyFlush(event FlushEvent) {
+	t.flushListenersMu.RLock()
+	listeners := t.flushListeners
+	t.flushListenersMu.RUnlock()
 
-	return nil
+	for _, fn := range listeners {
+		fn(event)
+	}
 }
 
 // recover 恢复数据
@@ -669,18 +1242,37 @@ func (t *Table) recover() error {
 
 			// 重放 WAL 到 Active MemTable
 			for _, entry := range entries {
+				data := entry.Data
+				if entry.Type == WALEntryTypeBatchPut {
+					var committed bool
+					if data, committed = t.batches.replay(entry.Data); !committed {
+						// 崩溃时未提交的原子批量写入：其他表的记录可能没有写入，整批丢弃
+						if entry.Seq > t.seq.Load() {
+							t.seq.Store(entry.Seq)
+						}
+						continue
+					}
+				}
+
 				// 使用二进制解码验证 Schema
-				row, err := decodeSSTableRowBinary(entry.Data, t.schema)
+				row, err := decodeSSTableRowBinary(data, t.schema.Load())
 				if err != nil {
 					return fmt.Errorf("failed to decode row during recovery (seq=%d): %w", entry.Seq, err)
 				}
 
 				// 验证 Schema
-				if err := t.schema.Validate(row.Data); err != nil {
+				if err := t.schema.Load().Validate(row.Data); err != nil {
 					return NewErrorf(ErrCodeSchemaValidationFailed, "schema validation failed during recovery (seq=%d)", entry.Seq, err)
 				}
 
-				t.memtableManager.Put(entry.Seq, entry.Data)
+				// 已 flush 的 WAL 删除失败时会被重新回放，不能覆盖 SST 中更新的版本
+				if t.mutations.contains(entry.Seq) {
+					if newest, _, found := t.sstManager.latestVersion(entry.Seq); found && newest > row.Time {
+						continue
+					}
+				}
+
+				t.memtableManager.Put(entry.Seq, data)
 				if entry.Seq > t.seq.Load() {
 					t.seq.Store(entry.Seq)
 				}
@@ -693,15 +1285,20 @@ func (t *Table) recover() error {
 
 // autoFlushMonitor 自动 flush 监控
 func (t *Table) autoFlushMonitor() {
-	ticker := time.NewTicker(t.autoFlushTimeout / 2) // 每半个超时时间检查一次
+	timeout := time.Duration(t.autoFlushTimeout.Load())
+	ticker := time.NewTicker(timeout / 2) // 每半个超时时间检查一次
 	defer ticker.Stop()
 
 	for {
 		select {
+		case <-t.autoFlushConfig:
+			// 超时时间变更：按新间隔重新计时
+			timeout = time.Duration(t.autoFlushTimeout.Load())
+			ticker.Reset(timeout / 2)
 		case <-ticker.C:
 			// 检查是否超时
 			lastWrite := time.Unix(0, t.lastWriteTime.Load())
-			if time.Since(lastWrite) >= t.autoFlushTimeout {
+			if t.clock.Now().Sub(lastWrite) >= timeout {
 				// 检查 MemTable 是否有数据
 				active := t.memtableManager.GetActive()
 				if active != nil && active.Size() > 0 {
@@ -735,7 +1332,28 @@ func (t *Table) Flush() error {
 }
 
 // Close 关闭引擎
+//
+// 等待进行中的读取完成；之后未关闭的迭代器调用 Next() 返回 false，Err() 返回 ErrTableClosed
 func (t *Table) Close() error {
+	t.lifecycleMu.Lock()
+	defer t.lifecycleMu.Unlock()
+
+	return t.close()
+}
+
+// close 关闭引擎，调用者必须持有 lifecycleMu 写锁
+func (t *Table) close() error {
+	if t.closed.Swap(true) {
+		return nil
+	}
+	t.epoch.Add(1)
+	t.inserted.notify()
+	t.stopStatsSubscriptions()
+	if w := t.shadow.Swap(nil); w != nil {
+		w.stop()
+	}
+	t.failReplicas(ErrTableClosed)
+
 	// 1. 停止自动 flush 监控（如果还在运行）
 	if t.stopAutoFlush != nil {
 		select {
@@ -772,9 +1390,13 @@ func (t *Table) Close() error {
 
 	// 5. 保存所有索引
 	if t.indexManager != nil {
+		t.indexManager.drainQueues()
 		t.indexManager.BuildAll()
 		t.indexManager.Close()
 	}
+	if t.caggs != nil {
+		t.caggs.save()
+	}
 
 	// 6. 关闭 VersionSet
 	if t.versionSet != nil {
@@ -795,10 +1417,24 @@ func (t *Table) Close() error {
 }
 
 // Clean 清除所有数据（保留 Table 可用）
+//
+// 等待进行中的读取完成；之前创建且未关闭的迭代器调用 Next() 返回 false，Err() 返回 ErrTableReset
 func (t *Table) Clean() error {
 	t.flushMu.Lock()
 	defer t.flushMu.Unlock()
 
+	t.lifecycleMu.Lock()
+	defer t.lifecycleMu.Unlock()
+
+	if t.closed.Load() {
+		return ErrTableClosed
+	}
+	if n := t.iterators.Load(); n > 0 {
+		t.logger.Warn("[Table] Clean invalidates open iterators", "dir", t.dir, "iterators", n)
+	}
+	t.epoch.Add(1)
+	t.failReplicas(ErrTableReset)
+
 	// 0. 停止自动 flush 监控（临时）
 	t.stopAutoFlushMu.Lock()
 	if t.stopAutoFlush != nil {
@@ -833,6 +1469,8 @@ func (t *Table) Clean() error {
 		if err != nil {
 			return fmt.Errorf("recreate wal manager: %w", err)
 		}
+		walMgr.SetCompression(t.walCompression)
+		walMgr.SetFaultInjector(t.faults)
 		t.walManager = walMgr
 		t.memtableManager.SetActiveWAL(walMgr.GetCurrentNumber())
 	}
@@ -845,13 +1483,14 @@ func (t *Table) Clean() error {
 		os.MkdirAll(sstDir, 0755)
 
 		// 重新创建 SST Manager
-		sstMgr, err := NewSSTableManager(sstDir)
+		sstMgr, err := NewSSTableManagerWithIOMode(sstDir, t.ioMode)
 		if err != nil {
 			return fmt.Errorf("recreate sst manager: %w", err)
 		}
 		t.sstManager = sstMgr
 		// 设置 Schema
-		t.sstManager.SetSchema(t.schema)
+		t.sstManager.SetSchema(t.schema.Load())
+		t.sstManager.SetMetadataCache(t.metaCache)
 	}
 
 	// 4. 删除所有索引文件
@@ -863,14 +1502,23 @@ func (t *Table) Clean() error {
 		os.MkdirAll(idxDir, 0755)
 
 		// 重新创建 Index Manager
-		t.indexManager = NewIndexManager(t.dir, t.schema)
+		t.indexManager = NewIndexManagerWithIOMode(t.dir, t.schema.Load(), t.ioMode)
+	}
+	if t.caggs != nil {
+		t.caggs.reset()
+	}
+	if err := t.mutations.reset(); err != nil {
+		return fmt.Errorf("reset mutations: %w", err)
 	}
 
 	// 5. 重置 MANIFEST
 	if t.versionSet != nil {
 		t.versionSet.Close()
 		manifestDir := t.dir
-		os.Remove(filepath.Join(manifestDir, "MANIFEST"))
+		manifests, _ := filepath.Glob(filepath.Join(manifestDir, "MANIFEST-*"))
+		for _, path := range manifests {
+			os.Remove(path)
+		}
 		os.Remove(filepath.Join(manifestDir, "CURRENT"))
 
 		// 重新创建 VersionSet
@@ -878,20 +1526,30 @@ func (t *Table) Clean() error {
 		if err != nil {
 			return fmt.Errorf("recreate version set: %w", err)
 		}
+		versionSet.SetFaultInjector(t.faults)
 		t.versionSet = versionSet
 	}
 
 	// 6. 重新创建 Compaction Manager
 	sstDir := filepath.Join(t.dir, "sst")
 	t.compactionManager = NewCompactionManager(sstDir, t.versionSet, t.sstManager)
-	t.compactionManager.SetSchema(t.schema)
+	t.compactionManager.SetSchema(t.schema.Load())
+	t.compactionManager.SetFaultInjector(t.faults)
+	t.compactionManager.SetParanoidChecks(t.paranoidChecks)
+	t.compactionManager.SetClock(t.clock)
+	t.compactionManager.SetWALCollector(t.collectFlushedWALs)
+	t.compactionManager.SetVersionChecker(t.olderVersions)
+	t.compactionManager.SetFileListener(t.notifyFile)
+	t.attachRetention()
 	t.compactionManager.Start()
 
 	// 7. 重置序列号
 	t.seq.Store(0)
+	t.durability.reset(t.epoch.Load())
+	t.inserted.notify()
 
 	// 8. 更新最后写入时间
-	t.lastWriteTime.Store(time.Now().UnixNano())
+	t.lastWriteTime.Store(t.clock.Now().UnixNano())
 
 	// 9. 重启自动 flush 监控
 	t.stopAutoFlushMu.Lock()
@@ -904,8 +1562,11 @@ func (t *Table) Clean() error {
 
 // Destroy 销毁 Table 并删除所有数据文件
 func (t *Table) Destroy() error {
+	t.lifecycleMu.Lock()
+	defer t.lifecycleMu.Unlock()
+
 	// 1. 先关闭 Table
-	if err := t.Close(); err != nil {
+	if err := t.close(); err != nil {
 		return fmt.Errorf("close table: %w", err)
 	}
 
@@ -931,6 +1592,20 @@ type TableStats struct {
 	MemTableCount int
 	SSTCount      int
 	TotalRows     int64
+	OpenIterators int64 // 未关闭的惰性迭代器数量
+}
+
+// FlushLagStats 表的 flush 积压情况
+//
+// 用于监控：积压持续增长说明 flush 跟不上写入，重启时的 WAL 回放时间也会随之变长。
+type FlushLagStats struct {
+	PendingWALBytes    int64         // 尚未 flush 的 WAL 字节数（重启时需要回放）
+	PendingWALFiles    int           // 尚未 flush 的 WAL 文件数
+	ImmutableCount     int           // 等待 flush 的 Immutable MemTable 数量
+	ImmutableSize      int64         // 等待 flush 的 Immutable MemTable 总大小
+	ActiveSize         int64         // Active MemTable 大小
+	OldestUnflushedSeq int64         // 最旧的未 flush 记录的 seq，0 表示没有未 flush 的数据
+	OldestUnflushedAge time.Duration // 最旧的未 flush 记录距今的时间，0 表示没有未 flush 的数据
 }
 
 // GetVersionSet 获取 VersionSet（用于高级操作）
@@ -955,12 +1630,12 @@ func (t *Table) GetSSTManager() *SSTableManager {
 
 // GetMaxSeq 获取当前最大的 seq 号
 func (t *Table) GetMaxSeq() int64 {
-	return t.seq.Load() - 1 // seq 是下一个要分配的，所以最大的是 seq - 1
+	return t.seq.Load() // seq 是最后分配的序列号
 }
 
 // GetName 获取表名
 func (t *Table) GetName() string {
-	return t.schema.Name
+	return t.schema.Load().Name
 }
 
 // GetDir 获取表目录
@@ -970,7 +1645,72 @@ func (t *Table) GetDir() string {
 
 // GetSchema 获取 Schema
 func (t *Table) GetSchema() *Schema {
-	return t.schema
+	return t.schema.Load()
+}
+
+// UpdateSchemaMetadata 更新字段注释等元数据并重写 schema.json
+// comments 为字段名到新注释的映射，不影响 Schema 结构与已有数据
+//
+// 更新构造新的 Schema 并整体替换，不修改正在被读取的 Schema；并发调用按顺序执行。
+func (t *Table) UpdateSchemaMetadata(comments map[string]string) error {
+	t.schemaMu.Lock()
+	defer t.schemaMu.Unlock()
+
+	// 1. 基于当前 Schema 构造新的字段列表
+	current := t.schema.Load()
+	fields := slices.Clone(current.Fields)
+
+	for name, comment := range comments {
+		found := false
+		for i := range fields {
+			if fields[i].Name == name {
+				fields[i].Comment = comment
+				found = true
+				break
+			}
+		}
+		if !found {
+			return NewErrorf(ErrCodeFieldNotFound, "field %s not found", name)
+		}
+	}
+
+	// 2. 先持久化，成功后再替换内存中的 Schema
+	updated := &Schema{Name: current.Name, Fields: fields}
+	if err := writeSchemaFile(t.dir, updated); err != nil {
+		return err
+	}
+	t.schema.Store(updated)
+
+	// 从库的 Schema 不随之更新，结束复制流（见 ServeReplica）
+	t.failReplicas(NewErrorf(ErrCodeSchemaMismatch, "table %s schema changed during replication, replica must bootstrap again", current.Name))
+
+	return nil
+}
+
+// writeSchemaFile 将 Schema（带校验和）原子写入 dir/schema.json
+// 临时文件在重命名前同步，重命名后同步目录，崩溃后不会留下空的或与校验和不符的 schema.json
+func writeSchemaFile(dir string, sch *Schema) error {
+	schemaFile, err := NewSchemaFile(sch)
+	if err != nil {
+		return fmt.Errorf("create schema file: %w", err)
+	}
+	schemaData, err := json.MarshalIndent(schemaFile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal schema: %w", err)
+	}
+
+	schemaPath := filepath.Join(dir, "schema.json")
+	tmpPath := schemaPath + ".tmp"
+	if err := writeFileSync(tmpPath, schemaData); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("write schema: %w", err)
+	}
+	if err := os.Rename(tmpPath, schemaPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename schema: %w", err)
+	}
+	syncDir(dir)
+	return nil
 }
 
 // Stats 获取统计信息
@@ -982,6 +1722,7 @@ func (t *Table) Stats() *TableStats {
 		MemTableSize:  memStats.TotalSize,
 		MemTableCount: memStats.TotalCount,
 		SSTCount:      sstStats.FileCount,
+		OpenIterators: t.iterators.Load(),
 	}
 
 	// 计算总行数
@@ -995,11 +1736,71 @@ func (t *Table) Stats() *TableStats {
 	return stats
 }
 
+// FlushLag 获取 flush 积压情况
+func (t *Table) FlushLag() (*FlushLagStats, error) {
+	memStats := t.memtableManager.GetStats()
+	stats := &FlushLagStats{
+		ImmutableCount: memStats.ImmutableCount,
+		ImmutableSize:  memStats.ImmutablesSize,
+		ActiveSize:     memStats.ActiveSize,
+	}
+
+	pendingBytes, pendingFiles, err := t.walManager.PendingSize()
+	if err != nil {
+		return nil, err
+	}
+	stats.PendingWALBytes = pendingBytes
+	stats.PendingWALFiles = pendingFiles
+
+	// 最旧的未 flush 行及其写入时间
+	if seq, value, ok := t.memtableManager.Oldest(); ok {
+		stats.OldestUnflushedSeq = seq
+		if rowTime, ok := encodedRowTime(value); ok {
+			stats.OldestUnflushedAge = max(t.clock.Now().Sub(time.Unix(0, rowTime)), 0)
+		}
+	}
+
+	return stats, nil
+}
+
+// WALStats 获取 WAL 写入统计（自表打开或上次 Clean 以来）
+//
+// 开启 WALCompression 时，DataBytes 与 WrittenBytes 之比即 WAL 的压缩效果。
+func (t *Table) WALStats() WALStats {
+	return t.walManager.Stats()
+}
+
 // CreateIndex 创建索引
 func (t *Table) CreateIndex(field string) error {
 	return t.indexManager.CreateIndex(field)
 }
 
+// CreateIndexWhere 创建部分索引，只为满足 where 的行建立索引
+//
+// 适合大部分行不需要按该字段查询的场景（如只按 email 查询活跃用户），
+// 索引的体积与写放大只与满足条件的行数有关。查询条件包含 where 的每一项时才会使用该索引：
+//
+//	table.CreateIndexWhere("email", srdb.Eq("is_active", true))
+//	table.Query().Eq("is_active", true).Eq("email", email).Rows() // 使用索引
+//	table.Query().Eq("email", email).Rows()                       // 全表扫描
+//
+// 创建后立即为已有数据建立索引。where 只支持内置的条件（比较、And、Or、Not），
+// 比较值只能是 nil、布尔、数值、字符串及其列表，与索引一起持久化。
+func (t *Table) CreateIndexWhere(field string, where Expr) error {
+	if err := t.indexManager.CreateIndexWhere(field, where); err != nil {
+		return err
+	}
+
+	idx, _ := t.indexManager.GetIndex(field)
+	return idx.IncrementalUpdate(func(seq int64) (map[string]any, error) {
+		row, err := t.getWithPriority(PriorityNormal, seq)
+		if err != nil {
+			return nil, err
+		}
+		return row.Data, nil
+	}, 1, t.seq.Load())
+}
+
 // DropIndex 删除索引
 func (t *Table) DropIndex(field string) error {
 	return t.indexManager.DropIndex(field)
@@ -1025,6 +1826,14 @@ func (t *Table) GetIndexMetadata() map[string]IndexMetadata {
 	return t.indexManager.GetIndexMetadata()
 }
 
+// IndexStats 返回每个索引的条目数、不同值数量、磁盘与内存占用及最后构建时间，按字段名排序
+//
+// 用于找出体积过大或选择性差（DistinctKeys 接近 1 或接近 Entries 却只用于范围查询）的索引，
+// 决定是否 DropIndex。统计需要遍历所有索引值，不适合在热路径上频繁调用。
+func (t *Table) IndexStats() []IndexStats {
+	return t.indexManager.Stats()
+}
+
 // RepairIndexes 手动修复索引
 func (t *Table) RepairIndexes() error {
 	return t.verifyAndRepairIndexes()
@@ -1042,7 +1851,7 @@ func (t *Table) verifyAndRepairIndexes() error {
 
 	// 创建 getData 函数
 	getData := func(seq int64) (map[string]any, error) {
-		row, err := t.Get(seq)
+		row, err := t.getWithPriority(PriorityNormal, seq)
 		if err != nil {
 			return nil, err
 		}
@@ -2,8 +2,10 @@
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
@@ -1597,8 +1599,8 @@ func TestTableSchemaTamperDetection(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// 将 "age" 的注释从 "年龄" 改为 "AGE"（简单篡改）
-	tamperedData := strings.Replace(string(schemaData), "年龄", "AGE", 1)
+	// 将字段的 Indexed 从 false 改为 true（结构性篡改；仅修改注释视为兼容）
+	tamperedData := strings.Replace(string(schemaData), `"Indexed": false`, `"Indexed": true`, 1)
 
 	err = os.WriteFile(schemaPath, []byte(tamperedData), 0644)
 	if err != nil {
@@ -1625,6 +1627,255 @@ func TestTableSchemaTamperDetection(t *testing.T) {
 	t.Log("Schema tamper detection test passed!")
 }
 
+// TestTableSchemaCommentOnlyChange 测试仅修改注释时可以正常打开
+func TestTableSchemaCommentOnlyChange(t *testing.T) {
+	dir := "test_schema_comment_only"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	table1, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 10 * 1024 * 1024,
+		Name:         "users",
+		Fields: []Field{
+			{Name: "name", Type: String, Comment: "用户名"},
+			{Name: "age", Type: Int64, Comment: "年龄"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table1.Close()
+
+	// 手动修改注释
+	schemaPath := fmt.Sprintf("%s/schema.json", dir)
+	schemaData, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(schemaPath, []byte(strings.Replace(string(schemaData), "年龄", "AGE", 1)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table2, err := OpenTable(&TableOptions{Dir: dir, MemTableSize: 10 * 1024 * 1024})
+	if err != nil {
+		t.Fatalf("Comment-only change should be compatible: %v", err)
+	}
+	field, err := table2.GetSchema().GetField("age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field.Comment != "AGE" {
+		t.Errorf("Expected comment AGE, got %s", field.Comment)
+	}
+	table2.Close()
+
+	// 打开时已刷新校验和
+	schemaData, err = os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf := &SchemaFile{}
+	if err := json.Unmarshal(schemaData, sf); err != nil {
+		t.Fatal(err)
+	}
+	checksum, _ := sf.Schema.ComputeChecksum()
+	if checksum != sf.Checksum {
+		t.Errorf("Expected checksum to be refreshed on open")
+	}
+}
+
+// TestTableSchemaCommentOnlyChangeLegacyFile 测试旧版本（没有 structure_checksum）的 schema.json 仅修改注释时可以正常打开
+func TestTableSchemaCommentOnlyChangeLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+
+	table1, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 10 * 1024 * 1024,
+		Name:         "users",
+		Fields: []Field{
+			{Name: "name", Type: String, Comment: "用户名"},
+			{Name: "age", Type: Int64, Comment: "年龄"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table1.Close()
+
+	// 按旧版本的格式改写 schema.json：没有 structure_checksum，然后手动修改注释
+	schemaPath := filepath.Join(dir, "schema.json")
+	legacy := `{
+  "version": 1,
+  "timestamp": 1700000000,
+  "checksum": "%s",
+  "schema": {
+    "Name": "users",
+    "Fields": [
+      {"Name": "name", "Type": 13, "Indexed": false, "Nullable": false, "Comment": "用户名"},
+      {"Name": "age", "Type": 5, "Indexed": false, "Nullable": false, "Comment": "%s"}
+    ]
+  }
+}`
+	original, err := NewSchema("users", []Field{
+		{Name: "name", Type: String, Comment: "用户名"},
+		{Name: "age", Type: Int64, Comment: "年龄"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := original.ComputeChecksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(schemaPath, []byte(fmt.Sprintf(legacy, checksum, "AGE")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	table2, err := OpenTable(&TableOptions{Dir: dir, MemTableSize: 10 * 1024 * 1024})
+	if err != nil {
+		t.Fatalf("Comment-only change of a legacy schema.json should be compatible: %v", err)
+	}
+	field, err := table2.GetSchema().GetField("age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field.Comment != "AGE" {
+		t.Errorf("Expected comment AGE, got %s", field.Comment)
+	}
+	table2.Close()
+
+	// 打开时已重写文件并记录结构校验和
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf := &SchemaFile{}
+	if err := json.Unmarshal(data, sf); err != nil {
+		t.Fatal(err)
+	}
+	structure, _ := original.ComputeStructureChecksum()
+	if sf.StructureChecksum != structure {
+		t.Errorf("Expected structure checksum %s to be recorded, got %q", structure, sf.StructureChecksum)
+	}
+}
+
+// TestTableUpdateSchemaMetadata 测试通过 API 更新字段注释
+func TestTableUpdateSchemaMetadata(t *testing.T) {
+	dir := "test_update_schema_metadata"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 10 * 1024 * 1024,
+		Name:         "users",
+		Fields: []Field{
+			{Name: "name", Type: String, Indexed: true, Comment: "用户名"},
+			{Name: "age", Type: Int64, Comment: "年龄"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := table.Insert(map[string]any{"name": "alice", "age": int64(20)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := table.UpdateSchemaMetadata(map[string]string{"age": "用户年龄"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = table.UpdateSchemaMetadata(map[string]string{"missing": "x"})
+	if err == nil || GetErrorCode(err) != ErrCodeFieldNotFound {
+		t.Errorf("Expected field not found error, got %v", err)
+	}
+	table.Close()
+
+	table2, err := OpenTable(&TableOptions{Dir: dir, MemTableSize: 10 * 1024 * 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table2.Close()
+
+	field, _ := table2.GetSchema().GetField("age")
+	if field.Comment != "用户年龄" {
+		t.Errorf("Expected updated comment, got %s", field.Comment)
+	}
+	row, err := table2.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Data["name"] != "alice" {
+		t.Errorf("Expected data to be preserved, got %v", row.Data)
+	}
+}
+
+// TestTableUpdateSchemaMetadataConcurrent 测试更新注释与插入、其他更新并发执行（配合 -race 运行）
+func TestTableUpdateSchemaMetadataConcurrent(t *testing.T) {
+	dir := t.TempDir()
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 10 * 1024 * 1024,
+		Name:         "users",
+		Fields: []Field{
+			{Name: "name", Type: String, Indexed: true, Comment: "用户名"},
+			{Name: "age", Type: Int64, Comment: "年龄"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	var wg sync.WaitGroup
+	for w := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				comment := fmt.Sprintf("writer %d comment %d", w, i)
+				if err := table.UpdateSchemaMetadata(map[string]string{"age": comment}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 200 {
+			if err := table.Insert(map[string]any{"name": fmt.Sprintf("user%d", i), "age": int64(i)}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	// 内存中的 Schema 与磁盘上的 schema.json 一致
+	data, err := os.ReadFile(filepath.Join(dir, "schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf := &SchemaFile{}
+	if err := json.Unmarshal(data, sf); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	onDisk, _ := sf.Schema.GetField("age")
+	inMemory, _ := table.GetSchema().GetField("age")
+	if onDisk.Comment != inMemory.Comment {
+		t.Errorf("Expected schema.json comment %q to match in-memory comment %q", onDisk.Comment, inMemory.Comment)
+	}
+}
+
 func TestTableClean(t *testing.T) {
 	dir := "./test_table_clean_data"
 	defer os.RemoveAll(dir)
@@ -2428,3 +2679,328 @@ func TestBatchInsertPerformance(t *testing.T) {
 
 	t.Logf("✓ Batch insert performance test passed (%d rows)", batchSize)
 }
+
+// TestTableOnFlush 测试 flush 监听器
+func TestTableOnFlush(t *testing.T) {
+	dir := "test_on_flush"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 10 * 1024 * 1024,
+		Name:         "events",
+		Fields: []Field{
+			{Name: "name", Type: String},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	events := make(chan FlushEvent, 1)
+	table.OnFlush(func(e FlushEvent) {
+		events <- e
+	})
+
+	for i := range 10 {
+		if err := table.Insert(map[string]any{"name": fmt.Sprintf("e%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-events:
+		if e.Table != "events" {
+			t.Errorf("Expected table events, got %s", e.Table)
+		}
+		if e.MinSeq != 1 || e.MaxSeq != 10 {
+			t.Errorf("Expected seq range [1, 10], got [%d, %d]", e.MinSeq, e.MaxSeq)
+		}
+		if e.File.RowCount != 10 || e.File.Level != 0 {
+			t.Errorf("Unexpected file metadata: %+v", e.File)
+		}
+		// 监听器触发时文件已在当前版本中
+		found := false
+		for _, f := range table.GetVersionSet().GetCurrent().GetLevel(0) {
+			if f.FileNumber == e.File.FileNumber {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Flushed file %d not in current version", e.File.FileNumber)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for flush event")
+	}
+}
+
+// TestTableInsertSkipZero 测试 skipzero 标记
+func TestTableInsertSkipZero(t *testing.T) {
+	dir := "test_insert_skipzero"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	type Player struct {
+		Name  string `srdb:"field:name"`
+		Score int64  `srdb:"field:score;skipzero"`
+		Level int64  `srdb:"field:level;skipzero"`
+	}
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 10 * 1024 * 1024,
+		Name:         "players",
+		Fields: []Field{
+			{Name: "name", Type: String},
+			{Name: "score", Type: Int64, Nullable: true},
+			{Name: "level", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	err = table.Insert([]Player{
+		{Name: "alice"},
+		{Name: "bob", Score: 5, Level: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(stage string) {
+		row, err := table.Get(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := row.Data["score"]; ok {
+			t.Errorf("%s: expected score to be NULL, got %v", stage, row.Data["score"])
+		}
+		if row.Data["level"] != int64(0) {
+			t.Errorf("%s: expected non-nullable level to default to 0, got %v", stage, row.Data["level"])
+		}
+
+		row, err = table.Get(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row.Data["score"] != int64(5) || row.Data["level"] != int64(2) {
+			t.Errorf("%s: unexpected data %v", stage, row.Data)
+		}
+	}
+
+	check("memtable")
+
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	check("sstable")
+
+	rows, err := table.Query().IsNull("score").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if n := rows.Count(); n != 1 {
+		t.Errorf("Expected 1 row with NULL score, got %d", n)
+	}
+}
+
+// TestTableFlushLag 测试 flush 积压指标
+func TestTableFlushLag(t *testing.T) {
+	dir := "test_flush_lag"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(&TableOptions{
+		Dir:          dir,
+		MemTableSize: 10 * 1024 * 1024,
+		Name:         "events",
+		Fields: []Field{
+			{Name: "name", Type: String},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	lag, err := table.FlushLag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lag.OldestUnflushedSeq != 0 || lag.OldestUnflushedAge != 0 || lag.ImmutableCount != 0 {
+		t.Errorf("Expected no lag on empty table, got %+v", lag)
+	}
+
+	for i := range 10 {
+		if err := table.Insert(map[string]any{"name": fmt.Sprintf("e%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	lag, err = table.FlushLag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lag.PendingWALBytes == 0 || lag.PendingWALFiles != 1 {
+		t.Errorf("Expected pending WAL data, got %+v", lag)
+	}
+	if lag.OldestUnflushedSeq != 1 {
+		t.Errorf("Expected oldest unflushed seq 1, got %d", lag.OldestUnflushedSeq)
+	}
+	if lag.OldestUnflushedAge < 20*time.Millisecond {
+		t.Errorf("Expected oldest unflushed age >= 20ms, got %v", lag.OldestUnflushedAge)
+	}
+	if lag.ActiveSize == 0 {
+		t.Errorf("Expected active memtable size > 0")
+	}
+
+	// flush 完成后积压清零
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	lag, err = table.FlushLag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lag.OldestUnflushedSeq != 0 || lag.ImmutableCount != 0 || lag.PendingWALFiles != 1 {
+		t.Errorf("Expected no lag after flush, got %+v", lag)
+	}
+}
+
+func TestRowChecksum(t *testing.T) {
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:         dir,
+		Name:        "audit",
+		Fields:      []Field{{Name: "msg", Type: String}},
+		RowChecksum: true,
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := table.Insert(map[string]any{"msg": "original-message"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// MemTable 中的行
+	row, err := table.Query().First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum := row.Checksum()
+	if len(checksum) != 64 {
+		t.Fatalf("expected hex SHA-256 checksum, got %q", checksum)
+	}
+	if err := table.VerifyRow(row.Seq()); err != nil {
+		t.Errorf("VerifyRow in memtable: %v", err)
+	}
+	if err := table.VerifyRow(999); !IsError(err, ErrCodeNotFound) {
+		t.Errorf("expected ErrCodeNotFound, got %v", err)
+	}
+
+	// flush 后校验和保持不变
+	table.Flush()
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	row, err = table.Query().First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Checksum() != checksum {
+		t.Errorf("checksum changed after flush: %s != %s", row.Checksum(), checksum)
+	}
+	if err := table.VerifyRow(row.Seq()); err != nil {
+		t.Errorf("VerifyRow in sst: %v", err)
+	}
+	table.Close()
+
+	// 直接修改 SST 文件中的数据
+	files, _ := os.ReadDir(dir + "/sst")
+	tampered := false
+	for _, f := range files {
+		path := dir + "/sst/" + f.Name()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i := strings.Index(string(data), "original-message"); i >= 0 {
+			data[i] = 'O'
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			tampered = true
+		}
+	}
+	if !tampered {
+		t.Fatal("row data not found in sst files")
+	}
+
+	table, err = OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	if err := table.VerifyRow(1); !IsError(err, ErrCodeChecksumMismatch) {
+		t.Errorf("expected ErrCodeChecksumMismatch from VerifyRow, got %v", err)
+	}
+	if _, err := table.Get(1); !IsError(err, ErrCodeChecksumMismatch) {
+		t.Errorf("expected ErrCodeChecksumMismatch from Get, got %v", err)
+	}
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		t.Error("tampered row should not be returned")
+	}
+	if !IsError(rows.Err(), ErrCodeChecksumMismatch) {
+		t.Errorf("expected ErrCodeChecksumMismatch from Rows, got %v", rows.Err())
+	}
+	rows.Close()
+}
+
+func TestRowChecksumDisabled(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "plain",
+		Fields: []Field{{Name: "msg", Type: String}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(map[string]any{"msg": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	row, err := table.Query().First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Checksum() != "" {
+		t.Errorf("expected no checksum, got %q", row.Checksum())
+	}
+	if err := table.VerifyRow(row.Seq()); !IsError(err, ErrCodeInvalidData) {
+		t.Errorf("expected ErrCodeInvalidData, got %v", err)
+	}
+}
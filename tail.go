@@ -0,0 +1,251 @@
+package srdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// TailRows Tail 返回的结果流
+//
+// 用法与 Rows 相同（Next、Row、Err、Close），区别是读完已有数据后 Next 不返回 false，
+// 而是阻塞等待之后插入的匹配行；ctx 取消、表被关闭或清空时 Next 返回 false，Err 返回原因。
+// TailRows 不是并发安全的，应在单个 goroutine 中使用。
+type TailRows struct {
+	qb    *QueryBuilder
+	table *Table
+	ctx   context.Context
+	epoch int64 // 创建时表的 epoch，用于检测 Clean/Close
+
+	rows    *Rows // 只用于构造结果行（见 Rows.newRow）
+	history *Rows // 已有数据的结果集，读完后为 nil
+	cut     int64 // history 负责的最大 seq，之后的行由实时阶段按 seq 顺序读取
+	next    int64 // 实时阶段下一个要读取的 seq
+
+	currentRow *Row
+	err        error
+	closed     bool
+}
+
+// Tail 先返回已有的匹配行，再持续返回之后插入的匹配行（类似 SELECT ... FOLLOW），
+// 用于仪表盘、告警等需要跟随新数据的场景
+//
+// 已有数据按查询的正常路径（索引、zone map）读取；之后插入的行按 _seq 顺序逐行读取并用查询条件过滤，
+// 每一行恰好返回一次。支持 Where 条件、Select 与 WithSystemColumns，
+// 不支持 OrderBy、Offset、Limit 与 Distinct（返回 ErrCodeInvalidParam）。
+// 表被清空时 Err 返回 ErrTableReset，之前返回的行不再有效，需要重新调用 Tail。
+func (qb *QueryBuilder) Tail(ctx context.Context) (*TailRows, error) {
+	qb, err := qb.follow(ctx, "Tail")
+	if err != nil {
+		return nil, err
+	}
+
+	// 先取水位再创建结果集：水位以下的行在结果集创建时均已写入 MemTable 与索引
+	t := qb.table
+	epoch := t.epoch.Load()
+	cut := t.durability.watermark()
+	history, err := qb.Rows()
+	if err != nil {
+		return nil, err
+	}
+	if history.epoch != epoch {
+		history.Close()
+		return nil, ErrTableReset
+	}
+
+	return &TailRows{
+		qb:      qb,
+		table:   t,
+		ctx:     ctx,
+		epoch:   epoch,
+		rows:    qb.resultRows(),
+		history: history,
+		cut:     cut,
+		next:    cut + 1,
+	}, nil
+}
+
+// Watch 返回调用之后插入且匹配 filters 的行（变更流），不返回已有数据
+//
+// 条件与 Query().WithFilter(filters...) 相同，在引擎内逐行求值，不匹配的行不会交给订阅者：
+//
+//	errors := srdb.NewFilter().Eq("level", "ERROR")
+//	feed, err := table.Watch(ctx, errors)
+//	for feed.Next() {
+//	    alert(feed.Row().Data())
+//	}
+//
+// 结果流的用法与 Tail 相同；需要 Select、WithSystemColumns 等选项时使用 Query().WithFilter(...).Watch(ctx)。
+func (t *Table) Watch(ctx context.Context, filters ...Filter) (*TailRows, error) {
+	return t.Query().WithFilter(filters...).Watch(ctx)
+}
+
+// Watch 与 Tail 相同，但跳过已有数据，只返回调用之后插入的匹配行
+func (qb *QueryBuilder) Watch(ctx context.Context) (*TailRows, error) {
+	qb, err := qb.follow(ctx, "Watch")
+	if err != nil {
+		return nil, err
+	}
+
+	t := qb.table
+	epoch := t.epoch.Load()
+	done, err := t.beginRead(epoch)
+	if err != nil {
+		return nil, err
+	}
+	cut := t.durability.watermark()
+	done()
+	return &TailRows{
+		qb:    qb,
+		table: t,
+		ctx:   ctx,
+		epoch: epoch,
+		rows:  qb.resultRows(),
+		cut:   cut,
+		next:  cut + 1,
+	}, nil
+}
+
+// follow 检查 Tail/Watch 支持的查询选项，未通过 WithContext 提供 context 时行级策略使用 ctx
+func (qb *QueryBuilder) follow(ctx context.Context, op string) (*QueryBuilder, error) {
+	if qb.table == nil {
+		return nil, fmt.Errorf("table is nil")
+	}
+	if qb.orderBy != "" || qb.offset > 0 || qb.limit > 0 || qb.distinct {
+		return nil, NewErrorf(ErrCodeInvalidParam, "%s does not support OrderBy, Offset, Limit or Distinct", op)
+	}
+	if qb.snap != nil {
+		return nil, NewErrorf(ErrCodeInvalidParam, "%s does not support snapshot queries", op)
+	}
+	if qb.ctx == nil {
+		scoped := *qb
+		scoped.ctx = ctx
+		qb = &scoped
+	}
+	return qb, nil
+}
+
+// Next 移动到下一行，没有新数据时阻塞等待
+func (r *TailRows) Next() bool {
+	if r.closed || r.err != nil {
+		return false
+	}
+
+	// 1. 已有数据
+	if r.history != nil {
+		for r.history.Next() {
+			if row := r.history.Row(); row.Seq() <= r.cut {
+				r.currentRow = row
+				return true
+			}
+		}
+		err := r.history.Err()
+		r.history.Close()
+		r.history = nil
+		if err != nil {
+			r.err = err
+			return false
+		}
+	}
+
+	// 2. 之后插入的数据（先取通知再检查，避免错过检查与等待之间的插入）
+	for {
+		inserted := r.table.inserted.wait()
+		row, err := r.poll()
+		if err != nil {
+			r.err = err
+			return false
+		}
+		if row != nil {
+			r.currentRow = r.rows.newRow(row)
+			return true
+		}
+
+		select {
+		case <-r.ctx.Done():
+			r.err = r.ctx.Err()
+			return false
+		case <-inserted:
+		}
+	}
+}
+
+// poll 按 seq 顺序读取下一个已可见且匹配的行，没有时返回 nil
+func (r *TailRows) poll() (*SSTableRow, error) {
+	done, err := r.table.beginRead(r.epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	mark := r.table.durability.watermark()
+	for ; r.next <= mark; r.next++ {
+		// 插入失败或属于其他表（共享序列）的 seq 不存在
+		if _, ok := r.table.locateRow(r.next); !ok {
+			continue
+		}
+		row, err := r.table.getWithPriority(r.qb.priority, r.next)
+		if err != nil {
+			return nil, err
+		}
+		if r.qb.matchRow(row) {
+			r.next++
+			return row, nil
+		}
+	}
+	return nil, nil
+}
+
+// resultRows 返回只用于构造实时阶段结果行的结果集，不读取数据
+func (qb *QueryBuilder) resultRows() *Rows {
+	return &Rows{schema: qb.table.schema.Load(), fields: qb.fields, qb: qb, table: qb.table}
+}
+
+// Row 返回当前行
+func (r *TailRows) Row() *Row {
+	return r.currentRow
+}
+
+// Err 返回导致 Next 结束的原因：ctx.Err()、ErrTableClosed、ErrTableReset 或读取错误
+func (r *TailRows) Err() error {
+	return r.err
+}
+
+// Close 关闭结果流，之后 Next 返回 false
+func (r *TailRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.history != nil {
+		r.history.Close()
+		r.history = nil
+	}
+	return nil
+}
+
+// insertSignal 新插入的行可见时唤醒等待者（见 Tail），没有等待者时不分配 channel
+type insertSignal struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// wait 返回下一次插入（或表被关闭、清空）时关闭的 channel
+func (s *insertSignal) wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+// notify 唤醒所有等待者
+func (s *insertSignal) notify() {
+	s.mu.Lock()
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+	s.mu.Unlock()
+}
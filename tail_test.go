@@ -0,0 +1,179 @@
+package srdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueryTail(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "logs",
+		Fields: []Field{
+			{Name: "level", Type: String, Indexed: true},
+			{Name: "msg", Type: String},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	insert := func(level, msg string) {
+		t.Helper()
+		if err := table.Insert(map[string]any{"level": level, "msg": msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 已有数据：一部分在 SST 中
+	insert("error", "e1")
+	insert("info", "i1")
+	table.Flush()
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	insert("error", "e2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tail, err := table.Query().Eq("level", "error").Select("msg").Tail(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tail.Close()
+
+	next := func() string {
+		t.Helper()
+		if !tail.Next() {
+			t.Fatalf("Next returned false: %v", tail.Err())
+		}
+		msg, _ := tail.Row().Data()["msg"].(string)
+		return msg
+	}
+	if got := next(); got != "e1" {
+		t.Errorf("expected e1, got %s", got)
+	}
+	if got := next(); got != "e2" {
+		t.Errorf("expected e2, got %s", got)
+	}
+
+	// 之后插入的行：Next 阻塞到匹配的行写入
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		insert("info", "i2")
+		insert("error", "e3")
+		insert("error", "e4")
+	}()
+	if got := next(); got != "e3" {
+		t.Errorf("expected e3, got %s", got)
+	}
+	if got := next(); got != "e4" {
+		t.Errorf("expected e4, got %s", got)
+	}
+	if data := tail.Row().Data(); len(data) != 1 {
+		t.Errorf("expected only selected field, got %v", data)
+	}
+
+	// 取消 ctx 后 Next 返回 false
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if tail.Next() {
+		t.Fatalf("unexpected row: %v", tail.Row().Data())
+	}
+	if !errors.Is(tail.Err(), context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", tail.Err())
+	}
+}
+
+func TestQueryTailTableClosed(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "logs",
+		Fields: []Field{{Name: "msg", Type: String}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := table.Query().OrderByDesc("_seq").Tail(context.Background()); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for OrderBy, got %v", err)
+	}
+
+	tail, err := table.Query().Tail(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tail.Close()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		table.Close()
+	}()
+	if tail.Next() {
+		t.Fatal("expected no rows")
+	}
+	if !errors.Is(tail.Err(), ErrTableClosed) {
+		t.Errorf("expected ErrTableClosed, got %v", tail.Err())
+	}
+}
+
+func TestTableWatch(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "logs",
+		Fields: []Field{
+			{Name: "level", Type: String},
+			{Name: "msg", Type: String},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	insert := func(level, msg string) {
+		t.Helper()
+		if err := table.Insert(map[string]any{"level": level, "msg": msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 已有数据不返回
+	insert("ERROR", "old")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	feed, err := table.Watch(ctx, NewFilter().Eq("level", "ERROR"), NewFilter().Contains("msg", "disk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer feed.Close()
+
+	go func() {
+		for _, row := range []map[string]any{
+			{"level": "INFO", "msg": "disk ok"},
+			{"level": "ERROR", "msg": "network down"},
+			{"level": "ERROR", "msg": "disk full"},
+		} {
+			if err := table.Insert(row); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	if !feed.Next() {
+		t.Fatalf("Next returned false: %v", feed.Err())
+	}
+	if msg := feed.Row().Data()["msg"]; msg != "disk full" {
+		t.Errorf("expected disk full, got %v", msg)
+	}
+
+	if _, err := table.Query().OrderBy("msg").Watch(ctx); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam, got %v", err)
+	}
+}
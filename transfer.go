@@ -0,0 +1,533 @@
+package srdb
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// DataFormat Export 与 Import 使用的文本格式
+type DataFormat int
+
+const (
+	// FormatJSONL 每行一个 JSON 对象（NDJSON）
+	FormatJSONL DataFormat = iota
+
+	// FormatCSV 第一行为列名的 CSV
+	FormatCSV
+)
+
+// String 返回格式名称
+func (f DataFormat) String() string {
+	switch f {
+	case FormatJSONL:
+		return "jsonl"
+	case FormatCSV:
+		return "csv"
+	default:
+		return "unknown"
+	}
+}
+
+// ExportOptions Export 的选项
+type ExportOptions struct {
+	Query    *QueryBuilder    // 导出的查询，nil 时导出整张表
+	Progress func(rows int64) // 每导出 MigrateBatchSize 行以及导出结束时回调已导出的行数
+}
+
+// Export 将查询结果按 format 导出到 w，返回导出的行数
+//
+// 与 ExportParquet 相同，导出在快照上执行，未使用 Select 时导出 _seq、_time 与所有未弃用的字段，
+// 否则按 Select 的顺序导出指定的字段（不支持计算列）。值的表示方式与 Import 对应，导出的数据可以直接导入：
+//
+//   - Time 与 _time 为 RFC3339Nano 字符串（_time 为 UTC），Decimal 为十进制字符串
+//   - Duration 在 JSONL 中为纳秒数，在 CSV 中为 time.Duration 的字符串形式（如 1m30s）
+//   - Object/Array 在 JSONL 中为嵌套的 JSON，在 CSV 中为 JSON 文本
+//   - NULL 在 JSONL 中为 null，在 CSV 中为空字符串（导入时 String 字段的空字符串不会还原为 NULL）
+func (t *Table) Export(w io.Writer, format DataFormat, opts *ExportOptions) (int64, error) {
+	if w == nil {
+		return 0, NewErrorf(ErrCodeInvalidParam, "writer is nil")
+	}
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+
+	var enc rowEncoder
+	switch format {
+	case FormatJSONL:
+		enc = &jsonlEncoder{w: bufio.NewWriter(w)}
+	case FormatCSV:
+		enc = &csvEncoder{w: csv.NewWriter(w)}
+	default:
+		return 0, NewErrorf(ErrCodeInvalidParam, "unknown data format %d", format)
+	}
+
+	q, release, err := t.exportQuery(opts.Query, "Export")
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	columns := exportColumns(t.schema.Load(), q.fields)
+	for _, name := range columns {
+		if name == "_seq" || name == "_time" {
+			continue
+		}
+		if _, err := t.schema.Load().GetField(name); err != nil {
+			return 0, NewErrorf(ErrCodeFieldNotFound, "field %s not found", name)
+		}
+	}
+	if err := enc.header(columns); err != nil {
+		return 0, err
+	}
+
+	rows, err := q.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int64
+	for rows.Next() {
+		if err := enc.encode(rows.currentRow.inner, columns); err != nil {
+			return n, WrapError(err, "row %d", rows.currentRow.inner.Seq)
+		}
+		n++
+		if opts.Progress != nil && n%MigrateBatchSize == 0 {
+			opts.Progress(n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	if err := enc.flush(); err != nil {
+		return n, err
+	}
+	if opts.Progress != nil && n%MigrateBatchSize != 0 {
+		opts.Progress(n)
+	}
+	return n, nil
+}
+
+// exportQuery 返回在快照上执行的查询（q 为 nil 时查询整张表），release 释放为导出创建的快照
+func (t *Table) exportQuery(q *QueryBuilder, op string) (*QueryBuilder, func(), error) {
+	if q == nil {
+		q = t.Query()
+	}
+	if q.table != t {
+		return nil, nil, NewErrorf(ErrCodeInvalidParam, "query belongs to another table")
+	}
+	if len(q.exprs) > 0 {
+		return nil, nil, NewErrorf(ErrCodeInvalidParam, "%s does not support computed columns in Select", op)
+	}
+	if q.snap != nil {
+		return q, func() {}, nil
+	}
+	snap, err := t.Snapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+	scoped := *q
+	scoped.snap = snap
+	return &scoped, snap.Release, nil
+}
+
+// exportColumns 返回导出的列：Select 的字段，为空时为 _seq、_time 与所有未弃用的字段
+func exportColumns(schema *Schema, fields []string) []string {
+	if len(fields) > 0 {
+		return fields
+	}
+	columns := []string{"_seq", "_time"}
+	for _, f := range schema.Fields {
+		if !f.Deprecated {
+			columns = append(columns, f.Name)
+		}
+	}
+	return columns
+}
+
+// exportValue 返回行中一列的值
+func exportValue(row *SSTableRow, name string) any {
+	switch name {
+	case "_seq":
+		return row.Seq
+	case "_time":
+		return time.Unix(0, row.Time).UTC()
+	default:
+		return row.Data[name]
+	}
+}
+
+// rowEncoder 按格式写出导出的行
+type rowEncoder interface {
+	header(columns []string) error
+	encode(row *SSTableRow, columns []string) error
+	flush() error
+}
+
+// jsonlEncoder 每行写出一个按列顺序排列的 JSON 对象
+type jsonlEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *jsonlEncoder) header(columns []string) error {
+	return nil
+}
+
+func (e *jsonlEncoder) encode(row *SSTableRow, columns []string) error {
+	e.w.WriteByte('{')
+	for i, name := range columns {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		e.w.Write(key)
+		e.w.WriteByte(':')
+		value, err := json.Marshal(exportValue(row, name))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+		e.w.Write(value)
+	}
+	e.w.WriteByte('}')
+	return e.w.WriteByte('\n')
+}
+
+func (e *jsonlEncoder) flush() error {
+	return e.w.Flush()
+}
+
+// csvEncoder 第一行写出列名，之后每行一条记录
+type csvEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvEncoder) header(columns []string) error {
+	e.record = make([]string, len(columns))
+	return e.w.Write(columns)
+}
+
+func (e *csvEncoder) encode(row *SSTableRow, columns []string) error {
+	for i, name := range columns {
+		value, err := csvValue(exportValue(row, name))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+		e.record[i] = value
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvValue 返回值在 CSV 中的文本（见 Table.Export）
+func csvValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		return string(data), err
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// ImportErrorMode Import 遇到无效行时的处理方式
+type ImportErrorMode int
+
+const (
+	// ImportAbort 停止导入（默认），无效行之前的行已写入
+	ImportAbort ImportErrorMode = iota
+
+	// ImportSkip 跳过无效行继续导入，跳过的行记录在 ImportResult 中
+	ImportSkip
+)
+
+// String 返回处理方式名称
+func (m ImportErrorMode) String() string {
+	switch m {
+	case ImportAbort:
+		return "abort"
+	case ImportSkip:
+		return "skip"
+	default:
+		return "unknown"
+	}
+}
+
+// importMaxErrors ImportResult.Errors 最多保留的错误数量
+const importMaxErrors = 100
+
+// ImportOptions Import 的选项
+type ImportOptions struct {
+	BatchSize int             // 每批写入的行数（每批生成一个 L0 SST 文件），<= 0 时为 MigrateBatchSize
+	OnError   ImportErrorMode // 遇到无效行时的处理方式
+	MaxErrors int64           // ImportSkip 时最多跳过的行数，超过后停止导入；0 表示不限制
+
+	// Progress 每写入一批后回调已导入与已跳过的行数
+	Progress func(imported, skipped int64)
+}
+
+// ImportRowError 一行无效数据及其原因
+type ImportRowError struct {
+	Line int   // 行号（CSV 中记录开始的行，从 1 开始）
+	Err  error // 解析、类型转换或 Schema 验证的错误
+}
+
+// Error 返回带行号的错误信息
+func (e ImportRowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ImportResult Import 的结果
+type ImportResult struct {
+	Rows    int64            // 导入的行数
+	Skipped int64            // 跳过的无效行数（ImportSkip）
+	Errors  []ImportRowError // 跳过的行的错误，最多保留前 100 个
+}
+
+// Import 按 format 从 r 导入数据，返回导入与跳过的行数
+//
+// 值按字段类型转换：JSONL 的规则与 ImportJSON 相同，CSV 的规则与 ImportCSV 相同（空字符串视为 NULL，String 字段除外）。
+// Schema 中不存在的字段（包括 Export 导出的 _seq、_time）与计算列被忽略。数据按批跳过 WAL 直接写入 SST，
+// 每批写入后即已持久化；无法解析、无法转换或不满足 Schema 的行按 opts.OnError 处理：
+// ImportAbort 时返回带行号的错误，之前的行已写入；ImportSkip 时跳过该行。
+// 读取 r 失败时停止导入，返回的结果包含已写入的行数。
+func (t *Table) Import(r io.Reader, format DataFormat, opts *ImportOptions) (*ImportResult, error) {
+	if r == nil {
+		return &ImportResult{}, NewErrorf(ErrCodeInvalidParam, "reader is nil")
+	}
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+
+	var reader rowDecoder
+	switch format {
+	case FormatJSONL:
+		reader = &jsonlDecoder{schema: t.schema.Load(), lines: newJSONLineReader(r)}
+	case FormatCSV:
+		reader = newCSVDecoder(t.schema.Load(), r)
+	default:
+		return &ImportResult{}, NewErrorf(ErrCodeInvalidParam, "unknown data format %d", format)
+	}
+
+	loader := &importLoader{table: t, opts: opts, size: opts.BatchSize}
+	if loader.size <= 0 {
+		loader.size = MigrateBatchSize
+	}
+	for {
+		row, line, rowErr, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return &loader.result, err
+		}
+		if rowErr != nil {
+			err = loader.reject(line, rowErr)
+		} else {
+			err = loader.add(row, line)
+		}
+		if err != nil {
+			return &loader.result, err
+		}
+	}
+	return &loader.result, loader.flush()
+}
+
+// rowDecoder 按格式读取导入的行
+type rowDecoder interface {
+	// next 返回下一行及其行号；rowErr 不为 nil 表示该行无效，err 为 io.EOF 表示读完，其他 err 需要停止导入
+	next() (row map[string]any, line int, rowErr, err error)
+}
+
+// jsonlDecoder 逐行读取 NDJSON
+type jsonlDecoder struct {
+	schema *Schema
+	lines  *jsonLineReader
+}
+
+func (d *jsonlDecoder) next() (map[string]any, int, error, error) {
+	data, err := d.lines.next()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	row := make(map[string]any)
+	err = decodeJSONObject(data, func(key string, value any) {
+		field, err := d.schema.GetField(key)
+		if err != nil || field.Computed != "" {
+			return
+		}
+		row[key] = importJSONValue(value, field.Type)
+	})
+	return row, d.lines.line, err, nil
+}
+
+// csvDecoder 按第一行的列名读取 CSV
+type csvDecoder struct {
+	schema *Schema
+	reader *csv.Reader
+	fields []*Field // 列对应的字段（nil 表示忽略该列），读取列名之前为 nil
+}
+
+func newCSVDecoder(schema *Schema, r io.Reader) *csvDecoder {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	reader.FieldsPerRecord = -1 // 缺少的列按未提供处理
+	return &csvDecoder{schema: schema, reader: reader}
+}
+
+func (d *csvDecoder) next() (map[string]any, int, error, error) {
+	if d.fields == nil {
+		header, err := d.reader.Read()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		d.fields = make([]*Field, len(header))
+		for i, name := range header {
+			if field, err := d.schema.GetField(strings.TrimSpace(name)); err == nil && field.Computed == "" {
+				d.fields[i] = field
+			}
+		}
+	}
+
+	record, err := d.reader.Read()
+	if err != nil {
+		// 格式错误只影响当前记录，之后的记录可以继续读取
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.StartLine, err, nil
+		}
+		return nil, 0, nil, err
+	}
+
+	line, _ := d.reader.FieldPos(0)
+	row := make(map[string]any, len(d.fields))
+	for i, field := range d.fields {
+		if field == nil || i >= len(record) {
+			continue
+		}
+		value, err := migrateValue(record[i], field.Type)
+		if err != nil {
+			return nil, line, fmt.Errorf("column %s: %w", field.Name, err), nil
+		}
+		row[field.Name] = value
+	}
+	return row, line, nil, nil
+}
+
+// importLoader 累积有效的行并按批写入
+type importLoader struct {
+	table  *Table
+	opts   *ImportOptions
+	size   int
+	batch  []map[string]any
+	lines  []int // batch 中每行的行号
+	result ImportResult
+}
+
+func (l *importLoader) add(row map[string]any, line int) error {
+	l.batch = append(l.batch, row)
+	l.lines = append(l.lines, line)
+	if len(l.batch) >= l.size {
+		return l.flush()
+	}
+	return nil
+}
+
+// reject 按 OnError 处理一行无效数据，需要停止导入时返回错误（ImportAbort 时先写入之前的行）
+func (l *importLoader) reject(line int, err error) error {
+	if l.opts.OnError != ImportSkip {
+		if flushErr := l.flush(); flushErr != nil {
+			return flushErr
+		}
+		return WrapError(err, "line %d", line)
+	}
+	return l.skip(line, err)
+}
+
+// skip 记录跳过的行，超过 MaxErrors 时返回错误
+func (l *importLoader) skip(line int, err error) error {
+	l.result.Skipped++
+	if len(l.result.Errors) < importMaxErrors {
+		l.result.Errors = append(l.result.Errors, ImportRowError{Line: line, Err: err})
+	}
+	if l.opts.MaxErrors > 0 && l.result.Skipped > l.opts.MaxErrors {
+		return WrapError(err, "line %d: more than %d invalid rows", line, l.opts.MaxErrors)
+	}
+	return nil
+}
+
+// flush 写入当前批次
+//
+// 整批验证失败时逐行找出不满足 Schema 的行：ImportAbort 时写入第一个无效行之前的行后返回错误，
+// ImportSkip 时跳过这些行后写入其余的行。
+func (l *importLoader) flush() error {
+	if len(l.batch) == 0 {
+		return nil
+	}
+	// bulkLoad 不保留 batch 中的 map，底层数组可以复用
+	batch, lines := l.batch, l.lines
+	l.batch, l.lines = l.batch[:0], l.lines[:0]
+
+	err := l.table.bulkLoad(batch)
+	if IsError(err, ErrCodeSchemaValidationFailed) {
+		err = l.loadValid(batch, lines)
+	} else if err != nil {
+		err = WrapError(err, "lines %d-%d", lines[0], lines[len(lines)-1])
+	} else {
+		l.result.Rows += int64(len(batch))
+	}
+	if err != nil {
+		return err
+	}
+
+	if l.opts.Progress != nil {
+		l.opts.Progress(l.result.Rows, l.result.Skipped)
+	}
+	return nil
+}
+
+// loadValid 逐行验证整批写入失败的批次，写入有效的行
+func (l *importLoader) loadValid(batch []map[string]any, lines []int) error {
+	schema := l.table.schema.Load()
+	now := l.table.clock.Now().UnixNano()
+	valid := make([]map[string]any, 0, len(batch))
+	var stop error
+	for i, row := range batch {
+		if _, _, err := l.table.prepareRow(schema, row, now); err != nil {
+			if l.opts.OnError != ImportSkip {
+				stop = WrapError(err, "line %d", lines[i])
+			} else {
+				stop = l.skip(lines[i], err)
+			}
+			if stop != nil {
+				break
+			}
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	if len(valid) > 0 {
+		if err := l.table.bulkLoad(valid); err != nil {
+			return WrapError(err, "lines %d-%d", lines[0], lines[len(lines)-1])
+		}
+		l.result.Rows += int64(len(valid))
+	}
+	return stop
+}
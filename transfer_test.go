@@ -0,0 +1,175 @@
+package srdb
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestExportImport(t *testing.T) {
+	fields := []Field{
+		{Name: "id", Type: Int64, Indexed: true},
+		{Name: "name", Type: String},
+		{Name: "score", Type: Float64, Nullable: true},
+		{Name: "ok", Type: Bool},
+		{Name: "at", Type: Time},
+		{Name: "took", Type: Duration},
+		{Name: "price", Type: Decimal},
+		{Name: "meta", Type: Object, Nullable: true},
+		{Name: "tags", Type: Array, Nullable: true},
+	}
+	open := func(name string) *Table {
+		t.Helper()
+		table, err := OpenTable(&TableOptions{Dir: t.TempDir(), Name: name, Fields: fields})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { table.Close() })
+		return table
+	}
+
+	src := open("src")
+	at := time.Date(2024, 5, 1, 8, 30, 0, 123456789, time.UTC)
+	for i := range 25 {
+		row := map[string]any{
+			"id":    int64(i),
+			"name":  "n,\"" + strings.Repeat("x", i%3),
+			"score": 1.5 * float64(i),
+			"ok":    i%2 == 0,
+			"at":    at.Add(time.Duration(i) * time.Hour),
+			"took":  time.Duration(i) * time.Second,
+			"price": decimal.RequireFromString("19.99"),
+			"meta":  map[string]any{"k": "v"},
+			"tags":  []any{"a", "b"},
+		}
+		if i == 3 {
+			row["score"], row["meta"], row["tags"] = nil, nil, nil
+		}
+		if err := src.Insert(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, format := range []DataFormat{FormatJSONL, FormatCSV} {
+		var buf bytes.Buffer
+		var progress []int64
+		n, err := src.Export(&buf, format, &ExportOptions{Progress: func(rows int64) { progress = append(progress, rows) }})
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if n != 25 || !reflect.DeepEqual(progress, []int64{25}) {
+			t.Errorf("%s: expected 25 rows, got %d (progress %v)", format, n, progress)
+		}
+
+		dst := open("dst")
+		result, err := dst.Import(&buf, format, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if result.Rows != 25 || result.Skipped != 0 {
+			t.Errorf("%s: unexpected result %+v", format, result)
+		}
+
+		// 导入后的行与原表相同（_seq 与 _time 不导入）
+		for seq := int64(1); seq <= 25; seq++ {
+			want, err := src.Get(seq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := dst.Get(seq)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			for key, value := range want.Data {
+				if d, ok := value.(decimal.Decimal); ok {
+					if g, ok := got.Data[key].(decimal.Decimal); !ok || !g.Equal(d) {
+						t.Errorf("%s: seq %d %s: expected %v, got %v", format, seq, key, value, got.Data[key])
+					}
+					continue
+				}
+				if !reflect.DeepEqual(got.Data[key], value) {
+					t.Errorf("%s: seq %d %s: expected %#v, got %#v", format, seq, key, value, got.Data[key])
+				}
+			}
+		}
+		if n, err := dst.Query().Eq("id", int64(7)).Count(); err != nil || n != 1 {
+			t.Errorf("%s: expected imported rows to be indexed, got %d %v", format, n, err)
+		}
+	}
+
+	// 按查询导出指定的列
+	var buf bytes.Buffer
+	if _, err := src.Export(&buf, FormatCSV, &ExportOptions{Query: src.Query().Lt("id", int64(2)).Select("id", "took")}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "id,took\n0,0s\n1,1s\n"; buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+	if _, err := src.Export(&buf, DataFormat(9), nil); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for an unknown format, got %v", err)
+	}
+}
+
+func TestImportErrorModes(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:  t.TempDir(),
+		Name: "orders",
+		Fields: []Field{
+			{Name: "id", Type: Int64},
+			{Name: "qty", Type: Int16},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 第 3 行无法转换，第 5 行的 qty 为 NULL（写入时验证失败）
+	input := "id,qty\n1,1\n2,many\n3,3\n4,\n5,5\n"
+
+	// 停止导入时无效行之前的行已写入
+	result, err := table.Import(strings.NewReader(input), FormatCSV, nil)
+	if err == nil || !strings.Contains(err.Error(), "line 3") || result.Rows != 1 {
+		t.Fatalf("expected abort on line 3 after 1 row, got %+v %v", result, err)
+	}
+	result, err = table.Import(strings.NewReader("id,qty\n3,3\n4,\n5,5\n"), FormatCSV, nil)
+	if !IsError(err, ErrCodeSchemaValidationFailed) || !strings.Contains(err.Error(), "line 3") || result.Rows != 1 {
+		t.Fatalf("expected validation failure on line 3 after 1 row, got %+v %v", result, err)
+	}
+
+	// 跳过无效行，每批写入后回调进度
+	var progress [][2]int64
+	result, err = table.Import(strings.NewReader(input), FormatCSV, &ImportOptions{
+		BatchSize: 2,
+		OnError:   ImportSkip,
+		Progress:  func(imported, skipped int64) { progress = append(progress, [2]int64{imported, skipped}) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rows != 3 || result.Skipped != 2 || len(result.Errors) != 2 || result.Errors[0].Line != 3 || result.Errors[1].Line != 5 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if want := [][2]int64{{2, 1}, {3, 2}}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("expected progress %v, got %v", want, progress)
+	}
+
+	// 超过 MaxErrors 时停止
+	_, err = table.Import(strings.NewReader(input), FormatCSV, &ImportOptions{OnError: ImportSkip, MaxErrors: 1})
+	if err == nil || !strings.Contains(err.Error(), "more than 1 invalid rows") {
+		t.Errorf("expected MaxErrors to stop the import, got %v", err)
+	}
+
+	// JSONL 中无法解析的行同样可以跳过
+	result, err = table.Import(strings.NewReader("{\"id\":10,\"qty\":1}\n{oops\n\n{\"id\":11,\"qty\":2,\"_seq\":99}\n"), FormatJSONL, &ImportOptions{OnError: ImportSkip})
+	if err != nil || result.Rows != 2 || result.Skipped != 1 || result.Errors[0].Line != 2 {
+		t.Errorf("unexpected JSONL result %+v %v", result, err)
+	}
+	if n, err := table.Query().Eq("id", int64(11)).Count(); err != nil || n != 1 {
+		t.Errorf("expected id 11 to be imported, got %d %v", n, err)
+	}
+}
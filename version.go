@@ -1,6 +1,7 @@
 package srdb
 
 import (
+	"bufio"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,7 @@
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // FileMetadata SST 文件元数据
@@ -21,10 +23,17 @@ type FileMetadata struct {
 	MinKey     int64 // 最小 key
 	MaxKey     int64 // 最大 key
 	RowCount   int64 // 行数
+
+	// 行的最小、最大写入时间 _time（UnixNano），与 SST 文件头部一致；旧版本写入的记录为 0
+	MinTime int64 `json:",omitempty"`
+	MaxTime int64 `json:",omitempty"`
 }
 
 const (
 	NumLevels = 4 // L0-L3
+
+	// DefaultManifestMaxSize MANIFEST 超过此大小时重写为当前版本的快照
+	DefaultManifestMaxSize = 4 * 1024 * 1024 // 4MB
 )
 
 // Version 数据库的一个版本快照
@@ -267,8 +276,25 @@ func (w *ManifestWriter) WriteEdit(edit *VersionEdit) error {
 	EditTypeSetLastSeq  EditType = 4 // 设置最后序列号
 )
 
+// 版本变更的原因（VersionEdit.Reason）
+const (
+	EditReasonInit       = "init"       // 创建 MANIFEST 时的初始记录
+	EditReasonFlush      = "flush"      // MemTable 写入 L0
+	EditReasonBulkLoad   = "bulk_load"  // BulkLoad 直接写入 L0
+	EditReasonCompaction = "compaction" // Compaction 合并文件
+	EditReasonSnapshot   = "snapshot"   // 重写 MANIFEST 时写入的当前版本快照
+	EditReasonRetention  = "retention"  // 保留策略删除所有行都已过期的 SST
+	EditReasonBackup     = "backup"     // 备份目录中按固定的版本生成的记录
+)
+
 // VersionEdit 版本变更记录
 type VersionEdit struct {
+	// 变更时间（UnixNano），LogAndApply 时记录；旧版本写入的记录为 0
+	Time int64 `json:",omitempty"`
+
+	// 变更原因（EditReason*），旧版本写入的记录为空
+	Reason string `json:",omitempty"`
+
 	// 添加的文件
 	AddedFiles []*FileMetadata
 
@@ -310,6 +336,19 @@ func (e *VersionEdit) SetLastSequence(seq int64) {
 	e.LastSequence = &seq
 }
 
+// SetReason 设置变更原因
+func (e *VersionEdit) SetReason(reason string) {
+	e.Reason = reason
+}
+
+// When 返回变更时间，没有记录时返回零值
+func (e *VersionEdit) When() time.Time {
+	if e.Time == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, e.Time)
+}
+
 // Encode 编码为字节
 func (e *VersionEdit) Encode() ([]byte, error) {
 	// 使用 JSON 编码（简单实现）
@@ -369,9 +408,15 @@ type VersionSet struct {
 	current *Version
 
 	// MANIFEST 文件
-	manifestFile   *os.File
-	manifestWriter *ManifestWriter
-	manifestNumber int64
+	manifestFile    *os.File
+	manifestWriter  *ManifestWriter
+	manifestNumber  int64
+	manifestSize    int64 // 当前 MANIFEST 大小
+	manifestBase    int64 // 上次重写后的大小（快照大小）
+	manifestMaxSize int64 // 超过后自动重写，0 表示不自动重写
+
+	// 故障注入（仅测试）
+	faults *FaultInjector
 
 	// 下一个文件编号
 	nextFileNumber atomic.Int64
@@ -389,7 +434,8 @@ type VersionSet struct {
 // NewVersionSet 创建版本集合
 func NewVersionSet(dir string) (*VersionSet, error) {
 	vs := &VersionSet{
-		dir: dir,
+		dir:             dir,
+		manifestMaxSize: DefaultManifestMaxSize,
 	}
 
 	// 确保目录存在
@@ -429,12 +475,37 @@ func NewVersionSet(dir string) (*VersionSet, error) {
 	if err != nil {
 		return nil, err
 	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
 	vs.manifestFile = file
 	vs.manifestWriter = NewManifestWriter(file)
+	vs.manifestSize = stat.Size()
+
+	// 清理重写 MANIFEST 时崩溃遗留的文件
+	vs.removeObsoleteManifests(manifestName)
 
 	return vs, nil
 }
 
+// removeObsoleteManifests 删除 CURRENT 未引用的 MANIFEST 文件
+//
+// 重写在切换 CURRENT 之前崩溃时遗留未完成的新文件，切换之后崩溃时遗留旧文件，两者都不再需要。
+func (vs *VersionSet) removeObsoleteManifests(current string) {
+	matches, err := filepath.Glob(filepath.Join(vs.dir, "MANIFEST-*"))
+	if err != nil {
+		return
+	}
+	for _, path := range matches {
+		if filepath.Base(path) != current {
+			os.Remove(path)
+		}
+	}
+	os.Remove(filepath.Join(vs.dir, "CURRENT.tmp"))
+}
+
 // createNewManifest 创建新的 MANIFEST
 func (vs *VersionSet) createNewManifest() error {
 	// 生成新的 MANIFEST 文件名
@@ -456,6 +527,8 @@ func (vs *VersionSet) createNewManifest() error {
 
 	// 写入初始版本
 	edit := NewVersionEdit()
+	edit.SetReason(EditReasonInit)
+	edit.Time = time.Now().UnixNano()
 	nextFile := vs.manifestNumber
 	edit.SetNextFileNumber(nextFile)
 	lastSeq := int64(0)
@@ -471,6 +544,9 @@ func (vs *VersionSet) createNewManifest() error {
 	if err != nil {
 		return err
 	}
+	if stat, err := file.Stat(); err == nil {
+		vs.manifestSize = stat.Size()
+	}
 
 	// 更新 CURRENT 文件
 	return vs.updateCurrent(manifestName)
@@ -512,11 +588,22 @@ func (vs *VersionSet) updateCurrent(manifestName string) error {
 	currentPath := filepath.Join(vs.dir, "CURRENT")
 	tmpPath := currentPath + ".tmp"
 
-	// 1. 写入临时文件
-	err := os.WriteFile(tmpPath, []byte(manifestName+"\n"), 0644)
+	// 1. 写入临时文件并同步
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
+	_, err = file.WriteString(manifestName + "\n")
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
 
 	// 2. 原子性重命名
 	err = os.Rename(tmpPath, currentPath)
@@ -525,14 +612,30 @@ func (vs *VersionSet) updateCurrent(manifestName string) error {
 		return err
 	}
 
+	// 3. 同步目录，确保重命名已持久化
+	syncDir(vs.dir)
 	return nil
 }
 
+// syncDir 同步目录项（尽力而为：部分平台不支持同步目录）
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
 // LogAndApply 记录并应用版本变更
 func (vs *VersionSet) LogAndApply(edit *VersionEdit) error {
 	vs.mu.Lock()
 	defer vs.mu.Unlock()
 
+	if edit.Time == 0 {
+		edit.Time = time.Now().UnixNano()
+	}
+
 	// 1. 创建新版本
 	newVersion := vs.current.Clone()
 
@@ -540,10 +643,18 @@ func (vs *VersionSet) LogAndApply(edit *VersionEdit) error {
 	newVersion.Apply(edit)
 
 	// 3. 写入 MANIFEST
-	err := vs.manifestWriter.WriteEdit(edit)
+	if err := vs.faults.check(FaultManifestWrite); err != nil {
+		return err
+	}
+	data, err := edit.Encode()
+	if err != nil {
+		return err
+	}
+	_, err = vs.manifestFile.Write(data)
 	if err != nil {
 		return err
 	}
+	vs.manifestSize += int64(len(data))
 
 	// 4. 同步到磁盘
 	err = vs.manifestFile.Sync()
@@ -572,9 +683,144 @@ func (vs *VersionSet) LogAndApply(edit *VersionEdit) error {
 		vs.lastSequence.Store(*edit.LastSequence)
 	}
 
+	// 7. MANIFEST 过大时重写（变更已持久化，重写失败不影响本次结果，下次变更时重试）
+	// 同时要求超过快照大小的 2 倍，避免快照本身接近阈值时频繁重写
+	if vs.manifestMaxSize > 0 && vs.manifestSize > vs.manifestMaxSize && vs.manifestSize > 2*vs.manifestBase {
+		vs.rewriteManifest()
+	}
+
+	return nil
+}
+
+// SetFaultInjector 设置故障注入器（仅测试）
+func (vs *VersionSet) SetFaultInjector(faults *FaultInjector) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.faults = faults
+}
+
+// SetManifestMaxSize 设置 MANIFEST 自动重写的大小阈值，0 表示不自动重写
+//
+// 超过阈值且超过上次重写后大小的 2 倍时重写。
+func (vs *VersionSet) SetManifestMaxSize(size int64) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.manifestMaxSize = max(size, 0)
+}
+
+// ManifestSize 返回当前 MANIFEST 文件的大小
+func (vs *VersionSet) ManifestSize() int64 {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	return vs.manifestSize
+}
+
+// RewriteManifest 将当前版本的快照写入新的 MANIFEST 并切换，删除旧文件
+//
+// MANIFEST 记录每一次 VersionEdit，打开时需要全部回放；重写后只包含一条快照记录。
+// 切换通过原子替换 CURRENT 完成：之前崩溃仍使用旧文件，之后崩溃使用新文件，
+// 遗留的文件在下次打开时清理。
+func (vs *VersionSet) RewriteManifest() error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	return vs.rewriteManifest()
+}
+
+// rewriteManifest 重写 MANIFEST（调用者必须持有 vs.mu 写锁）
+func (vs *VersionSet) rewriteManifest() error {
+	// 1. 当前版本的快照
+	number := vs.nextFileNumber.Add(1)
+	current := vs.current
+
+	edit := NewVersionEdit()
+	edit.SetReason(EditReasonSnapshot)
+	edit.Time = time.Now().UnixNano()
+	for level := range NumLevels {
+		for _, file := range current.GetLevel(level) {
+			edit.AddFile(file)
+		}
+	}
+	edit.SetNextFileNumber(max(current.GetNextFileNumber(), vs.nextFileNumber.Load()))
+	edit.SetLastSequence(current.GetLastSequence())
+
+	data, err := edit.Encode()
+	if err != nil {
+		return err
+	}
+
+	// 2. 写入新 MANIFEST 并同步
+	manifestName := fmt.Sprintf("MANIFEST-%06d", number)
+	manifestPath := filepath.Join(vs.dir, manifestName)
+	file, err := os.OpenFile(manifestPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(manifestPath)
+		return err
+	}
+
+	// 3. 切换 CURRENT（提交点）
+	if err := vs.updateCurrent(manifestName); err != nil {
+		file.Close()
+		os.Remove(manifestPath)
+		return err
+	}
+
+	// 4. 切换写入目标，删除旧 MANIFEST
+	oldPath := vs.manifestFile.Name()
+	vs.manifestFile.Close()
+	vs.manifestFile = file
+	vs.manifestWriter = NewManifestWriter(file)
+	vs.manifestNumber = number
+	vs.manifestSize = int64(len(data))
+	vs.manifestBase = vs.manifestSize
+	os.Remove(oldPath)
+
 	return nil
 }
 
+// History 返回当前 MANIFEST 中最近的 n 条版本变更（按时间顺序，n <= 0 返回全部）
+//
+// 每条记录包括变更时间、原因与增删的文件，用于回答"某个时间点磁盘上发生了什么"。
+// MANIFEST 重写后只保留一条快照记录（EditReasonSnapshot），之前的历史不再可用；
+// 旧版本写入的记录没有时间与原因。
+func (vs *VersionSet) History(n int) ([]*VersionEdit, error) {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	if vs.manifestFile == nil {
+		return nil, nil
+	}
+	file, err := os.Open(vs.manifestFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := NewManifestReader(bufio.NewReader(file))
+	var edits []*VersionEdit
+	for {
+		edit, err := reader.ReadEdit()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+		if n > 0 && len(edits) > n {
+			edits = edits[1:]
+		}
+	}
+	return edits, nil
+}
+
 // GetCurrent 获取当前版本
 func (vs *VersionSet) GetCurrent() *Version {
 	vs.mu.RLock()
@@ -2,7 +2,9 @@
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestVersionSetBasic(t *testing.T) {
@@ -218,3 +220,212 @@ func TestVersionEditEncodeDecode(t *testing.T) {
 
 	t.Log("VersionEdit encode/decode test passed!")
 }
+
+func TestVersionSetRewriteManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	vs, err := NewVersionSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs.SetManifestMaxSize(2048)
+
+	// 反复添加、删除文件，使 MANIFEST 超过阈值并自动重写
+	rewrites := 0
+	for i := int64(1); i <= 100; i++ {
+		before := vs.ManifestSize()
+		edit := NewVersionEdit()
+		fileNumber := vs.AllocateFileNumber()
+		edit.AddFile(&FileMetadata{FileNumber: fileNumber, Level: int(i % NumLevels), FileSize: 1024, MinKey: i, MaxKey: i, RowCount: 1})
+		if i%3 == 0 {
+			edit.DeleteFile(fileNumber - 1)
+		}
+		edit.SetNextFileNumber(vs.GetNextFileNumber())
+		edit.SetLastSequence(i)
+		if err := vs.LogAndApply(edit); err != nil {
+			t.Fatal(err)
+		}
+		if vs.ManifestSize() < before {
+			rewrites++
+		}
+	}
+	if rewrites == 0 {
+		t.Errorf("expected manifest to be rewritten, size %d", vs.ManifestSize())
+	}
+
+	expected := vs.GetCurrent()
+	manifests, _ := filepath.Glob(filepath.Join(dir, "MANIFEST-*"))
+	if len(manifests) != 1 {
+		t.Errorf("expected only the current manifest, got %v", manifests)
+	}
+	vs.Close()
+
+	check := func(vs *VersionSet) {
+		t.Helper()
+		current := vs.GetCurrent()
+		for level := range NumLevels {
+			got, want := current.GetLevel(level), expected.GetLevel(level)
+			if len(got) != len(want) {
+				t.Fatalf("level %d: expected %d files, got %d", level, len(want), len(got))
+			}
+			for i := range got {
+				if *got[i] != *want[i] {
+					t.Errorf("level %d file %d: expected %+v, got %+v", level, i, want[i], got[i])
+				}
+			}
+		}
+		if current.GetLastSequence() != 100 {
+			t.Errorf("expected last sequence 100, got %d", current.GetLastSequence())
+		}
+		if vs.GetNextFileNumber() < expected.GetNextFileNumber() {
+			t.Errorf("next file number went backwards: %d < %d", vs.GetNextFileNumber(), expected.GetNextFileNumber())
+		}
+	}
+
+	// 重新打开后与重写前的版本一致
+	vs, err = NewVersionSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(vs)
+
+	// 手动重写后继续追加
+	if err := vs.RewriteManifest(); err != nil {
+		t.Fatal(err)
+	}
+	edit := NewVersionEdit()
+	edit.SetLastSequence(100)
+	if err := vs.LogAndApply(edit); err != nil {
+		t.Fatal(err)
+	}
+	current, _ := os.ReadFile(filepath.Join(dir, "CURRENT"))
+	vs.Close()
+
+	// 模拟切换 CURRENT 之前崩溃：遗留未完成的新 MANIFEST 与临时文件
+	os.WriteFile(filepath.Join(dir, "MANIFEST-999999"), []byte("partial"), 0644)
+	os.WriteFile(filepath.Join(dir, "CURRENT.tmp"), []byte("MANIFEST-999999\n"), 0644)
+
+	vs, err = NewVersionSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(vs)
+	vs.Close()
+
+	manifests, _ = filepath.Glob(filepath.Join(dir, "MANIFEST-*"))
+	if len(manifests) != 1 || filepath.Base(manifests[0])+"\n" != string(current) {
+		t.Errorf("expected leftover files to be removed, got %v", manifests)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CURRENT.tmp")); !os.IsNotExist(err) {
+		t.Error("expected CURRENT.tmp to be removed")
+	}
+}
+
+func TestVersionSetHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	vs, err := NewVersionSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := int64(1); i <= 5; i++ {
+		edit := NewVersionEdit()
+		edit.SetReason(EditReasonFlush)
+		edit.AddFile(&FileMetadata{FileNumber: 100 + i, Level: 0, FileSize: 1024, MinKey: i, MaxKey: i, RowCount: 1})
+		if err := vs.LogAndApply(edit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	edit := NewVersionEdit()
+	edit.SetReason(EditReasonCompaction)
+	edit.DeleteFile(101)
+	edit.DeleteFile(102)
+	edit.AddFile(&FileMetadata{FileNumber: 200, Level: 1, FileSize: 2048, MinKey: 1, MaxKey: 2, RowCount: 2})
+	if err := vs.LogAndApply(edit); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := vs.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 初始记录 + 5 次 flush + 1 次 compaction
+	if len(all) != 7 || all[0].Reason != EditReasonInit {
+		t.Fatalf("expected 7 edits starting with init, got %d", len(all))
+	}
+
+	recent, err := vs.History(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || recent[0].Reason != EditReasonFlush || recent[1].Reason != EditReasonCompaction {
+		t.Fatalf("unexpected recent edits: %+v", recent)
+	}
+	last := recent[1]
+	if len(last.DeletedFiles) != 2 || len(last.AddedFiles) != 1 || last.AddedFiles[0].FileNumber != 200 {
+		t.Errorf("unexpected compaction edit: %+v", last)
+	}
+	if last.When().Before(start) || last.When().Before(recent[0].When()) {
+		t.Errorf("unexpected edit time: %v", last.When())
+	}
+
+	// 重新打开后历史仍然可用
+	vs.Close()
+	vs, err = NewVersionSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vs.Close()
+	reopened, err := vs.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reopened) != 7 || reopened[6].Time != last.Time {
+		t.Fatalf("history changed after reopen: %d edits", len(reopened))
+	}
+
+	// 重写后只剩快照
+	if err := vs.RewriteManifest(); err != nil {
+		t.Fatal(err)
+	}
+	rewritten, err := vs.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rewritten) != 1 || rewritten[0].Reason != EditReasonSnapshot || len(rewritten[0].AddedFiles) != 4 {
+		t.Fatalf("unexpected history after rewrite: %+v", rewritten)
+	}
+}
+
+func TestTableManifestHistoryReasons(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:    t.TempDir(),
+		Name:   "events",
+		Fields: []Field{{Name: "name", Type: String}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(map[string]any{"name": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	table.Flush()
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := table.bulkLoad([]map[string]any{{"name": "b"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	edits, err := table.GetVersionSet().History(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 2 || edits[0].Reason != EditReasonFlush || edits[1].Reason != EditReasonBulkLoad {
+		t.Fatalf("unexpected history: %+v", edits)
+	}
+}
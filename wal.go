@@ -16,12 +16,29 @@
 const (
 	// Entry 类型
 	WALEntryTypePut    = 1
-	WALEntryTypeDelete = 2 // 预留，暂不支持
+	WALEntryTypeDelete = 2 // Data 为删除标记的行编码（见 Table.Delete）
+	// WALEntryTypeBatchPut 原子批量写入的插入：Data 为 8 字节批次编号加行编码，
+	// 数据库的提交记录中有该批次时才回放（见 Database.Batch）
+	WALEntryTypeBatchPut = 3
+
+	// WALEntryFlagCompressed Type 的最高位：Data 经过 Snappy 压缩（读取时自动解压并清除）
+	WALEntryFlagCompressed = 0x80
 
 	// Entry Header 大小
 	WALEntryHeaderSize = 17 // CRC32(4) + Length(4) + Type(1) + Seq(8)
+
+	// walCompressMinSize 小于此大小的记录不压缩
+	walCompressMinSize = 256
 )
 
+// WALStats WAL 写入统计（自 WAL 管理器创建以来）
+type WALStats struct {
+	Records           int64 // 写入的记录数
+	CompressedRecords int64 // 压缩后写入的记录数（压缩后不小于原始数据时按原样写入）
+	DataBytes         int64 // 记录数据压缩前的字节数（不含记录头）
+	WrittenBytes      int64 // 实际写入 WAL 文件的字节数（含记录头）
+}
+
 // WALEntry WAL 条目
 type WALEntry struct {
 	Type  byte   // 操作类型
@@ -214,7 +231,7 @@ func (r *WALReader) readEntry() (*WALEntry, error) {
 		return nil, err
 	}
 
-	// 验证 CRC32
+	// 验证 CRC32（覆盖写入磁盘的数据，即压缩后的数据）
 	crcData := make([]byte, WALEntryHeaderSize-4+int(dataLen))
 	copy(crcData[0:WALEntryHeaderSize-4], header[4:])
 	copy(crcData[WALEntryHeaderSize-4:], data)
@@ -223,6 +240,15 @@ func (r *WALReader) readEntry() (*WALEntry, error) {
 		return nil, io.ErrUnexpectedEOF // CRC 校验失败
 	}
 
+	// 解压
+	if entryType&WALEntryFlagCompressed != 0 {
+		entryType &^= WALEntryFlagCompressed
+		data, err = snappyDecode(data)
+		if err != nil {
+			return nil, NewError(ErrCodeWALCorrupted, err)
+		}
+	}
+
 	return &WALEntry{
 		Type:  entryType,
 		Seq:   seq,
@@ -236,6 +262,9 @@ type WALManager struct {
 	dir           string
 	currentWAL    *WAL
 	currentNumber int64
+	compression   bool // 使用 Snappy 压缩记录数据
+	stats         WALStats
+	faults        *FaultInjector // 故障注入（仅测试）
 	mu            sync.Mutex
 }
 
@@ -280,7 +309,56 @@ func (m *WALManager) Append(entry *WALEntry) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.currentWAL.Append(entry)
+	if err := m.faults.check(FaultWALAppend); err != nil {
+		return err
+	}
+
+	record := entry
+	if m.compression && len(entry.Data) >= walCompressMinSize {
+		if compressed := snappyEncode(entry.Data); len(compressed) < len(entry.Data) {
+			record = &WALEntry{
+				Type: entry.Type | WALEntryFlagCompressed,
+				Seq:  entry.Seq,
+				Data: compressed,
+			}
+		}
+	}
+
+	if err := m.currentWAL.Append(record); err != nil {
+		return err
+	}
+
+	m.stats.Records++
+	if record != entry {
+		m.stats.CompressedRecords++
+	}
+	m.stats.DataBytes += int64(len(entry.Data))
+	m.stats.WrittenBytes += int64(WALEntryHeaderSize + len(record.Data))
+	return nil
+}
+
+// SetCompression 设置之后写入的记录是否使用 Snappy 压缩（已写入的记录不受影响）
+func (m *WALManager) SetCompression(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.compression = enabled
+}
+
+// SetFaultInjector 设置故障注入器（仅测试）
+func (m *WALManager) SetFaultInjector(faults *FaultInjector) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.faults = faults
+}
+
+// Stats 获取写入统计
+func (m *WALManager) Stats() WALStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stats
 }
 
 // Sync 同步当前 WAL 到磁盘
@@ -288,6 +366,9 @@ func (m *WALManager) Sync() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if err := m.faults.check(FaultWALSync); err != nil {
+		return err
+	}
 	return m.currentWAL.Sync()
 }
 
@@ -299,8 +380,15 @@ func (m *WALManager) Rotate() (int64, error) {
 	// 记录旧的 WAL 编号
 	oldNumber := m.currentNumber
 
-	// 关闭当前 WAL
-	err := m.currentWAL.Close()
+	// 同步并关闭当前 WAL
+	err := m.faults.check(FaultWALSync)
+	if err == nil {
+		err = m.currentWAL.Sync()
+	}
+	if err != nil {
+		return 0, err
+	}
+	err = m.currentWAL.Close()
 	if err != nil {
 		return 0, err
 	}
@@ -391,6 +479,26 @@ func (m *WALManager) ListWALFiles() ([]string, error) {
 	return files, nil
 }
 
+// PendingSize 返回磁盘上所有 WAL 文件（即尚未 flush、重启时需要回放的数据）的总字节数和文件数
+func (m *WALManager) PendingSize() (int64, int, error) {
+	files, err := m.ListWALFiles()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var total int64
+	count := 0
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue // 文件可能刚被删除
+		}
+		total += info.Size()
+		count++
+	}
+	return total, count, nil
+}
+
 // Close 关闭 WAL 管理器
 func (m *WALManager) Close() error {
 	m.mu.Lock()
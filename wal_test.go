@@ -1,8 +1,12 @@
 package srdb
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestWAL(t *testing.T) {
@@ -129,3 +133,204 @@ func BenchmarkWALAppend(b *testing.B) {
 		wal.Append(entry)
 	}
 }
+
+func TestWALCompression(t *testing.T) {
+	dir := t.TempDir()
+
+	mgr, err := NewWALManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	large := []byte(strings.Repeat("payload-", 1000))
+	small := []byte("tiny")
+	write := func(seq int64, data []byte) {
+		t.Helper()
+		if err := mgr.Append(&WALEntry{Type: WALEntryTypePut, Seq: seq, Data: data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 未开启压缩的记录与压缩记录混合在同一个文件中
+	write(1, large)
+	mgr.SetCompression(true)
+	write(2, large)
+	write(3, small)
+	mgr.SetCompression(false)
+	write(4, large)
+
+	stats := mgr.Stats()
+	if stats.Records != 4 || stats.CompressedRecords != 1 {
+		t.Errorf("unexpected record counts %+v", stats)
+	}
+	if stats.DataBytes != int64(3*len(large)+len(small)) {
+		t.Errorf("unexpected data bytes %+v", stats)
+	}
+	if saved := stats.DataBytes + 4*WALEntryHeaderSize - stats.WrittenBytes; saved < int64(len(large))/2 {
+		t.Errorf("expected compression to save space, got %+v", stats)
+	}
+	pending, _, err := mgr.PendingSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != stats.WrittenBytes {
+		t.Errorf("expected %d bytes on disk, got %d", stats.WrittenBytes, pending)
+	}
+	mgr.Close()
+
+	entries, err := mgr.RecoverAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		expected := large
+		if i == 2 {
+			expected = small
+		}
+		if entry.Type != WALEntryTypePut || !bytes.Equal(entry.Data, expected) {
+			t.Errorf("entry %d: unexpected type %d or data length %d", i, entry.Type, len(entry.Data))
+		}
+	}
+}
+
+func TestTableWALCompression(t *testing.T) {
+	dir := t.TempDir()
+	opts := &TableOptions{
+		Dir:            dir,
+		Name:           "events",
+		Fields:         []Field{{Name: "body", Type: String}},
+		WALCompression: true,
+	}
+	table, err := OpenTable(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := strings.Repeat(`{"level":"info","msg":"request served"}`, 200)
+	for range 10 {
+		if err := table.Insert(map[string]any{"body": body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := table.WALStats()
+	if stats.Records != 10 || stats.CompressedRecords != 10 || stats.WrittenBytes*10 > stats.DataBytes {
+		t.Errorf("unexpected WAL stats %+v", stats)
+	}
+
+	// 模拟崩溃：不 flush，直接从 WAL 恢复
+	table.compactionManager.Stop()
+	table.walManager.Close()
+	table.versionSet.Close()
+	table.sstManager.Close()
+
+	table, err = OpenTable(&TableOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		if rows.Row().Data()["body"] != body {
+			t.Fatal("unexpected body after recovery")
+		}
+		n++
+	}
+	if n != 10 {
+		t.Errorf("expected 10 recovered rows, got %d", n)
+	}
+}
+
+func TestCollectFlushedWALs(t *testing.T) {
+	dir := t.TempDir()
+	table, err := OpenTable(&TableOptions{
+		Dir:    dir,
+		Name:   "events",
+		Fields: []Field{{Name: "n", Type: Int64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟崩溃时 WAL 1 已轮转但尚未 flush
+	for i := range 5 {
+		if err := table.Insert(map[string]any{"n": int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := table.walManager.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if err := table.Insert(map[string]any{"n": int64(5 + i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	table.compactionManager.Stop()
+	table.walManager.Close()
+	table.versionSet.Close()
+	table.sstManager.Close()
+
+	// 重启后 WAL 1 回放到 Active MemTable，数据未 flush 前不能删除
+	table, err = OpenTable(&TableOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stalePath := filepath.Join(dir, "wal", "000001.wal")
+	if n, _ := table.collectFlushedWALs(0); n != 0 {
+		t.Fatalf("deleted %d WALs with unflushed data", n)
+	}
+
+	// flush 只删除当前 WAL，WAL 1 留在磁盘上
+	if err := table.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for table.memtableManager.GetImmutableCount() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(stalePath); err != nil {
+		t.Fatalf("expected stale WAL to remain after flush: %v", err)
+	}
+	if n, _ := table.collectFlushedWALs(time.Hour); n != 0 {
+		t.Fatalf("deleted %d WALs younger than min age", n)
+	}
+
+	// GC 循环删除已 flush 的 WAL 并记录统计
+	table.compactionManager.configMu.Lock()
+	table.compactionManager.gcFileMinAge = 0
+	table.compactionManager.configMu.Unlock()
+	table.compactionManager.CleanupOrphanFiles()
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Fatalf("expected stale WAL to be deleted, got %v", err)
+	}
+	stats := table.compactionManager.GetStats()
+	if stats.OrphanWALsDeleted != 1 || stats.OrphanWALBytes == 0 || stats.LastGCTime.IsZero() {
+		t.Errorf("unexpected GC stats %+v", stats)
+	}
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err = OpenTable(&TableOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if n := rows.Count(); n != 8 {
+		t.Errorf("expected 8 rows after reopen, got %d", n)
+	}
+}
@@ -19,9 +19,10 @@
 
 // WebUI Web 界面处理器 v2 (Preact)
 type WebUI struct {
-	db       *srdb.Database
-	basePath string
-	handler  http.Handler
+	db         *srdb.Database
+	basePath   string
+	handler    http.Handler
+	privileged bool // 是否返回敏感字段的原始值
 }
 
 // NewWebUI 创建 WebUI v2 实例
@@ -55,6 +56,21 @@ func (ui *WebUI) setupHandler() http.Handler {
 	return mux
 }
 
+// SetPrivileged 设置是否返回敏感字段（Schema 中标记为 sensitive）的原始值
+// 默认 false：数据接口以 srdb.RedactedValue 代替敏感字段的值，避免通过管理界面泄露。
+// 仅应在已有鉴权、且调用者确认有权查看敏感数据时开启；需要在开始处理请求前调用
+func (ui *WebUI) SetPrivileged(privileged bool) *WebUI {
+	ui.privileged = privileged
+	return ui
+}
+
+// redact 非特权模式下对行数据中的敏感字段脱敏
+func (ui *WebUI) redact(schema *srdb.Schema, data map[string]any) {
+	if !ui.privileged {
+		schema.Redact(data)
+	}
+}
+
 // path 返回带 basePath 前缀的路径
 func (ui *WebUI) path(p string) string {
 	if ui.basePath == "" {
@@ -85,10 +101,12 @@ func (ui *WebUI) handleListTables(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type FieldInfo struct {
-		Name    string `json:"name"`
-		Type    string `json:"type"`
-		Indexed bool   `json:"indexed"`
-		Comment string `json:"comment"`
+		Name       string `json:"name"`
+		Type       string `json:"type"`
+		Indexed    bool   `json:"indexed"`
+		Comment    string `json:"comment"`
+		Sensitive  bool   `json:"sensitive,omitempty"`
+		Deprecated bool   `json:"deprecated,omitempty"`
 	}
 
 	type TableListItem struct {
@@ -105,10 +123,12 @@ type TableListItem struct {
 		fields := make([]FieldInfo, 0, len(schema.Fields))
 		for _, field := range schema.Fields {
 			fields = append(fields, FieldInfo{
-				Name:    field.Name,
-				Type:    field.Type.String(),
-				Indexed: field.Indexed,
-				Comment: field.Comment,
+				Name:       field.Name,
+				Type:       field.Type.String(),
+				Indexed:    field.Indexed,
+				Comment:    field.Comment,
+				Sensitive:  field.Sensitive,
+				Deprecated: field.Deprecated,
 			})
 		}
 
@@ -187,19 +207,23 @@ func (ui *WebUI) handleTableSchema(w http.ResponseWriter, r *http.Request, table
 	schema := table.GetSchema()
 
 	type FieldInfo struct {
-		Name    string `json:"name"`
-		Type    string `json:"type"`
-		Indexed bool   `json:"indexed"`
-		Comment string `json:"comment"`
+		Name       string `json:"name"`
+		Type       string `json:"type"`
+		Indexed    bool   `json:"indexed"`
+		Comment    string `json:"comment"`
+		Sensitive  bool   `json:"sensitive,omitempty"`
+		Deprecated bool   `json:"deprecated,omitempty"`
 	}
 
 	fields := make([]FieldInfo, 0, len(schema.Fields))
 	for _, field := range schema.Fields {
 		fields = append(fields, FieldInfo{
-			Name:    field.Name,
-			Type:    field.Type.String(),
-			Indexed: field.Indexed,
-			Comment: field.Comment,
+			Name:       field.Name,
+			Type:       field.Type.String(),
+			Indexed:    field.Indexed,
+			Comment:    field.Comment,
+			Sensitive:  field.Sensitive,
+			Deprecated: field.Deprecated,
 		})
 	}
 
@@ -326,11 +350,12 @@ func (ui *WebUI) handleTableDataBySeq(w http.ResponseWriter, r *http.Request, ta
 		return
 	}
 
-	// 构造响应（不进行剪裁，返回完整数据）
+	// 构造响应（不进行剪裁，返回完整数据，敏感字段除外）
 	rowData := make(map[string]any)
 	rowData["_seq"] = row.Seq
 	rowData["_time"] = row.Time
 	maps.Copy(rowData, row.Data)
+	ui.redact(table.GetSchema(), rowData)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(rowData)
@@ -433,13 +458,14 @@ func (ui *WebUI) handleTableData(w http.ResponseWriter, r *http.Request, tableNa
 			continue
 		}
 
-		row := queryRows.Row()
+		rowValues := queryRows.Row().Data()
+		ui.redact(tableSchema, rowValues)
 		rowData := make(map[string]any)
-		rowData["_seq"] = row.Data()["_seq"]
-		rowData["_time"] = row.Data()["_time"]
+		rowData["_seq"] = rowValues["_seq"]
+		rowData["_time"] = rowValues["_time"]
 
 		// 遍历所有字段并进行字符串截断
-		for k, v := range row.Data() {
+		for k, v := range rowValues {
 			if k == "_seq" || k == "_time" {
 				continue
 			}
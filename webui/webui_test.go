@@ -0,0 +1,89 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hupeh/srdb"
+)
+
+// TestWebUIRedactsSensitiveFields 测试数据接口默认对敏感字段脱敏
+func TestWebUIRedactsSensitiveFields(t *testing.T) {
+	db, err := srdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema, err := srdb.NewSchema("accounts", []srdb.Field{
+		{Name: "name", Type: srdb.String},
+		{Name: "password", Type: srdb.String, Sensitive: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := db.CreateTable("accounts", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(map[string]any{"name": "alice", "password": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(ui *WebUI, path string, v any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		ui.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != 200 {
+			t.Fatalf("GET %s: status %d: %s", path, rec.Code, rec.Body)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ui := NewWebUI(db)
+
+	var page struct {
+		Data []map[string]any `json:"data"`
+	}
+	get(ui, "/api/tables/accounts/data", &page)
+	if len(page.Data) != 1 || page.Data[0]["password"] != srdb.RedactedValue || page.Data[0]["name"] != "alice" {
+		t.Errorf("unexpected page data: %v", page.Data)
+	}
+
+	// 显式选择敏感字段同样脱敏
+	get(ui, "/api/tables/accounts/data?select=password", &page)
+	if len(page.Data) != 1 || page.Data[0]["password"] != srdb.RedactedValue {
+		t.Errorf("unexpected selected data: %v", page.Data)
+	}
+
+	var row map[string]any
+	get(ui, "/api/tables/accounts/data/1", &row)
+	if row["password"] != srdb.RedactedValue {
+		t.Errorf("unexpected row: %v", row)
+	}
+
+	var info struct {
+		Fields []struct {
+			Name      string `json:"name"`
+			Sensitive bool   `json:"sensitive"`
+		} `json:"fields"`
+	}
+	get(ui, "/api/tables/accounts/schema", &info)
+	if len(info.Fields) != 2 || info.Fields[0].Sensitive || !info.Fields[1].Sensitive {
+		t.Errorf("unexpected schema fields: %+v", info.Fields)
+	}
+
+	// 特权模式返回原始值
+	privileged := NewWebUI(db).SetPrivileged(true)
+	get(privileged, "/api/tables/accounts/data/1", &row)
+	if row["password"] != "secret" {
+		t.Errorf("privileged row should not be redacted: %v", row)
+	}
+	get(privileged, "/api/tables/accounts/data", &page)
+	if page.Data[0]["password"] != "secret" {
+		t.Errorf("privileged page should not be redacted: %v", page.Data)
+	}
+}
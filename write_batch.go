@@ -0,0 +1,197 @@
+package srdb
+
+import (
+	"context"
+	"sync"
+)
+
+// WriteBatch 跨表的批量写入
+//
+// 通过 Database.NewBatch 创建，Insert 只记录待写入的数据，Commit 时统一执行：
+//  1. 每张表只查找一次（只获取一次数据库锁）
+//  2. 转换并验证所有行，任意一行验证失败时不写入任何数据
+//  3. 按表写入，每张表的行作为一个整体经过 MaxConcurrentWriters 限制
+//
+// 验证之后的写入失败（例如 WAL 写入错误）不会回滚已写入的行，批量写入不提供跨表原子性；
+// 需要原子性时使用 Database.Batch 创建。WriteBatch 不是并发安全的。
+type WriteBatch struct {
+	db      *Database
+	entries []writeBatchEntry
+	atomic  bool // 由 Database.Batch 创建，跨表原子提交
+}
+
+// writeBatchEntry 一次 Insert 调用记录的数据
+type writeBatchEntry struct {
+	table string
+	data  any
+}
+
+// writeBatchTable 一张表在批量写入中的待写入行
+type writeBatchTable struct {
+	name     string
+	table    *Table
+	rows     []map[string]any // 原始行（写入者限制按行数合并排队的请求）
+	prepared []preparedRow
+}
+
+// preparedRow 已经过 prepareRow 转换与验证的行
+type preparedRow struct {
+	schema    *Schema // prepareRow 使用的 Schema，编码时沿用
+	converted map[string]any
+	indexed   map[string]any
+	now       int64
+}
+
+// NewBatch 创建跨表的批量写入
+func (db *Database) NewBatch() *WriteBatch {
+	return &WriteBatch{db: db}
+}
+
+// Insert 记录待写入 table 的数据（支持的类型同 Table.Insert），在 Commit 时写入
+func (b *WriteBatch) Insert(table string, data any) {
+	b.entries = append(b.entries, writeBatchEntry{table: table, data: data})
+}
+
+// Len 返回已记录的 Insert 次数
+func (b *WriteBatch) Len() int {
+	return len(b.entries)
+}
+
+// Reset 清空已记录的数据，批量写入可以重复使用
+func (b *WriteBatch) Reset() {
+	clear(b.entries)
+	b.entries = b.entries[:0]
+}
+
+// Commit 验证并写入所有记录的数据，成功后清空批量写入
+//
+// 与 Table.Insert 相同，数据写入 WAL 与 MemTable 后立即返回，不等待 fsync。
+func (b *WriteBatch) Commit() error {
+	_, err := b.commit()
+	return err
+}
+
+// CommitDurable 写入所有记录的数据并等待它们持久化
+//
+// 涉及的每张表各自 fsync WAL，这些 fsync 并发执行，所有表都持久化后返回；
+// 返回错误时数据可能已经写入但尚未确认持久化。
+func (b *WriteBatch) CommitDurable(ctx context.Context) error {
+	tokens, err := b.commit()
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(tokens))
+	var wg sync.WaitGroup
+	for i, tok := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = tok.Wait(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commit 写入所有记录的数据，返回每张表的持久化凭证
+func (b *WriteBatch) commit() ([]DurabilityToken, error) {
+	tables, err := b.prepare()
+	if err != nil {
+		return nil, err
+	}
+	if b.atomic {
+		tokens, err := b.commitAtomic(tables)
+		if err != nil {
+			return nil, err
+		}
+		b.Reset()
+		return tokens, nil
+	}
+
+	tokens := make([]DurabilityToken, 0, len(tables))
+	for _, wt := range tables {
+		if len(wt.rows) == 0 {
+			continue
+		}
+		t := wt.table
+		t.durability.mu.Lock()
+		epoch := t.durability.epoch
+		t.durability.mu.Unlock()
+
+		seq, err := t.writers.do(wt.rows, func([]map[string]any) (int64, error) {
+			return t.writePrepared(wt.prepared)
+		})
+		if err != nil {
+			return nil, WrapError(err, "write batch to table %s", wt.name)
+		}
+		tokens = append(tokens, DurabilityToken{Seq: seq, table: t, epoch: epoch})
+	}
+
+	b.Reset()
+	return tokens, nil
+}
+
+// prepare 查找涉及的表，转换并验证所有行（按第一次 Insert 的顺序返回各表）
+func (b *WriteBatch) prepare() ([]*writeBatchTable, error) {
+	byName := make(map[string]*writeBatchTable)
+	var tables []*writeBatchTable
+
+	b.db.mu.RLock()
+	for _, entry := range b.entries {
+		if _, ok := byName[entry.table]; ok {
+			continue
+		}
+		table, exists := b.db.tables[entry.table]
+		if !exists {
+			b.db.mu.RUnlock()
+			return nil, NewErrorf(ErrCodeTableNotFound, "table %s not found", entry.table)
+		}
+		wt := &writeBatchTable{name: entry.table, table: table}
+		byName[entry.table] = wt
+		tables = append(tables, wt)
+	}
+	b.db.mu.RUnlock()
+
+	for i, entry := range b.entries {
+		wt := byName[entry.table]
+		rows, err := wt.table.normalizeInsertData(entry.data)
+		if err != nil {
+			return nil, NewErrorf(ErrCodeInvalidParam, "batch insert %d (table %s): %v", i, entry.table, err.Error())
+		}
+		wt.rows = append(wt.rows, rows...)
+	}
+
+	for _, wt := range tables {
+		t := wt.table
+		schema := t.schema.Load()
+		wt.prepared = make([]preparedRow, 0, len(wt.rows))
+		for i, data := range wt.rows {
+			now := t.clock.Now().UnixNano()
+			converted, indexed, err := t.prepareRow(schema, data, now)
+			if err != nil {
+				return nil, WrapError(err, "table %s row %d", wt.name, i)
+			}
+			wt.prepared = append(wt.prepared, preparedRow{schema: schema, converted: converted, indexed: indexed, now: now})
+		}
+	}
+	return tables, nil
+}
+
+// writePrepared 逐条写入已验证的行，返回最后一条数据的 seq
+func (t *Table) writePrepared(rows []preparedRow) (int64, error) {
+	var seq int64
+	for _, row := range rows {
+		var err error
+		if seq, err = t.writeRow(row.schema, row.converted, row.indexed, row.now); err != nil {
+			return 0, err
+		}
+	}
+	return seq, nil
+}
@@ -0,0 +1,109 @@
+package srdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWriteBatch(t *testing.T) {
+	db, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	users, err := NewSchema("users", []Field{
+		{Name: "name", Type: String},
+		{Name: "age", Type: Int64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := NewSchema("orders", []Field{
+		{Name: "user", Type: String},
+		{Name: "amount", Type: Float64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usersTable, err := db.CreateTable("users", users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ordersTable, err := db.CreateTable("orders", orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Order struct {
+		User   string  `srdb:"user"`
+		Amount float64 `srdb:"amount"`
+	}
+
+	b := db.NewBatch()
+	b.Insert("users", []map[string]any{{"name": "alice", "age": 30}, {"name": "bob", "age": 25}})
+	b.Insert("orders", []Order{{User: "alice", Amount: 9.5}, {User: "bob", Amount: 3}})
+	b.Insert("users", map[string]any{"name": "carol", "age": 41})
+	if b.Len() != 3 {
+		t.Errorf("expected 3 entries, got %d", b.Len())
+	}
+	if err := b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("expected batch to be reset after commit, got %d entries", b.Len())
+	}
+
+	rows, err := usersTable.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rows.Count(); n != 3 {
+		t.Errorf("expected 3 users, got %d", n)
+	}
+	rows, err = ordersTable.Query().Eq("user", "alice").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rows.Count(); n != 1 {
+		t.Errorf("expected 1 order of alice, got %d", n)
+	}
+
+	// 任意一行验证失败时不写入任何数据
+	b.Insert("users", map[string]any{"name": "dave", "age": 50})
+	b.Insert("orders", map[string]any{"user": "dave", "amount": "not a number"})
+	if err := b.Commit(); !IsError(err, ErrCodeSchemaValidationFailed) {
+		t.Fatalf("expected schema validation error, got %v", err)
+	}
+	if seq := usersTable.seq.Load(); seq != 3 {
+		t.Errorf("expected no rows written after failed validation, users seq is %d", seq)
+	}
+
+	// 表不存在
+	b.Reset()
+	b.Insert("missing", map[string]any{"name": "x"})
+	if err := b.Commit(); !IsError(err, ErrCodeTableNotFound) {
+		t.Fatalf("expected table not found, got %v", err)
+	}
+
+	// 等待所有表持久化
+	b.Reset()
+	b.Insert("users", map[string]any{"name": "erin", "age": 22})
+	b.Insert("orders", map[string]any{"user": "erin", "amount": 1.25})
+	if err := b.CommitDurable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []*Table{usersTable, ordersTable} {
+		table.durability.mu.Lock()
+		durable := table.durability.durable
+		table.durability.mu.Unlock()
+		if durable != table.seq.Load() {
+			t.Errorf("table %s: expected durable %d, got %d", table.GetName(), table.seq.Load(), durable)
+		}
+	}
+
+	// 空批量写入
+	if err := db.NewBatch().Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,116 @@
+package srdb
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// 写入者并发限制
+//
+// 大量 goroutine 同时向一张表插入时，它们在 WAL、MemTable 与索引的锁上反复争抢（锁护航），
+// 所有写入者的延迟都会变差。设置 MaxConcurrentWriters 后，同时执行插入的 goroutine 不超过该值，
+// 超出的写入者按到达顺序排队；释放名额时，名额交给队首的写入者，
+// 它除了写入自己的行，还顺带写入紧随其后的一组排队请求（每个请求的结果单独返回），
+// 排队的写入者因此不需要逐个被唤醒再争抢锁。
+
+// writerGroupMaxRows 一个写入者顺带写入的排队请求的总行数上限
+const writerGroupMaxRows = 1024
+
+// writeRequest 一个排队的插入请求
+type writeRequest struct {
+	rows  []map[string]any
+	write func([]map[string]any) (int64, error) // 由领导者代为写入时调用（WriteBatch 写入预先验证的行）
+	group []*writeRequest                       // 被选为领导者时需要顺带写入的请求
+	lead  bool                                  // 获得名额，需要自己执行写入
+	seq   int64
+	err   error
+	done  chan struct{}
+}
+
+// writerGate 限制同时执行插入的写入者数量，nil 表示不限制
+type writerGate struct {
+	mu     sync.Mutex
+	limit  int
+	active int
+	queue  []*writeRequest
+}
+
+// newWriterGate 创建写入者限制，limit <= 0 时返回 nil（不限制）
+func newWriterGate(limit int) *writerGate {
+	if limit <= 0 {
+		return nil
+	}
+	return &writerGate{limit: limit}
+}
+
+// do 在名额内执行 write，返回 write 的结果
+func (g *writerGate) do(rows []map[string]any, write func([]map[string]any) (int64, error)) (int64, error) {
+	return g.doContext(context.Background(), rows, write)
+}
+
+// doContext 与 do 相同，排队期间 ctx 取消时放弃排队并返回 ctx.Err()（已被领导者选中的请求照常写入）
+func (g *writerGate) doContext(ctx context.Context, rows []map[string]any, write func([]map[string]any) (int64, error)) (int64, error) {
+	if g == nil {
+		return write(rows)
+	}
+
+	g.mu.Lock()
+	if g.active < g.limit && len(g.queue) == 0 {
+		g.active++
+		g.mu.Unlock()
+		seq, err := write(rows)
+		g.release()
+		return seq, err
+	}
+	req := &writeRequest{rows: rows, write: write, done: make(chan struct{})}
+	g.queue = append(g.queue, req)
+	g.mu.Unlock()
+
+	select {
+	case <-req.done:
+	case <-ctx.Done():
+		g.mu.Lock()
+		if i := slices.Index(g.queue, req); i >= 0 {
+			g.queue = slices.Delete(g.queue, i, i+1)
+			g.mu.Unlock()
+			return 0, ctx.Err()
+		}
+		g.mu.Unlock()
+		<-req.done
+	}
+	if !req.lead {
+		// 已由领导者写入
+		return req.seq, req.err
+	}
+
+	seq, err := write(req.rows)
+	for _, follower := range req.group {
+		follower.seq, follower.err = follower.write(follower.rows)
+		close(follower.done)
+	}
+	g.release()
+	return seq, err
+}
+
+// release 释放名额：有排队的请求时把名额连同一组请求交给队首，否则归还名额
+func (g *writerGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.queue) == 0 {
+		g.active--
+		return
+	}
+
+	leader := g.queue[0]
+	n, rows := 1, 0
+	for n < len(g.queue) && rows+len(g.queue[n].rows) <= writerGroupMaxRows {
+		rows += len(g.queue[n].rows)
+		n++
+	}
+	leader.lead = true
+	leader.group = g.queue[1:n:n]
+	g.queue = g.queue[n:]
+	close(leader.done)
+}
@@ -0,0 +1,108 @@
+package srdb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWriterGate(t *testing.T) {
+	gate := newWriterGate(2)
+
+	var active, peak atomic.Int32
+	var written atomic.Int64
+	errBad := errors.New("bad row")
+	write := func(rows []map[string]any) (int64, error) {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+		if rows[0]["bad"] == true {
+			return 0, errBad
+		}
+		return written.Add(int64(len(rows))), nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 64)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = gate.do([]map[string]any{{"bad": i%8 == 0}}, write)
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 concurrent writers, got %d", peak.Load())
+	}
+	// 每个请求单独返回自己的结果，合并写入不影响其他请求
+	for i, err := range errs {
+		if (i%8 == 0) != errors.Is(err, errBad) {
+			t.Errorf("request %d: unexpected error %v", i, err)
+		}
+	}
+	if written.Load() != 56 {
+		t.Errorf("expected 56 rows written, got %d", written.Load())
+	}
+	if gate.active != 0 || len(gate.queue) != 0 {
+		t.Errorf("expected gate to be idle, got active=%d queued=%d", gate.active, len(gate.queue))
+	}
+
+	// nil 表示不限制
+	if seq, err := newWriterGate(0).do([]map[string]any{{}}, write); err != nil || seq == 0 {
+		t.Errorf("unexpected result from unlimited gate: %d, %v", seq, err)
+	}
+}
+
+func TestTableMaxConcurrentWriters(t *testing.T) {
+	table, err := OpenTable(&TableOptions{
+		Dir:                  t.TempDir(),
+		Name:                 "events",
+		Fields:               []Field{{Name: "name", Type: String}},
+		MaxConcurrentWriters: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				if err := table.Insert(map[string]any{"name": fmt.Sprintf("%d-%d", i, j)}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	rows, err := table.Query().Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rows.Len(); n != 1000 {
+		t.Errorf("expected 1000 rows, got %d", n)
+	}
+	rows.Close()
+
+	opts := DefaultOptions(t.TempDir())
+	opts.MaxConcurrentWriters = -1
+	if err := opts.Validate(); !IsError(err, ErrCodeInvalidParam) {
+		t.Errorf("expected ErrCodeInvalidParam for negative MaxConcurrentWriters, got %v", err)
+	}
+}
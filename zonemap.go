@@ -0,0 +1,319 @@
+package srdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// zoneMapMagic SST 列统计信息块的 Magic Number
+	zoneMapMagic = 0x5A4F4E31 // "ZON1"
+
+	// zoneMapMaxString 字符串列参与统计的最大长度
+	// 超过该长度的值无法安全截断（截断后的最大值偏小），出现时放弃该列的统计
+	zoneMapMaxString = 64
+
+	zoneKindNumber = 1 // 数值列，min/max 按 float64 存储（与查询条件的比较方式一致）
+	zoneKindString = 2 // 字符串列
+)
+
+// zoneRange 一个 SST 文件中某一列非 NULL 值的范围
+type zoneRange struct {
+	kind           uint8
+	minNum, maxNum float64
+	minStr, maxStr string
+}
+
+// zoneMapKind 字段类型对应的统计类型，不支持的类型返回 0
+func zoneMapKind(t FieldType) uint8 {
+	switch t {
+	case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Float32, Float64, Byte, Rune:
+		return zoneKindNumber
+	case String:
+		return zoneKindString
+	}
+	return 0
+}
+
+// zoneMapBuilder 写入 SST 时收集每列的 min/max（zone map）
+type zoneMapBuilder struct {
+	fields  []Field
+	ranges  map[string]*zoneRange
+	dropped map[string]bool
+}
+
+func newZoneMapBuilder(schema *Schema) *zoneMapBuilder {
+	b := &zoneMapBuilder{
+		ranges:  make(map[string]*zoneRange),
+		dropped: make(map[string]bool),
+	}
+	if schema != nil {
+		for _, field := range schema.Fields {
+			if zoneMapKind(field.Type) != 0 {
+				b.fields = append(b.fields, field)
+			}
+		}
+	}
+	return b
+}
+
+// add 累加一行数据
+func (b *zoneMapBuilder) add(data map[string]any) {
+	for _, field := range b.fields {
+		if b.dropped[field.Name] {
+			continue
+		}
+		value := data[field.Name]
+		if value == nil {
+			continue
+		}
+
+		r := b.ranges[field.Name]
+		switch zoneMapKind(field.Type) {
+		case zoneKindNumber:
+			f, ok := toFloat64(value)
+			if !ok || math.IsNaN(f) {
+				continue
+			}
+			if r == nil {
+				b.ranges[field.Name] = &zoneRange{kind: zoneKindNumber, minNum: f, maxNum: f}
+				continue
+			}
+			r.minNum = min(r.minNum, f)
+			r.maxNum = max(r.maxNum, f)
+
+		case zoneKindString:
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if len(s) > zoneMapMaxString {
+				b.dropped[field.Name] = true
+				delete(b.ranges, field.Name)
+				continue
+			}
+			if r == nil {
+				b.ranges[field.Name] = &zoneRange{kind: zoneKindString, minStr: s, maxStr: s}
+				continue
+			}
+			r.minStr = min(r.minStr, s)
+			r.maxStr = max(r.maxStr, s)
+		}
+	}
+}
+
+// encode 编码统计信息块，没有可统计的列时返回 nil
+//
+// 格式：[Magic: 4][Count: 2] + Count × [NameLen: 2][Name][Kind: 1][Min][Max]
+// 数值的 Min/Max 为 float64（8 bytes），字符串为 [Len: 2][Bytes]
+func (b *zoneMapBuilder) encode() []byte {
+	if len(b.ranges) == 0 {
+		return nil
+	}
+
+	buf := binary.LittleEndian.AppendUint32(nil, zoneMapMagic)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(b.ranges)))
+	for _, field := range b.fields {
+		r, ok := b.ranges[field.Name]
+		if !ok {
+			continue
+		}
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(field.Name)))
+		buf = append(buf, field.Name...)
+		buf = append(buf, r.kind)
+		if r.kind == zoneKindNumber {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(r.minNum))
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(r.maxNum))
+		} else {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(r.minStr)))
+			buf = append(buf, r.minStr...)
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(r.maxStr)))
+			buf = append(buf, r.maxStr...)
+		}
+	}
+	return buf
+}
+
+// decodeZoneMap 解码统计信息块
+func decodeZoneMap(data []byte) (map[string]zoneRange, error) {
+	if len(data) < 6 || binary.LittleEndian.Uint32(data) != zoneMapMagic {
+		return nil, fmt.Errorf("invalid zone map magic")
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	data = data[6:]
+
+	readString := func() (string, error) {
+		if len(data) < 2 {
+			return "", fmt.Errorf("zone map truncated")
+		}
+		n := int(binary.LittleEndian.Uint16(data))
+		if len(data) < 2+n {
+			return "", fmt.Errorf("zone map truncated")
+		}
+		s := string(data[2 : 2+n])
+		data = data[2+n:]
+		return s, nil
+	}
+
+	zones := make(map[string]zoneRange, count)
+	for range count {
+		name, err := readString()
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 1 {
+			return nil, fmt.Errorf("zone map truncated")
+		}
+		r := zoneRange{kind: data[0]}
+		data = data[1:]
+
+		switch r.kind {
+		case zoneKindNumber:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("zone map truncated")
+			}
+			r.minNum = math.Float64frombits(binary.LittleEndian.Uint64(data))
+			r.maxNum = math.Float64frombits(binary.LittleEndian.Uint64(data[8:]))
+			data = data[16:]
+		case zoneKindString:
+			if r.minStr, err = readString(); err != nil {
+				return nil, err
+			}
+			if r.maxStr, err = readString(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown zone map kind %d", r.kind)
+		}
+		zones[name] = r
+	}
+	return zones, nil
+}
+
+// zoneMayMatch 根据列统计判断文件中是否可能存在匹配 expr 的行
+// 无法判断时（没有统计、不支持的操作符、类型不一致）保守地返回 true
+func zoneMayMatch(zones map[string]zoneRange, expr Expr) bool {
+	switch e := expr.(type) {
+	case compare:
+		r, ok := zones[e.field]
+		if !ok {
+			return true
+		}
+		return r.mayMatch(e)
+
+	case group:
+		if e.and {
+			for _, sub := range e.exprs {
+				if !zoneMayMatch(zones, sub) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, sub := range e.exprs {
+			if zoneMayMatch(zones, sub) {
+				return true
+			}
+		}
+		return len(e.exprs) == 0
+	}
+	return true
+}
+
+// mayMatch 判断范围内是否可能存在满足比较条件的值（NULL 不满足这些条件）
+func (r zoneRange) mayMatch(c compare) bool {
+	switch c.op {
+	case "=":
+		return r.contains(c.right, c.right)
+	case "<":
+		return r.below(c.right, false)
+	case "<=":
+		return r.below(c.right, true)
+	case ">":
+		return r.above(c.right, false)
+	case ">=":
+		return r.above(c.right, true)
+	case "BETWEEN":
+		if list, ok := c.right.([]any); ok && len(list) == 2 {
+			return r.contains(list[0], list[1])
+		}
+	case "IN":
+		list, ok := c.right.([]any)
+		if !ok {
+			return true
+		}
+		for _, item := range list {
+			if r.contains(item, item) {
+				return true
+			}
+		}
+		return false
+	case "STARTS WITH":
+		prefix, ok := c.right.(string)
+		if !ok || r.kind != zoneKindString {
+			return true
+		}
+		// 以 prefix 开头的字符串都 >= prefix，且 min 之后第一个以 prefix 开头的值不会超过 max
+		return r.maxStr >= prefix && (r.minStr <= prefix || strings.HasPrefix(r.minStr, prefix))
+	}
+	return true
+}
+
+// contains 范围是否与 [lo, hi] 相交
+func (r zoneRange) contains(lo, hi any) bool {
+	return r.above(lo, true) && r.below(hi, true)
+}
+
+// below 是否可能存在 < v（inclusive 时 <= v）的值
+func (r zoneRange) below(v any, inclusive bool) bool {
+	switch r.kind {
+	case zoneKindNumber:
+		f, ok := toFloat64(v)
+		if !ok {
+			return true
+		}
+		return r.minNum < f || (inclusive && r.minNum == f)
+	case zoneKindString:
+		s, ok := v.(string)
+		if !ok {
+			return true
+		}
+		return r.minStr < s || (inclusive && r.minStr == s)
+	}
+	return true
+}
+
+// above 是否可能存在 > v（inclusive 时 >= v）的值
+func (r zoneRange) above(v any, inclusive bool) bool {
+	switch r.kind {
+	case zoneKindNumber:
+		f, ok := toFloat64(v)
+		if !ok {
+			return true
+		}
+		return r.maxNum > f || (inclusive && r.maxNum == f)
+	case zoneKindString:
+		s, ok := v.(string)
+		if !ok {
+			return true
+		}
+		return r.maxStr > s || (inclusive && r.maxStr == s)
+	}
+	return true
+}
+
+// mayMatch 根据列统计判断文件中是否可能存在满足所有条件的行
+// 旧版本写入的文件没有统计信息，总是返回 true
+func (r *SSTableReader) mayMatch(conds []Expr) bool {
+	if r.zones == nil {
+		return true
+	}
+	for _, cond := range conds {
+		if !zoneMayMatch(r.zones, cond) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package srdb
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestZoneMapFileSkipping(t *testing.T) {
+	dir := t.TempDir()
+
+	table, err := OpenTable(&TableOptions{
+		Dir:  dir,
+		Name: "readings",
+		Fields: []Field{
+			{Name: "sensor", Type: String},
+			{Name: "temperature", Type: Float64, Nullable: true},
+			{Name: "count", Type: Int64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	// 三个 SST 文件，温度范围互不重叠：[0,9] [50,59] [100,109]
+	for file, base := range []float64{0, 50, 100} {
+		for i := range 10 {
+			data := map[string]any{
+				"sensor":      fmt.Sprintf("%c-sensor-%d", 'a'+file, i),
+				"temperature": base + float64(i),
+				"count":       int64(file*10 + i),
+			}
+			if i == 0 {
+				data["temperature"] = nil
+			}
+			if err := table.Insert(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := table.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		for table.memtableManager.GetImmutableCount() > 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	readers := table.sstManager.GetReaders()
+	if len(readers) != 3 {
+		t.Fatalf("expected 3 SST files, got %d", len(readers))
+	}
+
+	tests := []struct {
+		name    string
+		expr    Expr
+		files   int // 可能匹配的文件数
+		matches int
+	}{
+		{"gt", Gt("temperature", 100), 1, 9},
+		{"gte boundary", Gte("temperature", 109), 1, 1},
+		{"lt", Lt("temperature", 2), 1, 1},
+		{"eq miss", Eq("temperature", 30), 0, 0},
+		{"between", Between("temperature", 55, 105), 2, 10},
+		{"in", In("temperature", []any{3, 200}), 1, 1},
+		{"and", And(Gt("temperature", 50), Lt("count", 10)), 0, 0},
+		{"or", Or(Eq("temperature", 5), Eq("temperature", 105)), 2, 2},
+		{"string eq", Eq("sensor", "b-sensor-3"), 1, 1},
+		{"starts with", StartsWith("sensor", "c-"), 1, 10},
+		{"starts with miss", StartsWith("sensor", "d"), 0, 0},
+		{"int column", Gte("count", int64(15)), 2, 15},
+		{"not is conservative", Not(Gt("temperature", 1000)), 3, 30},
+		{"is null is conservative", IsNull("temperature"), 3, 3},
+		{"type mismatch is conservative", Eq("temperature", "hot"), 3, 0},
+		{"unknown field is conservative", Gt("missing", 1), 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := 0
+			for _, reader := range readers {
+				if reader.mayMatch([]Expr{tt.expr}) {
+					files++
+				}
+			}
+			if files != tt.files {
+				t.Errorf("expected %d candidate files, got %d", tt.files, files)
+			}
+
+			rows, err := table.Query().Where(tt.expr).Rows()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			if len(rows.scanning) != tt.files {
+				t.Errorf("expected lazy scan over %d files, got %d", tt.files, len(rows.scanning))
+			}
+			if n := rows.Count(); n != tt.matches {
+				t.Errorf("expected %d rows, got %d", tt.matches, n)
+			}
+
+			// OrderBy 与 BatchRows 使用相同的文件过滤
+			all, err := table.Query().Where(tt.expr).OrderByDesc("_seq").Rows()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := all.Count(); n != tt.matches {
+				t.Errorf("expected %d ordered rows, got %d", tt.matches, n)
+			}
+			batches, err := table.Query().Where(tt.expr).BatchRows([]string{"count"}, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer batches.Close()
+			n := 0
+			for batches.Next() {
+				n += batches.Batch().Len()
+			}
+			if n != tt.matches {
+				t.Errorf("expected %d batched rows, got %d", tt.matches, n)
+			}
+		})
+	}
+
+	// 重新打开后从文件读取统计信息
+	if err := table.Close(); err != nil {
+		t.Fatal(err)
+	}
+	table, err = OpenTable(&TableOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+
+	rows, err := table.Query().Gt("temperature", 100).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if files := len(rows.scanning); files != 1 || rows.Count() != 9 {
+		t.Errorf("expected 9 rows from 1 file after reopen, got %d files", files)
+	}
+}
+
+func TestZoneMapEncoding(t *testing.T) {
+	schema, err := NewSchema("test", []Field{
+		{Name: "n", Type: Int32, Nullable: true},
+		{Name: "s", Type: String},
+		{Name: "long", Type: String},
+		{Name: "b", Type: Bool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := newZoneMapBuilder(schema)
+	b.add(map[string]any{"n": int32(5), "s": "m", "long": "short", "b": true})
+	b.add(map[string]any{"n": nil, "s": "a", "long": strings.Repeat("x", zoneMapMaxString+1), "b": false})
+	b.add(map[string]any{"n": int32(-3), "s": "z", "long": "tiny", "b": true})
+
+	zones, err := decodeZoneMap(b.encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 2 {
+		t.Fatalf("expected zones for n and s only, got %v", zones)
+	}
+	if r := zones["n"]; r.kind != zoneKindNumber || r.minNum != -3 || r.maxNum != 5 {
+		t.Errorf("unexpected range for n: %+v", r)
+	}
+	if r := zones["s"]; r.kind != zoneKindString || r.minStr != "a" || r.maxStr != "z" {
+		t.Errorf("unexpected range for s: %+v", r)
+	}
+
+	// 截断或损坏的数据返回错误
+	data := b.encode()
+	if _, err := decodeZoneMap(data[:len(data)-1]); err == nil {
+		t.Error("expected error for truncated zone map")
+	}
+	if newZoneMapBuilder(nil).encode() != nil {
+		t.Error("expected no zone map without schema")
+	}
+
+	// 没有统计信息的旧文件不跳过
+	var reader SSTableReader
+	if !reader.mayMatch([]Expr{Eq("n", 100)}) {
+		t.Error("reader without zone map must not skip")
+	}
+}